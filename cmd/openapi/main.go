@@ -0,0 +1,48 @@
+// Command openapi writes the OpenAPI 3.1 document and the TypeScript client
+// generated from the swag annotations (run swag init first).
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/docs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/openapi"
+)
+
+func main() {
+	specOut := flag.String("spec", "", "write the OpenAPI 3.1 document to this file")
+	clientOut := flag.String("client", "", "write the TypeScript client to this file")
+	flag.Parse()
+	if *specOut == "" && *clientOut == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := openapi.ConvertV3([]byte(docs.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		log.Fatalf("convert spec: %v", err)
+	}
+	if *specOut != "" {
+		write(*specOut, spec)
+	}
+	if *clientOut != "" {
+		client, err := openapi.TypeScriptClient(spec)
+		if err != nil {
+			log.Fatalf("generate client: %v", err)
+		}
+		write(*clientOut, client)
+	}
+}
+
+func write(path string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s", path)
+}
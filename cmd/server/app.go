@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/backup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/cleanup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/errreport"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/logging"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metadb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/schedule"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/search"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/secrets"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tlsconfig"
+)
+
+// app holds the components that run in the background or serve requests.
+// Everything is built by initializeApp; run starts it and blocks until shutdown.
+type app struct {
+	cfg    *config.Config
+	logger *zerolog.Logger
+
+	secretState     *secretState
+	clients         *storageClients
+	backend         storage.Backend
+	queue           *jobs.Queue
+	statsScanner    *stats.Scanner
+	searchIndex     *search.Index
+	metaDB          *metadb.DB
+	presigned       *presigned.Service
+	resolver        *tenancy.Resolver
+	cleaner         *cleanup.Cleaner
+	cleanupSchedule *schedule.Schedule
+	backups         *backup.Service
+	reporter        errreport.Reporter
+
+	router     *gin.Engine
+	serverTLS  *tlsconfig.Server
+	grpcServer *grpc.Server
+}
+
+// run starts the background work and the servers, then waits for SIGINT or
+// SIGTERM and shuts the servers down
+func (a *app) run() {
+	cfg, logger := a.cfg, a.logger
+
+	// Every component has registered its job kinds by now
+	if err := a.queue.Start(context.Background()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to start job queue")
+	}
+
+	scheduleCtx, stopSchedules := context.WithCancel(context.Background())
+	defer stopSchedules()
+	if a.statsScanner != nil {
+		a.statsScanner.Schedule(scheduleCtx, time.Duration(cfg.StatsScanInterval)*time.Second)
+	}
+	if a.cleanupSchedule != nil {
+		a.cleaner.Schedule(scheduleCtx, a.cleanupSchedule)
+	}
+	if a.backups != nil {
+		a.backups.Schedule(scheduleCtx)
+	}
+	if a.searchIndex != nil && cfg.SearchIndexInterval > 0 {
+		go a.searchIndex.Run(scheduleCtx, time.Duration(cfg.SearchIndexInterval)*time.Second)
+	}
+	if a.metaDB != nil {
+		syncer := metadb.NewSyncer(a.backend, a.metaDB, metadb.SyncOptions{Buckets: cfg.MetadataDBBuckets}, logger)
+		go syncer.Run(scheduleCtx, time.Duration(cfg.MetadataDBReconcileInterval)*time.Second)
+	}
+	if cfg.PresignNotifications {
+		if buckets, err := a.resolver.Buckets(scheduleCtx); err != nil {
+			logger.Error().Err(err).Msg("Failed to list buckets for presigned upload notifications")
+		} else {
+			a.presigned.Listen(scheduleCtx, buckets)
+		}
+	}
+	if cfg.PresignReconcileInterval > 0 {
+		go a.presigned.Run(scheduleCtx, time.Duration(cfg.PresignReconcileInterval)*time.Second)
+	}
+	if a.secretState.source != nil && cfg.SecretsRefreshInterval > 0 {
+		go secrets.Watch(scheduleCtx, a.secretState.source, time.Duration(cfg.SecretsRefreshInterval)*time.Second, a.secretState.values, a.rotateCredentials(scheduleCtx), logger)
+	}
+
+	// Apply a reloaded LOG_LEVEL to every logger
+	cfg.OnReload(func(s config.Settings) error {
+		return logging.SetLevel(s.LogLevel)
+	})
+
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:    ":" + cfg.ServerPort,
+		Handler: a.router,
+	}
+	// Plain HTTP listener for ACME challenges and redirects to HTTPS
+	var redirectSrv *http.Server
+	if a.serverTLS != nil {
+		srv.TLSConfig = a.serverTLS.Config()
+		// SIGHUP also picks up renewed certificate files
+		cfg.OnReload(func(config.Settings) error {
+			return a.serverTLS.Reload()
+		})
+		if cfg.TLSHTTPPort != "" {
+			redirectSrv = &http.Server{
+				Addr:              ":" + cfg.TLSHTTPPort,
+				Handler:           a.serverTLS.HTTPHandler(cfg.ServerPort),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+		}
+	}
+
+	// Start server
+	go func() {
+		var err error
+		if a.serverTLS != nil {
+			logger.Info().Msgf("Starting HTTPS server on port %s", cfg.ServerPort)
+			// Certificates come from TLSConfig; HTTP/2 is negotiated over ALPN
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			logger.Info().Msgf("Starting server on port %s", cfg.ServerPort)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Fatal().Err(err).Msg("Server failed to start")
+		}
+	}()
+	if redirectSrv != nil {
+		go func() {
+			logger.Info().Msgf("Starting HTTP redirect server on port %s", cfg.TLSHTTPPort)
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal().Err(err).Msg("HTTP redirect server failed to start")
+			}
+		}()
+	}
+
+	// gRPC storage service on its own port, for internal services
+	if a.grpcServer != nil {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("gRPC server failed to listen")
+		}
+		go func() {
+			logger.Info().Msgf("Starting gRPC server on port %s", cfg.GRPCPort)
+			if err := a.grpcServer.Serve(listener); err != nil {
+				logger.Fatal().Err(err).Msg("gRPC server failed")
+			}
+		}()
+	}
+
+	// SIGHUP reloads the non-structural settings without dropping connections
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := cfg.Reload(); err != nil {
+				logger.Error().Err(err).Msg("Configuration reload failed")
+				continue
+			}
+			logger.Info().Msg("Configuration reloaded")
+		}
+	}()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.Info().Msg("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Fatal().Err(err).Msg("Server forced to shutdown")
+	}
+	if redirectSrv != nil {
+		_ = redirectSrv.Shutdown(ctx)
+	}
+	if a.grpcServer != nil {
+		// Let in-flight calls finish, but not past the shutdown deadline
+		stopped := make(chan struct{})
+		go func() {
+			a.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			a.grpcServer.Stop()
+		}
+	}
+	if err := a.reporter.Close(ctx); err != nil {
+		logger.Warn().Err(err).Msg("Error reports not delivered before shutdown")
+	}
+}
+
+// rotateCredentials rebuilds the storage clients when the credentials secret
+// is rotated
+func (a *app) rotateCredentials(ctx context.Context) func(map[string]string) error {
+	return func(values map[string]string) error {
+		if err := a.cfg.ApplySecrets(values); err != nil {
+			return err
+		}
+		// storage.New checks the new credentials against the bucket before they are used
+		primary, err := storage.New(ctx, a.cfg)
+		if err != nil {
+			return err
+		}
+		if a.clients.rotatingSecondary != nil {
+			secondary, err := storage.Open(a.cfg.ReplicationProvider, a.cfg)
+			if err != nil {
+				return err
+			}
+			a.clients.rotatingSecondary.Swap(secondary)
+		}
+		a.clients.rotatingPrimary.Swap(primary)
+		return nil
+	}
+}
@@ -1,26 +1,19 @@
 package main
 
 import (
-	"context"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 	_ "github.com/muhammad-junaid-iftikhar/app-minio-api/docs" // Import generated docs
 	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
-	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/routes"
-	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/logging"
 )
 
 // @title           Minio Go API
 // @version         1.0
-// @description     A RESTful API for Minio Go application
+// @description     A RESTful API for Minio Go application. /api/v2 serves the same operations with responses wrapped as {"data", "meta"}; listings carry their next page in meta.page.next_cursor, passed back as ?cursor=, instead of the X-Continuation-Token header.
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -48,93 +41,31 @@ func main() {
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to load configuration")
 	}
-
-	// Initialize MinIO client
-	minioClient, err := config.InitMinioClient(cfg)
+	// Reconfigure the logger now the LOG_* settings are known
+	configured, err := logging.New(os.Stdout, logging.Options{
+		Format:     cfg.LogFormat,
+		Level:      cfg.LogLevel,
+		RedactKeys: cfg.LogRedactKeys,
+		// Chunk, presign and progress lines flood the logs at debug level
+		DebugSampleRate: cfg.LogDebugSampleRate,
+	})
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to initialize MinIO client")
+		logger.Fatal().Err(err).Msg("Invalid logging configuration")
 	}
+	logger = configured
 
-	// Set up Gin router
-	router := gin.New()
-	router.Use(gin.Recovery())
-	// Ensure every request has a correlation ID
-	router.Use(utils.CorrelationIDMiddleware())
-	router.Use(utils.LoggerMiddleware(&logger))
-
-	// CORS middleware
-	router.Use(func(c *gin.Context) {
-		// List of allowed origins
-		allowedOrigins := []string{
-			"http://localhost:3000",
-			"https://drive-two.junistudio.org",
-		}
-
-		origin := c.Request.Header.Get("Origin")
-		allowed := false
-
-		// Check if the origin is in the allowed list
-		for _, o := range allowedOrigins {
-			if o == origin {
-				allowed = true
-				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-				break
-			}
-		}
-
-		// If origin is not in the allowed list, use the first one as default
-		if !allowed && len(allowedOrigins) > 0 {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigins[0])
-		}
-
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With, X-Request-Id, X-Correlation-Id")
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
-			c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, X-Request-Id, X-Correlation-Id")
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-
-		// For actual requests
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, X-Request-Id, X-Correlation-Id")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		c.Next()
-	})
-
-	// Initialize routes
-	routes.SetupRoutes(router, minioClient, &logger, cfg)
-
-	// Swagger documentation endpoint
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:    ":" + cfg.ServerPort,
-		Handler: router,
+	// Register additional extension to mime type mappings
+	if err := filetype.Default.RegisterMappings(cfg.UploadMimeTypes); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid UPLOAD_MIME_TYPES configuration")
 	}
 
-	// Start server
-	go func() {
-		logger.Info().Msgf("Starting server on port %s", cfg.ServerPort)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal().Err(err).Msg("Server failed to start")
-		}
-	}()
-
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logger.Info().Msg("Shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal().Err(err).Msg("Server forced to shutdown")
+	// Build every component once; cleanup stops them in reverse order
+	application, cleanup, err := initializeApp(cfg, &logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize server")
 	}
+	defer cleanup()
+
+	application.run()
 	logger.Info().Msg("Server exited")
 }
\ No newline at end of file
@@ -0,0 +1,776 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/wire"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/analytics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/routes"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/audit"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/backup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/cleanup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/contentindex"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/costs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/dedupe"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/errreport"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/grpcapi"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/hls"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/importer"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/integrity"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metadb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/moderation"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/posthook"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/replication"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/schedule"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/search"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/secrets"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tlsconfig"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/uploadlink"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// Providers build each component once from the configuration; wire passes
+// them to everything that depends on them (see wire.go). Components that
+// hold resources return a cleanup function, run in reverse order on shutdown.
+var providers = wire.NewSet(
+	provideSecrets,
+	provideStorageClients,
+	provideSharedState,
+	provideMetaStore,
+	provideJobQueue,
+	provideContentIndex,
+	providePostUploadHooks,
+	provideBackend,
+	provideStatsScanner,
+	provideSearchIndex,
+	provideMetaDB,
+	provideCleaner,
+	provideCleanupSchedule,
+	provideBackups,
+	integrity.NewChecker,
+	provideUploadNotifier,
+	providePresignedUploads,
+	provideFetcher,
+	provideImporter,
+	// One hub, so progress sockets see uploads handled by any route module
+	progress.NewHub,
+	provideVerifier,
+	wire.Bind(new(auth.Verifier), new(*auth.ServiceVerifier)),
+	provideAuditRecorder,
+	provideEventStream,
+	provideAnalytics,
+	provideCostMeter,
+	provideCostReporter,
+	provideEvents,
+	wire.Bind(new(events.Publisher), new(*events.Dispatcher)),
+	provideReporter,
+	provideQuotas,
+	provideFileService,
+	provideResolver,
+	provideRolePolicy,
+	wire.Struct(new(routes.Dependencies), "*"),
+	provideRouter,
+	provideTLS,
+	provideGRPCServer,
+	wire.Struct(new(app), "*"),
+)
+
+// secretState is the storage credentials secret, if any, and its values at startup
+type secretState struct {
+	source secrets.Source
+	values map[string]string
+}
+
+// provideSecrets loads storage credentials from a secrets manager; they
+// override the environment
+func provideSecrets(cfg *config.Config, logger *zerolog.Logger) (*secretState, error) {
+	source, err := secrets.Open(secrets.Options{
+		Provider:    cfg.SecretsProvider,
+		VaultAddr:   cfg.VaultAddr,
+		VaultToken:  cfg.VaultToken,
+		VaultPath:   cfg.VaultSecretPath,
+		AWSRegion:   cfg.SecretsAWSRegion,
+		AWSSecretID: cfg.SecretsAWSSecretID,
+		GCPSecret:   cfg.SecretsGCPSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets configuration: %w", err)
+	}
+	state := &secretState{source: source}
+	if source == nil {
+		return state, nil
+	}
+	if state.values, err = source.Fetch(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load storage credentials from %s: %w", source.Name(), err)
+	}
+	if err := cfg.ApplySecrets(state.values); err != nil {
+		return nil, fmt.Errorf("invalid storage credentials secret %s: %w", source.Name(), err)
+	}
+	logger.Info().Str("secret", source.Name()).Msg("Loaded storage credentials from secrets manager")
+	return state, nil
+}
+
+// storageClients are the backends selected by STORAGE_PROVIDER and
+// REPLICATION_PROVIDER, before the feature layers wrap them. With rotated
+// credentials they are Rotating, so new clients can be swapped in underneath
+// the wrappers.
+type storageClients struct {
+	primary   storage.Backend
+	secondary storage.Backend // nil without replication
+
+	rotatingPrimary, rotatingSecondary *storage.Rotating
+}
+
+func provideStorageClients(cfg *config.Config, secretState *secretState) (*storageClients, error) {
+	primary, err := storage.New(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s storage backend: %w", cfg.StorageProvider, err)
+	}
+	retry, err := retryOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	clients := &storageClients{primary: primary}
+	if secretState.source != nil {
+		clients.rotatingPrimary = storage.NewRotating(primary)
+		clients.primary = clients.rotatingPrimary
+	}
+	if retry.MaxAttempts > 1 {
+		clients.primary = storage.NewRetry(clients.primary, retry)
+	}
+
+	// Generated variants (thumbnails) are cached in their own bucket
+	if err := storage.EnsureBucket(context.Background(), clients.primary, cfg.DerivedBucket()); err != nil {
+		return nil, fmt.Errorf("failed to initialize derived bucket: %w", err)
+	}
+
+	if cfg.ReplicationProvider != "" {
+		secondary, err := storage.Open(cfg.ReplicationProvider, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s replication backend: %w", cfg.ReplicationProvider, err)
+		}
+		clients.secondary = secondary
+		if secretState.source != nil {
+			clients.rotatingSecondary = storage.NewRotating(secondary)
+			clients.secondary = clients.rotatingSecondary
+		}
+		if retry.MaxAttempts > 1 {
+			clients.secondary = storage.NewRetry(clients.secondary, retry)
+		}
+	}
+	return clients, nil
+}
+
+// retryOptions reads the STORAGE_RETRY_* settings
+func retryOptions(cfg *config.Config) (storage.RetryOptions, error) {
+	budgets, err := storage.ParseRetryBudgets(cfg.StorageRetryBudgets)
+	if err != nil {
+		return storage.RetryOptions{}, fmt.Errorf("invalid STORAGE_RETRY_BUDGETS configuration: %w", err)
+	}
+	return storage.RetryOptions{
+		MaxAttempts: cfg.StorageRetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.StorageRetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.StorageRetryMaxDelayMs) * time.Millisecond,
+		Budgets:     budgets,
+	}, nil
+}
+
+// provideSharedState connects to Redis when REDIS_URL is set, otherwise
+// keeps rate limits, cached verifications and record locks in process
+func provideSharedState(cfg *config.Config) (state.Shared, func(), error) {
+	shared, err := state.New(cfg.RedisURL, cfg.RedisKeyPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shared, func() { _ = shared.Close() }, nil
+}
+
+// provideMetaStore creates the metadata store; with shared state in Redis,
+// record updates are locked across replicas
+func provideMetaStore(cfg *config.Config, clients *storageClients, shared state.Shared) (store.Store, error) {
+	metaStore, err := store.New(cfg, clients.primary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize metadata store: %w", err)
+	}
+	if cfg.RedisURL != "" {
+		metaStore = store.Locked(metaStore, shared)
+	}
+	return metaStore, nil
+}
+
+// provideJobQueue creates the background job queue, persisted in the metadata
+// store. Components register their job kinds as they are built; the app
+// starts the queue once they all have.
+func provideJobQueue(metaStore store.Store, cfg *config.Config, logger *zerolog.Logger) (*jobs.Queue, func()) {
+	queue := jobs.NewQueue(metaStore, logger, cfg.JobsWorkers, cfg.JobsMaxAttempts)
+	return queue, queue.Close
+}
+
+// provideContentIndex opens the document text index for content search, nil
+// unless CONTENT_INDEX is set
+func provideContentIndex(cfg *config.Config) (contentindex.Index, error) {
+	if cfg.ContentIndex == "" {
+		return nil, nil
+	}
+	index, err := contentindex.Open(context.Background(), contentindex.Options{
+		Kind:      cfg.ContentIndex,
+		URL:       cfg.ContentIndexURL,
+		IndexName: cfg.ContentIndexName,
+		Username:  cfg.ContentIndexUsername,
+		Password:  cfg.ContentIndexPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize content index: %w", err)
+	}
+	return index, nil
+}
+
+func providePostUploadHooks(cfg *config.Config, logger *zerolog.Logger) ([]posthook.Hook, error) {
+	var hooks []posthook.Hook
+	if cfg.ModerationURL != "" {
+		hooks = append(hooks, moderation.New(moderation.Options{
+			URL:              cfg.ModerationURL,
+			APIKey:           cfg.ModerationAPIKey,
+			ContentTypes:     cfg.ModerationContentTypes,
+			Threshold:        cfg.ModerationThreshold,
+			MaxSize:          cfg.ModerationMaxSize,
+			Quarantine:       cfg.ModerationQuarantine,
+			QuarantinePrefix: cfg.ModerationQuarantinePrefix,
+		}, logger))
+		logger.Info().Bool("quarantine", cfg.ModerationQuarantine).Msg("Upload moderation enabled")
+	}
+	if cfg.HLSTranscoder != "" {
+		var transcoder hls.Transcoder
+		switch cfg.HLSTranscoder {
+		case "ffmpeg":
+			renditions, err := hls.ParseRenditions(cfg.HLSRenditions)
+			if err != nil {
+				return nil, fmt.Errorf("invalid HLS_RENDITIONS: %w", err)
+			}
+			transcoder = &hls.FFmpeg{
+				FFmpegPath:     cfg.HLSFFmpegPath,
+				FFprobePath:    cfg.HLSFFprobePath,
+				Renditions:     renditions,
+				SegmentSeconds: cfg.HLSSegmentSeconds,
+			}
+		case "external":
+			transcoder = hls.NewExternal(cfg.HLSTranscoderURL, cfg.HLSTranscoderAPIKey)
+		}
+		hooks = append(hooks, hls.NewPackager(transcoder, cfg.DerivedBucket(), cfg.HLSMaxSize, logger))
+		logger.Info().Str("transcoder", cfg.HLSTranscoder).Msg("HLS packaging enabled")
+	}
+	return hooks, nil
+}
+
+// provideBackend layers the configured features over the storage clients:
+// replication and failover, envelope encryption, content indexing and,
+// outermost so their own writes go through the other layers, post-upload hooks
+func provideBackend(cfg *config.Config, clients *storageClients, queue *jobs.Queue, contentIndex contentindex.Index, hooks []posthook.Hook, logger *zerolog.Logger) (storage.Backend, func(), error) {
+	backend := clients.primary
+	cleanup := func() {}
+
+	// Mirror writes to a secondary provider
+	if clients.secondary != nil {
+		backend = replication.New(backend, clients.secondary, queue, logger)
+		logger.Info().Str("primary", backend.Name()).Str("secondary", clients.secondary.Name()).Msg("Replication enabled")
+
+		// Serve reads from the mirror while the primary is unreachable
+		if cfg.FailoverEnabled {
+			failover := storage.NewFailover(backend, clients.secondary, storage.FailoverOptions{
+				FailureThreshold:  cfg.FailoverFailureThreshold,
+				RecoveryThreshold: cfg.FailoverRecoveryThreshold,
+				ProbeInterval:     time.Duration(cfg.FailoverProbeInterval) * time.Second,
+				ProbeBucket:       cfg.MinioBucketName,
+			}, logger)
+			cleanup = failover.Close
+			backend = failover
+		}
+	} else if cfg.FailoverEnabled {
+		return nil, nil, errors.New("FAILOVER_ENABLED requires REPLICATION_PROVIDER")
+	}
+
+	// Encrypt content before it reaches storage (and the mirror)
+	if cfg.EnvelopeEncryption {
+		keyring, err := storage.ParseKeyring(cfg.EnvelopeMasterKeys, cfg.EnvelopeKeyID)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("invalid ENVELOPE_MASTER_KEYS configuration: %w", err)
+		}
+		backend = storage.NewEnvelope(backend, keyring)
+		logger.Info().Msg("Envelope encryption enabled")
+	}
+
+	// Index document text for content search
+	if contentIndex != nil {
+		backend = contentindex.NewBackend(backend, contentIndex, queue, cfg.ContentIndexMaxSize, logger)
+		logger.Info().Str("index", cfg.ContentIndex).Msg("Content indexing enabled")
+	}
+
+	if len(hooks) > 0 {
+		backend = posthook.New(backend, queue, logger, hooks...)
+	}
+	return backend, cleanup, nil
+}
+
+// provideStatsScanner creates the storage statistics, computed by background
+// scans, nil unless the stats feature is enabled
+func provideStatsScanner(backend storage.Backend, metaStore store.Store, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) *stats.Scanner {
+	if !cfg.FeatureEnabled(config.FeatureStats) {
+		return nil
+	}
+	return stats.NewScanner(backend, metaStore, queue, stats.Options{
+		Buckets:      cfg.StatsBuckets,
+		PrefixDepth:  cfg.StatsPrefixDepth,
+		TopN:         cfg.StatsTopN,
+		HistoryLimit: cfg.StatsHistoryLimit,
+	}, logger)
+}
+
+// provideSearchIndex creates the file search index, kept in memory and
+// refreshed in the background, nil unless the search feature is enabled
+func provideSearchIndex(backend storage.Backend, contentIndex contentindex.Index, cfg *config.Config, logger *zerolog.Logger) *search.Index {
+	if !cfg.FeatureEnabled(config.FeatureSearch) {
+		return nil
+	}
+	return search.NewIndex(backend, search.Options{
+		Buckets:     cfg.SearchBuckets,
+		Concurrency: cfg.SearchIndexConcurrency,
+		Content:     contentIndex,
+	}, logger)
+}
+
+// provideMetaDB opens the database mirroring object records, nil unless
+// METADATA_DB is set
+func provideMetaDB(cfg *config.Config, logger *zerolog.Logger) (*metadb.DB, func(), error) {
+	if cfg.MetadataDB == "" {
+		return nil, func() {}, nil
+	}
+	db, err := metadb.Open(context.Background(), cfg.MetadataDB, cfg.MetadataDBDSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open metadata database: %w", err)
+	}
+	logger.Info().Str("database", cfg.MetadataDB).Msg("Metadata database enabled")
+	return db, func() { db.Close() }, nil
+}
+
+// provideCleaner creates the scheduled removal of temp objects, stale uploads,
+// expired share links and orphaned derived objects
+func provideCleaner(backend storage.Backend, metaStore store.Store, resolver *tenancy.Resolver, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) *cleanup.Cleaner {
+	var derivedBucket string
+	if cfg.CleanupDerived {
+		derivedBucket = cfg.DerivedBucket()
+	}
+	return cleanup.New(backend, metaStore, resolver, queue, cleanup.Options{
+		TempPrefixes:    cfg.CleanupTempPrefixes,
+		TempMaxAge:      time.Duration(cfg.CleanupTempMaxAge) * time.Second,
+		MultipartMaxAge: time.Duration(cfg.CleanupMultipartMaxAge) * time.Second,
+		ShareRetention:  time.Duration(cfg.CleanupShareRetention) * time.Second,
+		InternalBuckets: []string{cfg.MetadataBucket(), cfg.AuditBucket(), cfg.DerivedBucket()},
+		DerivedBucket:   derivedBucket,
+	}, logger)
+}
+
+// provideCleanupSchedule parses CLEANUP_SCHEDULE, nil when cleanup is not
+// scheduled or the cleanup feature is disabled
+func provideCleanupSchedule(cfg *config.Config) (*schedule.Schedule, error) {
+	if cfg.CleanupSchedule == "" || !cfg.FeatureEnabled(config.FeatureCleanup) {
+		return nil, nil
+	}
+	sched, err := schedule.Parse(cfg.CleanupSchedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLEANUP_SCHEDULE: %w", err)
+	}
+	return sched, nil
+}
+
+// provideBackups creates the snapshots of the data bucket into the
+// BACKUP_PROVIDER bucket, nil unless one is set. Objects are copied as
+// stored, so envelope-encrypted content stays encrypted in snapshots.
+func provideBackups(clients *storageClients, metaStore store.Store, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) (*backup.Service, error) {
+	if cfg.BackupProvider == "" {
+		return nil, nil
+	}
+	destination, err := storage.Open(cfg.BackupProvider, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s backup backend: %w", cfg.BackupProvider, err)
+	}
+	retry, err := retryOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if retry.MaxAttempts > 1 {
+		destination = storage.NewRetry(destination, retry)
+	}
+	if err := storage.EnsureBucket(context.Background(), destination, cfg.BackupBucketName); err != nil {
+		return nil, fmt.Errorf("failed to initialize backup bucket: %w", err)
+	}
+	var sched *schedule.Schedule
+	if cfg.BackupSchedule != "" {
+		if sched, err = schedule.Parse(cfg.BackupSchedule); err != nil {
+			return nil, fmt.Errorf("invalid BACKUP_SCHEDULE: %w", err)
+		}
+	}
+	logger.Info().Str("destination", destination.Name()).Str("bucket", cfg.BackupBucketName).Msg("Backups enabled")
+	return backup.New(clients.primary, destination, metaStore, queue, backup.Options{
+		Bucket:      cfg.BackupBucket(),
+		Prefix:      cfg.BackupPrefix,
+		Destination: cfg.BackupBucketName,
+		Retention:   cfg.BackupRetention,
+		Schedule:    sched,
+	}, logger), nil
+}
+
+// provideUploadNotifier creates the webhooks announcing files received
+// through upload links, nil when none are configured
+func provideUploadNotifier(cfg *config.Config, queue *jobs.Queue, logger *zerolog.Logger) *uploadlink.Notifier {
+	if !cfg.UploadLinksEnabled || (cfg.UploadLinkWebhookURL == "" && len(cfg.UploadLinkNotifyHosts) == 0) {
+		return nil
+	}
+	return uploadlink.NewNotifier(queue, cfg.UploadLinkWebhookURL, cfg.UploadLinkWebhookSecret, logger)
+}
+
+// providePresignedUploads creates the completion of presigned PUT uploads
+func providePresignedUploads(metaStore store.Store, backend storage.Backend, quotas *quota.Service, publisher events.Publisher, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) *presigned.Service {
+	// Callback URLs are the callers' choice: connect only to public addresses
+	callbacks := fetch.NewClient(fetch.Options{})
+	return presigned.NewService(metaStore, backend, quotas, publisher, queue, presigned.Options{
+		CallbackSecret:    cfg.PresignCallbackSecret,
+		MaxFileSize:       func() int64 { return cfg.Live().MaxFileSize },
+		CallbackTransport: callbacks.Transport(),
+	}, logger)
+}
+
+// provideFetcher creates the imports from remote URLs, nil unless
+// FETCH_ALLOWED_HOSTS lists any
+func provideFetcher(files service.FileService, publisher events.Publisher, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) *fetch.Service {
+	if len(cfg.FetchAllowedHosts) == 0 {
+		return nil
+	}
+	client := fetch.NewClient(fetch.Options{
+		Schemes:      cfg.FetchAllowedSchemes,
+		Hosts:        cfg.FetchAllowedHosts,
+		AllowPrivate: cfg.FetchAllowPrivate,
+		MaxSize: func() int64 {
+			if limit := cfg.Live().MaxFileSize; cfg.FetchMaxSize == 0 || limit > 0 && limit < cfg.FetchMaxSize {
+				return limit
+			}
+			return cfg.FetchMaxSize
+		},
+		Timeout: time.Duration(cfg.FetchTimeout) * time.Second,
+	})
+	return fetch.NewService(client, files, publisher, queue, logger)
+}
+
+// provideImporter creates the imports from other S3 buckets, nil unless
+// source endpoints are allowed. Source credentials are sealed with the
+// envelope master keys, or held in memory when none are configured.
+func provideImporter(files service.FileService, metaStore store.Store, publisher events.Publisher, queue *jobs.Queue, cfg *config.Config, logger *zerolog.Logger) (*importer.Service, error) {
+	if len(cfg.ImportAllowedEndpoints) == 0 {
+		return nil, nil
+	}
+	var keys storage.KeyWrapper
+	if len(cfg.EnvelopeMasterKeys) > 0 {
+		keyring, err := storage.ParseKeyring(cfg.EnvelopeMasterKeys, cfg.EnvelopeKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENVELOPE_MASTER_KEYS configuration: %w", err)
+		}
+		keys = keyring
+	} else {
+		logger.Warn().Msg("No ENVELOPE_MASTER_KEYS, import credentials are held in memory and imports don't survive a restart")
+	}
+	client := fetch.NewClient(fetch.Options{
+		Schemes:      cfg.ImportAllowedSchemes,
+		Hosts:        cfg.ImportAllowedEndpoints,
+		AllowPrivate: cfg.ImportAllowPrivate,
+		MaxSize:      func() int64 { return cfg.Live().MaxFileSize },
+	})
+	return importer.NewService(client, files, metaStore, keys, publisher, queue, logger), nil
+}
+
+// provideVerifier creates the credential verification for bearer tokens, API
+// keys and signed requests. Bearer tokens go to the auth service, or to the
+// OIDC issuers in AUTH_MODE oidc.
+func provideVerifier(cfg *config.Config, shared state.Shared) (*auth.ServiceVerifier, error) {
+	verifier, err := auth.NewServiceVerifier(cfg.AuthVerifyURL(), cfg.APIKeys, time.Duration(cfg.AuthCacheTTL)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth configuration: %w", err)
+	}
+	if cfg.RedisURL != "" {
+		verifier.ShareCache(shared)
+	}
+	if len(cfg.SigningKeys) > 0 {
+		signatures, err := auth.NewSignatureVerifier(cfg.SigningKeys, time.Duration(cfg.SignatureMaxSkew)*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SIGNING_KEYS: %w", err)
+		}
+		verifier.AcceptSignatures(signatures)
+	}
+	if cfg.AuthMode == "oidc" {
+		oidc, err := auth.NewOIDCVerifier(auth.OIDCOptions{
+			Issuers:     cfg.OIDCIssuers,
+			Audiences:   cfg.OIDCAudiences,
+			RoleClaims:  cfg.OIDCRoleClaims,
+			RoleMapping: cfg.OIDCRoleMapping,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid OIDC configuration: %w", err)
+		}
+		verifier.UseOIDC(oidc)
+	}
+	return verifier, nil
+}
+
+// provideAuditRecorder creates the audit log, nil unless AUDIT_ENABLED is set
+// and the audit feature is enabled
+func provideAuditRecorder(cfg *config.Config, backend storage.Backend, logger *zerolog.Logger) (*audit.Recorder, func(), error) {
+	if !cfg.AuditEnabled || !cfg.FeatureEnabled(config.FeatureAudit) {
+		return nil, func() {}, nil
+	}
+	auditStore, err := store.NewObjectStore(context.Background(), backend, cfg.AuditBucket())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize audit store: %w", err)
+	}
+	recorder := audit.NewRecorder(auditStore, logger)
+	return recorder, recorder.Close, nil
+}
+
+// provideEventStream creates the event stream served at /admin/events, nil
+// unless EVENT_STREAM_ENABLED is set
+func provideEventStream(cfg *config.Config) *events.Stream {
+	if !cfg.EventStreamEnabled {
+		return nil
+	}
+	return events.NewStream()
+}
+
+// provideAnalytics creates the download counters, fed by the file events,
+// nil unless the analytics feature is enabled
+func provideAnalytics(metaStore store.Store, cfg *config.Config) (*analytics.Tracker, error) {
+	if !cfg.FeatureEnabled(config.FeatureAnalytics) {
+		return nil, nil
+	}
+	tracker, err := analytics.NewTracker(context.Background(), metaStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize download analytics: %w", err)
+	}
+	return tracker, nil
+}
+
+// provideCostMeter creates the meter of billable operations, fed by the file
+// events, nil unless the costs feature is enabled
+func provideCostMeter(metaStore store.Store, cfg *config.Config) *costs.Meter {
+	if !cfg.FeatureEnabled(config.FeatureCosts) {
+		return nil
+	}
+	return costs.NewMeter(metaStore, cfg.StatsPrefixDepth)
+}
+
+// provideCostReporter creates the cost estimates, nil unless the costs
+// feature is enabled
+func provideCostReporter(meter *costs.Meter, scanner *stats.Scanner, resolver *tenancy.Resolver, cfg *config.Config) (*costs.Reporter, error) {
+	if meter == nil {
+		return nil, nil
+	}
+	minioPrices, err := costs.ParsePrices(cfg.CostMinioPrices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COST_MINIO_PRICES: %w", err)
+	}
+	r2Prices, err := costs.ParsePrices(cfg.CostR2Prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COST_R2_PRICES: %w", err)
+	}
+	return costs.NewReporter(meter, scanner, resolver, costs.Pricing{Minio: minioPrices, R2: r2Prices, R2Buckets: cfg.CostR2Buckets}), nil
+}
+
+// provideEvents creates the dispatcher of file events with the configured
+// sinks; without sinks it discards events
+func provideEvents(cfg *config.Config, stream *events.Stream, tracker *analytics.Tracker, meter *costs.Meter, logger *zerolog.Logger) (*events.Dispatcher, func(), error) {
+	var sinks []events.Sink
+	if cfg.EventLog {
+		sinks = append(sinks, events.NewLogSink(logger))
+	}
+	if cfg.EventWebhookURL != "" {
+		sinks = append(sinks, events.NewWebhookSink(cfg.EventWebhookURL, cfg.EventWebhookSecret))
+	}
+	if len(cfg.KafkaBrokers) > 0 {
+		kafkaSink, err := events.NewKafkaSink(events.KafkaOptions{
+			Brokers:      cfg.KafkaBrokers,
+			Topic:        cfg.KafkaTopic,
+			PartitionKey: cfg.KafkaPartitionKey,
+			ClientID:     cfg.KafkaClientID,
+			TLS:          cfg.KafkaTLS,
+			Username:     cfg.KafkaSASLUsername,
+			Password:     cfg.KafkaSASLPassword,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid Kafka configuration: %w", err)
+		}
+		sinks = append(sinks, kafkaSink)
+	}
+	if cfg.NATSURL != "" {
+		natsSink, err := events.NewNATSSink(cfg.NATSURL, cfg.NATSSubject, "app-minio-api")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		sinks = append(sinks, natsSink)
+	}
+	if stream != nil {
+		sinks = append(sinks, stream)
+	}
+	if tracker != nil {
+		sinks = append(sinks, tracker)
+	}
+	if meter != nil {
+		sinks = append(sinks, meter)
+	}
+	dispatcher := events.NewDispatcher(logger, sinks...)
+	return dispatcher, dispatcher.Close, nil
+}
+
+// provideReporter sends panics and server errors to Sentry when SENTRY_DSN is set
+func provideReporter(cfg *config.Config, logger *zerolog.Logger) (errreport.Reporter, error) {
+	reporter, err := errreport.New(cfg.SentryDSN, errreport.Options{
+		Environment: cfg.SentryEnvironment,
+		Release:     cfg.SentryRelease,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SENTRY_DSN: %w", err)
+	}
+	return reporter, nil
+}
+
+// provideQuotas creates the per-user storage quotas, nil unless QUOTA_ENABLED is set
+func provideQuotas(cfg *config.Config, metaStore store.Store) (*quota.Service, error) {
+	if !cfg.QuotaEnabled {
+		return nil, nil
+	}
+	quotas, err := quota.NewService(metaStore, cfg.QuotaDefaultBytes, cfg.QuotaOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quota configuration: %w", err)
+	}
+	cfg.OnReload(func(s config.Settings) error {
+		return quotas.SetLimits(s.QuotaDefaultBytes, s.QuotaOverrides)
+	})
+	return quotas, nil
+}
+
+// provideFileService creates the upload and delete rules shared by the HTTP
+// and gRPC APIs. Changes to a file are locked on the shared state, across
+// replicas with Redis.
+func provideFileService(backend storage.Backend, quotas *quota.Service, metaStore store.Store, shared state.Shared, cfg *config.Config, logger *zerolog.Logger) service.FileService {
+	var fileQuotas service.Quotas // stays a nil interface when quotas are disabled
+	if quotas != nil {
+		fileQuotas = quotas
+	}
+	locks := objectlock.New(shared, time.Duration(cfg.ObjectLockTimeout)*time.Second)
+	return service.NewFiles(backend, fileQuotas, dedupe.NewRefs(metaStore), locks, cfg, logger)
+}
+
+// provideResolver creates the tenant scoping for requests that touch stored objects
+func provideResolver(cfg *config.Config, backend storage.Backend) (*tenancy.Resolver, error) {
+	resolver, err := tenancy.NewResolver(cfg.TenancyMode, cfg.TenantClaim, cfg.MinioBucketName, cfg.TenantBucketPrefix, cfg.HomeDirectoryPattern, cfg.HomeDirectories, backend)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenancy configuration: %w", err)
+	}
+	return resolver, nil
+}
+
+// provideRolePolicy creates the per-route permission checks
+func provideRolePolicy(cfg *config.Config) (*auth.RolePolicy, error) {
+	policy, err := auth.NewRolePolicy(cfg.RBACRoleScopes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RBAC configuration: %w", err)
+	}
+	return policy, nil
+}
+
+// provideRouter sets up the Gin router: global middleware and the API routes
+func provideRouter(cfg *config.Config, reporter errreport.Reporter, verifier *auth.ServiceVerifier, shared state.Shared, deps routes.Dependencies, logger *zerolog.Logger) (*gin.Engine, error) {
+	router := gin.New()
+	// Resolve the real client IP: forwarding headers only count from trusted proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES configuration: %w", err)
+	}
+	router.RemoteIPHeaders = cfg.RemoteIPHeaders
+	router.TrustedPlatform = cfg.ClientIPHeader()
+	router.Use(gin.CustomRecovery(middleware.RecoveryHandler(reporter)))
+	// Ensure every request has a correlation ID
+	router.Use(utils.CorrelationIDMiddleware())
+	router.Use(middleware.LoggerMiddleware(logger, middleware.LoggerOptions{
+		LogRequestBodies: cfg.LogRequestBodies,
+		MaxBodyBytes:     cfg.LogBodyMaxBytes,
+		SampleRate:       cfg.LogSampleRate,
+	}))
+	router.Use(middleware.ErrorReportingMiddleware(reporter))
+	router.Use(middleware.RequestLoggerMiddleware(logger))
+	// CORS and rate limiting come before authentication: preflights are
+	// answered without credentials, 401 and 403 responses are readable by
+	// browsers, and unauthenticated floods never reach the verifier
+	router.Use(middleware.CORSMiddleware(middleware.CORSOptions{
+		AllowedOrigins:   func() []string { return cfg.Live().CORSAllowedOrigins },
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}))
+	if cfg.RateLimitRequests > 0 {
+		router.Use(middleware.RateLimitMiddleware(shared, cfg.RateLimitRequests, time.Duration(cfg.RateLimitWindow)*time.Second, logger))
+	}
+	router.Use(middleware.AuthMiddleware(verifier, func() int64 { return cfg.Live().MaxFileSize }, logger))
+	if deps.Audit != nil {
+		router.Use(middleware.AuditMiddleware(deps.Audit, cfg.MinioBucketName))
+	}
+
+	// Initialize routes
+	routes.SetupRoutes(router, deps)
+	return router, nil
+}
+
+// provideTLS loads the TLS settings of the server, nil when it serves plain HTTP
+func provideTLS(cfg *config.Config) (*tlsconfig.Server, error) {
+	serverTLS, err := tlsconfig.New(tlsconfig.Options{
+		CertFile:         cfg.TLSCertFile,
+		KeyFile:          cfg.TLSKeyFile,
+		ACMEDomains:      cfg.TLSACMEDomains,
+		ACMEEmail:        cfg.TLSACMEEmail,
+		ACMECacheDir:     cfg.TLSACMECacheDir,
+		ACMEDirectoryURL: cfg.TLSACMEDirectoryURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	return serverTLS, nil
+}
+
+// provideGRPCServer creates the gRPC storage service for internal services,
+// nil unless GRPC_PORT is set
+func provideGRPCServer(cfg *config.Config, files service.FileService, backend storage.Backend, verifier auth.Verifier, resolver *tenancy.Resolver, rolePolicy *auth.RolePolicy, publisher events.Publisher, logger *zerolog.Logger) *grpc.Server {
+	if cfg.GRPCPort == "" {
+		return nil
+	}
+	return grpcapi.NewServer(grpcapi.NewService(files, backend, verifier, resolver, rolePolicy, publisher, logger, cfg))
+}
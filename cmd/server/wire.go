@@ -0,0 +1,18 @@
+//go:build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+	"github.com/rs/zerolog"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+)
+
+// initializeApp builds every component of the server from the configuration.
+// Run `go generate ./cmd/server` after changing the providers to regenerate
+// wire_gen.go.
+func initializeApp(cfg *config.Config, logger *zerolog.Logger) (*app, func(), error) {
+	wire.Build(providers)
+	return nil, nil, nil
+}
@@ -5,15 +5,26 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
+// Part sizes S3 accepts for multipart uploads; MaxUploadPartSize is also the
+// largest object it takes in a single request
+const (
+	MinUploadPartSize = 5 << 20
+	MaxUploadPartSize = 5 << 30
+)
+
 type Config struct {
 	ServerPort      string `mapstructure:"SERVER_PORT"`
 	MinioEndpoint   string `mapstructure:"MINIO_ENDPOINT"`
@@ -22,21 +33,543 @@ type Config struct {
 	MinioSecretKey  string `mapstructure:"MINIO_SECRET_KEY"`
 	MinioUseSSL     bool   `mapstructure:"MINIO_USE_SSL"`
 	MinioBucketName string `mapstructure:"MINIO_BUCKET_NAME"`
+
+	// Storage provider: minio (default), s3, wasabi, b2, azure, fs, memory (lost on exit) or any
+	// S3-compatible service with "s3" and S3_ENDPOINT. MINIO_BUCKET_NAME names the bucket on every provider.
+	StorageProvider   string `mapstructure:"STORAGE_PROVIDER"`
+	S3Endpoint        string `mapstructure:"S3_ENDPOINT"` // host[:port]; derived from the region for aws, wasabi and b2
+	S3Region          string `mapstructure:"S3_REGION"`
+	S3AccessKeyID     string `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	S3SessionToken    string `mapstructure:"S3_SESSION_TOKEN"`
+	S3UseSSL          bool   `mapstructure:"S3_USE_SSL"`
+	S3ForcePathStyle  bool   `mapstructure:"S3_FORCE_PATH_STYLE"`
+
+	// Azure Blob Storage
+	AzureAccount    string `mapstructure:"AZURE_STORAGE_ACCOUNT"`
+	AzureAccountKey string `mapstructure:"AZURE_STORAGE_KEY"`
+	AzureEndpoint   string `mapstructure:"AZURE_STORAGE_ENDPOINT"` // defaults to https://<account>.blob.core.windows.net/
+
+	// Local filesystem, for development and tests
+	StorageFSRoot string `mapstructure:"STORAGE_FS_ROOT"` // buckets are directories under this root
+
+	// "bucket=provider" entries serving individual buckets from another provider;
+	// a trailing "*" matches a prefix, e.g. "tenant-*=azure"
+	StorageBucketProviders []string `mapstructure:"STORAGE_BUCKET_PROVIDERS"`
+
+	// HTTP connection pool of the MinIO and S3 clients. Timeouts are in
+	// seconds; 0 disables the timeout, and MaxConnsPerHost 0 means no limit.
+	StorageHTTPMaxIdleConns          int `mapstructure:"STORAGE_HTTP_MAX_IDLE_CONNS"`
+	StorageHTTPMaxIdleConnsPerHost   int `mapstructure:"STORAGE_HTTP_MAX_IDLE_CONNS_PER_HOST"`
+	StorageHTTPMaxConnsPerHost       int `mapstructure:"STORAGE_HTTP_MAX_CONNS_PER_HOST"`
+	StorageHTTPIdleConnTimeout       int `mapstructure:"STORAGE_HTTP_IDLE_CONN_TIMEOUT"`
+	StorageHTTPDialTimeout           int `mapstructure:"STORAGE_HTTP_DIAL_TIMEOUT"`
+	StorageHTTPKeepAlive             int `mapstructure:"STORAGE_HTTP_KEEP_ALIVE"`
+	StorageHTTPTLSHandshakeTimeout   int `mapstructure:"STORAGE_HTTP_TLS_HANDSHAKE_TIMEOUT"`
+	StorageHTTPResponseHeaderTimeout int `mapstructure:"STORAGE_HTTP_RESPONSE_HEADER_TIMEOUT"`
+
+	// Retries of idempotent storage operations on network errors and 5xx
+	// responses; 1 attempt disables retrying. Budgets are "operation=seconds"
+	// entries bounding the time spent across attempts, e.g. "list=20".
+	StorageRetryMaxAttempts int      `mapstructure:"STORAGE_RETRY_MAX_ATTEMPTS"`
+	StorageRetryBaseDelayMs int      `mapstructure:"STORAGE_RETRY_BASE_DELAY_MS"`
+	StorageRetryMaxDelayMs  int      `mapstructure:"STORAGE_RETRY_MAX_DELAY_MS"`
+	StorageRetryBudgets     []string `mapstructure:"STORAGE_RETRY_BUDGETS"`
+
+	// Default server-side encryption of uploads on S3 providers: sse-s3, sse-kms or
+	// empty to leave it to the bucket. Requests may still send their own SSE-C key.
+	SSEMode     string `mapstructure:"SSE_MODE"`
+	SSEKMSKeyID string `mapstructure:"SSE_KMS_KEY_ID"` // required for sse-kms
+
+	// Envelope encryption: the API encrypts content with a per-object data key
+	// wrapped by a master key before storing it. Master keys are "id=base64key"
+	// entries; ENVELOPE_KEY_ID picks the one new objects use (default: the first).
+	EnvelopeEncryption bool     `mapstructure:"ENVELOPE_ENCRYPTION"`
+	EnvelopeMasterKeys []string `mapstructure:"ENVELOPE_MASTER_KEYS"`
+	EnvelopeKeyID      string   `mapstructure:"ENVELOPE_KEY_ID"`
+
+	// Upload limits
+	MaxFileSize        int64    `mapstructure:"MAX_FILE_SIZE"`        // bytes, 0 disables the limit
+	UploadAllowedTypes []string `mapstructure:"UPLOAD_ALLOWED_TYPES"` // e.g. "image/*,application/pdf"; empty allows all
+	UploadDeniedTypes  []string `mapstructure:"UPLOAD_DENIED_TYPES"`
+	UploadMimeTypes    []string `mapstructure:"UPLOAD_MIME_TYPES"` // extra "ext=media/type" mappings
+	// Default key collision strategy: overwrite, suffix, uuid or reject
+	UploadCollisionStrategy string `mapstructure:"UPLOAD_COLLISION_STRATEGY"`
+	// Checksums computed on upload in addition to SHA-256 (md5, crc32c)
+	UploadChecksums []string `mapstructure:"UPLOAD_CHECKSUMS"`
+	// Store identical content once, keyed by its SHA-256
+	UploadDedupe bool `mapstructure:"UPLOAD_DEDUPE"`
+	// Image metadata handling
+	UploadExtractExif bool `mapstructure:"UPLOAD_EXTRACT_EXIF"`
+	UploadStripGPS    bool `mapstructure:"UPLOAD_STRIP_GPS"`
+	// Batch uploads: files per request, including zip entries, and files stored in parallel
+	UploadBatchMaxFiles    int `mapstructure:"UPLOAD_BATCH_MAX_FILES"`
+	UploadBatchConcurrency int `mapstructure:"UPLOAD_BATCH_CONCURRENCY"`
+	// Storage upload tuning; 0 leaves the provider default (16 MiB parts or
+	// larger, 4 parts at a time on S3). Objects below the threshold are sent in
+	// a single request.
+	UploadPartSize           int64 `mapstructure:"UPLOAD_PART_SIZE"`   // bytes
+	UploadConcurrency        int   `mapstructure:"UPLOAD_CONCURRENCY"` // parts in flight per upload
+	UploadSendContentMD5     bool  `mapstructure:"UPLOAD_SEND_CONTENT_MD5"`
+	UploadMultipartThreshold int64 `mapstructure:"UPLOAD_MULTIPART_THRESHOLD"` // bytes
+	// Per-request overrides may buffer at most this many bytes (part size × concurrency)
+	UploadMaxPartBuffer int64 `mapstructure:"UPLOAD_MAX_PART_BUFFER"`
+	// Upload links let anyone with the link upload into a folder, within limits
+	UploadLinksEnabled bool  `mapstructure:"UPLOAD_LINKS_ENABLED"`
+	UploadLinkMaxTTL   int64 `mapstructure:"UPLOAD_LINK_MAX_TTL"` // seconds
+	UploadLinkMaxFiles int   `mapstructure:"UPLOAD_LINK_MAX_FILES"`
+	// Webhooks announcing files received through upload links: every event
+	// goes to UPLOAD_LINK_WEBHOOK_URL, and to a link's own notify URL when its
+	// host is listed in UPLOAD_LINK_NOTIFY_HOSTS ("*" allows any)
+	UploadLinkWebhookURL    string   `mapstructure:"UPLOAD_LINK_WEBHOOK_URL"`
+	UploadLinkWebhookSecret string   `mapstructure:"UPLOAD_LINK_WEBHOOK_SECRET"` // signs webhook bodies
+	UploadLinkNotifyHosts   []string `mapstructure:"UPLOAD_LINK_NOTIFY_HOSTS"`
+	// Completion of presigned PUT uploads: a presign's callback URL must be on
+	// a host listed in PRESIGN_CALLBACK_HOSTS ("*" allows any), and with
+	// PRESIGN_NOTIFICATIONS bucket notifications complete uploads nobody confirms;
+	// every PRESIGN_RECONCILE_INTERVAL the remaining ones are looked up in storage
+	PresignCallbackHosts     []string `mapstructure:"PRESIGN_CALLBACK_HOSTS"`
+	PresignCallbackSecret    string   `mapstructure:"PRESIGN_CALLBACK_SECRET"` // signs callback bodies
+	PresignNotifications     bool     `mapstructure:"PRESIGN_NOTIFICATIONS"`
+	PresignReconcileInterval int      `mapstructure:"PRESIGN_RECONCILE_INTERVAL"` // seconds; 0 disables
+	// Imports from remote URLs, enabled by listing FETCH_ALLOWED_HOSTS ("*"
+	// allows any, "*.example.com" subdomains). Private addresses are refused
+	// unless FETCH_ALLOW_PRIVATE; files announced over FETCH_SYNC_MAX_SIZE are
+	// fetched by a background job.
+	FetchAllowedHosts   []string `mapstructure:"FETCH_ALLOWED_HOSTS"`
+	FetchAllowedSchemes []string `mapstructure:"FETCH_ALLOWED_SCHEMES"`
+	FetchAllowPrivate   bool     `mapstructure:"FETCH_ALLOW_PRIVATE"`
+	FetchMaxSize        int64    `mapstructure:"FETCH_MAX_SIZE"`      // bytes, 0 leaves MAX_FILE_SIZE
+	FetchSyncMaxSize    int64    `mapstructure:"FETCH_SYNC_MAX_SIZE"` // bytes
+	FetchTimeout        int      `mapstructure:"FETCH_TIMEOUT"`       // seconds
+	// Imports from other S3 buckets, enabled by listing the source endpoint
+	// hosts allowed in IMPORT_ALLOWED_ENDPOINTS (as FETCH_ALLOWED_HOSTS).
+	// Endpoints on private addresses are refused unless IMPORT_ALLOW_PRIVATE.
+	// Source credentials are stored sealed with ENVELOPE_MASTER_KEYS, or only
+	// held in memory without them.
+	ImportAllowedEndpoints []string `mapstructure:"IMPORT_ALLOWED_ENDPOINTS"`
+	ImportAllowedSchemes   []string `mapstructure:"IMPORT_ALLOWED_SCHEMES"`
+	ImportAllowPrivate     bool     `mapstructure:"IMPORT_ALLOW_PRIVATE"`
+
+	// Parallel metadata lookups when listing files with include_metadata
+	ListMetadataConcurrency int `mapstructure:"LIST_METADATA_CONCURRENCY"`
+
+	// Proxied upload/download limits; 0 disables each
+	TransferMaxConcurrent   int   `mapstructure:"TRANSFER_MAX_CONCURRENT"`
+	TransferQueueTimeout    int   `mapstructure:"TRANSFER_QUEUE_TIMEOUT"`     // seconds to wait for a slot
+	TransferRateLimit       int64 `mapstructure:"TRANSFER_RATE_LIMIT"`        // bytes per second, per transfer
+	TransferGlobalRateLimit int64 `mapstructure:"TRANSFER_GLOBAL_RATE_LIMIT"` // bytes per second, all transfers
+
+	// Request deadlines in seconds, 0 disables each. Transfers get
+	// TRANSFER_TIMEOUT instead of their module's deadline.
+	RequestTimeout  int      `mapstructure:"REQUEST_TIMEOUT"`
+	TransferTimeout int      `mapstructure:"TRANSFER_TIMEOUT"`
+	RouteTimeouts   []string `mapstructure:"ROUTE_TIMEOUTS"` // "module=seconds" overrides of REQUEST_TIMEOUT
+
+	// Requests per client IP in each window, counted before authentication;
+	// 0 disables. Counted across replicas when REDIS_URL is set.
+	RateLimitRequests int `mapstructure:"RATE_LIMIT_REQUESTS"`
+	RateLimitWindow   int `mapstructure:"RATE_LIMIT_WINDOW"` // seconds
+
+	// Download caching
+	CacheControlDefault string `mapstructure:"CACHE_CONTROL_DEFAULT"`
+	CacheControlRules   string `mapstructure:"CACHE_CONTROL_RULES"` // "image/*=public, max-age=86400; text/html=no-cache"
+
+	// Bucket for generated variants (thumbnails, renditions)
+	DerivedBucketName string `mapstructure:"DERIVED_BUCKET_NAME"`
+
+	// Wrap the data key of envelope-encrypted files under a key derived from
+	// the password of share links to them, so only the password decrypts them
+	// for the link
+	SharePasswordEncryption bool `mapstructure:"SHARE_PASSWORD_ENCRYPTION"`
+
+	// Authentication
+	AuthServiceURL string   `mapstructure:"AUTH_SERVICE_URL"`
+	AuthVerifyPath string   `mapstructure:"AUTH_VERIFY_PATH"`
+	AuthCacheTTL   int      `mapstructure:"AUTH_CACHE_TTL"` // seconds
+	APIKeys        []string `mapstructure:"API_KEYS"`       // "name:key" or "name:key:scope1 scope2" entries
+	// Shared secrets of services that sign their requests instead of sending
+	// a token ("name:secret" or "name:secret:scope1 scope2" entries), and how
+	// far a signed request's date may be from the server's clock
+	SigningKeys      []string `mapstructure:"SIGNING_KEYS"`
+	SignatureMaxSkew int      `mapstructure:"SIGNATURE_MAX_SKEW"` // seconds
+	// AUTH_MODE "service" verifies bearer tokens with the auth service at
+	// AUTH_SERVICE_URL; "oidc" verifies them as JWTs signed by one of
+	// OIDC_ISSUERS for one of OIDC_AUDIENCES. The values of OIDC_ROLE_CLAIMS
+	// (dotted paths reach nested claims) become roles, translated by
+	// OIDC_ROLE_MAPPING "value=role1 role2" entries.
+	AuthMode        string   `mapstructure:"AUTH_MODE"`
+	OIDCIssuers     []string `mapstructure:"OIDC_ISSUERS"`
+	OIDCAudiences   []string `mapstructure:"OIDC_AUDIENCES"`
+	OIDCRoleClaims  []string `mapstructure:"OIDC_ROLE_CLAIMS"`
+	OIDCRoleMapping []string `mapstructure:"OIDC_ROLE_MAPPING"`
+
+	// Optional subsystems to run (see Features); unset runs all of them and
+	// "none" only the core file API
+	Features []string `mapstructure:"FEATURES"`
+
+	// Retirement of /api/v1 in favor of /api/v2: RFC 3339 times announced in
+	// the Deprecation and Sunset headers of the v1 routes of these modules
+	// (empty for all); unset times send no headers
+	APIV1DeprecatedAt      string   `mapstructure:"API_V1_DEPRECATED_AT"`
+	APIV1SunsetAt          string   `mapstructure:"API_V1_SUNSET_AT"`
+	APIV1DeprecatedModules []string `mapstructure:"API_V1_DEPRECATED_MODULES"`
+
+	// Route security: "group=level" entries, level is public, authenticated or admin
+	RouteSecurity []string `mapstructure:"ROUTE_SECURITY"`
+
+	// Role-based access control
+	RBACEnabled    bool     `mapstructure:"RBAC_ENABLED"`
+	RBACRoleScopes []string `mapstructure:"RBAC_ROLE_SCOPES"` // "role=scope1 scope2" entries
+
+	// Storage quotas
+	QuotaEnabled      bool     `mapstructure:"QUOTA_ENABLED"`
+	QuotaDefaultBytes int64    `mapstructure:"QUOTA_DEFAULT_BYTES"` // 0 = unlimited
+	QuotaOverrides    []string `mapstructure:"QUOTA_OVERRIDES"`     // "subject=bytes" entries
+
+	// Multi-tenancy
+	TenancyMode        string `mapstructure:"TENANCY_MODE"`         // "", "prefix" or "bucket"
+	TenantClaim        string `mapstructure:"TENANT_CLAIM"`         // identity claim holding the tenant ID
+	TenantBucketPrefix string `mapstructure:"TENANT_BUCKET_PREFIX"` // bucket mode: bucket = prefix + tenant ID
+
+	// Per-user home directories; {subject} is the caller's subject
+	HomeDirectories      bool   `mapstructure:"HOME_DIRECTORIES"` // confine authenticated file operations to the caller's home
+	HomeDirectoryPattern string `mapstructure:"HOME_DIRECTORY_PATTERN"`
+
+	// Audit log
+	AuditEnabled    bool   `mapstructure:"AUDIT_ENABLED"`
+	AuditBucketName string `mapstructure:"AUDIT_BUCKET_NAME"`
+
+	// File events (see internal/events), delivered to each sink that is
+	// configured: the log, a webhook, Kafka, NATS and the admin event stream
+	EventLog           bool   `mapstructure:"EVENT_LOG"`
+	EventWebhookURL    string `mapstructure:"EVENT_WEBHOOK_URL"`
+	EventWebhookSecret string `mapstructure:"EVENT_WEBHOOK_SECRET"` // signs webhook bodies
+	EventStreamEnabled bool   `mapstructure:"EVENT_STREAM_ENABLED"` // GET /admin/events
+	// Kafka producer; empty KAFKA_BROKERS disables
+	KafkaBrokers      []string `mapstructure:"KAFKA_BROKERS"`
+	KafkaTopic        string   `mapstructure:"KAFKA_TOPIC"`
+	KafkaPartitionKey string   `mapstructure:"KAFKA_PARTITION_KEY"` // key, bucket, actor or none
+	KafkaClientID     string   `mapstructure:"KAFKA_CLIENT_ID"`
+	KafkaTLS          bool     `mapstructure:"KAFKA_TLS"`
+	KafkaSASLUsername string   `mapstructure:"KAFKA_SASL_USERNAME"` // SASL/PLAIN; empty disables
+	KafkaSASLPassword string   `mapstructure:"KAFKA_SASL_PASSWORD"`
+	// NATS publisher; empty NATS_URL disables
+	NATSURL     string `mapstructure:"NATS_URL"`
+	NATSSubject string `mapstructure:"NATS_SUBJECT"` // events go to <subject>.<type>
+
+	// State shared by replicas (see internal/state): rate limit counters,
+	// cached token verifications and locks on metadata records. Empty
+	// REDIS_URL keeps them in process, which is only right for one replica.
+	RedisURL       string `mapstructure:"REDIS_URL"` // redis:// or rediss:// URL
+	RedisKeyPrefix string `mapstructure:"REDIS_KEY_PREFIX"`
+	// Seconds a change to a file waits for another one to finish before
+	// failing with 409; 0 waits until the request's deadline
+	ObjectLockTimeout int `mapstructure:"OBJECT_LOCK_TIMEOUT"`
+
+	// Logging: format is gcp, json or console; level is debug, info, warn or error
+	LogFormat     string `mapstructure:"LOG_FORMAT"`
+	LogLevel      string `mapstructure:"LOG_LEVEL"`
+	LogSampleRate uint32 `mapstructure:"LOG_SAMPLE_RATE"` // log 1 in N successful requests; 0 logs all
+	// Past a burst per second, log 1 in N debug lines; 0 logs all
+	LogDebugSampleRate uint32 `mapstructure:"LOG_DEBUG_SAMPLE_RATE"`
+	// Field names masked in logs on top of Authorization, tokens, passwords and secrets
+	LogRedactKeys []string `mapstructure:"LOG_REDACT_KEYS"`
+	// Request bodies are only captured for JSON requests up to the limit
+	LogRequestBodies bool  `mapstructure:"LOG_REQUEST_BODIES"`
+	LogBodyMaxBytes  int64 `mapstructure:"LOG_BODY_MAX_BYTES"`
+
+	// Error reporting to Sentry; empty DSN disables it
+	SentryDSN         string `mapstructure:"SENTRY_DSN"`
+	SentryEnvironment string `mapstructure:"SENTRY_ENVIRONMENT"`
+	SentryRelease     string `mapstructure:"SENTRY_RELEASE"`
+
+	// Metadata store
+	MetadataStore      string `mapstructure:"METADATA_STORE"` // object (alias minio) or memory
+	MetadataBucketName string `mapstructure:"METADATA_BUCKET_NAME"`
+
+	// Metadata database mirroring object records; empty disables
+	MetadataDB                  string   `mapstructure:"METADATA_DB"` // sqlite or postgres
+	MetadataDBDSN               string   `mapstructure:"METADATA_DB_DSN"`
+	MetadataDBBuckets           []string `mapstructure:"METADATA_DB_BUCKETS"`            // empty mirrors every bucket
+	MetadataDBReconcileInterval int      `mapstructure:"METADATA_DB_RECONCILE_INTERVAL"` // seconds; 0 reconciles only at startup
+
+	// Background jobs
+	JobsWorkers     int `mapstructure:"JOBS_WORKERS"`
+	JobsMaxAttempts int `mapstructure:"JOBS_MAX_ATTEMPTS"`
+
+	// Storage statistics: background scans for GET /admin/stats
+	StatsScanInterval int      `mapstructure:"STATS_SCAN_INTERVAL"` // seconds; 0 scans only on demand
+	StatsBuckets      []string `mapstructure:"STATS_BUCKETS"`       // empty scans every bucket
+	StatsPrefixDepth  int      `mapstructure:"STATS_PREFIX_DEPTH"`  // path segments per reported prefix
+	StatsTopN         int      `mapstructure:"STATS_TOP_N"`         // largest objects to report
+	StatsHistoryLimit int      `mapstructure:"STATS_HISTORY_LIMIT"` // scans kept for growth over time
+
+	// Cost estimates: price tables in USD as "storage=0.015,egress=0,class_a=4.50,class_b=0.36",
+	// per GB-month stored, GB downloaded and million writes (class A) and
+	// reads (class B). Buckets matching COST_R2_BUCKETS use the R2 table.
+	CostMinioPrices string   `mapstructure:"COST_MINIO_PRICES"`
+	CostR2Prices    string   `mapstructure:"COST_R2_PRICES"`
+	CostR2Buckets   []string `mapstructure:"COST_R2_BUCKETS"` // bucket names or prefixes ending in *
+
+	// File search: an in-memory index refreshed in the background for GET /files/search
+	SearchIndexInterval    int      `mapstructure:"SEARCH_INDEX_INTERVAL"`    // seconds; 0 lists the bucket on every query
+	SearchBuckets          []string `mapstructure:"SEARCH_BUCKETS"`           // empty indexes every bucket
+	SearchIndexConcurrency int      `mapstructure:"SEARCH_INDEX_CONCURRENCY"` // objects inspected in parallel
+
+	// Full-text content search: text extracted from PDF, DOCX and text files
+	ContentIndex         string `mapstructure:"CONTENT_INDEX"` // memory or elasticsearch; empty disables
+	ContentIndexURL      string `mapstructure:"CONTENT_INDEX_URL"`
+	ContentIndexName     string `mapstructure:"CONTENT_INDEX_NAME"`
+	ContentIndexUsername string `mapstructure:"CONTENT_INDEX_USERNAME"`
+	ContentIndexPassword string `mapstructure:"CONTENT_INDEX_PASSWORD"`
+	ContentIndexMaxSize  int64  `mapstructure:"CONTENT_INDEX_MAX_SIZE"` // bytes; larger files aren't indexed
+
+	// Moderation of uploaded media by an external API; empty URL disables
+	ModerationURL              string   `mapstructure:"MODERATION_URL"`
+	ModerationAPIKey           string   `mapstructure:"MODERATION_API_KEY"`
+	ModerationContentTypes     []string `mapstructure:"MODERATION_CONTENT_TYPES"`
+	ModerationThreshold        float64  `mapstructure:"MODERATION_THRESHOLD"` // label score flagging an object, 0-1
+	ModerationMaxSize          int64    `mapstructure:"MODERATION_MAX_SIZE"`  // bytes; larger files aren't checked
+	ModerationQuarantine       bool     `mapstructure:"MODERATION_QUARANTINE"`
+	ModerationQuarantinePrefix string   `mapstructure:"MODERATION_QUARANTINE_PREFIX"`
+
+	// HLS packaging of uploaded videos: ffmpeg, external, or empty to disable.
+	// Each video is packaged within a single job attempt (10 minutes).
+	HLSTranscoder       string   `mapstructure:"HLS_TRANSCODER"`
+	HLSFFmpegPath       string   `mapstructure:"HLS_FFMPEG_PATH"`
+	HLSFFprobePath      string   `mapstructure:"HLS_FFPROBE_PATH"`
+	HLSTranscoderURL    string   `mapstructure:"HLS_TRANSCODER_URL"`
+	HLSTranscoderAPIKey string   `mapstructure:"HLS_TRANSCODER_API_KEY"`
+	HLSRenditions       []string `mapstructure:"HLS_RENDITIONS"`      // height:kbps, e.g. 720:2800
+	HLSSegmentSeconds   int      `mapstructure:"HLS_SEGMENT_SECONDS"` // target segment duration
+	HLSMaxSize          int64    `mapstructure:"HLS_MAX_SIZE"`        // bytes; larger videos aren't packaged
+
+	// Scheduled cleanup; max ages and retention are seconds, 0 disables a task
+	CleanupSchedule        string   `mapstructure:"CLEANUP_SCHEDULE"` // cron expression (UTC); empty disables
+	CleanupTempPrefixes    []string `mapstructure:"CLEANUP_TEMP_PREFIXES"`
+	CleanupTempMaxAge      int      `mapstructure:"CLEANUP_TEMP_MAX_AGE"`
+	CleanupMultipartMaxAge int      `mapstructure:"CLEANUP_MULTIPART_MAX_AGE"`
+	CleanupShareRetention  int      `mapstructure:"CLEANUP_SHARE_RETENTION"`
+	CleanupDerived         bool     `mapstructure:"CLEANUP_DERIVED"` // thumbnails and streams of deleted or replaced objects
+
+	// Replication: mirror writes to a secondary provider (e.g. "s3" pointed at R2); empty disables
+	ReplicationProvider string `mapstructure:"REPLICATION_PROVIDER"`
+
+	// Backups: snapshots of a bucket, or a prefix of it, into a bucket of
+	// another provider (e.g. "s3" pointed at R2); empty BACKUP_PROVIDER disables
+	BackupProvider     string `mapstructure:"BACKUP_PROVIDER"`
+	BackupBucketName   string `mapstructure:"BACKUP_BUCKET_NAME"`   // destination bucket
+	BackupSourceBucket string `mapstructure:"BACKUP_SOURCE_BUCKET"` // defaults to MINIO_BUCKET_NAME
+	BackupPrefix       string `mapstructure:"BACKUP_PREFIX"`
+	BackupSchedule     string `mapstructure:"BACKUP_SCHEDULE"`  // cron expression (UTC); empty only takes snapshots on demand
+	BackupRetention    int    `mapstructure:"BACKUP_RETENTION"` // succeeded snapshots kept
+
+	// Read failover to the replication mirror when the primary is unreachable
+	FailoverEnabled           bool `mapstructure:"FAILOVER_ENABLED"`
+	FailoverFailureThreshold  int  `mapstructure:"FAILOVER_FAILURE_THRESHOLD"`  // consecutive errors before failing over
+	FailoverRecoveryThreshold int  `mapstructure:"FAILOVER_RECOVERY_THRESHOLD"` // consecutive probes before failing back
+	FailoverProbeInterval     int  `mapstructure:"FAILOVER_PROBE_INTERVAL"`     // seconds
+
+	// Idempotency-Key replay window for mutating requests; 0 disables
+	IdempotencyTTL int `mapstructure:"IDEMPOTENCY_TTL"` // seconds
+
+	// Validate requests against the generated OpenAPI spec
+	RequestValidation bool `mapstructure:"REQUEST_VALIDATION"`
+
+	// gRPC storage service, served on its own port; empty disables it
+	GRPCPort string `mapstructure:"GRPC_PORT"`
+
+	// Static website hosting: serve a bucket (or prefix) at /site/*path
+	SiteEnabled           bool   `mapstructure:"SITE_ENABLED"`
+	SiteBucketName        string `mapstructure:"SITE_BUCKET_NAME"` // defaults to MINIO_BUCKET_NAME
+	SitePrefix            string `mapstructure:"SITE_PREFIX"`      // e.g. "site/"
+	SiteIndexDocument     string `mapstructure:"SITE_INDEX_DOCUMENT"`
+	SiteErrorDocument     string `mapstructure:"SITE_ERROR_DOCUMENT"` // served with 404 for missing paths; empty disables
+	SiteCacheControl      string `mapstructure:"SITE_CACHE_CONTROL"`
+	SiteCacheControlRules string `mapstructure:"SITE_CACHE_CONTROL_RULES"` // same format as CACHE_CONTROL_RULES
+
+	// Native TLS with HTTP/2, from certificate files or automatic ACME certificates;
+	// leave both unset when a reverse proxy terminates TLS
+	TLSCertFile         string   `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile          string   `mapstructure:"TLS_KEY_FILE"`
+	TLSACMEDomains      []string `mapstructure:"TLS_ACME_DOMAINS"`
+	TLSACMEEmail        string   `mapstructure:"TLS_ACME_EMAIL"`
+	TLSACMECacheDir     string   `mapstructure:"TLS_ACME_CACHE_DIR"`
+	TLSACMEDirectoryURL string   `mapstructure:"TLS_ACME_DIRECTORY_URL"` // defaults to Let's Encrypt production
+	TLSHTTPPort         string   `mapstructure:"TLS_HTTP_PORT"`          // plain HTTP port for ACME challenges and redirects; empty disables
+
+	// Storage credentials from a secrets manager: vault, aws, gcp or empty for the
+	// environment. The secret is a JSON object keyed by the variables it replaces
+	// (MINIO_ACCESS_KEY, S3_SECRET_ACCESS_KEY, AZURE_STORAGE_KEY, ...).
+	SecretsProvider        string `mapstructure:"SECRETS_PROVIDER"`
+	SecretsRefreshInterval int    `mapstructure:"SECRETS_REFRESH_INTERVAL"` // seconds between rotation checks; 0 disables
+	VaultAddr              string `mapstructure:"VAULT_ADDR"`
+	VaultToken             string `mapstructure:"VAULT_TOKEN"`
+	VaultSecretPath        string `mapstructure:"VAULT_SECRET_PATH"` // e.g. secret/data/app-minio-api
+	SecretsAWSRegion       string `mapstructure:"SECRETS_AWS_REGION"`
+	SecretsAWSSecretID     string `mapstructure:"SECRETS_AWS_SECRET_ID"`
+	SecretsGCPSecret       string `mapstructure:"SECRETS_GCP_SECRET"` // projects/<project>/secrets/<name>
+
+	// Client IP resolution: forwarding headers are only honored from these proxy
+	// IPs or CIDRs (empty trusts none, so the connection's address is used)
+	TrustedProxies  []string `mapstructure:"TRUSTED_PROXIES"`
+	RemoteIPHeaders []string `mapstructure:"REMOTE_IP_HEADERS"` // checked in order on trusted requests
+	// Header set by a CDN in front of every request: cloudflare (CF-Connecting-IP),
+	// appengine or a header name. It is trusted from any peer, so only set it when
+	// the server is not reachable except through that CDN.
+	TrustedPlatform string `mapstructure:"TRUSTED_PLATFORM"`
+
+	// Response compression (gzip) of JSON and text responses, per route group as
+	// named in ROUTE_SECURITY
+	CompressionGroups  []string `mapstructure:"COMPRESSION_GROUPS"`
+	CompressionLevel   int      `mapstructure:"COMPRESSION_LEVEL"`    // 1 (fastest) to 9 (smallest); 0 = default
+	CompressionMinSize int      `mapstructure:"COMPRESSION_MIN_SIZE"` // bytes
+
+	// Downloads on these route groups (files, share_links) redirect to a
+	// presigned storage URL instead of streaming through the API
+	DownloadRedirectGroups []string `mapstructure:"DOWNLOAD_REDIRECT_GROUPS"`
+	DownloadRedirectExpiry int      `mapstructure:"DOWNLOAD_REDIRECT_EXPIRY"` // seconds
+	// Public base URL of the default bucket (e.g. an R2 public bucket or a CDN
+	// custom domain); redirects go there instead of to presigned URLs
+	DownloadPublicURL string `mapstructure:"DOWNLOAD_PUBLIC_URL"`
+	// Downloads of these content types (exact, wildcard or suffix media types
+	// such as */*+xml), and of objects without one, are forced to attachments
+	// served as application/octet-stream, so uploaded HTML or SVG never runs
+	// in the API's origin. With DOWNLOAD_SANITIZE_SVG,
+	// SVGs are instead shown inline with scripts and external links removed.
+	DownloadRiskyTypes  []string `mapstructure:"DOWNLOAD_RISKY_TYPES"`
+	DownloadSanitizeSVG bool     `mapstructure:"DOWNLOAD_SANITIZE_SVG"`
+
+	// Browser origins allowed to call the API ("*" allows any, and requires
+	// CORS_ALLOW_CREDENTIALS=false), and the CORS headers sent to them
+	CORSAllowedOrigins   []string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   []string `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   []string `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders   []string `mapstructure:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials bool     `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           int      `mapstructure:"CORS_MAX_AGE"` // seconds
+
+	// Settings applied by Reload, and the components notified of them
+	live      atomic.Pointer[Settings]
+	reloadMu  sync.Mutex
+	listeners []func(Settings) error
+	// secretsApplied is set once credentials from SECRETS_PROVIDER are in place
+	secretsApplied bool
+}
+
+// DerivedBucket returns the bucket holding generated variants of user files
+func (c *Config) DerivedBucket() string {
+	if c.DerivedBucketName != "" {
+		return c.DerivedBucketName
+	}
+	return c.MinioBucketName + "-derived"
+}
+
+// BackupBucket returns the bucket snapshotted by backups
+func (c *Config) BackupBucket() string {
+	if c.BackupSourceBucket != "" {
+		return c.BackupSourceBucket
+	}
+	return c.MinioBucketName
+}
+
+// SiteBucket returns the bucket served by static website hosting
+func (c *Config) SiteBucket() string {
+	if c.SiteBucketName != "" {
+		return c.SiteBucketName
+	}
+	return c.MinioBucketName
+}
+
+// AuditBucket returns the bucket holding audit events
+func (c *Config) AuditBucket() string {
+	if c.AuditBucketName != "" {
+		return c.AuditBucketName
+	}
+	return c.MinioBucketName + "-audit"
+}
+
+// APIV1Retirement returns the parsed API_V1_DEPRECATED_AT and API_V1_SUNSET_AT,
+// zero when unset
+func (c *Config) APIV1Retirement() (deprecatedAt, sunsetAt time.Time, err error) {
+	if c.APIV1DeprecatedAt != "" {
+		if deprecatedAt, err = time.Parse(time.RFC3339, c.APIV1DeprecatedAt); err != nil {
+			return deprecatedAt, sunsetAt, fmt.Errorf("API_V1_DEPRECATED_AT %q must be an RFC 3339 time", c.APIV1DeprecatedAt)
+		}
+	}
+	if c.APIV1SunsetAt != "" {
+		if sunsetAt, err = time.Parse(time.RFC3339, c.APIV1SunsetAt); err != nil {
+			return deprecatedAt, sunsetAt, fmt.Errorf("API_V1_SUNSET_AT %q must be an RFC 3339 time", c.APIV1SunsetAt)
+		}
+	}
+	if !deprecatedAt.IsZero() && !sunsetAt.IsZero() && !sunsetAt.After(deprecatedAt) {
+		return deprecatedAt, sunsetAt, fmt.Errorf("API_V1_SUNSET_AT must be after API_V1_DEPRECATED_AT")
+	}
+	return deprecatedAt, sunsetAt, nil
+}
+
+// TLSEnabled reports whether the server terminates TLS itself
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != "" || len(c.TLSACMEDomains) > 0
+}
+
+// ClientIPHeader returns the header named by TRUSTED_PLATFORM, or empty
+func (c *Config) ClientIPHeader() string {
+	switch strings.ToLower(c.TrustedPlatform) {
+	case "":
+		return ""
+	case "cloudflare":
+		return "CF-Connecting-IP"
+	case "appengine":
+		return "X-Appengine-Remote-Addr"
+	}
+	return c.TrustedPlatform
+}
+
+// AuthVerifyURL returns the auth service token verification endpoint
+func (c *Config) AuthVerifyURL() string {
+	if c.AuthServiceURL == "" {
+		return ""
+	}
+	return strings.TrimRight(c.AuthServiceURL, "/") + c.AuthVerifyPath
 }
 
+// MetadataBucket returns the bucket holding API metadata records, derived from
+// the data bucket name unless configured explicitly
+func (c *Config) MetadataBucket() string {
+	if c.MetadataBucketName != "" {
+		return c.MetadataBucketName
+	}
+	return c.MinioBucketName + "-metadata"
+}
+
+// envFileKeys are the variables set from .env rather than the real environment.
+// Reloading refreshes only those, so the environment keeps precedence.
+var envFileKeys = map[string]bool{}
+
 // loadEnvFile loads environment variables from .env file if it exists
 func loadEnvFile() {
 	// Try to load .env file but don't fail if it doesn't exist
 	if _, err := os.Stat(".env"); err == nil {
-		if err := godotenv.Load(); err != nil {
+		values, err := godotenv.Read()
+		if err != nil {
 			log.Warn().Err(err).Msg("Failed to load .env file")
-		} else {
-			log.Info().Msg("Loaded environment variables from .env file")
+			return
+		}
+		for key, value := range values {
+			if _, set := os.LookupEnv(key); set && !envFileKeys[key] {
+				continue
+			}
+			envFileKeys[key] = true
+			_ = os.Setenv(key, value)
 		}
+		log.Info().Msg("Loaded environment variables from .env file")
 	}
 }
 
 // LoadConfig loads the configuration from environment variables with .env fallback
+// and validates it
 func LoadConfig() (*Config, error) {
 	// Load environment variables from .env file if it exists (development only)
 	loadEnvFile()
@@ -58,11 +591,17 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Fail at startup rather than on the first request that needs a setting
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Debug: Print the loaded configuration
 	log.Info().
 		Str("server_port", cfg.ServerPort).
 		Str("minio_endpoint", cfg.MinioEndpoint).
 		Str("minio_port", cfg.MinioPort).
+		Int64("max_file_size", cfg.MaxFileSize).
 		Msg("Loaded configuration")
 
 	return &cfg, nil
@@ -77,6 +616,298 @@ func setDefaults() {
 	viper.SetDefault("MINIO_PORT", "9000")
 	viper.SetDefault("MINIO_USE_SSL", false)
 	viper.SetDefault("MINIO_BUCKET_NAME", "my-bucket")
+
+	// Storage provider defaults
+	viper.SetDefault("STORAGE_PROVIDER", "minio")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_USE_SSL", true)
+	viper.SetDefault("S3_FORCE_PATH_STYLE", false)
+	viper.SetDefault("STORAGE_BUCKET_PROVIDERS", []string{})
+	viper.SetDefault("STORAGE_FS_ROOT", "./data")
+	// minio-go keeps 16 idle connections per host, which churns under concurrent transfers
+	viper.SetDefault("STORAGE_HTTP_MAX_IDLE_CONNS", 256)
+	viper.SetDefault("STORAGE_HTTP_MAX_IDLE_CONNS_PER_HOST", 128)
+	viper.SetDefault("STORAGE_HTTP_MAX_CONNS_PER_HOST", 0)
+	viper.SetDefault("STORAGE_HTTP_IDLE_CONN_TIMEOUT", 90)
+	viper.SetDefault("STORAGE_HTTP_DIAL_TIMEOUT", 10)
+	viper.SetDefault("STORAGE_HTTP_KEEP_ALIVE", 30)
+	viper.SetDefault("STORAGE_HTTP_TLS_HANDSHAKE_TIMEOUT", 10)
+	viper.SetDefault("STORAGE_HTTP_RESPONSE_HEADER_TIMEOUT", 60)
+	viper.SetDefault("STORAGE_RETRY_MAX_ATTEMPTS", 3)
+	viper.SetDefault("STORAGE_RETRY_BASE_DELAY_MS", 100)
+	viper.SetDefault("STORAGE_RETRY_MAX_DELAY_MS", 2000)
+	viper.SetDefault("STORAGE_RETRY_BUDGETS", []string{"stat=5", "get=10", "remove=10", "list=20"})
+
+	// Encryption defaults
+	viper.SetDefault("SSE_MODE", "")
+	viper.SetDefault("SSE_KMS_KEY_ID", "")
+	viper.SetDefault("ENVELOPE_ENCRYPTION", false)
+	viper.SetDefault("ENVELOPE_MASTER_KEYS", []string{})
+	viper.SetDefault("ENVELOPE_KEY_ID", "")
+
+	// Upload defaults
+	viper.SetDefault("MAX_FILE_SIZE", 100<<20) // 100 MiB
+	viper.SetDefault("UPLOAD_ALLOWED_TYPES", []string{})
+	viper.SetDefault("UPLOAD_DENIED_TYPES", []string{
+		"application/x-msdownload",
+		"application/x-executable",
+		"application/x-mach-binary",
+		"text/x-shellscript",
+	})
+	viper.SetDefault("UPLOAD_COLLISION_STRATEGY", "suffix")
+	viper.SetDefault("UPLOAD_CHECKSUMS", []string{})
+	viper.SetDefault("UPLOAD_DEDUPE", false)
+	viper.SetDefault("UPLOAD_EXTRACT_EXIF", false)
+	viper.SetDefault("UPLOAD_STRIP_GPS", false)
+	viper.SetDefault("UPLOAD_BATCH_MAX_FILES", 1000)
+	viper.SetDefault("UPLOAD_BATCH_CONCURRENCY", 4)
+	viper.SetDefault("UPLOAD_PART_SIZE", 0)
+	viper.SetDefault("UPLOAD_CONCURRENCY", 0)
+	viper.SetDefault("UPLOAD_SEND_CONTENT_MD5", false)
+	viper.SetDefault("UPLOAD_MULTIPART_THRESHOLD", 0)
+	viper.SetDefault("UPLOAD_MAX_PART_BUFFER", 512<<20)
+	viper.SetDefault("UPLOAD_LINKS_ENABLED", false)
+	viper.SetDefault("UPLOAD_LINK_MAX_TTL", 604800) // 7 days
+	viper.SetDefault("UPLOAD_LINK_MAX_FILES", 100)
+	viper.SetDefault("UPLOAD_LINK_WEBHOOK_URL", "")
+	viper.SetDefault("UPLOAD_LINK_WEBHOOK_SECRET", "")
+	viper.SetDefault("UPLOAD_LINK_NOTIFY_HOSTS", []string{})
+	viper.SetDefault("PRESIGN_CALLBACK_HOSTS", []string{})
+	viper.SetDefault("PRESIGN_CALLBACK_SECRET", "")
+	viper.SetDefault("PRESIGN_NOTIFICATIONS", false)
+	viper.SetDefault("PRESIGN_RECONCILE_INTERVAL", 300)
+	viper.SetDefault("FETCH_ALLOWED_HOSTS", []string{})
+	viper.SetDefault("FETCH_ALLOWED_SCHEMES", []string{"https"})
+	viper.SetDefault("FETCH_ALLOW_PRIVATE", false)
+	viper.SetDefault("FETCH_MAX_SIZE", 0)
+	viper.SetDefault("FETCH_SYNC_MAX_SIZE", 32<<20)
+	viper.SetDefault("FETCH_TIMEOUT", 600)
+	viper.SetDefault("IMPORT_ALLOWED_ENDPOINTS", []string{})
+	viper.SetDefault("IMPORT_ALLOWED_SCHEMES", []string{"https"})
+	viper.SetDefault("IMPORT_ALLOW_PRIVATE", false)
+	viper.SetDefault("LIST_METADATA_CONCURRENCY", 16)
+
+	// Transfer limit defaults
+	viper.SetDefault("TRANSFER_MAX_CONCURRENT", 0)
+	viper.SetDefault("TRANSFER_QUEUE_TIMEOUT", 10)
+	viper.SetDefault("TRANSFER_RATE_LIMIT", 0)
+	viper.SetDefault("TRANSFER_GLOBAL_RATE_LIMIT", 0)
+	viper.SetDefault("REQUEST_TIMEOUT", 30)
+	viper.SetDefault("TRANSFER_TIMEOUT", 3600)
+	viper.SetDefault("ROUTE_TIMEOUTS", []string{})
+	viper.SetDefault("RATE_LIMIT_REQUESTS", 0)
+	viper.SetDefault("RATE_LIMIT_WINDOW", 60)
+
+	// Download caching defaults
+	viper.SetDefault("CACHE_CONTROL_DEFAULT", "private, no-cache")
+	viper.SetDefault("CACHE_CONTROL_RULES", "")
+
+	// Share link defaults
+	viper.SetDefault("SHARE_PASSWORD_ENCRYPTION", true)
+
+	// Auth defaults
+	viper.SetDefault("AUTH_VERIFY_PATH", "/api/v1/auth/verify")
+	viper.SetDefault("AUTH_CACHE_TTL", 60)
+	viper.SetDefault("API_KEYS", []string{})
+	viper.SetDefault("SIGNING_KEYS", []string{})
+	viper.SetDefault("SIGNATURE_MAX_SKEW", 300)
+	viper.SetDefault("AUTH_MODE", "service")
+	viper.SetDefault("OIDC_ISSUERS", []string{})
+	viper.SetDefault("OIDC_AUDIENCES", []string{})
+	viper.SetDefault("OIDC_ROLE_CLAIMS", []string{"roles", "groups", "realm_access.roles"})
+	viper.SetDefault("OIDC_ROLE_MAPPING", []string{})
+
+	// Route security defaults
+	viper.SetDefault("ROUTE_SECURITY", []string{
+		"files=authenticated", "uploads=authenticated", "shares=authenticated", "usage=authenticated",
+		"buckets=admin", "admin=admin", "share_links=public", "upload_links=public", "site=public",
+	})
+
+	// RBAC defaults
+	viper.SetDefault("RBAC_ENABLED", false)
+	viper.SetDefault("RBAC_ROLE_SCOPES", []string{"admin=*", "editor=files:read files:write", "viewer=files:read"})
+
+	// Quota defaults
+	viper.SetDefault("QUOTA_ENABLED", false)
+	viper.SetDefault("QUOTA_DEFAULT_BYTES", 0)
+	viper.SetDefault("QUOTA_OVERRIDES", []string{})
+
+	// Tenancy defaults
+	viper.SetDefault("TENANCY_MODE", "")
+	viper.SetDefault("TENANT_CLAIM", "tenant_id")
+	viper.SetDefault("TENANT_BUCKET_PREFIX", "tenant-")
+	viper.SetDefault("HOME_DIRECTORIES", false)
+	viper.SetDefault("HOME_DIRECTORY_PATTERN", "users/{subject}/")
+
+	// Audit defaults
+	viper.SetDefault("AUDIT_ENABLED", true)
+
+	// Event defaults
+	viper.SetDefault("EVENT_LOG", false)
+	viper.SetDefault("EVENT_WEBHOOK_URL", "")
+	viper.SetDefault("EVENT_STREAM_ENABLED", false)
+	viper.SetDefault("KAFKA_BROKERS", []string{})
+	viper.SetDefault("KAFKA_TOPIC", "storage-activity")
+	viper.SetDefault("KAFKA_PARTITION_KEY", "key")
+	viper.SetDefault("KAFKA_CLIENT_ID", "app-minio-api")
+	viper.SetDefault("KAFKA_TLS", false)
+	viper.SetDefault("NATS_URL", "")
+	viper.SetDefault("NATS_SUBJECT", "storage.activity")
+
+	// Shared state defaults
+	viper.SetDefault("REDIS_URL", "")
+	viper.SetDefault("REDIS_KEY_PREFIX", "app-minio-api:")
+	viper.SetDefault("OBJECT_LOCK_TIMEOUT", 10)
+
+	// Logging defaults
+	viper.SetDefault("LOG_FORMAT", "gcp")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_SAMPLE_RATE", 0)
+	viper.SetDefault("LOG_DEBUG_SAMPLE_RATE", 0)
+	viper.SetDefault("LOG_REDACT_KEYS", []string{})
+	viper.SetDefault("LOG_REQUEST_BODIES", false)
+	viper.SetDefault("LOG_BODY_MAX_BYTES", 4096)
+
+	// Error reporting defaults
+	viper.SetDefault("SENTRY_DSN", "")
+	viper.SetDefault("SENTRY_ENVIRONMENT", "")
+	viper.SetDefault("SENTRY_RELEASE", "")
+
+	// Metadata store defaults
+	viper.SetDefault("METADATA_STORE", "minio")
+	viper.SetDefault("METADATA_DB", "")
+	viper.SetDefault("METADATA_DB_BUCKETS", []string{})
+	viper.SetDefault("METADATA_DB_RECONCILE_INTERVAL", 3600)
+
+	// Jobs defaults
+	viper.SetDefault("JOBS_WORKERS", 4)
+	viper.SetDefault("JOBS_MAX_ATTEMPTS", 5)
+
+	// Storage statistics defaults: scan every 6 hours, keep 30 days of history
+	viper.SetDefault("STATS_SCAN_INTERVAL", 21600)
+	viper.SetDefault("STATS_BUCKETS", []string{})
+	viper.SetDefault("STATS_PREFIX_DEPTH", 1)
+	viper.SetDefault("STATS_TOP_N", 20)
+	viper.SetDefault("STATS_HISTORY_LIMIT", 120)
+
+	// Cost defaults: amortized disk for MinIO, list prices for R2
+	viper.SetDefault("COST_MINIO_PRICES", "storage=0.02")
+	viper.SetDefault("COST_R2_PRICES", "storage=0.015,class_a=4.50,class_b=0.36")
+	viper.SetDefault("COST_R2_BUCKETS", []string{})
+
+	// Search defaults: refresh the index every 5 minutes
+	viper.SetDefault("SEARCH_INDEX_INTERVAL", 300)
+	viper.SetDefault("SEARCH_BUCKETS", []string{})
+	viper.SetDefault("SEARCH_INDEX_CONCURRENCY", 8)
+	viper.SetDefault("CONTENT_INDEX", "")
+	viper.SetDefault("CONTENT_INDEX_NAME", "files")
+	viper.SetDefault("CONTENT_INDEX_MAX_SIZE", 50<<20)
+	viper.SetDefault("MODERATION_URL", "")
+	viper.SetDefault("MODERATION_CONTENT_TYPES", []string{"image/*", "video/*"})
+	viper.SetDefault("MODERATION_THRESHOLD", 0.8)
+	viper.SetDefault("MODERATION_MAX_SIZE", 20<<20)
+	viper.SetDefault("MODERATION_QUARANTINE", false)
+	viper.SetDefault("MODERATION_QUARANTINE_PREFIX", "quarantine/")
+	viper.SetDefault("HLS_TRANSCODER", "")
+	viper.SetDefault("HLS_FFMPEG_PATH", "ffmpeg")
+	viper.SetDefault("HLS_FFPROBE_PATH", "ffprobe")
+	viper.SetDefault("HLS_TRANSCODER_URL", "")
+	viper.SetDefault("HLS_RENDITIONS", []string{"1080:5000", "720:2800", "480:1400", "360:800"})
+	viper.SetDefault("HLS_SEGMENT_SECONDS", 6)
+	viper.SetDefault("HLS_MAX_SIZE", 1<<30)
+
+	// Cleanup defaults: daily at 03:00 UTC, no temp prefixes
+	viper.SetDefault("CLEANUP_SCHEDULE", "0 3 * * *")
+	viper.SetDefault("CLEANUP_TEMP_PREFIXES", []string{})
+	viper.SetDefault("CLEANUP_TEMP_MAX_AGE", 604800)     // 7 days
+	viper.SetDefault("CLEANUP_MULTIPART_MAX_AGE", 86400) // 1 day
+	viper.SetDefault("CLEANUP_SHARE_RETENTION", 2592000) // 30 days
+	viper.SetDefault("CLEANUP_DERIVED", true)
+
+	// Backup defaults: disabled; when enabled, nightly at 02:00 UTC keeping 7
+	viper.SetDefault("BACKUP_PROVIDER", "")
+	viper.SetDefault("BACKUP_SCHEDULE", "0 2 * * *")
+	viper.SetDefault("BACKUP_RETENTION", 7)
+
+	// Failover defaults
+	viper.SetDefault("FAILOVER_ENABLED", false)
+	viper.SetDefault("FAILOVER_FAILURE_THRESHOLD", 3)
+	viper.SetDefault("FAILOVER_RECOVERY_THRESHOLD", 3)
+	viper.SetDefault("FAILOVER_PROBE_INTERVAL", 10)
+
+	// Idempotency defaults
+	viper.SetDefault("IDEMPOTENCY_TTL", 86400)
+
+	// Request validation defaults
+	viper.SetDefault("REQUEST_VALIDATION", true)
+
+	// gRPC defaults (disabled)
+	viper.SetDefault("GRPC_PORT", "")
+
+	// TLS defaults (disabled)
+	viper.SetDefault("TLS_ACME_DOMAINS", []string{})
+	viper.SetDefault("TLS_ACME_CACHE_DIR", "./certs")
+	viper.SetDefault("TLS_HTTP_PORT", "")
+
+	// Secrets manager defaults
+	viper.SetDefault("SECRETS_PROVIDER", "")
+	viper.SetDefault("SECRETS_REFRESH_INTERVAL", 300)
+	viper.SetDefault("SECRETS_AWS_REGION", "us-east-1")
+
+	// Client IP defaults: no proxy is trusted until configured
+	viper.SetDefault("TRUSTED_PROXIES", []string{})
+	viper.SetDefault("REMOTE_IP_HEADERS", []string{"X-Forwarded-For", "X-Real-IP"})
+	viper.SetDefault("TRUSTED_PLATFORM", "")
+
+	// Every optional subsystem runs unless FEATURES narrows them down
+	viper.SetDefault("FEATURES", []string{})
+
+	// /api/v1 is not deprecated until announced
+	viper.SetDefault("API_V1_DEPRECATED_AT", "")
+	viper.SetDefault("API_V1_SUNSET_AT", "")
+	viper.SetDefault("API_V1_DEPRECATED_MODULES", []string{})
+
+	// Compression defaults: everything but upload responses, which are small
+	viper.SetDefault("COMPRESSION_GROUPS", []string{"files", "shares", "usage", "buckets", "admin", "share_links", "site"})
+	viper.SetDefault("COMPRESSION_LEVEL", 0)
+	viper.SetDefault("COMPRESSION_MIN_SIZE", 1024)
+
+	// Download redirects are off; every route streams through the API
+	viper.SetDefault("DOWNLOAD_REDIRECT_GROUPS", []string{})
+	viper.SetDefault("DOWNLOAD_REDIRECT_EXPIRY", 300)
+	viper.SetDefault("DOWNLOAD_PUBLIC_URL", "")
+	// Any +xml type can carry XHTML script, SVG included
+	viper.SetDefault("DOWNLOAD_RISKY_TYPES", []string{
+		"text/html", "*/*+xml", "text/xml", "application/xml",
+		"text/javascript", "application/javascript", "application/x-shockwave-flash",
+	})
+	viper.SetDefault("DOWNLOAD_SANITIZE_SVG", false)
+
+	// CORS defaults
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "https://drive-two.junistudio.org"})
+	viper.SetDefault("CORS_ALLOWED_METHODS", []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
+	viper.SetDefault("CORS_ALLOWED_HEADERS", []string{
+		"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-Key",
+		"X-Requested-With", "X-Request-Id", "X-Correlation-Id", "X-Upload-Id", "X-Content-SHA256", "Content-MD5",
+		"X-Share-Password", "X-SSE-Customer-Key", "X-SSE-Customer-Key-MD5", "Idempotency-Key", "If-None-Match",
+		"If-Modified-Since", "Range", "If-Range",
+	})
+	viper.SetDefault("CORS_EXPOSED_HEADERS", []string{
+		"Content-Length", "Content-Type", "X-Request-Id", "X-Correlation-Id", "ETag", "Last-Modified",
+		"X-Continuation-Token", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+		"Accept-Ranges", "Content-Range",
+	})
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
+	viper.SetDefault("CORS_MAX_AGE", 86400) // 24 hours
+
+	// Static website defaults: long-lived caching for assets, revalidated HTML so deploys show up
+	viper.SetDefault("SITE_ENABLED", false)
+	viper.SetDefault("SITE_BUCKET_NAME", "")
+	viper.SetDefault("SITE_PREFIX", "")
+	viper.SetDefault("SITE_INDEX_DOCUMENT", "index.html")
+	viper.SetDefault("SITE_ERROR_DOCUMENT", "404.html")
+	viper.SetDefault("SITE_CACHE_CONTROL", "public, max-age=31536000, immutable")
+	viper.SetDefault("SITE_CACHE_CONTROL_RULES", "text/html=public, no-cache")
 }
 
 func bindEnvVars() {
@@ -90,18 +921,314 @@ func bindEnvVars() {
 	_ = viper.BindEnv("MINIO_SECRET_KEY")
 	_ = viper.BindEnv("MINIO_USE_SSL")
 	_ = viper.BindEnv("MINIO_BUCKET_NAME")
-}
 
+	// Storage provider
+	_ = viper.BindEnv("STORAGE_PROVIDER")
+	_ = viper.BindEnv("S3_ENDPOINT")
+	_ = viper.BindEnv("S3_REGION")
+	_ = viper.BindEnv("S3_ACCESS_KEY_ID")
+	_ = viper.BindEnv("S3_SECRET_ACCESS_KEY")
+	_ = viper.BindEnv("S3_SESSION_TOKEN")
+	_ = viper.BindEnv("S3_USE_SSL")
+	_ = viper.BindEnv("S3_FORCE_PATH_STYLE")
+	_ = viper.BindEnv("STORAGE_BUCKET_PROVIDERS")
+	_ = viper.BindEnv("AZURE_STORAGE_ACCOUNT")
+	_ = viper.BindEnv("AZURE_STORAGE_KEY")
+	_ = viper.BindEnv("AZURE_STORAGE_ENDPOINT")
+	_ = viper.BindEnv("STORAGE_FS_ROOT")
+	_ = viper.BindEnv("STORAGE_HTTP_MAX_IDLE_CONNS")
+	_ = viper.BindEnv("STORAGE_HTTP_MAX_IDLE_CONNS_PER_HOST")
+	_ = viper.BindEnv("STORAGE_HTTP_MAX_CONNS_PER_HOST")
+	_ = viper.BindEnv("STORAGE_HTTP_IDLE_CONN_TIMEOUT")
+	_ = viper.BindEnv("STORAGE_HTTP_DIAL_TIMEOUT")
+	_ = viper.BindEnv("STORAGE_HTTP_KEEP_ALIVE")
+	_ = viper.BindEnv("STORAGE_HTTP_TLS_HANDSHAKE_TIMEOUT")
+	_ = viper.BindEnv("STORAGE_HTTP_RESPONSE_HEADER_TIMEOUT")
+	_ = viper.BindEnv("STORAGE_RETRY_MAX_ATTEMPTS")
+	_ = viper.BindEnv("STORAGE_RETRY_BASE_DELAY_MS")
+	_ = viper.BindEnv("STORAGE_RETRY_MAX_DELAY_MS")
+	_ = viper.BindEnv("STORAGE_RETRY_BUDGETS")
+
+	// Encryption
+	_ = viper.BindEnv("SSE_MODE")
+	_ = viper.BindEnv("SSE_KMS_KEY_ID")
+	_ = viper.BindEnv("ENVELOPE_ENCRYPTION")
+	_ = viper.BindEnv("ENVELOPE_MASTER_KEYS")
+	_ = viper.BindEnv("ENVELOPE_KEY_ID")
+
+	// Uploads
+	_ = viper.BindEnv("MAX_FILE_SIZE")
+	_ = viper.BindEnv("UPLOAD_ALLOWED_TYPES")
+	_ = viper.BindEnv("UPLOAD_DENIED_TYPES")
+	_ = viper.BindEnv("UPLOAD_MIME_TYPES")
+	_ = viper.BindEnv("UPLOAD_COLLISION_STRATEGY")
+	_ = viper.BindEnv("UPLOAD_CHECKSUMS")
+	_ = viper.BindEnv("UPLOAD_DEDUPE")
+	_ = viper.BindEnv("UPLOAD_EXTRACT_EXIF")
+	_ = viper.BindEnv("UPLOAD_STRIP_GPS")
+	_ = viper.BindEnv("UPLOAD_BATCH_MAX_FILES")
+	_ = viper.BindEnv("UPLOAD_BATCH_CONCURRENCY")
+	_ = viper.BindEnv("UPLOAD_PART_SIZE")
+	_ = viper.BindEnv("UPLOAD_CONCURRENCY")
+	_ = viper.BindEnv("UPLOAD_SEND_CONTENT_MD5")
+	_ = viper.BindEnv("UPLOAD_MULTIPART_THRESHOLD")
+	_ = viper.BindEnv("UPLOAD_MAX_PART_BUFFER")
+	_ = viper.BindEnv("UPLOAD_LINKS_ENABLED")
+	_ = viper.BindEnv("UPLOAD_LINK_MAX_TTL")
+	_ = viper.BindEnv("UPLOAD_LINK_MAX_FILES")
+	_ = viper.BindEnv("UPLOAD_LINK_WEBHOOK_URL")
+	_ = viper.BindEnv("UPLOAD_LINK_WEBHOOK_SECRET")
+	_ = viper.BindEnv("UPLOAD_LINK_NOTIFY_HOSTS")
+	_ = viper.BindEnv("PRESIGN_CALLBACK_HOSTS")
+	_ = viper.BindEnv("PRESIGN_CALLBACK_SECRET")
+	_ = viper.BindEnv("PRESIGN_NOTIFICATIONS")
+	_ = viper.BindEnv("PRESIGN_RECONCILE_INTERVAL")
+	_ = viper.BindEnv("FETCH_ALLOWED_HOSTS")
+	_ = viper.BindEnv("FETCH_ALLOWED_SCHEMES")
+	_ = viper.BindEnv("FETCH_ALLOW_PRIVATE")
+	_ = viper.BindEnv("FETCH_MAX_SIZE")
+	_ = viper.BindEnv("FETCH_SYNC_MAX_SIZE")
+	_ = viper.BindEnv("FETCH_TIMEOUT")
+	_ = viper.BindEnv("IMPORT_ALLOWED_ENDPOINTS")
+	_ = viper.BindEnv("IMPORT_ALLOWED_SCHEMES")
+	_ = viper.BindEnv("IMPORT_ALLOW_PRIVATE")
+	_ = viper.BindEnv("LIST_METADATA_CONCURRENCY")
+
+	// Transfer limits
+	_ = viper.BindEnv("TRANSFER_MAX_CONCURRENT")
+	_ = viper.BindEnv("TRANSFER_QUEUE_TIMEOUT")
+	_ = viper.BindEnv("TRANSFER_RATE_LIMIT")
+	_ = viper.BindEnv("TRANSFER_GLOBAL_RATE_LIMIT")
+	_ = viper.BindEnv("REQUEST_TIMEOUT")
+	_ = viper.BindEnv("TRANSFER_TIMEOUT")
+	_ = viper.BindEnv("ROUTE_TIMEOUTS")
+	_ = viper.BindEnv("RATE_LIMIT_REQUESTS")
+	_ = viper.BindEnv("RATE_LIMIT_WINDOW")
+
+	// Download caching
+	_ = viper.BindEnv("CACHE_CONTROL_DEFAULT")
+	_ = viper.BindEnv("CACHE_CONTROL_RULES")
+
+	// Derived variants
+	_ = viper.BindEnv("DERIVED_BUCKET_NAME")
+
+	// Share links
+	_ = viper.BindEnv("SHARE_PASSWORD_ENCRYPTION")
+
+	// Auth
+	_ = viper.BindEnv("AUTH_SERVICE_URL")
+	_ = viper.BindEnv("AUTH_VERIFY_PATH")
+	_ = viper.BindEnv("AUTH_CACHE_TTL")
+	_ = viper.BindEnv("API_KEYS")
+	_ = viper.BindEnv("SIGNING_KEYS")
+	_ = viper.BindEnv("SIGNATURE_MAX_SKEW")
+	_ = viper.BindEnv("AUTH_MODE")
+	_ = viper.BindEnv("OIDC_ISSUERS")
+	_ = viper.BindEnv("OIDC_AUDIENCES")
+	_ = viper.BindEnv("OIDC_ROLE_CLAIMS")
+	_ = viper.BindEnv("OIDC_ROLE_MAPPING")
+
+	// Route security
+	_ = viper.BindEnv("ROUTE_SECURITY")
+
+	// RBAC
+	_ = viper.BindEnv("RBAC_ENABLED")
+	_ = viper.BindEnv("RBAC_ROLE_SCOPES")
+
+	// Quotas
+	_ = viper.BindEnv("QUOTA_ENABLED")
+	_ = viper.BindEnv("QUOTA_DEFAULT_BYTES")
+	_ = viper.BindEnv("QUOTA_OVERRIDES")
+
+	// Tenancy
+	_ = viper.BindEnv("TENANCY_MODE")
+	_ = viper.BindEnv("TENANT_CLAIM")
+	_ = viper.BindEnv("TENANT_BUCKET_PREFIX")
+	_ = viper.BindEnv("HOME_DIRECTORIES")
+	_ = viper.BindEnv("HOME_DIRECTORY_PATTERN")
+
+	// Audit
+	_ = viper.BindEnv("AUDIT_ENABLED")
+	_ = viper.BindEnv("AUDIT_BUCKET_NAME")
 
+	// Events
+	_ = viper.BindEnv("EVENT_LOG")
+	_ = viper.BindEnv("EVENT_WEBHOOK_URL")
+	_ = viper.BindEnv("EVENT_WEBHOOK_SECRET")
+	_ = viper.BindEnv("EVENT_STREAM_ENABLED")
+	_ = viper.BindEnv("KAFKA_BROKERS")
+	_ = viper.BindEnv("KAFKA_TOPIC")
+	_ = viper.BindEnv("KAFKA_PARTITION_KEY")
+	_ = viper.BindEnv("KAFKA_CLIENT_ID")
+	_ = viper.BindEnv("KAFKA_TLS")
+	_ = viper.BindEnv("KAFKA_SASL_USERNAME")
+	_ = viper.BindEnv("KAFKA_SASL_PASSWORD")
+	_ = viper.BindEnv("NATS_URL")
+	_ = viper.BindEnv("NATS_SUBJECT")
+
+	// Shared state
+	_ = viper.BindEnv("REDIS_URL")
+	_ = viper.BindEnv("REDIS_KEY_PREFIX")
+	_ = viper.BindEnv("OBJECT_LOCK_TIMEOUT")
+
+	// Logging
+	_ = viper.BindEnv("LOG_FORMAT")
+	_ = viper.BindEnv("LOG_LEVEL")
+	_ = viper.BindEnv("LOG_SAMPLE_RATE")
+	_ = viper.BindEnv("LOG_DEBUG_SAMPLE_RATE")
+	_ = viper.BindEnv("LOG_REDACT_KEYS")
+	_ = viper.BindEnv("LOG_REQUEST_BODIES")
+	_ = viper.BindEnv("LOG_BODY_MAX_BYTES")
+
+	// Error reporting
+	_ = viper.BindEnv("SENTRY_DSN")
+	_ = viper.BindEnv("SENTRY_ENVIRONMENT")
+	_ = viper.BindEnv("SENTRY_RELEASE")
+
+	// Metadata store
+	_ = viper.BindEnv("METADATA_STORE")
+	_ = viper.BindEnv("METADATA_BUCKET_NAME")
+	_ = viper.BindEnv("METADATA_DB")
+	_ = viper.BindEnv("METADATA_DB_DSN")
+	_ = viper.BindEnv("METADATA_DB_BUCKETS")
+	_ = viper.BindEnv("METADATA_DB_RECONCILE_INTERVAL")
+
+	// Jobs and replication
+	_ = viper.BindEnv("JOBS_WORKERS")
+	_ = viper.BindEnv("JOBS_MAX_ATTEMPTS")
+	_ = viper.BindEnv("STATS_SCAN_INTERVAL")
+	_ = viper.BindEnv("STATS_BUCKETS")
+	_ = viper.BindEnv("STATS_PREFIX_DEPTH")
+	_ = viper.BindEnv("STATS_TOP_N")
+	_ = viper.BindEnv("STATS_HISTORY_LIMIT")
+	_ = viper.BindEnv("COST_MINIO_PRICES")
+	_ = viper.BindEnv("COST_R2_PRICES")
+	_ = viper.BindEnv("COST_R2_BUCKETS")
+	_ = viper.BindEnv("SEARCH_INDEX_INTERVAL")
+	_ = viper.BindEnv("SEARCH_BUCKETS")
+	_ = viper.BindEnv("SEARCH_INDEX_CONCURRENCY")
+	_ = viper.BindEnv("CONTENT_INDEX")
+	_ = viper.BindEnv("CONTENT_INDEX_URL")
+	_ = viper.BindEnv("CONTENT_INDEX_NAME")
+	_ = viper.BindEnv("CONTENT_INDEX_USERNAME")
+	_ = viper.BindEnv("CONTENT_INDEX_PASSWORD")
+	_ = viper.BindEnv("CONTENT_INDEX_MAX_SIZE")
+	_ = viper.BindEnv("MODERATION_URL")
+	_ = viper.BindEnv("MODERATION_API_KEY")
+	_ = viper.BindEnv("MODERATION_CONTENT_TYPES")
+	_ = viper.BindEnv("MODERATION_THRESHOLD")
+	_ = viper.BindEnv("MODERATION_MAX_SIZE")
+	_ = viper.BindEnv("MODERATION_QUARANTINE")
+	_ = viper.BindEnv("MODERATION_QUARANTINE_PREFIX")
+	_ = viper.BindEnv("HLS_TRANSCODER")
+	_ = viper.BindEnv("HLS_FFMPEG_PATH")
+	_ = viper.BindEnv("HLS_FFPROBE_PATH")
+	_ = viper.BindEnv("HLS_TRANSCODER_URL")
+	_ = viper.BindEnv("HLS_TRANSCODER_API_KEY")
+	_ = viper.BindEnv("HLS_RENDITIONS")
+	_ = viper.BindEnv("HLS_SEGMENT_SECONDS")
+	_ = viper.BindEnv("HLS_MAX_SIZE")
+	_ = viper.BindEnv("CLEANUP_SCHEDULE")
+	_ = viper.BindEnv("CLEANUP_TEMP_PREFIXES")
+	_ = viper.BindEnv("CLEANUP_TEMP_MAX_AGE")
+	_ = viper.BindEnv("CLEANUP_MULTIPART_MAX_AGE")
+	_ = viper.BindEnv("CLEANUP_SHARE_RETENTION")
+	_ = viper.BindEnv("CLEANUP_DERIVED")
+	_ = viper.BindEnv("REPLICATION_PROVIDER")
+	_ = viper.BindEnv("BACKUP_PROVIDER")
+	_ = viper.BindEnv("BACKUP_BUCKET_NAME")
+	_ = viper.BindEnv("BACKUP_SOURCE_BUCKET")
+	_ = viper.BindEnv("BACKUP_PREFIX")
+	_ = viper.BindEnv("BACKUP_SCHEDULE")
+	_ = viper.BindEnv("BACKUP_RETENTION")
+	_ = viper.BindEnv("FAILOVER_ENABLED")
+	_ = viper.BindEnv("FAILOVER_FAILURE_THRESHOLD")
+	_ = viper.BindEnv("FAILOVER_RECOVERY_THRESHOLD")
+	_ = viper.BindEnv("FAILOVER_PROBE_INTERVAL")
+
+	// Idempotency
+	_ = viper.BindEnv("IDEMPOTENCY_TTL")
+
+	// Request validation
+	_ = viper.BindEnv("REQUEST_VALIDATION")
+
+	// gRPC
+	_ = viper.BindEnv("GRPC_PORT")
+
+	// Static website
+	_ = viper.BindEnv("SITE_ENABLED")
+	_ = viper.BindEnv("SITE_BUCKET_NAME")
+	_ = viper.BindEnv("SITE_PREFIX")
+	_ = viper.BindEnv("SITE_INDEX_DOCUMENT")
+	_ = viper.BindEnv("SITE_ERROR_DOCUMENT")
+	_ = viper.BindEnv("SITE_CACHE_CONTROL")
+	_ = viper.BindEnv("SITE_CACHE_CONTROL_RULES")
+
+	// TLS
+	_ = viper.BindEnv("TLS_CERT_FILE")
+	_ = viper.BindEnv("TLS_KEY_FILE")
+	_ = viper.BindEnv("TLS_ACME_DOMAINS")
+	_ = viper.BindEnv("TLS_ACME_EMAIL")
+	_ = viper.BindEnv("TLS_ACME_CACHE_DIR")
+	_ = viper.BindEnv("TLS_ACME_DIRECTORY_URL")
+	_ = viper.BindEnv("TLS_HTTP_PORT")
+
+	// Secrets manager
+	_ = viper.BindEnv("SECRETS_PROVIDER")
+	_ = viper.BindEnv("SECRETS_REFRESH_INTERVAL")
+	_ = viper.BindEnv("VAULT_ADDR")
+	_ = viper.BindEnv("VAULT_TOKEN")
+	_ = viper.BindEnv("VAULT_SECRET_PATH")
+	_ = viper.BindEnv("SECRETS_AWS_REGION")
+	_ = viper.BindEnv("SECRETS_AWS_SECRET_ID")
+	_ = viper.BindEnv("SECRETS_GCP_SECRET")
+
+	// Client IP
+	_ = viper.BindEnv("TRUSTED_PROXIES")
+	_ = viper.BindEnv("REMOTE_IP_HEADERS")
+	_ = viper.BindEnv("TRUSTED_PLATFORM")
+
+	// Features
+	_ = viper.BindEnv("FEATURES")
+
+	// API versions
+	_ = viper.BindEnv("API_V1_DEPRECATED_AT")
+	_ = viper.BindEnv("API_V1_SUNSET_AT")
+	_ = viper.BindEnv("API_V1_DEPRECATED_MODULES")
+
+	// Compression
+	_ = viper.BindEnv("COMPRESSION_GROUPS")
+	_ = viper.BindEnv("COMPRESSION_LEVEL")
+	_ = viper.BindEnv("COMPRESSION_MIN_SIZE")
+	_ = viper.BindEnv("DOWNLOAD_REDIRECT_GROUPS")
+	_ = viper.BindEnv("DOWNLOAD_REDIRECT_EXPIRY")
+	_ = viper.BindEnv("DOWNLOAD_PUBLIC_URL")
+	_ = viper.BindEnv("DOWNLOAD_RISKY_TYPES")
+	_ = viper.BindEnv("DOWNLOAD_SANITIZE_SVG")
+
+	// CORS
+	_ = viper.BindEnv("CORS_ALLOWED_ORIGINS")
+	_ = viper.BindEnv("CORS_ALLOWED_METHODS")
+	_ = viper.BindEnv("CORS_ALLOWED_HEADERS")
+	_ = viper.BindEnv("CORS_EXPOSED_HEADERS")
+	_ = viper.BindEnv("CORS_ALLOW_CREDENTIALS")
+	_ = viper.BindEnv("CORS_MAX_AGE")
+}
 
 func InitMinioClient(cfg *Config) (*minio.Client, error) {
 	// Initialize MinIO client
 	// Simply combine the endpoint and port as provided in the config
 	endpoint := cfg.MinioEndpoint + ":" + cfg.MinioPort
 
+	transport, err := cfg.StorageTransport(cfg.MinioUseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO transport: %w", err)
+	}
 	client, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.MinioAccessKey, cfg.MinioSecretKey, ""),
 		Secure: cfg.MinioUseSSL,
+		// Tag calls with the IDs of the API request they serve
+		Transport: requestid.Transport(transport),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
@@ -126,4 +1253,4 @@ func InitMinioClient(cfg *Config) (*minio.Client, error) {
 	}
 
 	return client, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,32 @@
+package config
+
+import "strings"
+
+// Optional subsystems that FEATURES selects
+const (
+	FeatureThumbnails = "thumbnails" // image resizing at /files/{filename}/thumbnail
+	FeatureSharing    = "sharing"    // share links
+	FeatureAudit      = "audit"      // audit log, also requires AUDIT_ENABLED
+	FeatureStats      = "stats"      // storage statistics scans
+	FeatureSearch     = "search"     // file search index
+	FeatureCleanup    = "cleanup"    // scheduled cleanup, also requires CLEANUP_SCHEDULE
+	FeatureAnalytics  = "analytics"  // download counts per object
+	FeatureCosts      = "costs"      // cost estimates at /admin/cost-report
+)
+
+// Features lists every optional subsystem
+var Features = []string{FeatureThumbnails, FeatureSharing, FeatureAudit, FeatureStats, FeatureSearch, FeatureCleanup, FeatureAnalytics, FeatureCosts}
+
+// FeatureEnabled reports whether an optional subsystem may run. Every feature
+// is enabled while FEATURES is unset; "none" disables them all.
+func (c *Config) FeatureEnabled(name string) bool {
+	if len(c.Features) == 0 {
+		return true
+	}
+	for _, feature := range c.Features {
+		if strings.ToLower(strings.TrimSpace(feature)) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package config
+
+import "errors"
+
+// Settings are the values Reload applies to a running server. Everything else,
+// such as storage providers, ports, auth and encryption, takes a restart.
+type Settings struct {
+	CORSAllowedOrigins      []string `json:"corsAllowedOrigins"`
+	MaxFileSize             int64    `json:"maxFileSize"`
+	TransferRateLimit       int64    `json:"transferRateLimit"`
+	TransferGlobalRateLimit int64    `json:"transferGlobalRateLimit"`
+	LogLevel                string   `json:"logLevel"`
+	QuotaDefaultBytes       int64    `json:"quotaDefaultBytes"`
+	QuotaOverrides          []string `json:"quotaOverrides"`
+}
+
+// Live returns the current reloadable settings: the last reloaded values, or
+// the ones loaded at startup
+func (c *Config) Live() Settings {
+	if s := c.live.Load(); s != nil {
+		return *s
+	}
+	return c.settings()
+}
+
+func (c *Config) settings() Settings {
+	return Settings{
+		CORSAllowedOrigins:      c.CORSAllowedOrigins,
+		MaxFileSize:             c.MaxFileSize,
+		TransferRateLimit:       c.TransferRateLimit,
+		TransferGlobalRateLimit: c.TransferGlobalRateLimit,
+		LogLevel:                c.LogLevel,
+		QuotaDefaultBytes:       c.QuotaDefaultBytes,
+		QuotaOverrides:          c.QuotaOverrides,
+	}
+}
+
+// OnReload registers fn to apply settings after each successful Reload.
+// Register before the server starts; Reload reports fn's error.
+func (c *Config) OnReload(fn func(Settings) error) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+// Reload reads the environment and .env file again and, if the result is
+// valid, publishes the new Settings and notifies OnReload listeners. An
+// invalid configuration is rejected as a whole and the current one kept.
+func (c *Config) Reload() (Settings, error) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	next, err := LoadConfig()
+	if err != nil {
+		return c.Live(), err
+	}
+	settings := next.settings()
+	c.live.Store(&settings)
+
+	var errs []error
+	for _, apply := range c.listeners {
+		if err := apply(settings); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return settings, errors.Join(errs...)
+}
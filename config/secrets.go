@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// secretFields are the credentials a secrets manager may supply
+func (c *Config) secretFields() map[string]*string {
+	return map[string]*string{
+		"MINIO_ACCESS_KEY":     &c.MinioAccessKey,
+		"MINIO_SECRET_KEY":     &c.MinioSecretKey,
+		"S3_ACCESS_KEY_ID":     &c.S3AccessKeyID,
+		"S3_SECRET_ACCESS_KEY": &c.S3SecretAccessKey,
+		"S3_SESSION_TOKEN":     &c.S3SessionToken,
+		"AZURE_STORAGE_KEY":    &c.AzureAccountKey,
+	}
+}
+
+// ApplySecrets overrides storage credentials with the values of a secret and
+// validates the result. Keys other than credentials are ignored, so the secret
+// may be shared with other services.
+//
+// Credentials are only read when storage clients are created, so call this
+// before rebuilding them and not concurrently with it.
+func (c *Config) ApplySecrets(values map[string]string) error {
+	fields := c.secretFields()
+	applied := 0
+	for key, value := range values {
+		if field, ok := fields[key]; ok {
+			*field = value
+			applied++
+		}
+	}
+	if applied == 0 {
+		known := make([]string, 0, len(fields))
+		for key := range fields {
+			known = append(known, key)
+		}
+		sort.Strings(known)
+		return fmt.Errorf("secret holds none of %v", known)
+	}
+	c.secretsApplied = true
+	return c.Validate()
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// StorageTransport returns the HTTP transport of the S3 storage clients: the
+// minio-go defaults with the STORAGE_HTTP_* connection pool and timeouts
+func (c *Config) StorageTransport(secure bool) (*http.Transport, error) {
+	transport, err := minio.DefaultTransport(secure)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = (&net.Dialer{
+		Timeout:   seconds(c.StorageHTTPDialTimeout),
+		KeepAlive: seconds(c.StorageHTTPKeepAlive),
+	}).DialContext
+	transport.MaxIdleConns = c.StorageHTTPMaxIdleConns
+	transport.MaxIdleConnsPerHost = c.StorageHTTPMaxIdleConnsPerHost
+	transport.MaxConnsPerHost = c.StorageHTTPMaxConnsPerHost
+	transport.IdleConnTimeout = seconds(c.StorageHTTPIdleConnTimeout)
+	transport.TLSHandshakeTimeout = seconds(c.StorageHTTPTLSHandshakeTimeout)
+	transport.ResponseHeaderTimeout = seconds(c.StorageHTTPResponseHeaderTimeout)
+	return transport, nil
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// ValidationError lists every problem found in a configuration, so all of
+// them can be fixed before the next start instead of one per restart
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the credentials each enabled storage provider needs, enum
+// settings and numeric ranges. It returns a *ValidationError listing every
+// problem, or nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.require(c.ServerPort != "", "SERVER_PORT is required")
+	v.port("SERVER_PORT", c.ServerPort)
+	if c.GRPCPort != "" {
+		v.port("GRPC_PORT", c.GRPCPort)
+		v.require(c.GRPCPort != c.ServerPort, "GRPC_PORT must differ from SERVER_PORT (%s)", c.ServerPort)
+	}
+	v.require(c.MinioBucketName != "", "MINIO_BUCKET_NAME is required")
+
+	// TLS
+	v.require((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	v.require(c.TLSCertFile == "" || len(c.TLSACMEDomains) == 0, "TLS_CERT_FILE and TLS_ACME_DOMAINS are mutually exclusive")
+	if len(c.TLSACMEDomains) > 0 {
+		v.require(c.TLSACMECacheDir != "", "TLS_ACME_CACHE_DIR is required with TLS_ACME_DOMAINS")
+	}
+	if c.TLSHTTPPort != "" {
+		v.require(c.TLSEnabled(), "TLS_HTTP_PORT requires TLS_CERT_FILE or TLS_ACME_DOMAINS")
+		v.port("TLS_HTTP_PORT", c.TLSHTTPPort)
+		v.require(c.TLSHTTPPort != c.ServerPort, "TLS_HTTP_PORT must differ from SERVER_PORT (%s)", c.ServerPort)
+	}
+
+	// Storage HTTP connections
+	v.nonNegative("STORAGE_HTTP_MAX_IDLE_CONNS", int64(c.StorageHTTPMaxIdleConns))
+	v.nonNegative("STORAGE_HTTP_MAX_IDLE_CONNS_PER_HOST", int64(c.StorageHTTPMaxIdleConnsPerHost))
+	v.nonNegative("STORAGE_HTTP_MAX_CONNS_PER_HOST", int64(c.StorageHTTPMaxConnsPerHost))
+	v.nonNegative("STORAGE_HTTP_IDLE_CONN_TIMEOUT", int64(c.StorageHTTPIdleConnTimeout))
+	v.nonNegative("STORAGE_HTTP_DIAL_TIMEOUT", int64(c.StorageHTTPDialTimeout))
+	v.nonNegative("STORAGE_HTTP_KEEP_ALIVE", int64(c.StorageHTTPKeepAlive))
+	v.nonNegative("STORAGE_HTTP_TLS_HANDSHAKE_TIMEOUT", int64(c.StorageHTTPTLSHandshakeTimeout))
+	v.nonNegative("STORAGE_HTTP_RESPONSE_HEADER_TIMEOUT", int64(c.StorageHTTPResponseHeaderTimeout))
+	v.positive("STORAGE_RETRY_MAX_ATTEMPTS", int64(c.StorageRetryMaxAttempts))
+	v.nonNegative("STORAGE_RETRY_BASE_DELAY_MS", int64(c.StorageRetryBaseDelayMs))
+	v.require(c.StorageRetryMaxDelayMs >= c.StorageRetryBaseDelayMs,
+		"STORAGE_RETRY_MAX_DELAY_MS (%d) must not be below STORAGE_RETRY_BASE_DELAY_MS (%d)", c.StorageRetryMaxDelayMs, c.StorageRetryBaseDelayMs)
+	for _, entry := range c.StorageRetryBudgets {
+		op, seconds, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		var n int
+		if _, err := fmt.Sscan(seconds, &n); !ok || err != nil || fmt.Sprint(n) != seconds || n <= 0 {
+			v.add("STORAGE_RETRY_BUDGETS entry %q must be operation=seconds", entry)
+			continue
+		}
+		v.oneOf("STORAGE_RETRY_BUDGETS operation", op, "stat", "get", "list", "remove", "list_buckets", "bucket_exists")
+	}
+
+	// Client IP
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			v.add("TRUSTED_PROXIES entry %q must be an IP address or CIDR", proxy)
+		}
+	}
+
+	// Storage providers: the primary, per-bucket overrides and the replica
+	providers := []string{c.StorageProvider}
+	for _, entry := range c.StorageBucketProviders {
+		pattern, provider, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || pattern == "" || provider == "" {
+			v.add("STORAGE_BUCKET_PROVIDERS entry %q must be bucket=provider", entry)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	if c.ReplicationProvider != "" {
+		providers = append(providers, c.ReplicationProvider)
+	} else {
+		v.require(!c.FailoverEnabled, "FAILOVER_ENABLED requires REPLICATION_PROVIDER")
+	}
+	if c.BackupProvider != "" {
+		providers = append(providers, c.BackupProvider)
+	}
+	// Credentials from SECRETS_PROVIDER are checked once ApplySecrets has run
+	credentials := c.SecretsProvider == "" || c.secretsApplied
+	checked := map[string]bool{}
+	for _, provider := range providers {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" {
+			provider = "minio"
+		}
+		if !checked[provider] {
+			checked[provider] = true
+			c.validateProvider(v, provider, credentials)
+		}
+	}
+
+	// Encryption
+	switch strings.ToLower(strings.TrimSpace(c.SSEMode)) {
+	case "", "none", "sse-s3":
+	case "sse-kms":
+		v.require(c.SSEKMSKeyID != "", "SSE_KMS_KEY_ID is required when SSE_MODE is sse-kms")
+	default:
+		v.add("SSE_MODE %q must be sse-s3, sse-kms or empty", c.SSEMode)
+	}
+	if c.EnvelopeEncryption {
+		v.require(len(c.EnvelopeMasterKeys) > 0, "ENVELOPE_MASTER_KEYS is required when ENVELOPE_ENCRYPTION is enabled")
+	}
+
+	// Uploads and transfers
+	v.require(c.MaxFileSize >= 0, "MAX_FILE_SIZE must be 0 (unlimited) or a positive number of bytes, got %d", c.MaxFileSize)
+	v.oneOf("UPLOAD_COLLISION_STRATEGY", strings.ToLower(strings.TrimSpace(c.UploadCollisionStrategy)), "overwrite", "suffix", "uuid", "reject")
+	v.positive("LIST_METADATA_CONCURRENCY", int64(c.ListMetadataConcurrency))
+	v.positive("UPLOAD_BATCH_MAX_FILES", int64(c.UploadBatchMaxFiles))
+	v.positive("UPLOAD_BATCH_CONCURRENCY", int64(c.UploadBatchConcurrency))
+	v.require(c.UploadPartSize == 0 || (c.UploadPartSize >= MinUploadPartSize && c.UploadPartSize <= MaxUploadPartSize),
+		"UPLOAD_PART_SIZE must be 0 (provider default) or between %d and %d bytes, got %d", MinUploadPartSize, MaxUploadPartSize, c.UploadPartSize)
+	v.nonNegative("UPLOAD_CONCURRENCY", int64(c.UploadConcurrency))
+	v.require(c.UploadMultipartThreshold >= 0 && c.UploadMultipartThreshold <= MaxUploadPartSize,
+		"UPLOAD_MULTIPART_THRESHOLD must be between 0 and %d bytes, got %d", MaxUploadPartSize, c.UploadMultipartThreshold)
+	v.positive("UPLOAD_MAX_PART_BUFFER", c.UploadMaxPartBuffer)
+	if c.UploadLinksEnabled {
+		v.positive("UPLOAD_LINK_MAX_TTL", c.UploadLinkMaxTTL)
+		v.positive("UPLOAD_LINK_MAX_FILES", int64(c.UploadLinkMaxFiles))
+		v.require(c.UploadLinkWebhookURL == "" || strings.HasPrefix(c.UploadLinkWebhookURL, "http://") || strings.HasPrefix(c.UploadLinkWebhookURL, "https://"),
+			"UPLOAD_LINK_WEBHOOK_URL %q must be an http:// or https:// URL", c.UploadLinkWebhookURL)
+	}
+	if len(c.FetchAllowedHosts) > 0 {
+		for _, scheme := range c.FetchAllowedSchemes {
+			v.oneOf("FETCH_ALLOWED_SCHEMES", scheme, "http", "https")
+		}
+		v.nonNegative("FETCH_MAX_SIZE", c.FetchMaxSize)
+		v.nonNegative("FETCH_SYNC_MAX_SIZE", c.FetchSyncMaxSize)
+		v.positive("FETCH_TIMEOUT", int64(c.FetchTimeout))
+	}
+	for _, scheme := range c.ImportAllowedSchemes {
+		v.oneOf("IMPORT_ALLOWED_SCHEMES", scheme, "http", "https")
+	}
+	v.nonNegative("TRANSFER_MAX_CONCURRENT", int64(c.TransferMaxConcurrent))
+	v.nonNegative("TRANSFER_QUEUE_TIMEOUT", int64(c.TransferQueueTimeout))
+	v.nonNegative("TRANSFER_RATE_LIMIT", c.TransferRateLimit)
+	v.nonNegative("TRANSFER_GLOBAL_RATE_LIMIT", c.TransferGlobalRateLimit)
+	v.nonNegative("REQUEST_TIMEOUT", int64(c.RequestTimeout))
+	v.nonNegative("TRANSFER_TIMEOUT", int64(c.TransferTimeout))
+	v.nonNegative("RATE_LIMIT_REQUESTS", int64(c.RateLimitRequests))
+	if c.RateLimitRequests > 0 {
+		v.positive("RATE_LIMIT_WINDOW", int64(c.RateLimitWindow))
+	}
+	// Module names are checked when the routes are mounted
+	for _, entry := range c.RouteTimeouts {
+		_, seconds, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		var n int
+		if _, err := fmt.Sscan(seconds, &n); !ok || err != nil || fmt.Sprint(n) != seconds || n < 0 {
+			v.add("ROUTE_TIMEOUTS entry %q must be module=seconds", entry)
+		}
+	}
+
+	v.require(c.CompressionLevel >= 0 && c.CompressionLevel <= 9, "COMPRESSION_LEVEL must be between 1 and 9, or 0 for the default, got %d", c.CompressionLevel)
+	v.nonNegative("COMPRESSION_MIN_SIZE", int64(c.CompressionMinSize))
+	v.require(c.DownloadRedirectExpiry > 0 && c.DownloadRedirectExpiry <= 7*24*3600,
+		"DOWNLOAD_REDIRECT_EXPIRY must be between 1 second and 7 days, got %d", c.DownloadRedirectExpiry)
+	v.require(c.DownloadPublicURL == "" || strings.HasPrefix(c.DownloadPublicURL, "http://") || strings.HasPrefix(c.DownloadPublicURL, "https://"),
+		"DOWNLOAD_PUBLIC_URL %q must be an http:// or https:// URL", c.DownloadPublicURL)
+	v.nonNegative("CORS_MAX_AGE", int64(c.CORSMaxAge))
+	v.require(!c.CORSAllowCredentials || !slices.Contains(c.CORSAllowedOrigins, "*"),
+		"CORS_ALLOWED_ORIGINS cannot contain * while CORS_ALLOW_CREDENTIALS is enabled; list the origins or disable credentials")
+
+	// Features
+	for _, feature := range c.Features {
+		v.oneOf("FEATURES entry", strings.ToLower(strings.TrimSpace(feature)), append([]string{"none"}, Features...)...)
+	}
+	v.require(len(c.Features) <= 1 || !c.FeatureEnabled("none"), "FEATURES cannot combine none with other features")
+	if _, _, err := c.APIV1Retirement(); err != nil {
+		v.add("%s", err)
+	}
+
+	// Auth, quotas and tenancy
+	v.nonNegative("AUTH_CACHE_TTL", int64(c.AuthCacheTTL))
+	v.positive("SIGNATURE_MAX_SKEW", int64(c.SignatureMaxSkew))
+	v.oneOf("AUTH_MODE", c.AuthMode, "service", "oidc")
+	if c.AuthMode == "oidc" {
+		v.require(len(c.OIDCIssuers) > 0, "OIDC_ISSUERS is required when AUTH_MODE is oidc")
+		v.require(len(c.OIDCAudiences) > 0, "OIDC_AUDIENCES is required when AUTH_MODE is oidc")
+		for _, issuer := range c.OIDCIssuers {
+			issuer = strings.TrimSpace(issuer)
+			v.require(strings.HasPrefix(issuer, "http://") || strings.HasPrefix(issuer, "https://"),
+				"OIDC_ISSUERS entry %q must be an http:// or https:// URL", issuer)
+		}
+	}
+	v.require(c.AuthServiceURL == "" || strings.HasPrefix(c.AuthServiceURL, "http://") || strings.HasPrefix(c.AuthServiceURL, "https://"),
+		"AUTH_SERVICE_URL %q must be an http:// or https:// URL", c.AuthServiceURL)
+	v.nonNegative("QUOTA_DEFAULT_BYTES", c.QuotaDefaultBytes)
+	v.oneOf("TENANCY_MODE", c.TenancyMode, "", "prefix", "bucket")
+	if c.TenancyMode != "" {
+		v.require(c.TenantClaim != "", "TENANT_CLAIM is required when TENANCY_MODE is set")
+	}
+	v.require(strings.Contains(c.HomeDirectoryPattern, "{subject}") && strings.HasSuffix(c.HomeDirectoryPattern, "/") && !strings.HasPrefix(c.HomeDirectoryPattern, "/"),
+		"HOME_DIRECTORY_PATTERN %q must contain {subject} and end, but not start, with /", c.HomeDirectoryPattern)
+
+	// Logging
+	v.oneOf("LOG_FORMAT", strings.ToLower(c.LogFormat), "", "gcp", "json", "console")
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "", "trace", "debug", "info", "warn", "error")
+	if c.LogRequestBodies {
+		v.positive("LOG_BODY_MAX_BYTES", c.LogBodyMaxBytes)
+	}
+
+	// Background work and failover
+	v.oneOf("METADATA_STORE", c.MetadataStore, "", "object", "minio", "memory")
+	v.oneOf("METADATA_DB", c.MetadataDB, "", "sqlite", "postgres")
+	v.require(c.MetadataDB == "" || c.MetadataDBDSN != "", "METADATA_DB_DSN is required when METADATA_DB is set")
+	v.nonNegative("METADATA_DB_RECONCILE_INTERVAL", int64(c.MetadataDBReconcileInterval))
+	v.nonNegative("PRESIGN_RECONCILE_INTERVAL", int64(c.PresignReconcileInterval))
+	v.positive("JOBS_WORKERS", int64(c.JobsWorkers))
+	v.positive("JOBS_MAX_ATTEMPTS", int64(c.JobsMaxAttempts))
+	v.nonNegative("STATS_SCAN_INTERVAL", int64(c.StatsScanInterval))
+	v.positive("STATS_PREFIX_DEPTH", int64(c.StatsPrefixDepth))
+	v.positive("STATS_TOP_N", int64(c.StatsTopN))
+	v.positive("STATS_HISTORY_LIMIT", int64(c.StatsHistoryLimit))
+	v.nonNegative("SEARCH_INDEX_INTERVAL", int64(c.SearchIndexInterval))
+	v.positive("SEARCH_INDEX_CONCURRENCY", int64(c.SearchIndexConcurrency))
+	v.oneOf("CONTENT_INDEX", c.ContentIndex, "", "memory", "elasticsearch")
+	if c.ContentIndex == "elasticsearch" {
+		v.require(strings.HasPrefix(c.ContentIndexURL, "http://") || strings.HasPrefix(c.ContentIndexURL, "https://"),
+			"CONTENT_INDEX_URL %q must be an http:// or https:// URL", c.ContentIndexURL)
+		v.require(c.ContentIndexName != "", "CONTENT_INDEX_NAME is required with CONTENT_INDEX=elasticsearch")
+	}
+	v.nonNegative("CONTENT_INDEX_MAX_SIZE", c.ContentIndexMaxSize)
+	if c.ModerationURL != "" {
+		v.require(strings.HasPrefix(c.ModerationURL, "http://") || strings.HasPrefix(c.ModerationURL, "https://"),
+			"MODERATION_URL %q must be an http:// or https:// URL", c.ModerationURL)
+		v.require(c.ModerationThreshold > 0 && c.ModerationThreshold <= 1, "MODERATION_THRESHOLD must be in (0, 1]")
+		v.nonNegative("MODERATION_MAX_SIZE", c.ModerationMaxSize)
+		if c.ModerationQuarantine {
+			v.require(strings.HasSuffix(c.ModerationQuarantinePrefix, "/") && !strings.HasPrefix(c.ModerationQuarantinePrefix, "/"),
+				"MODERATION_QUARANTINE_PREFIX %q must end, but not start, with /", c.ModerationQuarantinePrefix)
+		}
+	}
+	if c.EventWebhookURL != "" {
+		v.require(strings.HasPrefix(c.EventWebhookURL, "http://") || strings.HasPrefix(c.EventWebhookURL, "https://"),
+			"EVENT_WEBHOOK_URL %q must be an http:// or https:// URL", c.EventWebhookURL)
+	}
+	if c.NATSURL != "" {
+		v.require(c.NATSSubject != "", "NATS_SUBJECT is required when NATS_URL is set")
+	}
+	v.nonNegative("OBJECT_LOCK_TIMEOUT", int64(c.ObjectLockTimeout))
+	if c.RedisURL != "" {
+		v.require(strings.HasPrefix(c.RedisURL, "redis://") || strings.HasPrefix(c.RedisURL, "rediss://"),
+			"REDIS_URL must be a redis:// or rediss:// URL")
+	}
+	if len(c.KafkaBrokers) > 0 {
+		v.require(c.KafkaTopic != "", "KAFKA_TOPIC is required when KAFKA_BROKERS is set")
+		v.oneOf("KAFKA_PARTITION_KEY", c.KafkaPartitionKey, "key", "bucket", "actor", "none")
+	}
+	if c.HLSTranscoder != "" {
+		v.oneOf("HLS_TRANSCODER", c.HLSTranscoder, "ffmpeg", "external")
+		if c.HLSTranscoder == "external" {
+			v.require(strings.HasPrefix(c.HLSTranscoderURL, "http://") || strings.HasPrefix(c.HLSTranscoderURL, "https://"),
+				"HLS_TRANSCODER_URL %q must be an http:// or https:// URL", c.HLSTranscoderURL)
+		}
+		v.positive("HLS_SEGMENT_SECONDS", int64(c.HLSSegmentSeconds))
+		v.nonNegative("HLS_MAX_SIZE", c.HLSMaxSize)
+	}
+	v.nonNegative("CLEANUP_TEMP_MAX_AGE", int64(c.CleanupTempMaxAge))
+	v.nonNegative("CLEANUP_MULTIPART_MAX_AGE", int64(c.CleanupMultipartMaxAge))
+	v.nonNegative("CLEANUP_SHARE_RETENTION", int64(c.CleanupShareRetention))
+	if c.BackupProvider != "" {
+		v.require(c.BackupBucketName != "", "BACKUP_BUCKET_NAME is required when BACKUP_PROVIDER is set")
+		v.positive("BACKUP_RETENTION", int64(c.BackupRetention))
+	}
+	if c.FailoverEnabled {
+		v.positive("FAILOVER_FAILURE_THRESHOLD", int64(c.FailoverFailureThreshold))
+		v.positive("FAILOVER_RECOVERY_THRESHOLD", int64(c.FailoverRecoveryThreshold))
+		v.positive("FAILOVER_PROBE_INTERVAL", int64(c.FailoverProbeInterval))
+	}
+	v.nonNegative("IDEMPOTENCY_TTL", int64(c.IdempotencyTTL))
+
+	// Secrets manager
+	switch c.SecretsProvider {
+	case "":
+	case "vault":
+		v.require(c.VaultAddr != "" && c.VaultToken != "" && c.VaultSecretPath != "", "VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are required when SECRETS_PROVIDER is vault")
+	case "aws":
+		v.require(c.SecretsAWSSecretID != "" && c.SecretsAWSRegion != "", "SECRETS_AWS_SECRET_ID and SECRETS_AWS_REGION are required when SECRETS_PROVIDER is aws")
+	case "gcp":
+		v.require(strings.HasPrefix(c.SecretsGCPSecret, "projects/"), "SECRETS_GCP_SECRET must be projects/<project>/secrets/<name> when SECRETS_PROVIDER is gcp")
+	default:
+		v.add("SECRETS_PROVIDER %q must be vault, aws, gcp or empty", c.SecretsProvider)
+	}
+	v.nonNegative("SECRETS_REFRESH_INTERVAL", int64(c.SecretsRefreshInterval))
+
+	if c.SiteEnabled {
+		v.require(c.SiteIndexDocument != "", "SITE_INDEX_DOCUMENT is required when SITE_ENABLED is set")
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// validateProvider checks the settings a storage provider needs to connect
+func (c *Config) validateProvider(v *validator, provider string, credentials bool) {
+	switch provider {
+	case "minio":
+		v.require(c.MinioEndpoint != "", "MINIO_ENDPOINT is required for the minio storage provider")
+		v.port("MINIO_PORT", c.MinioPort)
+		v.require(!credentials || c.MinioAccessKey != "" && c.MinioSecretKey != "", "MINIO_ACCESS_KEY and MINIO_SECRET_KEY are required for the minio storage provider")
+	case "s3", "aws", "wasabi", "b2":
+		if provider == "s3" {
+			v.require(c.S3Endpoint != "", "S3_ENDPOINT is required for the s3 storage provider")
+		} else {
+			v.require(c.S3Endpoint != "" || c.S3Region != "", "S3_REGION or S3_ENDPOINT is required for the %s storage provider", provider)
+		}
+		// AWS falls back to its environment, shared file and instance role chain
+		if provider != "aws" && credentials {
+			v.require(c.S3AccessKeyID != "", "S3_ACCESS_KEY_ID is required for the %s storage provider", provider)
+		}
+		v.require(c.S3AccessKeyID == "" || c.S3SecretAccessKey != "", "S3_SECRET_ACCESS_KEY is required with S3_ACCESS_KEY_ID")
+	case "azure":
+		v.require(c.AzureAccount != "", "AZURE_STORAGE_ACCOUNT is required for the azure storage provider")
+		v.require(!credentials || c.AzureAccountKey != "", "AZURE_STORAGE_KEY is required for the azure storage provider")
+	case "fs":
+		v.require(c.StorageFSRoot != "", "STORAGE_FS_ROOT is required for the fs storage provider")
+	case "memory":
+	default:
+		v.add("unknown storage provider %q, expected minio, s3, aws, wasabi, b2, azure, fs or memory", provider)
+	}
+}
+
+// validator collects problems instead of stopping at the first
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) require(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.add(format, args...)
+	}
+}
+
+func (v *validator) positive(name string, n int64) {
+	v.require(n > 0, "%s must be greater than 0, got %d", name, n)
+}
+
+func (v *validator) nonNegative(name string, n int64) {
+	v.require(n >= 0, "%s must not be negative, got %d", name, n)
+}
+
+func (v *validator) port(name, port string) {
+	if port == "" {
+		return
+	}
+	var n int
+	if _, err := fmt.Sscan(port, &n); err != nil || fmt.Sprint(n) != port || n < 1 || n > 65535 {
+		v.add("%s %q must be a port number between 1 and 65535", name, port)
+	}
+}
+
+func (v *validator) oneOf(name, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	var named []string
+	for _, a := range allowed {
+		if a != "" {
+			named = append(named, a)
+		}
+	}
+	v.add("%s %q must be one of %s", name, value, strings.Join(named, ", "))
+}
@@ -24,100 +24,444 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/buckets": {
+        "/admin/analytics/top-downloads": {
             "get": {
-                "description": "List all buckets in MinIO",
+                "description": "Rank files by download count across buckets, optionally within a bucket, key prefix or tenant",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "buckets"
+                    "admin"
+                ],
+                "summary": "Get the most downloaded files",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of files to return (default 10, at most 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files in this bucket",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only keys starting with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files of this tenant",
+                        "name": "tenant",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all buckets",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object"
+                                "$ref": "#/definitions/analytics.ObjectStats"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/files": {
+        "/admin/audit": {
             "get": {
-                "description": "List all files in the MinIO bucket",
+                "description": "List audited operations, newest first, filtered by user, operation and time range",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
+                ],
+                "summary": "Query the audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Actor subject",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation, e.g. file.upload",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of events (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all files",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object"
+                                "$ref": "#/definitions/audit.Event"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/backups": {
+            "get": {
+                "description": "Return the snapshots kept in the backup bucket, newest first, with those running or failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List backup snapshots",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/backup.Snapshot"
                             }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Upload a file to MinIO storage",
+                "description": "Start copying the backed up bucket or prefix into a new snapshot of the backup bucket, with a manifest of every object and its SHA-256. A background job copies it; follow its progress at /admin/backups/{id}. Snapshots beyond BACKUP_RETENTION are deleted once it succeeds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Take a backup snapshot",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/backup.Snapshot"
+                        }
+                    },
+                    "409": {
+                        "description": "A snapshot is already running",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/backups/{id}": {
+            "get": {
+                "description": "Return the state of a snapshot: the objects and bytes copied so far, and where it is in the backup bucket",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a backup snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backup.Snapshot"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/backups/{id}/restore": {
+            "post": {
+                "description": "Copy the objects of a succeeded snapshot, or those below a prefix, back into the bucket they were taken from. Each object is checked against its SHA-256 in the manifest before it is written; mismatches are listed as failures. Existing objects are kept unless overwrite is set. A background job copies them; follow its progress at /admin/restores/{id}.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "Upload a file to MinIO",
+                "summary": "Restore a backup snapshot",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Restore scope",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RestoreRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/backup.Restore"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The snapshot is not complete",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
+                }
+            }
+        },
+        "/admin/config": {
+            "get": {
+                "description": "Return the settings that can change without a restart, as currently applied",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
                 ],
+                "summary": "Get reloadable settings",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/config.Settings"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/config/reload": {
+            "post": {
+                "description": "Re-read the environment and .env file and apply allowed origins, transfer rate limits, log level, max file size and quotas without a restart; other settings need a restart. Same as sending SIGHUP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/config.Settings"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cost-report": {
+            "get": {
+                "description": "Estimate a month's storage, egress and operations cost per bucket, prefix or tenant with the MinIO and R2 price tables (COST_MINIO_PRICES, COST_R2_PRICES; COST_R2_BUCKETS picks the buckets on R2). Storage is charged for the whole month at the size of the month's last storage scan; egress and operations count the transfers through the API, so presigned transfers are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a cost report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month as YYYY-MM (default the current month)",
+                        "name": "month",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "bucket",
+                            "prefix",
+                            "tenant"
+                        ],
+                        "type": "string",
+                        "description": "Grouping",
+                        "name": "group",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/costs.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/events": {
+            "get": {
+                "description": "Server-sent events for every upload, delete, download and share link creation, from the moment the stream is opened. Each event is named after its type and its data is the JSON event. A client that falls behind misses events.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Stream file events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types to receive (default all)",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "description": "List pending, running and failed jobs, oldest first. Completed jobs are removed, except the last 20 of each kind that report a result (such as cleanup runs), which are listed as succeeded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List background jobs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "running",
+                            "failed",
+                            "succeeded"
+                        ],
+                        "type": "string",
+                        "description": "Job status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of jobs (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/jobs.Job"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/files/{filename}": {
+        "/admin/jobs/{id}": {
             "get": {
-                "description": "Get a file from MinIO by its name",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "Get a file",
+                "summary": "Get a background job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "File name",
-                        "name": "filename",
+                        "description": "Job ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -126,25 +470,32 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a file from MinIO by its name",
+            }
+        },
+        "/admin/jobs/{id}/retry": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "Delete a file",
+                "summary": "Retry a failed background job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "File name",
-                        "name": "filename",
+                        "description": "Job ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -153,12 +504,5748 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/minio/drives": {
+            "get": {
+                "description": "Return every drive with its state and space, counts of online, offline and healing drives, and the progress of background healing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get MinIO drive health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/minioadmin.DriveHealth"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/minio/info": {
+            "get": {
+                "description": "Return the mode, version, uptime, network state and drives of every MinIO server, with bucket, object and usage totals. The storage credentials need MinIO admin permissions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get MinIO server info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/minioadmin.ServerInfo"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/minio/usage": {
+            "get": {
+                "description": "Return object counts, versions and sizes per bucket as of MinIO's last background scan",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get MinIO data usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/minioadmin.DataUsage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/replication/drift": {
+            "get": {
+                "description": "Compare objects on the primary and mirror backends by size and checksum",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Detect replication drift",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket (defaults to the API bucket)",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/replication.DriftReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/replication/reconcile": {
+            "post": {
+                "description": "Detect drift and queue copy jobs for missing or mismatched objects, and removal jobs for mirror-only objects when prune is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reconcile the replication mirror",
+                "parameters": [
+                    {
+                        "description": "Reconciliation scope",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReconcileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/replication.DriftReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/restores/{id}": {
+            "get": {
+                "description": "Return the progress of a restore: the objects restored, skipped because they exist and failed so far",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a backup restore",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Restore ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backup.Restore"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "description": "Return object counts and bytes per bucket and prefix, the largest objects and usage over time, as of the last background scan",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get storage statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of largest objects to return (default all scanned, up to STATS_TOP_N)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats/scan": {
+            "post": {
+                "description": "Queue a background scan that refreshes the storage statistics; follow it through /admin/jobs/{id}",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Start a storage scan",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/usage/owners": {
+            "get": {
+                "description": "Return object counts and bytes per bucket and owner, computed from the metadata database. Objects uploaded without an owner are reported under an empty owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get usage per owner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only report this bucket",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only report this owner",
+                        "name": "owner",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/metadb.OwnerUsage"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/verify": {
+            "post": {
+                "description": "Queue a job that reads every object of the bucket below the prefix, recomputes the checksums recorded at upload and compares them. Its result (GET /admin/jobs/{id}) lists the corrupted objects and, with the metadata database, the objects it records that storage no longer has. Large buckets are verified by a chain of jobs: each result names the job continuing it in continuedBy, and the last one is complete.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Verify stored objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket (defaults to the API bucket)",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buckets": {
+            "get": {
+                "description": "List all buckets in MinIO",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "List all buckets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Create a bucket",
+                "parameters": [
+                    {
+                        "description": "Bucket",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateBucketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buckets/{bucket}": {
+            "delete": {
+                "description": "Delete an empty bucket. Buckets used by the API itself can't be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Delete a bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buckets/{bucket}/cors": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Get bucket CORS configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketCORSRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the bucket's CORS rules so browsers can upload directly with presigned URLs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Set bucket CORS configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CORS rules",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketCORSRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketCORSRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Delete bucket CORS configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buckets/{bucket}/encryption": {
+            "get": {
+                "description": "Return the encryption applied to objects uploaded without their own; an unencrypted bucket has an empty mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Get bucket default encryption",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketEncryptionRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Encrypt objects uploaded without their own encryption with SSE-S3 or an SSE-KMS key. Existing objects are not re-encrypted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Set bucket default encryption",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Encryption configuration",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketEncryptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketEncryptionRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Delete bucket default encryption",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buckets/{bucket}/lifecycle": {
+            "get": {
+                "description": "Return the bucket's expiration rules; a bucket without a lifecycle configuration has no rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Get bucket lifecycle rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketLifecycleRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the bucket's lifecycle configuration, e.g. to delete objects under a temporary-upload prefix after N days and abort multipart uploads left incomplete. Each rule needs expirationDays, abortIncompleteUploadDays or both.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Set bucket lifecycle rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lifecycle rules",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketLifecycleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BucketLifecycleRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "buckets"
+                ],
+                "summary": "Delete bucket lifecycle rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files": {
+            "get": {
+                "description": "List files in the MinIO bucket. Results are paginated; when more files remain the X-Continuation-Token response header holds the token for the next page. Object metadata (content type, user metadata) is only fetched with include_metadata=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "List files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list files whose name starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from a previous page's X-Continuation-Token header",
+                        "name": "continuation_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fetch content type and user metadata for each file",
+                        "name": "include_metadata",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        },
+                        "headers": {
+                            "X-Continuation-Token": {
+                                "type": "string",
+                                "description": "Token for the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Upload a file to MinIO storage",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Upload a file to MinIO",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client-chosen ID used to stream progress via /ws/uploads/{upload_id}",
+                        "name": "X-Upload-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "overwrite",
+                            "suffix",
+                            "uuid",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Key collision strategy",
+                        "name": "collision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Extra checksums to compute (comma-separated: md5, crc32c)",
+                        "name": "checksums",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected hex SHA-256 of the file; mismatches are rejected",
+                        "name": "X-Content-SHA256",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 MD5 of the file, checked by the API and by storage; mismatches are rejected with BAD_DIGEST",
+                        "name": "Content-MD5",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store the file under its content hash, reusing an identical existing object",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Extract EXIF/IPTC metadata from images into object metadata",
+                        "name": "exif",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove GPS location data from JPEG images before storing",
+                        "name": "strip_gps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart part size in bytes (5 MiB to 5 GiB)",
+                        "name": "part_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Parts uploaded to storage at once",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Have storage verify every part against its MD5",
+                        "name": "content_md5",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Files smaller than this many bytes are stored in a single request",
+                        "name": "multipart_threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 AES-256 key to encrypt the file with (SSE-C); the same key is needed to read it",
+                        "name": "X-SSE-Customer-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 MD5 of the customer key",
+                        "name": "X-SSE-Customer-Key-MD5",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/batch": {
+            "post": {
+                "description": "Upload several files in one multipart request, as repeated \"files\" fields and/or zip archives in \"archive\" fields that are expanded into objects keeping their folder structure. Files are stored concurrently and each goes through the same checks as a single upload, so one rejected file doesn't fail the others; the response has a result per file. Deduplicated files are content-addressed and ignore the prefix and archive folders.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Upload many files",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Files to upload; repeat the field for each file",
+                        "name": "files",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Zip archive to expand into objects",
+                        "name": "archive",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Folder to store the files under",
+                        "name": "prefix",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "overwrite",
+                            "suffix",
+                            "uuid",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Key collision strategy",
+                        "name": "collision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Extra checksums to compute (comma-separated: md5, crc32c)",
+                        "name": "checksums",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store files under their content hash, reusing identical existing objects",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Extract EXIF/IPTC metadata from images into object metadata",
+                        "name": "exif",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove GPS location data from JPEG images before storing",
+                        "name": "strip_gps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart part size in bytes (5 MiB to 5 GiB)",
+                        "name": "part_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Parts uploaded to storage at once, per file",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Have storage verify every part against its MD5",
+                        "name": "content_md5",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Files smaller than this many bytes are stored in a single request",
+                        "name": "multipart_threshold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/export": {
+            "get": {
+                "description": "Stream all files under a prefix as a single archive, keeping their names as paths. tar is uncompressed and meant for backup tooling; zip is deflated for browsers. Files are streamed one at a time, so memory use doesn't grow with the export. A storage error part-way leaves the archive truncated, without its end marker.",
+                "produces": [
+                    "application/x-tar",
+                    "application/zip"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Export files as an archive",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only export files whose name starts with this prefix; empty exports every file",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "tar",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "default": "tar",
+                        "description": "Archive format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/fetch": {
+            "post": {
+                "description": "Download a file from a remote URL into the bucket, server-side. The URL must use a scheme of FETCH_ALLOWED_SCHEMES and a host of FETCH_ALLOWED_HOSTS, and may not resolve to a private address. The file goes through the same checks as an upload (size limit, content types, quota, collision strategy). Files announced over FETCH_SYNC_MAX_SIZE, or any with async set, are fetched by a background job: the response is a 202 with the job to follow at /files/fetch/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Upload a file from a URL",
+                "parameters": [
+                    {
+                        "description": "Remote file",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FetchRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "overwrite",
+                            "suffix",
+                            "uuid",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Key collision strategy",
+                        "name": "collision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Extra checksums to compute (comma-separated: md5, crc32c)",
+                        "name": "checksums",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store the file under its content hash, reusing an identical existing object",
+                        "name": "dedupe",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FetchJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The URL is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The remote server failed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/fetch/{id}": {
+            "get": {
+                "description": "Return the state of a remote fetch queued by the caller and, once it succeeded, the stored file",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get a background fetch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FetchJobResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/import": {
+            "post": {
+                "description": "Copy every object of another S3-compatible bucket, or those below a prefix, into the caller's files, for moving from another provider. The credentials, temporary ones with a session token preferably, only need to list and read the source; they are checked before the import starts and deleted once it ends. A background job stores each object like an upload (size limit, content types, quota), overwriting files of the same name unless skipExisting is set; follow its progress at /files/import/{id}. The endpoint's host must be listed in IMPORT_ALLOWED_ENDPOINTS and may not resolve to a private address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Import files from an S3 bucket",
+                "parameters": [
+                    {
+                        "description": "Source bucket and credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Extra checksums to compute (comma-separated: md5, crc32c)",
+                        "name": "checksums",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store files under their content hash, reusing identical existing objects",
+                        "name": "dedupe",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The endpoint is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "The source bucket is not readable with the credentials",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/import/{id}": {
+            "get": {
+                "description": "Return the progress of an import started by the caller: the objects copied, skipped and failed so far, and the last source key done",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get an import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop an import started by the caller and delete its credentials. Files already copied are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Cancel an import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The import already ended",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/records": {
+            "get": {
+                "description": "List files from the metadata database, which mirrors storage through bucket notifications and periodic reconciliation. Unlike GET /files it can filter by owner and tags with indexed queries, and returns owner, SHA-256 and tags without a request per file. Results are paginated like GET /files.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "List file records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list files whose name starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list files uploaded by this subject; \\",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag the file must have, as key or key=value; repeat for several",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from a previous page's X-Continuation-Token header",
+                        "name": "continuation_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/metadb.Record"
+                            }
+                        },
+                        "headers": {
+                            "X-Continuation-Token": {
+                                "type": "string",
+                                "description": "Token for the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/search": {
+            "get": {
+                "description": "Filter files server-side, sorted by name, or by relevance for content queries. Buckets are answered from an index refreshed in the background every SEARCH_INDEX_INTERVAL seconds, so recent changes may be missing until the next refresh (see indexedAt); buckets not indexed yet are listed for the query instead. Tags are only known on S3 backends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Search files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text the file name must contain, ignoring case",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words the document text must contain (PDF, DOCX and text files); results are ranked by relevance and include a snippet. Requires CONTENT_INDEX.",
+                        "name": "content",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only search files whose name starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag the file must have, as key or key=value; repeat for several",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Content type, exact or a whole type such as image/*",
+                        "name": "content_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files modified after this time (RFC 3339)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files modified before this time (RFC 3339)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of files (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}": {
+            "get": {
+                "description": "Get a file from MinIO by its name. On routes configured for download redirects the response is a 302 to a short-lived presigned storage URL, or to the public URL of the bucket, so the bytes don't pass through the API. Files of the content types in DOWNLOAD_RISKY_TYPES, such as HTML and SVG, are sent as application/octet-stream attachments whatever the disposition, so they never run in the API's origin; with DOWNLOAD_SANITIZE_SVG, SVGs shown inline are sanitized instead.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "inline",
+                            "attachment"
+                        ],
+                        "type": "string",
+                        "description": "Content disposition",
+                        "name": "disposition",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Override the download filename",
+                        "name": "filename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return 304 if the ETag matches",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return 304 if not modified since this date",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "A single byte range, e.g. bytes=0-1048575, to download part of the file",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Send the whole file instead of the range if its ETag or date no longer matches",
+                        "name": "If-Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 AES-256 key the file was encrypted with (SSE-C)",
+                        "name": "X-SSE-Customer-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 MD5 of the customer key",
+                        "name": "X-SSE-Customer-Key-MD5",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "The requested byte range",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the file in storage, on routes configured with DOWNLOAD_REDIRECT_GROUPS"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "416": {
+                        "description": "The range is past the end of the file",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a file from MinIO by its name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Delete a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Another request is changing the file",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Return size, content type, ETag and last-modified of a file as response headers without the body, and its moderation status when media moderation has checked it. The content type and disposition are those a GET of the same URL is sent with.",
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get file headers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "inline",
+                            "attachment"
+                        ],
+                        "type": "string",
+                        "description": "Content disposition",
+                        "name": "disposition",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Override the download filename",
+                        "name": "filename",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            }
+        },
+        "/files/{filename}/checksum": {
+            "get": {
+                "description": "Return the checksums recorded at upload time. With compute=true, missing SHA-256 digests are computed by streaming the object.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get file checksums",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Compute the SHA-256 if it was not stored",
+                        "name": "compute",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/exif": {
+            "get": {
+                "description": "Extract EXIF and IPTC metadata (camera, dates, dimensions, GPS, keywords) from a JPEG image",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get EXIF/IPTC metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/exif.Metadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/exif/strip-gps": {
+            "post": {
+                "description": "Remove GPS location data from a stored JPEG image, preserving its other metadata",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Strip GPS data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Another request is changing the file",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/exists": {
+            "get": {
+                "description": "Check for a file without downloading it, returning size, content type, ETag and last-modified when it exists",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Check if a file exists",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/parallel": {
+            "get": {
+                "description": "Split a file into up to ` + "`" + `parts` + "`" + ` contiguous byte ranges of near-equal size. Each part is fetched with a concurrent GET of the file's URL carrying the part's Range header; sending the returned ETag as If-Range makes a part come back whole (200) rather than mixing versions if the file is replaced meanwhile. Each part is a download of its own under the transfer limits: it takes a transfer slot and gets the whole per-transfer rate limit, so parts are capped at the number of transfer slots when that is limited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Plan a parallel download",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 4,
+                        "description": "Number of parts (1-64)",
+                        "name": "parts",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ParallelDownloadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/presign": {
+            "post": {
+                "description": "Generate a time-limited URL for GET, PUT, DELETE or HEAD directly against object storage. GET URLs can override the response Content-Type and Content-Disposition. PUT URLs can set the Content-Type, Cache-Control, Content-Disposition and custom metadata stored with the object; these become part of the signature, so the client must send them exactly as listed in headers. A PUT URL without them accepts any Content-Type. A PUT presigned with confirm or callbackUrl returns a callback token: the upload is charged to the quota and announced once the client confirms it through POST /uploads/confirm, or once a bucket notification reports it when PRESIGN_NOTIFICATIONS is on. Its callback URL then receives the object.uploaded event, signed like event webhooks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Generate a presigned URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Presign options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PresignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PresignResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/public-url": {
+            "get": {
+                "description": "Compose the URL of a file on the bucket's public address (DOWNLOAD_PUBLIC_URL, e.g. an R2 public bucket or a CDN custom domain), after checking the file exists. With cache_bust=true the URL carries the file's ETag as a \"v\" query parameter, so caches fetch it again when the content changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get a file's public URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the ETag as a cache-busting query parameter",
+                        "name": "cache_bust",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublicURLResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/stats": {
+            "get": {
+                "description": "Return how often a file was downloaded, through the API and share links, from how many distinct client addresses, and when it was last accessed. Counts start when the analytics feature is enabled and are dropped when the file is deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get file download statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileStatsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/stream/master.m3u8": {
+            "get": {
+                "description": "Serve the adaptive (HLS) stream of an uploaded video, starting with master.m3u8. Renditions and segments are served under the same path, relative to the playlist. Streams are generated in the background after upload; until then this returns 404.",
+                "produces": [
+                    "application/vnd.apple.mpegurl",
+                    "video/mp2t"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Stream a video",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{filename}/thumbnail": {
+            "get": {
+                "description": "Resize an image server-side. Generated variants are cached in the derived bucket and reused until the source changes.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Get an image thumbnail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width in pixels",
+                        "name": "w",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Height in pixels",
+                        "name": "h",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "contain",
+                            "cover",
+                            "fill"
+                        ],
+                        "type": "string",
+                        "description": "Fit mode",
+                        "name": "fit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/files": {
+            "get": {
+                "description": "List files in the caller's home directory (HOME_DIRECTORY_PATTERN, users/{subject}/ by default), whether or not HOME_DIRECTORIES confines every file route to it. Names are relative to the home directory. Paginated like GET /files.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "List my files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list files whose name starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Page size (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from a previous page's X-Continuation-Token header",
+                        "name": "continuation_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fetch content type and user metadata for each file",
+                        "name": "include_metadata",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        },
+                        "headers": {
+                            "X-Continuation-Token": {
+                                "type": "string",
+                                "description": "Token for the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/s/{slug}": {
+            "get": {
+                "description": "Public, unauthenticated download through a share link. Password-protected links take the password in the X-Share-Password header or, with POST, a password form or JSON field; never in the URL. A download only counts against the link's limit once it completes. Links to envelope-encrypted files decrypt them with the data key the password unwraps, unless SHARE_PASSWORD_ENCRYPTION is disabled; they are never redirected to storage, and stop serving the file once it is deleted or replaced. Files of the content types in DOWNLOAD_RISKY_TYPES, such as HTML and SVG, are sent as application/octet-stream attachments whatever the disposition, so they never run in the API's origin; with DOWNLOAD_SANITIZE_SVG, SVGs shown inline are sanitized instead.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Download a shared file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link password",
+                        "name": "X-Share-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link password (POST)",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "inline",
+                            "attachment"
+                        ],
+                        "type": "string",
+                        "description": "Content disposition",
+                        "name": "disposition",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the file in storage, when share link downloads are configured to redirect"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Public, unauthenticated download through a share link. Password-protected links take the password in the X-Share-Password header or, with POST, a password form or JSON field; never in the URL. A download only counts against the link's limit once it completes. Links to envelope-encrypted files decrypt them with the data key the password unwraps, unless SHARE_PASSWORD_ENCRYPTION is disabled; they are never redirected to storage, and stop serving the file once it is deleted or replaced. Files of the content types in DOWNLOAD_RISKY_TYPES, such as HTML and SVG, are sent as application/octet-stream attachments whatever the disposition, so they never run in the API's origin; with DOWNLOAD_SANITIZE_SVG, SVGs shown inline are sanitized instead.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Download a shared file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link password",
+                        "name": "X-Share-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link password (POST)",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "inline",
+                            "attachment"
+                        ],
+                        "type": "string",
+                        "description": "Content disposition",
+                        "name": "disposition",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the file in storage, when share link downloads are configured to redirect"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shares": {
+            "get": {
+                "description": "List all share links including expired and revoked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "List share links",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.ShareResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a short, unguessable public link for a file with optional password, expiry and download limit. When the file is envelope-encrypted, a password-protected link wraps its data key under the password, so the API can only decrypt the file for the link with the password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Create a share link",
+                "parameters": [
+                    {
+                        "description": "Share link options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shares/{slug}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Get a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Revoke a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/site/{path}": {
+            "get": {
+                "description": "Serve a file from the website bucket. Paths ending in \"/\" resolve to the index document, directories without a trailing slash redirect to it, and missing paths get the error document with a 404.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "site"
+                ],
+                "summary": "Serve the static website",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File path",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "301": {
+                        "description": "Moved Permanently"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/u/{token}": {
+            "get": {
+                "description": "Public, unauthenticated description of what an upload link accepts. Browsers asking for HTML get an upload page instead.",
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "upload-links"
+                ],
+                "summary": "Get upload link restrictions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadLinkInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Public, unauthenticated upload through an upload link. Each file uses up one of the link's files, and a file with the name of an existing one is stored with a numeric suffix rather than replacing it.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload-links"
+                ],
+                "summary": "Upload a file through an upload link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadLinkUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload-links": {
+            "get": {
+                "description": "List all upload links including expired and revoked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload-links"
+                ],
+                "summary": "List upload links",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.UploadLinkResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an unguessable link anyone can upload files through, without credentials, into a folder of the caller's files. The link accepts maxFiles files (1 by default, making it single-use) until it expires, each within maxFileSize and of one of contentTypes when given, on top of the server's upload limits. Uploads count against the creator's quota.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload-links"
+                ],
+                "summary": "Create an upload link",
+                "parameters": [
+                    {
+                        "description": "Upload link restrictions",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUploadLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload-links/{token}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upload-links"
+                ],
+                "summary": "Revoke an upload link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload link token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadLinkResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "description": "Start a resumable upload. Send the file in numbered chunks with PUT /uploads/{upload_id}/chunks/{n}, in any order and retrying as needed, then assemble them with the complete endpoint. The server keeps track of received chunks, so an interrupted client can ask which chunks are missing and resume. Every chunk but the last must be at least minChunkSize bytes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Start an upload session",
+                "parameters": [
+                    {
+                        "description": "Upload options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUploadSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/confirm": {
+            "post": {
+                "description": "Complete an upload made through a PUT URL presigned with confirm or callbackUrl, once the object is stored: the file is charged to the quota, the object.uploaded event is published and the callback URL, if any, receives it. Confirming again returns the same result. A file over MAX_FILE_SIZE or the quota is removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Confirm a presigned upload",
+                "parameters": [
+                    {
+                        "description": "File name and callback token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConfirmUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConfirmUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/multipart": {
+            "post": {
+                "description": "Start a resumable multipart upload. Parts are uploaded directly to object storage with presigned URLs from the parts endpoint, then assembled with the complete endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Start a multipart upload",
+                "parameters": [
+                    {
+                        "description": "Upload options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateMultipartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/multipart/{upload_id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Abort a multipart upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/multipart/{upload_id}/complete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Complete a multipart upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Uploaded parts",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CompleteMultipartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/multipart/{upload_id}/parts": {
+            "get": {
+                "description": "List parts already received for a multipart upload, to resume an interrupted upload",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "List uploaded parts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Return presigned PUT URLs for the given part numbers. Each URL's response carries an ETag header the client must keep for completion.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Presign upload part URLs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Part numbers",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PresignPartsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/policy": {
+            "post": {
+                "description": "Generate a form upload policy for plain HTML forms and mobile SDKs. Unlike a presigned PUT, the storage server enforces the conditions: maximum size (never above MAX_FILE_SIZE), key or key prefix, and content type. Post the returned fields followed by a \"file\" field to the URL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Generate a presigned POST policy",
+                "parameters": [
+                    {
+                        "description": "Policy conditions",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PostPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PostPolicyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{upload_id}": {
+            "get": {
+                "description": "Return the chunks stored so far, so an interrupted client knows which chunks to send again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadSessionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Abort a multipart upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{upload_id}/chunks/{n}": {
+            "put": {
+                "description": "Store chunk n (1-10000) of an upload session from the raw request body. Sending a chunk again replaces it, so failed chunks can simply be retried.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Upload a chunk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Chunk number (1-10000)",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected hex SHA-256 of the chunk; mismatches are rejected",
+                        "name": "X-Content-SHA256",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UploadSessionChunk"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "411": {
+                        "description": "Length Required",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{upload_id}/complete": {
+            "post": {
+                "description": "Assemble chunks 1 to N, as received, into the final object. Fails if a chunk in that range is missing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Complete an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage": {
+            "get": {
+                "description": "Return the authenticated caller's used bytes, object count and quota limit (0 means unlimited)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Get storage usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/uploads/{upload_id}": {
+            "get": {
+                "description": "Open a WebSocket that streams bytes-transferred updates for an upload tagged with the given upload ID. Only the caller that made the upload may subscribe; browsers must connect from an origin in CORS_ALLOWED_ORIGINS.",
+                "tags": [
+                    "files"
+                ],
+                "summary": "Stream upload progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "analytics.ObjectStats": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastAccessedAt": {
+                    "type": "string"
+                },
+                "shareDownloads": {
+                    "description": "ShareDownloads are the downloads through share links",
+                    "type": "integer"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "uniqueIps": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apierror.Code": {
+            "type": "string",
+            "enum": [
+                "INVALID_REQUEST",
+                "VALIDATION_FAILED",
+                "UNAUTHORIZED",
+                "FORBIDDEN",
+                "NOT_FOUND",
+                "FILE_NOT_FOUND",
+                "BUCKET_MISSING",
+                "BUCKET_EXISTS",
+                "BUCKET_NOT_EMPTY",
+                "METHOD_NOT_ALLOWED",
+                "CONFLICT",
+                "OBJECT_LOCKED",
+                "GONE",
+                "UNPROCESSABLE",
+                "IDEMPOTENCY_KEY_REUSED",
+                "REQUEST_IN_PROGRESS",
+                "PRECONDITION_FAILED",
+                "FILE_TOO_LARGE",
+                "BAD_DIGEST",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "RANGE_NOT_SATISFIABLE",
+                "RATE_LIMITED",
+                "QUOTA_EXCEEDED",
+                "NOT_IMPLEMENTED",
+                "STORAGE_UNAVAILABLE",
+                "REMOTE_FAILED",
+                "SERVICE_UNAVAILABLE",
+                "DEADLINE_EXCEEDED",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidRequest",
+                "CodeValidationFailed",
+                "CodeUnauthorized",
+                "CodeForbidden",
+                "CodeNotFound",
+                "CodeFileNotFound",
+                "CodeBucketMissing",
+                "CodeBucketExists",
+                "CodeBucketNotEmpty",
+                "CodeMethodNotAllowed",
+                "CodeConflict",
+                "CodeObjectLocked",
+                "CodeGone",
+                "CodeUnprocessable",
+                "CodeIdempotencyKeyReused",
+                "CodeRequestInProgress",
+                "CodePreconditionFailed",
+                "CodeFileTooLarge",
+                "CodeBadDigest",
+                "CodeUnsupportedMediaType",
+                "CodeRangeNotSatisfiable",
+                "CodeRateLimited",
+                "CodeQuotaExceeded",
+                "CodeNotImplemented",
+                "CodeStorageUnavailable",
+                "CodeRemoteFailed",
+                "CodeServiceUnavailable",
+                "CodeDeadlineExceeded",
+                "CodeInternal"
+            ]
+        },
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "limit"
+                },
+                "in": {
+                    "type": "string",
+                    "example": "query"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be an integer"
+                }
+            }
+        },
+        "audit.Event": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "authMethod": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "type": "string"
+                },
+                "clientIp": {
+                    "type": "string"
+                },
+                "correlationId": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
+                }
+            }
+        },
+        "backup.Failure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "backup.Restore": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "description": "Failures lists the first failed objects",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/backup.Failure"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jobId": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "overwrite": {
+                    "type": "boolean"
+                },
+                "part": {
+                    "description": "Part and Line locate the next entry of the manifest",
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "Prefix limits the restore to the keys below it",
+                    "type": "string"
+                },
+                "restored": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "snapshotId": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "backup.Snapshot": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "type": "string"
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jobId": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Location is where the snapshot is in the destination bucket",
+                    "type": "string"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "parts": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token and After locate the next object: the listing resumes at Token\nand skips the keys up to After",
+                    "type": "string"
+                },
+                "unreadable": {
+                    "description": "Unreadable counts the objects left out, sealed with a customer key\nonly their client holds; UnreadableKeys lists the first of them",
+                    "type": "integer"
+                },
+                "unreadableKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.Settings": {
+            "type": "object",
+            "properties": {
+                "corsAllowedOrigins": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "logLevel": {
+                    "type": "string"
+                },
+                "maxFileSize": {
+                    "type": "integer"
+                },
+                "quotaDefaultBytes": {
+                    "type": "integer"
+                },
+                "quotaOverrides": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "transferGlobalRateLimit": {
+                    "type": "integer"
+                },
+                "transferRateLimit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "costs.Line": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "classAOps": {
+                    "type": "integer"
+                },
+                "classBOps": {
+                    "type": "integer"
+                },
+                "egressBytes": {
+                    "type": "integer"
+                },
+                "egressCost": {
+                    "type": "number"
+                },
+                "operationsCost": {
+                    "type": "number"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "storageBytes": {
+                    "type": "integer"
+                },
+                "storageCost": {
+                    "type": "number"
+                },
+                "table": {
+                    "description": "Table is the price table applied, \"mixed\" for a tenant spanning both",
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "costs.Prices": {
+            "type": "object",
+            "properties": {
+                "classAMillion": {
+                    "description": "per million writes",
+                    "type": "number"
+                },
+                "classBMillion": {
+                    "description": "per million reads",
+                    "type": "number"
+                },
+                "egressGb": {
+                    "description": "per GB downloaded",
+                    "type": "number"
+                },
+                "storageGbMonth": {
+                    "description": "per GB stored for a month",
+                    "type": "number"
+                }
+            }
+        },
+        "costs.Report": {
+            "type": "object",
+            "properties": {
+                "groupBy": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/costs.Line"
+                    }
+                },
+                "month": {
+                    "type": "string"
+                },
+                "prices": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/costs.Prices"
+                    }
+                },
+                "storageScannedAt": {
+                    "description": "StorageScannedAt is when the stored bytes were measured, by the last\nscan of the month; storage is left out of months without one",
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "type": "string"
+                },
+                "clientIp": {
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "correlationId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "range": {
+                    "type": "string"
+                },
+                "shareSlug": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "exif.Metadata": {
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string"
+                },
+                "byline": {
+                    "type": "string"
+                },
+                "caption": {
+                    "description": "IPTC",
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "copyright": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "dateTime": {
+                    "type": "string"
+                },
+                "dateTimeOriginal": {
+                    "type": "string"
+                },
+                "exposureTime": {
+                    "type": "string"
+                },
+                "fNumber": {
+                    "type": "string"
+                },
+                "focalLength": {
+                    "type": "string"
+                },
+                "gpsLatitude": {
+                    "type": "number"
+                },
+                "gpsLongitude": {
+                    "type": "number"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "iso": {
+                    "type": "integer"
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lensModel": {
+                    "type": "string"
+                },
+                "make": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "orientation": {
+                    "type": "integer"
+                },
+                "software": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "fetch.Result": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "checksums": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "deduplicated": {
+                    "type": "boolean"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BatchUploadResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchUploadResult"
+                    }
+                },
+                "uploaded": {
+                    "type": "integer",
+                    "example": 9
+                }
+            }
+        },
+        "handlers.BatchUploadResult": {
+            "type": "object",
+            "properties": {
+                "checksums": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apierror.Code"
+                        }
+                    ],
+                    "example": "UNSUPPORTED_MEDIA_TYPE"
+                },
+                "contentType": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "deduplicated": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Content type application/x-msdownload is not allowed"
+                },
+                "filename": {
+                    "description": "Filename is the name the file was sent with, or its path in the archive",
+                    "type": "string",
+                    "example": "photos/cat.jpg"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "photos/cat.jpg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52431
+                },
+                "status": {
+                    "description": "Status is the HTTP status the file would have had as a single upload",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "handlers.BucketCORSRequest": {
+            "type": "object",
+            "required": [
+                "rules"
+            ],
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.CORSRule"
+                    }
+                }
+            }
+        },
+        "handlers.BucketEncryptionRequest": {
+            "type": "object",
+            "required": [
+                "mode"
+            ],
+            "properties": {
+                "kmsKeyId": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "my-minio-key"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "sse-s3",
+                        "sse-kms"
+                    ],
+                    "example": "sse-kms"
+                }
+            }
+        },
+        "handlers.BucketLifecycleRequest": {
+            "type": "object",
+            "required": [
+                "rules"
+            ],
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.LifecycleRule"
+                    }
+                }
+            }
+        },
+        "handlers.CORSRule": {
+            "type": "object",
+            "required": [
+                "allowedOrigins"
+            ],
+            "properties": {
+                "allowedHeaders": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "*"
+                    ]
+                },
+                "allowedMethods": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "GET",
+                        "PUT"
+                    ]
+                },
+                "allowedOrigins": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://app.example.com"
+                    ]
+                },
+                "exposeHeaders": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ETag"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "maxAgeSeconds": {
+                    "type": "integer",
+                    "example": 3600
+                }
+            }
+        },
+        "handlers.CompleteMultipartRequest": {
+            "type": "object",
+            "required": [
+                "parts"
+            ],
+            "properties": {
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.CompletedPart"
+                    }
+                }
+            }
+        },
+        "handlers.CompletedPart": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string",
+                    "example": "\"d41d8cd98f00b204e9800998ecf8427e\""
+                },
+                "partNumber": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.ConfirmUploadRequest": {
+            "type": "object",
+            "required": [
+                "filename",
+                "token"
+            ],
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "token": {
+                    "description": "callbackToken of the presign",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ConfirmUploadResponse": {
+            "type": "object",
+            "properties": {
+                "completedAt": {
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "etag": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CreateBucketRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "uploads"
+                }
+            }
+        },
+        "handlers.CreateMultipartRequest": {
+            "type": "object",
+            "required": [
+                "filename"
+            ],
+            "properties": {
+                "contentType": {
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "video.mp4"
+                }
+            }
+        },
+        "handlers.CreateShareRequest": {
+            "type": "object",
+            "required": [
+                "key"
+            ],
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "key": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "maxDownloads": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateUploadLinkRequest": {
+            "type": "object",
+            "properties": {
+                "contentTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "application/pdf",
+                        "image/*"
+                    ]
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "folder": {
+                    "description": "Folder receiving the uploads, below the caller's scope",
+                    "type": "string",
+                    "example": "incoming/invoices"
+                },
+                "maxFileSize": {
+                    "description": "bytes",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "maxFiles": {
+                    "description": "MaxFiles is how many files the link accepts; 1 makes it single-use",
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Please upload your signed invoices as PDF."
+                },
+                "name": {
+                    "description": "Name and Message are shown on the upload page",
+                    "type": "string",
+                    "example": "Invoices for March"
+                },
+                "notifyUrl": {
+                    "description": "NotifyURL receives a webhook for every file that arrives; its host must\nbe allowed by UPLOAD_LINK_NOTIFY_HOSTS",
+                    "type": "string",
+                    "example": "https://hooks.example.com/uploads"
+                }
+            }
+        },
+        "handlers.CreateUploadSessionRequest": {
+            "type": "object",
+            "required": [
+                "filename"
+            ],
+            "properties": {
+                "contentType": {
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "size": {
+                    "description": "Size is the total size, if known, used to suggest a chunk size",
+                    "type": "integer",
+                    "example": 104857600
+                }
+            }
+        },
+        "handlers.DownloadPart": {
+            "type": "object",
+            "properties": {
+                "length": {
+                    "type": "integer",
+                    "example": 13107200
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "range": {
+                    "description": "Range is the Range header that fetches the part",
+                    "type": "string",
+                    "example": "bytes=0-13107199"
+                }
+            }
+        },
+        "handlers.FetchJobResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/fetch.Result"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FetchRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "async": {
+                    "description": "Async fetches in a background job whatever the size",
+                    "type": "boolean"
+                },
+                "filename": {
+                    "description": "Filename names the stored file; the remote name by default",
+                    "type": "string",
+                    "example": "q3.pdf"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/reports/q3.pdf"
+                }
+            }
+        },
+        "handlers.FileStatsResponse": {
+            "type": "object",
+            "properties": {
+                "downloads": {
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "lastAccessedAt": {
+                    "type": "string"
+                },
+                "shareDownloads": {
+                    "type": "integer"
+                },
+                "uniqueIps": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ImportRequest": {
+            "type": "object",
+            "required": [
+                "accessKeyId",
+                "bucket",
+                "secretAccessKey"
+            ],
+            "properties": {
+                "accessKeyId": {
+                    "type": "string"
+                },
+                "bucket": {
+                    "type": "string",
+                    "example": "old-uploads"
+                },
+                "endpoint": {
+                    "description": "Endpoint is the source's S3 endpoint; AWS S3 by default",
+                    "type": "string",
+                    "example": "https://s3.eu-west-1.amazonaws.com"
+                },
+                "folder": {
+                    "description": "Folder is where the keys below the prefix land; the root by default",
+                    "type": "string",
+                    "example": "imported"
+                },
+                "prefix": {
+                    "description": "Prefix limits the import to the keys below it",
+                    "type": "string",
+                    "example": "photos/"
+                },
+                "region": {
+                    "type": "string",
+                    "example": "eu-west-1"
+                },
+                "secretAccessKey": {
+                    "type": "string"
+                },
+                "sessionToken": {
+                    "description": "SessionToken comes with temporary credentials",
+                    "type": "string"
+                },
+                "skipExisting": {
+                    "description": "SkipExisting keeps files already stored under an imported name",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "copied": {
+                    "description": "Copied, Skipped and Failed count the objects done so far, Bytes the size copied",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.Failure"
+                    }
+                },
+                "folder": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastKey": {
+                    "description": "LastKey is the last source key done",
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "source": {
+                    "$ref": "#/definitions/importer.Source"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "running"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LifecycleRule": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "abortIncompleteUploadDays": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "expirationDays": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 7
+                },
+                "id": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "expire-tmp"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "tmp/"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "Enabled",
+                        "Disabled"
+                    ],
+                    "example": "Enabled"
+                }
+            }
+        },
+        "handlers.ParallelDownloadResponse": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string",
+                    "example": "application/x-tar"
+                },
+                "etag": {
+                    "description": "ETag is quoted, ready for If-Range",
+                    "type": "string",
+                    "example": "d41d8cd98f00b204e9800998ecf8427e-4"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "backup.tar"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "parts": {
+                    "description": "Parts cover the file in order; an empty file has none",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DownloadPart"
+                    }
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52428800
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/api/v1/files/backup.tar"
+                }
+            }
+        },
+        "handlers.PostPolicyRequest": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "description": "exact content type",
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "contentTypePrefix": {
+                    "description": "content type must start with this",
+                    "type": "string",
+                    "example": "image/"
+                },
+                "expiresIn": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "filename": {
+                    "description": "exact key; omit to allow any key under keyPrefix",
+                    "type": "string",
+                    "example": "avatar.png"
+                },
+                "keyPrefix": {
+                    "description": "required key prefix when filename is omitted",
+                    "type": "string",
+                    "example": "avatars/"
+                },
+                "maxSize": {
+                    "description": "bytes, capped at MAX_FILE_SIZE",
+                    "type": "integer",
+                    "example": 10485760
+                }
+            }
+        },
+        "handlers.PostPolicyResponse": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "keyPrefix": {
+                    "type": "string"
+                },
+                "maxSize": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PresignPartsRequest": {
+            "type": "object",
+            "required": [
+                "partNumbers"
+            ],
+            "properties": {
+                "expiresIn": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "partNumbers": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "handlers.PresignRequest": {
+            "type": "object",
+            "properties": {
+                "cacheControl": {
+                    "description": "CacheControl and Metadata are stored with a PUT upload",
+                    "type": "string",
+                    "example": "public, max-age=31536000"
+                },
+                "callbackUrl": {
+                    "type": "string",
+                    "example": "https://hooks.example.com/uploads"
+                },
+                "confirm": {
+                    "description": "Confirm asks for a callback token completing a PUT upload through\nPOST /uploads/confirm; CallbackURL implies it and receives the upload\nevent once the upload completes, on a host allowed by PRESIGN_CALLBACK_HOSTS",
+                    "type": "boolean"
+                },
+                "contentDisposition": {
+                    "description": "inline or attachment",
+                    "type": "string",
+                    "example": "attachment"
+                },
+                "contentType": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "expiresIn": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "filename": {
+                    "description": "download name",
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "GET",
+                        "PUT",
+                        "DELETE",
+                        "HEAD"
+                    ],
+                    "example": "GET"
+                }
+            }
+        },
+        "handlers.PresignResponse": {
+            "type": "object",
+            "properties": {
+                "callbackToken": {
+                    "description": "CallbackToken completes the upload through POST /uploads/confirm",
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PublicURLResponse": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string",
+                    "example": "d41d8cd98f00b204e9800998ecf8427e"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52431
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://cdn.example.com/report.pdf?v=d41d8cd98f00b204e9800998ecf8427e"
+                }
+            }
+        },
+        "handlers.ReconcileRequest": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "description": "defaults to the API bucket",
+                    "type": "string",
+                    "example": "my-bucket"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "tenants/acme/"
+                },
+                "prune": {
+                    "description": "also remove objects that only exist on the mirror",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.RestoreRequest": {
+            "type": "object",
+            "properties": {
+                "overwrite": {
+                    "description": "Overwrite replaces existing objects; they are kept by default",
+                    "type": "boolean"
+                },
+                "prefix": {
+                    "description": "Prefix limits the restore to the keys below it",
+                    "type": "string",
+                    "example": "photos/"
+                }
+            }
+        },
+        "handlers.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/search.Entry"
+                    }
+                },
+                "indexedAt": {
+                    "description": "IndexedAt is when the index that answered was last refreshed; absent\nwhen the bucket was listed for this query",
+                    "type": "string"
+                },
+                "truncated": {
+                    "description": "Truncated is set when more files matched than the limit",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.ShareResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "maxDownloads": {
+                    "type": "integer"
+                },
+                "passwordProtected": {
+                    "type": "boolean"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stats.BucketStats"
+                    }
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "duration": {
+                    "type": "string"
+                },
+                "growth": {
+                    "$ref": "#/definitions/stats.Growth"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stats.Point"
+                    }
+                },
+                "largest": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stats.ObjectStats"
+                    }
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "scannedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UploadLinkInfo": {
+            "type": "object",
+            "properties": {
+                "contentTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "maxFileSize": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UploadLinkResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "contentTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "folder": {
+                    "type": "string"
+                },
+                "maxFileSize": {
+                    "type": "integer"
+                },
+                "maxFiles": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notifyUrl": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "uploads": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UploadLinkUploadResponse": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "description": "Remaining is how many more files the link accepts",
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UploadSessionChunk": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string",
+                    "example": "\"d41d8cd98f00b204e9800998ecf8427e\""
+                },
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8388608
+                }
+            }
+        },
+        "handlers.UploadSessionResponse": {
+            "type": "object",
+            "properties": {
+                "chunkSize": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.UploadSessionChunk"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "maxChunks": {
+                    "type": "integer",
+                    "example": 10000
+                },
+                "minChunkSize": {
+                    "type": "integer",
+                    "example": 5242880
+                },
+                "received": {
+                    "description": "Received is the total size of the stored chunks",
+                    "type": "integer",
+                    "example": 16777216
+                },
+                "uploadId": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Failure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Source": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "metadb.OwnerUsage": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string",
+                    "example": "uploads"
+                },
+                "bytes": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "objects": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "user-123"
+                }
+            }
+        },
+        "metadb.Record": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string",
+                    "example": "uploads"
+                },
+                "contentType": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "etag": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "reports/2024/q1.pdf"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "user-123"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52431
+                },
+                "syncedAt": {
+                    "description": "SyncedAt is when the record was last written from storage",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "minioadmin.Backend": {
+            "type": "object",
+            "properties": {
+                "backendType": {
+                    "type": "string"
+                },
+                "offlineDisks": {
+                    "type": "integer"
+                },
+                "onlineDisks": {
+                    "type": "integer"
+                },
+                "rrSCParity": {
+                    "type": "integer"
+                },
+                "standardSCParity": {
+                    "type": "integer"
+                },
+                "totalDrivesPerSet": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "totalSets": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "minioadmin.BucketUsage": {
+            "type": "object",
+            "properties": {
+                "deleteMarkersCount": {
+                    "type": "integer"
+                },
+                "objectsCount": {
+                    "type": "integer"
+                },
+                "objectsSizesHistogram": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "versionsCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "minioadmin.DataUsage": {
+            "type": "object",
+            "properties": {
+                "bucketsCount": {
+                    "type": "integer"
+                },
+                "bucketsUsageInfo": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/minioadmin.BucketUsage"
+                    }
+                },
+                "deleteMarkersCount": {
+                    "type": "integer"
+                },
+                "lastUpdate": {
+                    "type": "string"
+                },
+                "objectsCount": {
+                    "type": "integer"
+                },
+                "objectsTotalSize": {
+                    "type": "integer"
+                },
+                "versionsCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "minioadmin.Drive": {
+            "type": "object",
+            "properties": {
+                "availspace": {
+                    "type": "integer"
+                },
+                "disk_index": {
+                    "type": "integer"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "healing": {
+                    "type": "boolean"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "pool_index": {
+                    "type": "integer"
+                },
+                "scanning": {
+                    "type": "boolean"
+                },
+                "set_index": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "totalspace": {
+                    "type": "integer"
+                },
+                "usedspace": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "minioadmin.DriveHealth": {
+            "type": "object",
+            "properties": {
+                "drives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/minioadmin.Drive"
+                    }
+                },
+                "healDisks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "healing": {
+                    "type": "integer"
+                },
+                "offline": {
+                    "type": "integer"
+                },
+                "offlineNodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "online": {
+                    "type": "integer"
+                },
+                "scannedItems": {
+                    "type": "integer"
+                }
+            }
+        },
+        "minioadmin.Server": {
+            "type": "object",
+            "properties": {
+                "commitID": {
+                    "type": "string"
+                },
+                "drives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/minioadmin.Drive"
+                    }
+                },
+                "edition": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "network": {
+                    "description": "endpoint -\u003e online/offline",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "poolNumber": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "description": "seconds",
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "minioadmin.ServerInfo": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "$ref": "#/definitions/minioadmin.Backend"
+                },
+                "buckets": {
+                    "type": "object",
+                    "properties": {
+                        "count": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "deploymentID": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "objects": {
+                    "type": "object",
+                    "properties": {
+                        "count": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "region": {
+                    "type": "string"
+                },
+                "servers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/minioadmin.Server"
+                    }
+                },
+                "usage": {
+                    "type": "object",
+                    "properties": {
+                        "size": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        },
+        "replication.DriftReport": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "checked": {
+                    "type": "integer"
+                },
+                "checkedAt": {
+                    "type": "string"
+                },
+                "extra": {
+                    "description": "on the secondary only",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mismatched": {
+                    "description": "on both with different content",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "missing": {
+                    "description": "on the primary only",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "search.Entry": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "etag": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "reports/2024/q1.pdf"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Score and Snippet are set on results of content queries",
+                    "type": "number",
+                    "example": 3.2
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52431
+                },
+                "snippet": {
+                    "type": "string",
+                    "example": "revenue for the first quarter grew by"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "stats.BucketStats": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "prefixes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/stats.PrefixStats"
+                    }
+                },
+                "truncated": {
+                    "description": "Truncated is set when only the largest prefixes are listed",
+                    "type": "boolean"
+                }
+            }
+        },
+        "stats.Growth": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "bytesPerDay": {
+                    "type": "integer"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "stats.ObjectStats": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "stats.Point": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "description": "bytes per bucket",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "stats.PrefixStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apierror.Code"
                         }
+                    ],
+                    "example": "FILE_NOT_FOUND"
+                },
+                "correlation_id": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "File not found"
+                },
+                "errors": {
+                    "description": "Errors lists the invalid inputs of a VALIDATION_FAILED problem",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierror.FieldError"
                     }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/files/report.pdf"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
                 }
             }
         }
@@ -177,7 +6264,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Minio Go API",
-	Description:      "A RESTful API for Minio Go application",
+	Description:      "A RESTful API for Minio Go application. /api/v2 serves the same operations with responses wrapped as {\"data\", \"meta\"}; listings carry their next page in meta.page.next_cursor, passed back as ?cursor=, instead of the X-Continuation-Token header.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,188 @@
+// Package analytics counts downloads per object from the file events: how
+// often an object was downloaded, when it was last accessed and from about how
+// many distinct client addresses. Counts are kept in the metadata store, so they
+// survive restarts and are shared by replicas.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+)
+
+const (
+	recordPrefix = "analytics/objects/"
+	saltKey      = "analytics/salt"
+)
+
+// ObjectStats are the download counts of one object
+type ObjectStats struct {
+	Bucket         string     `json:"bucket"`
+	Key            string     `json:"key"`
+	TenantID       string     `json:"tenantId,omitempty"`
+	Downloads      int64      `json:"downloads"`
+	UniqueIPs      int        `json:"uniqueIps"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+	// ShareDownloads are the downloads through share links
+	ShareDownloads int64 `json:"shareDownloads"`
+}
+
+// record is the stored form of ObjectStats. Client addresses are counted by a
+// sketch of their keyed hashes, so the store never holds them and a record
+// stays small however many clients download the object.
+type record struct {
+	ObjectStats
+	Visitors sketch `json:"visitors,omitempty"`
+	// IPs are the hashed addresses of records written before the sketch,
+	// folded into it on their next download
+	IPs []string `json:"ips,omitempty"`
+}
+
+// Tracker is an events.Sink that records downloads and forgets deleted objects
+type Tracker struct {
+	store store.Store
+	salt  []byte
+}
+
+// NewTracker creates a Tracker keeping its counts in s. The key hashing
+// client addresses is created on first use and shared through s.
+func NewTracker(ctx context.Context, s store.Store) (*Tracker, error) {
+	salt, err := store.Update(ctx, s, saltKey, func(salt *[]byte, exists bool) (bool, error) {
+		if exists {
+			return true, nil
+		}
+		*salt = make([]byte, 32)
+		_, err := rand.Read(*salt)
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{store: s, salt: salt}, nil
+}
+
+func recordKey(bucket, key string) string {
+	return recordPrefix + bucket + "/" + key
+}
+
+func (t *Tracker) Name() string { return "analytics" }
+
+func (t *Tracker) Send(ctx context.Context, e events.Event) error {
+	switch e.Type {
+	case events.TypeDownloaded:
+		if e.Range != "" && !strings.HasPrefix(e.Range, "bytes 0-") {
+			// A download in parts is counted once, by its first part
+			return nil
+		}
+		return t.recordDownload(ctx, e)
+	case events.TypeDeleted:
+		return t.store.Delete(ctx, recordKey(e.Bucket, e.Key))
+	}
+	return nil
+}
+
+func (t *Tracker) Close() error { return nil }
+
+func (t *Tracker) recordDownload(ctx context.Context, e events.Event) error {
+	_, err := store.Update(ctx, t.store, recordKey(e.Bucket, e.Key), func(rec *record, exists bool) (bool, error) {
+		rec.Bucket, rec.Key, rec.TenantID = e.Bucket, e.Key, e.TenantID
+		rec.Downloads++
+		if e.ShareSlug != "" {
+			rec.ShareDownloads++
+		}
+		at := e.Time.UTC()
+		if rec.LastAccessedAt == nil || at.After(*rec.LastAccessedAt) {
+			rec.LastAccessedAt = &at
+		}
+		for _, ip := range rec.IPs {
+			// The hashes began with the bytes hashIP returns
+			if hash, err := base64.RawStdEncoding.DecodeString(ip); err == nil && len(hash) >= 8 {
+				rec.Visitors.add(binary.BigEndian.Uint64(hash))
+			}
+		}
+		rec.IPs = nil
+		if e.ClientIP != "" {
+			rec.Visitors.add(t.hashIP(e.ClientIP))
+		}
+		rec.UniqueIPs = rec.Visitors.estimate()
+		return true, nil
+	})
+	return err
+}
+
+func (t *Tracker) hashIP(ip string) uint64 {
+	mac := hmac.New(sha256.New, t.salt)
+	mac.Write([]byte(ip))
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+// Object returns the download counts of an object; an object never
+// downloaded has zero counts
+func (t *Tracker) Object(ctx context.Context, bucket, key string) (*ObjectStats, error) {
+	var rec record
+	if err := t.store.Get(ctx, recordKey(bucket, key), &rec); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return &ObjectStats{Bucket: bucket, Key: key}, nil
+		}
+		return nil, err
+	}
+	return &rec.ObjectStats, nil
+}
+
+// TopFilter selects the objects of a top downloads report
+type TopFilter struct {
+	Bucket   string
+	Prefix   string
+	TenantID string
+	Limit    int
+}
+
+// TopDownloads returns the most downloaded objects matching filter, most
+// downloaded first
+func (t *Tracker) TopDownloads(ctx context.Context, filter TopFilter) ([]ObjectStats, error) {
+	prefix := recordPrefix
+	if filter.Bucket != "" {
+		prefix = recordKey(filter.Bucket, filter.Prefix)
+	}
+	keys, err := t.store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	top := make([]ObjectStats, 0, len(keys))
+	for _, key := range keys {
+		var rec record
+		if err := t.store.Get(ctx, key, &rec); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if filter.TenantID != "" && rec.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.Bucket == "" && !strings.HasPrefix(rec.Key, filter.Prefix) {
+			continue
+		}
+		top = append(top, rec.ObjectStats)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Downloads != top[j].Downloads {
+			return top[i].Downloads > top[j].Downloads
+		}
+		return top[i].Bucket+"/"+top[i].Key < top[j].Bucket+"/"+top[j].Key
+	})
+	if filter.Limit > 0 && len(top) > filter.Limit {
+		top = top[:filter.Limit]
+	}
+	return top, nil
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"math"
+	"math/bits"
+)
+
+// sketchPrecision gives sketches 2^10 one-byte registers, which estimate any
+// number of distinct values to within about 3%
+const sketchPrecision = 10
+
+// sketch is a HyperLogLog counting distinct 64-bit hashes in constant space
+type sketch []byte
+
+// add counts a uniformly distributed hash
+func (s *sketch) add(hash uint64) {
+	if len(*s) != 1<<sketchPrecision {
+		*s = make(sketch, 1<<sketchPrecision)
+	}
+	register := hash >> (64 - sketchPrecision)
+	// The rank is the position of the first set bit of the rest of the hash
+	rank := byte(bits.LeadingZeros64(hash<<sketchPrecision|1<<(sketchPrecision-1))) + 1
+	if rank > (*s)[register] {
+		(*s)[register] = rank
+	}
+}
+
+// estimate returns the number of distinct hashes added
+func (s sketch) estimate() int {
+	if len(s) == 0 {
+		return 0
+	}
+	m := float64(len(s))
+	var sum float64
+	empty := 0
+	for _, rank := range s {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			empty++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Small counts are estimated from the registers still empty
+	if estimate <= 2.5*m && empty > 0 {
+		estimate = m * math.Log(m/float64(empty))
+	}
+	return int(math.Round(estimate))
+}
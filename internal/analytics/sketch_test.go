@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestSketchEstimate(t *testing.T) {
+	hash := func(i int) uint64 {
+		sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+		return binary.BigEndian.Uint64(sum[:])
+	}
+	var s sketch
+	if got := s.estimate(); got != 0 {
+		t.Errorf("empty sketch estimate = %d", got)
+	}
+	for _, added := range []int{1, 3, 50, 1000, 100000} {
+		for i := 0; i < added; i++ {
+			// Each value is added twice; repeats are not counted
+			s.add(hash(i))
+			s.add(hash(i))
+		}
+		got := s.estimate()
+		if math.Abs(float64(got-added)) > 0.05*float64(added) {
+			t.Errorf("estimate of %d distinct values = %d", added, got)
+		}
+		if added <= 3 && got != added {
+			t.Errorf("estimate of %d distinct values = %d, want exact", added, got)
+		}
+		if len(s) != 1<<sketchPrecision {
+			t.Fatalf("sketch has %d registers", len(s))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/analytics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultTopDownloads = 10
+	maxTopDownloads     = 1000
+)
+
+// AnalyticsHandler serves the download counts kept by the analytics tracker
+type AnalyticsHandler struct {
+	tracker *analytics.Tracker
+	files   *MinioHandler
+	logger  *zerolog.Logger
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler
+func NewAnalyticsHandler(tracker *analytics.Tracker, files *MinioHandler, logger *zerolog.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{tracker: tracker, files: files, logger: logger}
+}
+
+// FileStatsResponse are the download counts of a file
+type FileStatsResponse struct {
+	Filename       string     `json:"filename"`
+	Downloads      int64      `json:"downloads"`
+	ShareDownloads int64      `json:"shareDownloads"`
+	UniqueIPs      int        `json:"uniqueIps"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+}
+
+// GetFileStats returns the download counts of a file
+// @Summary Get file download statistics
+// @Description Return how often a file was downloaded, through the API and share links, from how many distinct client addresses, and when it was last accessed. Counts start when the analytics feature is enabled and are dropped when the file is deleted.
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Success 200 {object} FileStatsResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/{filename}/stats [get]
+func (h *AnalyticsHandler) GetFileStats(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.files.scope(c)
+
+	if _, err := h.files.storage.Stat(c.Request.Context(), scope.Bucket, scope.Key(filename)); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file info")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	stats, err := h.tracker.Object(c.Request.Context(), scope.Bucket, scope.Key(filename))
+	if err != nil {
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to read download statistics")
+		apierror.Send(c, err, "Failed to read download statistics")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, FileStatsResponse{
+		Filename:       filename,
+		Downloads:      stats.Downloads,
+		ShareDownloads: stats.ShareDownloads,
+		UniqueIPs:      stats.UniqueIPs,
+		LastAccessedAt: stats.LastAccessedAt,
+	})
+}
+
+// GetTopDownloads returns the most downloaded files
+// @Summary Get the most downloaded files
+// @Description Rank files by download count across buckets, optionally within a bucket, key prefix or tenant
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Number of files to return (default 10, at most 1000)"
+// @Param bucket query string false "Only files in this bucket"
+// @Param prefix query string false "Only keys starting with this prefix"
+// @Param tenant query string false "Only files of this tenant"
+// @Success 200 {array} analytics.ObjectStats
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/analytics/top-downloads [get]
+func (h *AnalyticsHandler) GetTopDownloads(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	limit := defaultTopDownloads
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTopDownloads {
+			utils.SendError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	top, err := h.tracker.TopDownloads(c.Request.Context(), analytics.TopFilter{
+		Bucket:   c.Query("bucket"),
+		Prefix:   c.Query("prefix"),
+		TenantID: c.Query("tenant"),
+		Limit:    limit,
+	})
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to read download statistics")
+		apierror.Send(c, err, "Failed to read download statistics")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, top)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/audit"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// AuditHandler exposes the audit log
+type AuditHandler struct {
+	recorder *audit.Recorder
+	logger   *zerolog.Logger
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(recorder *audit.Recorder, logger *zerolog.Logger) *AuditHandler {
+	return &AuditHandler{
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// ListEvents queries the audit log
+// @Summary Query the audit log
+// @Description List audited operations, newest first, filtered by user, operation and time range
+// @Tags admin
+// @Produce json
+// @Param user query string false "Actor subject"
+// @Param operation query string false "Operation, e.g. file.upload"
+// @Param from query string false "Start time (RFC 3339)"
+// @Param to query string false "End time (RFC 3339)"
+// @Param limit query int false "Maximum number of events (default 100, max 1000)"
+// @Success 200 {array} audit.Event
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/audit [get]
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	filter := audit.Filter{
+		Actor:     c.Query("user"),
+		Operation: c.Query("operation"),
+	}
+	var err error
+	if v := c.Query("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "from must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "to must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "limit must be a number")
+			return
+		}
+	}
+
+	events, err := h.recorder.Query(c.Request.Context(), filter)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to query audit log")
+		apierror.Send(c, err, "Failed to query audit log")
+		return
+	}
+	if events == nil {
+		events = []audit.Event{}
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, events)
+}
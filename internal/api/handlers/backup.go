@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/backup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// BackupHandler takes, lists and restores backup snapshots
+type BackupHandler struct {
+	backups *backup.Service
+	logger  *zerolog.Logger
+}
+
+// NewBackupHandler creates a new BackupHandler
+func NewBackupHandler(backups *backup.Service, logger *zerolog.Logger) *BackupHandler {
+	return &BackupHandler{backups: backups, logger: logger}
+}
+
+// RestoreRequest is the body of a snapshot restore
+type RestoreRequest struct {
+	// Prefix limits the restore to the keys below it
+	Prefix string `json:"prefix,omitempty" example:"photos/"`
+	// Overwrite replaces existing objects; they are kept by default
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+// ListSnapshots returns the backup snapshots
+// @Summary List backup snapshots
+// @Description Return the snapshots kept in the backup bucket, newest first, with those running or failed
+// @Tags admin
+// @Produce json
+// @Success 200 {array} backup.Snapshot
+// @Router /admin/backups [get]
+func (h *BackupHandler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.backups.List(c.Request.Context())
+	if err != nil {
+		newHandlerContext(c, h.logger).logger.Error().Err(err).Msg("Failed to list snapshots")
+		apierror.Send(c, err, "Failed to list snapshots")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, snapshots)
+}
+
+// StartSnapshot takes a snapshot now
+// @Summary Take a backup snapshot
+// @Description Start copying the backed up bucket or prefix into a new snapshot of the backup bucket, with a manifest of every object and its SHA-256. A background job copies it; follow its progress at /admin/backups/{id}. Snapshots beyond BACKUP_RETENTION are deleted once it succeeds.
+// @Tags admin
+// @Produce json
+// @Success 202 {object} backup.Snapshot
+// @Failure 409 {object} utils.ErrorResponse "A snapshot is already running"
+// @Router /admin/backups [post]
+func (h *BackupHandler) StartSnapshot(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	snap, err := h.backups.Snapshot(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, backup.ErrRunning) {
+			apierror.Write(c, http.StatusConflict, apierror.CodeConflict, err.Error())
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to start snapshot")
+		apierror.Send(c, err, "Failed to start snapshot")
+		return
+	}
+	hc.logger.Info().Str("snapshot_id", snap.ID).Msg("Snapshot started")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, snap)
+}
+
+// GetSnapshot returns a snapshot
+// @Summary Get a backup snapshot
+// @Description Return the state of a snapshot: the objects and bytes copied so far, and where it is in the backup bucket
+// @Tags admin
+// @Produce json
+// @Param id path string true "Snapshot ID"
+// @Success 200 {object} backup.Snapshot
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/backups/{id} [get]
+func (h *BackupHandler) GetSnapshot(c *gin.Context) {
+	snap, err := h.backups.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.sendError(c, err, "Failed to get snapshot")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, snap)
+}
+
+// StartRestore restores a snapshot
+// @Summary Restore a backup snapshot
+// @Description Copy the objects of a succeeded snapshot, or those below a prefix, back into the bucket they were taken from. Each object is checked against its SHA-256 in the manifest before it is written; mismatches are listed as failures. Existing objects are kept unless overwrite is set. A background job copies them; follow its progress at /admin/restores/{id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Snapshot ID"
+// @Param request body RestoreRequest false "Restore scope"
+// @Success 202 {object} backup.Restore
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "The snapshot is not complete"
+// @Router /admin/backups/{id}/restore [post]
+func (h *BackupHandler) StartRestore(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	var req RestoreRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	restore, err := h.backups.Restore(c.Request.Context(), c.Param("id"), req.Prefix, req.Overwrite)
+	if err != nil {
+		h.sendError(c, err, "Failed to start restore")
+		return
+	}
+	hc.logger.Info().Str("restore_id", restore.ID).Str("snapshot_id", restore.SnapshotID).
+		Str("prefix", req.Prefix).Bool("overwrite", req.Overwrite).Msg("Restore started")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, restore)
+}
+
+// GetRestore returns a restore
+// @Summary Get a backup restore
+// @Description Return the progress of a restore: the objects restored, skipped because they exist and failed so far
+// @Tags admin
+// @Produce json
+// @Param id path string true "Restore ID"
+// @Success 200 {object} backup.Restore
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/restores/{id} [get]
+func (h *BackupHandler) GetRestore(c *gin.Context) {
+	restore, err := h.backups.GetRestore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.sendError(c, err, "Failed to get restore")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, restore)
+}
+
+func (h *BackupHandler) sendError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, backup.ErrNotFound):
+		apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "Snapshot not found")
+	case errors.Is(err, backup.ErrRestoreNotFound):
+		apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "Restore not found")
+	case errors.Is(err, backup.ErrIncomplete):
+		apierror.Write(c, http.StatusConflict, apierror.CodeConflict, "Snapshot is not complete")
+	default:
+		newHandlerContext(c, h.logger).logger.Error().Err(err).Str("id", c.Param("id")).Msg(message)
+		apierror.Send(c, err, message)
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// BatchUploadResult is the outcome of one file of a batch upload
+type BatchUploadResult struct {
+	// Filename is the name the file was sent with, or its path in the archive
+	Filename string `json:"filename" example:"photos/cat.jpg"`
+	// Status is the HTTP status the file would have had as a single upload
+	Status       int                           `json:"status" example:"200"`
+	Key          string                        `json:"key,omitempty" example:"photos/cat.jpg"`
+	Size         int64                         `json:"size,omitempty" example:"52431"`
+	ContentType  string                        `json:"contentType,omitempty" example:"image/jpeg"`
+	Checksums    map[checksum.Algorithm]string `json:"checksums,omitempty"`
+	Deduplicated bool                          `json:"deduplicated,omitempty"`
+	Code         apierror.Code                 `json:"code,omitempty" example:"UNSUPPORTED_MEDIA_TYPE"`
+	Error        string                        `json:"error,omitempty" example:"Content type application/x-msdownload is not allowed"`
+}
+
+// BatchUploadResponse lists the outcome of every file of a batch upload
+type BatchUploadResponse struct {
+	Uploaded int                 `json:"uploaded" example:"9"`
+	Failed   int                 `json:"failed" example:"1"`
+	Results  []BatchUploadResult `json:"results"`
+}
+
+// batchItem is a file of a batch upload, opened when a worker picks it up
+type batchItem struct {
+	name     string
+	filename string
+	dir      string
+	open     func() (io.ReadSeekCloser, int64, error)
+}
+
+// UploadBatch stores many files from one request
+// @Summary Upload many files
+// @Description Upload several files in one multipart request, as repeated "files" fields and/or zip archives in "archive" fields that are expanded into objects keeping their folder structure. Files are stored concurrently and each goes through the same checks as a single upload, so one rejected file doesn't fail the others; the response has a result per file. Deduplicated files are content-addressed and ignore the prefix and archive folders.
+// @Tags files
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file false "Files to upload; repeat the field for each file"
+// @Param archive formData file false "Zip archive to expand into objects"
+// @Param prefix formData string false "Folder to store the files under"
+// @Param collision query string false "Key collision strategy" Enums(overwrite, suffix, uuid, reject)
+// @Param checksums query string false "Extra checksums to compute (comma-separated: md5, crc32c)"
+// @Param dedupe query bool false "Store files under their content hash, reusing identical existing objects"
+// @Param exif query bool false "Extract EXIF/IPTC metadata from images into object metadata"
+// @Param strip_gps query bool false "Remove GPS location data from JPEG images before storing"
+// @Param part_size query int false "Multipart part size in bytes (5 MiB to 5 GiB)"
+// @Param concurrency query int false "Parts uploaded to storage at once, per file"
+// @Param content_md5 query bool false "Have storage verify every part against its MD5"
+// @Param multipart_threshold query int false "Files smaller than this many bytes are stored in a single request"
+// @Success 200 {object} BatchUploadResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /files/batch [post]
+func (h *MinioHandler) UploadBatch(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			hc.logger.Warn().Int64("limit", maxBytesErr.Limit).Msg("Batch upload body exceeds size limit")
+			utils.SendError(c, http.StatusRequestEntityTooLarge, "Upload exceeds the maximum allowed size")
+			return
+		}
+		utils.SendError(c, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+	defer form.RemoveAll()
+
+	opts, ok := h.resolveUploadOptions(c)
+	if !ok {
+		return
+	}
+	dir := keygen.SanitizeDir(c.Request.FormValue("prefix"))
+
+	var items []batchItem
+	for _, header := range form.File["files"] {
+		items = append(items, batchItem{name: header.Filename, filename: header.Filename, dir: dir, open: openFormFile(header)})
+	}
+	for _, header := range form.File["archive"] {
+		archive, err := header.Open()
+		if err != nil {
+			hc.logger.Error().Err(err).Msg("Failed to open uploaded archive")
+			utils.SendError(c, http.StatusBadRequest, "Failed to read archive")
+			return
+		}
+		defer archive.Close()
+		zr, err := zip.NewReader(archive, header.Size)
+		if err != nil {
+			utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("%s is not a valid zip archive", header.Filename))
+			return
+		}
+		items = append(items, h.zipItems(zr, dir)...)
+	}
+
+	if len(items) == 0 {
+		utils.SendError(c, http.StatusBadRequest, "No files to upload")
+		return
+	}
+	if limit := h.config.UploadBatchMaxFiles; limit > 0 && len(items) > limit {
+		utils.SendError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("A batch may contain at most %d files", limit))
+		return
+	}
+
+	resp := BatchUploadResponse{Results: h.storeBatch(c, opts, items)}
+	for _, result := range resp.Results {
+		if result.Error == "" {
+			resp.Uploaded++
+		} else {
+			resp.Failed++
+		}
+	}
+	c.Set(middleware.AuditKeyKey, opts.scope.Key(dir))
+
+	hc.logger.Info().
+		Int("uploaded", resp.Uploaded).
+		Int("failed", resp.Failed).
+		Msg("Batch upload completed")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, resp)
+}
+
+// storeBatch stores the items with a bounded number of workers and returns
+// their results in order
+func (h *MinioHandler) storeBatch(c *gin.Context, opts uploadOptions, items []batchItem) []BatchUploadResult {
+	concurrency := h.config.UploadBatchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]BatchUploadResult, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = h.storeBatchItem(c, opts, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (h *MinioHandler) storeBatchItem(c *gin.Context, opts uploadOptions, item batchItem) BatchUploadResult {
+	result := BatchUploadResult{Filename: item.name}
+	stored, err := h.storeBatchFile(c, opts, item)
+	if err != nil {
+		var rejected *uploadError
+		if errors.As(err, &rejected) {
+			result.Status, result.Code, result.Error = rejected.status, rejected.code, rejected.message
+			return result
+		}
+		status, code, mapped := apierror.FromError(err)
+		result.Status, result.Code, result.Error = status, code, "Failed to upload file"
+		if mapped && status != http.StatusInternalServerError {
+			result.Error = http.StatusText(status)
+		}
+		return result
+	}
+	result.Status = http.StatusOK
+	result.Key = opts.scope.Name(stored.key)
+	result.Size = stored.size
+	result.ContentType = stored.contentType
+	result.Checksums = stored.sums
+	result.Deduplicated = stored.deduplicated
+	return result
+}
+
+func (h *MinioHandler) storeBatchFile(c *gin.Context, opts uploadOptions, item batchItem) (storedUpload, error) {
+	body, size, err := item.open()
+	if err != nil {
+		if !errors.As(err, new(*uploadError)) {
+			hc := newHandlerContext(c, h.logger)
+			hc.logger.Error().Err(err).Str("filename", item.name).Msg("Failed to read batch file")
+		}
+		return storedUpload{}, err
+	}
+	defer body.Close()
+	return h.storeUpload(c, opts, uploadInput{filename: item.filename, dir: item.dir, body: body, size: size})
+}
+
+// zipItems lists the files of an archive, placing each under dir with the
+// folders of its path in the archive
+func (h *MinioHandler) zipItems(zr *zip.Reader, dir string) []batchItem {
+	items := make([]batchItem, 0, len(zr.File))
+	for _, f := range zr.File {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		// Skip folders and the resource forks macOS adds to archives
+		if f.FileInfo().IsDir() || strings.HasSuffix(name, "/") || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+		f := f
+		items = append(items, batchItem{
+			name:     f.Name,
+			filename: path.Base(name),
+			dir:      dir + keygen.SanitizeDir(path.Dir(name)),
+			open: func() (io.ReadSeekCloser, int64, error) {
+				return spoolZipFile(f, h.config.Live().MaxFileSize)
+			},
+		})
+	}
+	return items
+}
+
+// openFormFile opens an uploaded form file
+func openFormFile(header *multipart.FileHeader) func() (io.ReadSeekCloser, int64, error) {
+	return func() (io.ReadSeekCloser, int64, error) {
+		file, err := header.Open()
+		return file, header.Size, err
+	}
+}
+
+// spoolZipFile extracts an archive entry to a temporary file, which is removed
+// on Close. Entries over limit are rejected without extracting them fully.
+func spoolZipFile(f *zip.File, limit int64) (io.ReadSeekCloser, int64, error) {
+	tooLarge := rejectUpload(http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+	if limit > 0 && f.UncompressedSize64 > uint64(limit) {
+		return nil, 0, tooLarge
+	}
+	entry, err := f.Open()
+	if err != nil {
+		return nil, 0, rejectUpload(http.StatusBadRequest, "Failed to read archive entry")
+	}
+	defer entry.Close()
+
+	tmp, err := os.CreateTemp("", "batch-upload-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	spooled := tempFile{tmp}
+	var src io.Reader = entry
+	if limit > 0 {
+		// The size in the archive header isn't trusted
+		src = io.LimitReader(entry, limit+1)
+	}
+	n, err := io.Copy(tmp, src)
+	if err == nil && limit > 0 && n > limit {
+		err = tooLarge
+	} else if errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) {
+		err = rejectUpload(http.StatusBadRequest, "Failed to read archive entry")
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = spooled.Close()
+		return nil, 0, err
+	}
+	return spooled, n, nil
+}
+
+// tempFile is a temporary file deleted on Close
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/cors"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// CreateBucketRequest is the body for creating a bucket
+type CreateBucketRequest struct {
+	Name string `json:"name" binding:"required" example:"uploads"`
+}
+
+// CORSRule is a bucket CORS rule. Empty methods default to GET, PUT, POST and HEAD,
+// empty headers to "*", and empty expose headers to ETag so browsers can complete
+// multipart uploads.
+type CORSRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins" binding:"required" example:"https://app.example.com"`
+	AllowedMethods []string `json:"allowedMethods,omitempty" example:"GET,PUT"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" example:"*"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty" example:"ETag"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty" example:"3600"`
+}
+
+// BucketCORSRequest is the body for replacing a bucket's CORS configuration
+type BucketCORSRequest struct {
+	Rules []CORSRule `json:"rules" binding:"required,min=1,dive"`
+}
+
+// CreateBucket creates a bucket
+// @Summary Create a bucket
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param request body CreateBucketRequest true "Bucket"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /buckets [post]
+func (h *MinioHandler) CreateBucket(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	var req CreateBucketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.storage.MakeBucket(c.Request.Context(), req.Name); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrBucketExists):
+			apierror.Write(c, http.StatusConflict, apierror.CodeBucketExists, "Bucket already exists")
+		case errors.Is(err, storage.ErrInvalidBucket):
+			utils.SendError(c, http.StatusBadRequest, "Invalid bucket name")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", req.Name).Msg("Failed to create bucket")
+			apierror.Send(c, err, "Failed to create bucket")
+		}
+		return
+	}
+
+	hc.logger.Info().Str("bucket", req.Name).Msg("Bucket created")
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, map[string]interface{}{
+		"name": req.Name,
+	})
+}
+
+// DeleteBucket deletes an empty bucket
+// @Summary Delete a bucket
+// @Description Delete an empty bucket. Buckets used by the API itself can't be deleted.
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /buckets/{bucket} [delete]
+func (h *MinioHandler) DeleteBucket(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+
+	if h.isSystemBucket(bucket) {
+		utils.SendError(c, http.StatusConflict, "Bucket is in use by the API")
+		return
+	}
+
+	if err := h.storage.RemoveBucket(c.Request.Context(), bucket); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrBucketNotFound):
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		case errors.Is(err, storage.ErrBucketNotEmpty):
+			apierror.Write(c, http.StatusConflict, apierror.CodeBucketNotEmpty, "Bucket is not empty")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to delete bucket")
+			apierror.Send(c, err, "Failed to delete bucket")
+		}
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Msg("Bucket deleted")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message": "Bucket deleted successfully",
+		"name":    bucket,
+	})
+}
+
+// GetBucketCORS returns a bucket's CORS configuration
+// @Summary Get bucket CORS configuration
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} BucketCORSRequest
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/cors [get]
+func (h *MinioHandler) GetBucketCORS(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	config, err := client.GetBucketCors(c.Request.Context(), bucket)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchBucket" {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to get bucket CORS")
+		apierror.Send(c, err, "Failed to get bucket CORS configuration")
+		return
+	}
+
+	rules := []CORSRule{}
+	if config != nil {
+		for _, r := range config.CORSRules {
+			rules = append(rules, CORSRule{
+				ID:             r.ID,
+				AllowedOrigins: r.AllowedOrigin,
+				AllowedMethods: r.AllowedMethod,
+				AllowedHeaders: r.AllowedHeader,
+				ExposeHeaders:  r.ExposeHeader,
+				MaxAgeSeconds:  r.MaxAgeSeconds,
+			})
+		}
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, BucketCORSRequest{Rules: rules})
+}
+
+// SetBucketCORS replaces a bucket's CORS configuration
+// @Summary Set bucket CORS configuration
+// @Description Replace the bucket's CORS rules so browsers can upload directly with presigned URLs
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketCORSRequest true "CORS rules"
+// @Success 200 {object} BucketCORSRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/cors [put]
+func (h *MinioHandler) SetBucketCORS(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	var req BucketCORSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rules := make([]cors.Rule, 0, len(req.Rules))
+	for i := range req.Rules {
+		r := &req.Rules[i]
+		if len(r.AllowedMethods) == 0 {
+			r.AllowedMethods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodHead}
+		}
+		if len(r.AllowedHeaders) == 0 {
+			r.AllowedHeaders = []string{"*"}
+		}
+		if len(r.ExposeHeaders) == 0 {
+			r.ExposeHeaders = []string{"ETag"}
+		}
+		rules = append(rules, cors.Rule{
+			ID:            r.ID,
+			AllowedOrigin: r.AllowedOrigins,
+			AllowedMethod: r.AllowedMethods,
+			AllowedHeader: r.AllowedHeaders,
+			ExposeHeader:  r.ExposeHeaders,
+			MaxAgeSeconds: r.MaxAgeSeconds,
+		})
+	}
+
+	if err := client.SetBucketCors(c.Request.Context(), bucket, cors.NewConfig(rules)); err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		case "MalformedXML", "InvalidRequest":
+			utils.SendError(c, http.StatusBadRequest, "Invalid CORS configuration")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to set bucket CORS")
+			apierror.Send(c, err, "Failed to set bucket CORS configuration")
+		}
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Int("rules", len(rules)).Msg("Bucket CORS updated")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, req)
+}
+
+// DeleteBucketCORS removes a bucket's CORS configuration
+// @Summary Delete bucket CORS configuration
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/cors [delete]
+func (h *MinioHandler) DeleteBucketCORS(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	if err := client.SetBucketCors(c.Request.Context(), bucket, nil); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchBucket" {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to delete bucket CORS")
+		apierror.Send(c, err, "Failed to delete bucket CORS configuration")
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Msg("Bucket CORS removed")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message": "Bucket CORS configuration removed",
+		"name":    bucket,
+	})
+}
+
+// isSystemBucket reports whether the API itself stores data in the bucket
+func (h *MinioHandler) isSystemBucket(bucket string) bool {
+	switch bucket {
+	case h.config.MinioBucketName, h.config.DerivedBucket(), h.config.MetadataBucket(), h.config.AuditBucket():
+		return true
+	}
+	return false
+}
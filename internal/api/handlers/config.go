@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// ConfigHandler exposes the reloadable settings of the running server
+type ConfigHandler struct {
+	config *config.Config
+	logger *zerolog.Logger
+}
+
+// NewConfigHandler creates a new ConfigHandler
+func NewConfigHandler(cfg *config.Config, logger *zerolog.Logger) *ConfigHandler {
+	return &ConfigHandler{config: cfg, logger: logger}
+}
+
+// GetSettings returns the settings currently in effect
+// @Summary Get reloadable settings
+// @Description Return the settings that can change without a restart, as currently applied
+// @Tags admin
+// @Produce json
+// @Success 200 {object} config.Settings
+// @Router /admin/config [get]
+func (h *ConfigHandler) GetSettings(c *gin.Context) {
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, h.config.Live())
+}
+
+// Reload re-reads the configuration and applies its reloadable settings
+// @Summary Reload configuration
+// @Description Re-read the environment and .env file and apply allowed origins, transfer rate limits, log level, max file size and quotas without a restart; other settings need a restart. Same as sending SIGHUP.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} config.Settings
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /admin/config/reload [post]
+func (h *ConfigHandler) Reload(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	settings, err := h.config.Reload()
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Configuration reload failed")
+		apierror.Write(c, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+	hc.logger.Info().Msg("Configuration reloaded")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, settings)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// handlerContext is what a handler needs to report on the request it serves:
+// its correlation ID and the request's logger, tagged with the correlation ID,
+// route and user by middleware.RequestLoggerMiddleware
+type handlerContext struct {
+	correlationID string
+	logger        *zerolog.Logger
+}
+
+func newHandlerContext(c *gin.Context, logger *zerolog.Logger) handlerContext {
+	return handlerContext{
+		correlationID: utils.CorrelationIDFrom(c),
+		logger:        middleware.LoggerFrom(c, logger),
+	}
+}
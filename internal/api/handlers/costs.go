@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/costs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// CostsHandler serves cost estimates
+type CostsHandler struct {
+	reporter *costs.Reporter
+	logger   *zerolog.Logger
+}
+
+// NewCostsHandler creates a new CostsHandler
+func NewCostsHandler(reporter *costs.Reporter, logger *zerolog.Logger) *CostsHandler {
+	return &CostsHandler{reporter: reporter, logger: logger}
+}
+
+// GetCostReport estimates the monthly cost per bucket, prefix or tenant
+// @Summary Get a cost report
+// @Description Estimate a month's storage, egress and operations cost per bucket, prefix or tenant with the MinIO and R2 price tables (COST_MINIO_PRICES, COST_R2_PRICES; COST_R2_BUCKETS picks the buckets on R2). Storage is charged for the whole month at the size of the month's last storage scan; egress and operations count the transfers through the API, so presigned transfers are left out.
+// @Tags admin
+// @Produce json
+// @Param month query string false "Month as YYYY-MM (default the current month)"
+// @Param group query string false "Grouping" Enums(bucket, prefix, tenant)
+// @Success 200 {object} costs.Report
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/cost-report [get]
+func (h *CostsHandler) GetCostReport(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	month := time.Now().UTC()
+	if v := c.Query("month"); v != "" {
+		parsed, err := time.Parse(costs.MonthLayout, v)
+		if err != nil {
+			utils.SendError(c, http.StatusBadRequest, "month must be formatted as YYYY-MM")
+			return
+		}
+		month = parsed
+	}
+	group := c.DefaultQuery("group", costs.GroupBucket)
+	switch group {
+	case costs.GroupBucket, costs.GroupPrefix, costs.GroupTenant:
+	default:
+		utils.SendError(c, http.StatusBadRequest, "group must be bucket, prefix or tenant")
+		return
+	}
+
+	report, err := h.reporter.Report(c.Request.Context(), month, group)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to build cost report")
+		apierror.Send(c, err, "Failed to build cost report")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, report)
+}
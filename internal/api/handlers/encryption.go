@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/sse"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// BucketEncryptionRequest is a bucket's default server-side encryption. An
+// empty mode means objects are stored unencrypted unless the upload asks otherwise.
+type BucketEncryptionRequest struct {
+	Mode     string `json:"mode" binding:"required,oneof=sse-s3 sse-kms" enums:"sse-s3,sse-kms" example:"sse-kms"`
+	KMSKeyID string `json:"kmsKeyId,omitempty" binding:"max=2048" example:"my-minio-key"`
+}
+
+// GetBucketEncryption returns a bucket's default encryption
+// @Summary Get bucket default encryption
+// @Description Return the encryption applied to objects uploaded without their own; an unencrypted bucket has an empty mode
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} BucketEncryptionRequest
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/encryption [get]
+func (h *MinioHandler) GetBucketEncryption(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	config, err := client.GetBucketEncryption(c.Request.Context(), bucket)
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "ServerSideEncryptionConfigurationNotFoundError":
+			utils.SendJSONWithCorrelationID(c, http.StatusOK, BucketEncryptionRequest{Mode: storage.SSEModeNone})
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to get bucket encryption")
+			apierror.Send(c, err, "Failed to get bucket encryption configuration")
+		}
+		return
+	}
+
+	resp := BucketEncryptionRequest{Mode: storage.SSEModeNone}
+	if len(config.Rules) > 0 {
+		rule := config.Rules[0].Apply
+		switch rule.SSEAlgorithm {
+		case "AES256":
+			resp.Mode = storage.SSEModeS3
+		case "aws:kms":
+			resp.Mode = storage.SSEModeKMS
+			resp.KMSKeyID = rule.KmsMasterKeyID
+		}
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, resp)
+}
+
+// SetBucketEncryption sets a bucket's default encryption
+// @Summary Set bucket default encryption
+// @Description Encrypt objects uploaded without their own encryption with SSE-S3 or an SSE-KMS key. Existing objects are not re-encrypted.
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketEncryptionRequest true "Encryption configuration"
+// @Success 200 {object} BucketEncryptionRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/encryption [put]
+func (h *MinioHandler) SetBucketEncryption(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	var req BucketEncryptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	config := sse.NewConfigurationSSES3()
+	if req.Mode == storage.SSEModeKMS {
+		if req.KMSKeyID == "" {
+			utils.SendError(c, http.StatusBadRequest, "kmsKeyId is required for sse-kms")
+			return
+		}
+		config = sse.NewConfigurationSSEKMS(req.KMSKeyID)
+	} else {
+		req.KMSKeyID = ""
+	}
+
+	if err := client.SetBucketEncryption(c.Request.Context(), bucket, config); err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		case "MalformedXML", "InvalidRequest", "InvalidArgument", "KMS.NotFoundException":
+			utils.SendError(c, http.StatusBadRequest, "Invalid encryption configuration")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to set bucket encryption")
+			apierror.Send(c, err, "Failed to set bucket encryption configuration")
+		}
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Str("mode", req.Mode).Msg("Bucket encryption updated")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, req)
+}
+
+// DeleteBucketEncryption removes a bucket's default encryption
+// @Summary Delete bucket default encryption
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/encryption [delete]
+func (h *MinioHandler) DeleteBucketEncryption(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	if err := client.RemoveBucketEncryption(c.Request.Context(), bucket); err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "ServerSideEncryptionConfigurationNotFoundError":
+			// Already unencrypted
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+			return
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to delete bucket encryption")
+			apierror.Send(c, err, "Failed to delete bucket encryption configuration")
+			return
+		}
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Msg("Bucket encryption removed")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message": "Bucket encryption configuration removed",
+		"name":    bucket,
+	})
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// eventStreamHeartbeat keeps idle streams open through proxies
+const eventStreamHeartbeat = 15 * time.Second
+
+// EventStreamHandler streams file events as server-sent events
+type EventStreamHandler struct {
+	stream *events.Stream
+	logger *zerolog.Logger
+}
+
+// NewEventStreamHandler creates a new EventStreamHandler
+func NewEventStreamHandler(stream *events.Stream, logger *zerolog.Logger) *EventStreamHandler {
+	return &EventStreamHandler{
+		stream: stream,
+		logger: logger,
+	}
+}
+
+// StreamEvents streams file events as they happen
+// @Summary Stream file events
+// @Description Server-sent events for every upload, delete, download and share link creation, from the moment the stream is opened. Each event is named after its type and its data is the JSON event. A client that falls behind misses events.
+// @Tags admin
+// @Produce text/event-stream
+// @Param type query string false "Comma-separated event types to receive (default all)"
+// @Success 200 {object} events.Event
+// @Router /admin/events [get]
+func (h *EventStreamHandler) StreamEvents(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	var types map[string]bool
+	if q := c.Query("type"); q != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(q, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case events.TypeUploaded, events.TypeDeleted, events.TypeDownloaded, events.TypeShared:
+				types[t] = true
+			default:
+				utils.SendError(c, http.StatusBadRequest, "unknown event type "+t)
+				return
+			}
+		}
+	}
+
+	// The stream stays open until the client leaves
+	finish := middleware.Deadline(c, 0)
+	defer finish()
+	sub, unsubscribe := h.stream.Subscribe()
+	defer unsubscribe()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	hc.logger.Debug().Msg("Event stream opened")
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	// Send the headers now so the client knows the stream is open
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case e, ok := <-sub:
+			if !ok {
+				return false
+			}
+			if types == nil || types[e.Type] {
+				c.Render(-1, sse.Event{Id: e.ID, Event: e.Type, Data: e})
+			}
+			return true
+		}
+	})
+	hc.logger.Debug().Msg("Event stream closed")
+}
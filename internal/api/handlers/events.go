@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// emit publishes an event about a file the request touched, adding who made
+// the request
+func (h *MinioHandler) emit(c *gin.Context, e events.Event) {
+	if h.events == nil {
+		return
+	}
+	e.Actor = auth.SubjectFrom(c)
+	e.ClientIP = c.ClientIP()
+	e.CorrelationID = utils.CorrelationIDFrom(c)
+	h.events.Publish(e)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/exif"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// boolOption reads a boolean query flag, falling back to the configured default
+func boolOption(c *gin.Context, name string, fallback bool) bool {
+	if v := c.Query(name); v != "" {
+		return v == "true" || v == "1"
+	}
+	return fallback
+}
+
+// GetExif returns EXIF and IPTC metadata of an image
+// @Summary Get EXIF/IPTC metadata
+// @Description Extract EXIF and IPTC metadata (camera, dates, dimensions, GPS, keywords) from a JPEG image
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Success 200 {object} exif.Metadata
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Router /files/{filename}/exif [get]
+func (h *MinioHandler) GetExif(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+
+	data, _, ok := h.readJPEG(c, hc, filename)
+	if !ok {
+		return
+	}
+	meta, err := exif.Extract(data)
+	if err != nil {
+		utils.SendError(c, http.StatusUnsupportedMediaType, "File is not a JPEG image")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, meta)
+}
+
+// StripExifGPS removes GPS data from a stored image
+// @Summary Strip GPS data
+// @Description Remove GPS location data from a stored JPEG image, preserving its other metadata
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "Another request is changing the file"
+// @Failure 415 {object} utils.ErrorResponse
+// @Router /files/{filename}/exif/strip-gps [post]
+func (h *MinioHandler) StripExifGPS(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+
+	scope := h.scope(c)
+	// Keep uploads and deletes off the image between reading and rewriting it
+	unlock, err := h.files.Lock(c.Request.Context(), scope.Bucket, scope.Key(filename))
+	if err != nil {
+		hc.logger.Warn().Err(err).Str("filename", filename).Msg("Failed to lock file")
+		apierror.Send(c, err, "Failed to lock file")
+		return
+	}
+	defer unlock()
+	data, stat, ok := h.readJPEG(c, hc, filename)
+	if !ok {
+		return
+	}
+	stripped, err := exif.StripGPS(data)
+	if err != nil {
+		utils.SendError(c, http.StatusUnsupportedMediaType, "File is not a JPEG image")
+		return
+	}
+
+	if stripped {
+		userMetadata := make(map[string]string, len(stat.Metadata)+1)
+		for k, v := range stat.Metadata {
+			userMetadata[k] = v
+		}
+		userMetadata["Exif-Gps-Stripped"] = "true"
+		delete(userMetadata, "Exif-Gps")
+		// Stored checksums no longer describe the rewritten content
+		for _, alg := range []checksum.Algorithm{checksum.SHA256, checksum.MD5, checksum.CRC32C} {
+			delete(userMetadata, checksum.MetadataKey(alg))
+		}
+		_, err = h.storage.Put(context.WithoutCancel(c.Request.Context()), scope.Bucket, scope.Key(filename),
+			bytes.NewReader(data), int64(len(data)),
+			storage.PutOptions{ContentType: stat.ContentType, Metadata: userMetadata})
+		if err != nil {
+			hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to store stripped image")
+			apierror.Send(c, err, "Failed to update file")
+			return
+		}
+		hc.logger.Info().Str("filename", filename).Msg("Stripped GPS data from image")
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"filename": filename,
+		"stripped": stripped,
+	})
+}
+
+// readJPEG loads a stored JPEG into memory, writing an error response on failure
+func (h *MinioHandler) readJPEG(c *gin.Context, hc handlerContext, filename string) ([]byte, storage.ObjectInfo, bool) {
+	scope := h.scope(c)
+	ctx := c.Request.Context()
+	stat, err := h.storage.Stat(ctx, scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return nil, stat, false
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return nil, stat, false
+	}
+	if stat.ContentType != "image/jpeg" {
+		utils.SendError(c, http.StatusUnsupportedMediaType, "File is not a JPEG image")
+		return nil, stat, false
+	}
+
+	object, _, err := h.storage.Get(ctx, scope.Bucket, scope.Key(filename))
+	if err == nil {
+		defer object.Close()
+		var data []byte
+		if data, err = io.ReadAll(object); err == nil {
+			return data, stat, true
+		}
+	}
+	hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to read file from MinIO")
+	apierror.Send(c, err, "Failed to get file")
+	return nil, stat, false
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// FetchHandler imports files from remote URLs
+type FetchHandler struct {
+	fetcher *fetch.Service
+	files   *MinioHandler
+	// syncMaxSize is the largest announced size fetched during the request
+	syncMaxSize int64
+	logger      *zerolog.Logger
+}
+
+// NewFetchHandler creates a new FetchHandler
+func NewFetchHandler(fetcher *fetch.Service, files *MinioHandler, syncMaxSize int64, logger *zerolog.Logger) *FetchHandler {
+	return &FetchHandler{fetcher: fetcher, files: files, syncMaxSize: syncMaxSize, logger: logger}
+}
+
+// FetchRequest is the body of a remote import
+type FetchRequest struct {
+	URL string `json:"url" binding:"required" example:"https://example.com/reports/q3.pdf"`
+	// Filename names the stored file; the remote name by default
+	Filename string `json:"filename,omitempty" example:"q3.pdf"`
+	// Async fetches in a background job whatever the size
+	Async bool `json:"async,omitempty"`
+}
+
+// FetchJobResponse is the state of a background fetch
+type FetchJobResponse struct {
+	ID        string        `json:"id"`
+	URL       string        `json:"url"`
+	Status    string        `json:"status" example:"pending"`
+	Attempts  int           `json:"attempts"`
+	LastError string        `json:"lastError,omitempty"`
+	Result    *fetch.Result `json:"result,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// FetchFile stores a file downloaded from a remote URL
+// @Summary Upload a file from a URL
+// @Description Download a file from a remote URL into the bucket, server-side. The URL must use a scheme of FETCH_ALLOWED_SCHEMES and a host of FETCH_ALLOWED_HOSTS, and may not resolve to a private address. The file goes through the same checks as an upload (size limit, content types, quota, collision strategy). Files announced over FETCH_SYNC_MAX_SIZE, or any with async set, are fetched by a background job: the response is a 202 with the job to follow at /files/fetch/{id}.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param request body FetchRequest true "Remote file"
+// @Param collision query string false "Key collision strategy" Enums(overwrite, suffix, uuid, reject)
+// @Param checksums query string false "Extra checksums to compute (comma-separated: md5, crc32c)"
+// @Param dedupe query bool false "Store the file under its content hash, reusing an identical existing object"
+// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} FetchJobResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse "The URL is not allowed"
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 502 {object} utils.ErrorResponse "The remote server failed"
+// @Failure 507 {object} utils.ErrorResponse
+// @Router /files/fetch [post]
+func (h *FetchHandler) FetchFile(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	var req FetchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	opts, ok := h.files.resolveUploadOptions(c)
+	if !ok {
+		return
+	}
+	request := fetch.Request{
+		URL:         req.URL,
+		Filename:    req.Filename,
+		Scope:       opts.scope,
+		Owner:       opts.owner,
+		Actor:       auth.SubjectFrom(c),
+		Policy:      opts.policy,
+		Algorithms:  opts.algorithms,
+		Deduplicate: opts.deduplicate,
+		Strategy:    opts.strategy,
+		ExtractExif: opts.extractExif,
+		StripGPS:    opts.stripGPS,
+		Tuning:      opts.tuning,
+	}
+
+	if !req.Async {
+		remote, err := h.fetcher.Open(c.Request.Context(), req.URL)
+		if err != nil {
+			h.sendFetchError(c, hc, req.URL, err)
+			return
+		}
+		if remote.Size < 0 || remote.Size <= h.syncMaxSize {
+			h.store(c, hc, opts, req, remote)
+			return
+		}
+		remote.Close()
+	} else if err := h.fetcher.Check(req.URL); err != nil {
+		h.sendFetchError(c, hc, req.URL, err)
+		return
+	}
+
+	job, err := h.fetcher.Enqueue(c.Request.Context(), request)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to queue remote fetch")
+		apierror.Send(c, err, "Failed to queue remote fetch")
+		return
+	}
+	hc.logger.Info().Str("job_id", job.ID).Str("url", req.URL).Msg("Remote fetch queued")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, fetchJobResponse(job, &request))
+}
+
+// store spools a remote file and stores it like an upload
+func (h *FetchHandler) store(c *gin.Context, hc handlerContext, opts uploadOptions, req FetchRequest, remote *fetch.Remote) {
+	file, err := h.fetcher.Spool(remote)
+	if err != nil {
+		h.sendFetchError(c, hc, req.URL, err)
+		return
+	}
+	defer file.Close()
+
+	name := req.Filename
+	if name == "" {
+		name = remote.Name
+	}
+	stored, err := h.files.storeUpload(c, opts, uploadInput{filename: name, body: file, size: file.Size})
+	if err != nil {
+		sendUploadError(c, err)
+		return
+	}
+	c.Set(middleware.AuditKeyKey, stored.key)
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message":      "File fetched successfully",
+		"filename":     opts.scope.Name(stored.key),
+		"key":          opts.scope.Name(stored.key),
+		"url":          req.URL,
+		"size":         stored.size,
+		"bucketName":   stored.bucket,
+		"contentType":  stored.contentType,
+		"checksums":    stored.sums,
+		"deduplicated": stored.deduplicated,
+	})
+}
+
+// GetFetch returns a background fetch of the caller
+// @Summary Get a background fetch
+// @Description Return the state of a remote fetch queued by the caller and, once it succeeded, the stored file
+// @Tags files
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} FetchJobResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/fetch/{id} [get]
+func (h *FetchHandler) GetFetch(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	job, request, err := h.fetcher.Job(c.Request.Context(), c.Param("id"), auth.SubjectFrom(c))
+	if err != nil {
+		if errors.Is(err, fetch.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "Fetch not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to get remote fetch")
+		apierror.Send(c, err, "Failed to get remote fetch")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, fetchJobResponse(job, request))
+}
+
+func fetchJobResponse(job *jobs.Job, request *fetch.Request) FetchJobResponse {
+	response := FetchJobResponse{
+		ID:        job.ID,
+		URL:       request.URL,
+		Status:    job.Status,
+		Attempts:  job.Attempts,
+		LastError: job.LastError,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if job.Result != nil {
+		var result fetch.Result
+		if json.Unmarshal(job.Result, &result) == nil {
+			response.Result = &result
+		}
+	}
+	return response
+}
+
+// sendFetchError writes the response for a remote file that can't be fetched
+func (h *FetchHandler) sendFetchError(c *gin.Context, hc handlerContext, rawURL string, err error) {
+	var remote *fetch.RemoteError
+	switch {
+	case errors.Is(err, fetch.ErrNotAllowed):
+		hc.logger.Warn().Err(err).Str("url", rawURL).Msg("Refused remote fetch")
+		apierror.Write(c, http.StatusForbidden, apierror.CodeForbidden, err.Error())
+	case errors.Is(err, fetch.ErrTooLarge):
+		apierror.Write(c, http.StatusRequestEntityTooLarge, apierror.CodeFileTooLarge, "Remote file exceeds the maximum allowed size")
+	case errors.As(err, &remote):
+		apierror.Write(c, http.StatusBadGateway, apierror.CodeRemoteFailed, err.Error())
+	default:
+		hc.logger.Warn().Err(err).Str("url", rawURL).Msg("Failed to fetch remote file")
+		apierror.Write(c, http.StatusBadGateway, apierror.CodeRemoteFailed, "Failed to fetch the remote file")
+	}
+}
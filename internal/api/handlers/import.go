@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/importer"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// ImportHandler imports the objects of other S3 buckets
+type ImportHandler struct {
+	importer *importer.Service
+	files    *MinioHandler
+	logger   *zerolog.Logger
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(svc *importer.Service, files *MinioHandler, logger *zerolog.Logger) *ImportHandler {
+	return &ImportHandler{importer: svc, files: files, logger: logger}
+}
+
+// ImportRequest is the body of a bucket import
+type ImportRequest struct {
+	// Endpoint is the source's S3 endpoint; AWS S3 by default
+	Endpoint string `json:"endpoint,omitempty" example:"https://s3.eu-west-1.amazonaws.com"`
+	Region   string `json:"region,omitempty" example:"eu-west-1"`
+	Bucket   string `json:"bucket" binding:"required" example:"old-uploads"`
+	// Prefix limits the import to the keys below it
+	Prefix          string `json:"prefix,omitempty" example:"photos/"`
+	AccessKeyID     string `json:"accessKeyId" binding:"required"`
+	SecretAccessKey string `json:"secretAccessKey" binding:"required"`
+	// SessionToken comes with temporary credentials
+	SessionToken string `json:"sessionToken,omitempty"`
+	// Folder is where the keys below the prefix land; the root by default
+	Folder string `json:"folder,omitempty" example:"imported"`
+	// SkipExisting keeps files already stored under an imported name
+	SkipExisting bool `json:"skipExisting,omitempty"`
+}
+
+// ImportResponse is the state and progress of an import
+type ImportResponse struct {
+	ID     string          `json:"id"`
+	Status string          `json:"status" example:"running"`
+	Source importer.Source `json:"source"`
+	Folder string          `json:"folder,omitempty"`
+	// Copied, Skipped and Failed count the objects done so far, Bytes the size copied
+	Copied   int64              `json:"copied"`
+	Bytes    int64              `json:"bytes"`
+	Skipped  int64              `json:"skipped"`
+	Failed   int64              `json:"failed"`
+	Failures []importer.Failure `json:"failures,omitempty"`
+	// LastKey is the last source key done
+	LastKey     string     `json:"lastKey,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// StartImport starts copying the objects of another S3 bucket
+// @Summary Import files from an S3 bucket
+// @Description Copy every object of another S3-compatible bucket, or those below a prefix, into the caller's files, for moving from another provider. The credentials, temporary ones with a session token preferably, only need to list and read the source; they are checked before the import starts and deleted once it ends. A background job stores each object like an upload (size limit, content types, quota), overwriting files of the same name unless skipExisting is set; follow its progress at /files/import/{id}. The endpoint's host must be listed in IMPORT_ALLOWED_ENDPOINTS and may not resolve to a private address.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param request body ImportRequest true "Source bucket and credentials"
+// @Param checksums query string false "Extra checksums to compute (comma-separated: md5, crc32c)"
+// @Param dedupe query bool false "Store files under their content hash, reusing identical existing objects"
+// @Success 202 {object} ImportResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse "The endpoint is not allowed"
+// @Failure 422 {object} utils.ErrorResponse "The source bucket is not readable with the credentials"
+// @Router /files/import [post]
+func (h *ImportHandler) StartImport(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	var req ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	opts, ok := h.files.resolveUploadOptions(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.importer.Start(c.Request.Context(), importer.Request{
+		Source: importer.Source{Endpoint: req.Endpoint, Region: req.Region, Bucket: req.Bucket, Prefix: req.Prefix},
+		Credentials: importer.Credentials{
+			AccessKeyID:     req.AccessKeyID,
+			SecretAccessKey: req.SecretAccessKey,
+			SessionToken:    req.SessionToken,
+		},
+		Dir:          keygen.SanitizeDir(req.Folder),
+		Scope:        opts.scope,
+		Owner:        opts.owner,
+		Actor:        auth.SubjectFrom(c),
+		Policy:       opts.policy,
+		Algorithms:   opts.algorithms,
+		Deduplicate:  opts.deduplicate,
+		SkipExisting: req.SkipExisting,
+		ExtractExif:  opts.extractExif,
+		StripGPS:     opts.stripGPS,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, importer.ErrInvalidEndpoint):
+			utils.SendError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, fetch.ErrNotAllowed):
+			hc.logger.Warn().Err(err).Str("endpoint", req.Endpoint).Msg("Refused import")
+			apierror.Write(c, http.StatusForbidden, apierror.CodeForbidden, err.Error())
+		case errors.Is(err, importer.ErrSource):
+			apierror.Write(c, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, err.Error())
+		default:
+			hc.logger.Error().Err(err).Msg("Failed to start import")
+			apierror.Send(c, err, "Failed to start import")
+		}
+		return
+	}
+	hc.logger.Info().Str("import_id", imp.ID).Str("source_bucket", req.Bucket).Str("prefix", req.Prefix).Msg("Import started")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, importResponse(imp))
+}
+
+// GetImport returns an import of the caller
+// @Summary Get an import
+// @Description Return the progress of an import started by the caller: the objects copied, skipped and failed so far, and the last source key done
+// @Tags files
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} ImportResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/import/{id} [get]
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	imp, err := h.importer.Get(c.Request.Context(), c.Param("id"), auth.SubjectFrom(c))
+	if err != nil {
+		h.sendImportError(c, err, "Failed to get import")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, importResponse(imp))
+}
+
+// CancelImport stops an import of the caller
+// @Summary Cancel an import
+// @Description Stop an import started by the caller and delete its credentials. Files already copied are kept.
+// @Tags files
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} ImportResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "The import already ended"
+// @Router /files/import/{id} [delete]
+func (h *ImportHandler) CancelImport(c *gin.Context) {
+	imp, err := h.importer.Cancel(c.Request.Context(), c.Param("id"), auth.SubjectFrom(c))
+	if err != nil {
+		h.sendImportError(c, err, "Failed to cancel import")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, importResponse(imp))
+}
+
+func (h *ImportHandler) sendImportError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, importer.ErrNotFound):
+		apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "Import not found")
+	case errors.Is(err, importer.ErrFinished):
+		apierror.Write(c, http.StatusConflict, apierror.CodeConflict, "Import already ended")
+	default:
+		newHandlerContext(c, h.logger).logger.Error().Err(err).Str("import_id", c.Param("id")).Msg(message)
+		apierror.Send(c, err, message)
+	}
+}
+
+func importResponse(imp *importer.Import) ImportResponse {
+	return ImportResponse{
+		ID:          imp.ID,
+		Status:      imp.Status,
+		Source:      imp.Source,
+		Folder:      imp.Dir,
+		Copied:      imp.Copied,
+		Bytes:       imp.Bytes,
+		Skipped:     imp.Skipped,
+		Failed:      imp.Failed,
+		Failures:    imp.Failures,
+		LastKey:     imp.After,
+		LastError:   imp.LastError,
+		CreatedAt:   imp.CreatedAt,
+		UpdatedAt:   imp.UpdatedAt,
+		CompletedAt: imp.CompletedAt,
+	}
+}
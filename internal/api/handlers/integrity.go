@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/integrity"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// IntegrityHandler starts integrity audits of stored objects
+type IntegrityHandler struct {
+	checker       *integrity.Checker
+	defaultBucket string
+	logger        *zerolog.Logger
+}
+
+// NewIntegrityHandler creates a new IntegrityHandler
+func NewIntegrityHandler(checker *integrity.Checker, defaultBucket string, logger *zerolog.Logger) *IntegrityHandler {
+	return &IntegrityHandler{checker: checker, defaultBucket: defaultBucket, logger: logger}
+}
+
+// Verify queues the verification of a bucket's objects
+// @Summary Verify stored objects
+// @Description Queue a job that reads every object of the bucket below the prefix, recomputes the checksums recorded at upload and compares them. Its result (GET /admin/jobs/{id}) lists the corrupted objects and, with the metadata database, the objects it records that storage no longer has. Large buckets are verified by a chain of jobs: each result names the job continuing it in continuedBy, and the last one is complete.
+// @Tags admin
+// @Produce json
+// @Param bucket query string false "Bucket (defaults to the API bucket)"
+// @Param prefix query string false "Key prefix"
+// @Success 202 {object} jobs.Job
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/verify [post]
+func (h *IntegrityHandler) Verify(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket, prefix := c.DefaultQuery("bucket", h.defaultBucket), c.Query("prefix")
+
+	job, err := h.checker.Start(c.Request.Context(), bucket, prefix)
+	if err != nil {
+		if errors.Is(err, storage.ErrBucketNotFound) || errors.Is(err, storage.ErrInvalidBucket) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to start integrity verification")
+		apierror.Send(c, err, "Failed to start integrity verification")
+		return
+	}
+	hc.logger.Info().Str("job_id", job.ID).Str("bucket", bucket).Str("prefix", prefix).Msg("Integrity verification queued")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, job)
+}
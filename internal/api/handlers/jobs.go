@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// JobsHandler exposes the background job queue
+type JobsHandler struct {
+	jobs   *jobs.Queue
+	logger *zerolog.Logger
+}
+
+// NewJobsHandler creates a new JobsHandler
+func NewJobsHandler(queue *jobs.Queue, logger *zerolog.Logger) *JobsHandler {
+	return &JobsHandler{
+		jobs:   queue,
+		logger: logger,
+	}
+}
+
+// ListJobs lists queued and failed jobs
+// @Summary List background jobs
+// @Description List pending, running and failed jobs, oldest first. Completed jobs are removed, except the last 20 of each kind that report a result (such as cleanup runs), which are listed as succeeded.
+// @Tags admin
+// @Produce json
+// @Param status query string false "Job status" Enums(pending, running, failed, succeeded)
+// @Param limit query int false "Maximum number of jobs (default 100, max 1000)"
+// @Success 200 {array} jobs.Job
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /admin/jobs [get]
+func (h *JobsHandler) ListJobs(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	status := c.Query("status")
+	switch status {
+	case "", jobs.StatusPending, jobs.StatusRunning, jobs.StatusFailed, jobs.StatusSucceeded:
+	default:
+		utils.SendError(c, http.StatusBadRequest, "status must be pending, running, failed or succeeded")
+		return
+	}
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			utils.SendError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	list, err := h.jobs.List(c.Request.Context(), status, limit)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to list jobs")
+		apierror.Send(c, err, "Failed to list jobs")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, list)
+}
+
+// GetJob returns a job
+// @Summary Get a background job
+// @Tags admin
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} jobs.Job
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/jobs/{id} [get]
+func (h *JobsHandler) GetJob(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	job, err := h.jobs.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Job not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to get job")
+		apierror.Send(c, err, "Failed to get job")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, job)
+}
+
+// RetryJob reschedules a failed job
+// @Summary Retry a failed background job
+// @Tags admin
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} jobs.Job
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/jobs/{id}/retry [post]
+func (h *JobsHandler) RetryJob(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	job, err := h.jobs.Retry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Job not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to retry job")
+		apierror.Send(c, err, "Failed to retry job")
+		return
+	}
+	hc.logger.Info().Str("job_id", job.ID).Str("kind", job.Kind).Msg("Job rescheduled")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, job)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// Lifecycle rule statuses
+const (
+	LifecycleEnabled  = "Enabled"
+	LifecycleDisabled = "Disabled"
+)
+
+// LifecycleRule expires objects under a prefix and/or aborts incomplete
+// multipart uploads under it after a number of days. An empty prefix applies
+// to the whole bucket.
+type LifecycleRule struct {
+	ID                        string `json:"id" binding:"required,max=255" example:"expire-tmp"`
+	Prefix                    string `json:"prefix,omitempty" example:"tmp/"`
+	Status                    string `json:"status,omitempty" binding:"omitempty,oneof=Enabled Disabled" enums:"Enabled,Disabled" example:"Enabled"`
+	ExpirationDays            int    `json:"expirationDays,omitempty" binding:"min=0" minimum:"0" example:"7"`
+	AbortIncompleteUploadDays int    `json:"abortIncompleteUploadDays,omitempty" binding:"min=0" minimum:"0" example:"1"`
+}
+
+// BucketLifecycleRequest is a bucket's lifecycle configuration
+type BucketLifecycleRequest struct {
+	Rules []LifecycleRule `json:"rules" binding:"required,min=1,max=1000,dive"`
+}
+
+// GetBucketLifecycle returns a bucket's lifecycle rules
+// @Summary Get bucket lifecycle rules
+// @Description Return the bucket's expiration rules; a bucket without a lifecycle configuration has no rules
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} BucketLifecycleRequest
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/lifecycle [get]
+func (h *MinioHandler) GetBucketLifecycle(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	rules := []LifecycleRule{}
+	config, err := client.GetBucketLifecycle(c.Request.Context(), bucket)
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchLifecycleConfiguration":
+			utils.SendJSONWithCorrelationID(c, http.StatusOK, BucketLifecycleRequest{Rules: rules})
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to get bucket lifecycle")
+			apierror.Send(c, err, "Failed to get bucket lifecycle configuration")
+		}
+		return
+	}
+
+	for _, r := range config.Rules {
+		prefix := r.RuleFilter.Prefix
+		if prefix == "" {
+			prefix = r.RuleFilter.And.Prefix
+		}
+		if prefix == "" {
+			prefix = r.Prefix
+		}
+		rules = append(rules, LifecycleRule{
+			ID:                        r.ID,
+			Prefix:                    prefix,
+			Status:                    r.Status,
+			ExpirationDays:            int(r.Expiration.Days),
+			AbortIncompleteUploadDays: int(r.AbortIncompleteMultipartUpload.DaysAfterInitiation),
+		})
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, BucketLifecycleRequest{Rules: rules})
+}
+
+// SetBucketLifecycle replaces a bucket's lifecycle rules
+// @Summary Set bucket lifecycle rules
+// @Description Replace the bucket's lifecycle configuration, e.g. to delete objects under a temporary-upload prefix after N days and abort multipart uploads left incomplete. Each rule needs expirationDays, abortIncompleteUploadDays or both.
+// @Tags buckets
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Param request body BucketLifecycleRequest true "Lifecycle rules"
+// @Success 200 {object} BucketLifecycleRequest
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/lifecycle [put]
+func (h *MinioHandler) SetBucketLifecycle(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	var req BucketLifecycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	config := lifecycle.NewConfiguration()
+	seen := make(map[string]bool, len(req.Rules))
+	for i := range req.Rules {
+		r := &req.Rules[i]
+		if seen[r.ID] {
+			utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("Duplicate rule id %q", r.ID))
+			return
+		}
+		seen[r.ID] = true
+		if r.ExpirationDays == 0 && r.AbortIncompleteUploadDays == 0 {
+			utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("Rule %q needs expirationDays or abortIncompleteUploadDays", r.ID))
+			return
+		}
+		if r.Status == "" {
+			r.Status = LifecycleEnabled
+		}
+		config.Rules = append(config.Rules, lifecycle.Rule{
+			ID:         r.ID,
+			Status:     r.Status,
+			RuleFilter: lifecycle.Filter{Prefix: r.Prefix},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(r.ExpirationDays)},
+			AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: lifecycle.ExpirationDays(r.AbortIncompleteUploadDays),
+			},
+		})
+	}
+
+	if err := client.SetBucketLifecycle(c.Request.Context(), bucket, config); err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchBucket":
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		case "MalformedXML", "InvalidRequest", "InvalidArgument":
+			utils.SendError(c, http.StatusBadRequest, "Invalid lifecycle configuration")
+		default:
+			hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to set bucket lifecycle")
+			apierror.Send(c, err, "Failed to set bucket lifecycle configuration")
+		}
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Int("rules", len(config.Rules)).Msg("Bucket lifecycle updated")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, req)
+}
+
+// DeleteBucketLifecycle removes a bucket's lifecycle configuration
+// @Summary Delete bucket lifecycle rules
+// @Tags buckets
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /buckets/{bucket}/lifecycle [delete]
+func (h *MinioHandler) DeleteBucketLifecycle(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.Param("bucket")
+	client, ok := h.s3Client(c, bucket)
+	if !ok {
+		return
+	}
+
+	// An empty configuration removes the bucket's lifecycle
+	if err := client.SetBucketLifecycle(c.Request.Context(), bucket, lifecycle.NewConfiguration()); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchBucket" {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("bucket", bucket).Msg("Failed to delete bucket lifecycle")
+		apierror.Send(c, err, "Failed to delete bucket lifecycle configuration")
+		return
+	}
+
+	hc.logger.Info().Str("bucket", bucket).Msg("Bucket lifecycle removed")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message": "Bucket lifecycle configuration removed",
+		"name":    bucket,
+	})
+}
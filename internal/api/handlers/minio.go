@@ -2,32 +2,87 @@ package handlers
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
 	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/httpcache"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/moderation"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
 	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
-	"github.com/minio/minio-go/v7"
 	"github.com/rs/zerolog"
 )
 
 // MinioHandler handles operations related to MinIO
 type MinioHandler struct {
-	minioClient *minio.Client
+	storage     storage.Backend
 	logger      *zerolog.Logger
 	config      *config.Config
+	progress    *progress.Hub
+	typePolicy  *filetype.Policy
+	store       store.Store
+	cachePolicy *httpcache.Policy
+	quotas      *quota.Service
+	files       service.FileService
+	events      events.Publisher
+	presigned   *presigned.Service
+	// riskyTypes are the content types of DOWNLOAD_RISKY_TYPES, nil without any
+	riskyTypes *filetype.Policy
 }
 
-// NewMinioHandler creates a new MinioHandler
-func NewMinioHandler(minioClient *minio.Client, logger *zerolog.Logger, cfg *config.Config) *MinioHandler {
+// NewMinioHandler creates a new MinioHandler. Files are written and removed
+// through files; backend serves reads. publisher, which may be nil, receives
+// the uploads, downloads and deletes of every handler built on this one.
+// uploads completes presigned PUTs; without it presigns can't ask for
+// completion. Tagged uploads report progress to hub.
+func NewMinioHandler(files service.FileService, backend storage.Backend, metaStore store.Store, quotas *quota.Service, publisher events.Publisher, uploads *presigned.Service, hub *progress.Hub, logger *zerolog.Logger, cfg *config.Config) *MinioHandler {
+	cacheRules, err := httpcache.ParseRules(cfg.CacheControlRules)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Ignoring invalid CACHE_CONTROL_RULES")
+	}
+	var riskyTypes *filetype.Policy
+	if len(cfg.DownloadRiskyTypes) > 0 {
+		riskyTypes = filetype.NewPolicy(cfg.DownloadRiskyTypes, nil)
+	}
 	return &MinioHandler{
-		minioClient: minioClient,
+		storage:     backend,
+		store:       metaStore,
+		quotas:      quotas,
+		files:       files,
+		events:      publisher,
+		presigned:   uploads,
 		logger:      logger,
 		config:      cfg,
+		progress:    hub,
+		typePolicy:  filetype.NewPolicy(cfg.UploadAllowedTypes, cfg.UploadDeniedTypes),
+		cachePolicy: &httpcache.Policy{Default: cfg.CacheControlDefault, Rules: cacheRules},
+		riskyTypes:  riskyTypes,
 	}
 }
 
@@ -38,160 +93,606 @@ func NewMinioHandler(minioClient *minio.Client, logger *zerolog.Logger, cfg *con
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "File to upload"
+// @Param X-Upload-ID header string false "Client-chosen ID used to stream progress via /ws/uploads/{upload_id}"
+// @Param collision query string false "Key collision strategy" Enums(overwrite, suffix, uuid, reject)
+// @Param checksums query string false "Extra checksums to compute (comma-separated: md5, crc32c)"
+// @Param X-Content-SHA256 header string false "Expected hex SHA-256 of the file; mismatches are rejected"
+// @Param Content-MD5 header string false "Base64 MD5 of the file, checked by the API and by storage; mismatches are rejected with BAD_DIGEST"
+// @Param dedupe query bool false "Store the file under its content hash, reusing an identical existing object"
+// @Param exif query bool false "Extract EXIF/IPTC metadata from images into object metadata"
+// @Param strip_gps query bool false "Remove GPS location data from JPEG images before storing"
+// @Param part_size query int false "Multipart part size in bytes (5 MiB to 5 GiB)"
+// @Param concurrency query int false "Parts uploaded to storage at once"
+// @Param content_md5 query bool false "Have storage verify every part against its MD5"
+// @Param multipart_threshold query int false "Files smaller than this many bytes are stored in a single request"
+// @Param X-SSE-Customer-Key header string false "Base64 AES-256 key to encrypt the file with (SSE-C); the same key is needed to read it"
+// @Param X-SSE-Customer-Key-MD5 header string false "Base64 MD5 of the customer key"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Failure 507 {object} utils.ErrorResponse
 // @Router /files [post]
 func (h *MinioHandler) UploadFile(c *gin.Context) {
-	correlationID, _ := c.Get("CorrelationID")
-	correlationIDStr, _ := correlationID.(string)
+	hc := newHandlerContext(c, h.logger)
 	// Get file from form
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Msg("Failed to get file from form")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			hc.logger.Warn().Int64("limit", maxBytesErr.Limit).Msg("Upload body exceeds size limit")
+			utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to get file from form")
 		utils.SendError(c, http.StatusBadRequest, "Failed to get file")
 		return
 	}
 	defer file.Close()
 
-	// Generate object name (using original filename)
-	objectName := header.Filename
-	contentType := header.Header.Get("Content-Type")
-
-	// If content type is not provided, try to determine it from the file extension
-	if contentType == "" {
-		contentType = "application/octet-stream"
-		ext := filepath.Ext(objectName)
-		switch ext {
-		case ".jpg", ".jpeg":
-			contentType = "image/jpeg"
-		case ".png":
-			contentType = "image/png"
-		case ".pdf":
-			contentType = "application/pdf"
-		case ".txt":
-			contentType = "text/plain"
-		case ".mp4":
-			contentType = "video/mp4"
-		}
+	contentMD5, ok := parseContentMD5(c.GetHeader("Content-MD5"))
+	if !ok {
+		utils.SendError(c, http.StatusBadRequest, "Content-MD5 must be the base64 MD5 of the file")
+		return
+	}
+	opts, ok := h.resolveUploadOptions(c)
+	if !ok {
+		return
 	}
 
-	// Upload the file to MinIO
-	info, err := h.minioClient.PutObject(
-		context.Background(),
-		h.config.MinioBucketName,
-		objectName,
-		file,
-		header.Size,
-		minio.PutObjectOptions{ContentType: contentType},
-	)
+	// Report progress to WebSocket subscribers when the client tagged the upload
+	uploadID := c.GetHeader(UploadIDHeader)
+	if uploadID == "" {
+		uploadID = c.Query("upload_id")
+	}
+
+	stored, err := h.storeUpload(c, opts, uploadInput{
+		filename:   header.Filename,
+		body:       file,
+		size:       header.Size,
+		sha256:     c.GetHeader(checksum.SHA256Header),
+		contentMD5: contentMD5,
+		uploadID:   uploadID,
+	})
 	if err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Msg("Failed to upload file to MinIO")
-		utils.SendError(c, http.StatusInternalServerError, "Failed to upload file")
+		sendUploadError(c, err)
 		return
 	}
+	c.Set(middleware.AuditKeyKey, stored.key)
 
-	h.logger.Info().
-		Str("correlation_id", correlationIDStr).
-		Str("bucket", info.Bucket).
-		Str("object", info.Key).
-		Int64("size", info.Size).
-		Msg("File uploaded successfully")
+	response := map[string]interface{}{
+		"message":          "File uploaded successfully",
+		"filename":         opts.scope.Name(stored.key),
+		"key":              opts.scope.Name(stored.key),
+		"originalFilename": header.Filename,
+		"size":             stored.size,
+		"bucketName":       stored.bucket,
+		"contentType":      stored.contentType,
+		"checksums":        stored.sums,
+		"deduplicated":     stored.deduplicated,
+	}
+	if stored.deduplicated {
+		response["message"] = "File already exists, upload deduplicated"
+	} else if uploadID != "" {
+		response["uploadId"] = uploadID
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, response)
+}
 
-	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
-		"message":    "File uploaded successfully",
-		"filename":   objectName,
-		"size":       info.Size,
-		"bucketName": info.Bucket,
+// uploadOptions are the settings a request applies to every file it uploads
+type uploadOptions struct {
+	scope       tenancy.Scope
+	policy      *filetype.Policy
+	algorithms  []checksum.Algorithm
+	deduplicate bool
+	strategy    keygen.Strategy
+	// owner is the subject charged for the uploads, if quotas apply
+	owner       string
+	extractExif bool
+	stripGPS    bool
+	// restrict narrows policy further and maxSize the per-file limit, for
+	// uploads through an upload link
+	restrict *filetype.Policy
+	maxSize  int64
+	// tuning overrides how files are sent to storage; nil keeps the configured tuning
+	tuning *storage.PutTuning
+}
+
+// resolveUploadOptions reads the upload settings of a request, writing a 400
+// response when one is invalid
+func (h *MinioHandler) resolveUploadOptions(c *gin.Context) (uploadOptions, bool) {
+	opts := uploadOptions{
+		scope:       h.scope(c),
+		policy:      h.contentTypePolicy(c),
+		deduplicate: h.config.UploadDedupe,
+		extractExif: boolOption(c, "exif", h.config.UploadExtractExif),
+		stripGPS:    boolOption(c, "strip_gps", h.config.UploadStripGPS),
+	}
+	if identity := auth.IdentityFrom(c); identity != nil {
+		opts.owner = identity.Subject
+	}
+
+	algorithmNames := h.config.UploadChecksums
+	if q := c.Query("checksums"); q != "" {
+		algorithmNames = strings.Split(q, ",")
+	}
+	var err error
+	if opts.algorithms, err = checksum.ParseAlgorithms(algorithmNames); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return opts, false
+	}
+
+	if opts.tuning, err = h.putTuning(c); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return opts, false
+	}
+
+	if q := c.Query("dedupe"); q != "" {
+		opts.deduplicate = q == "true"
+	}
+	if !opts.deduplicate {
+		strategyName := c.DefaultQuery("collision", c.Request.FormValue("collision"))
+		if strategyName == "" {
+			strategyName = h.config.UploadCollisionStrategy
+		}
+		if opts.strategy, err = keygen.ParseStrategy(strategyName); err != nil {
+			utils.SendError(c, http.StatusBadRequest, err.Error())
+			return opts, false
+		}
+	}
+	return opts, true
+}
+
+// parseContentMD5 returns the hex digest of a Content-MD5 header, which is
+// the base64 of the raw digest (RFC 1864), and false when it is malformed
+func parseContentMD5(header string) (string, bool) {
+	if header == "" {
+		return "", true
+	}
+	sum, err := base64.StdEncoding.DecodeString(header)
+	if err != nil || len(sum) != md5.Size {
+		return "", false
+	}
+	return hex.EncodeToString(sum), true
+}
+
+// uploadInput is one file to store
+type uploadInput struct {
+	// filename is the client filename, sanitized into the object name
+	filename string
+	// dir is a sanitized folder below the scope, ending in "/"
+	dir  string
+	body io.ReadSeeker
+	size int64
+	// sha256 is the digest the client expects, if it sent one
+	sha256 string
+	// contentMD5 is the hex MD5 the client expects, if it sent one
+	contentMD5 string
+	// uploadID tags the upload for progress subscribers
+	uploadID string
+}
+
+// storedUpload describes a stored file
+type storedUpload struct {
+	key          string
+	bucket       string
+	contentType  string
+	size         int64
+	sums         map[checksum.Algorithm]string
+	deduplicated bool
+}
+
+// uploadError rejects a file with a status and message meant for the client
+type uploadError struct {
+	status  int
+	code    apierror.Code
+	message string
+}
+
+func (e *uploadError) Error() string { return e.message }
+
+func rejectUpload(status int, message string) *uploadError {
+	return &uploadError{status: status, code: apierror.CodeForStatus(status), message: message}
+}
+
+// sendUploadError writes the response for a storeUpload error
+func sendUploadError(c *gin.Context, err error) {
+	var rejected *uploadError
+	if errors.As(err, &rejected) {
+		apierror.Write(c, rejected.status, rejected.code, rejected.message)
+		return
+	}
+	apierror.Send(c, err, "Failed to upload file")
+}
+
+// storeUpload stores a file through the file service, mapping rejections to
+// an *uploadError and logging the outcome. It is safe to call concurrently for
+// one request.
+func (h *MinioHandler) storeUpload(c *gin.Context, opts uploadOptions, in uploadInput) (storedUpload, error) {
+	hc := newHandlerContext(c, h.logger)
+
+	req := service.UploadRequest{
+		Scope:         opts.scope,
+		Dir:           in.dir,
+		Filename:      in.filename,
+		Body:          in.body,
+		Size:          in.size,
+		SHA256:        in.sha256,
+		ContentMD5:    in.contentMD5,
+		Policy:        opts.policy,
+		Restrict:      opts.restrict,
+		MaxSize:       opts.maxSize,
+		Algorithms:    opts.algorithms,
+		Deduplicate:   opts.deduplicate,
+		Strategy:      opts.strategy,
+		Owner:         opts.owner,
+		ExtractExif:   opts.extractExif,
+		StripGPS:      opts.stripGPS,
+		Tuning:        opts.tuning,
+		CorrelationID: hc.correlationID,
+	}
+	// Report progress to WebSocket subscribers when the client tagged the upload
+	if in.uploadID != "" {
+		owner := progressOwner(c, opts.scope)
+		req.Track = func(size int64) *progress.Tracker { return h.progress.Track(in.uploadID, owner, size) }
+	}
+
+	upload, err := h.files.Upload(c.Request.Context(), req)
+	if err != nil {
+		log := hc.logger.Warn().Err(err).Str("filename", in.filename)
+		var typeErr *service.TypeError
+		switch {
+		case errors.Is(err, service.ErrTooLarge):
+			log.Int64("size", in.size).Msg("Uploaded file exceeds size limit")
+			return storedUpload{}, rejectUpload(http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+		case errors.Is(err, service.ErrUnreadable):
+			log.Msg("Failed to inspect uploaded file")
+			return storedUpload{}, rejectUpload(http.StatusBadRequest, "Failed to read file")
+		case errors.As(err, &typeErr):
+			log.Str("detected_type", typeErr.ContentType).Msg("Rejected upload with disallowed content type")
+			return storedUpload{}, rejectUpload(http.StatusUnsupportedMediaType, fmt.Sprintf("Content type %s is not allowed", typeErr.ContentType))
+		case errors.Is(err, service.ErrChecksumMismatch):
+			log.Str("expected", in.sha256).Msg("Upload checksum mismatch")
+			return storedUpload{}, rejectUpload(http.StatusBadRequest, "SHA-256 checksum mismatch")
+		case errors.Is(err, storage.ErrBadDigest):
+			log.Str("expected", in.contentMD5).Msg("Upload Content-MD5 mismatch")
+			return storedUpload{}, &uploadError{status: http.StatusBadRequest, code: apierror.CodeBadDigest, message: "Content-MD5 mismatch"}
+		case errors.Is(err, keygen.ErrKeyExists):
+			return storedUpload{}, rejectUpload(http.StatusConflict, "A file with this name already exists")
+		case errors.Is(err, quota.ErrQuotaExceeded):
+			log.Str("subject", opts.owner).Msg("Upload rejected, quota exceeded")
+			return storedUpload{}, &uploadError{status: http.StatusInsufficientStorage, code: apierror.CodeQuotaExceeded, message: "Storage quota exceeded"}
+		case errors.Is(err, objectlock.ErrTimeout):
+			log.Msg("Upload rejected, file locked by another request")
+			return storedUpload{}, &uploadError{status: http.StatusConflict, code: apierror.CodeObjectLocked, message: "The file is being changed by another request, try again"}
+		}
+		hc.logger.Error().Err(err).Str("filename", in.filename).Msg("Failed to upload file")
+		return storedUpload{}, err
+	}
+
+	if upload.Deduplicated {
+		hc.logger.Info().Str("object", upload.Key).Msg("Upload deduplicated against existing object")
+	} else {
+		hc.logger.Info().
+			Str("bucket", upload.Bucket).
+			Str("object", upload.Key).
+			Int64("size", upload.Size).
+			Msg("File uploaded successfully")
+	}
+	h.emit(c, events.Event{
+		Type:        events.TypeUploaded,
+		Bucket:      upload.Bucket,
+		Key:         upload.Key,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		TenantID:    opts.scope.TenantID,
 	})
+	return storedUpload{
+		key:          upload.Key,
+		bucket:       upload.Bucket,
+		contentType:  upload.ContentType,
+		size:         upload.Size,
+		sums:         upload.Sums,
+		deduplicated: upload.Deduplicated,
+	}, nil
+}
+
+// contentTypePolicy returns the route-specific upload policy, falling back to the configured one
+func (h *MinioHandler) contentTypePolicy(c *gin.Context) *filetype.Policy {
+	if v, ok := c.Get(middleware.ContentTypePolicyKey); ok {
+		if policy, ok := v.(*filetype.Policy); ok {
+			return policy
+		}
+	}
+	return h.typePolicy
+}
+
+// s3Client returns the S3 client for S3-only features, writing a 501 response
+// when the configured backend isn't S3-compatible
+func (h *MinioHandler) s3Client(c *gin.Context, bucket string) (*minio.Client, bool) {
+	client, ok := storage.S3Client(h.storage, bucket)
+	if !ok {
+		utils.SendError(c, http.StatusNotImplemented, "Not supported by the "+storage.Route(h.storage, bucket).Name()+" storage backend")
+	}
+	return client, ok
+}
+
+// uploadClient is s3Client for uploads that go straight to storage, which
+// envelope encryption would never see
+func (h *MinioHandler) uploadClient(c *gin.Context, bucket string) (*minio.Client, bool) {
+	if _, ok := storage.As[*storage.Envelope](h.storage); ok {
+		utils.SendError(c, http.StatusNotImplemented, "Direct-to-storage uploads are not available with envelope encryption")
+		return nil, false
+	}
+	return h.s3Client(c, bucket)
+}
+
+// scope returns the tenant scope of the request, defaulting to the configured bucket
+func (h *MinioHandler) scope(c *gin.Context) tenancy.Scope {
+	return tenancy.ScopeFrom(c, tenancy.Scope{Bucket: h.config.MinioBucketName})
 }
 
-// ListFiles lists all files in the bucket
-// @Summary List all files
-// @Description List all files in the MinIO bucket
+// maxListPageSize is the largest page S3 returns per ListObjectsV2 call
+const maxListPageSize = 1000
+
+// ListFiles lists files in the bucket one page at a time
+// @Summary List files
+// @Description List files in the MinIO bucket. Results are paginated; when more files remain the X-Continuation-Token response header holds the token for the next page. Object metadata (content type, user metadata) is only fetched with include_metadata=true.
 // @Tags files
 // @Produce json
+// @Param prefix query string false "Only list files whose name starts with this prefix"
+// @Param limit query int false "Page size (1-1000)" default(1000)
+// @Param continuation_token query string false "Token from a previous page's X-Continuation-Token header"
+// @Param include_metadata query bool false "Fetch content type and user metadata for each file"
 // @Success 200 {array} object
+// @Header 200 {string} X-Continuation-Token "Token for the next page, absent on the last page"
+// @Failure 400 {object} utils.ErrorResponse
 // @Router /files [get]
 func (h *MinioHandler) ListFiles(c *gin.Context) {
-	correlationID, _ := c.Get("CorrelationID")
-	correlationIDStr, _ := correlationID.(string)
-	ctx := context.Background()
-	objectCh := h.minioClient.ListObjects(ctx, h.config.MinioBucketName, minio.ListObjectsOptions{
-		Recursive: true,
-	})
+	hc := newHandlerContext(c, h.logger)
+	scope := h.scope(c)
+
+	limit := maxListPageSize
+	if q := c.Query("limit"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > maxListPageSize {
+			utils.SendError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
 
-	var objects []map[string]interface{}
-	for object := range objectCh {
-		if object.Err != nil {
-			h.logger.Error().Err(object.Err).Str("correlation_id", correlationIDStr).Msg("Error listing objects")
-			utils.SendError(c, http.StatusInternalServerError, "Failed to list files")
+	result, err := h.storage.List(c.Request.Context(), scope.Bucket, storage.ListOptions{
+		Prefix:            scope.Key(c.Query("prefix")),
+		ContinuationToken: utils.PageCursor(c),
+		Limit:             limit,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidToken) {
+			utils.SendError(c, http.StatusBadRequest, "Invalid continuation token")
 			return
 		}
+		hc.logger.Error().Err(err).Msg("Error listing objects")
+		apierror.Send(c, err, "Failed to list files")
+		return
+	}
 
+	objects := make([]map[string]interface{}, 0, len(result.Objects))
+	for _, object := range result.Objects {
 		objects = append(objects, map[string]interface{}{
-			"name":         object.Key,
+			"name":         scope.Name(object.Key),
 			"size":         object.Size,
 			"lastModified": object.LastModified,
-			"contentType":  object.ContentType,
+			"etag":         object.ETag,
 		})
 	}
 
-	utils.SendJSONWithCorrelationID(c, http.StatusOK, objects)
+	if c.Query("include_metadata") == "true" {
+		keys := make([]string, len(result.Objects))
+		for i, object := range result.Objects {
+			keys[i] = object.Key
+		}
+		stats, errs := h.statObjects(c.Request.Context(), scope.Bucket, keys, h.config.ListMetadataConcurrency)
+		for i, stat := range stats {
+			if errs[i] != nil {
+				// The object may have been deleted since it was listed
+				hc.logger.Warn().Err(errs[i]).Str("object", keys[i]).Msg("Failed to fetch object metadata")
+				continue
+			}
+			objects[i]["contentType"] = stat.ContentType
+			objects[i]["metadata"] = stat.Metadata
+		}
+	}
+
+	utils.SendPage(c, http.StatusOK, objects, utils.Page{Limit: limit, NextCursor: result.NextContinuationToken})
+}
+
+// ListMyFiles lists the caller's own files
+// @Summary List my files
+// @Description List files in the caller's home directory (HOME_DIRECTORY_PATTERN, users/{subject}/ by default), whether or not HOME_DIRECTORIES confines every file route to it. Names are relative to the home directory. Paginated like GET /files.
+// @Tags files
+// @Produce json
+// @Param prefix query string false "Only list files whose name starts with this prefix"
+// @Param limit query int false "Page size (1-1000)" default(1000)
+// @Param continuation_token query string false "Token from a previous page's X-Continuation-Token header"
+// @Param include_metadata query bool false "Fetch content type and user metadata for each file"
+// @Success 200 {array} object
+// @Header 200 {string} X-Continuation-Token "Token for the next page, absent on the last page"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /me/files [get]
+func (h *MinioHandler) ListMyFiles(c *gin.Context) {
+	// HomeMiddleware has scoped the request to the caller's home
+	h.ListFiles(c)
+}
+
+// statObjects fetches object info for keys with at most concurrency requests in
+// flight. Results and errors are in key order.
+func (h *MinioHandler) statObjects(ctx context.Context, bucket string, keys []string, concurrency int) ([]storage.ObjectInfo, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	stats := make([]storage.ObjectInfo, len(keys))
+	errs := make([]error, len(keys))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(keys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stats[i], errs[i] = h.storage.Stat(ctx, bucket, keys[i])
+			}
+		}()
+	}
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return stats, errs
 }
 
 // GetFile gets a file from MinIO
 // @Summary Get a file
-// @Description Get a file from MinIO by its name
+// @Description Get a file from MinIO by its name. On routes configured for download redirects the response is a 302 to a short-lived presigned storage URL, or to the public URL of the bucket, so the bytes don't pass through the API. Files of the content types in DOWNLOAD_RISKY_TYPES, such as HTML and SVG, are sent as application/octet-stream attachments whatever the disposition, so they never run in the API's origin; with DOWNLOAD_SANITIZE_SVG, SVGs shown inline are sanitized instead.
 // @Tags files
 // @Produce octet-stream
 // @Param filename path string true "File name"
+// @Param disposition query string false "Content disposition" Enums(inline, attachment)
+// @Param filename query string false "Override the download filename"
+// @Param If-None-Match header string false "Return 304 if the ETag matches"
+// @Param If-Modified-Since header string false "Return 304 if not modified since this date"
+// @Param Range header string false "A single byte range, e.g. bytes=0-1048575, to download part of the file"
+// @Param If-Range header string false "Send the whole file instead of the range if its ETag or date no longer matches"
+// @Param X-SSE-Customer-Key header string false "Base64 AES-256 key the file was encrypted with (SSE-C)"
+// @Param X-SSE-Customer-Key-MD5 header string false "Base64 MD5 of the customer key"
 // @Success 200 {file} binary
+// @Success 206 {file} binary "The requested byte range"
+// @Success 302 "Redirect to the file in storage, on routes configured with DOWNLOAD_REDIRECT_GROUPS"
+// @Success 304
+// @Failure 416 {object} utils.ErrorResponse "The range is past the end of the file"
 // @Router /files/{filename} [get]
 func (h *MinioHandler) GetFile(c *gin.Context) {
-	correlationID, _ := c.Get("CorrelationID")
-	correlationIDStr, _ := correlationID.(string)
 	filename := c.Param("filename")
+	scope := h.scope(c)
 	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Filename is required"})
+		utils.SendError(c, http.StatusBadRequest, "Filename is required")
 		return
 	}
 
-	// Get the object from MinIO
-	object, err := h.minioClient.GetObject(
-		context.Background(),
-		h.config.MinioBucketName,
-		filename,
-		minio.GetObjectOptions{},
-	)
-	if err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Str("filename", filename).Msg("Failed to get file from MinIO")
-		utils.SendError(c, http.StatusInternalServerError, "Failed to get file")
+	disposition := c.DefaultQuery("disposition", "attachment")
+	if disposition != "attachment" && disposition != "inline" {
+		utils.SendError(c, http.StatusBadRequest, "disposition must be inline or attachment")
 		return
 	}
-	defer object.Close()
+	downloadName := c.Query("filename")
+	if downloadName == "" {
+		downloadName = path.Base(filename)
+	}
+
+	h.serveObject(c, scope, filename, disposition, downloadName)
+}
+
+// serveObject streams an object with caching validators and the given disposition,
+// writing error responses for missing objects. It reports whether the object
+// was delivered, in full or as a redirect to storage.
+func (h *MinioHandler) serveObject(c *gin.Context, scope tenancy.Scope, filename, disposition, downloadName string) bool {
+	hc := newHandlerContext(c, h.logger)
+
+	event := events.Event{
+		Type:      events.TypeDownloaded,
+		Bucket:    scope.Bucket,
+		Key:       scope.Key(filename),
+		TenantID:  scope.TenantID,
+		ShareSlug: c.Param("slug"),
+	}
+
+	// Offload the bytes to storage or a CDN on routes configured for it
+	if h.redirectDownload(c, scope, filename, disposition, downloadName) {
+		if c.Writer.Status() != http.StatusFound {
+			return false
+		}
+		h.emit(c, event)
+		return true
+	}
+
+	// A range is resolved against the object's size before reading just
+	// those bytes. Share links count every request as a download, so they
+	// serve whole objects.
+	ctx := c.Request.Context()
+	var byteRange *storage.ByteRange
+	var size int64
+	var rangeETag string
+	if c.GetHeader("Range") != "" && event.ShareSlug == "" {
+		info, err := h.storage.Stat(ctx, scope.Bucket, scope.Key(filename))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+				return false
+			}
+			hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to stat file")
+			apierror.Send(c, err, "Failed to get file")
+			return false
+		}
+		if !httpcache.NotModified(c.Request, info.ETag, info.LastModified) {
+			if byteRange, err = httpcache.Range(c.Request, info.Size, info.ETag, info.LastModified); err != nil {
+				c.Header("Content-Range", httpcache.ContentRange(nil, info.Size))
+				apierror.Write(c, http.StatusRequestedRangeNotSatisfiable, apierror.CodeRangeNotSatisfiable, "Range not satisfiable")
+				return false
+			}
+		}
+		if byteRange != nil {
+			size, rangeETag = info.Size, info.ETag
+			ctx = storage.WithRange(ctx, *byteRange)
+		}
+	}
 
-	// Get object info to determine content type
-	stat, err := object.Stat()
+	// Get the object and its info from storage
+	object, stat, err := h.storage.Get(ctx, scope.Bucket, scope.Key(filename))
 	if err != nil {
-		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
-			utils.SendError(c, http.StatusNotFound, "File not found")
-			return
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return false
 		}
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Str("filename", filename).Msg("Failed to get file stats")
-		utils.SendError(c, http.StatusInternalServerError, "Failed to get file info")
-		return
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file from MinIO")
+		apierror.Send(c, err, "Failed to get file")
+		return false
+	}
+	defer object.Close()
+	if byteRange != nil && stat.ETag != rangeETag {
+		// Replaced since the range was resolved; its bytes would not add up
+		apierror.Write(c, http.StatusConflict, apierror.CodeConflict, "The file changed while it was read, try again")
+		return false
 	}
 
-	// Set the content disposition header to force download with original filename
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	c.Header("Content-Type", stat.ContentType)
-	c.Header("Content-Length", fmt.Sprintf("%d", stat.Size))
+	// Validators let clients and proxies revalidate cached copies
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", httpcache.QuoteETag(stat.ETag))
+	c.Header("Last-Modified", stat.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(stat.ContentType))
+	if httpcache.NotModified(c.Request, stat.ETag, stat.LastModified) {
+		c.Status(http.StatusNotModified)
+		return false
+	}
+
+	if byteRange != nil {
+		event.Range = httpcache.ContentRange(byteRange, size)
+		c.Header("Content-Range", event.Range)
+		c.Status(http.StatusPartialContent)
+	}
 
 	// Stream the file to the response
-	if _, err := io.Copy(c.Writer, object); err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Str("filename", filename).Msg("Failed to stream file")
+	if err := h.writeContent(c, object, stat.ContentType, stat.Size, disposition, downloadName, byteRange != nil); err != nil {
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to stream file")
 		// Cannot send JSON response here as we've already started writing the response
-		return
+		return false
 	}
+	event.Size, event.ContentType = stat.Size, stat.ContentType
+	h.emit(c, event)
+	return true
 }
 
 // DeleteFile deletes a file from MinIO
@@ -201,32 +702,31 @@ func (h *MinioHandler) GetFile(c *gin.Context) {
 // @Produce json
 // @Param filename path string true "File name"
 // @Success 200 {object} map[string]string
+// @Failure 409 {object} utils.ErrorResponse "Another request is changing the file"
 // @Router /files/{filename} [delete]
 func (h *MinioHandler) DeleteFile(c *gin.Context) {
-	correlationID, _ := c.Get("CorrelationID")
-	correlationIDStr, _ := correlationID.(string)
+	hc := newHandlerContext(c, h.logger)
 	filename := c.Param("filename")
+	scope := h.scope(c)
 	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Filename is required"})
+		utils.SendError(c, http.StatusBadRequest, "Filename is required")
 		return
 	}
 
-	// Delete the object from MinIO
-	err := h.minioClient.RemoveObject(
-		context.Background(),
-		h.config.MinioBucketName,
-		filename,
-		minio.RemoveObjectOptions{},
-	)
+	removed, err := h.files.Delete(c.Request.Context(), scope, scope.Key(filename))
 	if err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Str("filename", filename).Msg("Failed to delete file from MinIO")
-		utils.SendError(c, http.StatusInternalServerError, "Failed to delete file")
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to delete file")
+		apierror.Send(c, err, "Failed to delete file")
 		return
 	}
-
-	h.logger.Info().Str("correlation_id", correlationIDStr).Str("filename", filename).Msg("File deleted successfully")
+	if removed {
+		hc.logger.Info().Str("filename", filename).Msg("File deleted successfully")
+	} else {
+		hc.logger.Info().Str("filename", filename).Msg("Released dedupe reference, object still referenced")
+	}
+	h.emit(c, events.Event{Type: events.TypeDeleted, Bucket: scope.Bucket, Key: scope.Key(filename), TenantID: scope.TenantID})
 	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
-		"message": "File deleted successfully",
+		"message":  "File deleted successfully",
 		"filename": filename,
 	})
 }
@@ -239,12 +739,11 @@ func (h *MinioHandler) DeleteFile(c *gin.Context) {
 // @Success 200 {array} object
 // @Router /buckets [get]
 func (h *MinioHandler) ListBuckets(c *gin.Context) {
-	correlationID, _ := c.Get("CorrelationID")
-	correlationIDStr, _ := correlationID.(string)
-	buckets, err := h.minioClient.ListBuckets(context.Background())
+	hc := newHandlerContext(c, h.logger)
+	buckets, err := h.storage.ListBuckets(context.Background())
 	if err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationIDStr).Msg("Failed to list buckets")
-		utils.SendError(c, http.StatusInternalServerError, "Failed to list buckets")
+		hc.logger.Error().Err(err).Msg("Failed to list buckets")
+		apierror.Send(c, err, "Failed to list buckets")
 		return
 	}
 
@@ -258,3 +757,148 @@ func (h *MinioHandler) ListBuckets(c *gin.Context) {
 
 	utils.SendJSONWithCorrelationID(c, http.StatusOK, result)
 }
+
+// GetFileChecksum returns the checksums stored for a file
+// @Summary Get file checksums
+// @Description Return the checksums recorded at upload time. With compute=true, missing SHA-256 digests are computed by streaming the object.
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Param compute query bool false "Compute the SHA-256 if it was not stored"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/{filename}/checksum [get]
+func (h *MinioHandler) GetFileChecksum(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+	ctx := c.Request.Context()
+
+	stat, err := h.storage.Stat(ctx, scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	sums := checksum.FromMetadata(stat.Metadata)
+	computed := false
+	if _, ok := sums[checksum.SHA256]; !ok && c.Query("compute") == "true" {
+		object, _, err := h.storage.Get(ctx, scope.Bucket, scope.Key(filename))
+		if err == nil {
+			var computedSums map[checksum.Algorithm]string
+			computedSums, err = checksum.Compute(object, []checksum.Algorithm{checksum.SHA256})
+			object.Close()
+			if err == nil {
+				sums[checksum.SHA256] = computedSums[checksum.SHA256]
+				computed = true
+			}
+		}
+		if err != nil {
+			hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to compute file checksum")
+			apierror.Send(c, err, "Failed to compute checksum")
+			return
+		}
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"filename":  filename,
+		"etag":      stat.ETag,
+		"checksums": sums,
+		"computed":  computed,
+	})
+}
+
+// HeadFile returns file metadata as headers without transferring the body
+// @Summary Get file headers
+// @Description Return size, content type, ETag and last-modified of a file as response headers without the body, and its moderation status when media moderation has checked it. The content type and disposition are those a GET of the same URL is sent with.
+// @Header 200 {string} X-Moderation-Status "approved or flagged"
+// @Tags files
+// @Param filename path string true "File name"
+// @Param disposition query string false "Content disposition" Enums(inline, attachment)
+// @Param filename query string false "Override the download filename"
+// @Success 200
+// @Failure 404
+// @Router /files/{filename} [head]
+func (h *MinioHandler) HeadFile(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+
+	// Headers answer for the GET of the same URL
+	disposition := c.DefaultQuery("disposition", "attachment")
+	if disposition != "attachment" && disposition != "inline" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	downloadName := c.Query("filename")
+	if downloadName == "" {
+		downloadName = path.Base(filename)
+	}
+
+	stat, err := h.storage.Stat(c.Request.Context(), scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	h.writeContentHeaders(c, stat.ContentType, stat.Size, disposition, downloadName)
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", httpcache.QuoteETag(stat.ETag))
+	c.Header("Last-Modified", stat.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(stat.ContentType))
+	if status := stat.Metadata[moderation.StatusKey]; status != "" {
+		c.Header(moderation.StatusHeader, status)
+	}
+	if httpcache.NotModified(c.Request, stat.ETag, stat.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// FileExists reports whether a file exists along with its metadata
+// @Summary Check if a file exists
+// @Description Check for a file without downloading it, returning size, content type, ETag and last-modified when it exists
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Success 200 {object} map[string]interface{}
+// @Router /files/{filename}/exists [get]
+func (h *MinioHandler) FileExists(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+
+	stat, err := h.storage.Stat(c.Request.Context(), scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+				"filename": filename,
+				"exists":   false,
+			})
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"filename":     filename,
+		"exists":       true,
+		"size":         stat.Size,
+		"contentType":  stat.ContentType,
+		"etag":         stat.ETag,
+		"lastModified": stat.LastModified,
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/minioadmin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// MinioAdminHandler exposes the admin API of a self-hosted MinIO cluster
+type MinioAdminHandler struct {
+	admin  *minioadmin.Client
+	logger *zerolog.Logger
+}
+
+// NewMinioAdminHandler creates a new MinioAdminHandler
+func NewMinioAdminHandler(admin *minioadmin.Client, logger *zerolog.Logger) *MinioAdminHandler {
+	return &MinioAdminHandler{admin: admin, logger: logger}
+}
+
+// ServerInfo returns the MinIO cluster overview
+// @Summary Get MinIO server info
+// @Description Return the mode, version, uptime, network state and drives of every MinIO server, with bucket, object and usage totals. The storage credentials need MinIO admin permissions.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} minioadmin.ServerInfo
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /admin/minio/info [get]
+func (h *MinioAdminHandler) ServerInfo(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	info, err := h.admin.ServerInfo(c.Request.Context())
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to get MinIO server info")
+		apierror.Send(c, err, "Failed to get MinIO server info")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, info)
+}
+
+// DataUsage returns MinIO's data usage per bucket
+// @Summary Get MinIO data usage
+// @Description Return object counts, versions and sizes per bucket as of MinIO's last background scan
+// @Tags admin
+// @Produce json
+// @Success 200 {object} minioadmin.DataUsage
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /admin/minio/usage [get]
+func (h *MinioAdminHandler) DataUsage(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	usage, err := h.admin.DataUsage(c.Request.Context())
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to get MinIO data usage")
+		apierror.Send(c, err, "Failed to get MinIO data usage")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, usage)
+}
+
+// DriveHealth returns the state of MinIO's drives and healing
+// @Summary Get MinIO drive health
+// @Description Return every drive with its state and space, counts of online, offline and healing drives, and the progress of background healing
+// @Tags admin
+// @Produce json
+// @Success 200 {object} minioadmin.DriveHealth
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /admin/minio/drives [get]
+func (h *MinioAdminHandler) DriveHealth(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	health, err := h.admin.DriveHealth(c.Request.Context())
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to get MinIO drive health")
+		apierror.Send(c, err, "Failed to get MinIO drive health")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, health)
+}
@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// S3 multipart limits
+const (
+	maxMultipartParts    = 10000
+	maxPartURLsPerCall   = 1000
+	minMultipartPartSize = 5 << 20
+)
+
+// multipartSession records an in-progress multipart upload so later calls only
+// need the upload ID and can't be redirected to another tenant's object
+type multipartSession struct {
+	UploadID  string    `json:"uploadId"`
+	TenantID  string    `json:"tenantId,omitempty"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Chunks are the parts received through the upload session API, by number
+	Chunks map[int]uploadedChunk `json:"chunks,omitempty"`
+}
+
+func multipartSessionKey(uploadID string) string {
+	return "multipart/" + uploadID
+}
+
+// CreateMultipartRequest is the body for starting a multipart upload
+type CreateMultipartRequest struct {
+	Filename    string `json:"filename" binding:"required" example:"video.mp4"`
+	ContentType string `json:"contentType,omitempty" example:"video/mp4"`
+}
+
+// PresignPartsRequest asks for presigned UploadPart URLs
+type PresignPartsRequest struct {
+	PartNumbers []int `json:"partNumbers" binding:"required" example:"1,2,3"`
+	ExpiresIn   int64 `json:"expiresIn,omitempty" example:"3600"` // seconds
+}
+
+// CompletedPart is a part uploaded by the client, identified by the ETag S3 returned
+type CompletedPart struct {
+	PartNumber int    `json:"partNumber" example:"1"`
+	ETag       string `json:"etag" example:"\"d41d8cd98f00b204e9800998ecf8427e\""`
+}
+
+// CompleteMultipartRequest is the body for completing a multipart upload
+type CompleteMultipartRequest struct {
+	Parts []CompletedPart `json:"parts" binding:"required"`
+}
+
+// CreateMultipartUpload starts a multipart upload for direct browser-to-storage transfers
+// @Summary Start a multipart upload
+// @Description Start a resumable multipart upload. Parts are uploaded directly to object storage with presigned URLs from the parts endpoint, then assembled with the complete endpoint.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body CreateMultipartRequest true "Upload options"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /uploads/multipart [post]
+func (h *MinioHandler) CreateMultipartUpload(c *gin.Context) {
+	var req CreateMultipartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	session, contentType, ok := h.startMultipart(c, req.Filename, req.ContentType)
+	if !ok {
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, map[string]interface{}{
+		"uploadId":    session.UploadID,
+		"key":         session.Name,
+		"contentType": contentType,
+		"maxParts":    maxMultipartParts,
+		"minPartSize": minMultipartPartSize,
+	})
+}
+
+// startMultipart starts a multipart upload of filename in the caller's scope
+// and records its session, writing the error response if it fails
+func (h *MinioHandler) startMultipart(c *gin.Context, filename, contentType string) (multipartSession, string, bool) {
+	hc := newHandlerContext(c, h.logger)
+	scope := h.scope(c)
+	client, ok := h.uploadClient(c, scope.Bucket)
+	if !ok {
+		return multipartSession{}, "", false
+	}
+
+	name := keygen.Sanitize(filename)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	owner := ""
+	if identity := auth.IdentityFrom(c); identity != nil {
+		owner = identity.Subject
+	}
+	opts := minio.PutObjectOptions{ContentType: contentType, UserMetadata: map[string]string{}}
+	if owner != "" {
+		opts.UserMetadata[quota.OwnerMetadataKey] = owner
+	}
+
+	core := minio.Core{Client: client}
+	uploadID, err := core.NewMultipartUpload(c.Request.Context(), scope.Bucket, scope.Key(name), opts)
+	if err != nil {
+		hc.logger.Error().Err(err).Str("filename", name).Msg("Failed to start multipart upload")
+		apierror.Send(c, err, "Failed to start multipart upload")
+		return multipartSession{}, "", false
+	}
+
+	session := multipartSession{
+		UploadID:  uploadID,
+		TenantID:  scope.TenantID,
+		Bucket:    scope.Bucket,
+		Key:       scope.Key(name),
+		Name:      name,
+		Owner:     owner,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.store.Put(c.Request.Context(), multipartSessionKey(uploadID), session); err != nil {
+		_ = core.AbortMultipartUpload(context.Background(), scope.Bucket, session.Key, uploadID)
+		hc.logger.Error().Err(err).Msg("Failed to record multipart upload")
+		apierror.Send(c, err, "Failed to start multipart upload")
+		return multipartSession{}, "", false
+	}
+
+	hc.logger.Info().Str("filename", name).Str("upload_id", uploadID).Msg("Multipart upload started")
+	return session, contentType, true
+}
+
+// PresignMultipartParts returns presigned UploadPart URLs
+// @Summary Presign upload part URLs
+// @Description Return presigned PUT URLs for the given part numbers. Each URL's response carries an ETag header the client must keep for completion.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Param request body PresignPartsRequest true "Part numbers"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /uploads/multipart/{upload_id}/parts [post]
+func (h *MinioHandler) PresignMultipartParts(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	client, ok := h.s3Client(c, session.Bucket)
+	if !ok {
+		return
+	}
+	var req PresignPartsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.PartNumbers) == 0 || len(req.PartNumbers) > maxPartURLsPerCall {
+		utils.SendError(c, http.StatusBadRequest, "partNumbers must contain between 1 and 1000 entries")
+		return
+	}
+	expiry := time.Hour
+	if req.ExpiresIn != 0 {
+		expiry = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		utils.SendError(c, http.StatusBadRequest, "expiresIn must be between 1 second and 7 days")
+		return
+	}
+
+	urls := make(map[string]string, len(req.PartNumbers))
+	for _, n := range req.PartNumbers {
+		if n < 1 || n > maxMultipartParts {
+			utils.SendError(c, http.StatusBadRequest, "part numbers must be between 1 and 10000")
+			return
+		}
+		params := url.Values{}
+		params.Set("partNumber", strconv.Itoa(n))
+		params.Set("uploadId", session.UploadID)
+		u, err := client.Presign(c.Request.Context(), http.MethodPut, session.Bucket, session.Key, expiry, params)
+		if err != nil {
+			hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Msg("Failed to presign upload part")
+			apierror.Send(c, err, "Failed to presign upload parts")
+			return
+		}
+		urls[strconv.Itoa(n)] = u.String()
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"uploadId":  session.UploadID,
+		"parts":     urls,
+		"expiresAt": time.Now().UTC().Add(expiry),
+	})
+}
+
+// ListMultipartParts lists the parts stored so far, so clients can resume
+// @Summary List uploaded parts
+// @Description List parts already received for a multipart upload, to resume an interrupted upload
+// @Tags uploads
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /uploads/multipart/{upload_id}/parts [get]
+func (h *MinioHandler) ListMultipartParts(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	client, ok := h.s3Client(c, session.Bucket)
+	if !ok {
+		return
+	}
+
+	core := minio.Core{Client: client}
+	parts := []map[string]interface{}{}
+	marker := 0
+	for {
+		result, err := core.ListObjectParts(c.Request.Context(), session.Bucket, session.Key, session.UploadID, marker, maxPartURLsPerCall)
+		if err != nil {
+			if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+				utils.SendError(c, http.StatusNotFound, "Upload not found")
+				return
+			}
+			hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Msg("Failed to list upload parts")
+			apierror.Send(c, err, "Failed to list upload parts")
+			return
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, map[string]interface{}{
+				"partNumber":   part.PartNumber,
+				"etag":         part.ETag,
+				"size":         part.Size,
+				"lastModified": part.LastModified,
+			})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"uploadId": session.UploadID,
+		"key":      session.Name,
+		"parts":    parts,
+	})
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the final object
+// @Summary Complete a multipart upload
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Param request body CompleteMultipartRequest true "Uploaded parts"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 507 {object} utils.ErrorResponse
+// @Router /uploads/multipart/{upload_id}/complete [post]
+func (h *MinioHandler) CompleteMultipartUpload(c *gin.Context) {
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	var req CompleteMultipartRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Parts) == 0 {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	parts := make([]minio.CompletePart, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		parts = append(parts, minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	h.completeMultipart(c, session, parts)
+}
+
+// completeMultipart assembles parts into the session's object, enforces the
+// size limit and quota and writes the response
+func (h *MinioHandler) completeMultipart(c *gin.Context, session multipartSession, parts []minio.CompletePart) {
+	hc := newHandlerContext(c, h.logger)
+	client, ok := h.s3Client(c, session.Bucket)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	core := minio.Core{Client: client}
+	previous, statErr := h.storage.Stat(ctx, session.Bucket, session.Key)
+	info, err := core.CompleteMultipartUpload(ctx, session.Bucket, session.Key, session.UploadID, parts, minio.PutObjectOptions{})
+	c.Set(middleware.AuditKeyKey, session.Key)
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchUpload":
+			utils.SendError(c, http.StatusNotFound, "Upload not found")
+		case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+			utils.SendError(c, http.StatusBadRequest, minio.ToErrorResponse(err).Message)
+		default:
+			hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Msg("Failed to complete multipart upload")
+			apierror.Send(c, err, "Failed to complete multipart upload")
+		}
+		return
+	}
+	_ = h.store.Delete(context.Background(), multipartSessionKey(session.UploadID))
+
+	// Size is only known once the parts are assembled, so limits are enforced after the fact
+	stat, err := h.storage.Stat(ctx, session.Bucket, session.Key)
+	if err != nil {
+		hc.logger.Error().Err(err).Str("filename", session.Name).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to complete multipart upload")
+		return
+	}
+	if limit := h.config.Live().MaxFileSize; limit > 0 && stat.Size > limit {
+		h.removeRejectedUpload(hc, session)
+		utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+		return
+	}
+	if session.Owner != "" && h.quotas != nil {
+		if err := h.quotas.Reserve(ctx, session.Owner, stat.Size); err != nil {
+			h.removeRejectedUpload(hc, session)
+			if errors.Is(err, quota.ErrQuotaExceeded) {
+				apierror.Write(c, http.StatusInsufficientStorage, apierror.CodeQuotaExceeded, "Storage quota exceeded")
+				return
+			}
+			hc.logger.Error().Err(err).Msg("Failed to reserve quota")
+			apierror.Send(c, err, "Failed to complete multipart upload")
+			return
+		}
+	}
+	if statErr == nil {
+		h.releaseQuota(c, previous)
+	}
+	// The parts were assembled on the S3 client, not through the backend
+	if err := storage.NotifyWrite(ctx, h.storage, session.Bucket, session.Key); err != nil {
+		hc.logger.Warn().Err(err).Str("filename", session.Name).Msg("Failed to notify storage of multipart upload")
+	}
+
+	hc.logger.Info().
+		Str("bucket", info.Bucket).
+		Str("object", info.Key).
+		Int64("size", stat.Size).
+		Int("parts", len(parts)).
+		Msg("Multipart upload completed")
+	h.emit(c, events.Event{
+		Type:        events.TypeUploaded,
+		Bucket:      session.Bucket,
+		Key:         session.Key,
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		TenantID:    session.TenantID,
+	})
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message":     "File uploaded successfully",
+		"filename":    session.Name,
+		"key":         session.Name,
+		"size":        stat.Size,
+		"etag":        info.ETag,
+		"bucketName":  info.Bucket,
+		"contentType": stat.ContentType,
+	})
+}
+
+// AbortMultipartUpload cancels a multipart upload and discards its parts
+// @Summary Abort a multipart upload
+// @Tags uploads
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /uploads/multipart/{upload_id} [delete]
+// @Router /uploads/{upload_id} [delete]
+func (h *MinioHandler) AbortMultipartUpload(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	client, ok := h.s3Client(c, session.Bucket)
+	if !ok {
+		return
+	}
+
+	core := minio.Core{Client: client}
+	err := core.AbortMultipartUpload(c.Request.Context(), session.Bucket, session.Key, session.UploadID)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Msg("Failed to abort multipart upload")
+		apierror.Send(c, err, "Failed to abort multipart upload")
+		return
+	}
+	_ = h.store.Delete(context.Background(), multipartSessionKey(session.UploadID))
+
+	hc.logger.Info().Str("upload_id", session.UploadID).Msg("Multipart upload aborted")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, map[string]interface{}{
+		"message":  "Upload aborted",
+		"uploadId": session.UploadID,
+	})
+}
+
+// multipartSession loads the session named in the path, writing a 404 if it is
+// unknown or belongs to another tenant
+func (h *MinioHandler) multipartSession(c *gin.Context) (multipartSession, bool) {
+	var session multipartSession
+	err := h.store.Get(c.Request.Context(), multipartSessionKey(c.Param("upload_id")), &session)
+	if err == nil && session.TenantID != h.scope(c).TenantID {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Upload not found")
+			return session, false
+		}
+		hc := newHandlerContext(c, h.logger)
+		hc.logger.Error().Err(err).Msg("Failed to load multipart upload")
+		apierror.Send(c, err, "Failed to load upload")
+		return session, false
+	}
+	return session, true
+}
+
+// removeRejectedUpload deletes an assembled object that failed a post-upload limit
+func (h *MinioHandler) removeRejectedUpload(hc handlerContext, session multipartSession) {
+	if err := h.storage.Remove(context.Background(), session.Bucket, session.Key); err != nil {
+		hc.logger.Error().Err(err).Str("filename", session.Name).Msg("Failed to remove rejected upload")
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/openapi"
+)
+
+// OpenAPIHandler serves the OpenAPI 3.1 document and the TypeScript client
+// generated from it
+type OpenAPIHandler struct {
+	spec   []byte
+	client []byte
+}
+
+// NewOpenAPIHandler converts the generated Swagger 2.0 document once at startup
+func NewOpenAPIHandler(swaggerDoc []byte) (*OpenAPIHandler, error) {
+	spec, err := openapi.ConvertV3(swaggerDoc)
+	if err != nil {
+		return nil, err
+	}
+	client, err := openapi.TypeScriptClient(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIHandler{spec: spec, client: client}, nil
+}
+
+// GetSpec serves the OpenAPI document
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.spec)
+}
+
+// GetClient serves the generated TypeScript client
+func (h *OpenAPIHandler) GetClient(c *gin.Context) {
+	c.Header("Content-Disposition", `attachment; filename="client.ts"`)
+	c.Data(http.StatusOK, "application/typescript; charset=utf-8", h.client)
+}
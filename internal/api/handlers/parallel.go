@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/httpcache"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+const (
+	// defaultDownloadParts is the number of parts when none is asked for
+	defaultDownloadParts = 4
+	// maxDownloadParts bounds the parts, and so the requests, of one download
+	maxDownloadParts = 64
+)
+
+// DownloadPart is one byte range of a parallel download
+type DownloadPart struct {
+	Offset int64 `json:"offset" example:"0"`
+	Length int64 `json:"length" example:"13107200"`
+	// Range is the Range header that fetches the part
+	Range string `json:"range" example:"bytes=0-13107199"`
+}
+
+// ParallelDownloadResponse describes how to download a file in parts
+type ParallelDownloadResponse struct {
+	Filename string `json:"filename" example:"backup.tar"`
+	URL      string `json:"url" example:"/api/v1/files/backup.tar"`
+	Size     int64  `json:"size" example:"52428800"`
+	// ETag is quoted, ready for If-Range
+	ETag         string    `json:"etag" example:"d41d8cd98f00b204e9800998ecf8427e-4"`
+	ContentType  string    `json:"contentType" example:"application/x-tar"`
+	LastModified time.Time `json:"lastModified"`
+	// Parts cover the file in order; an empty file has none
+	Parts []DownloadPart `json:"parts"`
+}
+
+// ParallelDownload splits a file into byte ranges to be downloaded in parallel
+// @Summary Plan a parallel download
+// @Description Split a file into up to `parts` contiguous byte ranges of near-equal size. Each part is fetched with a concurrent GET of the file's URL carrying the part's Range header; sending the returned ETag as If-Range makes a part come back whole (200) rather than mixing versions if the file is replaced meanwhile. Each part is a download of its own under the transfer limits: it takes a transfer slot and gets the whole per-transfer rate limit, so parts are capped at the number of transfer slots when that is limited.
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Param parts query int false "Number of parts (1-64)" default(4)
+// @Success 200 {object} ParallelDownloadResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/{filename}/parallel [get]
+func (h *MinioHandler) ParallelDownload(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+
+	parts := defaultDownloadParts
+	if q := c.Query("parts"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > maxDownloadParts {
+			utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("parts must be between 1 and %d", maxDownloadParts))
+			return
+		}
+		parts = n
+	}
+	// Parallel parts beyond the transfer slots would only queue for them
+	if slots := h.config.TransferMaxConcurrent; slots > 0 {
+		parts = min(parts, slots)
+	}
+
+	stat, err := h.storage.Stat(c.Request.Context(), scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, ParallelDownloadResponse{
+		Filename:     filename,
+		URL:          strings.TrimSuffix(c.Request.URL.EscapedPath(), "/parallel"),
+		Size:         stat.Size,
+		ETag:         httpcache.QuoteETag(stat.ETag),
+		ContentType:  stat.ContentType,
+		LastModified: stat.LastModified,
+		Parts:        splitRanges(stat.Size, parts),
+	})
+}
+
+// splitRanges divides size bytes into at most n contiguous parts whose
+// lengths differ by one byte at most
+func splitRanges(size int64, n int) []DownloadPart {
+	count := min(int64(n), size)
+	parts := make([]DownloadPart, 0, count)
+	var offset int64
+	for i := int64(0); i < count; i++ {
+		length := size / count
+		if i < size%count {
+			length++
+		}
+		parts = append(parts, DownloadPart{
+			Offset: offset,
+			Length: length,
+			Range:  fmt.Sprintf("bytes=%d-%d", offset, offset+length-1),
+		})
+		offset += length
+	}
+	return parts
+}
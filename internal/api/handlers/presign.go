@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/dedupe"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+const (
+	defaultPresignExpiry = 15 * time.Minute
+	// maxPresignExpiry is the longest lifetime S3 signature v4 allows
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// PresignRequest is the body for generating a presigned URL
+type PresignRequest struct {
+	Method             string `json:"method" example:"GET" enums:"GET,PUT,DELETE,HEAD"`
+	ExpiresIn          int64  `json:"expiresIn,omitempty" example:"900"` // seconds
+	ContentType        string `json:"contentType,omitempty" example:"application/pdf"`
+	ContentDisposition string `json:"contentDisposition,omitempty" example:"attachment"` // inline or attachment
+	Filename           string `json:"filename,omitempty" example:"report.pdf"`           // download name
+	// CacheControl and Metadata are stored with a PUT upload
+	CacheControl string            `json:"cacheControl,omitempty" example:"public, max-age=31536000"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	// Confirm asks for a callback token completing a PUT upload through
+	// POST /uploads/confirm; CallbackURL implies it and receives the upload
+	// event once the upload completes, on a host allowed by PRESIGN_CALLBACK_HOSTS
+	Confirm     bool   `json:"confirm,omitempty"`
+	CallbackURL string `json:"callbackUrl,omitempty" example:"https://hooks.example.com/uploads"`
+}
+
+// maxPresignMetadataSize is the S3 limit on the total size of user metadata
+const maxPresignMetadataSize = 2048
+
+// reservedMetadataKeys are set by the API itself and can't be presigned
+var reservedMetadataKeys = map[string]bool{
+	quota.OwnerMetadataKey:                true,
+	dedupe.MetadataKey:                    true,
+	"Detected-Content-Type":               true,
+	sourceETagMetadataKey:                 true,
+	checksum.MetadataKey(checksum.SHA256): true,
+	checksum.MetadataKey(checksum.MD5):    true,
+	checksum.MetadataKey(checksum.CRC32C): true,
+}
+
+// presignMetadata validates custom metadata for a presigned upload and
+// returns it with canonical keys
+func presignMetadata(metadata map[string]string) (map[string]string, error) {
+	canonical := make(map[string]string, len(metadata))
+	size := 0
+	for k, v := range metadata {
+		if k == "" || strings.IndexFunc(k, func(r rune) bool {
+			return !(r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z')
+		}) >= 0 {
+			return nil, fmt.Errorf("metadata key %q may only contain letters, digits and hyphens", k)
+		}
+		if strings.IndexFunc(v, func(r rune) bool { return r < ' ' || r > '~' }) >= 0 {
+			return nil, fmt.Errorf("metadata value of %q must be printable ASCII", k)
+		}
+		k = textproto.CanonicalMIMEHeaderKey(k)
+		if reservedMetadataKeys[k] {
+			return nil, fmt.Errorf("metadata key %q is reserved", k)
+		}
+		canonical[k] = v
+		size += len(k) + len(v)
+	}
+	if size > maxPresignMetadataSize {
+		return nil, fmt.Errorf("metadata must not exceed %d bytes", maxPresignMetadataSize)
+	}
+	return canonical, nil
+}
+
+// PresignResponse describes a presigned URL and the headers the client must send with it
+type PresignResponse struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Key       string            `json:"key"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	// CallbackToken completes the upload through POST /uploads/confirm
+	CallbackToken string `json:"callbackToken,omitempty"`
+}
+
+// PresignFile generates a presigned URL for direct access to an object
+// @Summary Generate a presigned URL
+// @Description Generate a time-limited URL for GET, PUT, DELETE or HEAD directly against object storage. GET URLs can override the response Content-Type and Content-Disposition. PUT URLs can set the Content-Type, Cache-Control, Content-Disposition and custom metadata stored with the object; these become part of the signature, so the client must send them exactly as listed in headers. A PUT URL without them accepts any Content-Type. A PUT presigned with confirm or callbackUrl returns a callback token: the upload is charged to the quota and announced once the client confirms it through POST /uploads/confirm, or once a bucket notification reports it when PRESIGN_NOTIFICATIONS is on. Its callback URL then receives the object.uploaded event, signed like event webhooks.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param filename path string true "File name"
+// @Param request body PresignRequest true "Presign options"
+// @Success 200 {object} PresignResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /files/{filename}/presign [post]
+func (h *MinioHandler) PresignFile(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+
+	var req PresignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	expiry := defaultPresignExpiry
+	if req.ExpiresIn != 0 {
+		expiry = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		utils.SendError(c, http.StatusBadRequest, "expiresIn must be between 1 second and 7 days")
+		return
+	}
+	if req.ContentDisposition != "" && req.ContentDisposition != "inline" && req.ContentDisposition != "attachment" {
+		utils.SendError(c, http.StatusBadRequest, "contentDisposition must be inline or attachment")
+		return
+	}
+	confirm := req.Confirm || req.CallbackURL != ""
+	if confirm && method != http.MethodPut {
+		utils.SendError(c, http.StatusBadRequest, "confirm and callbackUrl only apply to PUT")
+		return
+	}
+	if req.CallbackURL != "" && !webhookAllowed(req.CallbackURL, h.config.PresignCallbackHosts) {
+		utils.SendError(c, http.StatusBadRequest, "callbackUrl must be an http or https URL on a host allowed by PRESIGN_CALLBACK_HOSTS")
+		return
+	}
+	if confirm && h.presigned == nil {
+		utils.SendError(c, http.StatusNotImplemented, "Presigned upload completion is not available")
+		return
+	}
+
+	presigner, ok := h.storage.(storage.Presigner)
+	if !ok {
+		utils.SendError(c, http.StatusNotImplemented, "Presigned URLs are not supported by the "+h.storage.Name()+" storage backend")
+		return
+	}
+	contentDisposition := ""
+	if req.ContentDisposition != "" || req.Filename != "" {
+		disposition := req.ContentDisposition
+		if disposition == "" {
+			disposition = "attachment"
+		}
+		downloadName := req.Filename
+		if downloadName == "" {
+			downloadName = path.Base(filename)
+		}
+		contentDisposition = utils.ContentDisposition(disposition, downloadName)
+	}
+	var opts storage.PresignOptions
+	switch method {
+	case http.MethodGet:
+		opts.ResponseContentType = req.ContentType
+		opts.ResponseContentDisposition = contentDisposition
+	case http.MethodPut:
+		// Only the headers asked for are signed; the client must send them as returned
+		opts.ContentType = req.ContentType
+		opts.CacheControl = req.CacheControl
+		opts.ContentDisposition = contentDisposition
+		metadata, err := presignMetadata(req.Metadata)
+		if err != nil {
+			utils.SendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		// A completed upload is charged to the caller, like their own uploads
+		if identity := auth.IdentityFrom(c); confirm && identity != nil {
+			metadata[quota.OwnerMetadataKey] = identity.Subject
+		}
+		opts.Metadata = metadata
+	case http.MethodHead, http.MethodDelete:
+	default:
+		utils.SendError(c, http.StatusBadRequest, "method must be GET, PUT, DELETE or HEAD")
+		return
+	}
+
+	key := scope.Key(filename)
+	presignedURL, signedHeaders, err := presigner.Presign(c.Request.Context(), method, scope.Bucket, key, expiry, opts)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotSupported) {
+			utils.SendError(c, http.StatusNotImplemented, err.Error())
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Str("method", method).Msg("Failed to presign URL")
+		apierror.Send(c, err, "Failed to generate presigned URL")
+		return
+	}
+	headers := map[string]string{}
+	for name := range signedHeaders {
+		headers[name] = signedHeaders.Get(name)
+	}
+
+	c.Set(middleware.AuditKeyKey, key)
+	hc.logger.Debug().Str("filename", filename).Str("method", method).Dur("expiry", expiry).Msg("Presigned URL generated")
+	response := PresignResponse{
+		URL:       presignedURL.String(),
+		Method:    method,
+		Key:       filename,
+		ExpiresAt: time.Now().UTC().Add(expiry),
+	}
+	if len(headers) > 0 {
+		response.Headers = headers
+	}
+	if confirm {
+		upload := presigned.Upload{
+			Bucket:      scope.Bucket,
+			Key:         key,
+			Name:        filename,
+			TenantID:    scope.TenantID,
+			Owner:       opts.Metadata[quota.OwnerMetadataKey],
+			Actor:       auth.SubjectFrom(c),
+			CallbackURL: req.CallbackURL,
+			ExpiresAt:   response.ExpiresAt,
+		}
+		if previous, err := h.storage.Stat(c.Request.Context(), scope.Bucket, key); err == nil {
+			upload.Replaces = &presigned.Replaced{
+				Owner:        previous.Metadata[quota.OwnerMetadataKey],
+				Size:         previous.Size,
+				ETag:         previous.ETag,
+				LastModified: previous.LastModified,
+			}
+		}
+		if response.CallbackToken, err = h.presigned.Register(c.Request.Context(), upload); err != nil {
+			hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to record presigned upload")
+			apierror.Send(c, err, "Failed to generate presigned URL")
+			return
+		}
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, response)
+}
+
+// ConfirmUploadRequest is the body confirming a presigned upload
+type ConfirmUploadRequest struct {
+	Filename string `json:"filename" binding:"required" example:"report.pdf"`
+	Token    string `json:"token" binding:"required"` // callbackToken of the presign
+}
+
+// ConfirmUploadResponse describes a completed presigned upload
+type ConfirmUploadResponse struct {
+	Filename    string    `json:"filename"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag"`
+	ContentType string    `json:"contentType"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// ConfirmPresignedUpload completes an upload made through a presigned PUT URL
+// @Summary Confirm a presigned upload
+// @Description Complete an upload made through a PUT URL presigned with confirm or callbackUrl, once the object is stored: the file is charged to the quota, the object.uploaded event is published and the callback URL, if any, receives it. Confirming again returns the same result. A file over MAX_FILE_SIZE or the quota is removed.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body ConfirmUploadRequest true "File name and callback token"
+// @Success 200 {object} ConfirmUploadResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 507 {object} utils.ErrorResponse
+// @Router /uploads/confirm [post]
+func (h *MinioHandler) ConfirmPresignedUpload(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	scope := h.scope(c)
+
+	var req ConfirmUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if h.presigned == nil {
+		utils.SendError(c, http.StatusNotImplemented, "Presigned upload completion is not available")
+		return
+	}
+
+	key := scope.Key(req.Filename)
+	c.Set(middleware.AuditKeyKey, key)
+	upload, err := h.presigned.Confirm(c.Request.Context(), scope.Bucket, key, req.Token)
+	switch {
+	case err == nil:
+	case errors.Is(err, presigned.ErrNotFound):
+		apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "No pending upload for this file")
+		return
+	case errors.Is(err, presigned.ErrInvalidToken):
+		apierror.Write(c, http.StatusForbidden, apierror.CodeForbidden, "Invalid callback token")
+		return
+	case errors.Is(err, presigned.ErrNotUploaded):
+		apierror.Write(c, http.StatusConflict, apierror.CodeConflict, "The file has not been uploaded yet")
+		return
+	case errors.Is(err, presigned.ErrTooLarge):
+		apierror.Write(c, http.StatusRequestEntityTooLarge, apierror.CodeFileTooLarge, "File exceeds the maximum allowed size")
+		return
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		apierror.Write(c, http.StatusInsufficientStorage, apierror.CodeQuotaExceeded, "Storage quota exceeded")
+		return
+	default:
+		hc.logger.Error().Err(err).Str("filename", req.Filename).Msg("Failed to confirm presigned upload")
+		apierror.Send(c, err, "Failed to confirm upload")
+		return
+	}
+
+	hc.logger.Info().Str("bucket", scope.Bucket).Str("object", key).Int64("size", upload.Size).Msg("Presigned upload confirmed")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, ConfirmUploadResponse{
+		Filename:    req.Filename,
+		Size:        upload.Size,
+		ETag:        upload.ETag,
+		ContentType: upload.ContentType,
+		CompletedAt: *upload.CompletedAt,
+	})
+}
+
+// PostPolicyRequest is the body for generating a presigned POST policy
+type PostPolicyRequest struct {
+	Filename          string `json:"filename,omitempty" example:"avatar.png"`      // exact key; omit to allow any key under keyPrefix
+	KeyPrefix         string `json:"keyPrefix,omitempty" example:"avatars/"`       // required key prefix when filename is omitted
+	ContentType       string `json:"contentType,omitempty" example:"image/png"`    // exact content type
+	ContentTypePrefix string `json:"contentTypePrefix,omitempty" example:"image/"` // content type must start with this
+	MaxSize           int64  `json:"maxSize,omitempty" example:"10485760"`         // bytes, capped at MAX_FILE_SIZE
+	ExpiresIn         int64  `json:"expiresIn,omitempty" example:"900"`            // seconds
+}
+
+// PostPolicyResponse holds the form target and the fields the form must post
+// before the file field
+type PostPolicyResponse struct {
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields"`
+	KeyPrefix string            `json:"keyPrefix,omitempty"`
+	MaxSize   int64             `json:"maxSize,omitempty"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// CreatePostPolicy generates a presigned POST policy for browser form uploads
+// @Summary Generate a presigned POST policy
+// @Description Generate a form upload policy for plain HTML forms and mobile SDKs. Unlike a presigned PUT, the storage server enforces the conditions: maximum size (never above MAX_FILE_SIZE), key or key prefix, and content type. Post the returned fields followed by a "file" field to the URL.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body PostPolicyRequest true "Policy conditions"
+// @Success 200 {object} PostPolicyResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /uploads/policy [post]
+func (h *MinioHandler) CreatePostPolicy(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	scope := h.scope(c)
+	client, ok := h.uploadClient(c, scope.Bucket)
+	if !ok {
+		return
+	}
+
+	var req PostPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	expiry := defaultPresignExpiry
+	if req.ExpiresIn != 0 {
+		expiry = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		utils.SendError(c, http.StatusBadRequest, "expiresIn must be between 1 second and 7 days")
+		return
+	}
+	maxSize := req.MaxSize
+	if maxSize < 0 {
+		utils.SendError(c, http.StatusBadRequest, "maxSize must not be negative")
+		return
+	}
+	if limit := h.config.Live().MaxFileSize; limit > 0 && (maxSize == 0 || maxSize > limit) {
+		maxSize = limit
+	}
+	if req.ContentType != "" && req.ContentTypePrefix != "" {
+		utils.SendError(c, http.StatusBadRequest, "contentType and contentTypePrefix are mutually exclusive")
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(expiry)
+	policy := minio.NewPostPolicy()
+	err := policy.SetBucket(scope.Bucket)
+	if err == nil {
+		err = policy.SetExpires(expiresAt)
+	}
+	keyPrefix := ""
+	if err == nil {
+		if req.Filename != "" {
+			err = policy.SetKey(scope.Key(keygen.Sanitize(req.Filename)))
+		} else {
+			keyPrefix = req.KeyPrefix
+			err = policy.SetKeyStartsWith(scope.Key(keyPrefix))
+		}
+	}
+	if err == nil && maxSize > 0 {
+		err = policy.SetContentLengthRange(0, maxSize)
+	}
+	if err == nil && req.ContentType != "" {
+		err = policy.SetContentType(req.ContentType)
+	}
+	if err == nil && req.ContentTypePrefix != "" {
+		err = policy.SetContentTypeStartsWith(req.ContentTypePrefix)
+	}
+	if identity := auth.IdentityFrom(c); err == nil && identity != nil {
+		err = policy.SetUserMetadata(quota.OwnerMetadataKey, identity.Subject)
+	}
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	target, fields, err := client.PresignedPostPolicy(c.Request.Context(), policy)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to presign POST policy")
+		apierror.Send(c, err, "Failed to generate POST policy")
+		return
+	}
+
+	hc.logger.Debug().Str("bucket", scope.Bucket).Int64("max_size", maxSize).Dur("expiry", expiry).Msg("POST policy generated")
+	response := PostPolicyResponse{
+		URL:       target.String(),
+		Fields:    fields,
+		MaxSize:   maxSize,
+		ExpiresAt: expiresAt,
+	}
+	if req.Filename == "" {
+		response.KeyPrefix = scope.Key(keyPrefix)
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, response)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// UploadIDHeader is the header clients use to tag an upload for progress reporting
+const UploadIDHeader = "X-Upload-ID"
+
+// progressOwner identifies the caller an upload's progress belongs to
+func progressOwner(c *gin.Context, scope tenancy.Scope) string {
+	return scope.TenantID + "/" + auth.SubjectFrom(c)
+}
+
+// progressOrigin reports whether a progress socket may be opened from the
+// request's Origin: requests without one (non-browser clients), from the API's
+// own host, or from an origin in CORS_ALLOWED_ORIGINS
+func (h *MinioHandler) progressOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.Contains(h.config.Live().CORSAllowedOrigins, origin)
+}
+
+// UploadProgress streams upload progress over a WebSocket
+// @Summary Stream upload progress
+// @Description Open a WebSocket that streams bytes-transferred updates for an upload tagged with the given upload ID. Only the caller that made the upload may subscribe; browsers must connect from an origin in CORS_ALLOWED_ORIGINS.
+// @Tags files
+// @Param upload_id path string true "Upload ID"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /ws/uploads/{upload_id} [get]
+func (h *MinioHandler) UploadProgress(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	uploadID := c.Param("upload_id")
+	if uploadID == "" {
+		utils.SendError(c, http.StatusBadRequest, "Upload ID is required")
+		return
+	}
+
+	if !h.progressOrigin(c.Request) {
+		utils.SendError(c, http.StatusForbidden, "Origin not allowed")
+		return
+	}
+	updates, cancel, err := h.progress.Subscribe(uploadID, progressOwner(c, h.scope(c)))
+	if err != nil {
+		utils.SendError(c, http.StatusForbidden, "Upload belongs to another caller")
+		return
+	}
+	defer cancel()
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		// The origin was checked above
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		hc.logger.Error().Err(err).Str("upload_id", uploadID).Msg("Failed to upgrade progress connection")
+		return
+	}
+	defer conn.Close()
+
+	// Drain client frames so close messages are processed and disconnects are noticed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "upload finished"),
+					time.Now().Add(time.Second))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(update); err != nil {
+				hc.logger.Debug().Err(err).Str("upload_id", uploadID).Msg("Progress subscriber went away")
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// PublicURLResponse is the public address of a file
+type PublicURLResponse struct {
+	URL          string    `json:"url" example:"https://cdn.example.com/report.pdf?v=d41d8cd98f00b204e9800998ecf8427e"`
+	Key          string    `json:"key" example:"report.pdf"`
+	ETag         string    `json:"etag" example:"d41d8cd98f00b204e9800998ecf8427e"`
+	Size         int64     `json:"size" example:"52431"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// GetPublicURL returns the public URL of a file in a public bucket
+// @Summary Get a file's public URL
+// @Description Compose the URL of a file on the bucket's public address (DOWNLOAD_PUBLIC_URL, e.g. an R2 public bucket or a CDN custom domain), after checking the file exists. With cache_bust=true the URL carries the file's ETag as a "v" query parameter, so caches fetch it again when the content changes.
+// @Tags files
+// @Produce json
+// @Param filename path string true "File name"
+// @Param cache_bust query bool false "Add the ETag as a cache-busting query parameter"
+// @Success 200 {object} PublicURLResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 501 {object} utils.ErrorResponse
+// @Router /files/{filename}/public-url [get]
+func (h *MinioHandler) GetPublicURL(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+
+	base := h.config.DownloadPublicURL
+	if base == "" || scope.Bucket != h.config.MinioBucketName {
+		utils.SendError(c, http.StatusNotImplemented, "No public URL is configured for this bucket")
+		return
+	}
+
+	key := scope.Key(filename)
+	stat, err := h.storage.Stat(c.Request.Context(), scope.Bucket, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	publicURL := publicObjectURL(base, key)
+	if boolOption(c, "cache_bust", false) && stat.ETag != "" {
+		publicURL += "?" + url.Values{"v": {stat.ETag}}.Encode()
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, PublicURLResponse{
+		URL:          publicURL,
+		Key:          filename,
+		ETag:         stat.ETag,
+		Size:         stat.Size,
+		LastModified: stat.LastModified,
+	})
+}
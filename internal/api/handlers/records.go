@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metadb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// RecordsHandler serves listings and usage from the metadata database
+type RecordsHandler struct {
+	db     *metadb.DB
+	logger *zerolog.Logger
+	config *config.Config
+}
+
+// NewRecordsHandler creates a new RecordsHandler
+func NewRecordsHandler(db *metadb.DB, logger *zerolog.Logger, cfg *config.Config) *RecordsHandler {
+	return &RecordsHandler{db: db, logger: logger, config: cfg}
+}
+
+// ListRecords lists file records from the metadata database
+// @Summary List file records
+// @Description List files from the metadata database, which mirrors storage through bucket notifications and periodic reconciliation. Unlike GET /files it can filter by owner and tags with indexed queries, and returns owner, SHA-256 and tags without a request per file. Results are paginated like GET /files.
+// @Tags files
+// @Produce json
+// @Param prefix query string false "Only list files whose name starts with this prefix"
+// @Param owner query string false "Only list files uploaded by this subject; \"me\" is the caller"
+// @Param tag query []string false "Tag the file must have, as key or key=value; repeat for several" collectionFormat(multi)
+// @Param limit query int false "Page size (1-1000)" default(1000)
+// @Param continuation_token query string false "Token from a previous page's X-Continuation-Token header"
+// @Success 200 {array} metadb.Record
+// @Header 200 {string} X-Continuation-Token "Token for the next page, absent on the last page"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Router /files/records [get]
+func (h *RecordsHandler) ListRecords(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	scope := tenancy.ScopeFrom(c, tenancy.Scope{Bucket: h.config.MinioBucketName})
+
+	filter := metadb.Filter{
+		Bucket: scope.Bucket,
+		Prefix: scope.Key(c.Query("prefix")),
+		Owner:  c.Query("owner"),
+		Limit:  maxListPageSize,
+	}
+	if filter.Owner == "me" {
+		identity := auth.IdentityFrom(c)
+		if identity == nil {
+			utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		filter.Owner = identity.Subject
+	}
+	if token := utils.PageCursor(c); token != "" {
+		filter.After = scope.Key(token)
+	}
+	for _, tag := range c.QueryArray("tag") {
+		key, value, _ := strings.Cut(tag, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			utils.SendError(c, http.StatusBadRequest, "tag must be key or key=value")
+			return
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[key] = value
+	}
+	if q := c.Query("limit"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > maxListPageSize {
+			utils.SendError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+
+	// Fetch one extra record to know whether another page follows
+	filter.Limit++
+	records, err := h.db.List(c.Request.Context(), filter)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to list file records")
+		apierror.Send(c, err, "Failed to list file records")
+		return
+	}
+	page := utils.Page{Limit: filter.Limit - 1}
+	if len(records) == filter.Limit {
+		records = records[:filter.Limit-1]
+		page.NextCursor = scope.Name(records[len(records)-1].Key)
+	}
+	for i := range records {
+		records[i].Key = scope.Name(records[i].Key)
+	}
+	if records == nil {
+		records = []metadb.Record{}
+	}
+	utils.SendPage(c, http.StatusOK, records, page)
+}
+
+// GetOwnerUsage reports storage per owner from the metadata database
+// @Summary Get usage per owner
+// @Description Return object counts and bytes per bucket and owner, computed from the metadata database. Objects uploaded without an owner are reported under an empty owner.
+// @Tags admin
+// @Produce json
+// @Param bucket query string false "Only report this bucket"
+// @Param owner query string false "Only report this owner"
+// @Success 200 {array} metadb.OwnerUsage
+// @Router /admin/usage/owners [get]
+func (h *RecordsHandler) GetOwnerUsage(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	usage, err := h.db.Usage(c.Request.Context(), c.Query("bucket"), c.Query("owner"))
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to compute usage per owner")
+		apierror.Send(c, err, "Failed to compute usage per owner")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, usage)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// redirectDownload answers a download on a redirecting route with a 302 to
+// the public URL or a presigned storage URL. It reports false when the object
+// has to be streamed through the API instead.
+func (h *MinioHandler) redirectDownload(c *gin.Context, scope tenancy.Scope, filename, disposition, downloadName string) bool {
+	if redirect, _ := c.Get(middleware.DownloadRedirectKey); redirect != true {
+		return false
+	}
+	// Only the API can decrypt objects under a customer or envelope key
+	if storage.CustomerKey(c.Request.Context()) != nil {
+		return false
+	}
+	if _, ok := storage.As[*storage.Envelope](h.storage); ok {
+		return false
+	}
+
+	key := scope.Key(filename)
+	// Storage would serve risky content types as stored, in its own origin or
+	// the CDN's; the API forces them to downloads. A failed stat is reported
+	// by the streaming path.
+	if h.riskyTypes != nil {
+		info, err := h.storage.Stat(c.Request.Context(), scope.Bucket, key)
+		if err != nil || h.risky(info.ContentType) {
+			return false
+		}
+	}
+	// The public URL serves the default bucket, typically through a CDN
+	if base := h.config.DownloadPublicURL; base != "" && scope.Bucket == h.config.MinioBucketName {
+		c.Redirect(http.StatusFound, publicObjectURL(base, key))
+		return true
+	}
+
+	presigner, ok := h.storage.(storage.Presigner)
+	if !ok {
+		return false
+	}
+	expiry := time.Duration(h.config.DownloadRedirectExpiry) * time.Second
+	presigned, _, err := presigner.Presign(c.Request.Context(), http.MethodGet, scope.Bucket, key, expiry, storage.PresignOptions{
+		ResponseContentDisposition: utils.ContentDisposition(disposition, downloadName),
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotSupported) {
+			return false
+		}
+		hc := newHandlerContext(c, h.logger)
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to presign download redirect")
+		apierror.Send(c, err, "Failed to get file")
+		return true
+	}
+	// The signature expires, so the redirect itself must not be cached
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, presigned.String())
+	return true
+}
+
+// publicObjectURL joins a public base URL and an object key, escaping each
+// path segment of the key
+func publicObjectURL(base, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/replication"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// ReplicationHandler exposes drift detection and reconciliation between the
+// primary and mirror backends
+type ReplicationHandler struct {
+	mirror        *replication.Backend
+	defaultBucket string
+	logger        *zerolog.Logger
+}
+
+// NewReplicationHandler creates a new ReplicationHandler
+func NewReplicationHandler(mirror *replication.Backend, defaultBucket string, logger *zerolog.Logger) *ReplicationHandler {
+	return &ReplicationHandler{
+		mirror:        mirror,
+		defaultBucket: defaultBucket,
+		logger:        logger,
+	}
+}
+
+// ReconcileRequest is the body for reconciling the mirror
+type ReconcileRequest struct {
+	Bucket string `json:"bucket,omitempty" example:"my-bucket"` // defaults to the API bucket
+	Prefix string `json:"prefix,omitempty" example:"tenants/acme/"`
+	Prune  bool   `json:"prune,omitempty"` // also remove objects that only exist on the mirror
+}
+
+// GetDrift compares the primary and mirror backends
+// @Summary Detect replication drift
+// @Description Compare objects on the primary and mirror backends by size and checksum
+// @Tags admin
+// @Produce json
+// @Param bucket query string false "Bucket (defaults to the API bucket)"
+// @Param prefix query string false "Key prefix"
+// @Success 200 {object} replication.DriftReport
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/replication/drift [get]
+func (h *ReplicationHandler) GetDrift(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	bucket := c.DefaultQuery("bucket", h.defaultBucket)
+
+	report, err := h.mirror.Detect(c.Request.Context(), bucket, c.Query("prefix"))
+	if err != nil {
+		h.sendError(c, hc, bucket, err, "Failed to detect replication drift")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, report)
+}
+
+// Reconcile queues jobs that bring the mirror back in sync
+// @Summary Reconcile the replication mirror
+// @Description Detect drift and queue copy jobs for missing or mismatched objects, and removal jobs for mirror-only objects when prune is set
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body ReconcileRequest false "Reconciliation scope"
+// @Success 202 {object} replication.DriftReport
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/replication/reconcile [post]
+func (h *ReplicationHandler) Reconcile(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	var req ReconcileRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if req.Bucket == "" {
+		req.Bucket = h.defaultBucket
+	}
+
+	report, err := h.mirror.Reconcile(c.Request.Context(), req.Bucket, req.Prefix, req.Prune)
+	if err != nil {
+		h.sendError(c, hc, req.Bucket, err, "Failed to reconcile replication")
+		return
+	}
+	hc.logger.Info().
+		Str("bucket", req.Bucket).
+		Int("missing", len(report.Missing)).
+		Int("mismatched", len(report.Mismatched)).
+		Int("extra", len(report.Extra)).
+		Bool("prune", req.Prune).
+		Msg("Replication reconciliation queued")
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, report)
+}
+
+func (h *ReplicationHandler) sendError(c *gin.Context, hc handlerContext, bucket string, err error, message string) {
+	if errors.Is(err, storage.ErrBucketNotFound) || errors.Is(err, storage.ErrInvalidBucket) {
+		apierror.Write(c, http.StatusNotFound, apierror.CodeBucketMissing, "Bucket not found")
+		return
+	}
+	hc.logger.Error().Err(err).Str("bucket", bucket).Msg(message)
+	apierror.Send(c, err, message)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/search"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// Search result limits
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// SearchHandler filters files server-side
+type SearchHandler struct {
+	index  *search.Index
+	logger *zerolog.Logger
+	config *config.Config
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(index *search.Index, logger *zerolog.Logger, cfg *config.Config) *SearchHandler {
+	return &SearchHandler{index: index, logger: logger, config: cfg}
+}
+
+// SearchResponse holds the files matching a search
+type SearchResponse struct {
+	Files []search.Entry `json:"files"`
+	// Truncated is set when more files matched than the limit
+	Truncated bool `json:"truncated"`
+	// IndexedAt is when the index that answered was last refreshed; absent
+	// when the bucket was listed for this query
+	IndexedAt *time.Time `json:"indexedAt,omitempty"`
+}
+
+// SearchFiles finds files by name, tags, content type, size, date and content
+// @Summary Search files
+// @Description Filter files server-side, sorted by name, or by relevance for content queries. Buckets are answered from an index refreshed in the background every SEARCH_INDEX_INTERVAL seconds, so recent changes may be missing until the next refresh (see indexedAt); buckets not indexed yet are listed for the query instead. Tags are only known on S3 backends.
+// @Tags files
+// @Produce json
+// @Param q query string false "Text the file name must contain, ignoring case"
+// @Param content query string false "Words the document text must contain (PDF, DOCX and text files); results are ranked by relevance and include a snippet. Requires CONTENT_INDEX."
+// @Param prefix query string false "Only search files whose name starts with this prefix"
+// @Param tag query []string false "Tag the file must have, as key or key=value; repeat for several" collectionFormat(multi)
+// @Param content_type query string false "Content type, exact or a whole type such as image/*"
+// @Param min_size query int false "Minimum size in bytes"
+// @Param max_size query int false "Maximum size in bytes"
+// @Param after query string false "Only files modified after this time (RFC 3339)"
+// @Param before query string false "Only files modified before this time (RFC 3339)"
+// @Param limit query int false "Maximum number of files (1-1000)" default(100)
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 501 {object} utils.ErrorResponse
+// @Router /files/search [get]
+func (h *SearchHandler) SearchFiles(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	scope := tenancy.ScopeFrom(c, tenancy.Scope{Bucket: h.config.MinioBucketName})
+
+	query := search.Query{
+		Prefix:      scope.Key(c.Query("prefix")),
+		Text:        c.Query("q"),
+		Content:     strings.TrimSpace(c.Query("content")),
+		ContentType: strings.TrimSpace(c.Query("content_type")),
+		Limit:       defaultSearchLimit,
+	}
+	for _, tag := range c.QueryArray("tag") {
+		key, value, _ := strings.Cut(tag, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			utils.SendError(c, http.StatusBadRequest, "tag must be key or key=value")
+			return
+		}
+		if query.Tags == nil {
+			query.Tags = make(map[string]string)
+		}
+		query.Tags[key] = value
+	}
+	var err error
+	for _, size := range []struct {
+		param string
+		dst   *int64
+	}{{"min_size", &query.MinSize}, {"max_size", &query.MaxSize}} {
+		if v := c.Query(size.param); v != "" {
+			if *size.dst, err = strconv.ParseInt(v, 10, 64); err != nil || *size.dst < 0 {
+				utils.SendError(c, http.StatusBadRequest, size.param+" must be a non-negative number of bytes")
+				return
+			}
+		}
+	}
+	if query.MaxSize > 0 && query.MaxSize < query.MinSize {
+		utils.SendError(c, http.StatusBadRequest, "max_size must not be less than min_size")
+		return
+	}
+	if v := c.Query("after"); v != "" {
+		if query.After, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "after must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if v := c.Query("before"); v != "" {
+		if query.Before, err = time.Parse(time.RFC3339, v); err != nil {
+			utils.SendError(c, http.StatusBadRequest, "before must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 || query.Limit > maxSearchLimit {
+			utils.SendError(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+	}
+
+	result, err := h.index.Search(c.Request.Context(), scope.Bucket, query)
+	if errors.Is(err, search.ErrContentDisabled) {
+		utils.SendError(c, http.StatusNotImplemented, "Content search is not enabled")
+		return
+	}
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to search files")
+		apierror.Send(c, err, "Failed to search files")
+		return
+	}
+
+	resp := SearchResponse{Files: make([]search.Entry, 0, len(result.Entries)), Truncated: result.Truncated}
+	for _, entry := range result.Entries {
+		entry.Key = scope.Name(entry.Key)
+		resp.Files = append(resp.Files, entry)
+	}
+	if !result.IndexedAt.IsZero() {
+		resp.IndexedAt = &result.IndexedAt
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, resp)
+}
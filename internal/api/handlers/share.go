@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/sharing"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// SharePasswordHeader carries the password for protected share links
+const SharePasswordHeader = "X-Share-Password"
+
+// ShareHandler handles share link management and public share downloads
+type ShareHandler struct {
+	shares *sharing.Service
+	files  *MinioHandler
+	logger *zerolog.Logger
+}
+
+// NewShareHandler creates a new ShareHandler
+func NewShareHandler(shares *sharing.Service, files *MinioHandler, logger *zerolog.Logger) *ShareHandler {
+	return &ShareHandler{
+		shares: shares,
+		files:  files,
+		logger: logger,
+	}
+}
+
+// CreateShareRequest is the body for creating a share link
+type CreateShareRequest struct {
+	Key          string     `json:"key" binding:"required" example:"report.pdf"`
+	Password     string     `json:"password,omitempty"`
+	ExpiresIn    int64      `json:"expiresIn,omitempty" example:"86400"` // seconds
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	MaxDownloads int        `json:"maxDownloads,omitempty" example:"10"`
+}
+
+// ShareResponse describes a share link without its secret material
+type ShareResponse struct {
+	Slug              string     `json:"slug"`
+	URL               string     `json:"url"`
+	Key               string     `json:"key"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	MaxDownloads      int        `json:"maxDownloads,omitempty"`
+	Downloads         int        `json:"downloads"`
+	CreatedBy         string     `json:"createdBy,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+	Active            bool       `json:"active"`
+}
+
+func toShareResponse(link *sharing.Link) ShareResponse {
+	return ShareResponse{
+		Slug:              link.Slug,
+		URL:               "/s/" + link.Slug,
+		Key:               link.Key,
+		PasswordProtected: link.HasPassword(),
+		ExpiresAt:         link.ExpiresAt,
+		MaxDownloads:      link.MaxDownloads,
+		Downloads:         link.Downloads,
+		CreatedBy:         link.CreatedBy,
+		CreatedAt:         link.CreatedAt,
+		RevokedAt:         link.RevokedAt,
+		Active:            link.Usable(time.Now()) == nil,
+	}
+}
+
+// CreateShare creates a share link for a file
+// @Summary Create a share link
+// @Description Create a short, unguessable public link for a file with optional password, expiry and download limit. When the file is envelope-encrypted, a password-protected link wraps its data key under the password, so the API can only decrypt the file for the link with the password.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param request body CreateShareRequest true "Share link options"
+// @Success 201 {object} ShareResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /shares [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ExpiresIn < 0 || req.MaxDownloads < 0 {
+		utils.SendError(c, http.StatusBadRequest, "expiresIn and maxDownloads must not be negative")
+		return
+	}
+
+	scope := h.files.scope(c)
+	exists, err := h.files.files.Exists(c.Request.Context(), scope.Bucket, scope.Key(req.Key))
+	if err != nil {
+		hc.logger.Error().Err(err).Str("key", req.Key).Msg("Failed to check file existence")
+		apierror.Send(c, err, "Failed to create share link")
+		return
+	}
+	if !exists {
+		apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.ExpiresIn > 0 {
+		t := time.Now().UTC().Add(time.Duration(req.ExpiresIn) * time.Second)
+		expiresAt = &t
+	}
+
+	link, err := h.shares.Create(c.Request.Context(), sharing.CreateOptions{
+		TenantID:     scope.TenantID,
+		Bucket:       scope.Bucket,
+		Prefix:       scope.Prefix,
+		Key:          req.Key,
+		Password:     req.Password,
+		ExpiresAt:    expiresAt,
+		MaxDownloads: req.MaxDownloads,
+		CreatedBy:    auth.SubjectFrom(c),
+	})
+	if err != nil {
+		hc.logger.Error().Err(err).Str("key", req.Key).Msg("Failed to create share link")
+		apierror.Send(c, err, "Failed to create share link")
+		return
+	}
+
+	c.Set(middleware.AuditKeyKey, scope.Key(link.Key))
+	hc.logger.Info().Str("key", link.Key).Str("slug", link.Slug).Msg("Share link created")
+	h.files.emit(c, events.Event{
+		Type:      events.TypeShared,
+		Bucket:    scope.Bucket,
+		Key:       scope.Key(link.Key),
+		TenantID:  scope.TenantID,
+		ShareSlug: link.Slug,
+	})
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, toShareResponse(link))
+}
+
+// ListShares lists share links
+// @Summary List share links
+// @Description List all share links including expired and revoked ones
+// @Tags shares
+// @Produce json
+// @Success 200 {array} ShareResponse
+// @Router /shares [get]
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	links, err := h.shares.List(c.Request.Context(), h.files.scope(c).TenantID)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to list share links")
+		apierror.Send(c, err, "Failed to list share links")
+		return
+	}
+
+	result := make([]ShareResponse, 0, len(links))
+	for _, link := range links {
+		result = append(result, toShareResponse(link))
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, result)
+}
+
+// GetShare returns a share link
+// @Summary Get a share link
+// @Tags shares
+// @Produce json
+// @Param slug path string true "Share slug"
+// @Success 200 {object} ShareResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /shares/{slug} [get]
+func (h *ShareHandler) GetShare(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	link, err := h.tenantLink(c)
+	if err != nil {
+		if errors.Is(err, sharing.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Share link not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to get share link")
+		apierror.Send(c, err, "Failed to get share link")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, toShareResponse(link))
+}
+
+// RevokeShare revokes a share link
+// @Summary Revoke a share link
+// @Tags shares
+// @Produce json
+// @Param slug path string true "Share slug"
+// @Success 200 {object} ShareResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /shares/{slug} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	link, err := h.tenantLink(c)
+	if err == nil {
+		link, err = h.shares.Revoke(c.Request.Context(), c.Param("slug"))
+	}
+	if err != nil {
+		if errors.Is(err, sharing.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Share link not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to revoke share link")
+		apierror.Send(c, err, "Failed to revoke share link")
+		return
+	}
+
+	hc.logger.Info().Str("slug", link.Slug).Msg("Share link revoked")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, toShareResponse(link))
+}
+
+// DownloadShare serves the file behind a public share link
+// @Summary Download a shared file
+// @Description Public, unauthenticated download through a share link. Password-protected links take the password in the X-Share-Password header or, with POST, a password form or JSON field; never in the URL. A download only counts against the link's limit once it completes. Links to envelope-encrypted files decrypt them with the data key the password unwraps, unless SHARE_PASSWORD_ENCRYPTION is disabled; they are never redirected to storage, and stop serving the file once it is deleted or replaced. Files of the content types in DOWNLOAD_RISKY_TYPES, such as HTML and SVG, are sent as application/octet-stream attachments whatever the disposition, so they never run in the API's origin; with DOWNLOAD_SANITIZE_SVG, SVGs shown inline are sanitized instead.
+// @Tags shares
+// @Produce octet-stream
+// @Param slug path string true "Share slug"
+// @Param X-Share-Password header string false "Share link password"
+// @Param password formData string false "Share link password (POST)"
+// @Param disposition query string false "Content disposition" Enums(inline, attachment)
+// @Success 200 {file} binary
+// @Success 302 "Redirect to the file in storage, when share link downloads are configured to redirect"
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 410 {object} utils.ErrorResponse
+// @Router /s/{slug} [get]
+// @Router /s/{slug} [post]
+func (h *ShareHandler) DownloadShare(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	password := sharePassword(c)
+	link, err := h.shares.Consume(c.Request.Context(), c.Param("slug"), password)
+	if err != nil {
+		switch {
+		case errors.Is(err, sharing.ErrNotFound):
+			utils.SendError(c, http.StatusNotFound, "Share link not found")
+		case errors.Is(err, sharing.ErrPasswordRequired), errors.Is(err, sharing.ErrInvalidPassword):
+			utils.SendError(c, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, sharing.ErrExpired), errors.Is(err, sharing.ErrRevoked), errors.Is(err, sharing.ErrExhausted):
+			utils.SendError(c, http.StatusGone, err.Error())
+		default:
+			hc.logger.Error().Err(err).Msg("Failed to resolve share link")
+			apierror.Send(c, err, "Failed to resolve share link")
+		}
+		return
+	}
+
+	disposition := c.DefaultQuery("disposition", "attachment")
+	if disposition != "inline" {
+		disposition = "attachment"
+	}
+	hc.logger.Info().Str("slug", link.Slug).Str("key", link.Key).Int("downloads", link.Downloads).Msg("Share link download")
+	scope := tenancy.Scope{TenantID: link.TenantID, Bucket: link.Bucket, Prefix: link.Prefix}
+	if scope.Bucket == "" {
+		scope.Bucket = h.files.config.MinioBucketName
+	}
+	// Public downloads carry no credentials, so the link supplies the tenant scope
+	c.Set(tenancy.ScopeKey, scope)
+	c.Set(middleware.AuditKeyKey, scope.Key(link.Key))
+	var delivered bool
+	if link.Sealed != nil {
+		delivered = h.serveSealed(c, scope, link, disposition)
+	} else {
+		delivered = h.files.serveObject(c, scope, link.Key, disposition, path.Base(link.Key))
+	}
+	if !delivered {
+		// The client may have gone away; the reserved download still goes back
+		if err := h.shares.Release(context.WithoutCancel(c.Request.Context()), link.Slug); err != nil {
+			hc.logger.Error().Err(err).Str("slug", link.Slug).Msg("Failed to release share link download")
+		}
+	}
+}
+
+// sharePassword reads the password of a protected link from the
+// X-Share-Password header or, for POST, the password form or JSON field.
+// The query string is not accepted: it ends up in logs and browser history.
+func sharePassword(c *gin.Context) string {
+	if password := c.GetHeader(SharePasswordHeader); password != "" {
+		return password
+	}
+	if c.Request.Method != http.MethodPost {
+		return ""
+	}
+	if c.ContentType() == gin.MIMEJSON {
+		var body struct {
+			Password string `json:"password"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		return body.Password
+	}
+	return c.PostForm("password")
+}
+
+// serveSealed streams the file of a sealed link, decrypted with the data key
+// its password unwrapped, and reports whether it was delivered in full. Only
+// the API can decrypt it, so it is never redirected to storage.
+func (h *ShareHandler) serveSealed(c *gin.Context, scope tenancy.Scope, link *sharing.Link, disposition string) bool {
+	hc := newHandlerContext(c, h.logger)
+
+	content, info, err := h.shares.OpenContent(c.Request.Context(), link, scope.Bucket, scope.Key(link.Key))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return false
+		}
+		hc.logger.Error().Err(err).Str("slug", link.Slug).Msg("Failed to open sealed share file")
+		apierror.Send(c, err, "Failed to get file")
+		return false
+	}
+	defer content.Close()
+
+	c.Header("Cache-Control", "no-store")
+	if err := h.files.writeContent(c, content, info.ContentType, info.Size, disposition, path.Base(link.Key), false); err != nil {
+		hc.logger.Error().Err(err).Str("slug", link.Slug).Msg("Failed to stream sealed share file")
+		return false
+	}
+	h.files.emit(c, events.Event{
+		Type:        events.TypeDownloaded,
+		Bucket:      scope.Bucket,
+		Key:         scope.Key(link.Key),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		TenantID:    scope.TenantID,
+		ShareSlug:   link.Slug,
+	})
+	return true
+}
+
+// tenantLink loads the link named in the path, hiding links that belong to other tenants
+func (h *ShareHandler) tenantLink(c *gin.Context) (*sharing.Link, error) {
+	link, err := h.shares.Get(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		return nil, err
+	}
+	if link.TenantID != h.files.scope(c).TenantID {
+		return nil, sharing.ErrNotFound
+	}
+	return link, nil
+}
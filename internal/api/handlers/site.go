@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/httpcache"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// SiteHandler serves a bucket, or a prefix of one, as a static website
+type SiteHandler struct {
+	storage       storage.Backend
+	bucket        string
+	prefix        string
+	indexDocument string
+	errorDocument string
+	cachePolicy   *httpcache.Policy
+	logger        *zerolog.Logger
+}
+
+// NewSiteHandler creates a SiteHandler from the SITE_* configuration
+func NewSiteHandler(backend storage.Backend, logger *zerolog.Logger, cfg *config.Config) (*SiteHandler, error) {
+	rules, err := httpcache.ParseRules(cfg.SiteCacheControlRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SITE_CACHE_CONTROL_RULES: %w", err)
+	}
+	prefix := strings.Trim(cfg.SitePrefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &SiteHandler{
+		storage:       backend,
+		bucket:        cfg.SiteBucket(),
+		prefix:        prefix,
+		indexDocument: cfg.SiteIndexDocument,
+		errorDocument: cfg.SiteErrorDocument,
+		cachePolicy:   &httpcache.Policy{Default: cfg.SiteCacheControl, Rules: rules},
+		logger:        logger,
+	}, nil
+}
+
+// ServeSite serves a file of the hosted website
+// @Summary Serve the static website
+// @Description Serve a file from the website bucket. Paths ending in "/" resolve to the index document, directories without a trailing slash redirect to it, and missing paths get the error document with a 404.
+// @Tags site
+// @Produce octet-stream
+// @Param path path string true "File path"
+// @Success 200 {file} binary
+// @Success 301
+// @Success 304
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /site/{path} [get]
+func (h *SiteHandler) ServeSite(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	ctx := c.Request.Context()
+
+	// Clean against the root so ".." can't escape the prefix
+	requested := c.Param("path")
+	name := strings.TrimPrefix(path.Clean("/"+requested), "/")
+	if name == "" || strings.HasSuffix(requested, "/") {
+		name = path.Join(name, h.indexDocument)
+	}
+
+	key := h.prefix + name
+	object, stat, err := h.storage.Get(ctx, h.bucket, key)
+	if errors.Is(err, storage.ErrNotFound) && !strings.HasSuffix(requested, "/") && h.exists(ctx, h.prefix+path.Join(name, h.indexDocument)) {
+		// A directory: redirect so relative links in its index resolve against it
+		location := c.Request.URL.Path + "/"
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+	status := http.StatusOK
+	if errors.Is(err, storage.ErrNotFound) && h.errorDocument != "" {
+		status = http.StatusNotFound
+		key = h.prefix + h.errorDocument
+		object, stat, err = h.storage.Get(ctx, h.bucket, key)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "Page not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("path", name).Msg("Failed to get site file")
+		apierror.Send(c, err, "Failed to get file")
+		return
+	}
+	defer object.Close()
+
+	contentType := siteContentType(stat.ContentType, key)
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	if status == http.StatusOK {
+		c.Header("ETag", httpcache.QuoteETag(stat.ETag))
+		c.Header("Last-Modified", stat.LastModified.UTC().Format(http.TimeFormat))
+		c.Header("Cache-Control", h.cachePolicy.CacheControl(contentType))
+		if httpcache.NotModified(c.Request, stat.ETag, stat.LastModified) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else {
+		// The page may exist later; don't let caches pin the 404
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Header("Content-Length", fmt.Sprintf("%d", stat.Size))
+	c.Status(status)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(c.Writer, object); err != nil {
+		hc.logger.Error().Err(err).Str("path", name).Msg("Failed to stream site file")
+	}
+}
+
+func (h *SiteHandler) exists(ctx context.Context, key string) bool {
+	_, err := h.storage.Stat(ctx, h.bucket, key)
+	return err == nil
+}
+
+// siteContentType prefers the stored type, falling back to the extension for
+// objects uploaded without one (common with bulk uploads of build output)
+func siteContentType(stored, key string) string {
+	switch filetype.BaseType(stored) {
+	case "", "application/octet-stream", "binary/octet-stream":
+		if t, ok := filetype.Default.ByExtension(key); ok {
+			return t
+		}
+		if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+			return t
+		}
+	}
+	if stored == "" {
+		return "application/octet-stream"
+	}
+	return stored
+}
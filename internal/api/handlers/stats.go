@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// StatsHandler exposes storage usage statistics
+type StatsHandler struct {
+	scanner *stats.Scanner
+	logger  *zerolog.Logger
+}
+
+// NewStatsHandler creates a new StatsHandler
+func NewStatsHandler(scanner *stats.Scanner, logger *zerolog.Logger) *StatsHandler {
+	return &StatsHandler{scanner: scanner, logger: logger}
+}
+
+// StatsResponse is the latest scan with the usage history
+type StatsResponse struct {
+	*stats.Snapshot
+	History []stats.Point `json:"history"`
+	Growth  *stats.Growth `json:"growth,omitempty"`
+}
+
+// GetStats returns the latest storage statistics
+// @Summary Get storage statistics
+// @Description Return object counts and bytes per bucket and prefix, the largest objects and usage over time, as of the last background scan
+// @Tags admin
+// @Produce json
+// @Param top query int false "Number of largest objects to return (default all scanned, up to STATS_TOP_N)"
+// @Success 200 {object} StatsResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /admin/stats [get]
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	top := -1
+	if v := c.Query("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			utils.SendError(c, http.StatusBadRequest, "top must be a non-negative integer")
+			return
+		}
+		top = n
+	}
+
+	snapshot, err := h.scanner.Latest(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, stats.ErrNoSnapshot) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeNotFound, "No storage scan has completed yet; start one with POST /api/v1/admin/stats/scan")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to read storage statistics")
+		apierror.Send(c, err, "Failed to read storage statistics")
+		return
+	}
+	if top >= 0 && top < len(snapshot.Largest) {
+		snapshot.Largest = snapshot.Largest[:top]
+	}
+
+	history, err := h.scanner.History(c.Request.Context())
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to read storage history")
+		apierror.Send(c, err, "Failed to read storage history")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, StatsResponse{
+		Snapshot: snapshot,
+		History:  history,
+		Growth:   stats.GrowthOf(history),
+	})
+}
+
+// Scan starts a storage scan
+// @Summary Start a storage scan
+// @Description Queue a background scan that refreshes the storage statistics; follow it through /admin/jobs/{id}
+// @Tags admin
+// @Produce json
+// @Success 202 {object} jobs.Job
+// @Router /admin/stats/scan [post]
+func (h *StatsHandler) Scan(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	job, err := h.scanner.Enqueue(c.Request.Context())
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to queue storage scan")
+		apierror.Send(c, err, "Failed to queue storage scan")
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusAccepted, job)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/hls"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/httpcache"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// StreamVideo serves the HLS stream generated for a video
+// @Summary Stream a video
+// @Description Serve the adaptive (HLS) stream of an uploaded video, starting with master.m3u8. Renditions and segments are served under the same path, relative to the playlist. Streams are generated in the background after upload; until then this returns 404.
+// @Tags files
+// @Produce application/vnd.apple.mpegurl,video/mp2t
+// @Param filename path string true "File name"
+// @Success 200 {file} binary
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/{filename}/stream/master.m3u8 [get]
+func (h *MinioHandler) StreamVideo(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+	ctx := c.Request.Context()
+
+	asset := strings.TrimPrefix(c.Param("asset"), "/")
+	if asset == "" || path.Clean(asset) != asset || asset == ".." || strings.HasPrefix(asset, "../") {
+		utils.SendError(c, http.StatusBadRequest, "Invalid stream path")
+		return
+	}
+
+	stat, err := h.storage.Stat(ctx, scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+	if !strings.HasPrefix(stat.ContentType, "video/") {
+		utils.SendError(c, http.StatusUnsupportedMediaType, "File is not a video")
+		return
+	}
+
+	object, info, err := h.storage.Get(ctx, h.config.DerivedBucket(), hls.Prefix(scope.Bucket, stat)+asset)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			if asset == hls.MasterPlaylist {
+				utils.SendError(c, http.StatusNotFound, "Stream not available yet")
+			} else {
+				utils.SendError(c, http.StatusNotFound, "Stream file not found")
+			}
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Str("asset", asset).Msg("Failed to get stream file")
+		apierror.Send(c, err, "Failed to get stream")
+		return
+	}
+	defer object.Close()
+
+	contentType := hls.ContentType(asset)
+	c.Header("ETag", httpcache.QuoteETag(info.ETag))
+	if httpcache.NotModified(c.Request, info.ETag, time.Time{}) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(contentType))
+	c.DataFromReader(http.StatusOK, info.Size, contentType, object, nil)
+}
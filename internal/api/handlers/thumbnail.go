@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/httpcache"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/imaging"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// Metadata recorded on derived objects so they can be traced back to their source
+const (
+	sourceKeyMetadataKey  = storage.SourceKeyMetadataKey
+	sourceETagMetadataKey = "Source-Etag"
+)
+
+// thumbnailKey identifies a cached variant. The source ETag is part of the key so
+// replacing the source never serves a stale thumbnail.
+func thumbnailKey(sourceKey, sourceETag string, width, height int, fit imaging.Fit) string {
+	return fmt.Sprintf("thumbnails/%s/%s/%dx%d-%s", sourceKey, sourceETag, width, height, fit)
+}
+
+// GetThumbnail returns a resized version of an image
+// @Summary Get an image thumbnail
+// @Description Resize an image server-side. Generated variants are cached in the derived bucket and reused until the source changes.
+// @Tags files
+// @Produce image/jpeg,image/png,image/gif
+// @Param filename path string true "File name"
+// @Param w query int false "Width in pixels"
+// @Param h query int false "Height in pixels"
+// @Param fit query string false "Fit mode" Enums(contain, cover, fill)
+// @Success 200 {file} binary
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Router /files/{filename}/thumbnail [get]
+func (h *MinioHandler) GetThumbnail(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	filename := c.Param("filename")
+	scope := h.scope(c)
+	ctx := c.Request.Context()
+
+	width, errW := strconv.Atoi(c.DefaultQuery("w", "0"))
+	height, errH := strconv.Atoi(c.DefaultQuery("h", "0"))
+	if errW != nil || errH != nil || width < 0 || height < 0 || (width == 0 && height == 0) ||
+		width > imaging.MaxDimension || height > imaging.MaxDimension {
+		utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("w and/or h must be between 1 and %d", imaging.MaxDimension))
+		return
+	}
+	fit, err := imaging.ParseFit(c.Query("fit"))
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stat, err := h.storage.Stat(ctx, scope.Bucket, scope.Key(filename))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(c, http.StatusNotFound, apierror.CodeFileNotFound, "File not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file stats")
+		apierror.Send(c, err, "Failed to get file info")
+		return
+	}
+
+	derivedBucket := h.config.DerivedBucket()
+	key := thumbnailKey(path.Join(scope.Bucket, scope.Key(filename)), stat.ETag, width, height, fit)
+
+	// Serve the cached variant if it was generated before
+	if object, cached, err := h.storage.Get(ctx, derivedBucket, key); err == nil {
+		defer object.Close()
+		h.writeThumbnail(c, cached.ContentType, cached.ETag, cached.Size, object)
+		return
+	}
+
+	source, _, err := h.storage.Get(ctx, scope.Bucket, scope.Key(filename))
+	if err != nil {
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to get file from MinIO")
+		apierror.Send(c, err, "Failed to get file")
+		return
+	}
+	defer source.Close()
+
+	img, format, err := imaging.Decode(source)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupported) {
+			utils.SendError(c, http.StatusUnsupportedMediaType, "File is not a supported image")
+			return
+		}
+		hc.logger.Warn().Err(err).Str("filename", filename).Msg("Failed to decode image")
+		utils.SendError(c, http.StatusUnprocessableEntity, "Failed to decode image")
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType, err := imaging.Encode(&buf, imaging.Resize(img, width, height, fit), format)
+	if err != nil {
+		hc.logger.Error().Err(err).Str("filename", filename).Msg("Failed to encode thumbnail")
+		apierror.Send(c, err, "Failed to generate thumbnail")
+		return
+	}
+
+	// Caching is best effort; a failed write only costs a regeneration next time
+	data := buf.Bytes()
+	info, err := h.storage.Put(context.WithoutCancel(ctx), derivedBucket, key, bytes.NewReader(data), int64(len(data)),
+		storage.PutOptions{
+			ContentType: contentType,
+			Metadata: map[string]string{
+				sourceKeyMetadataKey:  scope.Key(filename),
+				sourceETagMetadataKey: stat.ETag,
+			},
+		})
+	etag := info.ETag
+	if err != nil {
+		hc.logger.Warn().Err(err).Str("key", key).Msg("Failed to cache thumbnail")
+		etag = ""
+	}
+
+	h.writeThumbnail(c, contentType, etag, int64(len(data)), bytes.NewReader(data))
+}
+
+func (h *MinioHandler) writeThumbnail(c *gin.Context, contentType, etag string, size int64, body io.Reader) {
+	if etag != "" {
+		c.Header("ETag", httpcache.QuoteETag(etag))
+		if httpcache.NotModified(c.Request, etag, time.Time{}) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.Header("Cache-Control", h.cachePolicy.CacheControl(contentType))
+	c.DataFromReader(http.StatusOK, size, contentType, body, nil)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// minio-go's defaults when the part size or concurrency is left at 0
+const (
+	defaultPartSize    = 16 << 20
+	defaultConcurrency = 4
+)
+
+// tuningParams are the query parameters that override the upload tuning
+var tuningParams = []string{"part_size", "concurrency", "content_md5", "multipart_threshold"}
+
+// putTuning applies a request's upload tuning overrides to the configured
+// tuning. It returns nil when the request overrides nothing.
+func (h *MinioHandler) putTuning(c *gin.Context) (*storage.PutTuning, error) {
+	overridden := false
+	for _, name := range tuningParams {
+		overridden = overridden || c.Query(name) != ""
+	}
+	if !overridden {
+		return nil, nil
+	}
+
+	tuning := storage.PutTuningFromConfig(h.config)
+	if q := c.Query("part_size"); q != "" {
+		n, err := strconv.ParseUint(q, 10, 64)
+		if err != nil || n < config.MinUploadPartSize || n > config.MaxUploadPartSize {
+			return nil, fmt.Errorf("part_size must be between %d and %d bytes", config.MinUploadPartSize, config.MaxUploadPartSize)
+		}
+		tuning.PartSize = n
+	}
+	if q := c.Query("concurrency"); q != "" {
+		n, err := strconv.ParseUint(q, 10, 32)
+		if err != nil || n == 0 {
+			return nil, errors.New("concurrency must be a positive integer")
+		}
+		tuning.Concurrency = uint(n)
+	}
+	tuning.SendContentMD5 = boolOption(c, "content_md5", tuning.SendContentMD5)
+	if q := c.Query("multipart_threshold"); q != "" {
+		n, err := strconv.ParseInt(q, 10, 64)
+		if err != nil || n < 0 || n > config.MaxUploadPartSize {
+			return nil, fmt.Errorf("multipart_threshold must be between 0 and %d bytes", config.MaxUploadPartSize)
+		}
+		tuning.MultipartThreshold = n
+	}
+
+	// Every part in flight is buffered in memory
+	partSize, concurrency := tuning.PartSize, uint64(tuning.Concurrency)
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+	if concurrency == 0 {
+		concurrency = defaultConcurrency
+	}
+	if limit := uint64(h.config.UploadMaxPartBuffer); partSize*concurrency > limit {
+		return nil, fmt.Errorf("part_size * concurrency must not exceed %d bytes", limit)
+	}
+	return &tuning, nil
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// Upload session chunk sizes. Every chunk but the last must be at least the S3
+// minimum part size.
+const (
+	defaultChunkSize = 8 << 20
+	maxChunkSize     = 5 << 30
+)
+
+// uploadedChunk is a chunk stored as a part of the session's multipart upload
+type uploadedChunk struct {
+	ETag       string    `json:"etag"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// CreateUploadSessionRequest is the body for starting an upload session
+type CreateUploadSessionRequest struct {
+	Filename    string `json:"filename" binding:"required" example:"video.mp4"`
+	ContentType string `json:"contentType,omitempty" example:"video/mp4"`
+	// Size is the total size, if known, used to suggest a chunk size
+	Size int64 `json:"size,omitempty" example:"104857600"`
+}
+
+// UploadSessionChunk is a chunk the server has stored
+type UploadSessionChunk struct {
+	Number int    `json:"number" example:"1"`
+	Size   int64  `json:"size" example:"8388608"`
+	ETag   string `json:"etag" example:"\"d41d8cd98f00b204e9800998ecf8427e\""`
+}
+
+// UploadSessionResponse describes an upload session and the chunks received so far
+type UploadSessionResponse struct {
+	UploadID     string               `json:"uploadId"`
+	Key          string               `json:"key" example:"video.mp4"`
+	ChunkSize    int64                `json:"chunkSize,omitempty" example:"8388608"`
+	MinChunkSize int64                `json:"minChunkSize" example:"5242880"`
+	MaxChunks    int                  `json:"maxChunks" example:"10000"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	Chunks       []UploadSessionChunk `json:"chunks"`
+	// Received is the total size of the stored chunks
+	Received int64 `json:"received" example:"16777216"`
+}
+
+func newUploadSessionResponse(session multipartSession) UploadSessionResponse {
+	resp := UploadSessionResponse{
+		UploadID:     session.UploadID,
+		Key:          session.Name,
+		MinChunkSize: minMultipartPartSize,
+		MaxChunks:    maxMultipartParts,
+		CreatedAt:    session.CreatedAt,
+		Chunks:       make([]UploadSessionChunk, 0, len(session.Chunks)),
+	}
+	for n, chunk := range session.Chunks {
+		resp.Chunks = append(resp.Chunks, UploadSessionChunk{Number: n, Size: chunk.Size, ETag: chunk.ETag})
+		resp.Received += chunk.Size
+	}
+	sort.Slice(resp.Chunks, func(i, j int) bool { return resp.Chunks[i].Number < resp.Chunks[j].Number })
+	return resp
+}
+
+// CreateUploadSession starts a resumable upload whose chunks go through the API
+// @Summary Start an upload session
+// @Description Start a resumable upload. Send the file in numbered chunks with PUT /uploads/{upload_id}/chunks/{n}, in any order and retrying as needed, then assemble them with the complete endpoint. The server keeps track of received chunks, so an interrupted client can ask which chunks are missing and resume. Every chunk but the last must be at least minChunkSize bytes.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body CreateUploadSessionRequest true "Upload options"
+// @Success 201 {object} UploadSessionResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /uploads [post]
+func (h *MinioHandler) CreateUploadSession(c *gin.Context) {
+	var req CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Size < 0 {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if limit := h.config.Live().MaxFileSize; limit > 0 && req.Size > limit {
+		utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+		return
+	}
+
+	session, _, ok := h.startMultipart(c, req.Filename, req.ContentType)
+	if !ok {
+		return
+	}
+	resp := newUploadSessionResponse(session)
+	resp.ChunkSize = defaultChunkSize
+	// Keep within the part limit for large files
+	if perPart := (req.Size + maxMultipartParts - 1) / maxMultipartParts; perPart > resp.ChunkSize {
+		resp.ChunkSize = perPart
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, resp)
+}
+
+// GetUploadSession returns an upload session with the chunks received so far
+// @Summary Get an upload session
+// @Description Return the chunks stored so far, so an interrupted client knows which chunks to send again
+// @Tags uploads
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Success 200 {object} UploadSessionResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /uploads/{upload_id} [get]
+func (h *MinioHandler) GetUploadSession(c *gin.Context) {
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, newUploadSessionResponse(session))
+}
+
+// UploadChunk stores one chunk of an upload session
+// @Summary Upload a chunk
+// @Description Store chunk n (1-10000) of an upload session from the raw request body. Sending a chunk again replaces it, so failed chunks can simply be retried.
+// @Tags uploads
+// @Accept application/octet-stream
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Param n path int true "Chunk number (1-10000)"
+// @Param X-Content-SHA256 header string false "Expected hex SHA-256 of the chunk; mismatches are rejected"
+// @Success 200 {object} UploadSessionChunk
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 411 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /uploads/{upload_id}/chunks/{n} [put]
+func (h *MinioHandler) UploadChunk(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(c.Param("n"))
+	if err != nil || n < 1 || n > maxMultipartParts {
+		utils.SendError(c, http.StatusBadRequest, "Chunk number must be between 1 and 10000")
+		return
+	}
+	size := c.Request.ContentLength
+	if size <= 0 {
+		utils.SendError(c, http.StatusLengthRequired, "Content-Length is required")
+		return
+	}
+	if limit := h.config.Live().MaxFileSize; size > maxChunkSize || (limit > 0 && size > limit) {
+		utils.SendError(c, http.StatusRequestEntityTooLarge, "Chunk exceeds the maximum allowed size")
+		return
+	}
+	var opts minio.PutObjectPartOptions
+	if expected := c.GetHeader(checksum.SHA256Header); expected != "" {
+		if _, err := hex.DecodeString(expected); err != nil || len(expected) != 64 {
+			utils.SendError(c, http.StatusBadRequest, "X-Content-SHA256 must be a hex SHA-256 digest")
+			return
+		}
+		// Storage verifies the digest before accepting the part
+		opts.Sha256Hex = strings.ToLower(expected)
+	}
+	client, ok := h.uploadClient(c, session.Bucket)
+	if !ok {
+		return
+	}
+
+	core := minio.Core{Client: client}
+	part, err := core.PutObjectPart(c.Request.Context(), session.Bucket, session.Key, session.UploadID, n, c.Request.Body, size, opts)
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "NoSuchUpload":
+			utils.SendError(c, http.StatusNotFound, "Upload not found")
+		case "XAmzContentSHA256Mismatch", "BadDigest":
+			utils.SendError(c, http.StatusBadRequest, "Chunk checksum mismatch")
+		default:
+			hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Int("chunk", n).Msg("Failed to upload chunk")
+			apierror.Send(c, err, "Failed to upload chunk")
+		}
+		return
+	}
+
+	chunk := uploadedChunk{ETag: part.ETag, Size: part.Size, UploadedAt: time.Now().UTC()}
+	_, err = store.Update(c.Request.Context(), h.store, multipartSessionKey(session.UploadID), func(s *multipartSession, exists bool) (bool, error) {
+		if !exists {
+			return false, store.ErrNotFound
+		}
+		if s.Chunks == nil {
+			s.Chunks = map[int]uploadedChunk{}
+		}
+		s.Chunks[n] = chunk
+		return true, nil
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Upload not found")
+			return
+		}
+		hc.logger.Error().Err(err).Str("upload_id", session.UploadID).Int("chunk", n).Msg("Failed to record chunk")
+		apierror.Send(c, err, "Failed to upload chunk")
+		return
+	}
+
+	hc.logger.Debug().Str("upload_id", session.UploadID).Int("chunk", n).Int64("size", part.Size).Msg("Chunk uploaded")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, UploadSessionChunk{Number: n, Size: chunk.Size, ETag: chunk.ETag})
+}
+
+// CompleteUploadSession assembles the received chunks into the final object
+// @Summary Complete an upload session
+// @Description Assemble chunks 1 to N, as received, into the final object. Fails if a chunk in that range is missing.
+// @Tags uploads
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 507 {object} utils.ErrorResponse
+// @Router /uploads/{upload_id}/complete [post]
+func (h *MinioHandler) CompleteUploadSession(c *gin.Context) {
+	session, ok := h.multipartSession(c)
+	if !ok {
+		return
+	}
+	if len(session.Chunks) == 0 {
+		utils.SendError(c, http.StatusBadRequest, "No chunks have been uploaded")
+		return
+	}
+	parts := make([]minio.CompletePart, 0, len(session.Chunks))
+	for n := 1; n <= len(session.Chunks); n++ {
+		chunk, ok := session.Chunks[n]
+		if !ok {
+			utils.SendError(c, http.StatusBadRequest, "Chunk "+strconv.Itoa(n)+" is missing")
+			return
+		}
+		parts = append(parts, minio.CompletePart{PartNumber: n, ETag: chunk.ETag})
+	}
+	h.completeMultipart(c, session, parts)
+}
@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/uploadlink"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// defaultUploadLinkTTL is how long an upload link lasts unless the request
+// says otherwise, capped by UPLOAD_LINK_MAX_TTL
+const defaultUploadLinkTTL = 24 * time.Hour
+
+// UploadLinkHandler handles upload link management and anonymous uploads
+type UploadLinkHandler struct {
+	links    *uploadlink.Service
+	notifier *uploadlink.Notifier
+	files    *MinioHandler
+	logger   *zerolog.Logger
+}
+
+// NewUploadLinkHandler creates a new UploadLinkHandler. notifier may be nil
+// when no webhooks are configured.
+func NewUploadLinkHandler(links *uploadlink.Service, notifier *uploadlink.Notifier, files *MinioHandler, logger *zerolog.Logger) *UploadLinkHandler {
+	return &UploadLinkHandler{
+		links:    links,
+		notifier: notifier,
+		files:    files,
+		logger:   logger,
+	}
+}
+
+// Upload link text limits
+const (
+	maxUploadLinkName    = 200
+	maxUploadLinkMessage = 2000
+)
+
+// CreateUploadLinkRequest is the body for creating an upload link
+type CreateUploadLinkRequest struct {
+	// Name and Message are shown on the upload page
+	Name    string `json:"name,omitempty" example:"Invoices for March"`
+	Message string `json:"message,omitempty" example:"Please upload your signed invoices as PDF."`
+	// NotifyURL receives a webhook for every file that arrives; its host must
+	// be allowed by UPLOAD_LINK_NOTIFY_HOSTS
+	NotifyURL string `json:"notifyUrl,omitempty" example:"https://hooks.example.com/uploads"`
+	// Folder receiving the uploads, below the caller's scope
+	Folder string `json:"folder,omitempty" example:"incoming/invoices"`
+	// MaxFiles is how many files the link accepts; 1 makes it single-use
+	MaxFiles     int        `json:"maxFiles,omitempty" example:"1"`
+	MaxFileSize  int64      `json:"maxFileSize,omitempty" example:"10485760"` // bytes
+	ContentTypes []string   `json:"contentTypes,omitempty" example:"application/pdf,image/*"`
+	ExpiresIn    int64      `json:"expiresIn,omitempty" example:"86400"` // seconds
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// UploadLinkResponse describes an upload link
+type UploadLinkResponse struct {
+	Token        string     `json:"token"`
+	URL          string     `json:"url"`
+	Name         string     `json:"name,omitempty"`
+	Message      string     `json:"message,omitempty"`
+	NotifyURL    string     `json:"notifyUrl,omitempty"`
+	Folder       string     `json:"folder,omitempty"`
+	MaxFiles     int        `json:"maxFiles"`
+	MaxFileSize  int64      `json:"maxFileSize,omitempty"`
+	ContentTypes []string   `json:"contentTypes,omitempty"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	Uploads      int        `json:"uploads"`
+	CreatedBy    string     `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	Active       bool       `json:"active"`
+}
+
+func toUploadLinkResponse(link *uploadlink.Link) UploadLinkResponse {
+	return UploadLinkResponse{
+		Token:        link.Token,
+		URL:          "/u/" + link.Token,
+		Name:         link.Name,
+		Message:      link.Message,
+		NotifyURL:    link.NotifyURL,
+		Folder:       link.Folder,
+		MaxFiles:     link.MaxFiles,
+		MaxFileSize:  link.MaxFileSize,
+		ContentTypes: link.ContentTypes,
+		ExpiresAt:    link.ExpiresAt,
+		Uploads:      link.Uploads,
+		CreatedBy:    link.CreatedBy,
+		CreatedAt:    link.CreatedAt,
+		RevokedAt:    link.RevokedAt,
+		Active:       link.Usable(time.Now()) == nil,
+	}
+}
+
+// UploadLinkInfo is what an uploader may learn about an upload link
+type UploadLinkInfo struct {
+	Name         string    `json:"name,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	MaxFileSize  int64     `json:"maxFileSize,omitempty"`
+	ContentTypes []string  `json:"contentTypes,omitempty"`
+	Remaining    int       `json:"remaining"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// UploadLinkUploadResponse describes a file uploaded through an upload link
+type UploadLinkUploadResponse struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	// Remaining is how many more files the link accepts
+	Remaining int `json:"remaining"`
+}
+
+// CreateUploadLink creates an upload link
+// @Summary Create an upload link
+// @Description Create an unguessable link anyone can upload files through, without credentials, into a folder of the caller's files. The link accepts maxFiles files (1 by default, making it single-use) until it expires, each within maxFileSize and of one of contentTypes when given, on top of the server's upload limits. Uploads count against the creator's quota.
+// @Tags upload-links
+// @Accept json
+// @Produce json
+// @Param request body CreateUploadLinkRequest true "Upload link restrictions"
+// @Success 201 {object} UploadLinkResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /upload-links [post]
+func (h *UploadLinkHandler) CreateUploadLink(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	cfg := h.files.config
+
+	var req CreateUploadLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.MaxFiles == 0 {
+		req.MaxFiles = 1
+	}
+	if req.MaxFiles < 0 || req.MaxFiles > cfg.UploadLinkMaxFiles {
+		utils.SendError(c, http.StatusBadRequest, "maxFiles must be between 1 and UPLOAD_LINK_MAX_FILES")
+		return
+	}
+	if req.MaxFileSize < 0 || req.ExpiresIn < 0 {
+		utils.SendError(c, http.StatusBadRequest, "maxFileSize and expiresIn must not be negative")
+		return
+	}
+	req.Name, req.Message = strings.TrimSpace(req.Name), strings.TrimSpace(req.Message)
+	if len(req.Name) > maxUploadLinkName || len(req.Message) > maxUploadLinkMessage {
+		utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("name and message must be at most %d and %d bytes", maxUploadLinkName, maxUploadLinkMessage))
+		return
+	}
+	if req.NotifyURL != "" && !h.notifyAllowed(req.NotifyURL) {
+		utils.SendError(c, http.StatusBadRequest, "notifyUrl must be an http or https URL on a host allowed by UPLOAD_LINK_NOTIFY_HOSTS")
+		return
+	}
+
+	now := time.Now().UTC()
+	maxTTL := time.Duration(cfg.UploadLinkMaxTTL) * time.Second
+	expiresAt := now.Add(min(defaultUploadLinkTTL, maxTTL))
+	switch {
+	case req.ExpiresIn > 0:
+		expiresAt = now.Add(time.Duration(req.ExpiresIn) * time.Second)
+	case req.ExpiresAt != nil:
+		expiresAt = req.ExpiresAt.UTC()
+	}
+	if !expiresAt.After(now) || expiresAt.Sub(now) > maxTTL {
+		utils.SendError(c, http.StatusBadRequest, "Expiry must be in the future and within UPLOAD_LINK_MAX_TTL")
+		return
+	}
+
+	// Uploads are charged to the creator, like their own uploads
+	var owner string
+	if identity := auth.IdentityFrom(c); identity != nil {
+		owner = identity.Subject
+	}
+	scope := h.files.scope(c)
+	link, err := h.links.Create(c.Request.Context(), uploadlink.CreateOptions{
+		TenantID:     scope.TenantID,
+		Bucket:       scope.Bucket,
+		Prefix:       scope.Prefix,
+		Name:         req.Name,
+		Message:      req.Message,
+		NotifyURL:    req.NotifyURL,
+		Folder:       keygen.SanitizeDir(req.Folder),
+		MaxFiles:     req.MaxFiles,
+		MaxFileSize:  req.MaxFileSize,
+		ContentTypes: filetype.NewPolicy(req.ContentTypes, nil).Allowed,
+		ExpiresAt:    expiresAt,
+		CreatedBy:    owner,
+	})
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to create upload link")
+		apierror.Send(c, err, "Failed to create upload link")
+		return
+	}
+
+	hc.logger.Info().Str("folder", link.Folder).Int("max_files", link.MaxFiles).Msg("Upload link created")
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, toUploadLinkResponse(link))
+}
+
+// ListUploadLinks lists upload links
+// @Summary List upload links
+// @Description List all upload links including expired and revoked ones
+// @Tags upload-links
+// @Produce json
+// @Success 200 {array} UploadLinkResponse
+// @Router /upload-links [get]
+func (h *UploadLinkHandler) ListUploadLinks(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	links, err := h.links.List(c.Request.Context(), h.files.scope(c).TenantID)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to list upload links")
+		apierror.Send(c, err, "Failed to list upload links")
+		return
+	}
+
+	result := make([]UploadLinkResponse, 0, len(links))
+	for _, link := range links {
+		result = append(result, toUploadLinkResponse(link))
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, result)
+}
+
+// RevokeUploadLink revokes an upload link
+// @Summary Revoke an upload link
+// @Tags upload-links
+// @Produce json
+// @Param token path string true "Upload link token"
+// @Success 200 {object} UploadLinkResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /upload-links/{token} [delete]
+func (h *UploadLinkHandler) RevokeUploadLink(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	link, err := h.links.Get(c.Request.Context(), c.Param("token"))
+	if err == nil && link.TenantID != h.files.scope(c).TenantID {
+		// Links of other tenants are hidden
+		err = uploadlink.ErrNotFound
+	}
+	if err == nil {
+		link, err = h.links.Revoke(c.Request.Context(), c.Param("token"))
+	}
+	if err != nil {
+		if errors.Is(err, uploadlink.ErrNotFound) {
+			utils.SendError(c, http.StatusNotFound, "Upload link not found")
+			return
+		}
+		hc.logger.Error().Err(err).Msg("Failed to revoke upload link")
+		apierror.Send(c, err, "Failed to revoke upload link")
+		return
+	}
+
+	hc.logger.Info().Msg("Upload link revoked")
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, toUploadLinkResponse(link))
+}
+
+// GetUploadLinkInfo describes what an upload link accepts
+// @Summary Get upload link restrictions
+// @Description Public, unauthenticated description of what an upload link accepts. Browsers asking for HTML get an upload page instead.
+// @Tags upload-links
+// @Produce json,html
+// @Param token path string true "Upload link token"
+// @Success 200 {object} UploadLinkInfo
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 410 {object} utils.ErrorResponse
+// @Router /u/{token} [get]
+func (h *UploadLinkHandler) GetUploadLinkInfo(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	html := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+	link, err := h.links.Get(c.Request.Context(), c.Param("token"))
+	if err == nil {
+		err = link.Usable(time.Now())
+	}
+	if err != nil {
+		if html {
+			h.renderUploadPage(c, nil, err)
+			return
+		}
+		h.sendLinkError(c, hc, err)
+		return
+	}
+	info := UploadLinkInfo{
+		Name:         link.Name,
+		Message:      link.Message,
+		MaxFileSize:  link.MaxFileSize,
+		ContentTypes: link.ContentTypes,
+		Remaining:    link.Remaining(),
+		ExpiresAt:    link.ExpiresAt,
+	}
+	if html {
+		h.renderUploadPage(c, &info, nil)
+		return
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, info)
+}
+
+// UploadThroughLink stores a file sent through an upload link
+// @Summary Upload a file through an upload link
+// @Description Public, unauthenticated upload through an upload link. Each file uses up one of the link's files, and a file with the name of an existing one is stored with a numeric suffix rather than replacing it.
+// @Tags upload-links
+// @Accept multipart/form-data
+// @Produce json
+// @Param token path string true "Upload link token"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} UploadLinkUploadResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 410 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Failure 415 {object} utils.ErrorResponse
+// @Failure 507 {object} utils.ErrorResponse
+// @Router /u/{token} [post]
+func (h *UploadLinkHandler) UploadThroughLink(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+	token := c.Param("token")
+
+	// Take one of the link's files before reading the body, so concurrent
+	// uploads can't exceed the limit
+	link, err := h.links.Reserve(c.Request.Context(), token)
+	if err != nil {
+		h.sendLinkError(c, hc, err)
+		return
+	}
+	stored := false
+	defer func() {
+		if !stored {
+			if err := h.links.Release(context.WithoutCancel(c.Request.Context()), token); err != nil {
+				hc.logger.Error().Err(err).Msg("Failed to release upload link slot")
+			}
+		}
+	}()
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+			return
+		}
+		utils.SendError(c, http.StatusBadRequest, "Failed to get file")
+		return
+	}
+	defer file.Close()
+
+	cfg := h.files.config
+	algorithms, err := checksum.ParseAlgorithms(cfg.UploadChecksums)
+	if err != nil {
+		apierror.Send(c, err, "Failed to upload file")
+		return
+	}
+	scope := tenancy.Scope{TenantID: link.TenantID, Bucket: link.Bucket, Prefix: link.Prefix}
+	if scope.Bucket == "" {
+		scope.Bucket = cfg.MinioBucketName
+	}
+	// Uploads carry no credentials, so the link supplies the scope and owner
+	// and anonymous uploaders never replace existing files
+	opts := uploadOptions{
+		scope:       scope,
+		policy:      h.files.typePolicy,
+		restrict:    filetype.NewPolicy(link.ContentTypes, nil),
+		maxSize:     link.MaxFileSize,
+		algorithms:  algorithms,
+		strategy:    keygen.StrategySuffix,
+		owner:       link.CreatedBy,
+		extractExif: cfg.UploadExtractExif,
+		stripGPS:    cfg.UploadStripGPS,
+	}
+	c.Set(tenancy.ScopeKey, scope)
+
+	result, err := h.files.storeUpload(c, opts, uploadInput{
+		filename: header.Filename,
+		dir:      link.Folder,
+		body:     file,
+		size:     header.Size,
+	})
+	if err != nil {
+		sendUploadError(c, err)
+		return
+	}
+	stored = true
+	c.Set(middleware.AuditKeyKey, result.key)
+	if h.notifier != nil {
+		h.notifier.FileReceived(c.Request.Context(), link, uploadlink.EventFile{
+			Key:         scope.Name(result.key),
+			Bucket:      result.bucket,
+			ObjectKey:   result.key,
+			Size:        result.size,
+			ContentType: result.contentType,
+		})
+	}
+
+	hc.logger.Info().Str("key", result.key).Int("uploads", link.Uploads).Msg("Upload link upload")
+	utils.SendJSONWithCorrelationID(c, http.StatusCreated, UploadLinkUploadResponse{
+		Filename:    scope.Name(result.key),
+		Size:        result.size,
+		ContentType: result.contentType,
+		Remaining:   link.Remaining(),
+	})
+}
+
+// notifyAllowed reports whether a link may send webhooks to rawURL
+func (h *UploadLinkHandler) notifyAllowed(rawURL string) bool {
+	return webhookAllowed(rawURL, h.files.config.UploadLinkNotifyHosts)
+}
+
+// webhookAllowed reports whether rawURL is an http or https URL on one of
+// hosts, where "*" allows any host
+func webhookAllowed(rawURL string, hosts []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host == "*" || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendLinkError writes the response for a link that can't be used
+func (h *UploadLinkHandler) sendLinkError(c *gin.Context, hc handlerContext, err error) {
+	switch {
+	case errors.Is(err, uploadlink.ErrNotFound):
+		utils.SendError(c, http.StatusNotFound, "Upload link not found")
+	case errors.Is(err, uploadlink.ErrExpired), errors.Is(err, uploadlink.ErrRevoked), errors.Is(err, uploadlink.ErrExhausted):
+		utils.SendError(c, http.StatusGone, err.Error())
+	default:
+		hc.logger.Error().Err(err).Msg("Failed to resolve upload link")
+		apierror.Send(c, err, "Failed to resolve upload link")
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/uploadlink"
+)
+
+// uploadPage is the page external uploaders open from an upload link. It
+// posts each chosen file to the link in turn.
+var uploadPage = template.Must(template.New("upload").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Info}}{{or .Info.Name "Upload files"}}{{else}}Upload link unavailable{{end}}</title>
+<style>
+body{font-family:system-ui,sans-serif;max-width:36rem;margin:3rem auto;padding:0 1rem;color:#222}
+.note{color:#666;font-size:.9rem}
+#files li.ok{color:#17702b}#files li.err{color:#b00020}
+</style>
+</head>
+<body>
+{{if .Info}}
+<h1>{{or .Info.Name "Upload files"}}</h1>
+{{if .Info.Message}}<p>{{.Info.Message}}</p>{{end}}
+<form id="form">
+<input type="file" id="input" name="file"{{if gt .Info.Remaining 1}} multiple{{end}}{{if .Accept}} accept="{{.Accept}}"{{end}} required>
+<button type="submit">Upload</button>
+</form>
+<p class="note">
+<span id="remaining">{{.Info.Remaining}}</span> file(s) remaining{{if .MaxSize}}, up to {{.MaxSize}} each{{end}}.
+Available until {{.Info.ExpiresAt.Format "2006-01-02 15:04 MST"}}.
+</p>
+<ul id="files"></ul>
+<script>
+document.getElementById("form").addEventListener("submit", async function (e) {
+  e.preventDefault();
+  var list = document.getElementById("files");
+  for (var file of document.getElementById("input").files) {
+    var item = document.createElement("li");
+    item.textContent = file.name + ": uploading…";
+    list.appendChild(item);
+    var body = new FormData();
+    body.append("file", file);
+    try {
+      var resp = await fetch(window.location.pathname, {method: "POST", body: body});
+      var data = await resp.json();
+      if (!resp.ok) throw new Error(data.detail || resp.statusText);
+      item.className = "ok";
+      item.textContent = file.name + ": uploaded";
+      document.getElementById("remaining").textContent = data.data.remaining;
+    } catch (err) {
+      item.className = "err";
+      item.textContent = file.name + ": " + err.message;
+    }
+  }
+  e.target.reset();
+});
+</script>
+{{else}}
+<h1>Upload link unavailable</h1>
+<p>{{.Error}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+// renderUploadPage writes the upload page for a usable link, or explains why
+// the link can't be used
+func (h *UploadLinkHandler) renderUploadPage(c *gin.Context, info *UploadLinkInfo, linkErr error) {
+	hc := newHandlerContext(c, h.logger)
+	data := struct {
+		Info    *UploadLinkInfo
+		Accept  string
+		MaxSize string
+		Error   string
+	}{Info: info}
+	status := http.StatusOK
+	switch {
+	case info != nil:
+		data.Accept = strings.Join(info.ContentTypes, ",")
+		if info.MaxFileSize > 0 {
+			data.MaxSize = humanSize(info.MaxFileSize)
+		}
+	case errors.Is(linkErr, uploadlink.ErrNotFound):
+		status, data.Error = http.StatusNotFound, "This upload link doesn't exist."
+	case errors.Is(linkErr, uploadlink.ErrExpired):
+		status, data.Error = http.StatusGone, "This upload link has expired."
+	case errors.Is(linkErr, uploadlink.ErrRevoked):
+		status, data.Error = http.StatusGone, "This upload link has been disabled."
+	case errors.Is(linkErr, uploadlink.ErrExhausted):
+		status, data.Error = http.StatusGone, "This upload link has received all the files it accepts."
+	default:
+		hc.logger.Error().Err(linkErr).Msg("Failed to resolve upload link")
+		status, data.Error = http.StatusInternalServerError, "Something went wrong, please try again later."
+	}
+
+	var b bytes.Buffer
+	if err := uploadPage.Execute(&b, data); err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to render upload page")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", b.Bytes())
+}
+
+// humanSize formats a byte count for people, e.g. "10 MB"
+func humanSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " bytes"
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64) + " " + []string{"kB", "MB", "GB", "TB", "PB"}[exp]
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// releaseQuota credits a removed or replaced object's size back to its owner
+func (h *MinioHandler) releaseQuota(c *gin.Context, stat storage.ObjectInfo) {
+	if err := h.files.ReleaseQuota(c.Request.Context(), stat); err != nil {
+		hc := newHandlerContext(c, h.logger)
+		hc.logger.Error().Err(err).Str("subject", stat.Metadata[quota.OwnerMetadataKey]).Msg("Failed to release quota")
+	}
+}
+
+// GetUsage returns the caller's storage usage and quota
+// @Summary Get storage usage
+// @Description Return the authenticated caller's used bytes, object count and quota limit (0 means unlimited)
+// @Tags usage
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} utils.ErrorResponse
+// @Router /usage [get]
+func (h *MinioHandler) GetUsage(c *gin.Context) {
+	hc := newHandlerContext(c, h.logger)
+
+	identity := auth.IdentityFrom(c)
+	if identity == nil {
+		utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if h.quotas == nil {
+		utils.SendError(c, http.StatusNotFound, "Quotas are not enabled")
+		return
+	}
+
+	usage, err := h.quotas.Usage(c.Request.Context(), identity.Subject)
+	if err != nil {
+		hc.logger.Error().Err(err).Msg("Failed to get usage")
+		apierror.Send(c, err, "Failed to get usage")
+		return
+	}
+
+	limit := h.quotas.Limit(identity.Subject)
+	response := map[string]interface{}{
+		"subject":   identity.Subject,
+		"usedBytes": usage.UsedBytes,
+		"objects":   usage.Objects,
+		"limit":     limit,
+	}
+	if limit > 0 {
+		response["remainingBytes"] = max(0, limit-usage.UsedBytes)
+	}
+	utils.SendJSONWithCorrelationID(c, http.StatusOK, response)
+}
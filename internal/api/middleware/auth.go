@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// APIKeyHeader carries static API keys for machine callers
+const APIKeyHeader = "X-API-Key"
+
+// accessTokenQuery carries the bearer token on WebSocket handshakes, where
+// browsers can't set an Authorization header
+const accessTokenQuery = "access_token"
+
+// AuthMiddleware resolves bearer tokens, API keys and signed requests into an auth.Identity stored
+// on the context. Requests without credentials continue anonymously; requests
+// with invalid credentials are rejected with 401. The whole body of a signed
+// request is hashed before its signature is checked, up to maxFileSize plus
+// multipart overhead when maxFileSize is set; larger ones are rejected with 413.
+func AuthMiddleware(verifier auth.Verifier, maxFileSize func() int64, logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var identity *auth.Identity
+		var err error
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			identity, err = verifier.VerifyAPIKey(key)
+		} else if header := c.GetHeader("Authorization"); auth.IsSignedRequest(header) {
+			bodySum, cleanup, hashErr := signedBody(c, maxFileSize)
+			if hashErr != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(hashErr, &tooLarge) {
+					utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+				} else {
+					LoggerFrom(c, logger).Warn().Err(hashErr).Msg("Failed to read signed request body")
+					utils.SendError(c, http.StatusBadRequest, "Failed to read request body")
+				}
+				c.Abort()
+				return
+			}
+			defer cleanup()
+			identity, err = verifier.VerifySignature(c.Request, bodySum)
+		} else if header != "" {
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || token == "" {
+				utils.SendError(c, http.StatusUnauthorized, "Invalid authorization header")
+				c.Abort()
+				return
+			}
+			identity, err = verifier.VerifyToken(c.Request.Context(), token)
+		} else if token := c.Query(accessTokenQuery); token != "" && c.IsWebsocket() {
+			identity, err = verifier.VerifyToken(c.Request.Context(), token)
+		} else {
+			c.Next()
+			return
+		}
+
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				utils.SendError(c, http.StatusUnauthorized, "Invalid credentials")
+			} else {
+				LoggerFrom(c, logger).Error().Err(err).Msg("Failed to verify credentials")
+				utils.SendError(c, http.StatusServiceUnavailable, "Authentication service unavailable")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set(auth.IdentityKey, identity)
+		tagUser(c, auth.SubjectFrom(c))
+		c.Next()
+	}
+}
+
+// signedBody hashes the body of a signed request and puts it back for the
+// handler, spooling large bodies like the idempotency check does
+func signedBody(c *gin.Context, maxFileSize func() int64) (string, func(), error) {
+	limit := int64(-1)
+	if maxFileSize != nil {
+		if max := maxFileSize(); max > 0 {
+			limit = max + multipartOverhead
+		}
+	}
+	return hashBody(c, limit)
+}
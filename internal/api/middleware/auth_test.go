@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// A captured signed request replayed with another body is rejected before the
+// handler runs, even when the handler's decoder would stop short of the end
+func TestSignedRequestBody(t *testing.T) {
+	verifier, err := auth.NewServiceVerifier("", nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := auth.NewSignatureVerifier([]string{"billing:s3cret"}, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.AcceptSignatures(signatures)
+
+	logger := zerolog.Nop()
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), AuthMiddleware(verifier, func() int64 { return 64 }, &logger))
+	var decoded []string
+	router.POST("/transfers", func(c *gin.Context) {
+		var body struct {
+			Amount int `json:"amount"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		decoded = append(decoded, auth.SubjectFrom(c))
+		c.Status(http.StatusOK)
+	})
+
+	send := func(signedBody, sentBody string) int {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(sentBody))
+		req.Header.Set("Content-Type", "application/json")
+		auth.SignRequest(req, "billing", "s3cret", []byte(signedBody), time.Now())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(`{"amount":1}`, `{"amount":1}`); code != http.StatusOK {
+		t.Fatalf("signed request: status = %d, want 200", code)
+	}
+	if code := send(`{"amount":1}`, `{"amount":1000}`); code != http.StatusUnauthorized {
+		t.Errorf("replayed with another body: status = %d, want 401", code)
+	}
+	// The decoder stops after the first value; the trailing bytes still count
+	if code := send(`{"amount":1}`, `{"amount":1} {"amount":1000}`); code != http.StatusUnauthorized {
+		t.Errorf("replayed with trailing content: status = %d, want 401", code)
+	}
+	if code := send(`{"amount":1}`, `{"amount":1}`+strings.Repeat(" ", 1<<21)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversize body: status = %d, want 413", code)
+	}
+	if len(decoded) != 1 || decoded[0] != "signed:billing" {
+		t.Errorf("handler ran for %v, want only the genuine request", decoded)
+	}
+}
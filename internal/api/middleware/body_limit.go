@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// multipartOverhead is the slack allowed on top of the file size for multipart
+// boundaries, part headers and small form fields
+const multipartOverhead = 1 << 20
+
+// BodyLimitMiddleware rejects requests whose body would exceed maxFileSize with 413.
+// Requests advertising a larger Content-Length are refused before any body is read,
+// and the body is wrapped so chunked uploads are cut off once the limit is crossed.
+// maxFileSize is read per request so a reloaded limit applies immediately.
+func BodyLimitMiddleware(maxFileSize func() int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxFileSize := maxFileSize()
+		if maxFileSize <= 0 {
+			c.Next()
+			return
+		}
+
+		limit := maxFileSize + multipartOverhead
+		if c.Request.ContentLength > limit {
+			utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionOptions controls response compression
+type CompressionOptions struct {
+	// Level is the gzip level from 1 (fastest) to 9 (smallest); 0 uses the default
+	Level int
+	// MinSize leaves bodies smaller than this many bytes uncompressed
+	MinSize int
+}
+
+// CompressionMiddleware gzips responses with a compressible media type (JSON,
+// text, XML, SVG, ...) for clients that accept it. Images, video, archives and
+// other already-compressed types pass through, as do range requests, so byte
+// offsets keep referring to the stored object.
+func CompressionMiddleware(opts CompressionOptions) gin.HandlerFunc {
+	level := opts.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, level)
+		return gz
+	}}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Range") != "" || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Writer.Header().Add("Vary", "Accept-Encoding")
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		w := &compressWriter{ResponseWriter: c.Writer, pool: pool, minSize: opts.MinSize}
+		c.Writer = w
+		defer w.close()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		}
+		return q > 0
+	}
+	return false
+}
+
+// compressible reports whether a media type benefits from compression
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		// Events must reach the client as they are written
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml", "application/javascript",
+		"application/yaml", "application/x-yaml", "application/wasm", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter decides on the first write, once the handler has set its
+// status and headers, whether to compress the body
+type compressWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	minSize int
+
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *compressWriter) decide(first []byte) {
+	w.decided = true
+	if w.ResponseWriter.Written() {
+		return
+	}
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified {
+		return
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || !compressible(h.Get("Content-Type")) {
+		return
+	}
+	size := int64(len(first))
+	if cl, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil {
+		size = cl
+	}
+	if size < int64(w.minSize) {
+		return
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+	// The encoded body differs byte for byte from the stored object
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decide(p)
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close writes the gzip trailer and returns the compressor to the pool
+func (w *compressWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	_ = w.gz.Close()
+	w.gz.Reset(io.Discard)
+	w.pool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+)
+
+// ContentTypePolicyKey is the context key holding a route-specific upload policy
+const ContentTypePolicyKey = "ContentTypePolicy"
+
+// ContentTypePolicyMiddleware overrides the global upload content-type policy for a route
+// (e.g. only allow images on an avatar endpoint). Handlers read it via ContentTypePolicyKey.
+func ContentTypePolicyMiddleware(policy *filetype.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ContentTypePolicyKey, policy)
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// CORSOptions controls the CORS headers of responses
+type CORSOptions struct {
+	// AllowedOrigins returns the browser origins allowed to call the API; "*"
+	// allows any. It is called per request so reloaded origins apply at once.
+	AllowedOrigins func() []string
+	// AllowedMethods and AllowedHeaders answer preflight requests
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers readable by browser scripts
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	// from the origins listed by name, never from those "*" lets in
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response, in seconds
+	MaxAge int
+}
+
+// CORSMiddleware is the single place CORS headers are written. Requests from
+// an allowed origin get that origin back in Access-Control-Allow-Origin, and
+// preflight requests from it are answered with 204 without reaching the
+// routes. Requests from other origins get no CORS headers, so browsers block
+// them; their preflights are refused with 403. Credentials are allowed only
+// to origins listed by name, so "*" can't hand any site a caller's session.
+func CORSMiddleware(opts CORSOptions) gin.HandlerFunc {
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(opts.MaxAge)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		// The response depends on the origin, whether or not it is allowed
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		allowed := opts.AllowedOrigins()
+		listed := slices.Contains(allowed, origin)
+		if !listed && !slices.Contains(allowed, "*") {
+			if preflight {
+				utils.SendError(c, http.StatusForbidden, "Origin not allowed")
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		if opts.AllowCredentials && listed {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
+			return
+		}
+
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		if opts.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	origins := []string{"https://app.example.com"}
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), CORSMiddleware(CORSOptions{
+		AllowedOrigins:   func() []string { return origins },
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Authorization"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		w := serve(http.MethodGet, "https://app.example.com", false)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		h := w.Header()
+		if got := h.Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "https://app.example.com" {
+			t.Errorf("Access-Control-Allow-Origin = %q", got)
+		}
+		if h.Get("Access-Control-Allow-Credentials") != "true" || h.Get("Access-Control-Expose-Headers") != "ETag" {
+			t.Errorf("headers = %v", h)
+		}
+		if h.Get("Vary") != "Origin" {
+			t.Errorf("Vary = %q, want Origin", h.Get("Vary"))
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		w := serve(http.MethodOptions, "https://app.example.com", true)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("status = %d, want 204", w.Code)
+		}
+		h := w.Header()
+		if h.Get("Access-Control-Allow-Methods") != "GET, PUT" || h.Get("Access-Control-Allow-Headers") != "Authorization" ||
+			h.Get("Access-Control-Max-Age") != "600" {
+			t.Errorf("headers = %v", h)
+		}
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		w := serve(http.MethodGet, "https://evil.example.com", false)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+		}
+		if w := serve(http.MethodOptions, "https://evil.example.com", true); w.Code != http.StatusForbidden {
+			t.Errorf("preflight status = %d, want 403", w.Code)
+		}
+	})
+
+	t.Run("no origin", func(t *testing.T) {
+		w := serve(http.MethodGet, "", false)
+		if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Vary") != "" {
+			t.Errorf("headers = %v, want no CORS headers", w.Header())
+		}
+	})
+
+	t.Run("reloaded origins", func(t *testing.T) {
+		origins = []string{"*"}
+		defer func() { origins = []string{"https://app.example.com"} }()
+		w := serve(http.MethodGet, "https://other.example.com", false)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://other.example.com" {
+			t.Errorf("Access-Control-Allow-Origin = %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("Access-Control-Allow-Credentials = %q for a wildcard origin, want none", got)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationMiddleware announces that a route is deprecated (Deprecation,
+// RFC 9745) and when it will be removed (Sunset, RFC 8594); either time may be
+// zero. successor maps the request path to the route replacing it, linked as
+// the successor-version.
+func DeprecationMiddleware(deprecatedAt, sunsetAt time.Time, successor func(path string) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !deprecatedAt.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		}
+		if !sunsetAt.IsZero() {
+			c.Header("Sunset", sunsetAt.UTC().Format(http.TimeFormat))
+		}
+		if successor != nil {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor(c.Request.URL.Path)))
+		}
+		c.Next()
+	}
+}
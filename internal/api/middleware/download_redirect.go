@@ -0,0 +1,17 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// DownloadRedirectKey is the context key marking routes whose downloads
+// redirect to storage instead of streaming through the API
+const DownloadRedirectKey = "DownloadRedirect"
+
+// DownloadRedirectMiddleware makes downloads on a route answer with a redirect
+// to a presigned storage URL, or to the public URL when one is configured.
+// Handlers read it via DownloadRedirectKey.
+func DownloadRedirectMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(DownloadRedirectKey, true)
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// SSE-C request headers, mirroring S3's x-amz-server-side-encryption-customer-*
+const (
+	HeaderSSECustomerKey    = "X-SSE-Customer-Key"
+	HeaderSSECustomerKeyMD5 = "X-SSE-Customer-Key-MD5"
+)
+
+// CustomerKeyMiddleware reads a base64 AES-256 key from X-SSE-Customer-Key and
+// carries it in the request context, so objects are written and read with SSE-C.
+// X-SSE-Customer-Key-MD5, when present, must match the key's base64 MD5.
+func CustomerKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoded := c.GetHeader(HeaderSSECustomerKey)
+		if encoded == "" {
+			c.Next()
+			return
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != storage.CustomerKeyLen {
+			utils.SendError(c, http.StatusBadRequest, "X-SSE-Customer-Key must be a base64 encoded 256-bit key")
+			c.Abort()
+			return
+		}
+		if digest := c.GetHeader(HeaderSSECustomerKeyMD5); digest != "" {
+			sum := md5.Sum(key)
+			if subtle.ConstantTimeCompare([]byte(digest), []byte(base64.StdEncoding.EncodeToString(sum[:]))) != 1 {
+				utils.SendError(c, http.StatusBadRequest, "X-SSE-Customer-Key-MD5 does not match the key")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(storage.WithCustomerKey(c.Request.Context(), key))
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/errreport"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// ErrorReportingMiddleware reports requests that end in a server error: any
+// 500, and other 5xx responses carrying an error (e.g. unreachable storage)
+func ErrorReportingMiddleware(reporter errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 500 || status == http.StatusNotImplemented {
+			return
+		}
+		last := c.Errors.Last()
+		if last == nil && status != http.StatusInternalServerError {
+			return
+		}
+
+		report := requestReport(c)
+		report.Tags["status"] = strconv.Itoa(status)
+		if last != nil {
+			report.Err = last.Err
+		} else {
+			report.Message = fmt.Sprintf("%s %s responded %d", c.Request.Method, c.FullPath(), status)
+		}
+		reporter.Capture(report)
+	}
+}
+
+// RecoveryHandler reports a recovered panic with its stack and responds 500;
+// use it with gin.CustomRecovery
+func RecoveryHandler(reporter errreport.Reporter) gin.RecoveryFunc {
+	return func(c *gin.Context, recovered interface{}) {
+		report := requestReport(c)
+		report.Err = fmt.Errorf("panic: %v", recovered)
+		report.Level = errreport.LevelFatal
+		report.Stack = errreport.Callers(1)
+		reporter.Capture(report)
+
+		utils.SendError(c, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// requestReport carries the correlation and request IDs, route and caller of the request
+func requestReport(c *gin.Context) errreport.Report {
+	correlationIDStr := utils.CorrelationIDFrom(c)
+
+	tags := map[string]string{
+		"correlation_id": correlationIDStr,
+		"request_id":     c.GetString(utils.RequestIDKey),
+		"route":          c.FullPath(),
+		"method":         c.Request.Method,
+		"subject":        auth.SubjectFrom(c),
+	}
+	return errreport.Report{
+		Tags:   tags,
+		Method: c.Request.Method,
+		URL:    redactQuery(c.Request.URL),
+	}
+}
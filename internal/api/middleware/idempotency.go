@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key of a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyPrefix    = "idempotency/"
+	maxIdempotencyKeyLen = 255
+	// maxReplayBodySize bounds the responses kept for replay; larger ones aren't cached
+	maxReplayBodySize = 1 << 20
+	// maxMemoryBodySize is how much of a request body is hashed in memory;
+	// the rest is spooled to a temporary file
+	maxMemoryBodySize = 1 << 20
+)
+
+// headers that describe the original exchange rather than the response
+var unreplayedHeaders = map[string]bool{
+	"Content-Length":          true,
+	"Date":                    true,
+	"Set-Cookie":              true,
+	utils.CorrelationIDHeader: true,
+}
+
+// idempotencyRecord is a stored response; Status is 0 while the first request runs
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+}
+
+// IdempotencyMiddleware replays the stored response when a POST, PUT, PATCH or
+// DELETE is retried with the same Idempotency-Key within ttl, so retries don't
+// repeat the operation. Keys are scoped to the authenticated caller; anonymous
+// requests are passed through. Reusing a key for a different request, body
+// included, is rejected with 422, and retrying while the first request is
+// still running with 409. Server errors and auth failures aren't stored, so
+// those requests can be retried for real. Bodies are hashed up to
+// maxFileSize plus multipart overhead, read per request; larger ones are
+// rejected with 413. A ttl of 0 disables the middleware.
+func IdempotencyMiddleware(s store.Store, ttl time.Duration, maxFileSize func() int64, logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		identity := auth.IdentityFrom(c)
+		if ttl <= 0 || key == "" || !isMutating(c.Request.Method) || identity == nil || identity.Subject == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			utils.SendError(c, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLen))
+			c.Abort()
+			return
+		}
+		reqLogger := LoggerFrom(c, logger)
+
+		limit := int64(-1)
+		if max := maxFileSize(); max > 0 {
+			limit = max + multipartOverhead
+		}
+		bodySum, cleanup, err := hashBody(c, limit)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.SendError(c, http.StatusRequestEntityTooLarge, "File exceeds the maximum allowed size")
+			} else {
+				reqLogger.Warn().Err(err).Msg("Failed to read request body for idempotency check")
+				utils.SendError(c, http.StatusBadRequest, "Failed to read request body")
+			}
+			c.Abort()
+			return
+		}
+		defer cleanup()
+
+		recordKey := idempotencyPrefix + digest(identity.Subject, key)
+		fingerprint := digest(c.Request.Method, c.Request.URL.RequestURI(), c.ContentType(), bodySum)
+		now := time.Now().UTC()
+
+		var previous *idempotencyRecord
+		_, err = store.Update(c.Request.Context(), s, recordKey, func(rec *idempotencyRecord, exists bool) (bool, error) {
+			if exists && now.Before(rec.ExpiresAt) {
+				stored := *rec
+				previous = &stored
+				return true, nil
+			}
+			*rec = idempotencyRecord{Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)}
+			return true, nil
+		})
+		if err != nil {
+			reqLogger.Error().Err(err).Msg("Failed to reserve idempotency key")
+			utils.SendError(c, http.StatusServiceUnavailable, "Idempotency keys are temporarily unavailable")
+			c.Abort()
+			return
+		}
+
+		if previous != nil {
+			switch {
+			case previous.Fingerprint != fingerprint:
+				apierror.Write(c, http.StatusUnprocessableEntity, apierror.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+			case previous.Status == 0:
+				apierror.Write(c, http.StatusConflict, apierror.CodeRequestInProgress, "A request with this Idempotency-Key is still in progress")
+			default:
+				for name, values := range previous.Header {
+					c.Writer.Header()[name] = values
+				}
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Status(previous.Status)
+				_, _ = c.Writer.Write(previous.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// The client may have gone away; that is exactly when the record matters
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := writer.Status()
+		if !isReplayable(status) || writer.overflow {
+			if err := s.Delete(ctx, recordKey); err != nil {
+				reqLogger.Error().Err(err).Msg("Failed to release idempotency key")
+			}
+			return
+		}
+		header := http.Header{}
+		for name, values := range writer.Header() {
+			if !unreplayedHeaders[name] {
+				header[name] = values
+			}
+		}
+		err = s.Put(ctx, recordKey, &idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Header:      header,
+			Body:        writer.body.Bytes(),
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			reqLogger.Error().Err(err).Msg("Failed to store idempotent response")
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isReplayable reports whether a response is the settled outcome of the request
+// rather than a transient failure worth retrying
+func isReplayable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// hashBody reads the request body, at most limit bytes when limit is not
+// negative, and puts it back for the handler. It returns the body's SHA-256 and
+// a cleanup that removes the temporary file large bodies are spooled to.
+func hashBody(c *gin.Context, limit int64) (string, func(), error) {
+	h := sha256.New()
+	nothing := func() {}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), nothing, nil
+	}
+	body := io.Reader(c.Request.Body)
+	if limit >= 0 {
+		if c.Request.ContentLength > limit {
+			return "", nothing, &http.MaxBytesError{Limit: limit}
+		}
+		body = http.MaxBytesReader(nil, c.Request.Body, limit)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(body, maxMemoryBodySize+1))
+	if err != nil {
+		return "", nothing, err
+	}
+	if len(buf) <= maxMemoryBodySize {
+		h.Write(buf)
+		c.Request.Body = readCloser{bytes.NewReader(buf), c.Request.Body}
+		return hex.EncodeToString(h.Sum(nil)), nothing, nil
+	}
+
+	spool, err := os.CreateTemp("", "idempotent-body-*")
+	if err != nil {
+		return "", nothing, err
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(io.MultiWriter(spool, h), io.MultiReader(bytes.NewReader(buf), body)); err != nil {
+		cleanup()
+		return "", nothing, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return "", nothing, err
+	}
+	c.Request.Body = readCloser{spool, c.Request.Body}
+	return hex.EncodeToString(h.Sum(nil)), cleanup, nil
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter keeps a copy of the response body for replay
+type captureWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(data) > maxReplayBodySize {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
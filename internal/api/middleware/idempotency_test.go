@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// A retry with the same key and body is replayed; the same key with a
+// different body of the same length is rejected, and anonymous callers are
+// never replayed
+func TestIdempotencyMiddleware(t *testing.T) {
+	logger := zerolog.Nop()
+	calls := 0
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			c.Set(auth.IdentityKey, &auth.Identity{Subject: subject})
+		}
+	})
+	router.Use(IdempotencyMiddleware(store.NewMemoryStore(), time.Hour, func() int64 { return 1 << 10 }, &logger))
+	router.POST("/", func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, "%s", body)
+	})
+
+	serve := func(subject, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		if subject != "" {
+			req.Header.Set("X-Subject", subject)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("alice", "first"); w.Code != http.StatusCreated || w.Body.String() != "first" {
+		t.Fatalf("first request: %d %q", w.Code, w.Body)
+	}
+	w := serve("alice", "first")
+	if w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "true" || calls != 1 {
+		t.Errorf("retry: status %d, replayed %q, calls %d", w.Code, w.Header().Get(IdempotentReplayedHeader), calls)
+	}
+	if w := serve("alice", "other"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("same key, different body: got %d, want 422", w.Code)
+	}
+	if w := serve("alice", strings.Repeat("x", 2<<20)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversize body: got %d, want 413", w.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve("", "first"); w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("anonymous request %d was replayed", i+1)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+}
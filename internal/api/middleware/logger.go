@@ -1,27 +1,139 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
 	"github.com/rs/zerolog"
 )
 
-func LoggerMiddleware(logger *zerolog.Logger) gin.HandlerFunc {
+// LoggerOptions controls what the request logger captures
+type LoggerOptions struct {
+	// LogRequestBodies logs the body of JSON requests up to MaxBodyBytes.
+	// Uploads and other non-JSON bodies are never read by the logger.
+	LogRequestBodies bool
+	MaxBodyBytes     int64
+	// SampleRate logs one in SampleRate successful requests; errors are always
+	// logged. 0 or 1 logs every request.
+	SampleRate uint32
+}
+
+// redactedQueryParams carry credentials that must not reach the logs
+var redactedQueryParams = []string{accessTokenQuery, "token", "password", "X-Amz-Signature", "X-Amz-Credential", "X-Amz-Security-Token"}
+
+// LoggerMiddleware logs one line per request once it completes, at warn level
+// for 4xx and error level for 5xx responses. Bodies are streamed straight
+// through: responses are never copied, and request bodies are only captured
+// for small JSON requests when opts.LogRequestBodies is set.
+func LoggerMiddleware(logger *zerolog.Logger, opts LoggerOptions) gin.HandlerFunc {
+	sampled := *logger
+	if opts.SampleRate > 1 {
+		sampled = logger.Sample(&zerolog.BasicSampler{N: opts.SampleRate})
+	}
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
+		path := redactQuery(c.Request.URL)
 		method := c.Request.Method
 
+		var body []byte
+		if opts.LogRequestBodies && opts.MaxBodyBytes > 0 && isJSON(c) {
+			body = captureBody(c, opts.MaxBodyBytes)
+		}
+
 		c.Next()
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
-		logger.Info().
-			Str("method", method).
-			Str("path", path).
-			Int("status", status).
-			Dur("latency", latency).
-			Msg("HTTP request")
+
+		msg := "Request"
+		if len(c.Errors) > 0 {
+			msg = c.Errors.String()
+		}
+
+		var event *zerolog.Event
+		switch {
+		case status >= 500:
+			event = logger.Error()
+		case status >= 400:
+			event = logger.Warn()
+		default:
+			event = sampled.Info()
+		}
+		if event == nil {
+			return
+		}
+
+		// httpRequest object (nested), as Cloud Logging expects it
+		httpRequest := map[string]interface{}{
+			"requestMethod": method,
+			"requestUrl":    path,
+			"status":        status,
+			"latency":       latency.String(),
+			"requestSize":   c.Request.ContentLength,
+			"responseSize":  c.Writer.Size(),
+			"userAgent":     c.Request.UserAgent(),
+			"remoteIp":      c.ClientIP(),
+		}
+
+		correlationIDStr := utils.CorrelationIDFrom(c)
+
+		event = event.
+			Str("correlation_id", correlationIDStr).
+			Str("request_id", c.GetString(utils.RequestIDKey)).
+			Interface("httpRequest", httpRequest)
+		if body != nil {
+			event = event.RawJSON("request_body", body)
+		}
+		event.Msg(msg)
 	}
-}
\ No newline at end of file
+}
+
+// captureBody reads a JSON request body of at most limit bytes and puts it back
+// for the handler. Larger or unknown-length bodies aren't read; a body that
+// turns out longer than advertised is restored unchanged and not logged.
+func captureBody(c *gin.Context, limit int64) []byte {
+	if c.Request.Body == nil || c.Request.ContentLength <= 0 || c.Request.ContentLength > limit {
+		return nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(buf), c.Request.Body), c.Request.Body}
+	if err != nil || int64(len(buf)) > limit || !json.Valid(buf) {
+		return nil
+	}
+	return compactJSON(buf)
+}
+
+func compactJSON(body []byte) []byte {
+	var out bytes.Buffer
+	if err := json.Compact(&out, body); err != nil {
+		return nil
+	}
+	return out.Bytes()
+}
+
+// redactQuery returns the request path and query with credentials masked
+func redactQuery(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	query := u.Query()
+	for name := range query {
+		for _, redacted := range redactedQueryParams {
+			if strings.EqualFold(name, redacted) {
+				query.Set(name, "REDACTED")
+			}
+		}
+	}
+	return u.Path + "?" + query.Encode()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/rs/zerolog"
+)
+
+// RateLimitMiddleware allows each client IP limit requests per window and
+// rejects the rest with 429 until the window ends. It runs before
+// AuthMiddleware, so requests with bad credentials are counted too and
+// cannot flood the verifier. When the counter fails the request is let
+// through.
+func RateLimitMiddleware(counter state.Counter, limit int, window time.Duration, logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, reset, err := counter.Incr(c.Request.Context(), "ratelimit/ip:"+c.ClientIP(), window)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Rate limit counter unavailable, request not limited")
+			c.Next()
+			return
+		}
+
+		remaining := int64(limit) - count
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(remaining, 0), 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if remaining < 0 {
+			retryAfter := int(time.Until(reset).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Write(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Rate limit exceeded, try again later")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/rs/zerolog"
+)
+
+// Each client IP gets its own allowance; past it requests fail with 429
+// until the window ends
+func TestRateLimitMiddleware(t *testing.T) {
+	logger := zerolog.Nop()
+	router := gin.New()
+	router.Use(RateLimitMiddleware(state.NewMemory(), 2, time.Hour, &logger))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	serve := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for i, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+		if w := serve("192.0.2.1"); w.Code != want {
+			t.Errorf("request %d: got %d, want %d", i+1, w.Code, want)
+		}
+	}
+	w := serve("192.0.2.1")
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("limited response headers: %v", w.Header())
+	}
+	if w := serve("192.0.2.2"); w.Code != http.StatusNoContent {
+		t.Errorf("other client: got %d, want 204", w.Code)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// RequireScope rejects anonymous callers with 401 and callers lacking any of
+// the given scopes with 403
+func RequireScope(policy *auth.RolePolicy, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := auth.IdentityFrom(c)
+		if identity == nil {
+			utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+			c.Abort()
+			return
+		}
+		if !policy.Allows(identity, scopes...) {
+			utils.SendError(c, http.StatusForbidden, "Insufficient scope")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
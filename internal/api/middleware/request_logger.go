@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// LoggerKey holds the request's logger in the Gin context
+const LoggerKey = "Logger"
+
+// RequestLoggerMiddleware gives every request a child of logger tagged with its
+// correlation ID, request ID and route; AuthMiddleware adds the user once the
+// caller is known. Handlers and middleware read it with LoggerFrom.
+func RequestLoggerMiddleware(logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		child := logger.With().
+			Str("correlation_id", utils.CorrelationIDFrom(c)).
+			Str("request_id", c.GetString(utils.RequestIDKey)).
+			Str("route", c.FullPath()).
+			Logger()
+		c.Set(LoggerKey, &child)
+		c.Next()
+	}
+}
+
+// LoggerFrom returns the request's logger. Where RequestLoggerMiddleware didn't
+// run it returns fallback tagged with the correlation ID.
+func LoggerFrom(c *gin.Context, fallback *zerolog.Logger) *zerolog.Logger {
+	if logger, ok := c.Get(LoggerKey); ok {
+		if l, ok := logger.(*zerolog.Logger); ok {
+			return l
+		}
+	}
+	child := fallback.With().Str("correlation_id", utils.CorrelationIDFrom(c)).Logger()
+	return &child
+}
+
+// tagUser adds the authenticated subject to the request's logger
+func tagUser(c *gin.Context, subject string) {
+	logger, ok := c.Get(LoggerKey)
+	if !ok {
+		return
+	}
+	if l, ok := logger.(*zerolog.Logger); ok {
+		child := l.With().Str("user", subject).Logger()
+		c.Set(LoggerKey, &child)
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// Lines logged through the request's logger carry the correlation ID, route
+// and authenticated user without the handler adding them
+func TestRequestLoggerTags(t *testing.T) {
+	verifier, err := auth.NewServiceVerifier("", []string{"ci:secret"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	logger := zerolog.New(&out)
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), RequestLoggerMiddleware(&logger), AuthMiddleware(verifier, nil, &logger))
+	router.GET("/files/:filename", func(c *gin.Context) {
+		LoggerFrom(c, &logger).Info().Msg("handled")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/files/report.pdf", nil)
+	req.Header.Set(APIKeyHeader, "secret")
+	req.Header.Set(utils.CorrelationIDHeader, "client-correlation")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var line map[string]string
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("invalid log line %q: %v", out.String(), err)
+	}
+	want := map[string]string{
+		"correlation_id": "client-correlation",
+		"request_id":     w.Header().Get(utils.RequestIDHeader),
+		"route":          "/files/:filename",
+		"user":           "apikey:ci",
+	}
+	for field, value := range want {
+		if line[field] != value {
+			t.Errorf("%s = %q, want %q", field, line[field], value)
+		}
+	}
+}
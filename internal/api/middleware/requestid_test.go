@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// The auth service sees the IDs of the request it verifies a token for, and
+// error responses carry both
+func TestRequestIDPropagation(t *testing.T) {
+	var forwarded http.Header
+	authService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Clone()
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer authService.Close()
+
+	verifier, err := auth.NewServiceVerifier(authService.URL, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zerolog.Nop()
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), AuthMiddleware(verifier, nil, &logger))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set(utils.CorrelationIDHeader, "client-correlation")
+	req.Header.Set(utils.RequestIDHeader, "client-request")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(utils.RequestIDHeader)
+	if requestID == "" || requestID == "client-request" {
+		t.Fatalf("request ID = %q, want one generated by the server", requestID)
+	}
+	if got := w.Header().Get(utils.CorrelationIDHeader); got != "client-correlation" {
+		t.Errorf("correlation ID = %q, want the client's", got)
+	}
+	if forwarded.Get(utils.RequestIDHeader) != requestID || forwarded.Get(utils.CorrelationIDHeader) != "client-correlation" {
+		t.Errorf("auth service received request ID %q and correlation ID %q", forwarded.Get(utils.RequestIDHeader), forwarded.Get(utils.CorrelationIDHeader))
+	}
+
+	var problem apierror.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem %s: %v", w.Body, err)
+	}
+	if problem.RequestID != requestID || problem.CorrelationID != "client-correlation" {
+		t.Errorf("problem carries request ID %q and correlation ID %q", problem.RequestID, problem.CorrelationID)
+	}
+}
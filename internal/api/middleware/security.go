@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+)
+
+// SecurityLevel is the authentication a route group requires
+type SecurityLevel string
+
+// Security levels
+const (
+	SecurityPublic        SecurityLevel = "public"
+	SecurityAuthenticated SecurityLevel = "authenticated"
+	SecurityAdmin         SecurityLevel = "admin"
+)
+
+// RouteSecurity maps route groups to their required security level
+type RouteSecurity struct {
+	levels   map[string]SecurityLevel
+	fallback SecurityLevel
+}
+
+// ParseRouteSecurity parses "group=level" entries. Groups without an entry
+// require authentication.
+func ParseRouteSecurity(entries []string) (*RouteSecurity, error) {
+	rs := &RouteSecurity{levels: make(map[string]SecurityLevel, len(entries)), fallback: SecurityAuthenticated}
+	for _, entry := range entries {
+		group, level, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid route security entry %q, expected group=level", entry)
+		}
+		switch l := SecurityLevel(strings.ToLower(strings.TrimSpace(level))); l {
+		case SecurityPublic, SecurityAuthenticated, SecurityAdmin:
+			rs.levels[group] = l
+		default:
+			return nil, fmt.Errorf("invalid security level %q for route group %q", level, group)
+		}
+	}
+	return rs, nil
+}
+
+// Level returns the security level of a route group
+func (rs *RouteSecurity) Level(group string) SecurityLevel {
+	if level, ok := rs.levels[group]; ok {
+		return level
+	}
+	return rs.fallback
+}
+
+// SecurityMiddleware enforces a security level: anonymous callers get 401 on
+// authenticated and admin routes, non-admin callers get 403 on admin routes.
+// It relies on AuthMiddleware having resolved the caller's identity.
+func SecurityMiddleware(level SecurityLevel, policy *auth.RolePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if level == SecurityPublic {
+			c.Next()
+			return
+		}
+		identity := auth.IdentityFrom(c)
+		if identity == nil {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+			c.Abort()
+			return
+		}
+		if level == SecurityAdmin && !policy.Allows(identity, auth.ScopeAdmin) {
+			utils.SendError(c, http.StatusForbidden, "Admin access required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// TenancyMiddleware resolves the caller's tenant scope from their claims. When
+// tenancy is enabled, anonymous callers are rejected with 401 and callers without
+// a tenant with 403. With automatic home directories, authenticated callers are
+// confined to their own.
+func TenancyMiddleware(resolver *tenancy.Resolver, logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := auth.IdentityFrom(c)
+		if identity == nil && resolver.Enabled() {
+			utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+			c.Abort()
+			return
+		}
+		scope, err := resolver.Resolve(c.Request.Context(), identity)
+		if err != nil {
+			abortScopeError(c, err, logger)
+			return
+		}
+		c.Set(tenancy.ScopeKey, scope)
+		c.Next()
+	}
+}
+
+// HomeMiddleware scopes the request to the caller's home directory, whether or
+// not home directories are automatic. Anonymous callers are rejected with 401.
+func HomeMiddleware(resolver *tenancy.Resolver, logger *zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := auth.IdentityFrom(c)
+		if identity == nil {
+			utils.SendError(c, http.StatusUnauthorized, "Authentication required")
+			c.Abort()
+			return
+		}
+		scope, err := resolver.ResolveHome(c.Request.Context(), identity)
+		if err != nil {
+			abortScopeError(c, err, logger)
+			return
+		}
+		c.Set(tenancy.ScopeKey, scope)
+		c.Next()
+	}
+}
+
+// abortScopeError rejects a request whose scope couldn't be resolved
+func abortScopeError(c *gin.Context, err error, logger *zerolog.Logger) {
+	switch {
+	case errors.Is(err, tenancy.ErrNoTenant):
+		utils.SendError(c, http.StatusForbidden, "No tenant associated with credentials")
+	case errors.Is(err, tenancy.ErrInvalidSubject):
+		utils.SendError(c, http.StatusForbidden, "Credentials have no subject usable as a home directory")
+	default:
+		LoggerFrom(c, logger).Error().Err(err).Msg("Failed to resolve tenant")
+		utils.SendError(c, http.StatusForbidden, "Invalid tenant")
+	}
+	c.Abort()
+}
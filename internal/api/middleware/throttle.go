@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/throttle"
+)
+
+// busyRetryAfter is the Retry-After sent when no transfer slot is free
+const busyRetryAfter = 5
+
+// TransferLimitMiddleware holds a transfer slot for the duration of the request
+// and meters its request and response bodies. Requests that can't get a slot
+// within the queue timeout are rejected with 503.
+func TransferLimitMiddleware(t *throttle.Throttle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		release, err := t.Acquire(ctx)
+		if err != nil {
+			c.Header("Retry-After", strconv.Itoa(busyRetryAfter))
+			apierror.Write(c, http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Too many concurrent transfers, try again later")
+			c.Abort()
+			return
+		}
+		defer release()
+
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = readCloser{t.Reader(ctx, c.Request.Body), c.Request.Body}
+		}
+		c.Writer = &throttledWriter{ResponseWriter: c.Writer, w: t.Writer(ctx, c.Writer)}
+		c.Next()
+	}
+}
+
+type throttledWriter struct {
+	gin.ResponseWriter
+	w io.Writer
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *throttledWriter) WriteString(s string) (int, error) {
+	return w.w.Write([]byte(s))
+}
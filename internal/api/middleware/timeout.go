@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+)
+
+const (
+	// timeoutBaseKey holds the request context as it was before the first
+	// deadline, so a later one can replace the deadline instead of only
+	// shortening it
+	timeoutBaseKey = "TimeoutBase"
+	// timeoutCurrentKey holds the context of the deadline in effect
+	timeoutCurrentKey = "TimeoutCurrent"
+)
+
+// TimeoutMiddleware gives a request d to complete; see Deadline
+func TimeoutMiddleware(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		finish := Deadline(c, d)
+		defer finish()
+		c.Next()
+	}
+}
+
+// Deadline gives the request d to complete from now. Storage calls made with
+// the request context are cancelled when it passes, and the request fails
+// with a 504 DEADLINE_EXCEEDED problem unless the handler already responded;
+// handlers that ignore the context are not interrupted. A later call replaces
+// the deadline, e.g. with a longer one for transfers, and d <= 0 removes it.
+// WebSocket connections have no deadline.
+//
+// Call finish once the rest of the chain has run.
+func Deadline(c *gin.Context, d time.Duration) (finish func()) {
+	if c.IsWebsocket() {
+		return func() {}
+	}
+	ctx := c.Request.Context()
+	if base, ok := c.Get(timeoutBaseKey); ok {
+		// Drop the earlier deadline, keeping the values added since
+		ctx = rebased{Context: base.(context.Context), values: ctx}
+	} else {
+		c.Set(timeoutBaseKey, ctx)
+	}
+	// A fresh cancelable context also keeps context.Cause from reporting a
+	// dropped deadline
+	var cancel context.CancelFunc
+	if d > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, d, apierror.ErrRequestTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(timeoutCurrentKey, ctx)
+
+	return func() {
+		defer cancel()
+		// A replaced deadline no longer decides the response
+		if current, _ := c.Get(timeoutCurrentKey); current != ctx {
+			return
+		}
+		if !c.Writer.Written() && errors.Is(context.Cause(ctx), apierror.ErrRequestTimeout) {
+			apierror.Write(c, http.StatusGatewayTimeout, apierror.CodeDeadlineExceeded, "The request did not complete in time")
+		}
+	}
+}
+
+// rebased has the cancellation of one context and the values of another
+type rebased struct {
+	context.Context
+	values context.Context
+}
+
+func (r rebased) Value(key any) any { return r.values.Value(key) }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+)
+
+// A handler still waiting on storage when the deadline passes fails with a
+// 504 problem, and a later deadline replaces the route's shorter one
+func TestTimeoutMiddleware(t *testing.T) {
+	// wait stands in for a storage call made with the request context
+	wait := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			apierror.Send(c, c.Request.Context().Err(), "Failed to get file")
+		case <-time.After(200 * time.Millisecond):
+			c.Status(http.StatusNoContent)
+		}
+	}
+	longer := func(c *gin.Context) {
+		finish := Deadline(c, time.Second)
+		defer finish()
+		c.Next()
+	}
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/slow", wait)
+	router.GET("/transfer", longer, wait)
+	router.GET("/silent", func(c *gin.Context) { <-c.Request.Context().Done() })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	for _, path := range []string{"/slow", "/silent"} {
+		w := serve(path)
+		var problem apierror.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: invalid problem %q: %v", path, w.Body.String(), err)
+		}
+		if w.Code != http.StatusGatewayTimeout || problem.Code != apierror.CodeDeadlineExceeded {
+			t.Errorf("%s: got %d %s, want 504 %s", path, w.Code, problem.Code, apierror.CodeDeadlineExceeded)
+		}
+	}
+	if w := serve("/transfer"); w.Code != http.StatusNoContent {
+		t.Errorf("/transfer: got %d, want 204", w.Code)
+	}
+}
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/minioadmin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/replication"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// registerAdmin mounts admin operations; endpoints of disabled subsystems
+// are left out
+func registerAdmin(v gin.IRouter, api *API) {
+	// Admin operations
+	admin := v.Group("/admin", api.secure("admin"), api.compress("admin"), api.validate, api.idempotent)
+	{
+		if api.Audit != nil {
+			auditHandler := handlers.NewAuditHandler(api.Audit, api.Logger)
+
+			// @Summary Query the audit log
+			// @Tags admin
+			// @Router /api/v1/admin/audit [get]
+			admin.GET("/audit", api.require(auth.ScopeAuditRead), auditHandler.ListEvents)
+		}
+
+		if api.EventStream != nil {
+			eventStreamHandler := handlers.NewEventStreamHandler(api.EventStream, api.Logger)
+
+			// @Summary Stream file events
+			// @Tags admin
+			// @Router /api/v1/admin/events [get]
+			admin.GET("/events", api.require(auth.ScopeAdmin), eventStreamHandler.StreamEvents)
+		}
+
+		if api.Jobs != nil {
+			jobsHandler := handlers.NewJobsHandler(api.Jobs, api.Logger)
+
+			// @Summary List background jobs
+			// @Tags admin
+			// @Router /api/v1/admin/jobs [get]
+			admin.GET("/jobs", api.require(auth.ScopeAdmin), jobsHandler.ListJobs)
+
+			// @Summary Get a background job
+			// @Tags admin
+			// @Router /api/v1/admin/jobs/{id} [get]
+			admin.GET("/jobs/:id", api.require(auth.ScopeAdmin), jobsHandler.GetJob)
+
+			// @Summary Retry a failed background job
+			// @Tags admin
+			// @Router /api/v1/admin/jobs/{id}/retry [post]
+			admin.POST("/jobs/:id/retry", api.require(auth.ScopeAdmin), jobsHandler.RetryJob)
+		}
+
+		if api.Integrity != nil {
+			integrityHandler := handlers.NewIntegrityHandler(api.Integrity, api.Config.MinioBucketName, api.Logger)
+
+			// @Summary Verify stored objects
+			// @Tags admin
+			// @Router /api/v1/admin/verify [post]
+			admin.POST("/verify", api.require(auth.ScopeAdmin), integrityHandler.Verify)
+		}
+
+		if api.Stats != nil {
+			statsHandler := handlers.NewStatsHandler(api.Stats, api.Logger)
+
+			// @Summary Get storage statistics
+			// @Tags admin
+			// @Router /api/v1/admin/stats [get]
+			admin.GET("/stats", api.require(auth.ScopeAdmin), statsHandler.GetStats)
+
+			// @Summary Start a storage scan
+			// @Tags admin
+			// @Router /api/v1/admin/stats/scan [post]
+			admin.POST("/stats/scan", api.require(auth.ScopeAdmin), statsHandler.Scan)
+		}
+
+		if api.Analytics != nil {
+			analyticsHandler := handlers.NewAnalyticsHandler(api.Analytics, api.minio, api.Logger)
+
+			// @Summary Get the most downloaded files
+			// @Tags admin
+			// @Router /api/v1/admin/analytics/top-downloads [get]
+			admin.GET("/analytics/top-downloads", api.require(auth.ScopeAdmin), analyticsHandler.GetTopDownloads)
+		}
+
+		if api.Costs != nil {
+			costsHandler := handlers.NewCostsHandler(api.Costs, api.Logger)
+
+			// @Summary Get a cost report
+			// @Tags admin
+			// @Router /api/v1/admin/cost-report [get]
+			admin.GET("/cost-report", api.require(auth.ScopeAdmin), costsHandler.GetCostReport)
+		}
+
+		if api.MetaDB != nil {
+			recordsHandler := handlers.NewRecordsHandler(api.MetaDB, api.Logger, api.Config)
+
+			// @Summary Get usage per owner
+			// @Tags admin
+			// @Router /api/v1/admin/usage/owners [get]
+			admin.GET("/usage/owners", api.require(auth.ScopeAdmin), recordsHandler.GetOwnerUsage)
+		}
+
+		// @Summary Runtime and storage failover metrics
+		// @Tags admin
+		// @Router /api/v1/admin/metrics [get]
+		admin.GET("/metrics", api.require(auth.ScopeAdmin), gin.WrapH(metrics.Handler()))
+
+		configHandler := handlers.NewConfigHandler(api.Config, api.Logger)
+
+		// @Summary Get reloadable settings
+		// @Tags admin
+		// @Router /api/v1/admin/config [get]
+		admin.GET("/config", api.require(auth.ScopeAdmin), configHandler.GetSettings)
+
+		// @Summary Reload configuration
+		// @Tags admin
+		// @Router /api/v1/admin/config/reload [post]
+		admin.POST("/config/reload", api.require(auth.ScopeAdmin), configHandler.Reload)
+
+		if mirror, ok := storage.As[*replication.Backend](api.Backend); ok {
+			replicationHandler := handlers.NewReplicationHandler(mirror, api.Config.MinioBucketName, api.Logger)
+
+			// @Summary Detect replication drift
+			// @Tags admin
+			// @Router /api/v1/admin/replication/drift [get]
+			admin.GET("/replication/drift", api.require(auth.ScopeAdmin), replicationHandler.GetDrift)
+
+			// @Summary Reconcile the replication mirror
+			// @Tags admin
+			// @Router /api/v1/admin/replication/reconcile [post]
+			admin.POST("/replication/reconcile", api.require(auth.ScopeAdmin), replicationHandler.Reconcile)
+		}
+
+		if api.Backups != nil {
+			backupHandler := handlers.NewBackupHandler(api.Backups, api.Logger)
+
+			// @Summary List backup snapshots
+			// @Tags admin
+			// @Router /api/v1/admin/backups [get]
+			admin.GET("/backups", api.require(auth.ScopeAdmin), backupHandler.ListSnapshots)
+
+			// @Summary Take a backup snapshot
+			// @Tags admin
+			// @Router /api/v1/admin/backups [post]
+			admin.POST("/backups", api.require(auth.ScopeAdmin), backupHandler.StartSnapshot)
+
+			// @Summary Get a backup snapshot
+			// @Tags admin
+			// @Router /api/v1/admin/backups/{id} [get]
+			admin.GET("/backups/:id", api.require(auth.ScopeAdmin), backupHandler.GetSnapshot)
+
+			// @Summary Restore a backup snapshot
+			// @Tags admin
+			// @Router /api/v1/admin/backups/{id}/restore [post]
+			admin.POST("/backups/:id/restore", api.require(auth.ScopeAdmin), backupHandler.StartRestore)
+
+			// @Summary Get a backup restore
+			// @Tags admin
+			// @Router /api/v1/admin/restores/{id} [get]
+			admin.GET("/restores/:id", api.require(auth.ScopeAdmin), backupHandler.GetRestore)
+		}
+
+		// Cluster monitoring for self-hosted MinIO
+		if primary, ok := storage.Route(api.Backend, api.Config.MinioBucketName).(*storage.S3Backend); ok && primary.Name() == storage.ProviderMinio {
+			minioAdmin := minioadmin.New(func() (*minio.Client, error) {
+				// Resolved per request, as the client is replaced when credentials rotate
+				primary, ok := storage.Route(api.Backend, api.Config.MinioBucketName).(*storage.S3Backend)
+				if !ok {
+					return nil, storage.ErrNotSupported
+				}
+				return primary.Client(), nil
+			})
+			minioAdminHandler := handlers.NewMinioAdminHandler(minioAdmin, api.Logger)
+
+			// @Summary Get MinIO server info
+			// @Tags admin
+			// @Router /api/v1/admin/minio/info [get]
+			admin.GET("/minio/info", api.require(auth.ScopeAdmin), minioAdminHandler.ServerInfo)
+
+			// @Summary Get MinIO data usage
+			// @Tags admin
+			// @Router /api/v1/admin/minio/usage [get]
+			admin.GET("/minio/usage", api.require(auth.ScopeAdmin), minioAdminHandler.DataUsage)
+
+			// @Summary Get MinIO drive health
+			// @Tags admin
+			// @Router /api/v1/admin/minio/drives [get]
+			admin.GET("/minio/drives", api.require(auth.ScopeAdmin), minioAdminHandler.DriveHealth)
+		}
+	}
+}
@@ -0,0 +1,1656 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/analytics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/backup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/costs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/importer"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/integrity"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// API keys of the in-memory router, by what they may do
+const (
+	readerKey = "reader-key"
+	writerKey = "writer-key"
+	adminKey  = "admin-key"
+)
+
+// testMaxFileSize is small so oversize paths are cheap to exercise
+const testMaxFileSize = 1 << 10
+
+// newMemoryRouter wires the real routes on an in-memory storage backend, so
+// requests run end to end without an object store
+func newMemoryRouter(t *testing.T) (*gin.Engine, *storage.MemoryBackend) {
+	t.Helper()
+	backend := storage.NewMemoryBackend()
+	return newBackendRouter(t, backend), backend
+}
+
+// newBackendRouter wires the real routes on backend, creating the buckets
+// they use
+func newBackendRouter(t *testing.T, backend storage.Backend) *gin.Engine {
+	t.Helper()
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+// mountRoutes mounts the modules of registry on backend, configured by cfg.
+// configure may replace dependencies before they are mounted.
+func mountRoutes(t *testing.T, backend storage.Backend, cfg *config.Config, registry *Registry, configure ...func(*Dependencies)) (*gin.Engine, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	verifier, err := auth.NewServiceVerifier("", []string{
+		"reader:" + readerKey + ":files:read",
+		"writer:" + writerKey + ":files:read files:write",
+		"admin:" + adminKey,
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range []string{cfg.MinioBucketName, cfg.DerivedBucket()} {
+		if err := backend.MakeBucket(context.Background(), bucket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := zerolog.Nop()
+
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), middleware.AuthMiddleware(verifier, nil, &logger))
+	deps := testDependencies(t, backend, &logger, cfg)
+	for _, fn := range configure {
+		fn(&deps)
+	}
+	return router, registry.Mount(router, deps)
+}
+
+// testConfig enables the checks the in-memory router exercises
+func testConfig() *config.Config {
+	return &config.Config{
+		MinioBucketName:         "test",
+		MaxFileSize:             testMaxFileSize,
+		TenantClaim:             "tenant_id",
+		RBACEnabled:             true,
+		RBACRoleScopes:          []string{"admin=*"},
+		QuotaEnabled:            true,
+		UploadCollisionStrategy: "overwrite",
+		UploadMaxPartBuffer:     64 << 20,
+		SharePasswordEncryption: true,
+		RouteSecurity: []string{
+			"files=authenticated", "uploads=authenticated", "shares=authenticated", "usage=authenticated",
+			"buckets=admin", "admin=admin", "share_links=public",
+		},
+	}
+}
+
+// serve sends a request with the API key, if any
+func serve(router *gin.Engine, method, path, key string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	if key != "" {
+		req.Header.Set(middleware.APIKeyHeader, key)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// uploadFile posts content as a multipart upload
+func uploadFile(t *testing.T, router *gin.Engine, key, name string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+	return serve(router, http.MethodPost, "/api/v1/files", key, &body, form.FormDataContentType())
+}
+
+// decodeData decodes the data of a JSON response envelope
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", w.Body.String(), err)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("invalid response data %s: %v", envelope.Data, err)
+	}
+}
+
+func TestFileLifecycle(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	content := []byte("hello, world")
+
+	w := uploadFile(t, router, writerKey, "notes.txt", content)
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	var uploaded map[string]interface{}
+	decodeData(t, w, &uploaded)
+	if uploaded["key"] != "notes.txt" || uploaded["contentType"] != "text/plain" {
+		t.Fatalf("unexpected upload response %v", uploaded)
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files", readerKey, nil, "")
+	var listed []map[string]interface{}
+	decodeData(t, w, &listed)
+	if w.Code != http.StatusOK || len(listed) != 1 || listed[0]["name"] != "notes.txt" {
+		t.Fatalf("list status = %d, files %v", w.Code, listed)
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files/notes.txt", readerKey, nil, "")
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Fatalf("download status = %d, body %q", w.Code, w.Body)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || !strings.Contains(w.Header().Get("Content-Disposition"), "notes.txt") {
+		t.Fatalf("missing download headers: %v", w.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/notes.txt", nil)
+	req.Header.Set(middleware.APIKeyHeader, readerKey)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional download status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	w = serve(router, http.MethodHead, "/api/v1/files/notes.txt", readerKey, nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Length") != "12" {
+		t.Errorf("head status = %d, headers %v", w.Code, w.Header())
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files/notes.txt/checksum", readerKey, nil, "")
+	var sums struct {
+		Checksums map[string]string `json:"checksums"`
+	}
+	decodeData(t, w, &sums)
+	if w.Code != http.StatusOK || sums.Checksums["sha256"] == "" {
+		t.Errorf("checksum status = %d, checksums %v", w.Code, sums.Checksums)
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/usage", readerKey, nil, "")
+	if w.Code != http.StatusOK {
+		t.Errorf("usage status = %d: %s", w.Code, w.Body)
+	}
+
+	w = serve(router, http.MethodDelete, "/api/v1/files/notes.txt", writerKey, nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+	w = serve(router, http.MethodGet, "/api/v1/files/notes.txt/exists", readerKey, nil, "")
+	var exists struct {
+		Exists bool `json:"exists"`
+	}
+	decodeData(t, w, &exists)
+	if w.Code != http.StatusOK || exists.Exists {
+		t.Errorf("exists after delete: status = %d, exists = %v", w.Code, exists.Exists)
+	}
+}
+
+func TestUploadCollision(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	if w := uploadFile(t, router, writerKey, "a.txt", []byte("one")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "a.txt")
+	part.Write([]byte("two"))
+	form.Close()
+	w := serve(router, http.MethodPost, "/api/v1/files?collision=reject", writerKey, &body, form.FormDataContentType())
+	if w.Code != http.StatusConflict {
+		t.Errorf("rejected collision status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	w = uploadFile(t, router, writerKey, "a.txt", []byte("three"))
+	var uploaded map[string]interface{}
+	decodeData(t, w, &uploaded)
+	if w.Code != http.StatusOK || uploaded["key"] != "a.txt" {
+		t.Errorf("overwrite status = %d, response %v", w.Code, uploaded)
+	}
+}
+
+func TestUploadOversize(t *testing.T) {
+	router, backend := newMemoryRouter(t)
+
+	w := uploadFile(t, router, writerKey, "big.bin", bytes.Repeat([]byte("x"), testMaxFileSize+1))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	page, err := backend.List(context.Background(), "test", storage.ListOptions{})
+	if err != nil || len(page.Objects) != 0 {
+		t.Errorf("oversize upload was stored: %v %v", page.Objects, err)
+	}
+}
+
+// Upload tuning overrides are checked before the file is stored
+func TestUploadTuningOverrides(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	upload := func(query string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "notes.txt")
+		part.Write([]byte("hello"))
+		form.Close()
+		return serve(router, http.MethodPost, "/api/v1/files?"+query, writerKey, &body, form.FormDataContentType())
+	}
+
+	for query, want := range map[string]int{
+		"part_size=5242880&concurrency=8&content_md5=true": http.StatusOK,
+		"multipart_threshold=1048576":                      http.StatusOK,
+		"part_size=1024":                                   http.StatusBadRequest,
+		"concurrency=0":                                    http.StatusBadRequest,
+		"part_size=16777216&concurrency=8":                 http.StatusBadRequest, // buffers 128 MiB
+	} {
+		if w := upload(query); w.Code != want {
+			t.Errorf("%s: status = %d, want %d: %s", query, w.Code, want, w.Body)
+		}
+	}
+}
+
+func TestMissingFiles(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/files/missing.txt"},
+		{http.MethodHead, "/api/v1/files/missing.txt"},
+		{http.MethodGet, "/api/v1/files/missing.txt/checksum"},
+		{http.MethodGet, "/api/v1/files/missing.txt/thumbnail?w=10"},
+		{http.MethodGet, "/api/v1/files/missing.txt/exif"},
+		{http.MethodGet, "/api/v1/shares/missing"},
+		{http.MethodGet, "/s/missing"},
+	}
+	for _, r := range routes {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			w := serve(router, r.method, r.path, readerKey, nil, "")
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body)
+			}
+		})
+	}
+}
+
+func TestScopeAndRoleChecks(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+
+	tests := []struct {
+		name         string
+		method, path string
+		key          string
+		want         int
+	}{
+		{"upload without a key", http.MethodPost, "/api/v1/files", "", http.StatusUnauthorized},
+		{"upload with an unknown key", http.MethodPost, "/api/v1/files", "wrong-key", http.StatusUnauthorized},
+		{"upload by a reader", http.MethodPost, "/api/v1/files", readerKey, http.StatusForbidden},
+		{"delete by a reader", http.MethodDelete, "/api/v1/files/a.txt", readerKey, http.StatusForbidden},
+		{"share by a reader", http.MethodPost, "/api/v1/shares", readerKey, http.StatusForbidden},
+		{"buckets by a writer", http.MethodGet, "/api/v1/buckets", writerKey, http.StatusForbidden},
+		{"buckets by an admin", http.MethodGet, "/api/v1/buckets", adminKey, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.path, tt.key, nil, "")
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestShareLink(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	if w := uploadFile(t, router, writerKey, "report.txt", []byte("quarterly")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"missing.txt"}`), "application/json")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("share of a missing file: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"report.txt","maxDownloads":1}`), "application/json")
+	if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+		t.Fatalf("create share status = %d: %s", w.Code, w.Body)
+	}
+	var share struct {
+		Slug string `json:"slug"`
+	}
+	decodeData(t, w, &share)
+
+	w = serve(router, http.MethodGet, "/s/"+share.Slug, "", nil, "")
+	if w.Code != http.StatusOK || w.Body.String() != "quarterly" {
+		t.Fatalf("share download status = %d, body %q", w.Code, w.Body)
+	}
+	// The download limit is used up
+	if w = serve(router, http.MethodGet, "/s/"+share.Slug, "", nil, ""); w.Code == http.StatusOK {
+		t.Errorf("second download succeeded past the limit")
+	}
+}
+
+// Share passwords are only taken from the header or a POST body, and failed
+// downloads don't use up a link's limit
+func TestShareLinkDownloads(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	create := func(body string) string {
+		t.Helper()
+		w := serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(body), "application/json")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create share status = %d: %s", w.Code, w.Body)
+		}
+		var share struct {
+			Slug string `json:"slug"`
+		}
+		decodeData(t, w, &share)
+		return share.Slug
+	}
+	if w := uploadFile(t, router, writerKey, "notes.txt", []byte("minutes")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	protected := create(`{"key":"notes.txt","password":"hunter22"}`)
+	if w := serve(router, http.MethodGet, "/s/"+protected+"?password=hunter22", "", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("password in the query: status = %d, want 401", w.Code)
+	}
+	w := serve(router, http.MethodPost, "/s/"+protected, "", strings.NewReader("password=hunter22"), "application/x-www-form-urlencoded")
+	if w.Code != http.StatusOK || w.Body.String() != "minutes" {
+		t.Errorf("password form download status = %d, body %q", w.Code, w.Body)
+	}
+
+	limited := create(`{"key":"notes.txt","maxDownloads":1}`)
+	if w := serve(router, http.MethodDelete, "/api/v1/files/notes.txt", writerKey, nil, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/s/"+limited, "", nil, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("download of a deleted file: status = %d, want 404", w.Code)
+	}
+	uploadFile(t, router, writerKey, "notes.txt", []byte("minutes"))
+	if w := serve(router, http.MethodGet, "/s/"+limited, "", nil, ""); w.Code != http.StatusOK {
+		t.Errorf("download after a failed one: status = %d, want 200", w.Code)
+	}
+	if w := serve(router, http.MethodGet, "/s/"+limited, "", nil, ""); w.Code != http.StatusGone {
+		t.Errorf("download past the limit: status = %d, want 410", w.Code)
+	}
+}
+
+// Password-protected links serve a copy encrypted under the password, which
+// outlives changes to the original
+func TestPasswordShareLink(t *testing.T) {
+	keyring, err := storage.ParseKeyring([]string{"k1=" + base64.StdEncoding.EncodeToString(make([]byte, 32))}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newBackendRouter(t, storage.NewEnvelope(storage.NewMemoryBackend(), keyring))
+	if w := uploadFile(t, router, writerKey, "secret.txt", []byte("launch codes")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"secret.txt","password":"hunter22"}`), "application/json")
+	if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+		t.Fatalf("create share status = %d: %s", w.Code, w.Body)
+	}
+	var share struct {
+		Slug              string `json:"slug"`
+		PasswordProtected bool   `json:"passwordProtected"`
+	}
+	decodeData(t, w, &share)
+	if !share.PasswordProtected {
+		t.Errorf("sealed link does not report a password")
+	}
+
+	download := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/s/"+share.Slug, nil)
+		req.Header.Set("X-Share-Password", password)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := download("wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("download with a wrong password: status %d, want 401", w.Code)
+	}
+	w = download("hunter22")
+	if w.Code != http.StatusOK || w.Body.String() != "launch codes" {
+		t.Fatalf("share download status = %d, body %q", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+
+	// The link's key belongs to the file it was created for: a replacement
+	// is not served, and nothing is left once the file is deleted
+	if w := uploadFile(t, router, writerKey, "secret.txt", []byte("new codes")); w.Code != http.StatusOK {
+		t.Fatalf("replace status = %d: %s", w.Code, w.Body)
+	}
+	if w := download("hunter22"); w.Code != http.StatusNotFound {
+		t.Errorf("download of a replaced file: status %d, want 404", w.Code)
+	}
+	if w := serve(router, http.MethodDelete, "/api/v1/files/secret.txt", writerKey, nil, ""); w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+	if w := download("hunter22"); w.Code != http.StatusNotFound {
+		t.Errorf("download of a deleted file: status %d, want 404", w.Code)
+	}
+}
+
+// Risky content types are downloaded as attachments from files and share
+// links, and SVGs shown inline are sanitized
+func TestRiskyDownloads(t *testing.T) {
+	cfg := testConfig()
+	cfg.DownloadRiskyTypes = []string{"text/html", "*/*+xml"}
+	cfg.DownloadSanitizeSVG = true
+	backend := untypedBackend{Backend: storage.NewMemoryBackend(), key: "blob"}
+	router, err := mountRoutes(t, backend, cfg, NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Presigned and multipart uploads store whatever type the client asks for
+	feed := []byte(`<feed><html:script xmlns:html="http://www.w3.org/1999/xhtml">alert(1)</html:script></feed>`)
+	for key, contentType := range map[string]string{"feed": "application/foo+xml", "blob": ""} {
+		if _, err := backend.Put(context.Background(), "test", key, bytes.NewReader(feed), int64(len(feed)), storage.PutOptions{ContentType: contentType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := []byte("<html><body><script>alert(1)</script></body></html>")
+	if w := uploadFile(t, router, writerKey, "page.html", page); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><script>alert(2)</script><circle r="4"/></svg>`)
+	if w := uploadFile(t, router, writerKey, "logo.svg", svg); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	if w := uploadFile(t, router, writerKey, "notes.txt", []byte("plain")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	forced := func(name string, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", name, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/octet-stream" {
+			t.Errorf("%s: Content-Type = %q, want application/octet-stream", name, got)
+		}
+		if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment") {
+			t.Errorf("%s: Content-Disposition = %q, want attachment", name, got)
+		}
+	}
+	forced("inline HTML", serve(router, http.MethodGet, "/api/v1/files/page.html?disposition=inline", readerKey, nil, ""))
+	forced("SVG attachment", serve(router, http.MethodGet, "/api/v1/files/logo.svg", readerKey, nil, ""))
+	forced("inline +xml", serve(router, http.MethodGet, "/api/v1/files/feed?disposition=inline", readerKey, nil, ""))
+	forced("inline untyped", serve(router, http.MethodGet, "/api/v1/files/blob?disposition=inline", readerKey, nil, ""))
+	w := serve(router, http.MethodHead, "/api/v1/files/page.html?disposition=inline", readerKey, nil, "")
+	forced("HEAD of HTML", w)
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Content-Length") != strconv.Itoa(len(page)) {
+		t.Errorf("HEAD of HTML: headers %v", w.Header())
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files/logo.svg?disposition=inline", readerKey, nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("inline SVG: status = %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); strings.Contains(body, "alert") || !strings.Contains(body, "<circle") {
+		t.Errorf("inline SVG not sanitized: %s", body)
+	}
+	if w.Header().Get("Content-Security-Policy") == "" {
+		t.Errorf("inline SVG has no Content-Security-Policy")
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files/notes.txt?disposition=inline", readerKey, nil, "")
+	if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "inline") || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("plain text: Content-Disposition = %q, headers %v", got, w.Header())
+	}
+
+	w = serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"page.html"}`), "application/json")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create share status = %d: %s", w.Code, w.Body)
+	}
+	var share struct {
+		Slug string `json:"slug"`
+	}
+	decodeData(t, w, &share)
+	forced("shared HTML", serve(router, http.MethodGet, "/s/"+share.Slug+"?disposition=inline", "", nil, ""))
+}
+
+// untypedBackend reports no content type for key, as objects stored without
+// one do
+type untypedBackend struct {
+	storage.Backend
+	key string
+}
+
+func (b untypedBackend) Stat(ctx context.Context, bucket, key string) (storage.ObjectInfo, error) {
+	info, err := b.Backend.Stat(ctx, bucket, key)
+	if key == b.key {
+		info.ContentType = ""
+	}
+	return info, err
+}
+
+func (b untypedBackend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	body, info, err := b.Backend.Get(ctx, bucket, key)
+	if key == b.key {
+		info.ContentType = ""
+	}
+	return body, info, err
+}
+
+// Progress sockets only open for the caller that made the upload, from allowed
+// origins, and a socket opened after the upload gets the final state and closes
+func TestUploadProgress(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "progress.txt")
+	part.Write([]byte("tracked"))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set(middleware.APIKeyHeader, writerKey)
+	req.Header.Set(handlers.UploadIDHeader, "upload-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	dial := func(key, origin string) (*websocket.Conn, *http.Response, error) {
+		header := http.Header{}
+		header.Set(middleware.APIKeyHeader, key)
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws/uploads/upload-1", header)
+	}
+
+	if _, resp, err := dial(readerKey, ""); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("another caller subscribed: err %v, response %v", err, resp)
+	}
+	if _, resp, err := dial(writerKey, "https://evil.example"); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("disallowed origin subscribed: err %v, response %v", err, resp)
+	}
+
+	conn, _, err := dial(writerKey, "")
+	if err != nil {
+		t.Fatalf("owner subscription: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var update progress.Update
+	if err := conn.ReadJSON(&update); err != nil || !update.Done || update.BytesTransferred != update.TotalBytes {
+		t.Fatalf("final update = %+v, %v", update, err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("socket not closed after the final update: %v", err)
+	}
+}
+
+// Downloads through the API and share links are counted per file and
+// ranked in the admin report; deleting a file drops its counts
+func TestDownloadAnalytics(t *testing.T) {
+	tracker, err := analytics.NewTracker(context.Background(), store.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		deps.Analytics = tracker
+		deps.Events = sinkEvents{tracker}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"popular.txt", "quiet.txt"} {
+		if w := uploadFile(t, router, writerKey, name, []byte("content")); w.Code != http.StatusOK {
+			t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+		}
+	}
+	for _, name := range []string{"popular.txt", "popular.txt", "quiet.txt"} {
+		if w := serve(router, http.MethodGet, "/api/v1/files/"+name, readerKey, nil, ""); w.Code != http.StatusOK {
+			t.Fatalf("download status = %d", w.Code)
+		}
+	}
+	w := serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"popular.txt"}`), "application/json")
+	var share struct {
+		Slug string `json:"slug"`
+	}
+	decodeData(t, w, &share)
+	req := httptest.NewRequest(http.MethodGet, "/s/"+share.Slug, nil)
+	req.RemoteAddr = "203.0.113.9:4000"
+	w = httptest.NewRecorder()
+	if router.ServeHTTP(w, req); w.Code != http.StatusOK {
+		t.Fatalf("share download status = %d", w.Code)
+	}
+
+	var stats handlers.FileStatsResponse
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/files/popular.txt/stats", readerKey, nil, ""), &stats)
+	if stats.Downloads != 3 || stats.ShareDownloads != 1 || stats.UniqueIPs != 2 || stats.LastAccessedAt == nil {
+		t.Errorf("stats = %+v, want 3 downloads, 1 through a share link, from 2 addresses", stats)
+	}
+
+	var top []analytics.ObjectStats
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/analytics/top-downloads?limit=1", adminKey, nil, ""), &top)
+	if len(top) != 1 || top[0].Key != "popular.txt" || top[0].Downloads != 3 {
+		t.Errorf("top downloads = %+v, want popular.txt with 3", top)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/admin/analytics/top-downloads", readerKey, nil, ""); w.Code != http.StatusForbidden {
+		t.Errorf("top downloads as reader: status %d, want 403", w.Code)
+	}
+
+	serve(router, http.MethodDelete, "/api/v1/files/popular.txt", writerKey, nil, "")
+	if w := serve(router, http.MethodGet, "/api/v1/files/popular.txt/stats", readerKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("stats of a deleted file: status %d, want 404", w.Code)
+	}
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/analytics/top-downloads", adminKey, nil, ""), &top)
+	if len(top) != 1 || top[0].Key != "quiet.txt" {
+		t.Errorf("top downloads after delete = %+v, want only quiet.txt", top)
+	}
+}
+
+// Uploads and downloads through the API are metered and priced with the
+// price table of their bucket
+func TestCostReport(t *testing.T) {
+	meter := costs.NewMeter(store.NewMemoryStore(), 1)
+	pricing := costs.Pricing{
+		Minio:     costs.Prices{StorageGBMonth: 0.02},
+		R2:        costs.Prices{EgressGB: 1e6, ClassAMillion: 1e4, ClassBMillion: 1e3},
+		R2Buckets: []string{"te*"},
+	}
+	backend := storage.NewMemoryBackend()
+	var queue *jobs.Queue
+	var scanner *stats.Scanner
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue = jobs.NewQueue(deps.Store, &logger, 1, 1)
+		scanner = stats.NewScanner(backend, deps.Store, queue, stats.Options{}, &logger)
+		deps.Costs = costs.NewReporter(meter, scanner, deps.Resolver, pricing)
+		deps.Events = sinkEvents{meter}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+
+	if w := uploadFile(t, router, writerKey, "report.txt", []byte("0123456789")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	for i := 0; i < 2; i++ {
+		if w := serve(router, http.MethodGet, "/api/v1/files/report.txt", readerKey, nil, ""); w.Code != http.StatusOK {
+			t.Fatalf("download status = %d", w.Code)
+		}
+	}
+
+	var report costs.Report
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/cost-report", adminKey, nil, ""), &report)
+	want := costs.Line{Bucket: "test", Table: costs.TableR2, EgressBytes: 20, ClassAOps: 1, ClassBOps: 2,
+		EgressCost: 0.02, OperationsCost: 0.012, Total: 0.032}
+	if len(report.Lines) != 1 || report.Lines[0] != want || report.Total != want.Total {
+		t.Errorf("report = %+v, want the single line %+v", report, want)
+	}
+	if report.Month != time.Now().UTC().Format(costs.MonthLayout) || report.StorageScannedAt != nil {
+		t.Errorf("report covers %s, storage scanned at %v; want this month without storage", report.Month, report.StorageScannedAt)
+	}
+
+	// Storage is billed to the month it was scanned in only
+	if _, err := scanner.Enqueue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := scanner.Latest(context.Background()); err != nil && time.Now().Before(deadline); _, err = scanner.Latest(context.Background()) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/cost-report", adminKey, nil, ""), &report)
+	if len(report.Lines) != 1 || report.Lines[0].StorageBytes != 10 || report.StorageScannedAt == nil {
+		t.Errorf("report after a scan = %+v, want 10 stored bytes", report)
+	}
+	report = costs.Report{}
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/cost-report?month=2001-01", adminKey, nil, ""), &report)
+	if len(report.Lines) != 0 || report.Total != 0 || report.StorageScannedAt != nil {
+		t.Errorf("report of a month without usage = %+v", report)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/admin/cost-report?group=team", adminKey, nil, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown grouping: status %d, want 400", w.Code)
+	}
+}
+
+// sinkEvents delivers published events straight to a sink
+type sinkEvents struct {
+	sink events.Sink
+}
+
+func (p sinkEvents) Publish(e events.Event) {
+	e.Time = time.Now().UTC()
+	_ = p.sink.Send(context.Background(), e)
+}
+
+// recordedEvents collects the events handlers publish
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *recordedEvents) Publish(e events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// Handlers publish uploads, downloads, share links and deletes with the caller
+func TestFileEvents(t *testing.T) {
+	recorded := &recordedEvents{}
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		deps.Events = recorded
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := uploadFile(t, router, writerKey, "report.txt", []byte("quarterly")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files/report.txt", readerKey, nil, ""); w.Code != http.StatusOK {
+		t.Fatalf("download status = %d", w.Code)
+	}
+	w := serve(router, http.MethodPost, "/api/v1/shares", writerKey, strings.NewReader(`{"key":"report.txt"}`), "application/json")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create share status = %d: %s", w.Code, w.Body)
+	}
+	var share struct {
+		Slug string `json:"slug"`
+	}
+	decodeData(t, w, &share)
+	if w := serve(router, http.MethodGet, "/s/"+share.Slug, "", nil, ""); w.Code != http.StatusOK {
+		t.Fatalf("share download status = %d", w.Code)
+	}
+	if w := serve(router, http.MethodDelete, "/api/v1/files/report.txt", writerKey, nil, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", w.Code)
+	}
+	// Failed requests publish nothing
+	serve(router, http.MethodGet, "/api/v1/files/report.txt", readerKey, nil, "")
+
+	want := []events.Event{
+		{Type: events.TypeUploaded, Actor: "apikey:writer", Size: 9},
+		{Type: events.TypeDownloaded, Actor: "apikey:reader", Size: 9},
+		{Type: events.TypeShared, Actor: "apikey:writer", ShareSlug: share.Slug},
+		{Type: events.TypeDownloaded, Actor: "anonymous", ShareSlug: share.Slug, Size: 9},
+		{Type: events.TypeDeleted, Actor: "apikey:writer"},
+	}
+	if len(recorded.events) != len(want) {
+		t.Fatalf("published %d events, want %d: %+v", len(recorded.events), len(want), recorded.events)
+	}
+	for i, got := range recorded.events {
+		w := want[i]
+		if got.Type != w.Type || got.Bucket != "test" || got.Key != "report.txt" || got.Size != w.Size ||
+			got.ShareSlug != w.ShareSlug || got.Actor != w.Actor || got.CorrelationID == "" {
+			t.Errorf("event %d = %+v, want %+v in bucket test for report.txt", i, got, w)
+		}
+	}
+}
+
+func TestBucketAdministration(t *testing.T) {
+	router, backend := newMemoryRouter(t)
+
+	w := serve(router, http.MethodPost, "/api/v1/buckets", adminKey, strings.NewReader(`{"name":"reports"}`), "application/json")
+	if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+		t.Fatalf("create bucket status = %d: %s", w.Code, w.Body)
+	}
+	if exists, _ := backend.BucketExists(context.Background(), "reports"); !exists {
+		t.Fatal("bucket not created")
+	}
+	w = serve(router, http.MethodDelete, "/api/v1/buckets/reports", adminKey, nil, "")
+	if w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Fatalf("delete bucket status = %d: %s", w.Code, w.Body)
+	}
+	w = serve(router, http.MethodDelete, "/api/v1/buckets/reports", adminKey, nil, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("delete missing bucket status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestV2EnvelopeAndPagination(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if w := uploadFile(t, router, writerKey, name, []byte(name)); w.Code != http.StatusOK {
+			t.Fatalf("upload %s status = %d: %s", name, w.Code, w.Body)
+		}
+	}
+
+	type page struct {
+		Data []struct {
+			Name string `json:"name"`
+		} `json:"data"`
+		Meta struct {
+			CorrelationID string     `json:"correlation_id"`
+			Page          utils.Page `json:"page"`
+		} `json:"meta"`
+	}
+	var first page
+	w := serve(router, http.MethodGet, "/api/v2/files?limit=2", readerKey, nil, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body %s", w.Code, w.Body)
+	}
+	if len(first.Data) != 2 || first.Meta.CorrelationID == "" || !first.Meta.Page.HasMore || first.Meta.Page.NextCursor == "" || first.Meta.Page.Limit != 2 {
+		t.Fatalf("first page = %+v", first)
+	}
+	if w.Header().Get(utils.ContinuationTokenHeader) != "" {
+		t.Error("v2 page also sent the v1 continuation header")
+	}
+
+	var second page
+	w = serve(router, http.MethodGet, "/api/v2/files?limit=2&cursor="+first.Meta.Page.NextCursor, readerKey, nil, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &second); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body %s", w.Code, w.Body)
+	}
+	if len(second.Data) != 1 || second.Data[0].Name != "c.txt" || second.Meta.Page.HasMore || second.Meta.Page.NextCursor != "" {
+		t.Errorf("second page = %+v", second)
+	}
+
+	// v1 keeps its envelope and the continuation header
+	w = serve(router, http.MethodGet, "/api/v1/files?limit=2", readerKey, nil, "")
+	var listed []map[string]interface{}
+	decodeData(t, w, &listed)
+	if len(listed) != 2 || w.Header().Get(utils.ContinuationTokenHeader) != first.Meta.Page.NextCursor {
+		t.Errorf("v1 page: %d files, continuation %q", len(listed), w.Header().Get(utils.ContinuationTokenHeader))
+	}
+	if !strings.Contains(w.Body.String(), `"correlation_id"`) || strings.Contains(w.Body.String(), `"meta"`) {
+		t.Errorf("v1 envelope changed: %s", w.Body)
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Error("v1 deprecated without API_V1_DEPRECATED_AT")
+	}
+}
+
+func TestV1DeprecationHeaders(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIV1DeprecatedAt = "2026-01-01T00:00:00Z"
+	cfg.APIV1SunsetAt = "2027-01-01T00:00:00Z"
+	cfg.APIV1DeprecatedModules = []string{"files"}
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(router, http.MethodGet, "/api/v1/files?prefix=docs/", readerKey, nil, "")
+	if got := w.Header().Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Deprecation = %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2/files>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	// Only the listed modules, and never v2
+	if w = serve(router, http.MethodGet, "/api/v1/buckets", adminKey, nil, ""); w.Header().Get("Deprecation") != "" {
+		t.Error("module not slated for removal is deprecated")
+	}
+	if w = serve(router, http.MethodGet, "/api/v2/files", readerKey, nil, ""); w.Header().Get("Deprecation") != "" || w.Header().Get("Sunset") != "" {
+		t.Error("v2 route is deprecated")
+	}
+
+	cfg = testConfig()
+	cfg.APIV1DeprecatedAt = "2026-01-01T00:00:00Z"
+	cfg.APIV1DeprecatedModules = []string{"r2"}
+	if _, err := mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...)); err == nil {
+		t.Error("deprecating an unknown module succeeded")
+	}
+}
+
+// presignBackend gives the in-memory backend presigned URLs; uploads through
+// them are simulated by writing to the backend
+type presignBackend struct {
+	storage.Backend
+}
+
+func (b presignBackend) Presign(_ context.Context, _, bucket, key string, _ time.Duration, _ storage.PresignOptions) (*url.URL, http.Header, error) {
+	return &url.URL{Scheme: "https", Host: "storage.example.com", Path: "/" + bucket + "/" + key}, http.Header{}, nil
+}
+
+// A presigned PUT asking for confirmation is charged and announced once the
+// holder of its callback token confirms it, and only once
+func TestConfirmPresignedUpload(t *testing.T) {
+	backend := presignBackend{storage.NewMemoryBackend()}
+	recorded := &recordedEvents{}
+	cfg := testConfig()
+	router, err := mountRoutes(t, backend, cfg, NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue := jobs.NewQueue(deps.Store, &logger, 1, 1)
+		deps.Events = recorded
+		deps.Presigned = presigned.NewService(deps.Store, backend, deps.Quotas, recorded, queue, presigned.Options{
+			MaxFileSize: func() int64 { return cfg.MaxFileSize },
+		}, &logger)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(router, http.MethodPost, "/api/v1/files/report.txt/presign", writerKey, strings.NewReader(`{"method":"PUT","confirm":true}`), "application/json")
+	var presign handlers.PresignResponse
+	decodeData(t, w, &presign)
+	if w.Code != http.StatusOK || presign.CallbackToken == "" {
+		t.Fatalf("presign status = %d, token %q: %s", w.Code, presign.CallbackToken, w.Body)
+	}
+	for _, body := range []string{`{"method":"GET","confirm":true}`, `{"method":"PUT","callbackUrl":"https://hooks.example.com/"}`} {
+		if w := serve(router, http.MethodPost, "/api/v1/files/report.txt/presign", writerKey, strings.NewReader(body), "application/json"); w.Code != http.StatusBadRequest {
+			t.Errorf("presign %s: status %d, want 400", body, w.Code)
+		}
+	}
+
+	confirm := func(token string) *httptest.ResponseRecorder {
+		body := `{"filename":"report.txt","token":"` + token + `"}`
+		return serve(router, http.MethodPost, "/api/v1/uploads/confirm", writerKey, strings.NewReader(body), "application/json")
+	}
+	if w := confirm(presign.CallbackToken); w.Code != http.StatusConflict {
+		t.Errorf("confirm before upload: status %d, want 409", w.Code)
+	}
+	if _, err := backend.Put(context.Background(), "test", "report.txt", strings.NewReader("quarterly"), 9, storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if w := confirm("wrong"); w.Code != http.StatusForbidden {
+		t.Errorf("confirm with a wrong token: status %d, want 403", w.Code)
+	}
+
+	usedBytes := func() int64 {
+		var usage struct {
+			UsedBytes int64 `json:"usedBytes"`
+		}
+		decodeData(t, serve(router, http.MethodGet, "/api/v1/usage", writerKey, nil, ""), &usage)
+		return usage.UsedBytes
+	}
+	for i := 0; i < 2; i++ {
+		w := confirm(presign.CallbackToken)
+		var confirmed handlers.ConfirmUploadResponse
+		decodeData(t, w, &confirmed)
+		if w.Code != http.StatusOK || confirmed.Size != 9 {
+			t.Fatalf("confirm %d: status %d, size %d: %s", i+1, w.Code, confirmed.Size, w.Body)
+		}
+		if used := usedBytes(); used != 9 {
+			t.Errorf("after confirm %d the writer uses %d bytes, want 9", i+1, used)
+		}
+	}
+	if len(recorded.events) != 1 || recorded.events[0].Type != events.TypeUploaded || recorded.events[0].Key != "report.txt" || recorded.events[0].Size != 9 {
+		t.Errorf("events = %+v, want one upload of report.txt", recorded.events)
+	}
+
+	// Over an existing object, the upload is the object replacing it
+	w = serve(router, http.MethodPost, "/api/v1/files/report.txt/presign", writerKey, strings.NewReader(`{"method":"PUT","confirm":true}`), "application/json")
+	decodeData(t, w, &presign)
+	if w := confirm(presign.CallbackToken); w.Code != http.StatusConflict {
+		t.Errorf("confirm before replacing: status %d, want 409", w.Code)
+	}
+	if _, err := backend.Put(context.Background(), "test", "report.txt", strings.NewReader("annual"), 6, storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w = confirm(presign.CallbackToken)
+	var confirmed handlers.ConfirmUploadResponse
+	decodeData(t, w, &confirmed)
+	if w.Code != http.StatusOK || confirmed.Size != 6 {
+		t.Errorf("confirm after replacing: status %d, size %d: %s", w.Code, confirmed.Size, w.Body)
+	}
+}
+
+// Reconciliation completes presigned uploads nobody confirmed
+func TestReconcilePresignedUploads(t *testing.T) {
+	backend := presignBackend{storage.NewMemoryBackend()}
+	recorded := &recordedEvents{}
+	var uploads *presigned.Service
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		uploads = presigned.NewService(deps.Store, backend, deps.Quotas, recorded, jobs.NewQueue(deps.Store, &logger, 1, 1), presigned.Options{}, &logger)
+		deps.Events, deps.Presigned = recorded, uploads
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"uploaded.txt", "pending.txt"} {
+		w := serve(router, http.MethodPost, "/api/v1/files/"+name+"/presign", writerKey, strings.NewReader(`{"method":"PUT","confirm":true}`), "application/json")
+		if w.Code != http.StatusOK {
+			t.Fatalf("presign %s: status %d: %s", name, w.Code, w.Body)
+		}
+	}
+	if _, err := backend.Put(context.Background(), "test", "uploaded.txt", strings.NewReader("quarterly"), 9, storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for pass, want := range []int{1, 0} {
+		completed, err := uploads.Reconcile(context.Background())
+		if err != nil || completed != want {
+			t.Errorf("reconcile %d completed %d (%v), want %d", pass+1, completed, err, want)
+		}
+	}
+	if len(recorded.events) != 1 || recorded.events[0].Key != "uploaded.txt" {
+		t.Errorf("events = %+v, want one upload of uploaded.txt", recorded.events)
+	}
+	var usage struct {
+		UsedBytes int64 `json:"usedBytes"`
+	}
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/usage", writerKey, nil, ""), &usage)
+	if usage.UsedBytes != 9 {
+		t.Errorf("the writer uses %d bytes, want 9", usage.UsedBytes)
+	}
+
+	// A presign over a stored object stays pending until the object is replaced
+	w := serve(router, http.MethodPost, "/api/v1/files/uploaded.txt/presign", writerKey, strings.NewReader(`{"method":"PUT","confirm":true}`), "application/json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("presign over uploaded.txt: status %d: %s", w.Code, w.Body)
+	}
+	if completed, err := uploads.Reconcile(context.Background()); err != nil || completed != 0 {
+		t.Errorf("reconcile before the replacement completed %d (%v), want 0", completed, err)
+	}
+	if _, err := backend.Put(context.Background(), "test", "uploaded.txt", strings.NewReader("annual"), 6, storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if completed, err := uploads.Reconcile(context.Background()); err != nil || completed != 1 {
+		t.Errorf("reconcile after the replacement completed %d (%v), want 1", completed, err)
+	}
+	if len(recorded.events) != 2 || recorded.events[1].Size != 6 {
+		t.Errorf("events = %+v, want the replacement's upload last", recorded.events)
+	}
+}
+
+func TestParallelRangeDownload(t *testing.T) {
+	cfg := testConfig()
+	cfg.TransferMaxConcurrent = 8
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("0123456789abcdefghij")
+	if w := uploadFile(t, router, writerKey, "parts.bin", content); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	get := func(header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/parts.bin", nil)
+		req.Header.Set(middleware.APIKeyHeader, readerKey)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("Range", "bytes=2-5")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" || w.Header().Get("Content-Range") != "bytes 2-5/20" || w.Header().Get("Content-Length") != "4" {
+		t.Errorf("range status = %d, body %q, headers %v", w.Code, w.Body, w.Header())
+	}
+	etag := w.Header().Get("ETag")
+	if w = get("Range", "bytes=-3"); w.Code != http.StatusPartialContent || w.Body.String() != "hij" {
+		t.Errorf("suffix range status = %d, body %q", w.Code, w.Body)
+	}
+	if w = get("Range", "bytes=20-"); w.Code != http.StatusRequestedRangeNotSatisfiable || w.Header().Get("Content-Range") != "bytes */20" {
+		t.Errorf("unsatisfiable range status = %d, headers %v", w.Code, w.Header())
+	}
+	if w = get("Range", "bytes=0-3", "If-Range", `"stale"`); w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Errorf("stale If-Range status = %d, body %q", w.Code, w.Body)
+	}
+
+	if w = serve(router, http.MethodGet, "/api/v1/files/parts.bin/parallel?parts=65", readerKey, nil, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("65 parts status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w = serve(router, http.MethodGet, "/api/v1/files/parts.bin/parallel?parts=3", readerKey, nil, "")
+	var plan handlers.ParallelDownloadResponse
+	decodeData(t, w, &plan)
+	if w.Code != http.StatusOK || plan.Size != 20 || plan.ETag != etag || len(plan.Parts) != 3 {
+		t.Fatalf("parallel status = %d, plan %+v", w.Code, plan)
+	}
+	var joined []byte
+	for _, part := range plan.Parts {
+		w := get("Range", part.Range, "If-Range", plan.ETag)
+		if w.Code != http.StatusPartialContent || int64(w.Body.Len()) != part.Length {
+			t.Fatalf("part %s status = %d, body %q", part.Range, w.Code, w.Body)
+		}
+		joined = append(joined, w.Body.Bytes()...)
+	}
+	if string(joined) != string(content) {
+		t.Errorf("joined parts = %q, want %q", joined, content)
+	}
+	// Each part takes a transfer slot, so no more parts than slots are planned
+	w = serve(router, http.MethodGet, "/api/v1/files/parts.bin/parallel?parts=16", readerKey, nil, "")
+	decodeData(t, w, &plan)
+	if w.Code != http.StatusOK || len(plan.Parts) != 8 {
+		t.Errorf("16 parts with 8 transfer slots status = %d, %d parts", w.Code, len(plan.Parts))
+	}
+}
+
+// Ranges of envelope-encrypted files decrypt only the segments holding them
+func TestEnvelopeRangeDownload(t *testing.T) {
+	keyring, err := storage.ParseKeyring([]string{"k1=" + base64.StdEncoding.EncodeToString(make([]byte, 32))}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &rangeRecorder{Backend: storage.NewMemoryBackend()}
+	backend := storage.NewEnvelope(stored, keyring)
+	router := newBackendRouter(t, backend)
+	content := make([]byte, 200<<10)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	if _, err := backend.Put(context.Background(), "test", "large.bin", bytes.NewReader(content), int64(len(content)), storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const segment = 64 << 10
+	for _, tc := range []struct {
+		header      string
+		from, to    int
+		storedBytes int64
+	}{
+		{"bytes=10-99", 10, 99, segment + 16},
+		{"bytes=65530-65545", 65530, 65545, 2 * (segment + 16)},
+		{"bytes=150000-", 150000, len(content) - 1, 2*16 + int64(len(content)) - 2*segment},
+		{"bytes=-5", len(content) - 5, len(content) - 1, 16 + int64(len(content)) - 3*segment},
+	} {
+		stored.read = nil
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/large.bin", nil)
+		req.Header.Set(middleware.APIKeyHeader, readerKey)
+		req.Header.Set("Range", tc.header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), content[tc.from:tc.to+1]) {
+			t.Errorf("%s: status = %d, %d bytes", tc.header, w.Code, w.Body.Len())
+			continue
+		}
+		if len(stored.read) != 1 || stored.read[0].Length != tc.storedBytes {
+			t.Errorf("%s: read %+v from storage, want %d bytes", tc.header, stored.read, tc.storedBytes)
+		}
+	}
+}
+
+// rangeRecorder records the ranges read from the backend it wraps
+type rangeRecorder struct {
+	storage.Backend
+	read []storage.ByteRange
+}
+
+func (b *rangeRecorder) Get(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	r, _ := storage.RangeFrom(ctx)
+	b.read = append(b.read, r)
+	return b.Backend.Get(ctx, bucket, key)
+}
+
+func TestUploadContentMD5(t *testing.T) {
+	router, _ := newMemoryRouter(t)
+	content := []byte("checked on the way in")
+
+	upload := func(contentMD5 string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "digest.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/files", &body)
+		req.Header.Set(middleware.APIKeyHeader, writerKey)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Content-MD5", contentMD5)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	sum := md5.Sum(content)
+	if w := upload(base64.StdEncoding.EncodeToString(sum[:])); w.Code != http.StatusOK {
+		t.Fatalf("matching digest status = %d: %s", w.Code, w.Body)
+	}
+	stale := md5.Sum([]byte("something else"))
+	w := upload(base64.StdEncoding.EncodeToString(stale[:]))
+	var problem apierror.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusBadRequest || problem.Code != apierror.CodeBadDigest {
+		t.Errorf("mismatched digest status = %d, code %q", w.Code, problem.Code)
+	}
+	if w := upload("not-a-digest"); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed digest status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFetchRemoteFile(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.txt":
+			w.Write([]byte("fetched"))
+		case "/archive.txt":
+			w.Write([]byte(strings.Repeat("large ", 50)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	cfg := testConfig()
+	cfg.FetchSyncMaxSize = 100
+	var queue *jobs.Queue
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue = jobs.NewQueue(deps.Store, &logger, 1, 1)
+		client := fetch.NewClient(fetch.Options{Schemes: []string{"http"}, Hosts: []string{"127.0.0.1"}, AllowPrivate: true})
+		deps.Fetcher = fetch.NewService(client, deps.Files, nil, queue, &logger)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+
+	post := func(url string) *httptest.ResponseRecorder {
+		return serve(router, http.MethodPost, "/api/v1/files/fetch", writerKey, strings.NewReader(`{"url":"`+url+`"}`), "application/json")
+	}
+	if w := post(remote.URL + "/report.txt"); w.Code != http.StatusOK {
+		t.Fatalf("fetch status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files/report.txt", readerKey, nil, ""); w.Body.String() != "fetched" {
+		t.Errorf("fetched file = %q", w.Body)
+	}
+	if w := post("http://example.com/report.txt"); w.Code != http.StatusForbidden {
+		t.Errorf("disallowed host status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := post(remote.URL + "/missing.txt"); w.Code != http.StatusBadGateway {
+		t.Errorf("missing remote file status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+
+	w := post(remote.URL + "/archive.txt")
+	var job handlers.FetchJobResponse
+	decodeData(t, w, &job)
+	if w.Code != http.StatusAccepted || job.ID == "" {
+		t.Fatalf("large fetch status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files/fetch/"+job.ID, readerKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("another caller's fetch status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != jobs.StatusSucceeded && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		decodeData(t, serve(router, http.MethodGet, "/api/v1/files/fetch/"+job.ID, writerKey, nil, ""), &job)
+	}
+	if job.Result == nil || job.Result.Filename != "archive.txt" || job.Result.Size != 300 {
+		t.Fatalf("background fetch = %+v", job)
+	}
+
+	private := fetch.NewClient(fetch.Options{Schemes: []string{"http"}, Hosts: []string{"*"}})
+	if _, err := private.Open(context.Background(), remote.URL+"/report.txt"); !errors.Is(err, fetch.ErrNotAllowed) {
+		t.Errorf("fetch from a loopback address: %v, want ErrNotAllowed", err)
+	}
+}
+
+func TestImportBucket(t *testing.T) {
+	objects := map[string]string{"photos/a.txt": "alpha", "photos/sub/b.txt": "bravo", "other/c.txt": "charlie"}
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=lent/") {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+			return
+		}
+		if r.URL.Path == "/old" || r.URL.Path == "/old/" {
+			var b strings.Builder
+			b.WriteString(`<ListBucketResult><Name>old</Name><IsTruncated>false</IsTruncated>`)
+			for _, key := range []string{"other/c.txt", "photos/a.txt", "photos/sub/b.txt"} {
+				if strings.HasPrefix(key, r.URL.Query().Get("prefix")) && key > r.URL.Query().Get("start-after") {
+					b.WriteString(`<Contents><Key>` + key + `</Key><Size>` + strconv.Itoa(len(objects[key])) + `</Size></Contents>`)
+				}
+			}
+			b.WriteString(`</ListBucketResult>`)
+			io.WriteString(w, b.String())
+			return
+		}
+		body, ok := objects[strings.TrimPrefix(r.URL.Path, "/old/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		io.WriteString(w, body)
+	}))
+	defer source.Close()
+
+	backend := storage.NewMemoryBackend()
+	keyring, err := storage.ParseKeyring([]string{"k1=" + base64.StdEncoding.EncodeToString(make([]byte, 32))}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queue *jobs.Queue
+	var metaStore store.Store
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue = jobs.NewQueue(deps.Store, &logger, 1, 1)
+		metaStore = deps.Store
+		client := fetch.NewClient(fetch.Options{Schemes: []string{"http"}, Hosts: []string{"127.0.0.1"}, AllowPrivate: true})
+		deps.Importer = importer.NewService(client, deps.Files, deps.Store, keyring, nil, queue, &logger)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+
+	start := func(endpoint, accessKey string, skipExisting bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(handlers.ImportRequest{
+			Endpoint: endpoint, Region: "us-east-1", Bucket: "old", Prefix: "photos/",
+			AccessKeyID: accessKey, SecretAccessKey: "secret", Folder: "imported", SkipExisting: skipExisting,
+		})
+		return serve(router, http.MethodPost, "/api/v1/files/import", writerKey, bytes.NewReader(body), "application/json")
+	}
+	wait := func(id string) handlers.ImportResponse {
+		var imp handlers.ImportResponse
+		deadline := time.Now().Add(5 * time.Second)
+		for imp.Status != importer.StatusSucceeded && imp.Status != importer.StatusFailed && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			decodeData(t, serve(router, http.MethodGet, "/api/v1/files/import/"+id, writerKey, nil, ""), &imp)
+		}
+		return imp
+	}
+
+	if w := start(source.URL, "stolen", false); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("wrong credentials status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := start("https://s3.example.com", "lent", false); w.Code != http.StatusForbidden {
+		t.Errorf("disallowed endpoint status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w := start(source.URL, "lent", false)
+	var imp handlers.ImportResponse
+	decodeData(t, w, &imp)
+	if w.Code != http.StatusAccepted || imp.ID == "" {
+		t.Fatalf("import status = %d: %s", w.Code, w.Body)
+	}
+	// The lent credentials are stored sealed until the import ends
+	var stored json.RawMessage
+	if err := metaStore.Get(context.Background(), "import-credentials/"+imp.ID, &stored); err != nil {
+		t.Fatalf("stored credentials: %v", err)
+	}
+	if strings.Contains(string(stored), "lent") || strings.Contains(string(stored), "secret") {
+		t.Errorf("credentials stored in the clear: %s", stored)
+	}
+	if err := queue.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files/import/"+imp.ID, readerKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("another caller's import status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	imp = wait(imp.ID)
+	if imp.Status != importer.StatusSucceeded || imp.Copied != 2 || imp.Bytes != 10 || imp.LastKey != "photos/sub/b.txt" {
+		t.Fatalf("import = %+v", imp)
+	}
+	if err := metaStore.Get(context.Background(), "import-credentials/"+imp.ID, &stored); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("credentials of an ended import: %v, want them deleted", err)
+	}
+	for key, want := range map[string]string{"imported/a.txt": "alpha", "imported/sub/b.txt": "bravo"} {
+		r, _, err := backend.Get(context.Background(), "test", key)
+		if err != nil {
+			t.Fatalf("imported %s: %v", key, err)
+		}
+		got, _ := io.ReadAll(r)
+		r.Close()
+		if string(got) != want {
+			t.Errorf("imported %s = %q, want %q", key, got, want)
+		}
+	}
+	if w := serve(router, http.MethodDelete, "/api/v1/files/import/"+imp.ID, writerKey, nil, ""); w.Code != http.StatusConflict {
+		t.Errorf("cancel of an ended import status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	w = start(source.URL, "lent", true)
+	decodeData(t, w, &imp)
+	if imp = wait(imp.ID); imp.Status != importer.StatusSucceeded || imp.Copied != 0 || imp.Skipped != 2 {
+		t.Errorf("import of existing files = %+v, want 2 skipped", imp)
+	}
+}
+
+func TestBackupSnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemoryBackend()
+	destination := storage.NewMemoryBackend()
+	if err := destination.MakeBucket(ctx, "backups"); err != nil {
+		t.Fatal(err)
+	}
+	var queue *jobs.Queue
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue = jobs.NewQueue(deps.Store, &logger, 1, 1)
+		deps.Backups = backup.New(sealedBackend{Backend: backend, key: "docs/sealed.txt"}, destination, deps.Store, queue, backup.Options{
+			Bucket: "test", Prefix: "docs/", Destination: "backups", Retention: 1,
+		}, &logger)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+
+	objects := map[string]string{"docs/a.txt": "alpha", "docs/sub/b.txt": "bravo", "other/c.txt": "charlie", "docs/sealed.txt": "delta"}
+	for key, body := range objects {
+		if _, err := backend.Put(ctx, "test", key, strings.NewReader(body), int64(len(body)), storage.PutOptions{ContentType: "text/plain"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(b storage.Backend, bucket, key string) string {
+		r, _, err := b.Get(ctx, bucket, key)
+		if err != nil {
+			return ""
+		}
+		defer r.Close()
+		got, _ := io.ReadAll(r)
+		return string(got)
+	}
+	snapshot := func() backup.Snapshot {
+		w := serve(router, http.MethodPost, "/api/v1/admin/backups", adminKey, nil, "")
+		var snap backup.Snapshot
+		decodeData(t, w, &snap)
+		if w.Code != http.StatusAccepted || snap.ID == "" {
+			t.Fatalf("snapshot status = %d: %s", w.Code, w.Body)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for snap.Status == backup.StatusRunning && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/backups/"+snap.ID, adminKey, nil, ""), &snap)
+		}
+		return snap
+	}
+	restore := func(id, body string) backup.Restore {
+		w := serve(router, http.MethodPost, "/api/v1/admin/backups/"+id+"/restore", adminKey, strings.NewReader(body), "application/json")
+		var restore backup.Restore
+		decodeData(t, w, &restore)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("restore status = %d: %s", w.Code, w.Body)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for restore.Status == backup.StatusRunning && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/restores/"+restore.ID, adminKey, nil, ""), &restore)
+		}
+		return restore
+	}
+
+	if w := serve(router, http.MethodPost, "/api/v1/admin/backups", writerKey, nil, ""); w.Code != http.StatusForbidden {
+		t.Errorf("snapshot by a writer status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	first := snapshot()
+	// Objects sealed with a customer key are listed, not copied
+	if first.Status != backup.StatusSucceeded || first.Objects != 2 || first.Bytes != 10 || first.Unreadable != 1 {
+		t.Fatalf("snapshot = %+v", first)
+	}
+	var manifest backup.Manifest
+	if err := json.Unmarshal([]byte(read(destination, "backups", first.Location+"manifest.json")), &manifest); err != nil || manifest.Objects != 2 ||
+		len(manifest.UnreadableKeys) != 1 || manifest.UnreadableKeys[0] != "docs/sealed.txt" {
+		t.Fatalf("manifest = %+v, %v", manifest, err)
+	}
+	if got := read(destination, "backups", first.Location+"objects/docs/a.txt"); got != "alpha" {
+		t.Errorf("backed up docs/a.txt = %q", got)
+	}
+
+	// Retention keeps the newest snapshot only
+	second := snapshot()
+	var snapshots []backup.Snapshot
+	decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/backups", adminKey, nil, ""), &snapshots)
+	if len(snapshots) != 1 || snapshots[0].ID != second.ID {
+		t.Fatalf("snapshots = %+v, want only %s", snapshots, second.ID)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/admin/backups/"+first.ID, adminKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("pruned snapshot status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := read(destination, "backups", first.Location+"manifest.json"); got != "" {
+		t.Error("pruned snapshot is still in the backup bucket")
+	}
+
+	backend.Remove(ctx, "test", "docs/a.txt")
+	backend.Put(ctx, "test", "docs/sub/b.txt", strings.NewReader("changed"), 7, storage.PutOptions{})
+	if r := restore(second.ID, ""); r.Status != backup.StatusSucceeded || r.Restored != 1 || r.Skipped != 1 {
+		t.Fatalf("restore = %+v, want 1 restored and 1 skipped", r)
+	}
+	if got := read(backend, "test", "docs/a.txt"); got != "alpha" {
+		t.Errorf("restored docs/a.txt = %q", got)
+	}
+	if got := read(backend, "test", "docs/sub/b.txt"); got != "changed" {
+		t.Errorf("kept docs/sub/b.txt = %q", got)
+	}
+
+	// Corrupted copies are reported, not restored
+	destination.Put(ctx, "backups", second.Location+"objects/docs/sub/b.txt", strings.NewReader("BRAVO"), 5, storage.PutOptions{})
+	r := restore(second.ID, `{"prefix":"docs/sub/","overwrite":true}`)
+	if r.Status != backup.StatusSucceeded || r.Restored != 0 || r.Failed != 1 || len(r.Failures) != 1 || r.Failures[0].Key != "docs/sub/b.txt" {
+		t.Fatalf("restore of a corrupted copy = %+v", r)
+	}
+	if got := read(backend, "test", "docs/sub/b.txt"); got != "changed" {
+		t.Errorf("docs/sub/b.txt after a failed restore = %q", got)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/admin/backups/unknown/restore", adminKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("restore of an unknown snapshot status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemoryBackend()
+	var queue *jobs.Queue
+	router, err := mountRoutes(t, backend, testConfig(), NewRegistry(DefaultModules()...), func(deps *Dependencies) {
+		logger := zerolog.Nop()
+		queue = jobs.NewQueue(deps.Store, &logger, 1, 1)
+		deps.Jobs = queue
+		deps.Integrity = integrity.NewChecker(sealedBackend{Backend: backend, key: "sealed.txt"}, nil, queue, &logger)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Close()
+
+	for name, body := range map[string]string{"intact.txt": "alpha", "rotten.txt": "bravo"} {
+		if w := uploadFile(t, router, writerKey, name, []byte(body)); w.Code != http.StatusOK {
+			t.Fatalf("upload %s status = %d: %s", name, w.Code, w.Body)
+		}
+	}
+	// Same size and metadata, different content
+	_, info, err := backend.Get(ctx, "test", "rotten.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Put(ctx, "test", "rotten.txt", strings.NewReader("BRAVO"), 5, storage.PutOptions{Metadata: info.Metadata})
+	backend.Put(ctx, "test", "raw.txt", strings.NewReader("charlie"), 7, storage.PutOptions{})
+	backend.Put(ctx, "test", "sealed.txt", strings.NewReader("delta"), 5, storage.PutOptions{})
+
+	if w := serve(router, http.MethodPost, "/api/v1/admin/verify", writerKey, nil, ""); w.Code != http.StatusForbidden {
+		t.Errorf("verify by a writer status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/admin/verify?bucket=nope", adminKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("verify of an unknown bucket status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	w := serve(router, http.MethodPost, "/api/v1/admin/verify", adminKey, nil, "")
+	var job jobs.Job
+	decodeData(t, w, &job)
+	if w.Code != http.StatusAccepted || job.ID == "" {
+		t.Fatalf("verify status = %d: %s", w.Code, w.Body)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != jobs.StatusSucceeded && job.Status != jobs.StatusFailed && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		decodeData(t, serve(router, http.MethodGet, "/api/v1/admin/jobs/"+job.ID, adminKey, nil, ""), &job)
+	}
+	var report integrity.Report
+	if err := json.Unmarshal(job.Result, &report); err != nil {
+		t.Fatalf("job %+v: %v", job, err)
+	}
+	if !report.Complete || report.Objects != 3 || report.Verified != 1 || report.Unverified != 1 || report.Skipped != 1 || report.CorruptedCount != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if problem := report.Corrupted[0]; problem.Key != "rotten.txt" || problem.Algorithm != "sha256" {
+		t.Errorf("corrupted = %+v, want rotten.txt by sha256", problem)
+	}
+}
+
+// sealedBackend refuses to read key, as storage does for objects sealed with
+// an SSE-C key when the key isn't sent
+type sealedBackend struct {
+	storage.Backend
+	key string
+}
+
+func (b sealedBackend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, storage.ObjectInfo, error) {
+	if key == b.key {
+		return nil, storage.ObjectInfo{}, fmt.Errorf("%w: %s", storage.ErrCustomerKeyRequired, key)
+	}
+	return b.Backend.Get(ctx, bucket, key)
+}
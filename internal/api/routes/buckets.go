@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// registerBuckets mounts bucket operations
+func registerBuckets(v gin.IRouter, api *API) {
+	// Bucket operations
+	buckets := v.Group("/buckets", api.secure("buckets"), api.compress("buckets"), api.validate, api.idempotent)
+	{
+		// List buckets
+		// @Summary List all buckets
+		// @Description List all buckets in MinIO
+		// @Tags buckets
+		// @Produce json
+		// @Success 200 {array} object
+		// @Router /api/v1/buckets [get]
+		buckets.GET("", api.require(auth.ScopeBucketsAdmin), api.minio.ListBuckets)
+
+		// @Summary Create a bucket
+		// @Tags buckets
+		// @Router /api/v1/buckets [post]
+		buckets.POST("", api.require(auth.ScopeBucketsAdmin), api.minio.CreateBucket)
+
+		// @Summary Delete a bucket
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket} [delete]
+		buckets.DELETE("/:bucket", api.require(auth.ScopeBucketsAdmin), api.minio.DeleteBucket)
+
+		// @Summary Get bucket CORS configuration
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/cors [get]
+		buckets.GET("/:bucket/cors", api.require(auth.ScopeBucketsAdmin), api.minio.GetBucketCORS)
+
+		// @Summary Set bucket CORS configuration
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/cors [put]
+		buckets.PUT("/:bucket/cors", api.require(auth.ScopeBucketsAdmin), api.minio.SetBucketCORS)
+
+		// @Summary Delete bucket CORS configuration
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/cors [delete]
+		buckets.DELETE("/:bucket/cors", api.require(auth.ScopeBucketsAdmin), api.minio.DeleteBucketCORS)
+
+		// @Summary Get bucket lifecycle rules
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/lifecycle [get]
+		buckets.GET("/:bucket/lifecycle", api.require(auth.ScopeBucketsAdmin), api.minio.GetBucketLifecycle)
+
+		// @Summary Set bucket lifecycle rules
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/lifecycle [put]
+		buckets.PUT("/:bucket/lifecycle", api.require(auth.ScopeBucketsAdmin), api.minio.SetBucketLifecycle)
+
+		// @Summary Delete bucket lifecycle rules
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/lifecycle [delete]
+		buckets.DELETE("/:bucket/lifecycle", api.require(auth.ScopeBucketsAdmin), api.minio.DeleteBucketLifecycle)
+
+		// @Summary Get bucket default encryption
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/encryption [get]
+		buckets.GET("/:bucket/encryption", api.require(auth.ScopeBucketsAdmin), api.minio.GetBucketEncryption)
+
+		// @Summary Set bucket default encryption
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/encryption [put]
+		buckets.PUT("/:bucket/encryption", api.require(auth.ScopeBucketsAdmin), api.minio.SetBucketEncryption)
+
+		// @Summary Delete bucket default encryption
+		// @Tags buckets
+		// @Router /api/v1/buckets/{bucket}/encryption [delete]
+		buckets.DELETE("/:bucket/encryption", api.require(auth.ScopeBucketsAdmin), api.minio.DeleteBucketEncryption)
+	}
+}
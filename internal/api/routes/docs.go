@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// registerDocs mounts the OpenAPI document, the generated TypeScript client
+// and the Swagger UI
+func registerDocs(r gin.IRouter, api *API) {
+	// OpenAPI 3.1 document and generated TypeScript client
+	if api.docErr == nil {
+		openapiHandler, err := handlers.NewOpenAPIHandler([]byte(api.doc))
+		if err != nil {
+			api.Logger.Fatal().Err(err).Msg("Failed to convert OpenAPI docs")
+		}
+
+		// @Summary OpenAPI 3.1 document
+		// @Tags docs
+		// @Router /openapi.json [get]
+		r.GET("/openapi.json", openapiHandler.GetSpec)
+
+		// @Summary Generated TypeScript client
+		// @Tags docs
+		// @Router /openapi/client.ts [get]
+		r.GET("/openapi/client.ts", openapiHandler.GetClient)
+	}
+
+	// Swagger documentation endpoint
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+}
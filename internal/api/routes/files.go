@@ -0,0 +1,310 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// filesGroup is the /files route group with the middleware every file
+// operation runs through
+func (a *API) filesGroup(v gin.IRouter) *gin.RouterGroup {
+	files := v.Group("/files", a.secure("files"), a.compress("files"))
+	files.Use(a.tenant...)
+	files.Use(a.validate, a.idempotent, middleware.CustomerKeyMiddleware())
+	return files
+}
+
+// registerFiles mounts file operations, upload progress, the caller's own
+// files and storage usage
+func registerFiles(v gin.IRouter, api *API) {
+	// File operations
+	files := api.filesGroup(v)
+	{
+		// Upload file
+		// @Summary Upload a file to MinIO
+		// @Description Upload a file to MinIO storage
+		// @Tags files
+		// @Accept multipart/form-data
+		// @Produce json
+		// @Param file formData file true "File to upload"
+		// @Success 200 {object} map[string]string
+		// @Failure 413 {object} utils.ErrorResponse
+		// @Router /api/v1/files [post]
+		files.POST("", api.require(auth.ScopeFilesWrite), api.transfers, middleware.BodyLimitMiddleware(api.maxFileSize), api.minio.UploadFile)
+
+		// Upload many files
+		// @Summary Upload many files
+		// @Description Upload several files, or a zip archive expanded into objects, in one request
+		// @Tags files
+		// @Accept multipart/form-data
+		// @Produce json
+		// @Success 200 {object} handlers.BatchUploadResponse
+		// @Failure 413 {object} utils.ErrorResponse
+		// @Router /api/v1/files/batch [post]
+		files.POST("/batch", api.require(auth.ScopeFilesWrite), api.transfers, middleware.BodyLimitMiddleware(api.maxFileSize), api.minio.UploadBatch)
+
+		if api.fetch != nil {
+			// Upload a file from a URL
+			// @Summary Upload a file from a URL
+			// @Description Download a remote file into the bucket server-side, in a background job for large files
+			// @Tags files
+			// @Accept json
+			// @Produce json
+			// @Success 200 {object} map[string]interface{}
+			// @Success 202 {object} handlers.FetchJobResponse
+			// @Router /api/v1/files/fetch [post]
+			files.POST("/fetch", api.require(auth.ScopeFilesWrite), api.transfers, api.fetch.FetchFile)
+
+			// Get a background fetch
+			// @Summary Get a background fetch
+			// @Description Return the state of a remote fetch queued by the caller
+			// @Tags files
+			// @Produce json
+			// @Param id path string true "Job ID"
+			// @Success 200 {object} handlers.FetchJobResponse
+			// @Router /api/v1/files/fetch/{id} [get]
+			files.GET("/fetch/:id", api.require(auth.ScopeFilesRead), api.fetch.GetFetch)
+		}
+
+		if api.imports != nil {
+			// Import files from an S3 bucket
+			// @Summary Import files from an S3 bucket
+			// @Description Copy the objects of another S3 bucket in a background job, with credentials lent for the source
+			// @Tags files
+			// @Accept json
+			// @Produce json
+			// @Success 202 {object} handlers.ImportResponse
+			// @Router /api/v1/files/import [post]
+			files.POST("/import", api.require(auth.ScopeFilesWrite), api.imports.StartImport)
+
+			// Get an import
+			// @Summary Get an import
+			// @Description Return the progress of an import started by the caller
+			// @Tags files
+			// @Produce json
+			// @Param id path string true "Import ID"
+			// @Success 200 {object} handlers.ImportResponse
+			// @Router /api/v1/files/import/{id} [get]
+			files.GET("/import/:id", api.require(auth.ScopeFilesRead), api.imports.GetImport)
+
+			// Cancel an import
+			// @Summary Cancel an import
+			// @Description Stop an import started by the caller; files already copied are kept
+			// @Tags files
+			// @Produce json
+			// @Param id path string true "Import ID"
+			// @Success 200 {object} handlers.ImportResponse
+			// @Router /api/v1/files/import/{id} [delete]
+			files.DELETE("/import/:id", api.require(auth.ScopeFilesWrite), api.imports.CancelImport)
+		}
+
+		// List files
+		// @Summary List all files
+		// @Description List all files in the MinIO bucket
+		// @Tags files
+		// @Produce json
+		// @Success 200 {array} object
+		// @Router /api/v1/files [get]
+		files.GET("", api.require(auth.ScopeFilesRead), api.minio.ListFiles)
+
+		// Export files
+		// @Summary Export files as an archive
+		// @Description Stream all files under a prefix as a tar or zip archive
+		// @Tags files
+		// @Produce application/x-tar,application/zip
+		// @Param prefix query string false "Prefix to export"
+		// @Param format query string false "Archive format" Enums(tar, zip)
+		// @Success 200 {file} binary
+		// @Router /api/v1/files/export [get]
+		files.GET("/export", api.require(auth.ScopeFilesRead), api.transfers, api.minio.ExportFiles)
+
+		if api.Search != nil {
+			searchHandler := handlers.NewSearchHandler(api.Search, api.Logger, api.Config)
+			// Search files
+			// @Summary Search files
+			// @Description Filter files by name, tags, content type, size and date
+			// @Tags files
+			// @Produce json
+			// @Param q query string false "Text the file name must contain"
+			// @Param content query string false "Words the document text must contain"
+			// @Param tag query []string false "Tag as key or key=value"
+			// @Param min_size query int false "Minimum size in bytes"
+			// @Param after query string false "Modified after (RFC 3339)"
+			// @Success 200 {object} handlers.SearchResponse
+			// @Router /api/v1/files/search [get]
+			files.GET("/search", api.require(auth.ScopeFilesRead), searchHandler.SearchFiles)
+		}
+
+		if api.MetaDB != nil {
+			recordsHandler := handlers.NewRecordsHandler(api.MetaDB, api.Logger, api.Config)
+			// List file records
+			// @Summary List file records
+			// @Description List files from the metadata database, filtered by owner and tags
+			// @Tags files
+			// @Produce json
+			// @Param owner query string false "Owner subject, or me"
+			// @Param tag query []string false "Tag as key or key=value"
+			// @Success 200 {array} metadb.Record
+			// @Router /api/v1/files/records [get]
+			files.GET("/records", api.require(auth.ScopeFilesRead), recordsHandler.ListRecords)
+		}
+
+		// Get file
+		// @Summary Get a file
+		// @Description Get a file from MinIO by its name
+		// @Tags files
+		// @Produce octet-stream
+		// @Param filename path string true "File name"
+		// @Success 200 {file} binary
+		// @Router /api/v1/files/{filename} [get]
+		files.GET("/:filename", api.require(auth.ScopeFilesRead), api.redirect("files"), api.transfers, api.minio.GetFile)
+
+		// Get file headers
+		// @Summary Get file headers
+		// @Description Return file metadata as headers without the body
+		// @Tags files
+		// @Param filename path string true "File name"
+		// @Success 200
+		// @Router /api/v1/files/{filename} [head]
+		files.HEAD("/:filename", api.require(auth.ScopeFilesRead), api.minio.HeadFile)
+
+		// Check file existence
+		// @Summary Check if a file exists
+		// @Description Check for a file without downloading it
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} map[string]interface{}
+		// @Router /api/v1/files/{filename}/exists [get]
+		files.GET("/:filename/exists", api.require(auth.ScopeFilesRead), api.minio.FileExists)
+
+		if api.Config.HLSTranscoder != "" {
+			// Stream a video
+			// @Summary Stream a video
+			// @Description Serve the HLS stream generated for a video, starting with master.m3u8
+			// @Tags files
+			// @Produce application/vnd.apple.mpegurl,video/mp2t
+			// @Param filename path string true "File name"
+			// @Success 200 {file} binary
+			// @Router /api/v1/files/{filename}/stream/master.m3u8 [get]
+			files.GET("/:filename/stream/*asset", api.require(auth.ScopeFilesRead), api.minio.StreamVideo)
+		}
+
+		// Get image metadata
+		// @Summary Get EXIF/IPTC metadata
+		// @Description Extract EXIF and IPTC metadata from a JPEG image
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} exif.Metadata
+		// @Router /api/v1/files/{filename}/exif [get]
+		files.GET("/:filename/exif", api.require(auth.ScopeFilesRead), api.minio.GetExif)
+
+		// Strip image location data
+		// @Summary Strip GPS data
+		// @Description Remove GPS location data from a stored JPEG image
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} map[string]interface{}
+		// @Router /api/v1/files/{filename}/exif/strip-gps [post]
+		files.POST("/:filename/exif/strip-gps", api.require(auth.ScopeFilesWrite), api.minio.StripExifGPS)
+
+		// Get file checksums
+		// @Summary Get file checksums
+		// @Description Return the checksums recorded for a file at upload time
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} map[string]interface{}
+		// @Router /api/v1/files/{filename}/checksum [get]
+		files.GET("/:filename/checksum", api.require(auth.ScopeFilesRead), api.minio.GetFileChecksum)
+
+		if api.Analytics != nil {
+			analyticsHandler := handlers.NewAnalyticsHandler(api.Analytics, api.minio, api.Logger)
+			// Get file download statistics
+			// @Summary Get file download statistics
+			// @Description Return download counts, unique client addresses and the last access time of a file
+			// @Tags files
+			// @Produce json
+			// @Param filename path string true "File name"
+			// @Success 200 {object} handlers.FileStatsResponse
+			// @Router /api/v1/files/{filename}/stats [get]
+			files.GET("/:filename/stats", api.require(auth.ScopeFilesRead), analyticsHandler.GetFileStats)
+		}
+
+		// Get a file's public URL
+		// @Summary Get a file's public URL
+		// @Description Return the URL of a file on the bucket's public address
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} handlers.PublicURLResponse
+		// @Router /api/v1/files/{filename}/public-url [get]
+		files.GET("/:filename/public-url", api.require(auth.ScopeFilesRead), api.minio.GetPublicURL)
+
+		// Plan a parallel download
+		// @Summary Plan a parallel download
+		// @Description Split a file into byte ranges to download with concurrent Range requests
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} handlers.ParallelDownloadResponse
+		// @Router /api/v1/files/{filename}/parallel [get]
+		files.GET("/:filename/parallel", api.require(auth.ScopeFilesRead), api.minio.ParallelDownload)
+
+		// Generate a presigned URL
+		// @Summary Generate a presigned URL
+		// @Description Generate a time-limited GET, PUT, DELETE or HEAD URL for direct object access
+		// @Tags files
+		// @Accept json
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} handlers.PresignResponse
+		// @Router /api/v1/files/{filename}/presign [post]
+		files.POST("/:filename/presign", api.require(auth.ScopeFilesWrite), api.minio.PresignFile)
+
+		// Delete file
+		// @Summary Delete a file
+		// @Description Delete a file from MinIO by its name
+		// @Tags files
+		// @Produce json
+		// @Param filename path string true "File name"
+		// @Success 200 {object} map[string]string
+		// @Router /api/v1/files/{filename} [delete]
+		files.DELETE("/:filename", api.require(auth.ScopeFilesWrite), api.minio.DeleteFile)
+	}
+
+	// Upload progress
+	// @Summary Stream upload progress
+	// @Description Open a WebSocket that streams bytes-transferred updates for an upload
+	// @Tags files
+	// @Param upload_id path string true "Upload ID"
+	// @Router /api/v1/ws/uploads/{upload_id} [get]
+	progress := append([]gin.HandlerFunc{api.secure("uploads")}, api.tenant...)
+	progress = append(progress, api.validate, api.require(auth.ScopeFilesRead), api.minio.UploadProgress)
+	v.GET("/ws/uploads/:upload_id", progress...)
+
+	// The caller's own files
+	me := v.Group("/me", api.secure("files"), api.compress("files"))
+	me.Use(middleware.HomeMiddleware(api.Resolver, api.Logger), api.validate)
+	{
+		// List my files
+		// @Summary List my files
+		// @Description List files in the caller's home directory
+		// @Tags files
+		// @Produce json
+		// @Success 200 {array} object
+		// @Failure 401 {object} utils.ErrorResponse
+		// @Router /api/v1/me/files [get]
+		me.GET("/files", api.require(auth.ScopeFilesRead), api.minio.ListMyFiles)
+	}
+
+	// Storage usage
+	// @Summary Get storage usage
+	// @Tags usage
+	// @Router /api/v1/usage [get]
+	v.GET("/usage", api.secure("usage"), api.compress("usage"), api.validate, api.require(auth.ScopeFilesRead), api.minio.GetUsage)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// registerHealth mounts the health check
+func registerHealth(r gin.IRouter, api *API) {
+	// Health check
+	// @Summary Health check endpoint
+	// @Description Check if the API is up and running
+	// @Tags health
+	// @Produce json
+	// @Success 200 {object} map[string]string
+	// @Router /health [get]
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+}
@@ -0,0 +1,237 @@
+//go:build integration
+
+package routes
+
+// Runs the HTTP stack against a real MinIO server started with
+// testcontainers, to catch regressions in how the SDK is used. Requires
+// Docker:
+//
+//	go test -tags integration ./internal/api/routes/
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	tcminio "github.com/testcontainers/testcontainers-go/modules/minio"
+)
+
+const minioImage = "minio/minio:RELEASE.2024-01-16T16-07-38Z"
+
+// newMinioRouter starts a MinIO container for the test and wires the real
+// routes on it
+func newMinioRouter(t *testing.T) (*gin.Engine, storage.Backend) {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := tcminio.Run(ctx, minioImage)
+	if err != nil {
+		t.Fatalf("start MinIO: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Errorf("stop MinIO: %v", err)
+		}
+	})
+	endpoint, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4(container.Username, container.Password, ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := storage.NewS3Backend(storage.ProviderMinio, client, "")
+	return newBackendRouter(t, backend), backend
+}
+
+func TestMinioFileLifecycle(t *testing.T) {
+	router, backend := newMinioRouter(t)
+	content := []byte("hello from minio")
+
+	w := uploadFile(t, router, writerKey, "notes.txt", content)
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	info, err := backend.Stat(context.Background(), "test", "notes.txt")
+	if err != nil || info.Size != int64(len(content)) || info.ContentType != "text/plain" {
+		t.Fatalf("stored object %+v, err %v", info, err)
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files", readerKey, nil, "")
+	var listed []map[string]interface{}
+	decodeData(t, w, &listed)
+	if w.Code != http.StatusOK || len(listed) != 1 {
+		t.Fatalf("list status = %d, files %v", w.Code, listed)
+	}
+
+	w = serve(router, http.MethodGet, "/api/v1/files/notes.txt", readerKey, nil, "")
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Fatalf("download status = %d, body %q", w.Code, w.Body)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"`+info.ETag+`"` {
+		t.Errorf("ETag = %s, want the stored %q", etag, info.ETag)
+	}
+
+	w = serve(router, http.MethodHead, "/api/v1/files/notes.txt", readerKey, nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Length") != "16" {
+		t.Errorf("head status = %d, headers %v", w.Code, w.Header())
+	}
+
+	w = serve(router, http.MethodDelete, "/api/v1/files/notes.txt", writerKey, nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+	if w = serve(router, http.MethodGet, "/api/v1/files/notes.txt", readerKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("download after delete status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// The API streams whole objects; ranged reads go through presigned URLs
+func TestMinioPresignedRangeDownload(t *testing.T) {
+	router, _ := newMinioRouter(t)
+	if w := uploadFile(t, router, writerKey, "digits.txt", []byte("0123456789")); w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(router, http.MethodPost, "/api/v1/files/digits.txt/presign", writerKey, strings.NewReader(`{"method":"GET"}`), "application/json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("presign status = %d: %s", w.Code, w.Body)
+	}
+	var presigned struct {
+		URL string `json:"url"`
+	}
+	decodeData(t, w, &presigned)
+
+	req, err := http.NewRequest(http.MethodGet, presigned.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Range", "bytes=2-5")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "2345" {
+		t.Errorf("range status = %d, body %q", resp.StatusCode, body)
+	}
+}
+
+// completeMultipart uploads content as a single part of a multipart upload
+// and returns the complete response
+func completeMultipart(t *testing.T, router *gin.Engine, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	w := serve(router, http.MethodPost, "/api/v1/uploads/multipart", writerKey, strings.NewReader(`{"filename":"`+filename+`"}`), "application/json")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create multipart status = %d: %s", w.Code, w.Body)
+	}
+	var upload struct {
+		UploadID string `json:"uploadId"`
+	}
+	decodeData(t, w, &upload)
+	base := "/api/v1/uploads/multipart/" + upload.UploadID
+
+	w = serve(router, http.MethodPost, base+"/parts", writerKey, strings.NewReader(`{"partNumbers":[1]}`), "application/json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("presign parts status = %d: %s", w.Code, w.Body)
+	}
+	var parts struct {
+		Parts map[string]string `json:"parts"`
+	}
+	decodeData(t, w, &parts)
+
+	req, err := http.NewRequest(http.MethodPut, parts.Parts["1"], bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("part upload status = %d", resp.StatusCode)
+	}
+
+	w = serve(router, http.MethodGet, base+"/parts", writerKey, nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list parts status = %d: %s", w.Code, w.Body)
+	}
+
+	complete := `{"parts":[{"partNumber":1,"etag":` + strconv.Quote(resp.Header.Get("ETag")) + `}]}`
+	return serve(router, http.MethodPost, base+"/complete", writerKey, strings.NewReader(complete), "application/json")
+}
+
+func TestMinioMultipartUpload(t *testing.T) {
+	router, backend := newMinioRouter(t)
+	content := bytes.Repeat([]byte("m"), testMaxFileSize/2)
+
+	w := completeMultipart(t, router, "parts.bin", content)
+	if w.Code != http.StatusOK {
+		t.Fatalf("complete status = %d: %s", w.Code, w.Body)
+	}
+	info, err := backend.Stat(context.Background(), "test", "parts.bin")
+	if err != nil || info.Size != int64(len(content)) {
+		t.Fatalf("assembled object %+v, err %v", info, err)
+	}
+}
+
+// Multipart sizes are only known once assembled, so the limit is enforced
+// after completion and the object removed
+func TestMinioMultipartOversize(t *testing.T) {
+	router, backend := newMinioRouter(t)
+
+	w := completeMultipart(t, router, "big.bin", bytes.Repeat([]byte("m"), testMaxFileSize+1))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("complete status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if _, err := backend.Stat(context.Background(), "test", "big.bin"); err == nil {
+		t.Error("oversize object kept")
+	}
+}
+
+func TestMinioBucketLifecycle(t *testing.T) {
+	router, _ := newMinioRouter(t)
+	path := "/api/v1/buckets/test/lifecycle"
+
+	rules := `{"rules":[{"id":"expire-tmp","prefix":"tmp/","status":"Enabled","expirationDays":7}]}`
+	w := serve(router, http.MethodPut, path, adminKey, strings.NewReader(rules), "application/json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("set lifecycle status = %d: %s", w.Code, w.Body)
+	}
+
+	w = serve(router, http.MethodGet, path, adminKey, nil, "")
+	var lifecycle struct {
+		Rules []map[string]interface{} `json:"rules"`
+	}
+	decodeData(t, w, &lifecycle)
+	if w.Code != http.StatusOK || len(lifecycle.Rules) != 1 || lifecycle.Rules[0]["prefix"] != "tmp/" {
+		t.Fatalf("get lifecycle status = %d, rules %v", w.Code, lifecycle.Rules)
+	}
+
+	if w = serve(router, http.MethodDelete, path, adminKey, nil, ""); w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Fatalf("delete lifecycle status = %d: %s", w.Code, w.Body)
+	}
+	w = serve(router, http.MethodGet, path, adminKey, nil, "")
+	decodeData(t, w, &lifecycle)
+	if len(lifecycle.Rules) != 0 {
+		t.Errorf("rules left after delete: %v", lifecycle.Rules)
+	}
+
+	if w = serve(router, http.MethodGet, "/api/v1/buckets/missing/lifecycle", adminKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("missing bucket status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
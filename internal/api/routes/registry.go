@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+)
+
+// apiVersions are the prefixes the API routes of every module are mounted
+// under. v2 serves the same operations as v1 in the utils.ResponseV2 envelope.
+var apiVersions = []string{"v1", "v2"}
+
+// Module is a related set of routes that registers itself on the router
+type Module struct {
+	Name string
+	// Enabled reports whether the module is mounted; nil means always
+	Enabled func(cfg *config.Config) bool
+	// Register mounts the module's API routes, once under each API version
+	Register func(v gin.IRouter, api *API)
+	// RegisterRoot mounts routes outside the versioned API
+	RegisterRoot func(r gin.IRouter, api *API)
+}
+
+// DefaultModules are the modules of the API, in mount order
+func DefaultModules() []Module {
+	return []Module{
+		{Name: "files", Register: registerFiles},
+		{Name: "thumbnails", Register: registerThumbnails, Enabled: feature(config.FeatureThumbnails)},
+		{Name: "uploads", Register: registerUploads},
+		{Name: "shares", Register: registerShares, RegisterRoot: registerShareLinks, Enabled: feature(config.FeatureSharing)},
+		{Name: "upload_links", Register: registerUploadLinks, RegisterRoot: registerUploadLinkPages, Enabled: func(cfg *config.Config) bool { return cfg.UploadLinksEnabled }},
+		{Name: "admin", Register: registerAdmin},
+		{Name: "buckets", Register: registerBuckets},
+		{Name: "site", RegisterRoot: registerSite, Enabled: func(cfg *config.Config) bool { return cfg.SiteEnabled }},
+		{Name: "docs", RegisterRoot: registerDocs},
+		{Name: "health", RegisterRoot: registerHealth},
+	}
+}
+
+// feature enables a module with an optional subsystem named in FEATURES
+func feature(name string) func(cfg *config.Config) bool {
+	return func(cfg *config.Config) bool { return cfg.FeatureEnabled(name) }
+}
+
+// Registry mounts route modules with their own middleware, skipping
+// disabled ones
+type Registry struct {
+	modules    []Module
+	middleware map[string][]gin.HandlerFunc
+	disabled   map[string]bool
+}
+
+// NewRegistry returns a registry of modules
+func NewRegistry(modules ...Module) *Registry {
+	return &Registry{
+		modules:    modules,
+		middleware: make(map[string][]gin.HandlerFunc),
+		disabled:   make(map[string]bool),
+	}
+}
+
+// Use adds middleware that runs before every route of a module
+func (r *Registry) Use(module string, middleware ...gin.HandlerFunc) {
+	r.middleware[module] = append(r.middleware[module], middleware...)
+}
+
+// Disable leaves modules out when mounting
+func (r *Registry) Disable(modules ...string) {
+	for _, name := range modules {
+		r.disabled[name] = true
+	}
+}
+
+// Mount registers the enabled modules on router
+func (r *Registry) Mount(router *gin.Engine, deps Dependencies) error {
+	known := make(map[string]bool, len(r.modules))
+	for _, m := range r.modules {
+		known[m.Name] = true
+	}
+	for name := range r.middleware {
+		if !known[name] {
+			return fmt.Errorf("middleware for unknown route module %q", name)
+		}
+	}
+	for name := range r.disabled {
+		if !known[name] {
+			return fmt.Errorf("unknown route module %q", name)
+		}
+	}
+
+	api, err := newAPI(deps)
+	if err != nil {
+		return err
+	}
+	for name := range api.deprecatedModules {
+		if !known[name] {
+			return fmt.Errorf("API_V1_DEPRECATED_MODULES names unknown route module %q", name)
+		}
+	}
+	for name := range api.timeouts {
+		if !known[name] {
+			return fmt.Errorf("ROUTE_TIMEOUTS names unknown route module %q", name)
+		}
+	}
+	for _, m := range r.modules {
+		if r.disabled[m.Name] || (m.Enabled != nil && !m.Enabled(deps.Config)) {
+			deps.Logger.Debug().Str("module", m.Name).Msg("Route module disabled")
+			continue
+		}
+		if m.Register != nil {
+			for _, version := range apiVersions {
+				v := router.Group("/api/"+version, api.version(version, m.Name)...)
+				v.Use(api.timeout(m.Name))
+				v.Use(r.middleware[m.Name]...)
+				m.Register(v, api)
+			}
+		}
+		if m.RegisterRoot != nil {
+			root := router.Group("", api.timeout(m.Name))
+			root.Use(r.middleware[m.Name]...)
+			m.RegisterRoot(root, api)
+		}
+	}
+	handleUnknownRoutes(router)
+	return nil
+}
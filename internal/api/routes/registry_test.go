@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// mountModules mounts the default modules on an in-memory backend after
+// configure has adjusted the registry
+func mountModules(t *testing.T, configure func(*Registry)) (*gin.Engine, error) {
+	t.Helper()
+	registry := NewRegistry(DefaultModules()...)
+	configure(registry)
+	return mountRoutes(t, storage.NewMemoryBackend(), testConfig(), registry)
+}
+
+func TestRegistryDisableModule(t *testing.T) {
+	router, err := mountModules(t, func(r *Registry) { r.Disable("health") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, http.MethodGet, "/health", "", nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("disabled module status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files", readerKey, nil, ""); w.Code != http.StatusOK {
+		t.Errorf("enabled module status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRegistryModuleMiddleware(t *testing.T) {
+	router, err := mountModules(t, func(r *Registry) {
+		r.Use("health", func(c *gin.Context) {
+			c.Header("X-Module", "health")
+			c.Next()
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, http.MethodGet, "/health", "", nil, ""); w.Header().Get("X-Module") != "health" {
+		t.Error("module middleware did not run on its routes")
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files", readerKey, nil, ""); w.Header().Get("X-Module") != "" {
+		t.Error("module middleware ran on another module's routes")
+	}
+}
+
+func TestRegistryUnknownModule(t *testing.T) {
+	if _, err := mountModules(t, func(r *Registry) { r.Disable("r2") }); err == nil {
+		t.Error("disabling an unknown module succeeded")
+	}
+	if _, err := mountModules(t, func(r *Registry) { r.Use("r2", func(c *gin.Context) {}) }); err == nil {
+		t.Error("middleware for an unknown module accepted")
+	}
+}
+
+func TestFeaturesSelectModules(t *testing.T) {
+	cfg := testConfig()
+	cfg.Features = []string{config.FeatureThumbnails}
+	router, err := mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/shares", writerKey, nil, ""); w.Code != http.StatusNotFound {
+		t.Errorf("shares without the sharing feature: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(router, http.MethodGet, "/s/unknown", "", nil, ""); !strings.Contains(w.Body.String(), "Route not found") {
+		t.Errorf("share link without the sharing feature: status = %d, body %s", w.Code, w.Body)
+	}
+	// The file API is core and always mounted
+	if w := serve(router, http.MethodGet, "/api/v1/files", readerKey, nil, ""); w.Code != http.StatusOK {
+		t.Errorf("files status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	cfg = testConfig()
+	cfg.Features = []string{"none"}
+	router, err = mountRoutes(t, storage.NewMemoryBackend(), cfg, NewRegistry(DefaultModules()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/files/photo.png/thumbnail", readerKey, nil, ""); !strings.Contains(w.Body.String(), "Route not found") {
+		t.Errorf("thumbnail without the thumbnails feature: status = %d, body %s", w.Code, w.Body)
+	}
+}
@@ -1,87 +1,290 @@
 package routes
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"github.com/minio/minio-go/v7"
-	"github.com/rs/zerolog"
 	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/analytics"
 	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/audit"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/backup"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/costs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/importer"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/integrity"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metadb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/openapi"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/search"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/sharing"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/throttle"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/uploadlink"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/swaggo/swag"
 )
 
-func SetupRoutes(router *gin.Engine, minioClient *minio.Client, logger *zerolog.Logger, cfg *config.Config) {
-
-	// Initialize MinIO handler
-	minioHandler := handlers.NewMinioHandler(minioClient, logger, cfg)
-
-	// API version group
-	v1 := router.Group("/api/v1")
-	{
-		// File operations
-		files := v1.Group("/files")
-		{
-			// Upload file
-			// @Summary Upload a file to MinIO
-			// @Description Upload a file to MinIO storage
-			// @Tags files
-			// @Accept multipart/form-data
-			// @Produce json
-			// @Param file formData file true "File to upload"
-			// @Success 200 {object} map[string]string
-			// @Router /api/v1/files [post]
-			files.POST("", minioHandler.UploadFile)
-
-			// List files
-			// @Summary List all files
-			// @Description List all files in the MinIO bucket
-			// @Tags files
-			// @Produce json
-			// @Success 200 {array} object
-			// @Router /api/v1/files [get]
-			files.GET("", minioHandler.ListFiles)
-
-			// Get file
-			// @Summary Get a file
-			// @Description Get a file from MinIO by its name
-			// @Tags files
-			// @Produce octet-stream
-			// @Param filename path string true "File name"
-			// @Success 200 {file} binary
-			// @Router /api/v1/files/{filename} [get]
-			files.GET("/:filename", minioHandler.GetFile)
-
-			// Delete file
-			// @Summary Delete a file
-			// @Description Delete a file from MinIO by its name
-			// @Tags files
-			// @Produce json
-			// @Param filename path string true "File name"
-			// @Success 200 {object} map[string]string
-			// @Router /api/v1/files/{filename} [delete]
-			files.DELETE("/:filename", minioHandler.DeleteFile)
+// Dependencies are the shared components the routes are built on. Optional
+// subsystems are nil when disabled.
+type Dependencies struct {
+	Backend        storage.Backend
+	Store          store.Store
+	Files          service.FileService
+	Quotas         *quota.Service
+	Resolver       *tenancy.Resolver
+	RolePolicy     *auth.RolePolicy
+	Audit          *audit.Recorder
+	Events         events.Publisher
+	EventStream    *events.Stream
+	Jobs           *jobs.Queue
+	Stats          *stats.Scanner
+	Analytics      *analytics.Tracker
+	Costs          *costs.Reporter
+	Search         *search.Index
+	MetaDB         *metadb.DB
+	UploadNotifier *uploadlink.Notifier
+	Presigned      *presigned.Service
+	Fetcher        *fetch.Service    // nil unless remote fetches are enabled
+	Importer       *importer.Service // nil unless imports are enabled
+	Backups        *backup.Service   // nil unless backups are enabled
+	Integrity      *integrity.Checker
+	Progress       *progress.Hub
+	Logger         *zerolog.Logger
+	Config         *config.Config
+}
+
+// SetupRoutes mounts the default route modules
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
+	if err := NewRegistry(DefaultModules()...).Mount(router, deps); err != nil {
+		deps.Logger.Fatal().Err(err).Msg("Failed to mount routes")
+	}
+}
+
+// API is what route modules share: the dependencies, the handlers used by
+// several modules and the middleware configured for route groups
+type API struct {
+	Dependencies
+
+	minio       *handlers.MinioHandler
+	shares      *handlers.ShareHandler
+	uploadLinks *handlers.UploadLinkHandler // nil unless upload links are enabled
+	fetch       *handlers.FetchHandler      // nil unless remote fetches are enabled
+	imports     *handlers.ImportHandler     // nil unless imports are enabled
+
+	// Tenant scoping for routes that touch stored objects
+	tenant []gin.HandlerFunc
+	// Checks inputs against the spec
+	validate gin.HandlerFunc
+	// Replays retried mutations carrying an Idempotency-Key; runs after auth so keys are per caller
+	idempotent gin.HandlerFunc
+	// Caps concurrency and bandwidth of uploads and downloads proxied through the
+	// API, and gives them TRANSFER_TIMEOUT in place of their module's deadline
+	transfers gin.HandlerFunc
+	// Deadlines of route modules, from ROUTE_TIMEOUTS and REQUEST_TIMEOUT
+	timeouts       map[string]time.Duration
+	requestTimeout time.Duration
+	// Upload size limit, read per request so a config reload applies it
+	maxFileSize func() int64
+
+	// Generated spec (docs/swagger.json); run `make swagger-gen` after changing annotations
+	doc    string
+	docErr error
+
+	security       *middleware.RouteSecurity
+	compression    gin.HandlerFunc
+	compressGroups map[string]bool
+	redirectGroups map[string]bool
+
+	// Deprecation headers for the v1 routes of deprecatedModules (all when
+	// empty), nil while v1 is not deprecated
+	deprecation       gin.HandlerFunc
+	deprecatedModules map[string]bool
+}
+
+func newAPI(deps Dependencies) (*API, error) {
+	cfg, logger := deps.Config, deps.Logger
+	api := &API{Dependencies: deps}
+
+	api.minio = handlers.NewMinioHandler(deps.Files, deps.Backend, deps.Store, deps.Quotas, deps.Events, deps.Presigned, deps.Progress, logger, cfg)
+	var envelope *storage.Envelope
+	if cfg.SharePasswordEncryption {
+		envelope, _ = storage.As[*storage.Envelope](deps.Backend)
+	}
+	api.shares = handlers.NewShareHandler(sharing.NewService(deps.Store, envelope), api.minio, logger)
+	if cfg.UploadLinksEnabled {
+		api.uploadLinks = handlers.NewUploadLinkHandler(uploadlink.NewService(deps.Store), deps.UploadNotifier, api.minio, logger)
+	}
+	if deps.Fetcher != nil {
+		api.fetch = handlers.NewFetchHandler(deps.Fetcher, api.minio, cfg.FetchSyncMaxSize, logger)
+	}
+	if deps.Importer != nil {
+		api.imports = handlers.NewImportHandler(deps.Importer, api.minio, logger)
+	}
+
+	if deps.Resolver.Scoped() {
+		api.tenant = append(api.tenant, middleware.TenancyMiddleware(deps.Resolver, logger))
+	}
+	security, err := middleware.ParseRouteSecurity(cfg.RouteSecurity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ROUTE_SECURITY configuration: %w", err)
+	}
+	api.security = security
+	api.maxFileSize = func() int64 { return cfg.Live().MaxFileSize }
+	api.idempotent = middleware.IdempotencyMiddleware(deps.Store, time.Duration(cfg.IdempotencyTTL)*time.Second, api.maxFileSize, logger)
+	transferLimits := throttle.New(throttle.Options{
+		MaxConcurrent:   cfg.TransferMaxConcurrent,
+		QueueTimeout:    time.Duration(cfg.TransferQueueTimeout) * time.Second,
+		RateLimit:       cfg.TransferRateLimit,
+		GlobalRateLimit: cfg.TransferGlobalRateLimit,
+	})
+	limitTransfer := middleware.TransferLimitMiddleware(transferLimits)
+	transferTimeout := time.Duration(cfg.TransferTimeout) * time.Second
+	api.transfers = func(c *gin.Context) {
+		// Set first so waiting for a transfer slot counts against the longer deadline
+		finish := middleware.Deadline(c, transferTimeout)
+		defer finish()
+		limitTransfer(c)
+	}
+	api.requestTimeout = time.Duration(cfg.RequestTimeout) * time.Second
+	if api.timeouts, err = parseRouteTimeouts(cfg.RouteTimeouts); err != nil {
+		return nil, fmt.Errorf("invalid ROUTE_TIMEOUTS configuration: %w", err)
+	}
+
+	// Apply reloaded limits to the components that copied them at startup
+	cfg.OnReload(func(s config.Settings) error {
+		transferLimits.SetRateLimits(s.TransferRateLimit, s.TransferGlobalRateLimit)
+		return nil
+	})
+
+	api.doc, api.docErr = swag.ReadDoc()
+	api.validate = func(c *gin.Context) { c.Next() }
+	if cfg.RequestValidation {
+		if api.docErr != nil {
+			logger.Fatal().Err(api.docErr).Msg("Request validation requires the generated OpenAPI docs")
+		}
+		spec, err := openapi.Load([]byte(api.doc))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid OpenAPI docs")
 		}
+		api.validate = middleware.RequestValidationMiddleware(spec, api.maxFileSize)
+	}
+
+	// Response compression for the route groups in COMPRESSION_GROUPS
+	api.compressGroups = groupSet(cfg.CompressionGroups)
+	api.compression = middleware.CompressionMiddleware(middleware.CompressionOptions{
+		Level:   cfg.CompressionLevel,
+		MinSize: cfg.CompressionMinSize,
+	})
+	// Download redirects to storage for the route groups in DOWNLOAD_REDIRECT_GROUPS
+	api.redirectGroups = groupSet(cfg.DownloadRedirectGroups)
 
-		// Bucket operations
-		buckets := v1.Group("/buckets")
-		{
-			// List buckets
-			// @Summary List all buckets
-			// @Description List all buckets in MinIO
-			// @Tags buckets
-			// @Produce json
-			// @Success 200 {array} object
-			// @Router /api/v1/buckets [get]
-			buckets.GET("", minioHandler.ListBuckets)
+	deprecatedAt, sunsetAt, err := cfg.APIV1Retirement()
+	if err != nil {
+		return nil, err
+	}
+	if !deprecatedAt.IsZero() || !sunsetAt.IsZero() {
+		api.deprecation = middleware.DeprecationMiddleware(deprecatedAt, sunsetAt, func(path string) string {
+			return "/api/v2" + strings.TrimPrefix(path, "/api/v1")
+		})
+		api.deprecatedModules = groupSet(cfg.APIV1DeprecatedModules)
+	}
+	return api, nil
+}
+
+// parseRouteTimeouts parses "module=seconds" entries
+func parseRouteTimeouts(entries []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		module, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		seconds, err := strconv.Atoi(value)
+		if !ok || err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid route timeout %q, expected module=seconds", entry)
 		}
+		timeouts[module] = time.Duration(seconds) * time.Second
 	}
+	return timeouts, nil
+}
 
-	// Health check
-	// @Summary Health check endpoint
-	// @Description Check if the API is up and running
-	// @Tags health
-	// @Produce json
-	// @Success 200 {object} map[string]string
-	// @Router /health [get]
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+func groupSet(groups []string) map[string]bool {
+	set := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		set[strings.TrimSpace(group)] = true
+	}
+	return set
+}
+
+// version marks requests with the API version they were routed to, and
+// announces the retirement of v1 routes
+func (a *API) version(version, module string) []gin.HandlerFunc {
+	handlers := []gin.HandlerFunc{func(c *gin.Context) {
+		c.Set(utils.APIVersionKey, version)
+		c.Next()
+	}}
+	if version == "v1" && a.deprecation != nil && (len(a.deprecatedModules) == 0 || a.deprecatedModules[module]) {
+		handlers = append(handlers, a.deprecation)
+	}
+	return handlers
+}
+
+// timeout applies the deadline of a route module
+func (a *API) timeout(module string) gin.HandlerFunc {
+	d, ok := a.timeouts[module]
+	if !ok {
+		d = a.requestTimeout
+	}
+	return middleware.TimeoutMiddleware(d)
+}
+
+// secure enforces the configured security level of a route group
+func (a *API) secure(group string) gin.HandlerFunc {
+	return middleware.SecurityMiddleware(a.security.Level(group), a.RolePolicy)
+}
+
+// require checks the caller holds scopes, a no-op unless RBAC is enabled
+func (a *API) require(scopes ...string) gin.HandlerFunc {
+	if !a.Config.RBACEnabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RequireScope(a.RolePolicy, scopes...)
+}
+
+func (a *API) compress(group string) gin.HandlerFunc {
+	if !a.compressGroups[group] {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return a.compression
+}
+
+func (a *API) redirect(group string) gin.HandlerFunc {
+	if !a.redirectGroups[group] {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.DownloadRedirectMiddleware()
+}
+
+// handleUnknownRoutes gives unknown routes problem responses like every other error
+func handleUnknownRoutes(router *gin.Engine) {
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(func(c *gin.Context) {
+		utils.SendError(c, http.StatusNotFound, "Route not found")
+	})
+	router.NoMethod(func(c *gin.Context) {
+		utils.SendError(c, http.StatusMethodNotAllowed, "Method not allowed")
 	})
-}
\ No newline at end of file
+}
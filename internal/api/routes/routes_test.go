@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/dedupe"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const testAPIKey = "test-key"
+
+// newTestRouter wires the real routes with the default route security. The
+// MinIO client points nowhere, so only requests stopped by middleware succeed.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	client, err := minio.New("127.0.0.1:1", &minio.Options{Creds: credentials.NewStaticV4("test", "test", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := auth.NewServiceVerifier("", []string{"reader:" + testAPIKey + ":files:read"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		MinioBucketName: "test",
+		MaxFileSize:     1 << 20,
+		TenantClaim:     "tenant_id",
+		RBACRoleScopes:  []string{"admin=*"},
+		RouteSecurity: []string{
+			"files=authenticated", "uploads=authenticated", "shares=authenticated", "usage=authenticated",
+			"buckets=admin", "admin=admin", "share_links=public",
+		},
+	}
+	logger := zerolog.Nop()
+
+	router := gin.New()
+	router.Use(utils.CorrelationIDMiddleware(), middleware.AuthMiddleware(verifier, nil, &logger))
+	SetupRoutes(router, testDependencies(t, storage.NewS3Backend(storage.ProviderMinio, client, ""), &logger, cfg))
+	return router
+}
+
+// testDependencies builds the components the routes require on backend; the
+// optional subsystems are left disabled
+func testDependencies(t *testing.T, backend storage.Backend, logger *zerolog.Logger, cfg *config.Config) Dependencies {
+	t.Helper()
+	metaStore := store.NewMemoryStore()
+	var quotas *quota.Service
+	var fileQuotas service.Quotas
+	if cfg.QuotaEnabled {
+		var err error
+		if quotas, err = quota.NewService(metaStore, cfg.QuotaDefaultBytes, cfg.QuotaOverrides); err != nil {
+			t.Fatal(err)
+		}
+		fileQuotas = quotas
+	}
+	resolver, err := tenancy.NewResolver(cfg.TenancyMode, cfg.TenantClaim, cfg.MinioBucketName, cfg.TenantBucketPrefix, cfg.HomeDirectoryPattern, cfg.HomeDirectories, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rolePolicy, err := auth.NewRolePolicy(cfg.RBACRoleScopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Dependencies{
+		Backend:    backend,
+		Store:      metaStore,
+		Files:      service.NewFiles(backend, fileQuotas, dedupe.NewRefs(metaStore), objectlock.New(state.NewMemory(), time.Second), cfg, logger),
+		Quotas:     quotas,
+		Resolver:   resolver,
+		RolePolicy: rolePolicy,
+		Progress:   progress.NewHub(),
+		Logger:     logger,
+		Config:     cfg,
+	}
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	router := newTestRouter(t)
+
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/files"},
+		{http.MethodGet, "/api/v1/files"},
+		{http.MethodGet, "/api/v1/files/report.pdf"},
+		{http.MethodHead, "/api/v1/files/report.pdf"},
+		{http.MethodDelete, "/api/v1/files/report.pdf"},
+		{http.MethodGet, "/api/v1/files/report.pdf/checksum"},
+		{http.MethodGet, "/api/v1/ws/uploads/abc"},
+		{http.MethodPost, "/api/v1/shares"},
+		{http.MethodGet, "/api/v1/shares"},
+		{http.MethodDelete, "/api/v1/shares/abc"},
+		{http.MethodGet, "/api/v1/usage"},
+		{http.MethodGet, "/api/v1/buckets"},
+	}
+	for _, r := range routes {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(r.method, r.path, nil))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestInvalidTokenRejected(t *testing.T) {
+	router := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files", nil)
+	req.Header.Set(middleware.APIKeyHeader, "wrong-key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminRoutesRejectNonAdmin(t *testing.T) {
+	router := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets", nil)
+	req.Header.Set(middleware.APIKeyHeader, testAPIKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestPublicRoutesAllowAnonymous(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("health status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Share links are public: an unknown slug is a 404, not a 401
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("share link status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// registerShares mounts share link management
+func registerShares(v gin.IRouter, api *API) {
+	// Share link management
+	shares := v.Group("/shares", api.secure("shares"), api.compress("shares"))
+	shares.Use(api.tenant...)
+	shares.Use(api.validate, api.idempotent)
+	{
+		// @Summary Create a share link
+		// @Tags shares
+		// @Router /api/v1/shares [post]
+		shares.POST("", api.require(auth.ScopeFilesWrite), api.shares.CreateShare)
+
+		// @Summary List share links
+		// @Tags shares
+		// @Router /api/v1/shares [get]
+		shares.GET("", api.require(auth.ScopeFilesRead), api.shares.ListShares)
+
+		// @Summary Get a share link
+		// @Tags shares
+		// @Router /api/v1/shares/{slug} [get]
+		shares.GET("/:slug", api.require(auth.ScopeFilesRead), api.shares.GetShare)
+
+		// @Summary Revoke a share link
+		// @Tags shares
+		// @Router /api/v1/shares/{slug} [delete]
+		shares.DELETE("/:slug", api.require(auth.ScopeFilesWrite), api.shares.RevokeShare)
+	}
+}
+
+// registerShareLinks mounts public share downloads
+func registerShareLinks(r gin.IRouter, api *API) {
+	// Public share link downloads (unauthenticated)
+	// @Summary Download a shared file
+	// @Tags shares
+	// @Router /s/{slug} [get]
+	r.GET("/s/:slug", api.secure("share_links"), api.compress("share_links"), api.redirect("share_links"), api.transfers, api.shares.DownloadShare)
+	// Password forms post the password rather than putting it in the URL
+	// @Summary Download a shared file
+	// @Tags shares
+	// @Router /s/{slug} [post]
+	r.POST("/s/:slug", api.secure("share_links"), api.compress("share_links"), api.redirect("share_links"), api.transfers, api.shares.DownloadShare)
+}
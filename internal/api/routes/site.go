@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/handlers"
+)
+
+// registerSite mounts static website hosting
+func registerSite(r gin.IRouter, api *API) {
+	siteHandler, err := handlers.NewSiteHandler(api.Backend, api.Logger, api.Config)
+	if err != nil {
+		api.Logger.Fatal().Err(err).Msg("Invalid static site configuration")
+	}
+
+	site := r.Group("/site", api.secure("site"), api.compress("site"))
+	{
+		site.GET("/*path", siteHandler.ServeSite)
+
+		site.HEAD("/*path", siteHandler.ServeSite)
+	}
+}
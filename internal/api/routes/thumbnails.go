@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// registerThumbnails mounts server-side image resizing
+func registerThumbnails(v gin.IRouter, api *API) {
+	files := api.filesGroup(v)
+	{
+		// Get image thumbnail
+		// @Summary Get an image thumbnail
+		// @Description Resize an image server-side, caching generated variants
+		// @Tags files
+		// @Produce image/jpeg,image/png,image/gif
+		// @Param filename path string true "File name"
+		// @Param w query int false "Width in pixels"
+		// @Param h query int false "Height in pixels"
+		// @Param fit query string false "Fit mode" Enums(contain, cover, fill)
+		// @Success 200 {file} binary
+		// @Router /api/v1/files/{filename}/thumbnail [get]
+		files.GET("/:filename/thumbnail", api.require(auth.ScopeFilesRead), api.minio.GetThumbnail)
+	}
+}
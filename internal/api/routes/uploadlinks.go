@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/middleware"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// registerUploadLinks mounts upload link management
+func registerUploadLinks(v gin.IRouter, api *API) {
+	// Upload link management
+	links := v.Group("/upload-links", api.secure("uploads"), api.compress("uploads"))
+	links.Use(api.tenant...)
+	links.Use(api.validate, api.idempotent)
+	{
+		// @Summary Create an upload link
+		// @Tags upload-links
+		// @Router /api/v1/upload-links [post]
+		links.POST("", api.require(auth.ScopeFilesWrite), api.uploadLinks.CreateUploadLink)
+
+		// @Summary List upload links
+		// @Tags upload-links
+		// @Router /api/v1/upload-links [get]
+		links.GET("", api.require(auth.ScopeFilesRead), api.uploadLinks.ListUploadLinks)
+
+		// @Summary Revoke an upload link
+		// @Tags upload-links
+		// @Router /api/v1/upload-links/{token} [delete]
+		links.DELETE("/:token", api.require(auth.ScopeFilesWrite), api.uploadLinks.RevokeUploadLink)
+	}
+}
+
+// registerUploadLinkPages mounts public uploads through upload links
+func registerUploadLinkPages(r gin.IRouter, api *API) {
+	// Public uploads through upload links (unauthenticated)
+	// @Summary Get upload link restrictions
+	// @Tags upload-links
+	// @Router /u/{token} [get]
+	r.GET("/u/:token", api.secure("upload_links"), api.uploadLinks.GetUploadLinkInfo)
+
+	// @Summary Upload a file through an upload link
+	// @Tags upload-links
+	// @Router /u/{token} [post]
+	r.POST("/u/:token", api.secure("upload_links"), api.transfers, middleware.BodyLimitMiddleware(api.maxFileSize), api.uploadLinks.UploadThroughLink)
+}
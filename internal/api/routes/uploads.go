@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+)
+
+// registerUploads mounts resumable upload sessions, multipart uploads,
+// presigned upload confirmation and presigned POST policies
+func registerUploads(v gin.IRouter, api *API) {
+	// Direct-to-storage uploads
+	uploads := v.Group("/uploads", api.secure("uploads"), api.compress("uploads"))
+	uploads.Use(api.tenant...)
+	uploads.Use(api.validate, api.idempotent)
+
+	// Resumable upload sessions, with chunks sent through the API
+	{
+		// @Summary Start an upload session
+		// @Tags uploads
+		// @Router /api/v1/uploads [post]
+		uploads.POST("", api.require(auth.ScopeFilesWrite), api.minio.CreateUploadSession)
+
+		// @Summary Get an upload session
+		// @Tags uploads
+		// @Router /api/v1/uploads/{upload_id} [get]
+		uploads.GET("/:upload_id", api.require(auth.ScopeFilesWrite), api.minio.GetUploadSession)
+
+		// @Summary Upload a chunk
+		// @Tags uploads
+		// @Router /api/v1/uploads/{upload_id}/chunks/{n} [put]
+		uploads.PUT("/:upload_id/chunks/:n", api.require(auth.ScopeFilesWrite), api.transfers, api.minio.UploadChunk)
+
+		// @Summary Complete an upload session
+		// @Tags uploads
+		// @Router /api/v1/uploads/{upload_id}/complete [post]
+		uploads.POST("/:upload_id/complete", api.require(auth.ScopeFilesWrite), api.minio.CompleteUploadSession)
+
+		// @Summary Abort a multipart upload
+		// @Tags uploads
+		// @Router /api/v1/uploads/{upload_id} [delete]
+		uploads.DELETE("/:upload_id", api.require(auth.ScopeFilesWrite), api.minio.AbortMultipartUpload)
+	}
+
+	// Multipart uploads
+	multipart := uploads.Group("/multipart")
+	{
+		// @Summary Start a multipart upload
+		// @Tags uploads
+		// @Router /api/v1/uploads/multipart [post]
+		multipart.POST("", api.require(auth.ScopeFilesWrite), api.minio.CreateMultipartUpload)
+
+		// @Summary Presign upload part URLs
+		// @Tags uploads
+		// @Router /api/v1/uploads/multipart/{upload_id}/parts [post]
+		multipart.POST("/:upload_id/parts", api.require(auth.ScopeFilesWrite), api.minio.PresignMultipartParts)
+
+		// @Summary List uploaded parts
+		// @Tags uploads
+		// @Router /api/v1/uploads/multipart/{upload_id}/parts [get]
+		multipart.GET("/:upload_id/parts", api.require(auth.ScopeFilesWrite), api.minio.ListMultipartParts)
+
+		// @Summary Complete a multipart upload
+		// @Tags uploads
+		// @Router /api/v1/uploads/multipart/{upload_id}/complete [post]
+		multipart.POST("/:upload_id/complete", api.require(auth.ScopeFilesWrite), api.minio.CompleteMultipartUpload)
+
+		// @Summary Abort a multipart upload
+		// @Tags uploads
+		// @Router /api/v1/uploads/multipart/{upload_id} [delete]
+		multipart.DELETE("/:upload_id", api.require(auth.ScopeFilesWrite), api.minio.AbortMultipartUpload)
+	}
+
+	// Completion of presigned PUT uploads
+	// @Summary Confirm a presigned upload
+	// @Tags uploads
+	// @Router /api/v1/uploads/confirm [post]
+	uploads.POST("/confirm", api.require(auth.ScopeFilesWrite), api.minio.ConfirmPresignedUpload)
+
+	// Presigned POST policies for form uploads
+	// @Summary Generate a presigned POST policy
+	// @Tags uploads
+	// @Router /api/v1/uploads/policy [post]
+	uploads.POST("/policy", api.require(auth.ScopeFilesWrite), api.minio.CreatePostPolicy)
+}
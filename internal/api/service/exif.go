@@ -0,0 +1,41 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/exif"
+)
+
+// processExif extracts image metadata and strips GPS data from JPEG uploads when
+// enabled. It returns the metadata to store and the (possibly rewritten) body.
+func processExif(body io.ReadSeeker, size int64, contentType string, extract, strip bool) (map[string]string, io.ReadSeeker, int64, error) {
+	if contentType != "image/jpeg" || (!extract && !strip) {
+		return nil, body, size, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	meta := map[string]string{}
+	if strip {
+		stripped, err := exif.StripGPS(data)
+		if err != nil && !errors.Is(err, exif.ErrNotJPEG) {
+			return nil, nil, 0, err
+		}
+		if stripped {
+			meta["Exif-Gps-Stripped"] = "true"
+		}
+	}
+	if extract {
+		if m, err := exif.Extract(data); err == nil {
+			for k, v := range m.MetadataHeaders() {
+				meta[k] = v
+			}
+		}
+	}
+	return meta, bytes.NewReader(data), int64(len(data)), nil
+}
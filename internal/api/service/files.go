@@ -0,0 +1,437 @@
+// Package service holds the file business rules shared by the HTTP and gRPC
+// APIs: size limits, content-type detection, checksum verification, key
+// generation, deduplication and quota accounting. Handlers translate requests
+// and errors; they don't write objects themselves.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/dedupe"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/progress"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/rs/zerolog"
+)
+
+// Errors rejecting an upload. Key collisions are reported as
+// keygen.ErrKeyExists, exhausted quotas as quota.ErrQuotaExceeded and objects
+// locked for too long by other operations as objectlock.ErrTimeout.
+var (
+	ErrTooLarge         = errors.New("file exceeds the maximum allowed size")
+	ErrUnreadable       = errors.New("failed to read file")
+	ErrChecksumMismatch = errors.New("SHA-256 checksum mismatch")
+	// ErrDigestMismatch wraps storage.ErrBadDigest, so a mismatch found by
+	// storage on the way in is reported the same
+	ErrDigestMismatch = fmt.Errorf("%w: Content-MD5 mismatch", storage.ErrBadDigest)
+)
+
+// TypeError rejects an upload whose detected content type isn't allowed
+type TypeError struct {
+	ContentType string
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("content type %s is not allowed", e.ContentType)
+}
+
+// FileService is what the APIs need to store and remove files
+type FileService interface {
+	// Upload checks and stores a file
+	Upload(ctx context.Context, req UploadRequest) (Upload, error)
+	// Delete removes an object, or drops one reference to a deduplicated
+	// object. It reports whether the object was removed.
+	Delete(ctx context.Context, scope tenancy.Scope, key string) (bool, error)
+	// ResolveKey applies a collision strategy to a sanitized name and returns
+	// the object key within the scope
+	ResolveKey(ctx context.Context, scope tenancy.Scope, name string, strategy keygen.Strategy) (string, error)
+	// Exists reports whether an object exists
+	Exists(ctx context.Context, bucket, key string) (bool, error)
+	// ReleaseQuota credits a removed or replaced object's size back to its owner
+	ReleaseQuota(ctx context.Context, stat storage.ObjectInfo) error
+	// Lock keeps other uploads, deletes and rewrites off an object until
+	// unlock is called
+	Lock(ctx context.Context, bucket, key string) (unlock func(), err error)
+}
+
+// Quotas charges stored bytes to their owners (see quota.Service)
+type Quotas interface {
+	Reserve(ctx context.Context, subject string, size int64) error
+	Release(ctx context.Context, subject string, size int64) error
+}
+
+// Refs counts the references to content-addressed objects (see dedupe.Refs)
+type Refs interface {
+	Acquire(ctx context.Context, scope tenancy.Scope, sha256, filename string, size int64) (string, int, error)
+	Release(ctx context.Context, scope tenancy.Scope, sha256 string) (bool, error)
+}
+
+// Locks serializes the changes to each object (see objectlock.Locks)
+type Locks interface {
+	Lock(ctx context.Context, bucket, key string) (unlock func(), err error)
+}
+
+// UploadRequest is one file to store and the rules it is stored under
+type UploadRequest struct {
+	Scope tenancy.Scope
+	// Dir is a sanitized folder below the scope, ending in "/"
+	Dir string
+	// Filename is the client filename, sanitized into the object name
+	Filename string
+	Body     io.ReadSeeker
+	Size     int64
+	// SHA256 is the digest the client expects, if it sent one
+	SHA256 string
+	// ContentMD5 is the hex MD5 the client expects, if it sent one. It is
+	// checked here and again by storage.
+	ContentMD5 string
+
+	// Policy is checked against the sniffed content type, and Restrict too
+	// when set. MaxSize lowers the configured size limit.
+	Policy   *filetype.Policy
+	Restrict *filetype.Policy
+	MaxSize  int64
+
+	Algorithms  []checksum.Algorithm
+	Deduplicate bool
+	Strategy    keygen.Strategy
+	// Owner is the subject charged for the upload, if quotas apply
+	Owner       string
+	ExtractExif bool
+	StripGPS    bool
+
+	// Track, if set, returns a tracker reporting the progress of the write
+	Track func(size int64) *progress.Tracker
+	// Tuning, if set, overrides how the file is sent to storage
+	Tuning *storage.PutTuning
+	// CorrelationID tags log lines
+	CorrelationID string
+}
+
+// Upload describes a stored file
+type Upload struct {
+	storage.ObjectInfo
+	Sums map[checksum.Algorithm]string
+	// Deduplicated is set when identical content was already stored
+	Deduplicated bool
+}
+
+// Files implements FileService on a storage backend
+type Files struct {
+	storage storage.Backend
+	quotas  Quotas
+	refs    Refs
+	locks   Locks
+	config  *config.Config
+	logger  *zerolog.Logger
+}
+
+// NewFiles creates a Files service. quotas may be nil when quotas are
+// disabled, and locks when objects are never changed concurrently.
+func NewFiles(backend storage.Backend, quotas Quotas, refs Refs, locks Locks, cfg *config.Config, logger *zerolog.Logger) *Files {
+	return &Files{storage: backend, quotas: quotas, refs: refs, locks: locks, config: cfg, logger: logger}
+}
+
+// Upload checks a file against the size limit, content-type policy and
+// expected checksum, charges it to the owner's quota and stores it. It is
+// safe to call concurrently.
+func (s *Files) Upload(ctx context.Context, req UploadRequest) (Upload, error) {
+	limit := s.config.Live().MaxFileSize
+	if req.MaxSize > 0 && (limit <= 0 || req.MaxSize < limit) {
+		limit = req.MaxSize
+	}
+	if limit > 0 && req.Size > limit {
+		return Upload{}, ErrTooLarge
+	}
+
+	// Enforce the content-type policy on the sniffed type, not the client-provided header
+	sniffedType, err := filetype.Sniff(req.Body, req.Filename)
+	if err != nil {
+		return Upload{}, fmt.Errorf("%w: %v", ErrUnreadable, err)
+	}
+	if !req.Policy.Allows(sniffedType) || !req.Restrict.Allows(sniffedType) {
+		return Upload{}, &TypeError{ContentType: sniffedType}
+	}
+
+	// Optionally extract EXIF/IPTC into metadata and remove location data from JPEGs.
+	// Stripping rewrites the content, so it happens before hashing.
+	exifMeta, body, size, err := processExif(req.Body, req.Size, sniffedType, req.ExtractExif, req.StripGPS)
+	if err != nil {
+		return Upload{}, fmt.Errorf("failed to process image metadata: %w", err)
+	}
+
+	// Hash the file before storing it so mismatched uploads never reach the bucket
+	algorithms := req.Algorithms
+	if req.ContentMD5 != "" && !slices.Contains(algorithms, checksum.MD5) {
+		algorithms = append(slices.Clip(algorithms), checksum.MD5)
+	}
+	sums, err := checksum.ComputeAndRewind(body, algorithms)
+	if err != nil {
+		return Upload{}, fmt.Errorf("failed to compute file checksum: %w", err)
+	}
+	if req.SHA256 != "" && !checksum.Matches(req.SHA256, sums[checksum.SHA256]) {
+		return Upload{}, ErrChecksumMismatch
+	}
+	if req.ContentMD5 != "" && !checksum.Matches(req.ContentMD5, sums[checksum.MD5]) {
+		return Upload{}, ErrDigestMismatch
+	}
+
+	// The sniffed type is stored rather than the client-provided Content-Type header
+	stored := Upload{ObjectInfo: storage.ObjectInfo{Bucket: req.Scope.Bucket, ContentType: sniffedType, Size: size}, Sums: sums}
+	// The object stays locked until it is stored, so its previous version is
+	// read, replaced and credited by one upload at a time
+	var unlock func()
+	if req.Deduplicate {
+		// Content-addressed: identical content maps to one reference-counted object
+		var exists bool
+		stored.Key, exists, unlock, err = s.acquireRef(ctx, req.Scope, sums[checksum.SHA256], keygen.Sanitize(req.Filename), size)
+		if err != nil {
+			return Upload{}, err
+		}
+		defer unlock()
+		if exists {
+			stored.Deduplicated = true
+			return stored, nil
+		}
+	} else {
+		// Generate a safe object key from the client filename
+		target := req.Scope
+		target.Prefix += req.Dir
+		if stored.Key, unlock, err = s.claimKey(ctx, target, keygen.Sanitize(req.Filename), req.Strategy); err != nil {
+			return Upload{}, err
+		}
+		defer unlock()
+	}
+
+	// Charge the upload against the owner's quota before storing anything
+	if req.Owner != "" && s.quotas != nil {
+		if err := s.quotas.Reserve(ctx, req.Owner, size); err != nil {
+			if req.Deduplicate {
+				_, _ = s.refs.Release(context.Background(), req.Scope, sums[checksum.SHA256])
+			}
+			return Upload{}, err
+		}
+	}
+	// An overwritten object's bytes are credited back to its previous owner after the upload
+	previous, err := s.storage.Stat(ctx, req.Scope.Bucket, stored.Key)
+	replacing := err == nil
+
+	putOpts := storage.PutOptions{
+		ContentType: sniffedType,
+		Tuning:      req.Tuning,
+		ContentMD5:  req.ContentMD5,
+		Metadata: map[string]string{
+			"Detected-Content-Type": sniffedType,
+		},
+	}
+	for alg, sum := range sums {
+		putOpts.Metadata[checksum.MetadataKey(alg)] = sum
+	}
+	if req.Deduplicate {
+		putOpts.Metadata[dedupe.MetadataKey] = sums[checksum.SHA256]
+	}
+	for k, v := range exifMeta {
+		putOpts.Metadata[k] = v
+	}
+	if req.Owner != "" {
+		putOpts.Metadata[quota.OwnerMetadataKey] = req.Owner
+	}
+
+	var tracker *progress.Tracker
+	if req.Track != nil {
+		tracker = req.Track(size)
+		putOpts.Progress = tracker
+	}
+
+	// A client going away mid-write must not leave a partial object
+	info, err := s.storage.Put(context.WithoutCancel(ctx), req.Scope.Bucket, stored.Key, body, size, putOpts)
+	if tracker != nil {
+		tracker.Finish(err)
+	}
+	if err != nil {
+		if req.Deduplicate {
+			if _, relErr := s.refs.Release(context.Background(), req.Scope, sums[checksum.SHA256]); relErr != nil {
+				s.logger.Error().Err(relErr).Str("correlation_id", req.CorrelationID).Msg("Failed to release dedupe reference")
+			}
+		}
+		if req.Owner != "" && s.quotas != nil {
+			if relErr := s.quotas.Release(context.Background(), req.Owner, size); relErr != nil {
+				s.logger.Error().Err(relErr).Str("correlation_id", req.CorrelationID).Msg("Failed to release quota reservation")
+			}
+		}
+		return Upload{}, err
+	}
+
+	if replacing {
+		if err := s.ReleaseQuota(ctx, previous); err != nil {
+			s.logger.Error().Err(err).Str("correlation_id", req.CorrelationID).Str("subject", previous.Metadata[quota.OwnerMetadataKey]).Msg("Failed to release quota")
+		}
+	}
+	// Backends don't all report what they stored
+	if info.ContentType == "" {
+		info.ContentType = sniffedType
+	}
+	info.Metadata = putOpts.Metadata
+	stored.ObjectInfo = info
+	return stored, nil
+}
+
+// acquireRef adds a reference for the content hash and returns the stored key,
+// whether the content is already present in the bucket, and the unlock of the
+// object. Holding the lock, the object can't be removed by the delete of its
+// last other reference, nor be checked while the first upload is storing it.
+func (s *Files) acquireRef(ctx context.Context, scope tenancy.Scope, sha256, filename string, size int64) (string, bool, func(), error) {
+	key, refs, err := s.refs.Acquire(ctx, scope, sha256, filename, size)
+	if err != nil {
+		return "", false, nil, fmt.Errorf("failed to record dedupe reference: %w", err)
+	}
+	unlock, err := s.Lock(ctx, scope.Bucket, key)
+	if err != nil {
+		if _, relErr := s.refs.Release(context.Background(), scope, sha256); relErr != nil {
+			s.logger.Error().Err(relErr).Str("key", key).Msg("Failed to release dedupe reference")
+		}
+		return "", false, nil, err
+	}
+	if refs == 1 {
+		return key, false, unlock, nil
+	}
+	// A record may exist while the first upload failed
+	stored, err := s.Exists(ctx, scope.Bucket, key)
+	if err != nil {
+		unlock()
+		return "", false, nil, err
+	}
+	return key, stored, unlock, nil
+}
+
+// claimKey resolves the key of an upload and locks it. A key found free may
+// be taken by another upload before it is locked, in which case the
+// collision strategy is applied again.
+func (s *Files) claimKey(ctx context.Context, scope tenancy.Scope, name string, strategy keygen.Strategy) (string, func(), error) {
+	for {
+		key, err := s.ResolveKey(ctx, scope, name, strategy)
+		if err != nil {
+			return "", nil, err
+		}
+		unlock, err := s.Lock(ctx, scope.Bucket, key)
+		if err != nil {
+			return "", nil, err
+		}
+		if strategy == keygen.StrategyOverwrite || strategy == keygen.StrategyUUID {
+			return key, unlock, nil
+		}
+		exists, err := s.Exists(ctx, scope.Bucket, key)
+		if err != nil {
+			unlock()
+			return "", nil, err
+		}
+		if !exists {
+			return key, unlock, nil
+		}
+		unlock()
+	}
+}
+
+// Lock locks an object against other uploads, deletes and rewrites
+func (s *Files) Lock(ctx context.Context, bucket, key string) (func(), error) {
+	if s.locks == nil {
+		return func() {}, nil
+	}
+	return s.locks.Lock(ctx, bucket, key)
+}
+
+// Delete removes an object. Content-addressed objects are only removed once
+// their last reference is deleted.
+func (s *Files) Delete(ctx context.Context, scope tenancy.Scope, key string) (bool, error) {
+	unlock, err := s.Lock(ctx, scope.Bucket, key)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	stat, err := s.storage.Stat(ctx, scope.Bucket, key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return false, err
+	}
+
+	if ref := stat.Metadata[dedupe.MetadataKey]; ref != "" {
+		unreferenced, err := s.refs.Release(ctx, scope, ref)
+		if err != nil {
+			return false, fmt.Errorf("failed to release dedupe reference: %w", err)
+		}
+		if !unreferenced {
+			return false, nil
+		}
+	}
+
+	if err := s.storage.Remove(context.WithoutCancel(ctx), scope.Bucket, key); err != nil {
+		return false, err
+	}
+	if err := s.ReleaseQuota(ctx, stat); err != nil {
+		// The object is gone; a failed credit only overstates usage
+		s.logger.Error().Err(err).Str("bucket", scope.Bucket).Str("key", key).Msg("Failed to release quota")
+	}
+	return true, nil
+}
+
+// maxSuffixAttempts bounds the " (n)" search for a free key
+const maxSuffixAttempts = 1000
+
+// ResolveKey applies the collision strategy to a sanitized name and returns the
+// final object key within the scope
+func (s *Files) ResolveKey(ctx context.Context, scope tenancy.Scope, name string, strategy keygen.Strategy) (string, error) {
+	switch strategy {
+	case keygen.StrategyOverwrite:
+		return scope.Key(name), nil
+	case keygen.StrategyUUID:
+		return scope.Key(keygen.WithUUID(name)), nil
+	}
+
+	exists, err := s.Exists(ctx, scope.Bucket, scope.Key(name))
+	if err != nil || !exists {
+		return scope.Key(name), err
+	}
+	if strategy == keygen.StrategyReject {
+		return "", keygen.ErrKeyExists
+	}
+
+	for n := 1; n <= maxSuffixAttempts; n++ {
+		candidate := keygen.WithSuffix(name, n)
+		exists, err := s.Exists(ctx, scope.Bucket, scope.Key(candidate))
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return scope.Key(candidate), nil
+		}
+	}
+	return scope.Key(keygen.WithUUID(name)), nil
+}
+
+// Exists reports whether an object with the given key exists in the bucket
+func (s *Files) Exists(ctx context.Context, bucket, key string) (bool, error) {
+	_, err := s.storage.Stat(ctx, bucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseQuota credits a removed or replaced object's size back to its owner
+func (s *Files) ReleaseQuota(_ context.Context, stat storage.ObjectInfo) error {
+	owner := stat.Metadata[quota.OwnerMetadataKey]
+	if owner == "" || s.quotas == nil {
+		return nil
+	}
+	// Credits must land even when the request that caused them is cancelled
+	return s.quotas.Release(context.Background(), owner, stat.Size)
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/dedupe"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/rs/zerolog"
+)
+
+// fakeBackend keeps objects in memory. Methods the service doesn't use panic
+// through the nil embedded Backend.
+type fakeBackend struct {
+	storage.Backend
+	mu      sync.Mutex
+	objects map[string]storage.ObjectInfo
+	putErr  error
+	puts    int
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{objects: make(map[string]storage.ObjectInfo)}
+}
+
+func (b *fakeBackend) Stat(_ context.Context, bucket, key string) (storage.ObjectInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info, ok := b.objects[bucket+"/"+key]
+	if !ok {
+		return storage.ObjectInfo{}, storage.ErrNotFound
+	}
+	return info, nil
+}
+
+func (b *fakeBackend) Put(_ context.Context, bucket, key string, r io.Reader, size int64, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	if b.putErr != nil {
+		return storage.ObjectInfo{}, b.putErr
+	}
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.puts++
+	info := storage.ObjectInfo{Bucket: bucket, Key: key, Size: n, ContentType: opts.ContentType, ETag: "etag", Metadata: opts.Metadata}
+	b.objects[bucket+"/"+key] = info
+	return info, nil
+}
+
+func (b *fakeBackend) Remove(_ context.Context, bucket, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.objects, bucket+"/"+key)
+	return nil
+}
+
+type fakeQuotas struct {
+	limit int64
+	used  map[string]int64
+}
+
+func (q *fakeQuotas) Reserve(_ context.Context, subject string, size int64) error {
+	if q.limit > 0 && q.used[subject]+size > q.limit {
+		return quota.ErrQuotaExceeded
+	}
+	q.used[subject] += size
+	return nil
+}
+
+func (q *fakeQuotas) Release(_ context.Context, subject string, size int64) error {
+	q.used[subject] -= size
+	return nil
+}
+
+type fakeRefs struct {
+	counts map[string]int
+}
+
+func (r *fakeRefs) Acquire(_ context.Context, _ tenancy.Scope, sha256, filename string, _ int64) (string, int, error) {
+	r.counts[sha256]++
+	return dedupe.ObjectKey(sha256, filename), r.counts[sha256], nil
+}
+
+func (r *fakeRefs) Release(_ context.Context, _ tenancy.Scope, sha256 string) (bool, error) {
+	r.counts[sha256]--
+	return r.counts[sha256] <= 0, nil
+}
+
+type fixture struct {
+	backend *fakeBackend
+	quotas  *fakeQuotas
+	refs    *fakeRefs
+	files   *Files
+}
+
+func newFixture(maxFileSize int64) *fixture {
+	logger := zerolog.Nop()
+	f := &fixture{
+		backend: newFakeBackend(),
+		quotas:  &fakeQuotas{used: make(map[string]int64)},
+		refs:    &fakeRefs{counts: make(map[string]int)},
+	}
+	locks := objectlock.New(state.NewMemory(), 50*time.Millisecond)
+	f.files = NewFiles(f.backend, f.quotas, f.refs, locks, &config.Config{MaxFileSize: maxFileSize}, &logger)
+	return f
+}
+
+var testScope = tenancy.Scope{Bucket: "files"}
+
+func textUpload(name, content string) UploadRequest {
+	return UploadRequest{
+		Scope:      testScope,
+		Filename:   name,
+		Body:       strings.NewReader(content),
+		Size:       int64(len(content)),
+		Algorithms: []checksum.Algorithm{checksum.SHA256},
+		Strategy:   keygen.StrategyOverwrite,
+	}
+}
+
+func TestUploadRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*UploadRequest)
+		want   func(error) bool
+	}{
+		{"over the size limit", func(r *UploadRequest) {
+			r.Body, r.Size = bytes.NewReader(make([]byte, 11)), 11
+		}, func(err error) bool { return errors.Is(err, ErrTooLarge) }},
+		{"over the request limit", func(r *UploadRequest) { r.MaxSize = 2 },
+			func(err error) bool { return errors.Is(err, ErrTooLarge) }},
+		{"disallowed type", func(r *UploadRequest) { r.Policy = filetype.NewPolicy([]string{"image/*"}, nil) },
+			func(err error) bool { var e *TypeError; return errors.As(err, &e) && e.ContentType == "text/plain" }},
+		{"restricted type", func(r *UploadRequest) { r.Restrict = filetype.NewPolicy(nil, []string{"text/*"}) },
+			func(err error) bool { var e *TypeError; return errors.As(err, &e) }},
+		{"checksum mismatch", func(r *UploadRequest) { r.SHA256 = strings.Repeat("0", 64) },
+			func(err error) bool { return errors.Is(err, ErrChecksumMismatch) }},
+		{"Content-MD5 mismatch", func(r *UploadRequest) { r.ContentMD5 = strings.Repeat("0", 32) },
+			func(err error) bool { return errors.Is(err, storage.ErrBadDigest) }},
+		{"quota exceeded", func(r *UploadRequest) { r.Owner = "over" },
+			func(err error) bool { return errors.Is(err, quota.ErrQuotaExceeded) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(10)
+			f.quotas.limit = 3
+			req := textUpload("a.txt", "hello")
+			tt.modify(&req)
+			if _, err := f.files.Upload(context.Background(), req); !tt.want(err) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if f.backend.puts != 0 {
+				t.Fatal("rejected upload was stored")
+			}
+		})
+	}
+}
+
+func TestUploadStoresSniffedTypeAndChecksums(t *testing.T) {
+	f := newFixture(0)
+	req := textUpload("notes.txt", "hello")
+	req.Owner = "u1"
+	upload, err := f.files.Upload(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upload.Key != "notes.txt" || upload.ContentType != "text/plain" || upload.Size != 5 {
+		t.Fatalf("unexpected upload %+v", upload)
+	}
+	sum := upload.Sums[checksum.SHA256]
+	if sum == "" || upload.Metadata[checksum.MetadataKey(checksum.SHA256)] != sum {
+		t.Fatalf("checksum not recorded: %+v", upload.Metadata)
+	}
+	if upload.Metadata[quota.OwnerMetadataKey] != "u1" || f.quotas.used["u1"] != 5 {
+		t.Fatalf("upload not charged to its owner: %v", f.quotas.used)
+	}
+}
+
+func TestUploadCollisionStrategies(t *testing.T) {
+	tests := []struct {
+		strategy keygen.Strategy
+		want     string
+		err      error
+	}{
+		{keygen.StrategyOverwrite, "docs/a.txt", nil},
+		{keygen.StrategySuffix, "docs/a (1).txt", nil},
+		{keygen.StrategyReject, "", keygen.ErrKeyExists},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			f := newFixture(0)
+			f.backend.objects["files/docs/a.txt"] = storage.ObjectInfo{Key: "docs/a.txt"}
+			req := textUpload("a.txt", "hello")
+			req.Dir = "docs/"
+			req.Strategy = tt.strategy
+			upload, err := f.files.Upload(context.Background(), req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if upload.Key != tt.want {
+				t.Fatalf("got key %q, want %q", upload.Key, tt.want)
+			}
+		})
+	}
+}
+
+// Of concurrent uploads to the same free name, one gets it and the others
+// see it taken
+func TestConcurrentUploadsClaimKeysOnce(t *testing.T) {
+	f := newFixture(0)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored, rejected := 0, 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := textUpload("a.txt", "hello")
+			req.Strategy = keygen.StrategyReject
+			_, err := f.files.Upload(context.Background(), req)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				stored++
+			case errors.Is(err, keygen.ErrKeyExists):
+				rejected++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if stored != 1 || rejected != 7 || f.backend.puts != 1 {
+		t.Fatalf("stored %d, rejected %d, puts %d; want 1, 7, 1", stored, rejected, f.backend.puts)
+	}
+}
+
+// A change to an object held by another operation fails once the lock
+// timeout passes
+func TestLockedObjectTimesOut(t *testing.T) {
+	f := newFixture(0)
+	f.backend.objects["files/a.txt"] = storage.ObjectInfo{Key: "a.txt"}
+	unlock, err := f.files.Lock(context.Background(), "files", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.files.Delete(context.Background(), testScope, "a.txt"); !errors.Is(err, objectlock.ErrTimeout) {
+		t.Fatalf("got error %v, want %v", err, objectlock.ErrTimeout)
+	}
+	unlock()
+	if removed, err := f.files.Delete(context.Background(), testScope, "a.txt"); err != nil || !removed {
+		t.Fatalf("removed=%v err=%v after unlock", removed, err)
+	}
+}
+
+func TestUploadReplacingCreditsPreviousOwner(t *testing.T) {
+	f := newFixture(0)
+	f.quotas.used["old"] = 100
+	f.backend.objects["files/a.txt"] = storage.ObjectInfo{Key: "a.txt", Size: 40, Metadata: map[string]string{quota.OwnerMetadataKey: "old"}}
+	req := textUpload("a.txt", "hello")
+	req.Owner = "new"
+	if _, err := f.files.Upload(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if f.quotas.used["old"] != 60 || f.quotas.used["new"] != 5 {
+		t.Fatalf("unexpected usage %v", f.quotas.used)
+	}
+}
+
+func TestUploadFailureReleasesReservation(t *testing.T) {
+	f := newFixture(0)
+	f.backend.putErr = errors.New("storage down")
+	req := textUpload("a.txt", "hello")
+	req.Owner = "u1"
+	req.Deduplicate = true
+	if _, err := f.files.Upload(context.Background(), req); err == nil {
+		t.Fatal("expected the storage error")
+	}
+	if f.quotas.used["u1"] != 0 {
+		t.Fatalf("reservation kept: %v", f.quotas.used)
+	}
+	for sum, n := range f.refs.counts {
+		if n != 0 {
+			t.Fatalf("reference to %s kept", sum)
+		}
+	}
+}
+
+func TestDeduplicatedUploadAndDelete(t *testing.T) {
+	f := newFixture(0)
+	ctx := context.Background()
+	req := textUpload("a.txt", "hello")
+	req.Deduplicate = true
+	first, err := f.files.Upload(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = textUpload("b.txt", "hello")
+	req.Deduplicate = true
+	second, err := f.files.Upload(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Deduplicated || !second.Deduplicated || second.Key != first.Key || f.backend.puts != 1 {
+		t.Fatalf("content not deduplicated: %+v %+v", first, second)
+	}
+
+	removed, err := f.files.Delete(ctx, testScope, first.Key)
+	if err != nil || removed {
+		t.Fatalf("first delete: removed=%v err=%v, want the object kept", removed, err)
+	}
+	removed, err = f.files.Delete(ctx, testScope, first.Key)
+	if err != nil || !removed {
+		t.Fatalf("last delete: removed=%v err=%v", removed, err)
+	}
+	if exists, _ := f.files.Exists(ctx, testScope.Bucket, first.Key); exists {
+		t.Fatal("object still stored after its last reference was deleted")
+	}
+}
+
+func TestDeleteCreditsOwner(t *testing.T) {
+	f := newFixture(0)
+	f.quotas.used["u1"] = 10
+	f.backend.objects["files/a.txt"] = storage.ObjectInfo{Key: "a.txt", Size: 10, Metadata: map[string]string{quota.OwnerMetadataKey: "u1"}}
+	if removed, err := f.files.Delete(context.Background(), testScope, "a.txt"); err != nil || !removed {
+		t.Fatalf("removed=%v err=%v", removed, err)
+	}
+	if f.quotas.used["u1"] != 0 {
+		t.Fatalf("owner not credited: %v", f.quotas.used)
+	}
+}
@@ -0,0 +1,243 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// ContentType is the media type of error responses (RFC 7807)
+const ContentType = "application/problem+json"
+
+// correlationIDKey and requestIDKey match utils.CorrelationIDKey and
+// utils.RequestIDKey; utils depends on this package
+const (
+	correlationIDKey = "CorrelationID"
+	requestIDKey     = "RequestID"
+)
+
+// ErrRequestTimeout is the cause of a request context cancelled by the
+// request's deadline (middleware.TimeoutMiddleware)
+var ErrRequestTimeout = errors.New("request deadline exceeded")
+
+// Code is a stable, machine-readable error code
+type Code string
+
+// Error codes. Clients should switch on these rather than on messages.
+const (
+	CodeInvalidRequest       Code = "INVALID_REQUEST"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeFileNotFound         Code = "FILE_NOT_FOUND"
+	CodeBucketMissing        Code = "BUCKET_MISSING"
+	CodeBucketExists         Code = "BUCKET_EXISTS"
+	CodeBucketNotEmpty       Code = "BUCKET_NOT_EMPTY"
+	CodeMethodNotAllowed     Code = "METHOD_NOT_ALLOWED"
+	CodeConflict             Code = "CONFLICT"
+	CodeObjectLocked         Code = "OBJECT_LOCKED"
+	CodeGone                 Code = "GONE"
+	CodeUnprocessable        Code = "UNPROCESSABLE"
+	CodeIdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeRequestInProgress    Code = "REQUEST_IN_PROGRESS"
+	CodePreconditionFailed   Code = "PRECONDITION_FAILED"
+	CodeFileTooLarge         Code = "FILE_TOO_LARGE"
+	CodeBadDigest            Code = "BAD_DIGEST"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRangeNotSatisfiable  Code = "RANGE_NOT_SATISFIABLE"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeQuotaExceeded        Code = "QUOTA_EXCEEDED"
+	CodeNotImplemented       Code = "NOT_IMPLEMENTED"
+	CodeStorageUnavailable   Code = "STORAGE_UNAVAILABLE"
+	CodeRemoteFailed         Code = "REMOTE_FAILED"
+	CodeServiceUnavailable   Code = "SERVICE_UNAVAILABLE"
+	CodeDeadlineExceeded     Code = "DEADLINE_EXCEEDED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+)
+
+// Problem is an RFC 7807 problem details body with the error code, correlation
+// ID and request ID as extension members
+type Problem struct {
+	Type          string `json:"type" example:"about:blank"`
+	Title         string `json:"title" example:"Not Found"`
+	Status        int    `json:"status" example:"404"`
+	Detail        string `json:"detail,omitempty" example:"File not found"`
+	Instance      string `json:"instance,omitempty" example:"/api/v1/files/report.pdf"`
+	Code          Code   `json:"code" example:"FILE_NOT_FOUND"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	RequestID     string `json:"request_id,omitempty"`
+	// Errors lists the invalid inputs of a VALIDATION_FAILED problem
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid request input
+type FieldError struct {
+	Field   string `json:"field" example:"limit"`
+	In      string `json:"in" example:"query"`
+	Message string `json:"message" example:"must be an integer"`
+}
+
+// Write sends a problem response and aborts the request
+func Write(c *gin.Context, status int, code Code, detail string) {
+	write(c, newProblem(c, status, code, detail))
+}
+
+// WriteInvalid sends a 400 VALIDATION_FAILED problem listing the invalid inputs
+func WriteInvalid(c *gin.Context, errs []FieldError) {
+	problem := newProblem(c, http.StatusBadRequest, CodeValidationFailed, "Request validation failed")
+	problem.Errors = errs
+	write(c, problem)
+}
+
+func newProblem(c *gin.Context, status int, code Code, detail string) Problem {
+	correlationID, _ := c.Get(correlationIDKey)
+	correlationIDStr, _ := correlationID.(string)
+	return Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      c.Request.URL.Path,
+		Code:          code,
+		CorrelationID: correlationIDStr,
+		RequestID:     c.GetString(requestIDKey),
+	}
+}
+
+func write(c *gin.Context, problem Problem) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// Send maps err to a status and code and sends it. Errors without a mapping
+// become a 500 with the given detail, so internal errors never reach clients.
+func Send(c *gin.Context, err error, detail string) {
+	status, code, mapped := FromError(err)
+	if errors.Is(err, context.DeadlineExceeded) && errors.Is(context.Cause(c.Request.Context()), ErrRequestTimeout) {
+		status, code = http.StatusGatewayTimeout, CodeDeadlineExceeded
+	}
+	if mapped && status != http.StatusInternalServerError {
+		detail = http.StatusText(status)
+		switch code {
+		case CodeFileNotFound:
+			detail = "File not found"
+		case CodeBucketMissing:
+			detail = "Bucket not found"
+		case CodeBucketExists:
+			detail = "Bucket already exists"
+		case CodeBucketNotEmpty:
+			detail = "Bucket is not empty"
+		case CodeStorageUnavailable:
+			detail = "Storage is temporarily unavailable"
+		case CodeDeadlineExceeded:
+			detail = "The request did not complete in time"
+		case CodeObjectLocked:
+			detail = "The file is being changed by another request, try again"
+		}
+	}
+	if status >= http.StatusInternalServerError && status != http.StatusNotImplemented {
+		// Keep the cause for the request log and error reporting
+		_ = c.Error(err)
+	}
+	Write(c, status, code, detail)
+}
+
+// FromError maps storage and S3 errors to an HTTP status and code. ok is false
+// for errors it doesn't recognize, which map to 500.
+func FromError(err error) (status int, code Code, ok bool) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound, CodeFileNotFound, true
+	case errors.Is(err, storage.ErrBucketNotFound):
+		return http.StatusNotFound, CodeBucketMissing, true
+	case errors.Is(err, storage.ErrBucketExists):
+		return http.StatusConflict, CodeBucketExists, true
+	case errors.Is(err, storage.ErrBucketNotEmpty):
+		return http.StatusConflict, CodeBucketNotEmpty, true
+	case errors.Is(err, storage.ErrInvalidBucket), errors.Is(err, storage.ErrInvalidToken):
+		return http.StatusBadRequest, CodeInvalidRequest, true
+	case errors.Is(err, storage.ErrBadDigest):
+		return http.StatusBadRequest, CodeBadDigest, true
+	case errors.Is(err, storage.ErrCustomerKeyRequired):
+		return http.StatusBadRequest, CodeInvalidRequest, true
+	case errors.Is(err, storage.ErrNotSupported):
+		return http.StatusNotImplemented, CodeNotImplemented, true
+	case errors.Is(err, objectlock.ErrTimeout):
+		return http.StatusConflict, CodeObjectLocked, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, CodeStorageUnavailable, true
+	}
+
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchVersion":
+		return http.StatusNotFound, CodeFileNotFound, true
+	case "NoSuchBucket":
+		return http.StatusNotFound, CodeBucketMissing, true
+	case "AccessDenied", "AllAccessDisabled":
+		return http.StatusForbidden, CodeForbidden, true
+	case "InvalidRange":
+		return http.StatusRequestedRangeNotSatisfiable, CodeRangeNotSatisfiable, true
+	case "PreconditionFailed":
+		return http.StatusPreconditionFailed, CodePreconditionFailed, true
+	case "BadDigest":
+		return http.StatusBadRequest, CodeBadDigest, true
+	case "EntityTooLarge":
+		return http.StatusRequestEntityTooLarge, CodeFileTooLarge, true
+	case "SlowDown", "ServiceUnavailable", "XMinioServerNotInitialized", "InternalError":
+		return http.StatusServiceUnavailable, CodeStorageUnavailable, true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return http.StatusServiceUnavailable, CodeStorageUnavailable, true
+	}
+	return http.StatusInternalServerError, CodeInternal, false
+}
+
+// CodeForStatus returns the generic code for a status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusRequestEntityTooLarge:
+		return CodeFileTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusRequestedRangeNotSatisfiable:
+		return CodeRangeNotSatisfiable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusInsufficientStorage:
+		return CodeQuotaExceeded
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeDeadlineExceeded
+	}
+	return CodeInternal
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Event is a single audited operation
+type Event struct {
+	ID            string            `json:"id"`
+	Time          time.Time         `json:"time"`
+	Actor         string            `json:"actor"`
+	AuthMethod    string            `json:"authMethod,omitempty"`
+	Operation     string            `json:"operation"`
+	Bucket        string            `json:"bucket,omitempty"`
+	Key           string            `json:"key,omitempty"`
+	Status        int               `json:"status"`
+	ClientIP      string            `json:"clientIp,omitempty"`
+	UserAgent     string            `json:"userAgent,omitempty"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	Details       map[string]string `json:"details,omitempty"`
+}
+
+// Filter narrows an audit query. Zero values match everything.
+type Filter struct {
+	Actor     string
+	Operation string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+const (
+	eventPrefix = "events/"
+	// maxQueryDays bounds how many daily partitions one query scans
+	maxQueryDays = 92
+	queueSize    = 1024
+)
+
+// Recorder persists audit events asynchronously so auditing never adds latency to requests
+type Recorder struct {
+	store  store.Store
+	logger *zerolog.Logger
+	queue  chan Event
+	wg     sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder writing to s and starts its background writer
+func NewRecorder(s store.Store, logger *zerolog.Logger) *Recorder {
+	r := &Recorder{
+		store:  s,
+		logger: logger,
+		queue:  make(chan Event, queueSize),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r
+}
+
+// eventKey partitions events by day so time-range queries only list relevant prefixes
+func eventKey(e Event) string {
+	return fmt.Sprintf("%s%s/%s-%s", eventPrefix, e.Time.Format("2006/01/02"), e.Time.Format("150405.000000000"), e.ID)
+}
+
+// Record queues an event. If the queue is full the event is written synchronously
+// rather than dropped, applying backpressure instead of losing audit data.
+func (r *Recorder) Record(e Event) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	select {
+	case r.queue <- e:
+	default:
+		r.write(e)
+	}
+}
+
+func (r *Recorder) run() {
+	defer r.wg.Done()
+	for e := range r.queue {
+		r.write(e)
+	}
+}
+
+func (r *Recorder) write(e Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.store.Put(ctx, eventKey(e), e); err != nil {
+		r.logger.Error().Err(err).Str("operation", e.Operation).Str("actor", e.Actor).Msg("Failed to persist audit event")
+	}
+}
+
+// Close flushes queued events and stops the background writer
+func (r *Recorder) Close() {
+	close(r.queue)
+	r.wg.Wait()
+}
+
+// Query returns events matching the filter, newest first
+func (r *Recorder) Query(ctx context.Context, f Filter) ([]Event, error) {
+	to := f.To
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	from := f.From
+	if from.IsZero() || to.Sub(from) > maxQueryDays*24*time.Hour {
+		from = to.Add(-maxQueryDays * 24 * time.Hour)
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	var events []Event
+	// Walk day partitions from newest to oldest until the limit is reached
+	for day := truncateDay(to); !day.Before(truncateDay(from)) && len(events) < limit; day = day.AddDate(0, 0, -1) {
+		keys, err := r.store.List(ctx, eventPrefix+day.Format("2006/01/02")+"/")
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+		for _, key := range keys {
+			var e Event
+			if err := r.store.Get(ctx, key, &e); err != nil {
+				continue
+			}
+			if e.Time.Before(from) || e.Time.After(to) || !f.matches(e) {
+				continue
+			}
+			events = append(events, e)
+			if len(events) >= limit {
+				break
+			}
+		}
+	}
+	return events, nil
+}
+
+func (f Filter) matches(e Event) bool {
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Operation != "" && !strings.EqualFold(e.Operation, f.Operation) {
+		return false
+	}
+	return true
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
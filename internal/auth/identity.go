@@ -0,0 +1,34 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// IdentityKey is the gin context key holding the caller's *Identity
+const IdentityKey = "Identity"
+
+// Identity describes an authenticated caller
+type Identity struct {
+	Subject string                 `json:"subject"`
+	Email   string                 `json:"email,omitempty"`
+	Method  string                 `json:"method"` // "bearer", "oidc", "api_key" or "signature"
+	Roles   []string               `json:"roles,omitempty"`
+	Scopes  []string               `json:"scopes,omitempty"`
+	Claims  map[string]interface{} `json:"claims,omitempty"`
+}
+
+// IdentityFrom returns the caller identity set by the auth middleware, or nil for anonymous requests
+func IdentityFrom(c *gin.Context) *Identity {
+	v, ok := c.Get(IdentityKey)
+	if !ok {
+		return nil
+	}
+	identity, _ := v.(*Identity)
+	return identity
+}
+
+// SubjectFrom returns the caller's subject or "anonymous"
+func SubjectFrom(c *gin.Context) string {
+	if identity := IdentityFrom(c); identity != nil && identity.Subject != "" {
+		return identity.Subject
+	}
+	return "anonymous"
+}
@@ -0,0 +1,440 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oidcLeeway tolerates clock drift between the API and identity providers
+	oidcLeeway = time.Minute
+	// oidcKeysTTL is how long an issuer's signing keys are used before they
+	// are fetched again
+	oidcKeysTTL = time.Hour
+	// oidcRefreshInterval bounds how often a token signed with an unknown key
+	// makes the keys be fetched again
+	oidcRefreshInterval = time.Minute
+)
+
+// OIDCOptions configures an OIDCVerifier
+type OIDCOptions struct {
+	// Issuers are the accepted issuer URLs, matched exactly against the iss
+	// claim; their signing keys are found through OpenID Connect discovery
+	Issuers []string
+	// Audiences are the accepted aud values; tokens need one of them
+	Audiences []string
+	// RoleClaims name the claims holding roles. Nested claims are written as
+	// paths, such as realm_access.roles in Keycloak tokens.
+	RoleClaims []string
+	// RoleMapping entries "value=role1 role2" turn values of the role claims
+	// into roles; values without an entry are roles as they are
+	RoleMapping []string
+}
+
+// OIDCVerifier validates bearer tokens issued by OpenID Connect providers,
+// such as Keycloak or Auth0, with the signing keys they publish
+type OIDCVerifier struct {
+	issuers   map[string]*oidcIssuer
+	audiences []string
+	claims    []string
+	mapping   map[string][]string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// oidcIssuer holds the signing keys of an issuer, fetched on first use
+type oidcIssuer struct {
+	url string
+
+	mu      sync.Mutex
+	jwksURI string
+	keys    map[string]jwk
+	fetched time.Time
+}
+
+// NewOIDCVerifier creates a verifier for the issuers of opts. Discovery
+// happens when the first token of an issuer is verified.
+func NewOIDCVerifier(opts OIDCOptions) (*OIDCVerifier, error) {
+	if len(opts.Issuers) == 0 {
+		return nil, errors.New("no OIDC issuers configured")
+	}
+	if len(opts.Audiences) == 0 {
+		return nil, errors.New("no OIDC audiences configured")
+	}
+	v := &OIDCVerifier{
+		issuers:   make(map[string]*oidcIssuer, len(opts.Issuers)),
+		audiences: opts.Audiences,
+		claims:    opts.RoleClaims,
+		mapping:   make(map[string][]string, len(opts.RoleMapping)),
+		client:    &http.Client{Timeout: 5 * time.Second},
+		now:       time.Now,
+	}
+	for _, issuer := range opts.Issuers {
+		issuer = strings.TrimSpace(issuer)
+		if !strings.HasPrefix(issuer, "https://") && !strings.HasPrefix(issuer, "http://") {
+			return nil, fmt.Errorf("invalid OIDC issuer %q, expected an http:// or https:// URL", issuer)
+		}
+		v.issuers[issuer] = &oidcIssuer{url: issuer}
+	}
+	for _, entry := range opts.RoleMapping {
+		value, roles, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid OIDC role mapping %q, expected value=role1 role2", entry)
+		}
+		v.mapping[value] = append(v.mapping[value], strings.Fields(roles)...)
+	}
+	return v, nil
+}
+
+// VerifyToken validates the signature, issuer, audience and lifetime of a
+// token and returns its identity. Tokens that fail any check are rejected
+// with ErrInvalidCredentials; other errors mean the issuer couldn't be reached.
+func (v *OIDCVerifier) VerifyToken(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCredentials
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	var claims map[string]interface{}
+	if decodeSegment(parts[0], &header) != nil || decodeSegment(parts[1], &claims) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	// The issuer is only trusted once the signature checks out with its keys
+	issuerURL, _ := claims["iss"].(string)
+	issuer, ok := v.issuers[issuerURL]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	key, err := issuer.key(ctx, v.client, header.Kid, header.Alg, v.now())
+	if err != nil {
+		return nil, err
+	}
+	if !verifyJWS(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrInvalidCredentials
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Subject: firstString(claims, "sub"),
+		Email:   firstString(claims, "email"),
+		Method:  "oidc",
+		Roles:   v.roles(claims),
+		Scopes:  stringList(claims, "scope", "scp", "permissions"),
+		Claims:  claims,
+	}
+	if identity.Subject == "" {
+		return nil, ErrInvalidCredentials
+	}
+	return identity, nil
+}
+
+// checkClaims checks a token's audience and lifetime
+func (v *OIDCVerifier) checkClaims(claims map[string]interface{}) error {
+	if !slices.ContainsFunc(listValue(claims["aud"]), func(aud string) bool { return slices.Contains(v.audiences, aud) }) {
+		return ErrInvalidCredentials
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcLeeway)) {
+		return ErrInvalidCredentials
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// roles reads the role claims of a token and applies the role mapping
+func (v *OIDCVerifier) roles(claims map[string]interface{}) []string {
+	var roles []string
+	for _, claim := range v.claims {
+		for _, value := range listValue(claimValue(claims, claim)) {
+			if mapped, ok := v.mapping[value]; ok {
+				roles = append(roles, mapped...)
+			} else {
+				roles = append(roles, value)
+			}
+		}
+	}
+	return roles
+}
+
+// claimValue looks up a claim by name or, failing that, by a dotted path
+// through nested objects. Names are tried first since namespaced claims,
+// such as Auth0's, are URLs with dots in them.
+func claimValue(claims map[string]interface{}, name string) interface{} {
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	return value
+}
+
+// key returns the issuer's signing key for a token, fetching the keys when
+// they are stale or the token names a key they don't have
+func (i *oidcIssuer) key(ctx context.Context, client *http.Client, kid, alg string, now time.Time) (crypto.PublicKey, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	stale := now.Sub(i.fetched) > oidcKeysTTL
+	k, found := i.find(kid, alg)
+	if stale || !found && now.Sub(i.fetched) > oidcRefreshInterval {
+		if err := i.fetch(ctx, client); err != nil {
+			if found {
+				// Keep using the keys known while the issuer is unreachable
+				return k.key, nil
+			}
+			return nil, err
+		}
+		i.fetched = now
+		k, found = i.find(kid, alg)
+	}
+	if !found {
+		return nil, ErrInvalidCredentials
+	}
+	return k.key, nil
+}
+
+// find returns the key named kid or, for tokens that don't name one, the
+// only key usable with alg
+func (i *oidcIssuer) find(kid, alg string) (jwk, bool) {
+	if kid != "" {
+		k, ok := i.keys[kid]
+		return k, ok && k.usableWith(alg)
+	}
+	var match jwk
+	matches := 0
+	for _, k := range i.keys {
+		if k.usableWith(alg) {
+			match = k
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// fetch reads the issuer's discovery document, then its key set
+func (i *oidcIssuer) fetch(ctx context.Context, client *http.Client) error {
+	if i.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := getJSON(ctx, client, strings.TrimSuffix(i.url, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("OIDC discovery of %s failed: %w", i.url, err)
+		}
+		if discovery.Issuer != i.url || discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC discovery document of %s names issuer %q and keys %q", i.url, discovery.Issuer, discovery.JWKSURI)
+		}
+		i.jwksURI = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := getJSON(ctx, client, i.jwksURI, &set); err != nil {
+		return fmt.Errorf("failed to fetch the signing keys of %s: %w", i.url, err)
+	}
+	keys := make(map[string]jwk, len(set.Keys))
+	for n, raw := range set.Keys {
+		// Keys of unsupported types are skipped; tokens signed with them fail
+		k, err := parseJWK(raw)
+		if err != nil {
+			continue
+		}
+		if k.kid == "" {
+			k.kid = fmt.Sprintf("#%d", n)
+		}
+		keys[k.kid] = k
+	}
+	i.keys = keys
+	return nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jwk is a public signing key of a JSON Web Key Set
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// usableWith reports whether the key can verify signatures of alg
+func (k jwk) usableWith(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+func parseJWK(raw json.RawMessage) (jwk, error) {
+	var k struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &k); err != nil {
+		return jwk{}, err
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return jwk{}, errors.New("not a signing key")
+	}
+	out := jwk{kid: k.Kid, alg: k.Alg}
+	switch k.Kty {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return jwk{}, errors.New("invalid RSA key")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 || pub.E < 3 {
+			return jwk{}, errors.New("RSA key too weak")
+		}
+		out.key = pub
+	case "EC":
+		var curve elliptic.Curve
+		var check ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, check = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, check = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, check = elliptic.P521(), ecdh.P521()
+		default:
+			return jwk{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return jwk{}, errors.New("invalid EC key")
+		}
+		// ecdh rejects points that aren't on the curve
+		if _, err := check.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return jwk{}, err
+		}
+		out.key = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return jwk{}, errors.New("invalid OKP key")
+		}
+		out.key = ed25519.PublicKey(x)
+	default:
+		return jwk{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	return out, nil
+}
+
+// verifyJWS checks a JWS signature. Only asymmetric algorithms are accepted:
+// "none" and the HMAC algorithms would let anyone who knows a public key sign.
+func verifyJWS(alg string, key crypto.PublicKey, signed, sig []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+	if len(alg) != 5 || hashes[alg[2:]] == 0 {
+		return false
+	}
+	hash := hashes[alg[2:]]
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		// ES256 signs with P-256, ES384 with P-384 and ES512 with P-521
+		pub, ok := key.(*ecdsa.PublicKey)
+		curves := map[string]string{"256": "P-256", "384": "P-384", "512": "P-521"}
+		if !ok || pub.Curve.Params().Name != curves[alg[2:]] {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOIDCVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	issuer = server.URL
+
+	v, err := NewOIDCVerifier(OIDCOptions{
+		Issuers:     []string{issuer},
+		Audiences:   []string{"storage-api"},
+		RoleClaims:  []string{"realm_access.roles"},
+		RoleMapping: []string{"storage-admins=admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":          issuer,
+			"sub":          "u1",
+			"aud":          []string{"account", "storage-api"},
+			"exp":          now.Add(time.Hour).Unix(),
+			"scope":        "openid files:read",
+			"realm_access": map[string]interface{}{"roles": []string{"storage-admins", "viewer"}},
+		}
+		for k, value := range overrides {
+			c[k] = value
+		}
+		return c
+	}
+	sign := func(alg, kid string, payload map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		body, _ := json.Marshal(payload)
+		signed := b64(header) + "." + b64(body)
+		digest := sha256.Sum256([]byte(signed))
+		var sig []byte
+		switch alg {
+		case "RS256":
+			sig, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		case "ES256":
+			r, s, _ := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		case "HS256":
+			// Signed with the public key as an HMAC secret
+			mac := hmac.New(sha256.New, rsaKey.N.Bytes())
+			mac.Write([]byte(signed))
+			sig = mac.Sum(nil)
+		}
+		return signed + "." + b64(sig)
+	}
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		kid := map[string]string{"RS256": "rsa1", "ES256": "ec1"}[alg]
+		identity, err := v.VerifyToken(context.Background(), sign(alg, kid, claims(nil)))
+		if err != nil {
+			t.Fatalf("%s: VerifyToken() error = %v", alg, err)
+		}
+		if identity.Subject != "u1" || identity.Method != "oidc" || !slices.Equal(identity.Roles, []string{"admin", "viewer"}) ||
+			!slices.Contains(identity.Scopes, "files:read") {
+			t.Errorf("%s: identity = %+v", alg, identity)
+		}
+	}
+
+	rejected := map[string]string{
+		"expired":        sign("RS256", "rsa1", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet valid":  sign("RS256", "rsa1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"wrong audience": sign("RS256", "rsa1", claims(map[string]interface{}{"aud": "other-api"})),
+		"other issuer":   sign("RS256", "rsa1", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"unknown key":    sign("RS256", "rsa2", claims(nil)),
+		"wrong key type": sign("ES256", "rsa1", claims(nil)),
+		"hmac":           sign("HS256", "rsa1", claims(nil)),
+		"none":           sign("none", "", claims(nil)),
+		"malformed":      "not-a-token",
+	}
+	for name, token := range rejected {
+		if _, err := v.VerifyToken(context.Background(), token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+}
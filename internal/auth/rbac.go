@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Permission scopes checked by routes
+const (
+	ScopeFilesRead    = "files:read"
+	ScopeFilesWrite   = "files:write"
+	ScopeBucketsAdmin = "buckets:admin"
+	ScopeAuditRead    = "audit:read"
+	ScopeAdmin        = "admin"
+)
+
+// scopeWildcard grants every scope; "files:*" grants every files scope
+const scopeWildcard = "*"
+
+// RolePolicy maps roles to the scopes they grant
+type RolePolicy struct {
+	roles map[string][]string
+}
+
+// NewRolePolicy parses "role=scope1 scope2" entries
+func NewRolePolicy(entries []string) (*RolePolicy, error) {
+	roles := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		role, scopes, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role entry %q, expected role=scope1 scope2", entry)
+		}
+		roles[role] = append(roles[role], strings.Fields(scopes)...)
+	}
+	return &RolePolicy{roles: roles}, nil
+}
+
+// Scopes returns the scopes held by an identity, directly or through its roles
+func (p *RolePolicy) Scopes(identity *Identity) []string {
+	if identity == nil {
+		return nil
+	}
+	scopes := append([]string(nil), identity.Scopes...)
+	for _, role := range identity.Roles {
+		scopes = append(scopes, p.roles[role]...)
+	}
+	return scopes
+}
+
+// Allows reports whether the identity holds all of the required scopes
+func (p *RolePolicy) Allows(identity *Identity, required ...string) bool {
+	held := p.Scopes(identity)
+	for _, scope := range required {
+		if !grants(held, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func grants(held []string, scope string) bool {
+	for _, h := range held {
+		if h == scope || h == scopeWildcard {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(h, ":*"); ok && strings.HasPrefix(scope, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList reads a claim that may be a JSON array, or a string of
+// space- or comma-separated values (as in the OAuth "scope" claim)
+func stringList(claims map[string]interface{}, keys ...string) []string {
+	var out []string
+	for _, key := range keys {
+		out = append(out, listValue(claims[key])...)
+	}
+	return out
+}
+
+// listValue reads a claim value that may be a JSON array, or a string of
+// space- or comma-separated values
+func listValue(value interface{}) []string {
+	var out []string
+	switch v := value.(type) {
+	case string:
+		out = append(out, strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	v, err := NewSignatureVerifier([]string{"billing:s3cret:files:read", "ops:0ther"}, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	v.now = func() time.Time { return now }
+
+	hashOf := func(body string) string {
+		sum := sha256.Sum256([]byte(body))
+		return hex.EncodeToString(sum[:])
+	}
+	signed := func(method, target, body string, at time.Time) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		SignRequest(req, "billing", "s3cret", []byte(body), at)
+		return req
+	}
+
+	req := signed(http.MethodPut, "/api/v1/files/a%20b.txt?b=2&a=1", "hello", now)
+	identity, err := v.Verify(req, hashOf("hello"))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if identity.Subject != "signed:billing" || identity.Method != "signature" || len(identity.Scopes) != 1 || identity.Scopes[0] != "files:read" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	// A body other than the signed one is rejected
+	req = signed(http.MethodPut, "/api/v1/files/x", "hello", now)
+	if _, err := v.Verify(req, hashOf("goodbye")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("tampered body: error = %v, want ErrInvalidCredentials", err)
+	}
+
+	rejected := map[string]*http.Request{
+		"stale":         signed(http.MethodGet, "/api/v1/files", "", now.Add(-6*time.Minute)),
+		"future":        signed(http.MethodGet, "/api/v1/files", "", now.Add(6*time.Minute)),
+		"unknown key":   httptest.NewRequest(http.MethodGet, "/api/v1/files", nil),
+		"changed path":  signed(http.MethodGet, "/api/v1/files", "", now),
+		"changed query": signed(http.MethodGet, "/api/v1/files?limit=1", "", now),
+		"wrong secret":  signed(http.MethodGet, "/api/v1/files", "", now),
+	}
+	SignRequest(rejected["unknown key"], "nobody", "s3cret", nil, now)
+	rejected["changed path"].URL.Path = "/api/v1/buckets"
+	rejected["changed query"].URL.RawQuery = "limit=1000"
+	SignRequest(rejected["wrong secret"], "ops", "s3cret", nil, now)
+	for name, req := range rejected {
+		if _, err := v.Verify(req, hashOf("")); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+)
+
+// maxCachedTokens triggers eviction of expired entries once the cache grows this large
+const maxCachedTokens = 10000
+
+// ErrInvalidCredentials is returned when a token or API key is rejected
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Verifier resolves credentials into identities
+type Verifier interface {
+	VerifyToken(ctx context.Context, token string) (*Identity, error)
+	VerifyAPIKey(key string) (*Identity, error)
+	VerifySignature(r *http.Request, bodySHA256 string) (*Identity, error)
+}
+
+// ServiceVerifier validates bearer tokens against the external auth service's
+// verify endpoint and API keys against a static list. Successful token
+// verifications are cached briefly to keep the auth service off the hot path.
+type ServiceVerifier struct {
+	verifyURL string
+	client    *http.Client
+	apiKeys   map[string]apiKey
+	cacheTTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedIdentity
+	// shared replaces cache when replicas share verifications
+	shared state.Cache
+	// signatures verifies signed requests, nil when none are accepted
+	signatures *SignatureVerifier
+	// oidc verifies bearer tokens instead of the auth service when set
+	oidc *OIDCVerifier
+}
+
+type apiKey struct {
+	name   string
+	scopes []string
+}
+
+type cachedIdentity struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// NewServiceVerifier creates a verifier. apiKeys entries are "name:key" or
+// "name:key:scope1 scope2"; keys without scopes are granted every scope.
+func NewServiceVerifier(verifyURL string, apiKeys []string, cacheTTL time.Duration) (*ServiceVerifier, error) {
+	keys := make(map[string]apiKey, len(apiKeys))
+	for _, entry := range apiKeys {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q, expected name:key", entry)
+		}
+		scopes := []string{scopeWildcard}
+		if len(parts) == 3 {
+			scopes = strings.Fields(parts[2])
+		}
+		keys[parts[1]] = apiKey{name: parts[0], scopes: scopes}
+	}
+	return &ServiceVerifier{
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: 5 * time.Second},
+		apiKeys:   keys,
+		cacheTTL:  cacheTTL,
+		cache:     make(map[string]cachedIdentity),
+	}, nil
+}
+
+// ShareCache caches token verifications in c instead of in process, so a
+// token verified by one replica isn't verified again by the others
+func (v *ServiceVerifier) ShareCache(c state.Cache) {
+	v.shared = c
+}
+
+// AcceptSignatures verifies signed requests with signatures
+func (v *ServiceVerifier) AcceptSignatures(signatures *SignatureVerifier) {
+	v.signatures = signatures
+}
+
+// VerifySignature checks a signed request whose body hashes to bodySHA256;
+// without AcceptSignatures every signature is rejected
+func (v *ServiceVerifier) VerifySignature(r *http.Request, bodySHA256 string) (*Identity, error) {
+	if v.signatures == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return v.signatures.Verify(r, bodySHA256)
+}
+
+// UseOIDC verifies bearer tokens with oidc instead of the auth service
+func (v *ServiceVerifier) UseOIDC(oidc *OIDCVerifier) {
+	v.oidc = oidc
+}
+
+// VerifyAPIKey looks up a static API key using a constant-time comparison
+func (v *ServiceVerifier) VerifyAPIKey(key string) (*Identity, error) {
+	for candidate, k := range v.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			return &Identity{Subject: "apikey:" + k.name, Method: "api_key", Scopes: k.scopes}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// VerifyToken validates a bearer token with the auth service, or with the
+// OIDC issuers after UseOIDC
+func (v *ServiceVerifier) VerifyToken(ctx context.Context, token string) (*Identity, error) {
+	if v.oidc != nil {
+		// Tokens are checked locally against cached keys, so there is nothing to cache
+		return v.oidc.VerifyToken(ctx, token)
+	}
+	if v.verifyURL == "" {
+		return nil, errors.New("auth service is not configured")
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	cacheKey := hex.EncodeToString(sum[:])
+	if identity := v.cached(ctx, cacheKey); identity != nil {
+		return identity, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.verifyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	requestid.SetHeaders(ctx, req.Header)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrInvalidCredentials
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode auth service response: %w", err)
+	}
+	claims := body
+	// Responses may wrap the claims, e.g. {"data": {...}} or {"user": {...}}
+	for _, wrapper := range []string{"data", "user", "claims"} {
+		if inner, ok := body[wrapper].(map[string]interface{}); ok {
+			claims = inner
+			break
+		}
+	}
+
+	identity := &Identity{
+		Subject: firstString(claims, "sub", "subject", "user_id", "userId", "id"),
+		Email:   firstString(claims, "email"),
+		Method:  "bearer",
+		Roles:   stringList(claims, "roles", "role"),
+		Scopes:  stringList(claims, "scope", "scopes", "permissions"),
+		Claims:  claims,
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("auth service response has no subject")
+	}
+
+	v.remember(ctx, cacheKey, identity)
+	return identity, nil
+}
+
+// remember caches a verified identity. A failure to reach the shared cache
+// only costs another verification later.
+func (v *ServiceVerifier) remember(ctx context.Context, key string, identity *Identity) {
+	if v.cacheTTL <= 0 {
+		return
+	}
+	if v.shared != nil {
+		if data, err := json.Marshal(identity); err == nil {
+			_ = v.shared.Set(ctx, "auth/"+key, data, v.cacheTTL)
+		}
+		return
+	}
+	v.mu.Lock()
+	if len(v.cache) >= maxCachedTokens {
+		v.evictExpired()
+	}
+	v.cache[key] = cachedIdentity{identity: identity, expires: time.Now().Add(v.cacheTTL)}
+	v.mu.Unlock()
+}
+
+// evictExpired drops stale cache entries; callers must hold v.mu
+func (v *ServiceVerifier) evictExpired() {
+	now := time.Now()
+	for key, entry := range v.cache {
+		if now.After(entry.expires) {
+			delete(v.cache, key)
+		}
+	}
+}
+
+func (v *ServiceVerifier) cached(ctx context.Context, key string) *Identity {
+	if v.shared != nil {
+		data, ok, err := v.shared.Get(ctx, "auth/"+key)
+		if err != nil || !ok {
+			return nil
+		}
+		var identity Identity
+		if json.Unmarshal(data, &identity) != nil {
+			return nil
+		}
+		return &identity
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry, ok := v.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(v.cache, key)
+		return nil
+	}
+	return entry.identity
+}
+
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
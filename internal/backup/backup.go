@@ -0,0 +1,792 @@
+// Package backup snapshots the data bucket, or a prefix of it, into a bucket
+// of another provider, on a schedule or on demand, and restores snapshots.
+// A snapshot holds a copy of every object and a manifest listing them with
+// their SHA-256, so a restore verifies what it reads back. Only the newest
+// snapshots are kept.
+//
+// In the destination bucket, snapshot <id> is laid out as
+//
+//	snapshots/<id>/objects/<key>            the objects
+//	snapshots/<id>/manifest/00001.jsonl     entries, one JSON object per line
+//	snapshots/<id>/manifest.json            written last, once complete
+//
+// Snapshots and restores are copied by jobs of a few minutes each that
+// record where they stopped, so large buckets outlive the job timeout and
+// resume after restarts.
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/schedule"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Job kinds copying a slice of a snapshot or a restore
+const (
+	KindSnapshot = "backup.snapshot"
+	KindRestore  = "backup.restore"
+)
+
+// Statuses of snapshots and restores
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	snapshotPrefix = "backups/snapshots/"
+	restorePrefix  = "backups/restores/"
+	// sliceDuration is how long one job copies before queueing the rest as
+	// another job, well within the queue's attempt timeout
+	sliceDuration = 5 * time.Minute
+	// partEntries is how many entries a manifest part holds at most
+	partEntries = 1000
+	// saveInterval is how often a restore records its progress
+	saveInterval = 2 * time.Second
+	// maxFailures bounds the failed objects listed on a restore, and the
+	// unreadable ones on a snapshot
+	maxFailures = 100
+)
+
+var (
+	// ErrNotFound is returned for unknown snapshots
+	ErrNotFound = errors.New("snapshot not found")
+	// ErrRestoreNotFound is returned for unknown restores
+	ErrRestoreNotFound = errors.New("restore not found")
+	// ErrRunning is returned when a snapshot is asked for while one runs
+	ErrRunning = errors.New("a snapshot is already running")
+	// ErrIncomplete is returned when restoring a snapshot that didn't succeed
+	ErrIncomplete = errors.New("snapshot is not complete")
+	// ErrDigestMismatch is recorded for backed up objects that don't match
+	// their manifest entry
+	ErrDigestMismatch = errors.New("content does not match its SHA-256 in the manifest")
+)
+
+// Options configures a Service
+type Options struct {
+	// Bucket and Prefix select the objects snapshotted
+	Bucket string
+	Prefix string
+	// Destination is the bucket of the destination backend holding snapshots
+	Destination string
+	// Retention is how many succeeded snapshots are kept
+	Retention int
+	// Schedule takes snapshots at the times it matches; nil only takes them
+	// on demand
+	Schedule *schedule.Schedule
+}
+
+// Entry is an object in a snapshot
+type Entry struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	SHA256       string            `json:"sha256"`
+	ContentType  string            `json:"contentType,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Manifest describes a complete snapshot. Its entries are in Parts JSON
+// Lines files next to it.
+type Manifest struct {
+	ID          string    `json:"id"`
+	Bucket      string    `json:"bucket"`
+	Prefix      string    `json:"prefix,omitempty"`
+	Objects     int64     `json:"objects"`
+	Bytes       int64     `json:"bytes"`
+	Parts       int       `json:"parts"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+	// Unreadable counts the objects left out, sealed with a customer key
+	// only their client holds; UnreadableKeys lists the first of them
+	Unreadable     int64    `json:"unreadable"`
+	UnreadableKeys []string `json:"unreadableKeys,omitempty"`
+}
+
+// Snapshot is the state of a snapshot
+type Snapshot struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"`
+	// Location is where the snapshot is in the destination bucket
+	Location string `json:"location"`
+	Objects  int64  `json:"objects"`
+	Bytes    int64  `json:"bytes"`
+	Parts    int    `json:"parts"`
+	// Unreadable counts the objects left out, sealed with a customer key
+	// only their client holds; UnreadableKeys lists the first of them
+	Unreadable     int64    `json:"unreadable"`
+	UnreadableKeys []string `json:"unreadableKeys,omitempty"`
+	// Token and After locate the next object: the listing resumes at Token
+	// and skips the keys up to After
+	Token       string     `json:"token,omitempty"`
+	After       string     `json:"after,omitempty"`
+	JobID       string     `json:"jobId,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Failure is an object that couldn't be restored
+type Failure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// Restore is the state of a restore
+type Restore struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	SnapshotID string `json:"snapshotId"`
+	Bucket     string `json:"bucket"`
+	// Prefix limits the restore to the keys below it
+	Prefix    string `json:"prefix,omitempty"`
+	Overwrite bool   `json:"overwrite"`
+	Restored  int64  `json:"restored"`
+	Bytes     int64  `json:"bytes"`
+	Skipped   int64  `json:"skipped"`
+	Failed    int64  `json:"failed"`
+	// Failures lists the first failed objects
+	Failures []Failure `json:"failures,omitempty"`
+	// Part and Line locate the next entry of the manifest
+	Part        int        `json:"part"`
+	Line        int        `json:"line"`
+	JobID       string     `json:"jobId,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+type jobPayload struct {
+	ID string `json:"id"`
+}
+
+// Service takes and restores snapshots as background jobs
+type Service struct {
+	source      storage.Backend
+	destination storage.Backend
+	store       store.Store
+	queue       *jobs.Queue
+	opts        Options
+	logger      *zerolog.Logger
+}
+
+// New creates a Service copying objects of source to destination, and
+// registers its job handlers on queue
+func New(source, destination storage.Backend, s store.Store, queue *jobs.Queue, opts Options, logger *zerolog.Logger) *Service {
+	if opts.Retention <= 0 {
+		opts.Retention = 1
+	}
+	svc := &Service{source: source, destination: destination, store: s, queue: queue, opts: opts, logger: logger}
+	queue.Register(KindSnapshot, svc.runSnapshot)
+	queue.Register(KindRestore, svc.runRestore)
+	return svc
+}
+
+// Schedule takes a snapshot at every time matched by the configured
+// schedule until ctx is done
+func (s *Service) Schedule(ctx context.Context) {
+	if s.opts.Schedule == nil {
+		return
+	}
+	go schedule.Run(ctx, s.opts.Schedule, func(ctx context.Context) {
+		if _, err := s.Snapshot(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to start scheduled snapshot")
+		}
+	})
+}
+
+// Snapshot starts a snapshot, unless one is running
+func (s *Service) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snapshots, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snapshots {
+		if snap.Status == StatusRunning {
+			return nil, ErrRunning
+		}
+	}
+	now := time.Now().UTC()
+	id := now.Format("20060102T150405.000Z")
+	snap := &Snapshot{
+		ID:        id,
+		Status:    StatusRunning,
+		Bucket:    s.opts.Bucket,
+		Prefix:    s.opts.Prefix,
+		Location:  snapshotDir(id),
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.store.Put(ctx, snapshotPrefix+id, snap); err != nil {
+		return nil, err
+	}
+	if _, err := s.queue.Enqueue(ctx, KindSnapshot, jobPayload{ID: id}); err != nil {
+		s.store.Delete(ctx, snapshotPrefix+id)
+		return nil, err
+	}
+	return snap, nil
+}
+
+// List returns the snapshots, newest first
+func (s *Service) List(ctx context.Context) ([]*Snapshot, error) {
+	keys, err := s.store.List(ctx, snapshotPrefix)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*Snapshot, 0, len(keys))
+	for _, key := range keys {
+		snap, err := s.Get(ctx, strings.TrimPrefix(key, snapshotPrefix))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].StartedAt.After(snapshots[j].StartedAt) })
+	return snapshots, nil
+}
+
+// Get returns a snapshot
+func (s *Service) Get(ctx context.Context, id string) (*Snapshot, error) {
+	var snap Snapshot
+	if err := s.store.Get(ctx, snapshotPrefix+id, &snap); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if lastError, failed := s.jobFailed(ctx, snap.Status, snap.JobID); failed {
+		snap.fail(lastError)
+		if err := s.store.Put(ctx, snapshotPrefix+id, &snap); err != nil {
+			return nil, err
+		}
+	}
+	return &snap, nil
+}
+
+// Restore starts restoring the objects of a snapshot below prefix into the
+// bucket they were taken from. Existing objects are kept unless overwrite.
+func (s *Service) Restore(ctx context.Context, snapshotID, prefix string, overwrite bool) (*Restore, error) {
+	snap, err := s.Get(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Status != StatusSucceeded {
+		return nil, ErrIncomplete
+	}
+	now := time.Now().UTC()
+	restore := &Restore{
+		ID:         uuid.New().String(),
+		Status:     StatusRunning,
+		SnapshotID: snap.ID,
+		Bucket:     snap.Bucket,
+		Prefix:     prefix,
+		Overwrite:  overwrite,
+		Part:       1,
+		StartedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.store.Put(ctx, restorePrefix+restore.ID, restore); err != nil {
+		return nil, err
+	}
+	if _, err := s.queue.Enqueue(ctx, KindRestore, jobPayload{ID: restore.ID}); err != nil {
+		s.store.Delete(ctx, restorePrefix+restore.ID)
+		return nil, err
+	}
+	return restore, nil
+}
+
+// GetRestore returns a restore
+func (s *Service) GetRestore(ctx context.Context, id string) (*Restore, error) {
+	var restore Restore
+	if err := s.store.Get(ctx, restorePrefix+id, &restore); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrRestoreNotFound
+		}
+		return nil, err
+	}
+	if lastError, failed := s.jobFailed(ctx, restore.Status, restore.JobID); failed {
+		restore.fail(lastError)
+		if err := s.store.Put(ctx, restorePrefix+id, &restore); err != nil {
+			return nil, err
+		}
+	}
+	return &restore, nil
+}
+
+// jobFailed reports whether the job of a running snapshot or restore ran
+// out of attempts, and its last error
+func (s *Service) jobFailed(ctx context.Context, status, jobID string) (string, bool) {
+	if status != StatusRunning || jobID == "" {
+		return "", false
+	}
+	job, err := s.queue.Get(ctx, jobID)
+	if err != nil || job.Status != jobs.StatusFailed {
+		return "", false
+	}
+	return job.LastError, true
+}
+
+func (snap *Snapshot) fail(lastError string) {
+	now := time.Now().UTC()
+	snap.Status = StatusFailed
+	snap.LastError = lastError
+	snap.UpdatedAt = now
+	snap.CompletedAt = &now
+}
+
+func (r *Restore) fail(lastError string) {
+	now := time.Now().UTC()
+	r.Status = StatusFailed
+	r.LastError = lastError
+	r.UpdatedAt = now
+	r.CompletedAt = &now
+}
+
+func snapshotDir(id string) string { return "snapshots/" + id + "/" }
+
+func objectKey(id, key string) string { return snapshotDir(id) + "objects/" + key }
+
+func partKey(id string, part int) string {
+	return fmt.Sprintf("%smanifest/%05d.jsonl", snapshotDir(id), part)
+}
+
+func manifestKey(id string) string { return snapshotDir(id) + "manifest.json" }
+
+// snapshotRun is a snapshot being copied by one job
+type snapshotRun struct {
+	*Service
+	snap *Snapshot
+	// entries are copied but not yet in a manifest part; token and after
+	// locate the object following them
+	entries      []Entry
+	token, after string
+}
+
+func (s *Service) runSnapshot(ctx context.Context, job *jobs.Job) error {
+	var payload jobPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var snap Snapshot
+	if err := s.store.Get(ctx, snapshotPrefix+payload.ID, &snap); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if snap.Status != StatusRunning {
+		return nil
+	}
+	snap.JobID = job.ID
+	run := &snapshotRun{Service: s, snap: &snap, token: snap.Token, after: snap.After}
+
+	deadline := time.Now().Add(sliceDuration)
+	opts := storage.ListOptions{Prefix: snap.Prefix, ContinuationToken: snap.Token}
+	for {
+		page, err := s.source.List(ctx, snap.Bucket, opts)
+		if errors.Is(err, storage.ErrInvalidToken) && opts.ContinuationToken != "" {
+			// The token expired; list again from the start, skipping the keys done
+			opts.ContinuationToken = ""
+			run.token = ""
+			continue
+		}
+		if err != nil {
+			return run.interrupted(ctx, err)
+		}
+		for _, object := range page.Objects {
+			if object.Key <= run.after {
+				continue
+			}
+			entry, err := s.copyOut(ctx, &snap, object.Key)
+			if errors.Is(err, storage.ErrNotFound) {
+				// Deleted since it was listed
+				continue
+			}
+			if errors.Is(err, storage.ErrCustomerKeyRequired) {
+				run.unreadable(object.Key)
+				continue
+			}
+			if err != nil {
+				return run.interrupted(ctx, err)
+			}
+			run.entries = append(run.entries, entry)
+			run.after = object.Key
+			if len(run.entries) == partEntries {
+				if err := run.flush(ctx); err != nil {
+					return err
+				}
+			}
+			if time.Now().After(deadline) {
+				return run.continueLater(ctx)
+			}
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+		// Entries of the previous page are flushed with the token that lists it
+		if err := run.flush(ctx); err != nil {
+			return err
+		}
+		run.token = page.NextContinuationToken
+	}
+	if err := run.flush(ctx); err != nil {
+		return err
+	}
+	return s.complete(ctx, &snap)
+}
+
+// copyOut copies an object into a snapshot and returns its entry
+func (s *Service) copyOut(ctx context.Context, snap *Snapshot, key string) (Entry, error) {
+	r, info, err := s.source.Get(ctx, snap.Bucket, key)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer r.Close()
+	hash := sha256.New()
+	_, err = s.destination.Put(ctx, s.opts.Destination, objectKey(snap.ID, key), io.TeeReader(r, hash), info.Size, storage.PutOptions{
+		ContentType: info.ContentType,
+		Metadata:    info.Metadata,
+	})
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to copy %s: %w", key, err)
+	}
+	return Entry{
+		Key:          key,
+		Size:         info.Size,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     info.Metadata,
+	}, nil
+}
+
+// unreadable leaves out an object the service can't read, recorded with the
+// progress on the next flush
+func (r *snapshotRun) unreadable(key string) {
+	r.snap.Unreadable++
+	if len(r.snap.UnreadableKeys) < maxFailures {
+		r.snap.UnreadableKeys = append(r.snap.UnreadableKeys, key)
+	}
+	r.after = key
+}
+
+// flush writes the pending entries as a manifest part and records the
+// progress of the snapshot
+func (r *snapshotRun) flush(ctx context.Context) error {
+	if len(r.entries) > 0 {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		var size int64
+		for _, entry := range r.entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+			size += entry.Size
+		}
+		part := r.snap.Parts + 1
+		_, err := r.destination.Put(ctx, r.opts.Destination, partKey(r.snap.ID, part), &buf, int64(buf.Len()), storage.PutOptions{ContentType: "application/x-ndjson"})
+		if err != nil {
+			return fmt.Errorf("failed to write manifest part %d: %w", part, err)
+		}
+		r.snap.Parts = part
+		r.snap.Objects += int64(len(r.entries))
+		r.snap.Bytes += size
+		r.entries = r.entries[:0]
+	}
+	r.snap.Token, r.snap.After = r.token, r.after
+	r.snap.UpdatedAt = time.Now().UTC()
+	return r.store.Put(ctx, snapshotPrefix+r.snap.ID, r.snap)
+}
+
+// interrupted records the progress before an error; the job is retried
+func (r *snapshotRun) interrupted(ctx context.Context, cause error) error {
+	r.snap.LastError = cause.Error()
+	if err := r.flush(ctx); err != nil {
+		r.logger.Error().Err(err).Str("snapshot_id", r.snap.ID).Msg("Failed to record snapshot progress")
+	}
+	return cause
+}
+
+// continueLater records the progress and queues the rest of the snapshot
+func (r *snapshotRun) continueLater(ctx context.Context) error {
+	if err := r.flush(ctx); err != nil {
+		return err
+	}
+	_, err := r.queue.Enqueue(ctx, KindSnapshot, jobPayload{ID: r.snap.ID})
+	return err
+}
+
+// complete writes the manifest of a snapshot and prunes old snapshots
+func (s *Service) complete(ctx context.Context, snap *Snapshot) error {
+	now := time.Now().UTC()
+	manifest, err := json.MarshalIndent(Manifest{
+		ID:             snap.ID,
+		Bucket:         snap.Bucket,
+		Prefix:         snap.Prefix,
+		Objects:        snap.Objects,
+		Bytes:          snap.Bytes,
+		Parts:          snap.Parts,
+		Unreadable:     snap.Unreadable,
+		UnreadableKeys: snap.UnreadableKeys,
+		StartedAt:      snap.StartedAt,
+		CompletedAt:    now,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := s.destination.Put(ctx, s.opts.Destination, manifestKey(snap.ID), bytes.NewReader(manifest), int64(len(manifest)), storage.PutOptions{ContentType: "application/json"}); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	snap.Status = StatusSucceeded
+	snap.Token, snap.After, snap.LastError = "", "", ""
+	snap.UpdatedAt = now
+	snap.CompletedAt = &now
+	if err := s.store.Put(ctx, snapshotPrefix+snap.ID, snap); err != nil {
+		return err
+	}
+	s.logger.Info().Str("snapshot_id", snap.ID).Int64("objects", snap.Objects).Int64("bytes", snap.Bytes).Int64("unreadable", snap.Unreadable).Msg("Snapshot completed")
+
+	if err := s.prune(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to prune old snapshots")
+	}
+	return nil
+}
+
+// prune deletes the succeeded snapshots beyond the retention, and failed
+// snapshots older than a succeeded one
+func (s *Service) prune(ctx context.Context) error {
+	snapshots, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	var kept int
+	var errs []error
+	for _, snap := range snapshots {
+		switch {
+		case snap.Status == StatusRunning:
+			continue
+		case snap.Status == StatusSucceeded && kept < s.opts.Retention:
+			kept++
+			continue
+		case snap.Status == StatusFailed && kept == 0:
+			// Kept for inspection until a newer snapshot succeeds
+			continue
+		}
+		if err := s.delete(ctx, snap.ID); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %s: %w", snap.ID, err))
+			continue
+		}
+		s.logger.Info().Str("snapshot_id", snap.ID).Msg("Snapshot deleted")
+	}
+	return errors.Join(errs...)
+}
+
+// delete removes a snapshot from the destination, then its record
+func (s *Service) delete(ctx context.Context, id string) error {
+	opts := storage.ListOptions{Prefix: snapshotDir(id)}
+	for {
+		page, err := s.destination.List(ctx, s.opts.Destination, opts)
+		if err != nil {
+			return err
+		}
+		for _, object := range page.Objects {
+			if err := s.destination.Remove(ctx, s.opts.Destination, object.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+	return s.store.Delete(ctx, snapshotPrefix+id)
+}
+
+func (s *Service) runRestore(ctx context.Context, job *jobs.Job) error {
+	var payload jobPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var restore Restore
+	if err := s.store.Get(ctx, restorePrefix+payload.ID, &restore); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if restore.Status != StatusRunning {
+		return nil
+	}
+	restore.JobID = job.ID
+	save := func() error {
+		restore.UpdatedAt = time.Now().UTC()
+		return s.store.Put(ctx, restorePrefix+restore.ID, &restore)
+	}
+	interrupted := func(cause error) error {
+		restore.LastError = cause.Error()
+		if err := save(); err != nil {
+			s.logger.Error().Err(err).Str("restore_id", restore.ID).Msg("Failed to record restore progress")
+		}
+		return cause
+	}
+
+	manifest, err := s.manifest(ctx, restore.SnapshotID)
+	if err != nil {
+		return interrupted(err)
+	}
+	deadline := time.Now().Add(sliceDuration)
+	saved := time.Now()
+	for ; restore.Part <= manifest.Parts; restore.Part, restore.Line = restore.Part+1, 0 {
+		entries, err := s.part(ctx, restore.SnapshotID, restore.Part)
+		if err != nil {
+			return interrupted(err)
+		}
+		for ; restore.Line < len(entries); restore.Line++ {
+			entry := entries[restore.Line]
+			if !strings.HasPrefix(entry.Key, restore.Prefix) {
+				continue
+			}
+			if time.Now().After(deadline) {
+				if err := save(); err != nil {
+					return err
+				}
+				_, err := s.queue.Enqueue(ctx, KindRestore, jobPayload{ID: restore.ID})
+				return err
+			}
+			restored, err := s.restoreEntry(ctx, &restore, entry)
+			switch {
+			case err == nil && restored:
+				restore.Restored++
+				restore.Bytes += entry.Size
+			case err == nil:
+				restore.Skipped++
+			case errors.Is(err, ErrDigestMismatch), errors.Is(err, storage.ErrNotFound):
+				restore.Failed++
+				if len(restore.Failures) < maxFailures {
+					restore.Failures = append(restore.Failures, Failure{Key: entry.Key, Error: err.Error()})
+				}
+			default:
+				return interrupted(err)
+			}
+			if time.Since(saved) >= saveInterval {
+				if err := save(); err != nil {
+					return err
+				}
+				saved = time.Now()
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	restore.Status = StatusSucceeded
+	restore.LastError = ""
+	restore.CompletedAt = &now
+	if err := save(); err != nil {
+		return err
+	}
+	s.logger.Info().Str("restore_id", restore.ID).Str("snapshot_id", restore.SnapshotID).
+		Int64("restored", restore.Restored).Int64("skipped", restore.Skipped).Int64("failed", restore.Failed).Msg("Restore completed")
+	return nil
+}
+
+// restoreEntry copies an object of a snapshot back, once its content
+// matches the manifest. It reports false for existing objects kept.
+func (s *Service) restoreEntry(ctx context.Context, restore *Restore, entry Entry) (bool, error) {
+	if !restore.Overwrite {
+		_, err := s.source.Stat(ctx, restore.Bucket, entry.Key)
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return false, err
+		}
+	}
+
+	r, _, err := s.destination.Get(ctx, s.opts.Destination, objectKey(restore.SnapshotID, entry.Key))
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	// Spooled so nothing is written before the content is verified
+	tmp, err := os.CreateTemp("", "restore-*")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	hash := sha256.New()
+	size, err := io.Copy(tmp, io.TeeReader(r, hash))
+	if err != nil {
+		return false, err
+	}
+	if size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		return false, ErrDigestMismatch
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	_, err = s.source.Put(ctx, restore.Bucket, entry.Key, tmp, size, storage.PutOptions{ContentType: entry.ContentType, Metadata: entry.Metadata})
+	return err == nil, err
+}
+
+// manifest reads the manifest of a complete snapshot
+func (s *Service) manifest(ctx context.Context, id string) (*Manifest, error) {
+	r, _, err := s.destination.Get(ctx, s.opts.Destination, manifestKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	defer r.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// part reads the entries of a manifest part
+func (s *Service) part(ctx context.Context, id string, part int) ([]Entry, error) {
+	r, _, err := s.destination.Get(ctx, s.opts.Destination, partKey(id, part))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest part %d: %w", part, err)
+	}
+	defer r.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to read manifest part %d: %w", part, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest part %d: %w", part, err)
+	}
+	return entries, nil
+}
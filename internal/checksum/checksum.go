@@ -0,0 +1,115 @@
+package checksum
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// Algorithm identifies a supported checksum algorithm
+type Algorithm string
+
+const (
+	SHA256 Algorithm = "sha256"
+	MD5    Algorithm = "md5"
+	CRC32C Algorithm = "crc32c"
+)
+
+// SHA256Header lets clients supply the expected SHA-256 of an upload
+const SHA256Header = "X-Content-SHA256"
+
+// MetadataKey returns the object user-metadata key used to store the algorithm's digest.
+// Keys are in canonical header form because that's how MinIO returns user metadata.
+func MetadataKey(alg Algorithm) string {
+	switch alg {
+	case SHA256:
+		return "Sha256"
+	case MD5:
+		return "Md5"
+	case CRC32C:
+		return "Crc32c"
+	}
+	return ""
+}
+
+// ParseAlgorithms parses algorithm names, always including SHA-256
+func ParseAlgorithms(names []string) ([]Algorithm, error) {
+	algs := []Algorithm{SHA256}
+	seen := map[Algorithm]bool{SHA256: true}
+	for _, name := range names {
+		alg := Algorithm(strings.ToLower(strings.TrimSpace(name)))
+		if alg == "" || seen[alg] {
+			continue
+		}
+		if MetadataKey(alg) == "" {
+			return nil, fmt.Errorf("unsupported checksum algorithm %q", name)
+		}
+		seen[alg] = true
+		algs = append(algs, alg)
+	}
+	return algs, nil
+}
+
+func newHash(alg Algorithm) hash.Hash {
+	switch alg {
+	case MD5:
+		return md5.New()
+	case CRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	default:
+		return sha256.New()
+	}
+}
+
+// Compute reads r to the end and returns hex digests for each algorithm
+func Compute(r io.Reader, algs []Algorithm) (map[Algorithm]string, error) {
+	hashes := make(map[Algorithm]hash.Hash, len(algs))
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		h := newHash(alg)
+		hashes[alg] = h
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, err
+	}
+
+	sums := make(map[Algorithm]string, len(algs))
+	for alg, h := range hashes {
+		sums[alg] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
+
+// ComputeAndRewind computes digests over r and seeks it back to the start
+func ComputeAndRewind(r io.ReadSeeker, algs []Algorithm) (map[Algorithm]string, error) {
+	sums, err := Compute(r, algs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// Matches compares a client-supplied hex digest with a computed one
+func Matches(expected, actual string) bool {
+	return strings.EqualFold(strings.TrimSpace(expected), actual)
+}
+
+// FromMetadata extracts stored digests from object user metadata
+func FromMetadata(userMetadata map[string]string) map[Algorithm]string {
+	sums := make(map[Algorithm]string)
+	for _, alg := range []Algorithm{SHA256, MD5, CRC32C} {
+		if v, ok := userMetadata[MetadataKey(alg)]; ok && v != "" {
+			sums[alg] = v
+		}
+	}
+	return sums
+}
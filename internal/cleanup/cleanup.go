@@ -0,0 +1,371 @@
+// Package cleanup removes leftovers on a schedule: old objects under temp
+// prefixes, stale multipart uploads, expired share links, settled presigned
+// uploads and derived objects whose source is gone
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/hls"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/presigned"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/schedule"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/sharing"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/uploadlink"
+	"github.com/rs/zerolog"
+)
+
+// KindRun is the job kind of a cleanup run
+const KindRun = "cleanup.run"
+
+// multipartPrefix holds the sessions recorded by the multipart upload handlers
+const multipartPrefix = "multipart/"
+
+// Options configures the cleanup tasks; a zero max age disables a task
+type Options struct {
+	// TempPrefixes are key prefixes, in every data bucket, holding temporary
+	// objects
+	TempPrefixes []string
+	// TempMaxAge is how old temporary objects get before they are deleted
+	TempMaxAge time.Duration
+	// MultipartMaxAge is how long an unfinished multipart upload may stay open
+	MultipartMaxAge time.Duration
+	// ShareRetention is how long expired and revoked share and upload links
+	// stay listed, and how long presigned uploads can be confirmed again
+	ShareRetention time.Duration
+	// InternalBuckets are never swept for temp objects or stale uploads, even
+	// when they look like tenant buckets
+	InternalBuckets []string
+	// DerivedBucket holds thumbnails and streams generated from stored
+	// objects; those of deleted or replaced objects are removed. Empty
+	// disables the task.
+	DerivedBucket string
+}
+
+// derivedKind is a kind of derived object. Its keys are the prefix, the
+// bucket and key of its source, the version of the source it was generated
+// from, then its own name.
+type derivedKind struct {
+	prefix  string
+	version func(storage.ObjectInfo) string
+}
+
+var derivedKinds = []derivedKind{
+	{prefix: "thumbnails/", version: func(info storage.ObjectInfo) string { return info.ETag }},
+	{prefix: "hls/", version: hls.Version},
+}
+
+// Report is the result of a cleanup run, stored on its job
+type Report struct {
+	StartedAt         time.Time `json:"startedAt"`
+	Duration          string    `json:"duration"`
+	TempObjects       int64     `json:"tempObjects"`
+	TempBytes         int64     `json:"tempBytes"`
+	MultipartAborted  int64     `json:"multipartAborted"`
+	SharesPurged      int64     `json:"sharesPurged"`
+	UploadLinksPurged int64     `json:"uploadLinksPurged"`
+	PresignedPurged   int64     `json:"presignedPurged"`
+	DerivedObjects    int64     `json:"derivedObjects"`
+	DerivedBytes      int64     `json:"derivedBytes"`
+}
+
+// Cleaner runs cleanup tasks as background jobs
+type Cleaner struct {
+	backend  storage.Backend
+	store    store.Store
+	resolver *tenancy.Resolver
+	shares   *sharing.Service
+	links    *uploadlink.Service
+	queue    *jobs.Queue
+	opts     Options
+	logger   *zerolog.Logger
+}
+
+// New creates a Cleaner and registers its job handler on queue. Temp objects
+// and stale uploads are only swept in the data buckets of resolver.
+func New(backend storage.Backend, s store.Store, resolver *tenancy.Resolver, queue *jobs.Queue, opts Options, logger *zerolog.Logger) *Cleaner {
+	c := &Cleaner{backend: backend, store: s, resolver: resolver, shares: sharing.NewService(s, nil), links: uploadlink.NewService(s), queue: queue, opts: opts, logger: logger}
+	queue.Register(KindRun, c.run)
+	return c
+}
+
+// Schedule queues a cleanup run at every time matched by sched until ctx is done
+func (c *Cleaner) Schedule(ctx context.Context, sched *schedule.Schedule) {
+	go schedule.Run(ctx, sched, func(ctx context.Context) {
+		if _, err := c.queue.Enqueue(ctx, KindRun, struct{}{}); err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to queue cleanup run")
+		}
+	})
+}
+
+func (c *Cleaner) run(ctx context.Context, job *jobs.Job) error {
+	report := Report{StartedAt: time.Now().UTC()}
+	var errs []error
+	if len(c.opts.TempPrefixes) > 0 && c.opts.TempMaxAge > 0 {
+		if err := c.cleanTemp(ctx, &report); err != nil {
+			errs = append(errs, fmt.Errorf("temp objects: %w", err))
+		}
+	}
+	if c.opts.MultipartMaxAge > 0 {
+		if err := c.abortMultipart(ctx, &report); err != nil {
+			errs = append(errs, fmt.Errorf("multipart uploads: %w", err))
+		}
+	}
+	if c.opts.ShareRetention > 0 {
+		purged, err := c.shares.Purge(ctx, report.StartedAt.Add(-c.opts.ShareRetention))
+		report.SharesPurged = int64(purged)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("share links: %w", err))
+		}
+		purged, err = c.links.Purge(ctx, report.StartedAt.Add(-c.opts.ShareRetention))
+		report.UploadLinksPurged = int64(purged)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("upload links: %w", err))
+		}
+		purged, err = presigned.Purge(ctx, c.store, report.StartedAt.Add(-c.opts.ShareRetention))
+		report.PresignedPurged = int64(purged)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("presigned uploads: %w", err))
+		}
+	}
+	if c.opts.DerivedBucket != "" {
+		if err := c.cleanDerived(ctx, &report); err != nil {
+			errs = append(errs, fmt.Errorf("derived objects: %w", err))
+		}
+	}
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+
+	metrics.Cleanup.Add("runs", 1)
+	metrics.Cleanup.Add("temp_objects_deleted", report.TempObjects)
+	metrics.Cleanup.Add("temp_bytes_deleted", report.TempBytes)
+	metrics.Cleanup.Add("multipart_aborted", report.MultipartAborted)
+	metrics.Cleanup.Add("shares_purged", report.SharesPurged)
+	metrics.Cleanup.Add("upload_links_purged", report.UploadLinksPurged)
+	metrics.Cleanup.Add("presigned_purged", report.PresignedPurged)
+	metrics.Cleanup.Add("derived_objects_deleted", report.DerivedObjects)
+	metrics.Cleanup.Add("derived_bytes_deleted", report.DerivedBytes)
+	if err := errors.Join(errs...); err != nil {
+		// Every task is idempotent, so the job is retried as a whole
+		metrics.Cleanup.Add("errors", 1)
+		return err
+	}
+	metrics.CleanupLastRun.Set(time.Now().Unix())
+
+	c.logger.Info().
+		Int64("temp_objects", report.TempObjects).
+		Int64("multipart_aborted", report.MultipartAborted).
+		Int64("shares_purged", report.SharesPurged).
+		Int64("upload_links_purged", report.UploadLinksPurged).
+		Int64("presigned_purged", report.PresignedPurged).
+		Int64("derived_objects", report.DerivedObjects).
+		Str("duration", report.Duration).
+		Msg("Cleanup completed")
+	return job.SetResult(report)
+}
+
+// dataBuckets returns the buckets swept for temp objects and stale uploads:
+// the configured bucket and tenant buckets, never the internal ones or other
+// buckets on the same server
+func (c *Cleaner) dataBuckets(ctx context.Context) ([]string, error) {
+	buckets, err := c.resolver.Buckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(buckets, func(bucket string) bool {
+		return slices.Contains(c.opts.InternalBuckets, bucket)
+	}), nil
+}
+
+// cleanTemp deletes objects under the temp prefixes of the data buckets that
+// were last modified before the cutoff
+func (c *Cleaner) cleanTemp(ctx context.Context, report *Report) error {
+	buckets, err := c.dataBuckets(ctx)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-c.opts.TempMaxAge)
+	for _, bucket := range buckets {
+		for _, prefix := range c.opts.TempPrefixes {
+			opts := storage.ListOptions{Prefix: strings.TrimSpace(prefix)}
+			for {
+				page, err := c.backend.List(ctx, bucket, opts)
+				if err != nil {
+					return fmt.Errorf("failed to list %s/%s: %w", bucket, opts.Prefix, err)
+				}
+				for _, object := range page.Objects {
+					if !object.LastModified.Before(cutoff) {
+						continue
+					}
+					if err := c.backend.Remove(ctx, bucket, object.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+						return fmt.Errorf("failed to delete %s/%s: %w", bucket, object.Key, err)
+					}
+					report.TempObjects++
+					report.TempBytes += object.Size
+				}
+				if page.NextContinuationToken == "" {
+					break
+				}
+				opts.ContinuationToken = page.NextContinuationToken
+			}
+		}
+	}
+	return nil
+}
+
+// cleanDerived deletes the derived objects whose source was deleted or
+// replaced; they can't be served again. Derived objects not recording their
+// source are kept.
+func (c *Cleaner) cleanDerived(ctx context.Context, report *Report) error {
+	for _, kind := range derivedKinds {
+		// Every object of a source version is under the same directory, listed
+		// together, so its verdict is reused until the directory changes
+		var dir string
+		var orphaned bool
+		opts := storage.ListOptions{Prefix: kind.prefix}
+		for {
+			page, err := c.backend.List(ctx, c.opts.DerivedBucket, opts)
+			if err != nil {
+				return fmt.Errorf("failed to list %s/%s: %w", c.opts.DerivedBucket, kind.prefix, err)
+			}
+			for _, object := range page.Objects {
+				if dir == "" || !strings.HasPrefix(object.Key, dir) {
+					dir, orphaned, err = c.derivedSource(ctx, kind, object.Key)
+					if err != nil {
+						return err
+					}
+				}
+				if !orphaned {
+					continue
+				}
+				if err := c.backend.Remove(ctx, c.opts.DerivedBucket, object.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return fmt.Errorf("failed to delete %s/%s: %w", c.opts.DerivedBucket, object.Key, err)
+				}
+				report.DerivedObjects++
+				report.DerivedBytes += object.Size
+			}
+			if page.NextContinuationToken == "" {
+				break
+			}
+			opts.ContinuationToken = page.NextContinuationToken
+		}
+	}
+	return nil
+}
+
+// derivedSource traces a derived object back to its source. It returns the
+// directory holding the derived objects of the same source version, empty
+// when the source isn't recorded, and whether that version is gone.
+func (c *Cleaner) derivedSource(ctx context.Context, kind derivedKind, key string) (string, bool, error) {
+	info, err := c.backend.Stat(ctx, c.opts.DerivedBucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to stat %s/%s: %w", c.opts.DerivedBucket, key, err)
+	}
+	sourceKey := info.Metadata[storage.SourceKeyMetadataKey]
+	bucket, rest, _ := strings.Cut(strings.TrimPrefix(key, kind.prefix), "/")
+	rest, ok := strings.CutPrefix(rest, sourceKey+"/")
+	version, _, hasName := strings.Cut(rest, "/")
+	if sourceKey == "" || !ok || version == "" || !hasName {
+		return "", false, nil
+	}
+	dir := kind.prefix + bucket + "/" + sourceKey + "/" + version + "/"
+
+	source, err := c.backend.Stat(ctx, bucket, sourceKey)
+	switch {
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrBucketNotFound):
+		return dir, true, nil
+	case err != nil:
+		return "", false, fmt.Errorf("failed to stat %s/%s: %w", bucket, sourceKey, err)
+	}
+	return dir, kind.version(source) != version, nil
+}
+
+// abortMultipart aborts multipart uploads started before the cutoff, both
+// those opened through the API and any left behind in the data buckets by
+// other clients
+func (c *Cleaner) abortMultipart(ctx context.Context, report *Report) error {
+	cutoff := time.Now().Add(-c.opts.MultipartMaxAge)
+
+	keys, err := c.store.List(ctx, multipartPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		var session struct {
+			UploadID  string    `json:"uploadId"`
+			Bucket    string    `json:"bucket"`
+			Key       string    `json:"key"`
+			CreatedAt time.Time `json:"createdAt"`
+		}
+		if err := c.store.Get(ctx, key, &session); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if !session.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if client, ok := c.client(session.Bucket); ok {
+			err := minio.Core{Client: client}.AbortMultipartUpload(ctx, session.Bucket, session.Key, session.UploadID)
+			if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+				return fmt.Errorf("failed to abort upload %s: %w", session.UploadID, err)
+			}
+			if err == nil {
+				report.MultipartAborted++
+			}
+		}
+		if err := c.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	buckets, err := c.dataBuckets(ctx)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		client, ok := c.client(bucket)
+		if !ok {
+			continue
+		}
+		core := minio.Core{Client: client}
+		for upload := range client.ListIncompleteUploads(ctx, bucket, "", true) {
+			if upload.Err != nil {
+				return fmt.Errorf("failed to list uploads in %s: %w", bucket, upload.Err)
+			}
+			if !upload.Initiated.Before(cutoff) {
+				continue
+			}
+			if err := core.AbortMultipartUpload(ctx, bucket, upload.Key, upload.UploadID); err != nil {
+				if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+					continue
+				}
+				return fmt.Errorf("failed to abort upload %s: %w", upload.UploadID, err)
+			}
+			report.MultipartAborted++
+		}
+	}
+	return nil
+}
+
+// client returns the S3 client of the backend serving bucket, if it has one
+func (c *Cleaner) client(bucket string) (*minio.Client, bool) {
+	s3, ok := storage.Route(c.backend, bucket).(*storage.S3Backend)
+	if !ok {
+		return nil, false
+	}
+	return s3.Client(), true
+}
@@ -0,0 +1,78 @@
+// Package contentindex extracts text from documents and indexes it for
+// full-text search. Extraction runs as background jobs fed by a storage
+// wrapper; the text goes to an in-memory index or to Elasticsearch.
+package contentindex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Document is the extracted text of an object
+type Document struct {
+	Bucket      string
+	Key         string
+	ContentType string
+	Size        int64
+	Text        string
+}
+
+// Query is a full-text query within a bucket
+type Query struct {
+	Bucket string
+	Prefix string
+	// Text is matched against document words; every word must occur
+	Text  string
+	Limit int
+}
+
+// Hit is a document matching a query
+type Hit struct {
+	Key   string
+	Score float64
+	// Snippet is a passage of the text around a match
+	Snippet string
+}
+
+// Index stores document text and answers full-text queries
+type Index interface {
+	Put(ctx context.Context, doc Document) error
+	// Delete removes a document; removing a missing document is not an error
+	Delete(ctx context.Context, bucket, key string) error
+	// Search returns matches, best first
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+// Options selects and configures an Index
+type Options struct {
+	// Kind is memory or elasticsearch
+	Kind string
+	// URL, IndexName and credentials of the Elasticsearch cluster
+	URL       string
+	IndexName string
+	Username  string
+	Password  string
+}
+
+// Open creates the index described by opts
+func Open(ctx context.Context, opts Options) (Index, error) {
+	switch opts.Kind {
+	case "memory":
+		return NewMemoryIndex(), nil
+	case "elasticsearch":
+		es := &Elasticsearch{
+			url:      opts.URL,
+			index:    opts.IndexName,
+			username: opts.Username,
+			password: opts.Password,
+			client:   &http.Client{Timeout: 30 * time.Second},
+		}
+		if err := es.ensureIndex(ctx); err != nil {
+			return nil, err
+		}
+		return es, nil
+	}
+	return nil, fmt.Errorf("unsupported content index %q", opts.Kind)
+}
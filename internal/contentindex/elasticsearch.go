@@ -0,0 +1,154 @@
+package contentindex
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Elasticsearch indexes documents in an Elasticsearch (or OpenSearch) index
+// through its REST API
+type Elasticsearch struct {
+	url      string
+	index    string
+	username string
+	password string
+	client   *http.Client
+}
+
+// esMapping keeps the bucket and key exact for filtering and analyzes the text
+const esMapping = `{"mappings":{"properties":{
+	"bucket":{"type":"keyword"},
+	"key":{"type":"keyword"},
+	"contentType":{"type":"keyword"},
+	"size":{"type":"long"},
+	"text":{"type":"text"}
+}}}`
+
+// docID derives a document ID within the 512 byte limit
+func (es *Elasticsearch) docID(bucket, key string) string {
+	sum := sha256.Sum256([]byte(bucket + "/" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// do sends a request, decoding a JSON response into out if given, and returns
+// the status. Statuses in ok besides 2xx are not errors.
+func (es *Elasticsearch) do(ctx context.Context, method, path string, body interface{}, out interface{}, ok ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(es.url, "/")+"/"+url.PathEscape(es.index)+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if es.username != "" {
+		req.SetBasicAuth(es.username, es.password)
+	}
+	resp, err := es.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		for _, status := range ok {
+			if resp.StatusCode == status {
+				return status, nil
+			}
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("elasticsearch %s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	if out != nil {
+		err = json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, err
+}
+
+// ensureIndex creates the index with its mapping unless it exists
+func (es *Elasticsearch) ensureIndex(ctx context.Context) error {
+	status, err := es.do(ctx, http.MethodHead, "", nil, nil, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("failed to reach content index: %w", err)
+	}
+	if status != http.StatusNotFound {
+		return nil
+	}
+	if _, err := es.do(ctx, http.MethodPut, "", json.RawMessage(esMapping), nil); err != nil {
+		return fmt.Errorf("failed to create content index: %w", err)
+	}
+	return nil
+}
+
+func (es *Elasticsearch) Put(ctx context.Context, doc Document) error {
+	_, err := es.do(ctx, http.MethodPut, "/_doc/"+es.docID(doc.Bucket, doc.Key), map[string]interface{}{
+		"bucket":      doc.Bucket,
+		"key":         doc.Key,
+		"contentType": doc.ContentType,
+		"size":        doc.Size,
+		"text":        doc.Text,
+	}, nil)
+	return err
+}
+
+func (es *Elasticsearch) Delete(ctx context.Context, bucket, key string) error {
+	_, err := es.do(ctx, http.MethodDelete, "/_doc/"+es.docID(bucket, key), nil, nil, http.StatusNotFound)
+	return err
+}
+
+func (es *Elasticsearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	filter := []interface{}{map[string]interface{}{"term": map[string]string{"bucket": q.Bucket}}}
+	if q.Prefix != "" {
+		filter = append(filter, map[string]interface{}{"prefix": map[string]string{"key": q.Prefix}})
+	}
+	size := q.Limit
+	if size <= 0 {
+		size = 1000
+	}
+	body := map[string]interface{}{
+		"size":    size,
+		"_source": []string{"key"},
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"must":   map[string]interface{}{"match": map[string]interface{}{"text": map[string]string{"query": q.Text, "operator": "and"}}},
+			"filter": filter,
+		}},
+		"highlight": map[string]interface{}{
+			"fields":    map[string]interface{}{"text": map[string]int{"fragment_size": 2 * snippetRadius, "number_of_fragments": 1}},
+			"pre_tags":  []string{""},
+			"post_tags": []string{""},
+		},
+	}
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				Score     float64              `json:"_score"`
+				Source    struct{ Key string } `json:"_source"`
+				Highlight map[string][]string  `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if _, err := es.do(ctx, http.MethodPost, "/_search", body, &resp); err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0, len(resp.Hits.Hits))
+	for _, h := range resp.Hits.Hits {
+		hit := Hit{Key: h.Source.Key, Score: h.Score}
+		if fragments := h.Highlight["text"]; len(fragments) > 0 {
+			hit.Snippet = strings.Join(strings.Fields(fragments[0]), " ")
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
@@ -0,0 +1,137 @@
+package contentindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTextBytes bounds the text kept per document
+const maxTextBytes = 1 << 20
+
+// ErrUnsupported is returned for content that text can't be extracted from
+var ErrUnsupported = errors.New("content type not supported for text extraction")
+
+const docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// extractor turns document bytes into plain text
+type extractor func(data []byte) (string, error)
+
+// extractorFor picks an extractor by content type, falling back to the file
+// extension for generic types
+func extractorFor(contentType, key string) (extractor, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/pdf":
+		return extractPDF, true
+	case mediaType == docxType:
+		return extractDOCX, true
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/json", mediaType == "application/xml":
+		return extractText, true
+	case mediaType != "" && mediaType != "application/octet-stream" && mediaType != "binary/octet-stream":
+		return nil, false
+	}
+	switch strings.ToLower(path.Ext(key)) {
+	case ".pdf":
+		return extractPDF, true
+	case ".docx":
+		return extractDOCX, true
+	case ".txt", ".md", ".csv", ".log", ".json", ".xml", ".html", ".htm":
+		return extractText, true
+	}
+	return nil, false
+}
+
+// Extractable reports whether text can be extracted from an object
+func Extractable(contentType, key string) bool {
+	_, ok := extractorFor(contentType, key)
+	return ok
+}
+
+// Extract returns the text of a document, truncated to maxTextBytes
+func Extract(data []byte, contentType, key string) (string, error) {
+	extract, ok := extractorFor(contentType, key)
+	if !ok {
+		return "", ErrUnsupported
+	}
+	text, err := extract(data)
+	if err != nil {
+		return "", err
+	}
+	if len(text) > maxTextBytes {
+		text = text[:maxTextBytes]
+		// Don't end on a partial character
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return text, nil
+}
+
+func extractText(data []byte) (string, error) {
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// extractDOCX reads the paragraphs of word/document.xml
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		return docxText(io.LimitReader(r, 64<<20))
+	}
+	return "", errors.New("docx has no word/document.xml")
+}
+
+func docxText(r io.Reader) (string, error) {
+	var b strings.Builder
+	dec := xml.NewDecoder(r)
+	inText := false
+	for b.Len() < maxTextBytes {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
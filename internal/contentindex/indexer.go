@@ -0,0 +1,142 @@
+package contentindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Job kinds run by the jobs queue
+const (
+	KindIndex  = "content.index"
+	KindRemove = "content.remove"
+)
+
+type objectRef struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+// Backend queues text extraction for every object written through it and
+// removes deleted objects from the index. Objects written around Put other
+// than through NotifyWrite (presigned uploads) are not indexed.
+type Backend struct {
+	storage.Backend
+	index   Index
+	jobs    *jobs.Queue
+	maxSize int64
+	logger  *zerolog.Logger
+}
+
+// NewBackend wraps backend and registers the indexing jobs on queue. Objects
+// larger than maxSize bytes are not indexed; 0 means no limit.
+func NewBackend(backend storage.Backend, index Index, queue *jobs.Queue, maxSize int64, logger *zerolog.Logger) *Backend {
+	b := &Backend{Backend: backend, index: index, jobs: queue, maxSize: maxSize, logger: logger}
+	queue.Register(KindIndex, b.runIndex)
+	queue.Register(KindRemove, b.runRemove)
+	return b
+}
+
+// Unwrap returns the wrapped backend
+func (b *Backend) Unwrap() storage.Backend { return b.Backend }
+
+// Index returns the index documents are written to
+func (b *Backend) Index() Index { return b.index }
+
+func (b *Backend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	info, err := b.Backend.Put(ctx, bucket, key, r, size, opts)
+	if err != nil {
+		return info, err
+	}
+	// Jobs can't read objects sealed with a key only the client holds
+	if storage.CustomerKey(ctx) == nil {
+		b.enqueue(ctx, KindIndex, bucket, key)
+	}
+	return info, nil
+}
+
+func (b *Backend) Remove(ctx context.Context, bucket, key string) error {
+	if err := b.Backend.Remove(ctx, bucket, key); err != nil {
+		return err
+	}
+	b.enqueue(ctx, KindRemove, bucket, key)
+	return nil
+}
+
+// NotifyWrite indexes an object written directly on storage and passes the
+// notification on
+func (b *Backend) NotifyWrite(ctx context.Context, bucket, key string) error {
+	b.enqueue(ctx, KindIndex, bucket, key)
+	return storage.NotifyWrite(ctx, b.Backend, bucket, key)
+}
+
+// Presign presigns against the wrapped backend
+func (b *Backend) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts storage.PresignOptions) (*url.URL, http.Header, error) {
+	presigner, ok := b.Backend.(storage.Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", storage.ErrNotSupported, b.Backend.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+// enqueue schedules an indexing job. The write already succeeded, so a
+// failure is logged rather than failing the request.
+func (b *Backend) enqueue(ctx context.Context, kind, bucket, key string) {
+	if _, err := b.jobs.Enqueue(ctx, kind, objectRef{Bucket: bucket, Key: key}); err != nil {
+		b.logger.Error().Err(err).Str("kind", kind).Str("bucket", bucket).Str("key", key).Msg("Failed to enqueue content indexing job")
+	}
+}
+
+func (b *Backend) runIndex(ctx context.Context, job *jobs.Job) error {
+	var ref objectRef
+	if err := job.Decode(&ref); err != nil {
+		return err
+	}
+	r, info, err := b.Backend.Get(ctx, ref.Bucket, ref.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted since the job was queued
+		return b.index.Delete(ctx, ref.Bucket, ref.Key)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// A replaced object may no longer be indexable; drop its old text
+	if !Extractable(info.ContentType, ref.Key) || (b.maxSize > 0 && info.Size > b.maxSize) {
+		return b.index.Delete(ctx, ref.Bucket, ref.Key)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	text, err := Extract(data, info.ContentType, ref.Key)
+	if err != nil {
+		// Malformed documents won't improve with retries
+		b.logger.Warn().Err(err).Str("bucket", ref.Bucket).Str("key", ref.Key).Msg("Failed to extract document text")
+		return b.index.Delete(ctx, ref.Bucket, ref.Key)
+	}
+	return b.index.Put(ctx, Document{
+		Bucket:      ref.Bucket,
+		Key:         ref.Key,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		Text:        text,
+	})
+}
+
+func (b *Backend) runRemove(ctx context.Context, job *jobs.Job) error {
+	var ref objectRef
+	if err := job.Decode(&ref); err != nil {
+		return err
+	}
+	return b.index.Delete(ctx, ref.Bucket, ref.Key)
+}
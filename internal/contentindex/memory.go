@@ -0,0 +1,146 @@
+package contentindex
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetRadius is how much text is kept on each side of a match
+const snippetRadius = 80
+
+// MemoryIndex is an inverted index held in process memory. It is lost on
+// restart and not shared between replicas, so it suits single-instance
+// deployments and development.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[docID]*memoryDoc
+	postings map[string]map[docID]struct{}
+}
+
+type docID struct {
+	bucket string
+	key    string
+}
+
+type memoryDoc struct {
+	text  string
+	terms map[string]int
+}
+
+// NewMemoryIndex creates an empty MemoryIndex
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{docs: make(map[docID]*memoryDoc), postings: make(map[string]map[docID]struct{})}
+}
+
+// terms splits text into lower-cased words
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (m *MemoryIndex) Put(_ context.Context, doc Document) error {
+	id := docID{doc.Bucket, doc.Key}
+	d := &memoryDoc{text: doc.Text, terms: make(map[string]int)}
+	for _, term := range terms(doc.Text) {
+		d.terms[term]++
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(id)
+	m.docs[id] = d
+	for term := range d.terms {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[docID]struct{})
+		}
+		m.postings[term][id] = struct{}{}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(_ context.Context, bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(docID{bucket, key})
+	return nil
+}
+
+// remove drops a document; the caller holds the write lock
+func (m *MemoryIndex) remove(id docID) {
+	d := m.docs[id]
+	if d == nil {
+		return
+	}
+	for term := range d.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	delete(m.docs, id)
+}
+
+// Search ranks documents containing every query word by how often the words occur
+func (m *MemoryIndex) Search(_ context.Context, q Query) ([]Hit, error) {
+	words := terms(q.Text)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	// Start from the rarest word to keep the candidate set small
+	sort.Slice(words, func(i, j int) bool { return len(m.postings[words[i]]) < len(m.postings[words[j]]) })
+	var hits []Hit
+	for id := range m.postings[words[0]] {
+		if id.bucket != q.Bucket || !strings.HasPrefix(id.key, q.Prefix) {
+			continue
+		}
+		d := m.docs[id]
+		score := 0
+		for _, word := range words {
+			n := d.terms[word]
+			if n == 0 {
+				score = 0
+				break
+			}
+			score += n
+		}
+		if score > 0 {
+			hits = append(hits, Hit{Key: id.key, Score: float64(score), Snippet: snippet(d.text, words[0])})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key < hits[j].Key
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// snippet returns the text around the first occurrence of word
+func snippet(text, word string) string {
+	i := strings.Index(strings.ToLower(text), word)
+	if i < 0 {
+		return ""
+	}
+	// Lower-casing can change byte lengths; keep offsets on rune boundaries
+	i = min(i, len(text))
+	start, end := max(0, i-snippetRadius), min(len(text), i+len(word)+snippetRadius)
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	return strings.Join(strings.Fields(text[start:end]), " ")
+}
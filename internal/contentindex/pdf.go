@@ -0,0 +1,216 @@
+package contentindex
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxStreamBytes bounds a decompressed PDF stream
+const maxStreamBytes = 16 << 20
+
+// extractPDF collects the strings shown by text operators in the page content
+// streams. It handles uncompressed and Flate streams with simple (single
+// byte) fonts; text in CID fonts or images is not recovered.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+	var b strings.Builder
+	rest := data
+	for b.Len() < maxTextBytes {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		dict := rest[:start]
+		if i := bytes.LastIndex(dict, []byte("<<")); i >= 0 {
+			dict = dict[i:]
+		}
+		body := rest[start+len("stream"):]
+		// The keyword is followed by CRLF or LF
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+		content := body[:end]
+
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			// Streams are often padded, so a truncated read still has the text
+			content, _ = io.ReadAll(io.LimitReader(zr, maxStreamBytes))
+			zr.Close()
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// Other encodings are used for images and fonts
+			continue
+		}
+		if bytes.Contains(content, []byte("BT")) {
+			pdfText(content, &b)
+		}
+	}
+	return strings.ToValidUTF8(b.String(), ""), nil
+}
+
+// pdfText writes the text shown by a content stream
+func pdfText(content []byte, b *strings.Builder) {
+	var operands []string
+	// wide spacing in a TJ array separates words
+	inArray := false
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteral(content[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			s, n := pdfHex(content[i:])
+			operands = append(operands, s)
+			i += n
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFSpace(c) || c == '<' || c == '>' || c == '{' || c == '}' || c == '/':
+			i++
+		default:
+			j := i
+			for j < len(content) && !isPDFSpace(content[j]) && !strings.ContainsRune("()<>[]{}/%", rune(content[j])) {
+				j++
+			}
+			token := string(content[i:j])
+			i = j
+			if n, err := strconv.ParseFloat(token, 64); err == nil {
+				if inArray && n < -200 && len(operands) > 0 {
+					operands[len(operands)-1] += " "
+				}
+				continue
+			}
+			switch token {
+			case "Tj", "TJ", "'", "\"":
+				if token == "'" || token == "\"" {
+					b.WriteByte('\n')
+				}
+				for _, s := range operands {
+					b.WriteString(s)
+				}
+			case "Td", "TD", "T*":
+				b.WriteByte(' ')
+			case "ET":
+				b.WriteByte('\n')
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+// pdfLiteral decodes a (string) with escapes and balanced parentheses,
+// returning it and the bytes consumed
+func pdfLiteral(data []byte) (string, int) {
+	var out []byte
+	depth := 0
+	i := 0
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return printable(out), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n', 'r':
+				out = append(out, ' ')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7' {
+						v = v*8 + int(data[i]-'0')
+						i++
+						n++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return printable(out), i
+}
+
+// pdfHex decodes a <hex string>, returning it and the bytes consumed
+func pdfHex(data []byte) (string, int) {
+	end := bytes.IndexByte(data, '>')
+	if end < 0 {
+		return "", len(data)
+	}
+	var digits []byte
+	for _, c := range data[1:end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return "", end + 1
+		}
+		out = append(out, byte(v))
+	}
+	return printable(out), end + 1
+}
+
+// printable reads bytes as Latin-1, close to PDFDocEncoding, dropping control
+// characters left by fonts with custom encodings
+func printable(s []byte) string {
+	var b strings.Builder
+	for _, c := range s {
+		if r := rune(c); unicode.IsPrint(r) || r == ' ' || r == '\t' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
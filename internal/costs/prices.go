@@ -0,0 +1,71 @@
+package costs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price tables
+const (
+	TableMinio = "minio"
+	TableR2    = "r2"
+)
+
+// Prices is a price table in USD
+type Prices struct {
+	StorageGBMonth float64 `json:"storageGbMonth"` // per GB stored for a month
+	EgressGB       float64 `json:"egressGb"`       // per GB downloaded
+	ClassAMillion  float64 `json:"classAMillion"`  // per million writes
+	ClassBMillion  float64 `json:"classBMillion"`  // per million reads
+}
+
+// ParsePrices parses "storage=0.015,egress=0,class_a=4.50,class_b=0.36";
+// prices left out are zero
+func ParsePrices(s string) (Prices, error) {
+	var p Prices
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || price < 0 {
+			return p, fmt.Errorf("invalid price %q, expected name=non-negative number", entry)
+		}
+		switch strings.TrimSpace(name) {
+		case "storage":
+			p.StorageGBMonth = price
+		case "egress":
+			p.EgressGB = price
+		case "class_a":
+			p.ClassAMillion = price
+		case "class_b":
+			p.ClassBMillion = price
+		default:
+			return p, fmt.Errorf("unknown price %q, expected storage, egress, class_a or class_b", name)
+		}
+	}
+	return p, nil
+}
+
+// Pricing picks the price table of each bucket: R2 for the buckets matching
+// an R2 pattern, MinIO for the others
+type Pricing struct {
+	Minio Prices
+	R2    Prices
+	// R2Buckets are bucket names, or prefixes ending in "*"
+	R2Buckets []string
+}
+
+// Table returns the name and prices of the table bucket is charged by
+func (p Pricing) Table(bucket string) (string, Prices) {
+	for _, pattern := range p.R2Buckets {
+		pattern = strings.TrimSpace(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(bucket, prefix) || pattern == bucket {
+			return TableR2, p.R2
+		}
+	}
+	return TableMinio, p.Minio
+}
@@ -0,0 +1,180 @@
+package costs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/stats"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+)
+
+// Report groupings
+const (
+	GroupBucket = "bucket"
+	GroupPrefix = "prefix"
+	GroupTenant = "tenant"
+)
+
+// bytesPerGB is the decimal gigabyte providers bill by
+const bytesPerGB = 1e9
+
+// Line is the estimated cost of one bucket, prefix or tenant. Costs are in
+// USD, rounded to a hundredth of a cent.
+type Line struct {
+	Bucket   string `json:"bucket,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	TenantID string `json:"tenantId,omitempty"`
+	// Table is the price table applied, "mixed" for a tenant spanning both
+	Table          string  `json:"table"`
+	StorageBytes   int64   `json:"storageBytes"`
+	EgressBytes    int64   `json:"egressBytes"`
+	ClassAOps      int64   `json:"classAOps"`
+	ClassBOps      int64   `json:"classBOps"`
+	StorageCost    float64 `json:"storageCost"`
+	EgressCost     float64 `json:"egressCost"`
+	OperationsCost float64 `json:"operationsCost"`
+	Total          float64 `json:"total"`
+}
+
+// Report is the estimated cost of a month
+type Report struct {
+	Month   string `json:"month"`
+	GroupBy string `json:"groupBy"`
+	// StorageScannedAt is when the stored bytes were measured, by the last
+	// scan of the month; storage is left out of months without one
+	StorageScannedAt *time.Time        `json:"storageScannedAt,omitempty"`
+	Prices           map[string]Prices `json:"prices"`
+	Lines            []Line            `json:"lines"`
+	Total            float64           `json:"total"`
+}
+
+// Reporter prices the metered usage and the stored bytes
+type Reporter struct {
+	meter    *Meter
+	scanner  *stats.Scanner
+	resolver *tenancy.Resolver
+	pricing  Pricing
+}
+
+// NewReporter creates a Reporter. Without a scanner reports leave storage
+// out; the resolver attributes stored bytes to tenants.
+func NewReporter(meter *Meter, scanner *stats.Scanner, resolver *tenancy.Resolver, pricing Pricing) *Reporter {
+	return &Reporter{meter: meter, scanner: scanner, resolver: resolver, pricing: pricing}
+}
+
+// Report estimates the cost of month, grouped by bucket, prefix or tenant.
+// Storage is charged for the whole month at the size of its last scan.
+func (r *Reporter) Report(ctx context.Context, month time.Time, groupBy string) (*Report, error) {
+	key, ok := groupKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", groupBy)
+	}
+	report := &Report{
+		Month:   month.UTC().Format(MonthLayout),
+		GroupBy: groupBy,
+		Prices:  map[string]Prices{TableMinio: r.pricing.Minio, TableR2: r.pricing.R2},
+		Lines:   []Line{},
+	}
+
+	// Cost every bucket, prefix and tenant, then add them up per group
+	var lines []Line
+	if r.scanner != nil {
+		snapshot, err := r.scanner.Month(ctx, month)
+		switch {
+		case err == nil:
+			report.StorageScannedAt = &snapshot.ScannedAt
+			lines = append(lines, r.storageLines(snapshot)...)
+		case !errors.Is(err, stats.ErrNoSnapshot):
+			return nil, err
+		}
+	}
+	usage, err := r.meter.Usage(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	for bucket, entries := range usage {
+		for _, u := range entries {
+			lines = append(lines, Line{Bucket: bucket, Prefix: u.Prefix, TenantID: u.TenantID, EgressBytes: u.EgressBytes, ClassAOps: u.ClassAOps, ClassBOps: u.ClassBOps})
+		}
+	}
+
+	groups := map[string]*Line{}
+	for _, line := range lines {
+		table, prices := r.pricing.Table(line.Bucket)
+		line.Table = table
+		line.StorageCost = float64(line.StorageBytes) / bytesPerGB * prices.StorageGBMonth
+		line.EgressCost = float64(line.EgressBytes) / bytesPerGB * prices.EgressGB
+		line.OperationsCost = float64(line.ClassAOps)/1e6*prices.ClassAMillion + float64(line.ClassBOps)/1e6*prices.ClassBMillion
+
+		g, ok := groups[key(line)]
+		if !ok {
+			g = &Line{Table: table}
+			switch groupBy {
+			case GroupBucket:
+				g.Bucket = line.Bucket
+			case GroupPrefix:
+				g.Bucket, g.Prefix = line.Bucket, line.Prefix
+			case GroupTenant:
+				g.TenantID = line.TenantID
+			}
+			groups[key(line)] = g
+		}
+		if g.Table != table {
+			g.Table = "mixed"
+		}
+		g.StorageBytes += line.StorageBytes
+		g.EgressBytes += line.EgressBytes
+		g.ClassAOps += line.ClassAOps
+		g.ClassBOps += line.ClassBOps
+		g.StorageCost += line.StorageCost
+		g.EgressCost += line.EgressCost
+		g.OperationsCost += line.OperationsCost
+	}
+	for _, g := range groups {
+		g.Total = round(g.StorageCost + g.EgressCost + g.OperationsCost)
+		g.StorageCost, g.EgressCost, g.OperationsCost = round(g.StorageCost), round(g.EgressCost), round(g.OperationsCost)
+		report.Lines = append(report.Lines, *g)
+		report.Total += g.Total
+	}
+	report.Total = round(report.Total)
+	sort.Slice(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return key(a) < key(b)
+	})
+	return report, nil
+}
+
+// groupKeys identify the group of a line
+var groupKeys = map[string]func(Line) string{
+	GroupBucket: func(l Line) string { return l.Bucket },
+	GroupPrefix: func(l Line) string { return l.Bucket + "/" + l.Prefix },
+	GroupTenant: func(l Line) string { return l.TenantID },
+}
+
+// storageLines are the stored bytes of every scanned prefix. Bytes the scan
+// doesn't list per prefix (beyond its largest prefixes) count for the bucket.
+func (r *Reporter) storageLines(snapshot *stats.Snapshot) []Line {
+	var lines []Line
+	for _, bucket := range snapshot.Buckets {
+		rest := bucket.Bytes
+		for _, p := range bucket.Prefixes {
+			lines = append(lines, Line{Bucket: bucket.Bucket, Prefix: p.Prefix, TenantID: r.resolver.TenantOf(bucket.Bucket, p.Prefix), StorageBytes: p.Bytes})
+			rest -= p.Bytes
+		}
+		if rest > 0 {
+			lines = append(lines, Line{Bucket: bucket.Bucket, TenantID: r.resolver.TenantOf(bucket.Bucket, ""), StorageBytes: rest})
+		}
+	}
+	return lines
+}
+
+func round(usd float64) float64 {
+	return math.Round(usd*1e4) / 1e4
+}
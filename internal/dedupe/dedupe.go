@@ -0,0 +1,84 @@
+// Package dedupe reference-counts content-addressed objects, so identical
+// uploads are stored once and removed with their last reference.
+package dedupe
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+)
+
+// MetadataKey marks content-addressed objects with the hash of their reference record
+const MetadataKey = "Dedupe-Ref"
+
+// record tracks how many uploads share one content-addressed object
+type record struct {
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	Refs      int       `json:"refs"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Refs keeps reference records in the metadata store
+type Refs struct {
+	store store.Store
+}
+
+// NewRefs creates a Refs backed by s
+func NewRefs(s store.Store) *Refs {
+	return &Refs{store: s}
+}
+
+// recordKey keeps reference records per tenant so content is never shared across tenants
+func recordKey(scope tenancy.Scope, sha256 string) string {
+	if scope.TenantID != "" {
+		return "dedupe/" + scope.TenantID + "/" + sha256
+	}
+	return "dedupe/" + sha256
+}
+
+// ObjectKey derives the content-addressed key, keeping the extension so downloads stay recognizable
+func ObjectKey(sha256, filename string) string {
+	return sha256 + strings.ToLower(path.Ext(filename))
+}
+
+// Acquire adds a reference for the content hash and returns the object key
+// within the scope and the number of references, including this one
+func (r *Refs) Acquire(ctx context.Context, scope tenancy.Scope, sha256, filename string, size int64) (string, int, error) {
+	rec, err := store.Update(ctx, r.store, recordKey(scope, sha256), func(rec *record, exists bool) (bool, error) {
+		now := time.Now().UTC()
+		if !exists {
+			rec.Key = scope.Key(ObjectKey(sha256, filename))
+			rec.Size = size
+			rec.CreatedAt = now
+		}
+		rec.Refs++
+		rec.UpdatedAt = now
+		return true, nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return rec.Key, rec.Refs, nil
+}
+
+// Release drops a reference and reports whether the object is no longer referenced
+func (r *Refs) Release(ctx context.Context, scope tenancy.Scope, sha256 string) (bool, error) {
+	rec, err := store.Update(ctx, r.store, recordKey(scope, sha256), func(rec *record, exists bool) (bool, error) {
+		if !exists {
+			return false, nil
+		}
+		rec.Refs--
+		rec.UpdatedAt = time.Now().UTC()
+		return rec.Refs > 0, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return rec.Refs <= 0, nil
+}
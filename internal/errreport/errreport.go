@@ -0,0 +1,287 @@
+// Package errreport sends panics and server errors to an error tracker
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// modulePath marks stack frames of this service as in-app
+const modulePath = "github.com/muhammad-junaid-iftikhar/app-minio-api"
+
+// Levels of a report
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// Report is one error occurrence
+type Report struct {
+	Err error
+	// Message describes the report when Err is nil
+	Message string
+	// Level defaults to LevelError
+	Level string
+	// Tags are indexed, low-cardinality context such as the route
+	Tags map[string]string
+	// Extra is free-form context
+	Extra map[string]interface{}
+	// Method and URL describe the request being served, if any
+	Method string
+	URL    string
+	// Stack holds program counters from Callers; nil sends no stack trace
+	Stack []uintptr
+}
+
+// Reporter delivers reports in the background
+type Reporter interface {
+	Capture(r Report)
+	// Close delivers queued reports until ctx is done
+	Close(ctx context.Context) error
+}
+
+// Callers returns the stack above the caller of Callers, skipping skip more frames
+func Callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	return pcs[:runtime.Callers(skip+2, pcs)]
+}
+
+// Options configures a Sentry reporter
+type Options struct {
+	Environment string
+	Release     string
+}
+
+// New returns a Sentry reporter for dsn, or a no-op reporter when dsn is empty
+func New(dsn string, opts Options, logger *zerolog.Logger) (Reporter, error) {
+	if dsn == "" {
+		return nop{}, nil
+	}
+	return NewSentry(dsn, opts, logger)
+}
+
+type nop struct{}
+
+func (nop) Capture(Report)                  {}
+func (nop) Close(ctx context.Context) error { return nil }
+
+// queueSize bounds reports waiting for delivery; more are dropped
+const queueSize = 100
+
+// Sentry posts reports to a Sentry project's envelope endpoint
+type Sentry struct {
+	dsn        string
+	endpoint   string
+	auth       string
+	opts       Options
+	serverName string
+	client     *http.Client
+	logger     *zerolog.Logger
+
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSentry parses dsn ("https://publickey@host/projectid") and starts the delivery worker
+func NewSentry(dsn string, opts Options, logger *zerolog.Logger) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, errors.New("invalid Sentry DSN, expected scheme://publickey@host/projectid")
+	}
+	prefix, project := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	if project == "" {
+		return nil, errors.New("invalid Sentry DSN: missing project ID")
+	}
+	serverName, _ := os.Hostname()
+
+	s := &Sentry{
+		dsn:        dsn,
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		auth:       "Sentry sentry_version=7, sentry_client=app-minio-api/1.0, sentry_key=" + u.User.Username(),
+		opts:       opts,
+		serverName: serverName,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+		queue:      make(chan []byte, queueSize),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Capture queues r for delivery, dropping it if the queue is full
+func (s *Sentry) Capture(r Report) {
+	envelope, err := s.envelope(r)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to encode error report")
+		return
+	}
+	defer func() {
+		// Capture after Close is a no-op
+		_ = recover()
+	}()
+	select {
+	case s.queue <- envelope:
+	default:
+		s.logger.Warn().Msg("Error report queue full, dropping report")
+	}
+}
+
+// Close stops accepting reports and waits for queued ones to be sent
+func (s *Sentry) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.queue) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sentry) run() {
+	defer close(s.done)
+	for envelope := range s.queue {
+		if err := s.send(envelope); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to send error report")
+		}
+	}
+}
+
+func (s *Sentry) send(envelope []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded %s", resp.Status)
+	}
+	return nil
+}
+
+// envelope encodes r as an envelope holding one event
+func (s *Sentry) envelope(r Report) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	eventID := hex.EncodeToString(id)
+	now := time.Now().UTC()
+
+	level := r.Level
+	if level == "" {
+		level = LevelError
+	}
+	event := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   now.Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       level,
+		"server_name": s.serverName,
+	}
+	if s.opts.Environment != "" {
+		event["environment"] = s.opts.Environment
+	}
+	if s.opts.Release != "" {
+		event["release"] = s.opts.Release
+	}
+	if len(r.Tags) > 0 {
+		event["tags"] = r.Tags
+	}
+	if len(r.Extra) > 0 {
+		event["extra"] = r.Extra
+	}
+	if r.Method != "" || r.URL != "" {
+		event["request"] = map[string]string{"method": r.Method, "url": r.URL}
+	}
+
+	if r.Err != nil {
+		exception := map[string]interface{}{
+			"type":  errorType(r.Err),
+			"value": r.Err.Error(),
+		}
+		if frames := stackFrames(r.Stack); len(frames) > 0 {
+			exception["stacktrace"] = map[string]interface{}{"frames": frames}
+		}
+		event["exception"] = map[string]interface{}{"values": []interface{}{exception}}
+	} else {
+		event["message"] = map[string]string{"formatted": r.Message}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": eventID, "dsn": s.dsn, "sent_at": now.Format(time.RFC3339Nano)})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(item)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// errorType names the innermost wrapped error's type, which groups better
+// than the type of a fmt.Errorf wrapper
+func errorType(err error) string {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// stackFrames converts program counters to Sentry frames, oldest call first
+func stackFrames(pcs []uintptr) []map[string]interface{} {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []map[string]interface{}
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, map[string]interface{}{
+			"function": frame.Function,
+			"abs_path": frame.File,
+			"lineno":   frame.Line,
+			"in_app":   strings.HasPrefix(frame.Function, modulePath),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
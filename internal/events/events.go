@@ -0,0 +1,158 @@
+// Package events carries what happens to stored files (uploads, deletes,
+// downloads and share links) from the handlers to pluggable sinks: the log,
+// a webhook, Kafka, NATS and a server-sent event stream. Handlers publish to
+// a Dispatcher and don't know which sinks are configured, so features that
+// react to file activity are added as sinks rather than in the handlers.
+//
+// Every sink receives an Event encoded as JSON:
+//
+//	{
+//	  "id": "0b8e5c2e-…",             // unique per event, for deduplication
+//	  "type": "object.uploaded",      // object.uploaded, object.deleted, object.downloaded or share.created
+//	  "time": "2026-01-02T15:04:05Z", // UTC
+//	  "bucket": "uploads",
+//	  "key": "tenant-a/report.pdf",   // full object key in the bucket
+//	  "size": 52133,                  // object size, uploads and downloads through the API
+//	  "contentType": "application/pdf",
+//	  "actor": "apikey:ci",           // authenticated subject, "anonymous" for public links
+//	  "tenantId": "tenant-a",         // omitted without tenancy
+//	  "shareSlug": "x7Kp2",           // share.created and downloads through /s/:slug
+//	  "range": "bytes 0-1023/52133",  // downloads of part of the object, whose size is then the part's
+//	  "clientIp": "203.0.113.7",
+//	  "correlationId": "…"
+//	}
+//
+// Fields may be added; consumers should ignore the ones they don't know.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// Event types
+const (
+	TypeUploaded   = "object.uploaded"
+	TypeDeleted    = "object.deleted"
+	TypeDownloaded = "object.downloaded"
+	TypeShared     = "share.created"
+)
+
+// Event is one operation on a stored file, see the package documentation
+type Event struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	Bucket        string    `json:"bucket"`
+	Key           string    `json:"key"`
+	Size          int64     `json:"size,omitempty"`
+	ContentType   string    `json:"contentType,omitempty"`
+	Actor         string    `json:"actor,omitempty"`
+	TenantID      string    `json:"tenantId,omitempty"`
+	ShareSlug     string    `json:"shareSlug,omitempty"`
+	Range         string    `json:"range,omitempty"`
+	ClientIP      string    `json:"clientIp,omitempty"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+}
+
+// Publisher accepts events from the handlers. Publish must not block.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Sink delivers events to one destination
+type Sink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	// Send delivers an event; a failed event is logged and not retried
+	Send(ctx context.Context, e Event) error
+	// Close flushes buffered events and releases connections
+	Close() error
+}
+
+const (
+	// queueSize bounds the events waiting for each sink; more are dropped
+	queueSize = 4096
+	// sendTimeout bounds the delivery of one event
+	sendTimeout = 10 * time.Second
+)
+
+// Dispatcher hands every published event to each sink. Sinks have their own
+// queue and goroutine, so a slow sink delays neither requests nor the other
+// sinks. With no sinks, publishing does nothing.
+type Dispatcher struct {
+	sinks  []*sinkQueue
+	logger *zerolog.Logger
+	wg     sync.WaitGroup
+}
+
+type sinkQueue struct {
+	sink  Sink
+	queue chan Event
+}
+
+// NewDispatcher starts delivering to sinks
+func NewDispatcher(logger *zerolog.Logger, sinks ...Sink) *Dispatcher {
+	d := &Dispatcher{logger: logger}
+	for _, sink := range sinks {
+		q := &sinkQueue{sink: sink, queue: make(chan Event, queueSize)}
+		d.sinks = append(d.sinks, q)
+		d.wg.Add(1)
+		go d.run(q)
+	}
+	return d
+}
+
+// Publish queues an event for every sink, filling in its ID and time when unset
+func (d *Dispatcher) Publish(e Event) {
+	if len(d.sinks) == 0 {
+		return
+	}
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	for _, q := range d.sinks {
+		select {
+		case q.queue <- e:
+		default:
+			metrics.Events.Add(q.sink.Name()+"_dropped", 1)
+			d.logger.Warn().Str("sink", q.sink.Name()).Str("type", e.Type).Msg("Event queue full, event dropped")
+		}
+	}
+}
+
+func (d *Dispatcher) run(q *sinkQueue) {
+	defer d.wg.Done()
+	for e := range q.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err := q.sink.Send(ctx, e)
+		cancel()
+		if err != nil {
+			metrics.Events.Add(q.sink.Name()+"_failed", 1)
+			d.logger.Error().Err(err).Str("sink", q.sink.Name()).Str("type", e.Type).Str("event_id", e.ID).Msg("Failed to deliver event")
+			continue
+		}
+		metrics.Events.Add(q.sink.Name()+"_delivered", 1)
+	}
+}
+
+// Close delivers the queued events and closes the sinks
+func (d *Dispatcher) Close() {
+	for _, q := range d.sinks {
+		close(q.queue)
+	}
+	d.wg.Wait()
+	for _, q := range d.sinks {
+		if err := q.sink.Close(); err != nil {
+			d.logger.Error().Err(err).Str("sink", q.sink.Name()).Msg("Failed to close event sink")
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// Kafka partition keys: events with the same key go to the same partition,
+// in order
+const (
+	PartitionByKey    = "key"    // bucket/key, ordering the events of each object
+	PartitionByBucket = "bucket" // ordering the events of each bucket
+	PartitionByActor  = "actor"  // ordering the events of each caller
+	PartitionNone     = "none"   // spreading events over partitions round-robin
+)
+
+// KafkaOptions configures the Kafka producer
+type KafkaOptions struct {
+	Brokers      []string
+	Topic        string
+	PartitionKey string // one of the Partition* constants; empty means PartitionByKey
+	ClientID     string
+	TLS          bool
+	// SASL/PLAIN credentials; empty disables SASL
+	Username string
+	Password string
+}
+
+// KafkaSink produces each event as one message on a topic. Messages are
+// batched and written in the background, so Send only fails once the sink is
+// closed; delivery failures are logged and counted as kafka_failed.
+type KafkaSink struct {
+	writer       *kafka.Writer
+	partitionKey string
+	logger       *zerolog.Logger
+}
+
+// NewKafkaSink creates a producer for opts. Brokers are contacted on the
+// first event, so an unreachable cluster doesn't stop the server.
+func NewKafkaSink(opts KafkaOptions, logger *zerolog.Logger) (*KafkaSink, error) {
+	if len(opts.Brokers) == 0 || opts.Topic == "" {
+		return nil, fmt.Errorf("kafka publishing needs brokers and a topic")
+	}
+	s := &KafkaSink{partitionKey: opts.PartitionKey, logger: logger}
+	if s.partitionKey == "" {
+		s.partitionKey = PartitionByKey
+	}
+
+	var balancer kafka.Balancer
+	switch s.partitionKey {
+	case PartitionByKey, PartitionByBucket, PartitionByActor:
+		// Murmur2 places keys on the same partitions as the Java clients do
+		balancer = kafka.Murmur2Balancer{}
+	case PartitionNone:
+		balancer = &kafka.RoundRobin{}
+	default:
+		return nil, fmt.Errorf("unknown partition key %q", s.partitionKey)
+	}
+
+	transport := &kafka.Transport{ClientID: opts.ClientID}
+	if opts.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if opts.Username != "" {
+		transport.SASL = plain.Mechanism{Username: opts.Username, Password: opts.Password}
+	}
+
+	s.writer = &kafka.Writer{
+		Addr:         kafka.TCP(opts.Brokers...),
+		Topic:        opts.Topic,
+		Balancer:     balancer,
+		Transport:    transport,
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 50 * time.Millisecond,
+		Async:        true,
+		Completion:   s.completed,
+	}
+	return s, nil
+}
+
+func (s *KafkaSink) Name() string { return "kafka" }
+
+// Send queues the event on the producer. Looking up the topic's partitions
+// can block on the brokers.
+func (s *KafkaSink) Send(ctx context.Context, e Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.writer.WriteMessages(ctx, kafka.Message{Key: s.key(e), Value: value})
+}
+
+func (s *KafkaSink) key(e Event) []byte {
+	switch s.partitionKey {
+	case PartitionByKey:
+		return []byte(e.Bucket + "/" + e.Key)
+	case PartitionByBucket:
+		return []byte(e.Bucket)
+	case PartitionByActor:
+		return []byte(e.Actor)
+	}
+	return nil
+}
+
+func (s *KafkaSink) completed(messages []kafka.Message, err error) {
+	if err != nil {
+		metrics.Events.Add("kafka_failed", int64(len(messages)))
+		s.logger.Error().Err(err).Int("events", len(messages)).Msg("Failed to produce events to Kafka")
+	}
+}
+
+// Close flushes the batched events and closes the connections
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,33 @@
+package events
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// LogSink writes events to the application log
+type LogSink struct {
+	logger *zerolog.Logger
+}
+
+// NewLogSink logs events with logger
+func NewLogSink(logger *zerolog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Name() string { return "log" }
+
+func (s *LogSink) Send(_ context.Context, e Event) error {
+	s.logger.Info().
+		Str("event_id", e.ID).
+		Str("type", e.Type).
+		Str("bucket", e.Bucket).
+		Str("key", e.Key).
+		Str("actor", e.Actor).
+		Str("correlation_id", e.CorrelationID).
+		Msg("File event")
+	return nil
+}
+
+func (s *LogSink) Close() error { return nil }
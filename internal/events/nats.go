@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink publishes each event on "<subject>.<type>", e.g.
+// storage.activity.object.uploaded, so subscribers can pick types with
+// wildcards
+type NATSSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSSink connects to the NATS server at url. The client reconnects on
+// its own; events published while disconnected are buffered by the client.
+func NewNATSSink(url, subject, clientName string) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name(clientName), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSSink{conn: conn, subject: subject}, nil
+}
+
+func (s *NATSSink) Name() string { return "nats" }
+
+func (s *NATSSink) Send(_ context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.conn.Publish(s.subject+"."+e.Type, data)
+}
+
+// Close sends buffered events before disconnecting
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
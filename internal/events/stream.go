@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+)
+
+// streamBuffer is how many events a subscriber may fall behind before it
+// misses events
+const streamBuffer = 256
+
+// Stream fans events out to live subscribers, for server-sent event
+// endpoints. Events are not kept: subscribers only see events published
+// while they are subscribed.
+type Stream struct {
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+// NewStream creates a Stream without subscribers
+func NewStream() *Stream {
+	return &Stream{subs: make(map[chan Event]struct{})}
+}
+
+func (s *Stream) Name() string { return "stream" }
+
+// Send passes the event to every subscriber, skipping those whose buffer is full
+func (s *Stream) Send(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		select {
+		case sub <- e:
+		default:
+			metrics.Events.Add("stream_skipped", 1)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the events published from now on,
+// and a function ending the subscription. The channel is closed when the
+// subscription ends or the stream is closed.
+func (s *Stream) Subscribe() (<-chan Event, func()) {
+	sub := make(chan Event, streamBuffer)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(sub)
+		return sub, func() {}
+	}
+	s.subs[sub] = struct{}{}
+	return sub, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[sub]; ok {
+			delete(s.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// Close ends every subscription
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		close(sub)
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+// Package exif reads EXIF/IPTC metadata from JPEG images and removes GPS data in place.
+// Only the subset of tags useful for a drive UI is decoded.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotJPEG is returned for inputs that aren't JPEG files
+var ErrNotJPEG = errors.New("not a JPEG image")
+
+// Metadata holds the decoded EXIF and IPTC fields
+type Metadata struct {
+	Make             string   `json:"make,omitempty"`
+	Model            string   `json:"model,omitempty"`
+	Software         string   `json:"software,omitempty"`
+	DateTime         string   `json:"dateTime,omitempty"`
+	DateTimeOriginal string   `json:"dateTimeOriginal,omitempty"`
+	Orientation      int      `json:"orientation,omitempty"`
+	ExposureTime     string   `json:"exposureTime,omitempty"`
+	FNumber          string   `json:"fNumber,omitempty"`
+	ISO              int      `json:"iso,omitempty"`
+	FocalLength      string   `json:"focalLength,omitempty"`
+	LensModel        string   `json:"lensModel,omitempty"`
+	Width            int      `json:"width,omitempty"`
+	Height           int      `json:"height,omitempty"`
+	GPSLatitude      *float64 `json:"gpsLatitude,omitempty"`
+	GPSLongitude     *float64 `json:"gpsLongitude,omitempty"`
+	Artist           string   `json:"artist,omitempty"`
+	Copyright        string   `json:"copyright,omitempty"`
+
+	// IPTC
+	Caption  string   `json:"caption,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	Byline   string   `json:"byline,omitempty"`
+	City     string   `json:"city,omitempty"`
+	Country  string   `json:"country,omitempty"`
+}
+
+// HasGPS reports whether location data is present
+func (m *Metadata) HasGPS() bool {
+	return m.GPSLatitude != nil || m.GPSLongitude != nil
+}
+
+// Tags
+const (
+	tagImageWidth       = 0x0100
+	tagImageHeight      = 0x0101
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+	tagArtist           = 0x013b
+	tagCopyright        = 0x8298
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagExposureTime     = 0x829a
+	tagFNumber          = 0x829d
+	tagISO              = 0x8827
+	tagDateTimeOriginal = 0x9003
+	tagFocalLength      = 0x920a
+	tagPixelXDimension  = 0xa002
+	tagPixelYDimension  = 0xa003
+	tagLensModel        = 0xa434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+)
+
+// typeSizes maps TIFF field types to their byte size
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+type segment struct {
+	marker byte
+	start  int // offset of the segment payload in the file
+	length int // payload length
+}
+
+// segments walks JPEG marker segments up to the start of scan
+func segments(data []byte) ([]segment, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, ErrNotJPEG
+	}
+	var segs []segment
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return segs, fmt.Errorf("invalid marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xff {
+			pos++
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 { // start of scan / end of image
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return segs, fmt.Errorf("truncated segment at offset %d", pos)
+		}
+		segs = append(segs, segment{marker: marker, start: pos + 4, length: length - 2})
+		pos += 2 + length
+	}
+	return segs, nil
+}
+
+// tiff is a view over the TIFF structure inside an APP1 Exif segment
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type entry struct {
+	tag    uint16
+	typ    uint16
+	count  uint32
+	offset int // offset of the value (inline or out of line) within data
+	pos    int // offset of the 12-byte entry itself
+}
+
+func newTIFF(payload []byte) (*tiff, bool) {
+	if len(payload) < 14 || !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+		return nil, false
+	}
+	data := payload[6:]
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, false
+	}
+	return &tiff{data: data, order: order}, true
+}
+
+func (t *tiff) u16(off int) uint16 { return t.order.Uint16(t.data[off:]) }
+func (t *tiff) u32(off int) uint32 { return t.order.Uint32(t.data[off:]) }
+
+// ifd reads the directory at off and returns its entries
+func (t *tiff) ifd(off int) ([]entry, error) {
+	if off <= 0 || off+2 > len(t.data) {
+		return nil, errors.New("ifd offset out of range")
+	}
+	n := int(t.u16(off))
+	if off+2+n*12 > len(t.data) {
+		return nil, errors.New("ifd truncated")
+	}
+	entries := make([]entry, 0, n)
+	for i := 0; i < n; i++ {
+		p := off + 2 + i*12
+		e := entry{tag: t.u16(p), typ: t.u16(p + 2), count: t.u32(p + 4), pos: p}
+		size := typeSizes[e.typ] * int(e.count)
+		if size <= 4 {
+			e.offset = p + 8
+		} else {
+			e.offset = int(t.u32(p + 8))
+			if e.offset+size > len(t.data) || e.offset < 0 {
+				continue
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (t *tiff) size(e entry) int { return typeSizes[e.typ] * int(e.count) }
+
+func (t *tiff) str(e entry) string {
+	b := t.data[e.offset : e.offset+t.size(e)]
+	return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+}
+
+func (t *tiff) uint(e entry) int {
+	switch e.typ {
+	case 3:
+		return int(t.u16(e.offset))
+	case 4:
+		return int(t.u32(e.offset))
+	}
+	return 0
+}
+
+func (t *tiff) rational(e entry, i int) (uint32, uint32) {
+	off := e.offset + i*8
+	return t.u32(off), t.u32(off + 4)
+}
+
+func (t *tiff) ratString(e entry) string {
+	if e.typ != 5 && e.typ != 10 {
+		return ""
+	}
+	num, den := t.rational(e, 0)
+	if den == 0 {
+		return ""
+	}
+	if num < den && num != 0 && den%num == 0 {
+		return fmt.Sprintf("1/%d", den/num)
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", float64(num)/float64(den)), "0"), ".")
+}
+
+func (t *tiff) degrees(e entry) *float64 {
+	if e.typ != 5 || e.count < 3 {
+		return nil
+	}
+	var parts [3]float64
+	for i := range parts {
+		num, den := t.rational(e, i)
+		if den == 0 {
+			return nil
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	v := parts[0] + parts[1]/60 + parts[2]/3600
+	return &v
+}
+
+// Extract decodes EXIF and IPTC metadata from a JPEG
+func Extract(data []byte) (*Metadata, error) {
+	segs, err := segments(data)
+	if err != nil && len(segs) == 0 {
+		return nil, err
+	}
+	m := &Metadata{}
+	for _, seg := range segs {
+		payload := data[seg.start : seg.start+seg.length]
+		switch seg.marker {
+		case 0xe1:
+			if t, ok := newTIFF(payload); ok {
+				t.extract(m)
+			}
+		case 0xed:
+			extractIPTC(payload, m)
+		}
+	}
+	return m, nil
+}
+
+func (t *tiff) extract(m *Metadata) {
+	ifd0, err := t.ifd(int(t.u32(4)))
+	if err != nil {
+		return
+	}
+	for _, e := range ifd0 {
+		switch e.tag {
+		case tagMake:
+			m.Make = t.str(e)
+		case tagModel:
+			m.Model = t.str(e)
+		case tagSoftware:
+			m.Software = t.str(e)
+		case tagDateTime:
+			m.DateTime = t.str(e)
+		case tagArtist:
+			m.Artist = t.str(e)
+		case tagCopyright:
+			m.Copyright = t.str(e)
+		case tagOrientation:
+			m.Orientation = t.uint(e)
+		case tagImageWidth:
+			m.Width = t.uint(e)
+		case tagImageHeight:
+			m.Height = t.uint(e)
+		case tagExifIFD:
+			t.extractExif(int(t.u32(e.offset)), m)
+		case tagGPSIFD:
+			t.extractGPS(int(t.u32(e.offset)), m)
+		}
+	}
+}
+
+func (t *tiff) extractExif(off int, m *Metadata) {
+	entries, err := t.ifd(off)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		switch e.tag {
+		case tagExposureTime:
+			m.ExposureTime = t.ratString(e)
+		case tagFNumber:
+			m.FNumber = t.ratString(e)
+		case tagISO:
+			m.ISO = t.uint(e)
+		case tagDateTimeOriginal:
+			m.DateTimeOriginal = t.str(e)
+		case tagFocalLength:
+			m.FocalLength = t.ratString(e)
+		case tagLensModel:
+			m.LensModel = t.str(e)
+		case tagPixelXDimension:
+			m.Width = t.uint(e)
+		case tagPixelYDimension:
+			m.Height = t.uint(e)
+		}
+	}
+}
+
+func (t *tiff) extractGPS(off int, m *Metadata) {
+	entries, err := t.ifd(off)
+	if err != nil {
+		return
+	}
+	var latRef, lonRef string
+	for _, e := range entries {
+		switch e.tag {
+		case tagGPSLatitudeRef:
+			latRef = t.str(e)
+		case tagGPSLongitudeRef:
+			lonRef = t.str(e)
+		case tagGPSLatitude:
+			m.GPSLatitude = t.degrees(e)
+		case tagGPSLongitude:
+			m.GPSLongitude = t.degrees(e)
+		}
+	}
+	if m.GPSLatitude != nil && latRef == "S" {
+		*m.GPSLatitude = -*m.GPSLatitude
+	}
+	if m.GPSLongitude != nil && lonRef == "W" {
+		*m.GPSLongitude = -*m.GPSLongitude
+	}
+}
+
+// extractIPTC reads IIM datasets from a Photoshop APP13 segment
+func extractIPTC(payload []byte, m *Metadata) {
+	const header = "Photoshop 3.0\x00"
+	if !bytes.HasPrefix(payload, []byte(header)) {
+		return
+	}
+	p := len(header)
+	for p+12 <= len(payload) && string(payload[p:p+4]) == "8BIM" {
+		id := binary.BigEndian.Uint16(payload[p+4:])
+		p += 6
+		nameLen := int(payload[p])
+		p += 1 + nameLen
+		if (1+nameLen)%2 == 1 {
+			p++ // names are padded to even length
+		}
+		if p+4 > len(payload) {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(payload[p:]))
+		p += 4
+		if p+size > len(payload) {
+			return
+		}
+		if id == 0x0404 {
+			parseIIM(payload[p:p+size], m)
+		}
+		p += size + size%2
+	}
+}
+
+func parseIIM(data []byte, m *Metadata) {
+	for p := 0; p+5 <= len(data) && data[p] == 0x1c; {
+		record, dataset := data[p+1], data[p+2]
+		size := int(binary.BigEndian.Uint16(data[p+3:]))
+		p += 5
+		if size&0x8000 != 0 || p+size > len(data) {
+			return // extended datasets aren't used for text fields
+		}
+		value := strings.TrimSpace(string(data[p : p+size]))
+		p += size
+		if record != 2 {
+			continue
+		}
+		switch dataset {
+		case 25:
+			m.Keywords = append(m.Keywords, value)
+		case 80:
+			m.Byline = value
+		case 90:
+			m.City = value
+		case 101:
+			m.Country = value
+		case 116:
+			if m.Copyright == "" {
+				m.Copyright = value
+			}
+		case 120:
+			m.Caption = value
+		}
+	}
+}
+
+// StripGPS removes GPS data from a JPEG in place: the GPS directory's entries and
+// their out-of-line values are zeroed and its entry count set to 0. Offsets are
+// unchanged, so the rest of the file stays byte-for-byte identical. It reports
+// whether any GPS data was removed.
+func StripGPS(data []byte) (bool, error) {
+	segs, err := segments(data)
+	if err != nil && len(segs) == 0 {
+		return false, err
+	}
+	stripped := false
+	for _, seg := range segs {
+		if seg.marker != 0xe1 {
+			continue
+		}
+		t, ok := newTIFF(data[seg.start : seg.start+seg.length])
+		if !ok {
+			continue
+		}
+		ifd0, err := t.ifd(int(t.u32(4)))
+		if err != nil {
+			continue
+		}
+		for _, e := range ifd0 {
+			if e.tag != tagGPSIFD {
+				continue
+			}
+			gpsOff := int(t.u32(e.offset))
+			entries, err := t.ifd(gpsOff)
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			for _, ge := range entries {
+				if size := t.size(ge); size > 4 {
+					clear(t.data[ge.offset : ge.offset+size])
+				}
+				clear(t.data[ge.pos : ge.pos+12])
+			}
+			t.order.PutUint16(t.data[gpsOff:], 0)
+			stripped = true
+		}
+	}
+	return stripped, nil
+}
+
+// MetadataHeaders flattens the most useful fields into object user-metadata entries.
+// Values are restricted to printable ASCII because S3 metadata travels in HTTP headers.
+func (m *Metadata) MetadataHeaders() map[string]string {
+	out := map[string]string{}
+	set := func(k, v string) {
+		v = asciiOnly(v)
+		if v != "" {
+			out["Exif-"+k] = v
+		}
+	}
+	set("Make", m.Make)
+	set("Model", m.Model)
+	set("Datetime-Original", m.DateTimeOriginal)
+	if m.Orientation != 0 {
+		set("Orientation", fmt.Sprint(m.Orientation))
+	}
+	if m.Width != 0 && m.Height != 0 {
+		set("Dimensions", fmt.Sprintf("%dx%d", m.Width, m.Height))
+	}
+	if m.GPSLatitude != nil && m.GPSLongitude != nil {
+		set("Gps", fmt.Sprintf("%.6f,%.6f", *m.GPSLatitude, *m.GPSLongitude))
+	}
+	if len(m.Keywords) > 0 {
+		set("Keywords", strings.Join(m.Keywords, ","))
+	}
+	return out
+}
+
+func asciiOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f {
+			b.WriteRune(r)
+		}
+	}
+	v := strings.TrimSpace(b.String())
+	if len(v) > 256 {
+		v = v[:256]
+	}
+	return v
+}
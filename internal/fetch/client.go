@@ -0,0 +1,295 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Errors returned when a URL can't be fetched
+var (
+	ErrNotAllowed = errors.New("URL is not allowed")
+	ErrTooLarge   = errors.New("remote file exceeds the maximum allowed size")
+)
+
+// policyError refuses a URL or address; it is ErrNotAllowed
+type policyError struct{ reason string }
+
+func (e *policyError) Error() string        { return ErrNotAllowed.Error() + ": " + e.reason }
+func (e *policyError) Is(target error) bool { return target == ErrNotAllowed }
+
+func notAllowed(format string, args ...interface{}) error {
+	return &policyError{reason: fmt.Sprintf(format, args...)}
+}
+
+// Refusal returns the policy refusal within err, without the request and
+// connection errors wrapping it, or nil if the policy didn't refuse
+func Refusal(err error) error {
+	var policy *policyError
+	if errors.As(err, &policy) {
+		return policy
+	}
+	return nil
+}
+
+// maxRedirects bounds the redirects followed, each checked like the URL
+const maxRedirects = 5
+
+// RemoteError is an error status answered by the remote server
+type RemoteError struct {
+	Status int
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("remote server answered %d %s", e.Status, http.StatusText(e.Status))
+}
+
+// Options is the policy of remote fetches
+type Options struct {
+	// Schemes are the URL schemes allowed, e.g. "https"
+	Schemes []string
+	// Hosts are the hosts allowed: a name, "*.example.com" for its
+	// subdomains or "*" for any
+	Hosts []string
+	// AllowPrivate allows loopback, private, link-local and other
+	// special-purpose addresses, which are otherwise refused whatever the
+	// host resolves to
+	AllowPrivate bool
+	// MaxSize returns the size limit of a remote file, 0 for none
+	MaxSize func() int64
+	// Timeout bounds a whole fetch, 0 for none
+	Timeout time.Duration
+}
+
+// Client downloads remote files under a policy
+type Client struct {
+	opts Options
+	http *http.Client
+}
+
+// NewClient creates a Client. Addresses are checked when connecting, so a
+// host resolving to a private address is refused even after a redirect.
+func NewClient(opts Options) *Client {
+	c := &Client{opts: opts}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: c.checkAddress}
+	c.http = &http.Client{
+		// No proxy: the address checked must be the one connected to
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		Timeout: opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return notAllowed("more than %d redirects", maxRedirects)
+			}
+			return c.Allowed(req.URL)
+		},
+	}
+	return c
+}
+
+// Transport returns the transport connecting under the address policy, for
+// other clients of the allowed hosts
+func (c *Client) Transport() http.RoundTripper {
+	return c.http.Transport
+}
+
+// Allowed checks a URL against the scheme and host policy
+func (c *Client) Allowed(u *url.URL) error {
+	if !slices.Contains(c.opts.Schemes, strings.ToLower(u.Scheme)) {
+		return notAllowed("scheme %q", u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return notAllowed("no host")
+	}
+	for _, allowed := range c.opts.Hosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == "*" || allowed == host || strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:]) {
+			return nil
+		}
+	}
+	return notAllowed("host %q", host)
+}
+
+// blockedPrefixes are the special-purpose ranges refused without AllowPrivate:
+// internal networks, cloud metadata services and ranges that translate to them
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT, some metadata services
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, most metadata services
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relays
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+	netip.MustParsePrefix("::/128"),          // unspecified
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("fec0::/10"),       // site-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// checkAddress refuses connections to internal addresses
+func (c *Client) checkAddress(_, address string, _ syscall.RawConn) error {
+	if c.opts.AllowPrivate {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return notAllowed("%s is not an IP address", address)
+	}
+	// IPv4-mapped addresses connect to the IPv4 address; zoned addresses
+	// match no prefix
+	addr := addrPort.Addr().Unmap().WithZone("")
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return notAllowed("%s is not a public address", addr)
+		}
+	}
+	return nil
+}
+
+// Remote is the response to a fetch
+type Remote struct {
+	Body io.ReadCloser
+	// Size is the announced length, -1 when unknown
+	Size int64
+	// Name is the file name from Content-Disposition or the URL path
+	Name string
+}
+
+// Close releases the response
+func (r *Remote) Close() error {
+	return r.Body.Close()
+}
+
+// Check parses a URL and checks it against the scheme and host policy
+func (c *Client) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return notAllowed("%v", err)
+	}
+	return c.Allowed(u)
+}
+
+// Open requests a remote file. Error statuses are returned as *RemoteError
+// and announced sizes over the limit as ErrTooLarge.
+func (c *Client) Open(ctx context.Context, rawURL string) (*Remote, error) {
+	if err := c.Check(rawURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Refused while redirecting or connecting; the reason is enough
+		if refusal := Refusal(err); refusal != nil {
+			return nil, refusal
+		}
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, &RemoteError{Status: resp.StatusCode}
+	}
+	if limit := c.maxSize(); limit > 0 && resp.ContentLength > limit {
+		resp.Body.Close()
+		return nil, ErrTooLarge
+	}
+	return &Remote{Body: resp.Body, Size: resp.ContentLength, Name: remoteName(resp)}, nil
+}
+
+// File is a remote file spooled to a temporary file, which Close removes
+type File struct {
+	*os.File
+	Size int64
+}
+
+// Close closes and removes the temporary file
+func (f *File) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// Spool copies a remote file to a temporary file, which the file service
+// can sniff and hash before storing, and closes the response
+func (c *Client) Spool(r *Remote) (*File, error) {
+	defer r.Close()
+	limit := c.maxSize()
+	if limit > 0 && r.Size > limit {
+		return nil, ErrTooLarge
+	}
+	tmp, err := os.CreateTemp("", "fetch-*")
+	if err != nil {
+		return nil, err
+	}
+	file := &File{File: tmp}
+	var body io.Reader = r.Body
+	if limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	if file.Size, err = io.Copy(tmp, body); err == nil && limit > 0 && file.Size > limit {
+		err = ErrTooLarge
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (c *Client) maxSize() int64 {
+	if c.opts.MaxSize == nil {
+		return 0
+	}
+	return c.opts.MaxSize()
+}
+
+// remoteName names a fetched file after its Content-Disposition or the last
+// segment of the final URL
+func remoteName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return path.Base(params["filename"])
+	}
+	if name := path.Base(resp.Request.URL.Path); name != "/" && name != "." {
+		return name
+	}
+	return "download"
+}
@@ -0,0 +1,64 @@
+package fetch
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	c := NewClient(Options{})
+	for _, tc := range []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"10.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.100.100.200", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"172.16.0.1", false},
+		{"192.0.0.170", false},
+		{"192.0.2.1", false},
+		{"192.88.99.1", false},
+		{"192.168.1.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"198.51.100.1", false},
+		{"203.0.113.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b:1::1", false},
+		{"100::1", false},
+		{"2001::1", false},
+		{"2001:db8::1", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"fe80::1%eth0", false},
+		{"fec0::1", false},
+		{"ff02::1", false},
+	} {
+		err := c.checkAddress("tcp", net.JoinHostPort(tc.ip, "443"), nil)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: refused: %v", tc.ip, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%s: error = %v, want ErrNotAllowed", tc.ip, err)
+		}
+	}
+
+	if err := NewClient(Options{AllowPrivate: true}).checkAddress("tcp", "10.1.2.3:443", nil); err != nil {
+		t.Errorf("private address refused with AllowPrivate: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+// Package fetch imports files from remote URLs. The API downloads the file
+// itself, under a scheme and host policy that also refuses internal
+// addresses, and stores it through the file service like an upload. Files
+// too large to fetch during a request are fetched by a background job.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/rs/zerolog"
+)
+
+// KindFetch is the job kind of a background fetch
+const KindFetch = "fetch.remote"
+
+// ErrNotFound is returned for unknown fetch jobs, and those of other callers
+var ErrNotFound = errors.New("fetch not found")
+
+// Request is a remote file to store and how to store it, the settings of an
+// upload through the API
+type Request struct {
+	URL string `json:"url"`
+	// Filename names the stored file; empty takes the remote name
+	Filename string        `json:"filename,omitempty"`
+	Scope    tenancy.Scope `json:"scope"`
+	// Owner is charged for the file, Actor asked for it
+	Owner       string               `json:"owner,omitempty"`
+	Actor       string               `json:"actor,omitempty"`
+	Policy      *filetype.Policy     `json:"policy,omitempty"`
+	Algorithms  []checksum.Algorithm `json:"algorithms,omitempty"`
+	Deduplicate bool                 `json:"deduplicate,omitempty"`
+	Strategy    keygen.Strategy      `json:"strategy,omitempty"`
+	ExtractExif bool                 `json:"extractExif,omitempty"`
+	StripGPS    bool                 `json:"stripGps,omitempty"`
+	Tuning      *storage.PutTuning   `json:"tuning,omitempty"`
+}
+
+// Result is the file a fetch job stored
+type Result struct {
+	Bucket       string                        `json:"bucket"`
+	Key          string                        `json:"key"`
+	Filename     string                        `json:"filename"`
+	Size         int64                         `json:"size"`
+	ContentType  string                        `json:"contentType"`
+	Checksums    map[checksum.Algorithm]string `json:"checksums"`
+	Deduplicated bool                          `json:"deduplicated"`
+}
+
+// Service fetches remote files, during a request or as jobs
+type Service struct {
+	*Client
+	files  service.FileService
+	events events.Publisher
+	jobs   *jobs.Queue
+	logger *zerolog.Logger
+}
+
+// NewService creates a Service and registers its job handler on queue.
+// publisher may be nil.
+func NewService(client *Client, files service.FileService, publisher events.Publisher, queue *jobs.Queue, logger *zerolog.Logger) *Service {
+	s := &Service{Client: client, files: files, events: publisher, jobs: queue, logger: logger}
+	queue.Register(KindFetch, s.run)
+	return s
+}
+
+// Enqueue queues a background fetch
+func (s *Service) Enqueue(ctx context.Context, req Request) (*jobs.Job, error) {
+	return s.jobs.Enqueue(ctx, KindFetch, req)
+}
+
+// Job returns a fetch job queued by actor, and its request
+func (s *Service) Job(ctx context.Context, id, actor string) (*jobs.Job, *Request, error) {
+	job, err := s.jobs.Get(ctx, id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var req Request
+	if job.Kind != KindFetch || job.Decode(&req) != nil || req.Actor != actor {
+		return nil, nil, ErrNotFound
+	}
+	return job, &req, nil
+}
+
+// uploadRequest returns the upload of a spooled remote file
+func (req *Request) uploadRequest(file *File, name string) service.UploadRequest {
+	if req.Filename != "" {
+		name = req.Filename
+	}
+	return service.UploadRequest{
+		Scope:       req.Scope,
+		Filename:    name,
+		Body:        file,
+		Size:        file.Size,
+		Policy:      req.Policy,
+		Algorithms:  req.Algorithms,
+		Deduplicate: req.Deduplicate,
+		Strategy:    req.Strategy,
+		Owner:       req.Owner,
+		ExtractExif: req.ExtractExif,
+		StripGPS:    req.StripGPS,
+		Tuning:      req.Tuning,
+	}
+}
+
+func (s *Service) run(ctx context.Context, job *jobs.Job) error {
+	var req Request
+	if err := job.Decode(&req); err != nil {
+		return jobs.Permanent(err)
+	}
+	remote, err := s.Open(ctx, req.URL)
+	if err != nil {
+		return retryable(err)
+	}
+	file, err := s.Spool(remote)
+	if err != nil {
+		return retryable(err)
+	}
+	defer file.Close()
+
+	upload, err := s.files.Upload(ctx, req.uploadRequest(file, remote.Name))
+	if err != nil {
+		return retryable(err)
+	}
+	if s.events != nil {
+		s.events.Publish(events.Event{
+			ID:          uuid.New().String(),
+			Type:        events.TypeUploaded,
+			Time:        time.Now().UTC(),
+			Bucket:      upload.Bucket,
+			Key:         upload.Key,
+			Size:        upload.Size,
+			ContentType: upload.ContentType,
+			Actor:       req.Actor,
+			TenantID:    req.Scope.TenantID,
+		})
+	}
+	s.logger.Info().Str("job_id", job.ID).Str("bucket", upload.Bucket).Str("object", upload.Key).Int64("size", upload.Size).Msg("Remote file fetched")
+	return job.SetResult(Result{
+		Bucket:       upload.Bucket,
+		Key:          upload.Key,
+		Filename:     req.Scope.Name(upload.Key),
+		Size:         upload.Size,
+		ContentType:  upload.ContentType,
+		Checksums:    upload.Sums,
+		Deduplicated: upload.Deduplicated,
+	})
+}
+
+// retryable marks the errors another attempt won't fix as permanent
+func retryable(err error) error {
+	var remote *RemoteError
+	var typeErr *service.TypeError
+	switch {
+	case errors.As(err, &remote) && remote.Status < http.StatusInternalServerError && remote.Status != http.StatusTooManyRequests,
+		errors.Is(err, ErrNotAllowed), errors.Is(err, ErrTooLarge), errors.Is(err, service.ErrTooLarge),
+		errors.As(err, &typeErr), errors.Is(err, keygen.ErrKeyExists), errors.Is(err, quota.ErrQuotaExceeded):
+		return jobs.Permanent(err)
+	}
+	return err
+}
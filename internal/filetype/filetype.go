@@ -0,0 +1,120 @@
+package filetype
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SniffLen is the number of leading bytes inspected when detecting content types
+const SniffLen = 512
+
+// executableSignatures are magic numbers http.DetectContentType does not recognize
+// but that must be identifiable so they can be blocked
+var executableSignatures = []struct {
+	magic    []byte
+	mimeType string
+}{
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("\xfe\xed\xfa\xce"), "application/x-mach-binary"},
+	{[]byte("\xfe\xed\xfa\xcf"), "application/x-mach-binary"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// Sniff detects the content type of r from its first SniffLen bytes and filename
+// using the Default registry, then rewinds r
+func Sniff(r io.ReadSeeker, filename string) (string, error) {
+	buf := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return Default.Detect(buf[:n], filename), nil
+}
+
+// detect returns the media type (without parameters) of the given leading bytes
+func detect(head []byte) string {
+	for _, sig := range executableSignatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return sig.mimeType
+		}
+	}
+	return BaseType(http.DetectContentType(head))
+}
+
+// BaseType strips parameters such as charset from a media type and lowercases it
+func BaseType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// Policy restricts which content types may be uploaded. Patterns are exact media
+// types or wildcards such as "image/*"; an empty Allowed list allows everything
+// not explicitly denied.
+type Policy struct {
+	Allowed []string
+	Denied  []string
+}
+
+// NewPolicy builds a Policy from allow and deny pattern lists
+func NewPolicy(allowed, denied []string) *Policy {
+	return &Policy{Allowed: normalize(allowed), Denied: normalize(denied)}
+}
+
+// Allows reports whether the given content type passes the policy
+func (p *Policy) Allows(contentType string) bool {
+	if p == nil {
+		return true
+	}
+	mediaType := BaseType(contentType)
+	if matchAny(p.Denied, mediaType) {
+		return false
+	}
+	return len(p.Allowed) == 0 || matchAny(p.Allowed, mediaType)
+}
+
+func matchAny(patterns []string, mediaType string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a media type matches a pattern such as "image/png",
+// "image/*" or "*", or a structured syntax suffix such as "*/*+xml" or
+// "application/*+json"
+func Match(pattern, mediaType string) bool {
+	if pattern == "*" || pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if typ, suffix, ok := strings.Cut(pattern, "/*+"); ok {
+		return strings.HasSuffix(mediaType, "+"+suffix) && (typ == "*" || strings.HasPrefix(mediaType, typ+"/"))
+	}
+	return strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+}
+
+func normalize(patterns []string) []string {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package filetype
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Registry maps file extensions and magic-byte signatures to media types. It refines
+// the generic results of http.DetectContentType (e.g. "application/zip" for .docx,
+// "text/xml" for .svg) so stored objects carry accurate content types.
+type Registry struct {
+	mu         sync.RWMutex
+	extensions map[string]string
+	signatures []signature
+}
+
+type signature struct {
+	offset   int
+	magic    []byte
+	mimeType string
+}
+
+// genericTypes are sniffing results that may be refined using the file extension
+var genericTypes = map[string]bool{
+	"application/octet-stream": true,
+	"application/zip":          true,
+	"text/plain":               true,
+	"text/xml":                 true,
+	"application/xml":          true,
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{extensions: make(map[string]string)}
+}
+
+// RegisterExtension maps a file extension (with or without the leading dot) to a media type
+func (r *Registry) RegisterExtension(ext, mimeType string) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extensions[ext] = BaseType(mimeType)
+}
+
+// RegisterMappings registers extension mappings written as "ext=media/type" (e.g. ".heif=image/heif")
+func (r *Registry) RegisterMappings(entries []string) error {
+	for _, entry := range entries {
+		ext, mimeType, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || ext == "" || !strings.Contains(mimeType, "/") {
+			return fmt.Errorf("invalid mime mapping %q, expected ext=media/type", entry)
+		}
+		r.RegisterExtension(strings.TrimSpace(ext), strings.TrimSpace(mimeType))
+	}
+	return nil
+}
+
+// RegisterSignature maps magic bytes found at offset to a media type
+func (r *Registry) RegisterSignature(offset int, magic []byte, mimeType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.signatures = append(r.signatures, signature{offset: offset, magic: magic, mimeType: BaseType(mimeType)})
+}
+
+// ByExtension returns the media type registered for the filename's extension
+func (r *Registry) ByExtension(filename string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mimeType, ok := r.extensions[strings.ToLower(filepath.Ext(filename))]
+	return mimeType, ok
+}
+
+// Detect determines the media type from the leading bytes of a file and its name.
+// Registered signatures win, then http.DetectContentType; generic sniffing results
+// are refined by extension when the registered type is consistent with the content.
+func (r *Registry) Detect(head []byte, filename string) string {
+	r.mu.RLock()
+	for _, sig := range r.signatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			r.mu.RUnlock()
+			return sig.mimeType
+		}
+	}
+	r.mu.RUnlock()
+
+	sniffed := detect(head)
+	if !genericTypes[sniffed] {
+		return sniffed
+	}
+	if byExt, ok := r.ByExtension(filename); ok && compatible(sniffed, byExt) {
+		return byExt
+	}
+	return sniffed
+}
+
+// compatible reports whether an extension-derived type is plausible for the sniffed one,
+// so renaming an executable to .png cannot smuggle it past type checks
+func compatible(sniffed, byExt string) bool {
+	switch sniffed {
+	case "application/zip":
+		return strings.Contains(byExt, "openxmlformats") || strings.Contains(byExt, "opendocument") ||
+			strings.HasSuffix(byExt, "+zip") || byExt == "application/epub+zip" || byExt == "application/java-archive"
+	case "text/plain", "text/xml", "application/xml":
+		return strings.HasPrefix(byExt, "text/") || strings.HasSuffix(byExt, "+xml") ||
+			strings.HasSuffix(byExt, "/xml") || strings.HasSuffix(byExt, "+json") || byExt == "application/json" ||
+			byExt == "application/javascript"
+	default:
+		return true
+	}
+}
+
+// Default is the registry used by package-level helpers
+var Default = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for ext, mimeType := range map[string]string{
+		".jpg":  "image/jpeg",
+		".jpeg": "image/jpeg",
+		".png":  "image/png",
+		".gif":  "image/gif",
+		".webp": "image/webp",
+		".svg":  "image/svg+xml",
+		".ico":  "image/x-icon",
+		".heic": "image/heic",
+		".avif": "image/avif",
+		".pdf":  "application/pdf",
+		".txt":  "text/plain",
+		".csv":  "text/csv",
+		".md":   "text/markdown",
+		".html": "text/html",
+		".htm":  "text/html",
+		".css":  "text/css",
+		".js":   "application/javascript",
+		".json": "application/json",
+		".xml":  "application/xml",
+		".zip":  "application/zip",
+		".epub": "application/epub+zip",
+		".jar":  "application/java-archive",
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".odt":  "application/vnd.oasis.opendocument.text",
+		".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+		".mp3":  "audio/mpeg",
+		".wav":  "audio/wav",
+		".mp4":  "video/mp4",
+		".webm": "video/webm",
+		".mov":  "video/quicktime",
+	} {
+		r.RegisterExtension(ext, mimeType)
+	}
+	// ISO base media files that http.DetectContentType reports as generic video/mp4 or not at all
+	r.RegisterSignature(4, []byte("ftypheic"), "image/heic")
+	r.RegisterSignature(4, []byte("ftypavif"), "image/avif")
+	r.RegisterSignature(4, []byte("ftypqt  "), "video/quicktime")
+	return r
+}
@@ -0,0 +1,135 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// SVG is the media type of SVG images
+const SVG = "image/svg+xml"
+
+// ErrUnsafeSVG is returned for documents SanitizeSVG can't make safe, such as
+// malformed XML or a root element other than svg
+var ErrUnsafeSVG = errors.New("not a sanitizable SVG document")
+
+// svgDroppedElements are removed along with their content: they run scripts,
+// embed other documents or render HTML
+var svgDroppedElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"handler":       true,
+	"listener":      true,
+}
+
+// SanitizeSVG returns the SVG document read from r without scripts, event
+// handler attributes, embedded documents, or links other than to fragments of
+// the document and data: images. Processing instructions, DOCTYPEs and
+// comments are dropped too. Browsers render the result without running code.
+func SanitizeSVG(r io.Reader) ([]byte, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = true
+	var out bytes.Buffer
+	depth, skip := 0, 0
+	for {
+		token, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrUnsafeSVG
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if depth == 1 && strings.ToLower(t.Name.Local) != "svg" {
+				return nil, ErrUnsafeSVG
+			}
+			if svgDroppedElements[strings.ToLower(t.Name.Local)] || animatesLink(t) {
+				skip = 1
+				continue
+			}
+			out.WriteString("<" + qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if !safeSVGAttr(attr) {
+					continue
+				}
+				out.WriteString(" " + qualifiedName(attr.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+		case xml.EndElement:
+			depth--
+			if skip > 0 {
+				skip--
+				continue
+			}
+			out.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			if skip == 0 && depth > 0 {
+				_ = xml.EscapeText(&out, t)
+			}
+		}
+	}
+	if out.Len() == 0 {
+		return nil, ErrUnsafeSVG
+	}
+	return out.Bytes(), nil
+}
+
+// safeSVGAttr reports whether an attribute is kept: event handlers are
+// dropped, and links only kept when they point within the document or at a
+// data: image
+func safeSVGAttr(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	if strings.HasPrefix(name, "on") {
+		return false
+	}
+	if name == "href" || name == "src" {
+		value := strings.ToLower(strings.TrimSpace(attr.Value))
+		return strings.HasPrefix(value, "#") ||
+			strings.HasPrefix(value, "data:image/") && !strings.HasPrefix(value, "data:image/svg")
+	}
+	return true
+}
+
+// animatesLink reports whether an animation element changes a link or an
+// event handler, which would bring back what the attribute filter removed
+func animatesLink(t xml.StartElement) bool {
+	switch strings.ToLower(t.Name.Local) {
+	case "set", "animate", "animatemotion", "animatetransform":
+	default:
+		return false
+	}
+	for _, attr := range t.Attr {
+		if strings.ToLower(attr.Name.Local) != "attributename" {
+			continue
+		}
+		_, target, _ := strings.Cut(strings.ToLower(attr.Value), ":")
+		if target == "" {
+			target = strings.ToLower(attr.Value)
+		}
+		target = strings.TrimSpace(target)
+		if target == "href" || target == "src" || strings.HasPrefix(target, "on") {
+			return true
+		}
+	}
+	return false
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
@@ -0,0 +1,274 @@
+// Package grpcapi serves the storage operations over gRPC, for internal services
+// that prefer it to multipart HTTP. The service is defined in
+// proto/storage/v1/storage.proto; regenerate storagepb with `make proto`.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/grpcapi/storagepb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/objectlock"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from incoming calls
+const (
+	authorizationMetadata = "authorization"
+	apiKeyMetadata        = "x-api-key"
+	correlationIDMetadata = "x-correlation-id"
+)
+
+// requestIDMetadata returns the ID generated for each call in its header
+const requestIDMetadata = "x-request-id"
+
+// methodScopes are the RBAC scopes each RPC requires
+var methodScopes = map[string]string{
+	storagepb.StorageService_Upload_FullMethodName:   auth.ScopeFilesWrite,
+	storagepb.StorageService_Download_FullMethodName: auth.ScopeFilesRead,
+	storagepb.StorageService_List_FullMethodName:     auth.ScopeFilesRead,
+	storagepb.StorageService_Delete_FullMethodName:   auth.ScopeFilesWrite,
+	storagepb.StorageService_Presign_FullMethodName:  auth.ScopeFilesWrite,
+}
+
+// callInfo is attached to the context of every authenticated call
+type callInfo struct {
+	correlationID string
+	requestID     string
+	identity      *auth.Identity
+	scope         tenancy.Scope
+}
+
+type callInfoKey struct{}
+
+func callFrom(ctx context.Context) *callInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*callInfo)
+	return info
+}
+
+// emit publishes an event about an object the call touched, adding who made
+// the call
+func (s *Service) emit(ctx context.Context, call *callInfo, e events.Event) {
+	if s.events == nil {
+		return
+	}
+	e.TenantID = call.scope.TenantID
+	e.CorrelationID = call.correlationID
+	if call.identity != nil {
+		e.Actor = call.identity.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			e.ClientIP = host
+		}
+	}
+	s.events.Publish(e)
+}
+
+// Service implements storagepb.StorageServiceServer on top of a storage backend
+type Service struct {
+	storagepb.UnimplementedStorageServiceServer
+
+	files      service.FileService
+	storage    storage.Backend
+	verifier   auth.Verifier
+	resolver   *tenancy.Resolver
+	rolePolicy *auth.RolePolicy
+	typePolicy *filetype.Policy
+	events     events.Publisher
+	logger     *zerolog.Logger
+	config     *config.Config
+}
+
+// NewService creates a Service. Files are written and removed through files;
+// backend serves reads. publisher, which may be nil, receives the uploads,
+// downloads and deletes.
+func NewService(files service.FileService, backend storage.Backend, verifier auth.Verifier, resolver *tenancy.Resolver, rolePolicy *auth.RolePolicy, publisher events.Publisher, logger *zerolog.Logger, cfg *config.Config) *Service {
+	return &Service{
+		files:      files,
+		storage:    backend,
+		verifier:   verifier,
+		resolver:   resolver,
+		rolePolicy: rolePolicy,
+		typePolicy: filetype.NewPolicy(cfg.UploadAllowedTypes, cfg.UploadDeniedTypes),
+		events:     publisher,
+		logger:     logger,
+		config:     cfg,
+	}
+}
+
+// NewServer returns a gRPC server serving svc. Every call must authenticate; the
+// caller's tenant scope and RBAC scopes are applied as for REST requests.
+func NewServer(svc *Service) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(svc.unaryInterceptor),
+		grpc.ChainStreamInterceptor(svc.streamInterceptor),
+	)
+	storagepb.RegisterStorageServiceServer(server, svc)
+	return server
+}
+
+func (s *Service) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, call, err := s.authenticate(ctx, info.FullMethod)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(correlationIDMetadata, call.correlationID, requestIDMetadata, call.requestID))
+	var resp interface{}
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	s.logCall(info.FullMethod, call, start, err)
+	return resp, err
+}
+
+func (s *Service) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, call, err := s.authenticate(ss.Context(), info.FullMethod)
+	_ = ss.SetHeader(metadata.Pairs(correlationIDMetadata, call.correlationID, requestIDMetadata, call.requestID))
+	if err == nil {
+		err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	s.logCall(info.FullMethod, call, start, err)
+	return err
+}
+
+// authenticate verifies the call's credentials, checks its RBAC scope and
+// resolves its tenant scope
+func (s *Service) authenticate(ctx context.Context, method string) (context.Context, *callInfo, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	call := &callInfo{correlationID: first(md, correlationIDMetadata), requestID: uuid.New().String()}
+	if call.correlationID == "" {
+		call.correlationID = uuid.New().String()
+	}
+	// Calls to the auth service and storage carry both IDs
+	ctx = requestid.NewContext(ctx, call.requestID, call.correlationID)
+
+	var err error
+	if key := first(md, apiKeyMetadata); key != "" {
+		call.identity, err = s.verifier.VerifyAPIKey(key)
+	} else if header := first(md, authorizationMetadata); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			return ctx, call, status.Error(codes.Unauthenticated, "invalid authorization metadata")
+		}
+		call.identity, err = s.verifier.VerifyToken(ctx, token)
+	} else {
+		return ctx, call, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return ctx, call, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Msg("Failed to verify credentials")
+		return ctx, call, status.Error(codes.Unavailable, "authentication service unavailable")
+	}
+
+	if scope, ok := methodScopes[method]; ok && s.config.RBACEnabled && !s.rolePolicy.Allows(call.identity, scope) {
+		return ctx, call, status.Errorf(codes.PermissionDenied, "missing scope %s", scope)
+	}
+
+	call.scope, err = s.resolver.Resolve(ctx, call.identity)
+	if err != nil {
+		if errors.Is(err, tenancy.ErrNoTenant) {
+			return ctx, call, status.Error(codes.PermissionDenied, "no tenant assigned to this identity")
+		}
+		if errors.Is(err, tenancy.ErrInvalidSubject) {
+			return ctx, call, status.Error(codes.PermissionDenied, "identity has no subject usable as a home directory")
+		}
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Msg("Failed to resolve tenant")
+		return ctx, call, toStatus(err, "failed to resolve tenant")
+	}
+	return context.WithValue(ctx, callInfoKey{}, call), call, nil
+}
+
+func (s *Service) logCall(method string, call *callInfo, start time.Time, err error) {
+	event := s.logger.Info()
+	if code := status.Code(err); code == codes.Internal || code == codes.Unknown || code == codes.Unavailable {
+		event = s.logger.Error()
+	}
+	if call.identity != nil {
+		event = event.Str("subject", call.identity.Subject)
+	}
+	event.
+		Str("correlation_id", call.correlationID).
+		Str("request_id", call.requestID).
+		Str("method", method).
+		Str("code", status.Code(err).String()).
+		Dur("latency", time.Since(start)).
+		Msg("gRPC call")
+}
+
+// toStatus maps a storage error to a gRPC status the same way REST requests map
+// it to a problem response; unrecognized errors become Internal with detail
+func toStatus(err error, detail string) error {
+	if st, ok := status.FromError(err); ok {
+		return st.Err()
+	}
+	if errors.Is(err, objectlock.ErrTimeout) {
+		return status.Error(codes.Aborted, "file is being changed by another request")
+	}
+	httpStatus, _, mapped := apierror.FromError(err)
+	if !mapped {
+		return status.Error(codes.Internal, detail)
+	}
+	return status.Error(codeForStatus(httpStatus), strings.ToLower(http.StatusText(httpStatus)))
+}
+
+func codeForStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestedRangeNotSatisfiable:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream replaces a stream's context with the authenticated one
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,302 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/grpcapi/storagepb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// downloadChunkSize is the size of the content messages streamed by Download
+	downloadChunkSize = 64 << 10
+
+	defaultListLimit = 1000
+	maxListLimit     = 1000
+
+	defaultPresignExpiry = 15 * time.Minute
+	maxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// Upload stores a streamed file. The content is spooled to a temporary file so
+// its type can be checked and its checksums verified before anything is stored.
+func (s *Service) Upload(stream storagepb.StorageService_UploadServer) error {
+	ctx := stream.Context()
+	call := callFrom(ctx)
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "upload metadata is required")
+	}
+	if err != nil {
+		return err
+	}
+	meta := first.GetMetadata()
+	if meta == nil || meta.GetFilename() == "" {
+		return status.Error(codes.InvalidArgument, "the first message must carry the upload metadata with a filename")
+	}
+	strategyName := meta.GetCollision()
+	if strategyName == "" {
+		strategyName = s.config.UploadCollisionStrategy
+	}
+	strategy, err := keygen.ParseStrategy(strategyName)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	algorithms, err := checksum.ParseAlgorithms(s.config.UploadChecksums)
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	spool, err := os.CreateTemp("", "grpc-upload-*")
+	if err != nil {
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Msg("Failed to create upload spool file")
+		return status.Error(codes.Internal, "failed to upload file")
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var size int64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetMetadata() != nil {
+			return status.Error(codes.InvalidArgument, "upload metadata must only be sent once")
+		}
+		size += int64(len(req.GetChunk()))
+		if limit := s.config.Live().MaxFileSize; limit > 0 && size > limit {
+			return status.Error(codes.ResourceExhausted, "file exceeds the maximum allowed size")
+		}
+		if _, err := spool.Write(req.GetChunk()); err != nil {
+			s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Msg("Failed to spool upload")
+			return status.Error(codes.Internal, "failed to upload file")
+		}
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return status.Error(codes.Internal, "failed to upload file")
+	}
+
+	// The same rules as REST uploads, without the HTTP-only options
+	upload, err := s.files.Upload(ctx, service.UploadRequest{
+		Scope:         call.scope,
+		Filename:      meta.GetFilename(),
+		Body:          spool,
+		Size:          size,
+		SHA256:        meta.GetSha256(),
+		Policy:        s.typePolicy,
+		Algorithms:    algorithms,
+		Strategy:      strategy,
+		Owner:         call.identity.Subject,
+		CorrelationID: call.correlationID,
+	})
+	if err != nil {
+		var typeErr *service.TypeError
+		switch {
+		case errors.Is(err, service.ErrTooLarge):
+			return status.Error(codes.ResourceExhausted, "file exceeds the maximum allowed size")
+		case errors.Is(err, service.ErrUnreadable):
+			return status.Error(codes.Internal, "failed to read file")
+		case errors.As(err, &typeErr):
+			return status.Errorf(codes.InvalidArgument, "content type %s is not allowed", typeErr.ContentType)
+		case errors.Is(err, service.ErrChecksumMismatch):
+			return status.Error(codes.InvalidArgument, "SHA-256 checksum mismatch")
+		case errors.Is(err, keygen.ErrKeyExists):
+			return status.Error(codes.AlreadyExists, "a file with this name already exists")
+		case errors.Is(err, quota.ErrQuotaExceeded):
+			return status.Error(codes.ResourceExhausted, "storage quota exceeded")
+		}
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Str("filename", meta.GetFilename()).Msg("Failed to upload file")
+		return toStatus(err, "failed to upload file")
+	}
+	info := upload.ObjectInfo
+	checksums := make(map[string]string, len(upload.Sums))
+	for alg, sum := range upload.Sums {
+		checksums[string(alg)] = sum
+	}
+
+	s.logger.Info().
+		Str("correlation_id", call.correlationID).
+		Str("bucket", info.Bucket).
+		Str("object", info.Key).
+		Int64("size", info.Size).
+		Msg("File uploaded over gRPC")
+	s.emit(ctx, call, events.Event{Type: events.TypeUploaded, Bucket: info.Bucket, Key: info.Key, Size: info.Size, ContentType: info.ContentType})
+	return stream.SendAndClose(&storagepb.UploadResponse{
+		Object:    objectInfo(call.scope, info),
+		Checksums: checksums,
+	})
+}
+
+// Download streams the object info followed by the file content
+func (s *Service) Download(req *storagepb.DownloadRequest, stream storagepb.StorageService_DownloadServer) error {
+	ctx := stream.Context()
+	call := callFrom(ctx)
+	if req.GetFilename() == "" {
+		return status.Error(codes.InvalidArgument, "filename is required")
+	}
+
+	object, info, err := s.storage.Get(ctx, call.scope.Bucket, call.scope.Key(req.GetFilename()))
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Str("filename", req.GetFilename()).Msg("Failed to get file")
+		}
+		return toStatus(err, "failed to get file")
+	}
+	defer object.Close()
+
+	if err := stream.Send(&storagepb.DownloadResponse{Data: &storagepb.DownloadResponse_Info{Info: objectInfo(call.scope, info)}}); err != nil {
+		return err
+	}
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := object.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&storagepb.DownloadResponse{Data: &storagepb.DownloadResponse_Chunk{Chunk: buf[:n]}}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			s.emit(ctx, call, events.Event{Type: events.TypeDownloaded, Bucket: call.scope.Bucket, Key: call.scope.Key(req.GetFilename()), Size: info.Size, ContentType: info.ContentType})
+			return nil
+		}
+		if err != nil {
+			s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Str("filename", req.GetFilename()).Msg("Failed to stream file")
+			return toStatus(err, "failed to read file")
+		}
+	}
+}
+
+// List returns one page of the caller's files
+func (s *Service) List(ctx context.Context, req *storagepb.ListRequest) (*storagepb.ListResponse, error) {
+	call := callFrom(ctx)
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if limit < 0 || limit > maxListLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxListLimit)
+	}
+
+	result, err := s.storage.List(ctx, call.scope.Bucket, storage.ListOptions{
+		Prefix:            call.scope.Key(req.GetPrefix()),
+		ContinuationToken: req.GetContinuationToken(),
+		Limit:             limit,
+	})
+	if err != nil {
+		if !errors.Is(err, storage.ErrInvalidToken) {
+			s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Msg("Failed to list files")
+		}
+		return nil, toStatus(err, "failed to list files")
+	}
+
+	resp := &storagepb.ListResponse{NextContinuationToken: result.NextContinuationToken}
+	for _, object := range result.Objects {
+		resp.Files = append(resp.Files, objectInfo(call.scope, object))
+	}
+	return resp, nil
+}
+
+// Delete removes a file, releasing its dedupe reference and quota like REST deletes
+func (s *Service) Delete(ctx context.Context, req *storagepb.DeleteRequest) (*storagepb.DeleteResponse, error) {
+	call := callFrom(ctx)
+	if req.GetFilename() == "" {
+		return nil, status.Error(codes.InvalidArgument, "filename is required")
+	}
+	key := call.scope.Key(req.GetFilename())
+
+	if _, err := s.files.Delete(ctx, call.scope, key); err != nil {
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Str("filename", req.GetFilename()).Msg("Failed to delete file")
+		return nil, toStatus(err, "failed to delete file")
+	}
+	s.emit(ctx, call, events.Event{Type: events.TypeDeleted, Bucket: call.scope.Bucket, Key: key})
+	return &storagepb.DeleteResponse{}, nil
+}
+
+// Presign returns a time-limited URL for direct access to object storage
+func (s *Service) Presign(ctx context.Context, req *storagepb.PresignRequest) (*storagepb.PresignResponse, error) {
+	call := callFrom(ctx)
+	if req.GetFilename() == "" {
+		return nil, status.Error(codes.InvalidArgument, "filename is required")
+	}
+	method := strings.ToUpper(req.GetMethod())
+	if method == "" {
+		method = http.MethodGet
+	}
+	expiry := defaultPresignExpiry
+	if req.GetExpiresInSeconds() != 0 {
+		expiry = time.Duration(req.GetExpiresInSeconds()) * time.Second
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return nil, status.Error(codes.InvalidArgument, "expires_in_seconds must be between 1 second and 7 days")
+	}
+
+	presigner, ok := s.storage.(storage.Presigner)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "presigned URLs are not supported by the %s storage backend", s.storage.Name())
+	}
+	var opts storage.PresignOptions
+	switch method {
+	case http.MethodGet:
+		opts.ResponseContentType = req.GetContentType()
+	case http.MethodPut:
+		opts.ContentType = req.GetContentType()
+		if opts.ContentType == "" {
+			opts.ContentType = "application/octet-stream"
+		}
+	case http.MethodHead, http.MethodDelete:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "method must be GET, PUT, DELETE or HEAD")
+	}
+
+	presigned, signedHeaders, err := presigner.Presign(ctx, method, call.scope.Bucket, call.scope.Key(req.GetFilename()), expiry, opts)
+	if err != nil {
+		s.logger.Error().Err(err).Str("correlation_id", call.correlationID).Str("filename", req.GetFilename()).Str("method", method).Msg("Failed to presign URL")
+		return nil, toStatus(err, "failed to generate presigned URL")
+	}
+	resp := &storagepb.PresignResponse{
+		Url:       presigned.String(),
+		Method:    method,
+		ExpiresAt: timestamppb.New(time.Now().UTC().Add(expiry)),
+	}
+	if len(signedHeaders) > 0 {
+		resp.Headers = make(map[string]string, len(signedHeaders))
+		for name := range signedHeaders {
+			resp.Headers[name] = signedHeaders.Get(name)
+		}
+	}
+	return resp, nil
+}
+
+func objectInfo(scope tenancy.Scope, info storage.ObjectInfo) *storagepb.ObjectInfo {
+	out := &storagepb.ObjectInfo{
+		Filename:    scope.Name(info.Key),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		Etag:        info.ETag,
+		Metadata:    info.Metadata,
+	}
+	if !info.LastModified.IsZero() {
+		out.LastModified = timestamppb.New(info.LastModified)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FFmpeg packages videos by running the ffmpeg and ffprobe commands, one
+// H.264/AAC encode per rendition
+type FFmpeg struct {
+	FFmpegPath  string
+	FFprobePath string
+	Renditions  []Rendition
+	// SegmentSeconds is the target segment duration
+	SegmentSeconds int
+}
+
+// Package encodes every rendition no taller than the source, or the smallest
+// one for sources smaller than all of them, and writes the master playlist
+func (f *FFmpeg) Package(ctx context.Context, src, dir string) error {
+	renditions := f.Renditions
+	if height, err := f.probeHeight(ctx, src); err == nil {
+		var fit []Rendition
+		for _, r := range renditions {
+			if r.Height <= height {
+				fit = append(fit, r)
+			}
+		}
+		if len(fit) == 0 {
+			fit = renditions[len(renditions)-1:]
+		}
+		renditions = fit
+	}
+
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range renditions {
+		if err := os.Mkdir(filepath.Join(dir, r.Name()), 0o700); err != nil {
+			return err
+		}
+		if err := f.encode(ctx, src, filepath.Join(dir, r.Name()), r); err != nil {
+			return err
+		}
+		// Peak bandwidth with headroom for audio and muxing overhead
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"avc1.640028,mp4a.40.2\"\n%s/index.m3u8\n",
+			(r.Bitrate+128)*1100, r.Name())
+	}
+	return os.WriteFile(filepath.Join(dir, MasterPlaylist), []byte(master.String()), 0o600)
+}
+
+func (f *FFmpeg) encode(ctx context.Context, src, dir string, r Rendition) error {
+	bitrate := strconv.Itoa(r.Bitrate) + "k"
+	cmd := exec.CommandContext(ctx, f.FFmpegPath,
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+		"-i", src,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", "scale=-2:"+strconv.Itoa(r.Height),
+		"-c:v", "libx264", "-profile:v", "high", "-preset", "veryfast",
+		"-b:v", bitrate, "-maxrate", bitrate, "-bufsize", strconv.Itoa(2*r.Bitrate)+"k",
+		// Keyframes on segment boundaries so renditions switch cleanly
+		"-force_key_frames", "expr:gte(t,n_forced*"+strconv.Itoa(f.SegmentSeconds)+")",
+		"-sc_threshold", "0",
+		"-c:a", "aac", "-b:a", "128k", "-ac", "2",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(f.SegmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "segment_%04d.ts"),
+		filepath.Join(dir, "index.m3u8"),
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg %s: %w: %s", r.Name(), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// probeHeight returns the height of the first video stream
+func (f *FFmpeg) probeHeight(ctx context.Context, src string) (int, error) {
+	out, err := exec.CommandContext(ctx, f.FFprobePath,
+		"-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=height", "-of", "csv=p=0", src,
+	).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
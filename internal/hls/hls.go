@@ -0,0 +1,205 @@
+package hls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// MasterPlaylist is the name of the playlist listing the renditions
+const MasterPlaylist = "master.m3u8"
+
+// Metadata recorded on generated files so they can be traced back to their source
+const (
+	SourceKeyMetadataKey     = storage.SourceKeyMetadataKey
+	SourceVersionMetadataKey = "Source-Version"
+)
+
+// Rendition is one quality level of a stream
+type Rendition struct {
+	// Height in pixels; the width follows the source aspect ratio
+	Height int
+	// Bitrate of the video in kbit/s
+	Bitrate int
+}
+
+// Name names the rendition's folder, e.g. "720p"
+func (r Rendition) Name() string {
+	return strconv.Itoa(r.Height) + "p"
+}
+
+// ParseRenditions reads "height:kbps" entries such as "720:2800"
+func ParseRenditions(entries []string) ([]Rendition, error) {
+	var renditions []Rendition
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		h, b, ok := strings.Cut(entry, ":")
+		height, errH := strconv.Atoi(h)
+		bitrate, errB := strconv.Atoi(b)
+		if !ok || errH != nil || errB != nil || height <= 0 || bitrate <= 0 || height%2 != 0 {
+			return nil, fmt.Errorf("invalid rendition %q, want even height:kbps such as 720:2800", entry)
+		}
+		renditions = append(renditions, Rendition{Height: height, Bitrate: bitrate})
+	}
+	if len(renditions) == 0 {
+		return nil, errors.New("no renditions configured")
+	}
+	return renditions, nil
+}
+
+// Transcoder packages a video file into HLS
+type Transcoder interface {
+	// Package writes MasterPlaylist and the renditions it references into
+	// dir, with paths relative to it
+	Package(ctx context.Context, src, dir string) error
+}
+
+// Prefix returns where the stream of an object is stored in the derived
+// bucket. It changes with the content, so a replaced video never serves a
+// stale stream, but not with metadata-only rewrites of the same content.
+func Prefix(bucket string, info storage.ObjectInfo) string {
+	return fmt.Sprintf("hls/%s/%s/", path.Join(bucket, info.Key), Version(info))
+}
+
+// Version identifies an object's content
+func Version(info storage.ObjectInfo) string {
+	if sum := info.Metadata[checksum.MetadataKey(checksum.SHA256)]; sum != "" {
+		return sum
+	}
+	return info.ETag
+}
+
+// ContentType returns the media type of a generated file
+func ContentType(name string) string {
+	switch path.Ext(name) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	}
+	return "application/octet-stream"
+}
+
+// Packager is a post-upload hook generating HLS streams for uploaded videos
+// into the derived bucket
+type Packager struct {
+	transcoder    Transcoder
+	derivedBucket string
+	maxSize       int64
+	logger        *zerolog.Logger
+}
+
+// NewPackager creates a Packager. Videos larger than maxSize bytes are not
+// packaged; 0 means no limit.
+func NewPackager(transcoder Transcoder, derivedBucket string, maxSize int64, logger *zerolog.Logger) *Packager {
+	return &Packager{transcoder: transcoder, derivedBucket: derivedBucket, maxSize: maxSize, logger: logger}
+}
+
+// Name identifies the hook
+func (p *Packager) Name() string { return "hls" }
+
+// Run packages a video unless its stream already exists
+func (p *Packager) Run(ctx context.Context, backend storage.Backend, bucket, key string) error {
+	info, err := backend.Stat(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info.ContentType, "video/") || (p.maxSize > 0 && info.Size > p.maxSize) {
+		return nil
+	}
+	prefix := Prefix(bucket, info)
+	if _, err := backend.Stat(ctx, p.derivedBucket, prefix+MasterPlaylist); err == nil {
+		return nil
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "hls-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "source")
+	if err := download(ctx, backend, bucket, key, src); err != nil {
+		return err
+	}
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0o700); err != nil {
+		return err
+	}
+	if err := p.transcoder.Package(ctx, src, out); err != nil {
+		return fmt.Errorf("failed to package %s/%s: %w", bucket, key, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, MasterPlaylist)); err != nil {
+		return fmt.Errorf("transcoder produced no %s", MasterPlaylist)
+	}
+
+	metadata := map[string]string{SourceKeyMetadataKey: key, SourceVersionMetadataKey: Version(info)}
+	// The master playlist goes last: once it exists the stream is complete
+	err = filepath.WalkDir(out, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == MasterPlaylist {
+			return err
+		}
+		rel, err := filepath.Rel(out, file)
+		if err != nil {
+			return err
+		}
+		return upload(ctx, backend, p.derivedBucket, prefix+filepath.ToSlash(rel), file, metadata)
+	})
+	if err != nil {
+		return err
+	}
+	if err := upload(ctx, backend, p.derivedBucket, prefix+MasterPlaylist, filepath.Join(out, MasterPlaylist), metadata); err != nil {
+		return err
+	}
+	p.logger.Info().Str("bucket", bucket).Str("key", key).Str("prefix", prefix).Msg("Video packaged for streaming")
+	return nil
+}
+
+func download(ctx context.Context, backend storage.Backend, bucket, key, dst string) error {
+	r, _, err := backend.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func upload(ctx context.Context, backend storage.Backend, bucket, key, src string, metadata map[string]string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = backend.Put(ctx, bucket, key, f, fi.Size(), storage.PutOptions{ContentType: ContentType(key), Metadata: metadata})
+	return err
+}
@@ -0,0 +1,93 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+)
+
+// QuoteETag returns the ETag in the quoted form used by HTTP headers
+func QuoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// NotModified evaluates If-None-Match and If-Modified-Since (RFC 9110 §13.2.2) and
+// reports whether a 304 response should be sent instead of the body
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-None-Match takes precedence; If-Modified-Since is ignored when present
+		return etagMatches(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have second precision
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// etagMatches performs the weak comparison required for If-None-Match
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(QuoteETag(etag), "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule assigns a Cache-Control value to content types matching Pattern
+type Rule struct {
+	Pattern      string
+	CacheControl string
+}
+
+// Policy chooses the Cache-Control header for a content type
+type Policy struct {
+	Default string
+	Rules   []Rule
+}
+
+// ParseRules parses "pattern=value" rules separated by semicolons, e.g.
+// "image/*=public, max-age=86400; text/html=no-cache". Semicolons are used because
+// Cache-Control values themselves contain commas.
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(pattern) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid cache-control rule %q, expected pattern=value", entry)
+		}
+		rules = append(rules, Rule{
+			Pattern:      strings.ToLower(strings.TrimSpace(pattern)),
+			CacheControl: strings.TrimSpace(value),
+		})
+	}
+	return rules, nil
+}
+
+// CacheControl returns the header value for the content type; the first matching rule wins
+func (p *Policy) CacheControl(contentType string) string {
+	mediaType := filetype.BaseType(contentType)
+	for _, rule := range p.Rules {
+		if filetype.Match(rule.Pattern, mediaType) {
+			return rule.CacheControl
+		}
+	}
+	return p.Default
+}
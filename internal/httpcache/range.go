@@ -0,0 +1,82 @@
+package httpcache
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// ErrRangeNotSatisfiable is returned by Range when no byte of the requested
+// range is in the object
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// Range evaluates Range and If-Range (RFC 9110 §14) for an object of size
+// bytes. It returns nil to send the whole object: without a Range header,
+// with a malformed one or one asking for several ranges, and when If-Range
+// no longer matches the object.
+func Range(r *http.Request, size int64, etag string, lastModified time.Time) (*storage.ByteRange, error) {
+	header := r.Header.Get("Range")
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") || !ifRange(r.Header.Get("If-Range"), etag, lastModified) {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	if first == "" {
+		// A suffix: the last bytes of the object
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, ErrRangeNotSatisfiable
+		}
+		n = min(n, size)
+		return &storage.ByteRange{Offset: size - n, Length: n}, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return nil, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, ErrRangeNotSatisfiable
+	}
+	return &storage.ByteRange{Offset: start, Length: end - start + 1}, nil
+}
+
+// ContentRange returns the Content-Range header of a range of an object of
+// size bytes, or of an unsatisfiable range when r is nil
+func ContentRange(r *storage.ByteRange, size int64) string {
+	if r == nil {
+		return "bytes */" + strconv.FormatInt(size, 10)
+	}
+	return "bytes " + strconv.FormatInt(r.Offset, 10) + "-" + strconv.FormatInt(r.Offset+r.Length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// ifRange reports whether an If-Range header, if any, still matches the
+// object. Ranges of a changed object would mix two versions, so an ETag must
+// match strongly and a date exactly.
+func ifRange(header, etag string, lastModified time.Time) bool {
+	if header == "" {
+		return true
+	}
+	if strings.HasPrefix(header, `"`) {
+		return header == QuoteETag(etag)
+	}
+	t, err := http.ParseTime(header)
+	return err == nil && lastModified.Truncate(time.Second).Equal(t)
+}
@@ -0,0 +1,140 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// MaxSourcePixels guards against decompression bombs
+const MaxSourcePixels = 50_000_000
+
+// MaxDimension caps requested output sizes
+const MaxDimension = 4096
+
+// ErrUnsupported is returned for sources that cannot be decoded as images
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Fit describes how an image is mapped onto the requested box
+type Fit string
+
+const (
+	// FitContain scales the image to fit inside the box, preserving aspect ratio
+	FitContain Fit = "contain"
+	// FitCover scales the image to fill the box, cropping the overflow
+	FitCover Fit = "cover"
+	// FitFill stretches the image to exactly the box
+	FitFill Fit = "fill"
+)
+
+// ParseFit validates a fit mode, defaulting to contain
+func ParseFit(s string) (Fit, error) {
+	switch Fit(s) {
+	case "", FitContain:
+		return FitContain, nil
+	case FitCover:
+		return FitCover, nil
+	case FitFill:
+		return FitFill, nil
+	}
+	return "", fmt.Errorf("unknown fit %q", s)
+}
+
+// Decode reads an image, rejecting formats we can't handle and oversized sources
+func Decode(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > MaxSourcePixels {
+		return nil, "", fmt.Errorf("image too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// Resize scales src into a width x height box. A zero width or height is derived
+// from the other dimension using the source aspect ratio.
+func Resize(src image.Image, width, height int, fit Fit) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	if sw == 0 || sh == 0 {
+		return src
+	}
+	if width <= 0 && height <= 0 {
+		width, height = sw, sh
+	} else if width <= 0 {
+		width = max(1, sw*height/sh)
+		fit = FitFill
+	} else if height <= 0 {
+		height = max(1, sh*width/sw)
+		fit = FitFill
+	}
+
+	srcRect := sb
+	dstW, dstH := width, height
+	switch fit {
+	case FitContain:
+		if sw*height > sh*width {
+			dstH = max(1, sh*width/sw)
+		} else {
+			dstW = max(1, sw*height/sh)
+		}
+	case FitCover:
+		// Crop the source to the target aspect ratio around its center
+		if sw*height > sh*width {
+			cw := sh * width / height
+			x0 := sb.Min.X + (sw-cw)/2
+			srcRect = image.Rect(x0, sb.Min.Y, x0+cw, sb.Max.Y)
+		} else {
+			ch := sw * height / width
+			y0 := sb.Min.Y + (sh-ch)/2
+			srcRect = image.Rect(sb.Min.X, y0, sb.Max.X, y0+ch)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Over, nil)
+	return dst
+}
+
+// Encode writes img in a web-friendly format matching the source: JPEG stays JPEG,
+// everything else becomes PNG to preserve transparency. It returns the content type.
+func Encode(w io.Writer, img image.Image, sourceFormat string) (string, error) {
+	switch sourceFormat {
+	case "jpeg":
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case "gif":
+		// Only the first frame is kept; thumbnails are static
+		return "image/gif", gif.Encode(w, img, nil)
+	default:
+		return "image/png", png.Encode(w, img)
+	}
+}
+
+// Extension returns the file extension for an encoded content type
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".png"
+	}
+}
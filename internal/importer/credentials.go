@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+)
+
+// dataKeySize is the AES-256 key sealing the credentials of one import
+const dataKeySize = 32
+
+// errUnreadable is returned for stored credentials the keyring can't decrypt
+var errUnreadable = errors.New("source credentials do not decrypt")
+
+// sealedCredentials are the credentials of an import as stored: encrypted
+// with a data key of their own, itself wrapped by a master key of the keyring
+type sealedCredentials struct {
+	KeyID   string `json:"keyId"`
+	DataKey []byte `json:"dataKey"` // wrapped
+	Sealed  []byte `json:"sealed"`  // nonce || ciphertext
+}
+
+// keep holds the credentials of an import until it ends. Without a keyring
+// they never leave this process, so an import outliving it fails.
+func (s *Service) keep(ctx context.Context, id string, creds Credentials) error {
+	if s.keys == nil {
+		s.memory.Store(id, creds)
+		return nil
+	}
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	keyID, wrapped, err := s.keys.WrapKey(dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to wrap credentials key: %w", err)
+	}
+	return s.store.Put(ctx, credentialsPrefix+id, sealedCredentials{
+		KeyID:   keyID,
+		DataKey: wrapped,
+		// Bound to the import, so they can't be swapped onto another
+		Sealed: aead.Seal(nonce, nonce, plain, []byte(id)),
+	})
+}
+
+// credentials returns the credentials kept for an import, store.ErrNotFound
+// once they are gone
+func (s *Service) credentials(ctx context.Context, id string) (Credentials, error) {
+	var creds Credentials
+	if s.keys == nil {
+		kept, ok := s.memory.Load(id)
+		if !ok {
+			return creds, store.ErrNotFound
+		}
+		return kept.(Credentials), nil
+	}
+	var sealed sealedCredentials
+	if err := s.store.Get(ctx, credentialsPrefix+id, &sealed); err != nil {
+		return creds, err
+	}
+	dataKey, err := s.keys.UnwrapKey(sealed.KeyID, sealed.DataKey)
+	if err != nil {
+		return creds, fmt.Errorf("%w: %v", errUnreadable, err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return creds, err
+	}
+	if len(sealed.Sealed) < aead.NonceSize() {
+		return creds, errUnreadable
+	}
+	nonce, ciphertext := sealed.Sealed[:aead.NonceSize()], sealed.Sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return creds, errUnreadable
+	}
+	err = json.Unmarshal(plain, &creds)
+	return creds, err
+}
+
+// forget deletes the credentials of an import
+func (s *Service) forget(ctx context.Context, id string) {
+	s.memory.Delete(id)
+	// Also removes those stored by earlier versions, in the clear
+	if err := s.store.Delete(ctx, credentialsPrefix+id); err != nil {
+		s.logger.Error().Err(err).Str("import_id", id).Msg("Failed to delete import credentials")
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
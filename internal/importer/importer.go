@@ -0,0 +1,572 @@
+// Package importer copies the objects of another S3 bucket into the managed
+// bucket, for users moving from other providers. The user lends credentials
+// for the source; a background job lists it and stores each object through
+// the file service like an upload, recording the last key done so the import
+// resumes there after a retry or a restart. The credentials are kept apart
+// from the import, sealed with the envelope keyring or else only in memory,
+// and deleted once it ends.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/api/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/fetch"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/keygen"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/tenancy"
+	"github.com/rs/zerolog"
+)
+
+// KindImport is the job kind copying a slice of an import
+const KindImport = "import.s3"
+
+// Import statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+const (
+	recordPrefix      = "imports/"
+	credentialsPrefix = "import-credentials/"
+	// DefaultEndpoint is the source endpoint when none is given
+	DefaultEndpoint = "https://s3.amazonaws.com"
+	// sliceDuration is how long one job copies before queueing the rest of
+	// the import as another job, well within the queue's attempt timeout
+	sliceDuration = 5 * time.Minute
+	// saveInterval is how often progress is recorded while copying
+	saveInterval = 2 * time.Second
+	// maxFailures bounds the failed objects listed on an import
+	maxFailures = 100
+)
+
+var (
+	// ErrNotFound is returned for unknown imports, and those of other callers
+	ErrNotFound = errors.New("import not found")
+	// ErrFinished is returned when canceling an import that already ended
+	ErrFinished = errors.New("import already ended")
+	// ErrInvalidEndpoint is returned for source endpoints that aren't URLs
+	ErrInvalidEndpoint = errors.New("endpoint must be a URL such as " + DefaultEndpoint)
+	// ErrSource is returned when the source can't be listed with the credentials
+	ErrSource = errors.New("source bucket is not readable")
+
+	// errStopped ends a job whose import was canceled or ended meanwhile
+	errStopped = errors.New("import is not running")
+)
+
+// Source is the bucket objects are imported from
+type Source struct {
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+}
+
+// Credentials read the source. Temporary credentials carry a session token.
+type Credentials struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+}
+
+// Request is an import to start and how to store the objects, the settings
+// of an upload through the API
+type Request struct {
+	Source      Source
+	Credentials Credentials
+	// Dir is a sanitized folder below the scope, ending in "/", that the
+	// keys below the source prefix are imported under
+	Dir   string
+	Scope tenancy.Scope
+	// Owner is charged for the files, Actor asked for the import
+	Owner       string
+	Actor       string
+	Policy      *filetype.Policy
+	Algorithms  []checksum.Algorithm
+	Deduplicate bool
+	// SkipExisting keeps files already stored under an imported name instead
+	// of overwriting them
+	SkipExisting bool
+	ExtractExif  bool
+	StripGPS     bool
+}
+
+// Failure is a source object that couldn't be imported
+type Failure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// Progress counts the objects an import went through
+type Progress struct {
+	Copied  int64 `json:"copied"`
+	Bytes   int64 `json:"bytes"`
+	Skipped int64 `json:"skipped"`
+	Failed  int64 `json:"failed"`
+	// Failures lists the first failed objects
+	Failures []Failure `json:"failures,omitempty"`
+	// After is the last source key done; the import resumes after it
+	After string `json:"after,omitempty"`
+}
+
+// Import is the state of an import
+type Import struct {
+	ID           string               `json:"id"`
+	Status       string               `json:"status"`
+	Source       Source               `json:"source"`
+	Dir          string               `json:"dir,omitempty"`
+	Scope        tenancy.Scope        `json:"scope"`
+	Owner        string               `json:"owner,omitempty"`
+	Actor        string               `json:"actor,omitempty"`
+	Policy       *filetype.Policy     `json:"policy,omitempty"`
+	Algorithms   []checksum.Algorithm `json:"algorithms,omitempty"`
+	Deduplicate  bool                 `json:"deduplicate,omitempty"`
+	SkipExisting bool                 `json:"skipExisting,omitempty"`
+	ExtractExif  bool                 `json:"extractExif,omitempty"`
+	StripGPS     bool                 `json:"stripGps,omitempty"`
+	Progress
+	// JobID is the job copying the current slice
+	JobID       string     `json:"jobId,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+type jobPayload struct {
+	ImportID string `json:"importId"`
+}
+
+// Service runs imports as background jobs
+type Service struct {
+	// client holds the endpoint policy, connects to sources and spools objects
+	client *fetch.Client
+	files  service.FileService
+	store  store.Store
+	// keys seal the credentials stored for running imports; without them the
+	// credentials are held in memory
+	keys   storage.KeyWrapper
+	memory sync.Map
+	events events.Publisher
+	jobs   *jobs.Queue
+	logger *zerolog.Logger
+}
+
+// NewService creates a Service and registers its job handler on queue.
+// Source endpoints are checked against the host policy of client.
+// publisher and keys may be nil.
+func NewService(client *fetch.Client, files service.FileService, s store.Store, keys storage.KeyWrapper, publisher events.Publisher, queue *jobs.Queue, logger *zerolog.Logger) *Service {
+	svc := &Service{client: client, files: files, store: s, keys: keys, events: publisher, jobs: queue, logger: logger}
+	queue.Register(KindImport, svc.run)
+	return svc
+}
+
+// Start checks that the source can be listed and queues the import
+func (s *Service) Start(ctx context.Context, req Request) (*Import, error) {
+	if req.Source.Endpoint == "" {
+		req.Source.Endpoint = DefaultEndpoint
+	}
+	src, err := s.source(req.Source, req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	// Refuse wrong credentials or buckets now rather than in the job
+	if err := probe(ctx, src, req.Source); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	imp := &Import{
+		ID:           uuid.New().String(),
+		Status:       StatusRunning,
+		Source:       req.Source,
+		Dir:          req.Dir,
+		Scope:        req.Scope,
+		Owner:        req.Owner,
+		Actor:        req.Actor,
+		Policy:       req.Policy,
+		Algorithms:   req.Algorithms,
+		Deduplicate:  req.Deduplicate,
+		SkipExisting: req.SkipExisting,
+		ExtractExif:  req.ExtractExif,
+		StripGPS:     req.StripGPS,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.keep(ctx, imp.ID, req.Credentials); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, recordPrefix+imp.ID, imp); err != nil {
+		s.forget(ctx, imp.ID)
+		return nil, err
+	}
+	if err := s.schedule(ctx, imp); err != nil {
+		s.forget(ctx, imp.ID)
+		s.store.Delete(ctx, recordPrefix+imp.ID)
+		return nil, err
+	}
+	return imp, nil
+}
+
+// Get returns an import started by actor
+func (s *Service) Get(ctx context.Context, id, actor string) (*Import, error) {
+	imp, err := s.load(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != StatusRunning || imp.JobID == "" {
+		return imp, nil
+	}
+	// The queue fails the job once it runs out of attempts
+	job, err := s.jobs.Get(ctx, imp.JobID)
+	if err != nil || job.Status != jobs.StatusFailed {
+		return imp, nil
+	}
+	if err := s.finish(ctx, imp, StatusFailed, errors.New(job.LastError)); err != nil && !errors.Is(err, errStopped) {
+		return nil, err
+	}
+	return s.load(ctx, id, actor)
+}
+
+// Cancel stops an import started by actor. Objects already copied stay.
+func (s *Service) Cancel(ctx context.Context, id, actor string) (*Import, error) {
+	if _, err := s.load(ctx, id, actor); err != nil {
+		return nil, err
+	}
+	imp, err := store.Update(ctx, s.store, recordPrefix+id, func(rec *Import, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		if rec.Status != StatusRunning {
+			return true, ErrFinished
+		}
+		now := time.Now().UTC()
+		rec.Status = StatusCanceled
+		rec.UpdatedAt = now
+		rec.CompletedAt = &now
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.forget(ctx, id)
+	return &imp, nil
+}
+
+func (s *Service) load(ctx context.Context, id, actor string) (*Import, error) {
+	var imp Import
+	if err := s.store.Get(ctx, recordPrefix+id, &imp); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if imp.Actor != actor {
+		return nil, ErrNotFound
+	}
+	return &imp, nil
+}
+
+// source returns a client of the source bucket, refusing endpoints outside
+// the host policy
+func (s *Service) source(src Source, creds Credentials) (*minio.Client, error) {
+	u, err := url.Parse(src.Endpoint)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, ErrInvalidEndpoint
+	}
+	if err := s.client.Allowed(u); err != nil {
+		return nil, err
+	}
+	return minio.New(u.Host, &minio.Options{
+		Creds:     credentials.NewStaticV4(creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken),
+		Secure:    u.Scheme == "https",
+		Region:    src.Region,
+		Transport: s.client.Transport(),
+	})
+}
+
+// probe lists the first object of the source
+func probe(ctx context.Context, client *minio.Client, src Source) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for object := range client.ListObjects(ctx, src.Bucket, minio.ListObjectsOptions{Prefix: src.Prefix, Recursive: true, MaxKeys: 1}) {
+		if object.Err != nil {
+			return sourceError(object.Err)
+		}
+		break
+	}
+	return nil
+}
+
+// sourceError keeps policy refusals and explains why the source can't be read
+func sourceError(err error) error {
+	if refusal := fetch.Refusal(err); refusal != nil {
+		return refusal
+	}
+	if resp := minio.ToErrorResponse(err); resp.Code != "" {
+		return fmt.Errorf("%w: %s", ErrSource, resp.Message)
+	}
+	return fmt.Errorf("%w: %v", ErrSource, err)
+}
+
+// permanentSourceError reports source errors that retrying won't fix
+func permanentSourceError(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "AccessDenied", "NoSuchBucket", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "InvalidToken":
+		return true
+	}
+	return errors.Is(err, fetch.ErrNotAllowed)
+}
+
+func (s *Service) run(ctx context.Context, job *jobs.Job) error {
+	var payload jobPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var imp Import
+	if err := s.store.Get(ctx, recordPrefix+payload.ImportID, &imp); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if imp.Status != StatusRunning {
+		return nil
+	}
+	creds, err := s.credentials(ctx, imp.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return s.stop(ctx, &imp, errors.New("source credentials are gone"))
+		}
+		if errors.Is(err, errUnreadable) {
+			return s.stop(ctx, &imp, err)
+		}
+		return err
+	}
+	src, err := s.source(imp.Source, creds)
+	if err != nil {
+		return s.stop(ctx, &imp, err)
+	}
+
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	deadline := time.Now().Add(sliceDuration)
+	saved := time.Now()
+	opts := minio.ListObjectsOptions{Prefix: imp.Source.Prefix, Recursive: true, StartAfter: imp.After}
+	for object := range src.ListObjects(listCtx, imp.Source.Bucket, opts) {
+		if object.Err != nil {
+			return s.interrupted(ctx, &imp, object.Err)
+		}
+		// Folder placeholders have nothing to copy
+		if !strings.HasSuffix(object.Key, "/") {
+			if err := s.copy(ctx, src, &imp, object); err != nil {
+				return s.interrupted(ctx, &imp, err)
+			}
+		}
+		imp.After = object.Key
+
+		if time.Now().After(deadline) {
+			return s.continueLater(ctx, &imp)
+		}
+		if time.Since(saved) >= saveInterval {
+			if err := s.save(ctx, &imp); err != nil {
+				if errors.Is(err, errStopped) {
+					return nil
+				}
+				return err
+			}
+			saved = time.Now()
+		}
+	}
+	if err := s.finish(ctx, &imp, StatusSucceeded, nil); err != nil && !errors.Is(err, errStopped) {
+		return err
+	}
+	s.logger.Info().Str("import_id", imp.ID).Str("source_bucket", imp.Source.Bucket).
+		Int64("copied", imp.Copied).Int64("skipped", imp.Skipped).Int64("failed", imp.Failed).Msg("Import completed")
+	return nil
+}
+
+// copy imports one source object, counting it as copied, skipped or failed.
+// It returns the errors that interrupt the import.
+func (s *Service) copy(ctx context.Context, src *minio.Client, imp *Import, object minio.ObjectInfo) error {
+	upload, err := s.copyObject(ctx, src, imp, object)
+	var typeErr *service.TypeError
+	switch {
+	case err == nil:
+		imp.Copied++
+		imp.Bytes += upload.Size
+		s.publish(imp, upload)
+	case errors.Is(err, keygen.ErrKeyExists):
+		imp.Skipped++
+	case errors.Is(err, fetch.ErrTooLarge), errors.Is(err, service.ErrTooLarge), errors.Is(err, service.ErrUnreadable),
+		errors.As(err, &typeErr), minio.ToErrorResponse(err).Code == "NoSuchKey":
+		imp.Failed++
+		if len(imp.Failures) < maxFailures {
+			imp.Failures = append(imp.Failures, Failure{Key: object.Key, Error: err.Error()})
+		}
+	default:
+		return err
+	}
+	return nil
+}
+
+func (s *Service) copyObject(ctx context.Context, src *minio.Client, imp *Import, object minio.ObjectInfo) (service.Upload, error) {
+	body, err := src.GetObject(ctx, imp.Source.Bucket, object.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return service.Upload{}, err
+	}
+	file, err := s.client.Spool(&fetch.Remote{Body: body, Size: object.Size})
+	if err != nil {
+		return service.Upload{}, err
+	}
+	defer file.Close()
+
+	name := strings.TrimPrefix(object.Key, imp.Source.Prefix)
+	strategy := keygen.StrategyOverwrite
+	if imp.SkipExisting {
+		strategy = keygen.StrategyReject
+	}
+	return s.files.Upload(ctx, service.UploadRequest{
+		Scope:       imp.Scope,
+		Dir:         imp.Dir + keygen.SanitizeDir(path.Dir(name)),
+		Filename:    path.Base(name),
+		Body:        file,
+		Size:        file.Size,
+		Policy:      imp.Policy,
+		Algorithms:  imp.Algorithms,
+		Deduplicate: imp.Deduplicate,
+		Strategy:    strategy,
+		Owner:       imp.Owner,
+		ExtractExif: imp.ExtractExif,
+		StripGPS:    imp.StripGPS,
+	})
+}
+
+func (s *Service) publish(imp *Import, upload service.Upload) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(events.Event{
+		ID:          uuid.New().String(),
+		Type:        events.TypeUploaded,
+		Time:        time.Now().UTC(),
+		Bucket:      upload.Bucket,
+		Key:         upload.Key,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		Actor:       imp.Actor,
+		TenantID:    imp.Scope.TenantID,
+	})
+}
+
+// interrupted records the progress before an error. The import fails on
+// errors another attempt won't fix; the job is retried on the others.
+func (s *Service) interrupted(ctx context.Context, imp *Import, err error) error {
+	if permanentSourceError(err) || errors.Is(err, quota.ErrQuotaExceeded) {
+		return s.stop(ctx, imp, err)
+	}
+	imp.LastError = err.Error()
+	if saveErr := s.save(ctx, imp); saveErr != nil {
+		if errors.Is(saveErr, errStopped) {
+			return nil
+		}
+		s.logger.Error().Err(saveErr).Str("import_id", imp.ID).Msg("Failed to record import progress")
+	}
+	return err
+}
+
+// stop fails an import for good
+func (s *Service) stop(ctx context.Context, imp *Import, err error) error {
+	s.logger.Warn().Err(err).Str("import_id", imp.ID).Msg("Import failed")
+	if finishErr := s.finish(ctx, imp, StatusFailed, err); finishErr != nil && !errors.Is(finishErr, errStopped) {
+		return finishErr
+	}
+	return nil
+}
+
+// continueLater records the progress and queues the rest of the import
+func (s *Service) continueLater(ctx context.Context, imp *Import) error {
+	if err := s.save(ctx, imp); err != nil {
+		if errors.Is(err, errStopped) {
+			return nil
+		}
+		return err
+	}
+	return s.schedule(ctx, imp)
+}
+
+// schedule queues a job copying the import from its last key
+func (s *Service) schedule(ctx context.Context, imp *Import) error {
+	job, err := s.jobs.Enqueue(ctx, KindImport, jobPayload{ImportID: imp.ID})
+	if err != nil {
+		return err
+	}
+	_, err = store.Update(ctx, s.store, recordPrefix+imp.ID, func(rec *Import, exists bool) (bool, error) {
+		if !exists {
+			return false, errStopped
+		}
+		rec.JobID = job.ID
+		return true, nil
+	})
+	if errors.Is(err, errStopped) {
+		return nil
+	}
+	imp.JobID = job.ID
+	return err
+}
+
+// save records the progress of a running import
+func (s *Service) save(ctx context.Context, imp *Import) error {
+	return s.update(ctx, imp, func(rec *Import) {})
+}
+
+// finish records the outcome of an import and deletes its credentials
+func (s *Service) finish(ctx context.Context, imp *Import, status string, cause error) error {
+	err := s.update(ctx, imp, func(rec *Import) {
+		rec.Status = status
+		rec.CompletedAt = &rec.UpdatedAt
+		if cause != nil {
+			rec.LastError = cause.Error()
+		}
+	})
+	// An import canceled meanwhile no longer needs them either
+	if err == nil || errors.Is(err, errStopped) {
+		s.forget(ctx, imp.ID)
+	}
+	return err
+}
+
+// update copies the progress of imp onto its record, unless it was canceled
+// or ended meanwhile
+func (s *Service) update(ctx context.Context, imp *Import, fn func(rec *Import)) error {
+	_, err := store.Update(ctx, s.store, recordPrefix+imp.ID, func(rec *Import, exists bool) (bool, error) {
+		if !exists || rec.Status != StatusRunning {
+			return exists, errStopped
+		}
+		rec.Progress = imp.Progress
+		rec.LastError = imp.LastError
+		rec.UpdatedAt = time.Now().UTC()
+		fn(rec)
+		return true, nil
+	})
+	return err
+}
@@ -0,0 +1,272 @@
+// Package integrity audits stored objects: it reads every object of a bucket,
+// or a prefix of it, recomputes the checksums recorded in its metadata at
+// upload, and reports the objects whose content no longer matches. With the
+// metadata database, objects it records that storage no longer has are
+// reported as missing.
+//
+// A verification reads for a few minutes per job, then queues the rest as
+// another job. The result of each job is the report so far, naming the job
+// that continues it; the result of the last job is the complete report.
+package integrity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metadb"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// KindVerify is the job kind of a verification
+const KindVerify = "integrity.verify"
+
+const (
+	// sliceDuration is how long one job reads before queueing the rest,
+	// well within the queue's attempt timeout
+	sliceDuration = 5 * time.Minute
+	// maxProblems bounds the corrupted and missing objects listed
+	maxProblems = 1000
+)
+
+// Problem is an object that failed verification
+type Problem struct {
+	Key string `json:"key"`
+	// Algorithm is the checksum that didn't match, empty for a size mismatch
+	Algorithm string `json:"algorithm,omitempty"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+// Report is the result of a verification, stored on its jobs
+type Report struct {
+	Bucket    string    `json:"bucket"`
+	Prefix    string    `json:"prefix,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	// Complete is set on the last job; earlier jobs name the next in ContinuedBy
+	Complete    bool   `json:"complete"`
+	ContinuedBy string `json:"continuedBy,omitempty"`
+	Duration    string `json:"duration,omitempty"`
+	// Objects and Bytes count what was read. Verified objects matched their
+	// checksums; Unverified ones had none recorded. Skipped objects are
+	// sealed with a customer key and can't be read.
+	Objects    int64 `json:"objects"`
+	Bytes      int64 `json:"bytes"`
+	Verified   int64 `json:"verified"`
+	Unverified int64 `json:"unverified"`
+	Skipped    int64 `json:"skipped"`
+	// Corrupted lists the objects whose content doesn't match, Missing the
+	// keys recorded in the metadata database but not in storage
+	Corrupted []Problem `json:"corrupted"`
+	Missing   []string  `json:"missing"`
+	// CorruptedCount and MissingCount include those beyond the lists
+	CorruptedCount int64 `json:"corruptedCount"`
+	MissingCount   int64 `json:"missingCount"`
+	// LastKey is the last key verified
+	LastKey string `json:"lastKey,omitempty"`
+}
+
+// Checker runs verifications as background jobs
+type Checker struct {
+	backend storage.Backend
+	records *metadb.DB
+	queue   *jobs.Queue
+	logger  *zerolog.Logger
+}
+
+// NewChecker creates a Checker and registers its job handler on queue.
+// records may be nil, in which case missing objects aren't detected.
+func NewChecker(backend storage.Backend, records *metadb.DB, queue *jobs.Queue, logger *zerolog.Logger) *Checker {
+	c := &Checker{backend: backend, records: records, queue: queue, logger: logger}
+	queue.Register(KindVerify, c.run)
+	return c
+}
+
+// payload carries the report so far from one job to the next
+type payload struct {
+	Report Report `json:"report"`
+	// Token resumes the listing; keys up to the report's LastKey are skipped
+	Token string `json:"token,omitempty"`
+}
+
+// Start queues the verification of the objects of bucket below prefix
+func (c *Checker) Start(ctx context.Context, bucket, prefix string) (*jobs.Job, error) {
+	if _, err := c.backend.List(ctx, bucket, storage.ListOptions{Prefix: prefix, Limit: 1}); err != nil {
+		return nil, err
+	}
+	return c.queue.Enqueue(ctx, KindVerify, payload{Report: Report{
+		Bucket:    bucket,
+		Prefix:    prefix,
+		StartedAt: time.Now().UTC(),
+		Corrupted: []Problem{},
+		Missing:   []string{},
+	}})
+}
+
+func (c *Checker) run(ctx context.Context, job *jobs.Job) error {
+	var p payload
+	if err := job.Decode(&p); err != nil {
+		return jobs.Permanent(err)
+	}
+	report := &p.Report
+
+	// Keys recorded in the metadata database past the last key, in order
+	var recorded []string
+	if c.records != nil {
+		keys, err := c.records.Keys(ctx, report.Bucket)
+		if err != nil {
+			return fmt.Errorf("failed to read recorded keys: %w", err)
+		}
+		for key := range keys {
+			if strings.HasPrefix(key, report.Prefix) && key > report.LastKey {
+				recorded = append(recorded, key)
+			}
+		}
+		sort.Strings(recorded)
+	}
+	// missingBefore reports the recorded keys listed before key as missing
+	missingBefore := func(key string) {
+		for len(recorded) > 0 && (key == "" || recorded[0] < key) {
+			report.missing(recorded[0])
+			recorded = recorded[1:]
+		}
+		if len(recorded) > 0 && recorded[0] == key {
+			recorded = recorded[1:]
+		}
+	}
+
+	deadline := time.Now().Add(sliceDuration)
+	opts := storage.ListOptions{Prefix: report.Prefix, ContinuationToken: p.Token}
+	for {
+		page, err := c.backend.List(ctx, report.Bucket, opts)
+		if errors.Is(err, storage.ErrInvalidToken) && opts.ContinuationToken != "" {
+			// The token expired; list again from the start, skipping the keys done
+			opts.ContinuationToken = ""
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", report.Bucket, err)
+		}
+		for _, object := range page.Objects {
+			if object.Key <= report.LastKey {
+				continue
+			}
+			missingBefore(object.Key)
+			if err := c.verify(ctx, report, object.Key); err != nil {
+				return err
+			}
+			report.LastKey = object.Key
+			if time.Now().After(deadline) {
+				return c.continueLater(ctx, job, p, opts.ContinuationToken)
+			}
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+	missingBefore("")
+
+	report.Complete = true
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+	c.logger.Info().
+		Str("bucket", report.Bucket).
+		Str("prefix", report.Prefix).
+		Int64("objects", report.Objects).
+		Int64("corrupted", report.CorruptedCount).
+		Int64("missing", report.MissingCount).
+		Str("duration", report.Duration).
+		Msg("Integrity verification completed")
+	return job.SetResult(report)
+}
+
+// verify reads an object and compares it with its recorded checksums
+func (c *Checker) verify(ctx context.Context, report *Report, key string) error {
+	r, info, err := c.backend.Get(ctx, report.Bucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted since it was listed
+		return nil
+	}
+	if errors.Is(err, storage.ErrCustomerKeyRequired) {
+		// Only the client holds the key
+		report.Skipped++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer r.Close()
+
+	expected := checksum.FromMetadata(info.Metadata)
+	algs := make([]checksum.Algorithm, 0, len(expected))
+	for alg := range expected {
+		algs = append(algs, alg)
+	}
+	counter := &countingReader{r: r}
+	sums, err := checksum.Compute(counter, algs)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	report.Objects++
+	report.Bytes += counter.n
+
+	if info.Size >= 0 && counter.n != info.Size {
+		report.corrupted(Problem{Key: key, Expected: fmt.Sprint(info.Size), Actual: fmt.Sprint(counter.n)})
+		return nil
+	}
+	if len(expected) == 0 {
+		report.Unverified++
+		return nil
+	}
+	for _, alg := range []checksum.Algorithm{checksum.SHA256, checksum.MD5, checksum.CRC32C} {
+		if want, ok := expected[alg]; ok && !checksum.Matches(want, sums[alg]) {
+			report.corrupted(Problem{Key: key, Algorithm: string(alg), Expected: want, Actual: sums[alg]})
+			return nil
+		}
+	}
+	report.Verified++
+	return nil
+}
+
+// continueLater records the report so far on job and queues the rest
+func (c *Checker) continueLater(ctx context.Context, job *jobs.Job, p payload, token string) error {
+	p.Token = token
+	next, err := c.queue.Enqueue(ctx, KindVerify, p)
+	if err != nil {
+		return err
+	}
+	p.Report.ContinuedBy = next.ID
+	return job.SetResult(p.Report)
+}
+
+func (r *Report) corrupted(problem Problem) {
+	r.CorruptedCount++
+	if len(r.Corrupted) < maxProblems {
+		r.Corrupted = append(r.Corrupted, problem)
+	}
+}
+
+func (r *Report) missing(key string) {
+	r.MissingCount++
+	if len(r.Missing) < maxProblems {
+		r.Missing = append(r.Missing, key)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
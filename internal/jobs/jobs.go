@@ -0,0 +1,362 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Job statuses. Jobs that succeed are deleted unless they report a result
+// (see SetResult), so mostly pending, running and failed jobs can be inspected.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusFailed    = "failed"
+	StatusSucceeded = "succeeded"
+)
+
+const (
+	jobPrefix = "jobs/"
+	queueSize = 1024
+	// jobTimeout bounds a single attempt
+	jobTimeout = 10 * time.Minute
+	// keepResults is how many succeeded jobs with a result are kept per kind
+	keepResults = 20
+)
+
+var (
+	// ErrNotFound is returned for unknown or completed jobs
+	ErrNotFound = errors.New("job not found")
+	// ErrClosed is returned when enqueueing on a closed queue
+	ErrClosed = errors.New("job queue is closed")
+)
+
+// Job is a unit of background work persisted in the metadata store so it
+// survives restarts
+type Job struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// SetResult records the outcome of a job. Jobs with a result are kept as
+// succeeded instead of being deleted, so it can be read from the jobs API.
+func (j *Job) SetResult(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	j.Result = data
+	return nil
+}
+
+// Handler runs a job. Returning an error retries the job with backoff until
+// the queue's attempt limit is reached, or fails it at once if the error is
+// Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Permanent marks an error that retrying won't fix
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Queue runs jobs on a fixed pool of workers
+type Queue struct {
+	store       store.Store
+	logger      *zerolog.Logger
+	workers     int
+	maxAttempts int
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	closed   bool
+
+	queue chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewQueue creates a Queue persisting jobs in s. Register handlers, then call Start.
+func NewQueue(s store.Store, logger *zerolog.Logger, workers, maxAttempts int) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Queue{
+		store:       s,
+		logger:      logger,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		handlers:    make(map[string]Handler),
+		queue:       make(chan string, queueSize),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job kind
+func (q *Queue) Register(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// Start resumes jobs left pending or running by a previous process and starts the workers
+func (q *Queue) Start(ctx context.Context) error {
+	jobs, err := q.List(ctx, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
+	}
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	for _, job := range jobs {
+		if job.Status == StatusPending || job.Status == StatusRunning {
+			q.dispatch(job.ID)
+		}
+	}
+	return nil
+}
+
+// Enqueue persists a job and schedules it
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error) {
+	q.mu.RLock()
+	closed := q.closed
+	q.mu.RUnlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Payload:   data,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+		return nil, err
+	}
+	q.dispatch(job.ID)
+	return job, nil
+}
+
+// Get returns a pending, running, failed or kept succeeded job
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := q.store.Get(ctx, jobPrefix+id, &job); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns jobs with the given status (all if empty), oldest first.
+// A limit of 0 returns every job.
+func (q *Queue) List(ctx context.Context, status string, limit int) ([]*Job, error) {
+	keys, err := q.store.List(ctx, jobPrefix)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(keys))
+	for _, key := range keys {
+		var job Job
+		if err := q.store.Get(ctx, key, &job); err != nil {
+			continue
+		}
+		if status == "" || job.Status == status {
+			jobs = append(jobs, &job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// Retry reschedules a failed job with a fresh attempt budget
+func (q *Queue) Retry(ctx context.Context, id string) (*Job, error) {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == StatusFailed {
+		job.Status = StatusPending
+		job.Attempts = 0
+		job.UpdatedAt = time.Now().UTC()
+		if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+			return nil, err
+		}
+		q.dispatch(job.ID)
+	}
+	return job, nil
+}
+
+// Close stops the workers after their current jobs. Queued jobs stay
+// persisted and resume on the next Start.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	q.mu.Unlock()
+	close(q.stop)
+	q.wg.Wait()
+}
+
+// dispatch hands a job to the workers without blocking the caller
+func (q *Queue) dispatch(id string) {
+	select {
+	case q.queue <- id:
+	default:
+		go func() {
+			select {
+			case q.queue <- id:
+			case <-q.stop:
+			}
+		}()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case id := <-q.queue:
+			q.run(id)
+		}
+	}
+}
+
+func (q *Queue) run(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			q.logger.Error().Err(err).Str("job_id", id).Msg("Failed to load job")
+		}
+		return
+	}
+	if job.Status == StatusFailed || job.Status == StatusSucceeded {
+		return
+	}
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		q.fail(ctx, job, fmt.Errorf("no handler for job kind %q", job.Kind))
+		return
+	}
+
+	job.Status = StatusRunning
+	job.Attempts++
+	job.UpdatedAt = time.Now().UTC()
+	if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+		q.logger.Error().Err(err).Str("job_id", id).Msg("Failed to update job")
+		return
+	}
+
+	if err := handler(ctx, job); err != nil {
+		var permanent *permanentError
+		if job.Attempts >= q.maxAttempts || errors.As(err, &permanent) {
+			q.fail(ctx, job, err)
+			return
+		}
+		job.Status = StatusPending
+		job.LastError = err.Error()
+		job.UpdatedAt = time.Now().UTC()
+		if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+			q.logger.Error().Err(err).Str("job_id", id).Msg("Failed to update job")
+			return
+		}
+		backoff := time.Duration(1<<min(job.Attempts, 8)) * time.Second
+		q.logger.Warn().Err(err).Str("job_id", id).Str("kind", job.Kind).Int("attempt", job.Attempts).Dur("backoff", backoff).Msg("Job failed, retrying")
+		time.AfterFunc(backoff, func() { q.dispatch(id) })
+		return
+	}
+
+	if job.Result != nil {
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.UpdatedAt = time.Now().UTC()
+		if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+			q.logger.Error().Err(err).Str("job_id", id).Msg("Failed to update job")
+		}
+		q.pruneResults(ctx, job.Kind)
+		return
+	}
+	if err := q.store.Delete(ctx, jobPrefix+job.ID); err != nil {
+		q.logger.Error().Err(err).Str("job_id", id).Msg("Failed to delete completed job")
+	}
+}
+
+// pruneResults deletes all but the newest keepResults succeeded jobs of a kind
+func (q *Queue) pruneResults(ctx context.Context, kind string) {
+	succeeded, err := q.List(ctx, StatusSucceeded, 0)
+	if err != nil {
+		q.logger.Error().Err(err).Str("kind", kind).Msg("Failed to list succeeded jobs")
+		return
+	}
+	var kept int
+	for i := len(succeeded) - 1; i >= 0; i-- {
+		if succeeded[i].Kind != kind {
+			continue
+		}
+		if kept++; kept > keepResults {
+			if err := q.store.Delete(ctx, jobPrefix+succeeded[i].ID); err != nil {
+				q.logger.Error().Err(err).Str("job_id", succeeded[i].ID).Msg("Failed to delete completed job")
+			}
+		}
+	}
+}
+
+func (q *Queue) fail(ctx context.Context, job *Job, err error) {
+	job.Status = StatusFailed
+	job.LastError = err.Error()
+	job.UpdatedAt = time.Now().UTC()
+	q.logger.Error().Err(err).Str("job_id", job.ID).Str("kind", job.Kind).Int("attempts", job.Attempts).Msg("Job failed")
+	if err := q.store.Put(ctx, jobPrefix+job.ID, job); err != nil {
+		q.logger.Error().Err(err).Str("job_id", job.ID).Msg("Failed to update job")
+	}
+}
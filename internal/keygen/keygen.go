@@ -0,0 +1,115 @@
+package keygen
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxKeyLength keeps generated names within common filesystem and S3 tooling limits
+const maxKeyLength = 255
+
+// Strategy decides what happens when an upload's key already exists
+type Strategy string
+
+const (
+	// StrategyOverwrite replaces the existing object
+	StrategyOverwrite Strategy = "overwrite"
+	// StrategySuffix appends " (1)", " (2)", ... before the extension until the key is free
+	StrategySuffix Strategy = "suffix"
+	// StrategyUUID prefixes the name with a random UUID
+	StrategyUUID Strategy = "uuid"
+	// StrategyReject refuses the upload
+	StrategyReject Strategy = "reject"
+)
+
+// ErrKeyExists is returned when the reject strategy hits an existing key
+var ErrKeyExists = errors.New("object key already exists")
+
+// ParseStrategy validates a strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(strings.ToLower(strings.TrimSpace(s))) {
+	case StrategyOverwrite:
+		return StrategyOverwrite, nil
+	case StrategySuffix:
+		return StrategySuffix, nil
+	case StrategyUUID:
+		return StrategyUUID, nil
+	case StrategyReject:
+		return StrategyReject, nil
+	}
+	return "", fmt.Errorf("unknown collision strategy %q", s)
+}
+
+// Sanitize turns a client-provided filename into a safe object name: directory
+// components and traversal segments are dropped, control and format characters
+// removed, reserved characters replaced, and the result length-limited.
+func Sanitize(name string) string {
+	if !utf8.ValidString(name) {
+		name = strings.ToValidUTF8(name, "")
+	}
+	// Browsers on Windows may send full paths; only the final element is kept
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			// Drop control chars and invisible formatting such as RTL overrides
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	cleaned := strings.Trim(strings.TrimSpace(b.String()), ".")
+	if cleaned == "" {
+		cleaned = "file"
+	}
+	return truncate(cleaned, maxKeyLength)
+}
+
+// SanitizeDir turns a client folder path into a sanitized key prefix ending
+// in "/", dropping empty and traversal segments
+func SanitizeDir(p string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(strings.ReplaceAll(p, "\\", "/"), "/") {
+		if segment = strings.TrimSpace(segment); segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		b.WriteString(Sanitize(segment))
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+// WithSuffix returns name with " (n)" inserted before its extension
+func WithSuffix(name string, n int) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	suffix := fmt.Sprintf(" (%d)", n)
+	return truncate(base, maxKeyLength-len(suffix)-len(ext)) + suffix + ext
+}
+
+// WithUUID returns name prefixed with a random UUID
+func WithUUID(name string) string {
+	id := uuid.New().String()
+	return id + "-" + truncate(name, maxKeyLength-len(id)-1)
+}
+
+// truncate shortens s to at most max bytes without splitting a UTF-8 sequence
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,128 @@
+// Package logging builds the process logger from the LOG_* configuration
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Output formats
+const (
+	// FormatGCP is JSON with Cloud Logging's severity and resource labels
+	FormatGCP = "gcp"
+	// FormatJSON is plain zerolog JSON
+	FormatJSON = "json"
+	// FormatConsole is human-readable output for local development
+	FormatConsole = "console"
+)
+
+// Options configures the process logger
+type Options struct {
+	// Format is gcp (default), json or console
+	Format string
+	// Level is debug, info (default), warn or error
+	Level string
+	// RedactKeys are field names masked in addition to the built-in credentials
+	RedactKeys []string
+	// DebugSampleRate keeps 1 in N debug lines once more than debugBurst were
+	// logged in a second. 0 or 1 logs every line.
+	DebugSampleRate uint32
+}
+
+// debugBurst is how many debug lines a second are logged before sampling
+const debugBurst = 100
+
+// New creates a logger writing to w. Every line passes through the redactor
+// before it is formatted, so no sink sees credentials. The level is applied
+// process-wide so SetLevel can change it later.
+func New(w io.Writer, opts Options) (zerolog.Logger, error) {
+	lvl, err := parseLevel(opts.Level)
+	if err != nil {
+		return zerolog.Logger{}, err
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format == FormatConsole {
+		w = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
+	}
+	w = NewRedactor(w, opts.RedactKeys)
+
+	var logger zerolog.Logger
+	switch format {
+	case FormatGCP, "":
+		// Resource labels (dynamic from environment variables)
+		logger = zerolog.New(w).Hook(severityHook{}).With().
+			Timestamp().
+			Interface("resource", map[string]interface{}{
+				"labels": map[string]interface{}{
+					"project_id": os.Getenv("PROJECT_ID"),
+					"app":        os.Getenv("APP_NAME"),
+					"source":     os.Getenv("APP_SOURCE"),
+				},
+			}).
+			Str("service", os.Getenv("APP_NAME")).
+			Logger()
+	case FormatJSON, FormatConsole:
+		logger = zerolog.New(w).With().Timestamp().Logger()
+	default:
+		return zerolog.Logger{}, fmt.Errorf("invalid log format %q, expected %s, %s or %s", opts.Format, FormatGCP, FormatJSON, FormatConsole)
+	}
+	if opts.DebugSampleRate > 1 {
+		logger = logger.Sample(zerolog.LevelSampler{DebugSampler: &zerolog.BurstSampler{
+			Burst:       debugBurst,
+			Period:      time.Second,
+			NextSampler: &zerolog.BasicSampler{N: opts.DebugSampleRate},
+		}})
+	}
+	zerolog.SetGlobalLevel(lvl)
+	return logger, nil
+}
+
+// SetLevel changes the level of every logger in the process
+func SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(lvl)
+	return nil
+}
+
+func parseLevel(level string) (zerolog.Level, error) {
+	if level == "" {
+		return zerolog.InfoLevel, nil
+	}
+	lvl, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return zerolog.NoLevel, fmt.Errorf("invalid log level %q", level)
+	}
+	return lvl, nil
+}
+
+// severityHook adds the uppercase severity Cloud Logging reads
+type severityHook struct{}
+
+func (severityHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	e.Str("severity", severity(level))
+}
+
+func severity(level zerolog.Level) string {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "DEBUG"
+	case zerolog.WarnLevel:
+		return "WARNING"
+	case zerolog.ErrorLevel:
+		return "ERROR"
+	case zerolog.FatalLevel:
+		return "CRITICAL"
+	case zerolog.PanicLevel:
+		return "ALERT"
+	}
+	return "INFO"
+}
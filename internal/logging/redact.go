@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces masked values
+const Redacted = "[REDACTED]"
+
+// defaultRedactKeys are field names whose values are always masked, compared
+// case-insensitively with "-" and "_" ignored
+var defaultRedactKeys = []string{
+	"authorization", "proxy-authorization", "cookie", "set-cookie",
+	"token", "access_token", "refresh_token", "id_token",
+	"password", "secret", "client_secret", "api_key", "x-api-key",
+	"x-sse-customer-key", "x-amz-security-token", "x-amz-signature",
+}
+
+var (
+	// bearerValue and jwtValue catch credentials embedded in free text such as
+	// error messages and URLs
+	bearerValue = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	jwtValue    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// signedQuery catches the credentials of presigned S3 URLs and Azure SAS
+	// URLs, which grant access to whoever holds them
+	signedQuery = regexp.MustCompile(`(?i)([?&](?:X-Amz-Signature|X-Amz-Credential|X-Amz-Security-Token|Signature|sig)=)[^&\s"]+`)
+)
+
+// redactor masks credentials in JSON log lines before they reach the sink
+type redactor struct {
+	out  io.Writer
+	keys map[string]bool
+	// needles are the spellings of keys looked for before parsing a line
+	needles [][]byte
+}
+
+// NewRedactor wraps w so sensitive fields (defaults plus extraKeys) and bearer
+// tokens or JWTs inside any string value are masked. Lines that don't mention
+// a credential are passed through untouched.
+func NewRedactor(w io.Writer, extraKeys []string) io.Writer {
+	r := &redactor{out: w, keys: make(map[string]bool)}
+	for _, k := range append(defaultRedactKeys, extraKeys...) {
+		normalized := normalizeKey(k)
+		if normalized == "" || r.keys[normalized] {
+			continue
+		}
+		r.keys[normalized] = true
+		lower := strings.ToLower(strings.TrimSpace(k))
+		for _, spelling := range []string{normalized, strings.ReplaceAll(lower, "-", "_"), strings.ReplaceAll(lower, "_", "-")} {
+			r.needles = append(r.needles, []byte(spelling))
+		}
+	}
+	return r
+}
+
+func (r *redactor) Write(p []byte) (int, error) {
+	if !r.suspicious(p) {
+		return r.out.Write(p)
+	}
+	var line map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	if err := decoder.Decode(&line); err != nil {
+		// Not a JSON line; mask what can be recognized in the text
+		if _, err := r.out.Write(redactString(p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	r.redactValue(line)
+	redacted, err := json.Marshal(line)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.out.Write(append(redacted, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// suspicious is a cheap check for lines that may need redaction
+func (r *redactor) suspicious(p []byte) bool {
+	if jwtValue.Match(p) || bearerValue.Match(p) || signedQuery.Match(p) {
+		return true
+	}
+	lower := bytes.ToLower(p)
+	for _, needle := range r.needles {
+		if bytes.Contains(lower, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if r.keys[normalizeKey(k)] {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = r.redactValue(child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.redactValue(child)
+		}
+		return v
+	case string:
+		return string(redactString([]byte(v)))
+	}
+	return v
+}
+
+func redactString(s []byte) []byte {
+	s = bearerValue.ReplaceAll(s, []byte("$1 "+Redacted))
+	s = signedQuery.ReplaceAll(s, []byte("${1}"+Redacted))
+	return jwtValue.ReplaceAll(s, []byte(Redacted))
+}
+
+func normalizeKey(k string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(k)))
+}
@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package metadb
+
+// Registers the "sqlite" database/sql driver (pure Go, no cgo)
+import _ "modernc.org/sqlite"
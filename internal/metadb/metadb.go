@@ -0,0 +1,385 @@
+// Package metadb mirrors object records into a SQL database (SQLite or
+// Postgres) so listings, per-owner views and usage accounting can be answered
+// with indexed queries instead of bucket listings.
+//
+// The database/sql driver has to be linked into the binary: build with the
+// sqlite tag for modernc.org/sqlite or the postgres tag for pgx.
+package metadb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// Record is an object as mirrored in the database
+type Record struct {
+	Bucket       string            `json:"bucket" example:"uploads"`
+	Key          string            `json:"key" example:"reports/2024/q1.pdf"`
+	Size         int64             `json:"size" example:"52431"`
+	ETag         string            `json:"etag,omitempty"`
+	SHA256       string            `json:"sha256,omitempty"`
+	ContentType  string            `json:"contentType,omitempty" example:"application/pdf"`
+	Owner        string            `json:"owner,omitempty" example:"user-123"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+	// SyncedAt is when the record was last written from storage
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// RecordFromObject builds a record from a stat and the object's tags
+func RecordFromObject(info storage.ObjectInfo, tags map[string]string) Record {
+	return Record{
+		Bucket:       info.Bucket,
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		SHA256:       info.Metadata[checksum.MetadataKey(checksum.SHA256)],
+		ContentType:  info.ContentType,
+		Owner:        info.Metadata[quota.OwnerMetadataKey],
+		Tags:         tags,
+		LastModified: info.LastModified,
+	}
+}
+
+// Filter selects records of one bucket. Zero fields match everything.
+type Filter struct {
+	Bucket string
+	Prefix string
+	Owner  string
+	// Tags must all be present; an empty value matches any value
+	Tags map[string]string
+	// After continues a listing after this key
+	After string
+	Limit int
+}
+
+// OwnerUsage is the storage used by one owner in a bucket; objects uploaded
+// without an owner are reported under ""
+type OwnerUsage struct {
+	Bucket  string `json:"bucket" example:"uploads"`
+	Owner   string `json:"owner" example:"user-123"`
+	Objects int64  `json:"objects" example:"42"`
+	Bytes   int64  `json:"bytes" example:"10485760"`
+}
+
+// dialect holds the differences between the supported databases
+type dialect struct {
+	// driver is the database/sql driver name
+	driver string
+	// numbered placeholders ($1) instead of ?
+	numbered bool
+	// key is the column type of object keys, which must compare bytewise for
+	// prefix ranges and continuation to match S3 ordering
+	key string
+	// timestamp is the column type of times
+	timestamp string
+}
+
+var dialects = map[string]dialect{
+	"sqlite":   {driver: "sqlite", key: "TEXT", timestamp: "TIMESTAMP"},
+	"postgres": {driver: "pgx", numbered: true, key: `TEXT COLLATE "C"`, timestamp: "TIMESTAMPTZ"},
+}
+
+// DB is a metadata database
+type DB struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Open connects to a metadata database and creates its tables. kind is
+// sqlite or postgres; dsn is passed to the driver.
+func Open(ctx context.Context, kind, dsn string) (*DB, error) {
+	d, ok := dialects[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported metadata database %q", kind)
+	}
+	if !driverRegistered(d.driver) {
+		return nil, fmt.Errorf("metadata database %s is not compiled in; build with -tags %s", kind, kind)
+	}
+	sqlDB, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "sqlite" {
+		// SQLite allows one writer; a single connection avoids SQLITE_BUSY
+		sqlDB.SetMaxOpenConns(1)
+	}
+	db := &DB{db: sqlDB, dialect: d}
+	if err := db.migrate(ctx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func driverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes the connection pool
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+func (db *DB) migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS objects (
+			bucket TEXT NOT NULL,
+			object_key ` + db.dialect.key + ` NOT NULL,
+			size BIGINT NOT NULL,
+			etag TEXT NOT NULL,
+			sha256 TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			owner TEXT NOT NULL,
+			last_modified ` + db.dialect.timestamp + ` NOT NULL,
+			synced_at ` + db.dialect.timestamp + ` NOT NULL,
+			PRIMARY KEY (bucket, object_key)
+		)`,
+		`CREATE INDEX IF NOT EXISTS objects_owner ON objects (owner, bucket)`,
+		`CREATE TABLE IF NOT EXISTS object_tags (
+			bucket TEXT NOT NULL,
+			object_key ` + db.dialect.key + ` NOT NULL,
+			name TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (bucket, object_key, name)
+		)`,
+		`CREATE INDEX IF NOT EXISTS object_tags_name ON object_tags (bucket, name, value)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate metadata database: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebind rewrites ? placeholders for databases using numbered ones
+func (db *DB) rebind(query string) string {
+	if !db.dialect.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Put inserts or replaces a record with its tags
+func (db *DB) Put(ctx context.Context, r Record) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, db.rebind(`INSERT INTO objects
+		(bucket, object_key, size, etag, sha256, content_type, owner, last_modified, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bucket, object_key) DO UPDATE SET
+			size = excluded.size, etag = excluded.etag, sha256 = excluded.sha256,
+			content_type = excluded.content_type, owner = excluded.owner,
+			last_modified = excluded.last_modified, synced_at = excluded.synced_at`),
+		r.Bucket, r.Key, r.Size, r.ETag, r.SHA256, r.ContentType, r.Owner, r.LastModified.UTC(), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, db.rebind(`DELETE FROM object_tags WHERE bucket = ? AND object_key = ?`), r.Bucket, r.Key); err != nil {
+		return err
+	}
+	for name, value := range r.Tags {
+		if _, err := tx.ExecContext(ctx, db.rebind(`INSERT INTO object_tags (bucket, object_key, name, value) VALUES (?, ?, ?, ?)`), r.Bucket, r.Key, name, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete removes a record; removing a missing record is not an error
+func (db *DB) Delete(ctx context.Context, bucket, key string) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"object_tags", "objects"} {
+		if _, err := tx.ExecContext(ctx, db.rebind(`DELETE FROM `+table+` WHERE bucket = ? AND object_key = ?`), bucket, key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// List returns the records matching f in key order
+func (db *DB) List(ctx context.Context, f Filter) ([]Record, error) {
+	where := []string{"o.bucket = ?"}
+	args := []any{f.Bucket}
+	if f.Prefix != "" {
+		// A range keeps the primary key usable, unlike LIKE with escaping
+		where = append(where, "o.object_key >= ?")
+		args = append(args, f.Prefix)
+		if end, ok := prefixEnd(f.Prefix); ok {
+			where = append(where, "o.object_key < ?")
+			args = append(args, end)
+		}
+	}
+	if f.After != "" {
+		where = append(where, "o.object_key > ?")
+		args = append(args, f.After)
+	}
+	if f.Owner != "" {
+		where = append(where, "o.owner = ?")
+		args = append(args, f.Owner)
+	}
+	for name, value := range f.Tags {
+		cond := "EXISTS (SELECT 1 FROM object_tags t WHERE t.bucket = o.bucket AND t.object_key = o.object_key AND t.name = ?"
+		args = append(args, name)
+		if value != "" {
+			cond += " AND t.value = ?"
+			args = append(args, value)
+		}
+		where = append(where, cond+")")
+	}
+	query := `SELECT o.bucket, o.object_key, o.size, o.etag, o.sha256, o.content_type, o.owner, o.last_modified, o.synced_at
+		FROM objects o WHERE ` + strings.Join(where, " AND ") + ` ORDER BY o.object_key`
+	if f.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(f.Limit)
+	}
+
+	rows, err := db.db.QueryContext(ctx, db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.Bucket, &r.Key, &r.Size, &r.ETag, &r.SHA256, &r.ContentType, &r.Owner, &r.LastModified, &r.SyncedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.loadTags(ctx, f.Bucket, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// loadTags fills in the tags of records from one bucket
+func (db *DB) loadTags(ctx context.Context, bucket string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	byKey := make(map[string]*Record, len(records))
+	for i := range records {
+		byKey[records[i].Key] = &records[i]
+	}
+	rows, err := db.db.QueryContext(ctx, db.rebind(`SELECT object_key, name, value FROM object_tags
+		WHERE bucket = ? AND object_key >= ? AND object_key <= ?`),
+		bucket, records[0].Key, records[len(records)-1].Key)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, name, value string
+		if err := rows.Scan(&key, &name, &value); err != nil {
+			return err
+		}
+		if r := byKey[key]; r != nil {
+			if r.Tags == nil {
+				r.Tags = make(map[string]string)
+			}
+			r.Tags[name] = value
+		}
+	}
+	return rows.Err()
+}
+
+// Keys returns the keys and ETags recorded for a bucket
+func (db *DB) Keys(ctx context.Context, bucket string) (map[string]string, error) {
+	rows, err := db.db.QueryContext(ctx, db.rebind(`SELECT object_key, etag FROM objects WHERE bucket = ?`), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make(map[string]string)
+	for rows.Next() {
+		var key, etag string
+		if err := rows.Scan(&key, &etag); err != nil {
+			return nil, err
+		}
+		keys[key] = etag
+	}
+	return keys, rows.Err()
+}
+
+// Usage returns the objects and bytes per owner and bucket. An empty bucket
+// reports every bucket; an empty owner every owner.
+func (db *DB) Usage(ctx context.Context, bucket, owner string) ([]OwnerUsage, error) {
+	var where []string
+	var args []any
+	if bucket != "" {
+		where = append(where, "bucket = ?")
+		args = append(args, bucket)
+	}
+	if owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, owner)
+	}
+	query := `SELECT bucket, owner, COUNT(*), COALESCE(SUM(size), 0) FROM objects`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " GROUP BY bucket, owner ORDER BY bucket, owner"
+
+	rows, err := db.db.QueryContext(ctx, db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := []OwnerUsage{}
+	for rows.Next() {
+		var u OwnerUsage
+		if err := rows.Scan(&u.Bucket, &u.Owner, &u.Objects, &u.Bytes); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// prefixEnd returns the smallest string greater than every string starting
+// with prefix, if there is one
+func prefixEnd(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+	return "", false
+}
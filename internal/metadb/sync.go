@@ -0,0 +1,241 @@
+package metadb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// listenRetryDelay is how long a dropped notification stream waits to reconnect
+const listenRetryDelay = 30 * time.Second
+
+// SyncOptions configures a Syncer
+type SyncOptions struct {
+	// Buckets to mirror; empty mirrors every bucket that exists at startup
+	Buckets []string
+	// Concurrency is how many objects are inspected in parallel during a
+	// reconciliation
+	Concurrency int
+}
+
+// Syncer keeps the database in step with storage. Bucket notifications
+// update records as objects change; a periodic reconciliation catches events
+// missed while disconnected and covers backends without notifications.
+type Syncer struct {
+	backend storage.Backend
+	db      *DB
+	opts    SyncOptions
+	logger  *zerolog.Logger
+}
+
+// NewSyncer creates a Syncer
+func NewSyncer(backend storage.Backend, db *DB, opts SyncOptions, logger *zerolog.Logger) *Syncer {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	return &Syncer{backend: backend, db: db, opts: opts, logger: logger}
+}
+
+// Run listens for bucket notifications and reconciles every interval until
+// ctx is done; a zero interval reconciles only at startup
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	buckets, err := s.buckets(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to start metadata sync")
+		return
+	}
+	for _, bucket := range buckets {
+		if client, ok := storage.S3Client(s.backend, bucket); ok {
+			go s.listen(ctx, client, bucket)
+		}
+	}
+	for {
+		for _, bucket := range buckets {
+			drift, err := s.Reconcile(ctx, bucket)
+			if err != nil && ctx.Err() == nil {
+				metrics.MetadataDrift.Add("errors", 1)
+				s.logger.Warn().Err(err).Str("bucket", bucket).Msg("Failed to reconcile metadata database")
+			}
+			if drift.Total() > 0 {
+				s.logger.Info().Str("bucket", bucket).Int("added", drift.Added).Int("changed", drift.Changed).Int("removed", drift.Removed).Msg("Metadata database drifted from storage")
+			}
+		}
+		metrics.MetadataDrift.Add("runs", 1)
+		if interval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *Syncer) buckets(ctx context.Context) ([]string, error) {
+	if len(s.opts.Buckets) > 0 {
+		return s.opts.Buckets, nil
+	}
+	list, err := s.backend.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	buckets := make([]string, 0, len(list))
+	for _, b := range list {
+		buckets = append(buckets, b.Name)
+	}
+	return buckets, nil
+}
+
+// listen applies the bucket's notifications, reconnecting until ctx is done.
+// Notifications are a MinIO extension; other S3 providers rely on
+// reconciliation alone.
+func (s *Syncer) listen(ctx context.Context, client *minio.Client, bucket string) {
+	events := []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}
+	for {
+		for info := range client.ListenBucketNotification(ctx, bucket, "", "", events) {
+			if info.Err != nil {
+				if minio.ToErrorResponse(info.Err).Code == "APINotSupported" {
+					s.logger.Info().Str("bucket", bucket).Msg("Bucket notifications not supported; metadata database relies on reconciliation")
+					return
+				}
+				if ctx.Err() == nil {
+					s.logger.Warn().Err(info.Err).Str("bucket", bucket).Msg("Bucket notification stream failed")
+				}
+				continue
+			}
+			for _, event := range info.Records {
+				key, err := url.QueryUnescape(event.S3.Object.Key)
+				if err != nil {
+					key = event.S3.Object.Key
+				}
+				if strings.HasPrefix(event.EventName, "s3:ObjectRemoved:") {
+					err = s.db.Delete(ctx, bucket, key)
+				} else {
+					err = s.sync(ctx, bucket, key)
+				}
+				if err != nil && ctx.Err() == nil {
+					s.logger.Warn().Err(err).Str("bucket", bucket).Str("key", key).Str("event", event.EventName).Msg("Failed to apply bucket notification")
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// sync writes the current state of one object, deleting its record if the
+// object is gone
+func (s *Syncer) sync(ctx context.Context, bucket, key string) error {
+	info, err := s.backend.Stat(ctx, bucket, key)
+	if err == nil {
+		var tags map[string]string
+		if tags, err = storage.ObjectTags(ctx, s.backend, bucket, key); err == nil {
+			info.Bucket = bucket
+			return s.db.Put(ctx, RecordFromObject(info, tags))
+		}
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return s.db.Delete(ctx, bucket, key)
+	}
+	return err
+}
+
+// Drift counts the records a reconciliation found out of step with storage:
+// objects nobody told the database about, such as presigned uploads, objects
+// changed since they were recorded, and records of objects deleted
+// out-of-band
+type Drift struct {
+	Added   int
+	Changed int
+	Removed int
+}
+
+// Total is the number of records repaired
+func (d Drift) Total() int {
+	return d.Added + d.Changed + d.Removed
+}
+
+// Reconcile compares the records of a bucket with a full listing, syncing new
+// and changed objects and removing records of deleted ones. Tag changes are
+// only picked up through notifications, as they don't change the ETag.
+func (s *Syncer) Reconcile(ctx context.Context, bucket string) (Drift, error) {
+	var drift Drift
+	start := time.Now()
+	recorded, err := s.db.Keys(ctx, bucket)
+	if err != nil {
+		return drift, err
+	}
+
+	var changed []string
+	opts := storage.ListOptions{}
+	for {
+		page, err := s.backend.List(ctx, bucket, opts)
+		if err != nil {
+			return drift, fmt.Errorf("failed to list bucket %s: %w", bucket, err)
+		}
+		for _, object := range page.Objects {
+			etag, ok := recorded[object.Key]
+			delete(recorded, object.Key)
+			switch {
+			case !ok:
+				drift.Added++
+			case etag != object.ETag:
+				drift.Changed++
+			default:
+				continue
+			}
+			changed = append(changed, object.Key)
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+
+	errs := make([]error, len(changed))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.opts.Concurrency && w < len(changed); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = s.sync(ctx, bucket, changed[i])
+			}
+		}()
+	}
+	for i := range changed {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	// Whatever is left was recorded but no longer listed
+	drift.Removed = len(recorded)
+	for key := range recorded {
+		if err := s.db.Delete(ctx, bucket, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	metrics.MetadataDrift.Add("added", int64(drift.Added))
+	metrics.MetadataDrift.Add("changed", int64(drift.Changed))
+	metrics.MetadataDrift.Add("removed", int64(drift.Removed))
+	if err := errors.Join(errs...); err != nil {
+		return drift, err
+	}
+	s.logger.Debug().Str("bucket", bucket).Int("synced", len(changed)).Int("removed", len(recorded)).Dur("duration", time.Since(start)).Msg("Metadata database reconciled")
+	return drift, nil
+}
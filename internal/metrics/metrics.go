@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Storage failover counters and state, published with the other expvar
+// variables (memstats, cmdline) at the admin metrics endpoint
+var (
+	// StorageFailover counts primary_failures, failover_reads, secondary_errors,
+	// marked_down and marked_up events
+	StorageFailover = expvar.NewMap("storage_failover")
+	// StoragePrimaryHealthy is 1 while reads are served by the primary backend
+	StoragePrimaryHealthy = new(expvar.Int)
+)
+
+// StorageRetries counts the retries of each storage operation (stat, get,
+// list, remove, list_buckets, bucket_exists) and the operations that still
+// failed after retrying (exhausted)
+var StorageRetries = expvar.NewMap("storage_retries")
+
+// Events counts, per sink, the events delivered (<sink>_delivered), those
+// that failed (<sink>_failed) and those dropped because the sink's queue was
+// full (<sink>_dropped). Kafka events count as delivered once handed to the
+// producer; stream_skipped counts events a slow subscriber missed.
+var Events = expvar.NewMap("events")
+
+// Object lock counters: locks acquired, waits that timed out, lock errors
+// (an unreachable Redis) and the total milliseconds spent waiting for and
+// holding locks. held is the number of locks currently held.
+var (
+	ObjectLocks     = expvar.NewMap("object_locks")
+	ObjectLocksHeld = new(expvar.Int)
+)
+
+// Proxied transfer counters and state. saturation is active / max_concurrent,
+// or 0 when concurrency is unlimited.
+var (
+	// Transfers counts rejected transfers and bytes_in/bytes_out
+	Transfers = expvar.NewMap("transfers")
+	// TransfersActive is the number of transfers holding a slot
+	TransfersActive = new(expvar.Int)
+	// TransfersWaiting is the number of transfers queued for a slot
+	TransfersWaiting = new(expvar.Int)
+	// TransfersLimit is the configured maximum of concurrent transfers
+	TransfersLimit = new(expvar.Int)
+)
+
+// Cleanup worker counters and the time of the last run (Unix seconds)
+var (
+	// Cleanup counts runs, errors, temp_objects_deleted, temp_bytes_deleted,
+	// multipart_aborted, shares_purged, upload_links_purged and
+	// presigned_purged
+	Cleanup = expvar.NewMap("cleanup")
+	// CleanupLastRun is when the last successful run finished
+	CleanupLastRun = new(expvar.Int)
+)
+
+// MetadataDrift counts what reconciling the metadata database with storage
+// repaired: objects added or changed behind its back (presigned uploads,
+// direct writes) and records removed for objects deleted out-of-band. runs
+// counts reconciliation passes over every bucket, errors the failed buckets.
+var MetadataDrift = expvar.NewMap("metadata_drift")
+
+// PresignedUploads counts the presigned uploads completed, by confirmation,
+// bucket notification or reconciliation (reconciled counts the latter too),
+// those rejected for their size or quota and those expired without an upload
+var PresignedUploads = expvar.NewMap("presigned_uploads")
+
+func init() {
+	StorageFailover.Set("primary_healthy", StoragePrimaryHealthy)
+	StoragePrimaryHealthy.Set(1)
+
+	Cleanup.Set("last_run", CleanupLastRun)
+
+	ObjectLocks.Set("held", ObjectLocksHeld)
+
+	Transfers.Set("active", TransfersActive)
+	Transfers.Set("waiting", TransfersWaiting)
+	Transfers.Set("max_concurrent", TransfersLimit)
+	Transfers.Set("saturation", expvar.Func(func() any {
+		limit := TransfersLimit.Value()
+		if limit <= 0 {
+			return 0.0
+		}
+		return float64(TransfersActive.Value()) / float64(limit)
+	}))
+}
+
+// Handler serves all published variables as JSON
+func Handler() http.Handler {
+	return expvar.Handler()
+}
@@ -0,0 +1,238 @@
+// Package minioadmin calls the MinIO admin API (/minio/admin/v3) for cluster
+// information, data usage and drive health. It signs requests with the
+// credentials of the storage client, which must have admin permissions.
+package minioadmin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/signer"
+)
+
+const (
+	adminPath = "/minio/admin/v3"
+	// region is MinIO's default; the admin API is not region specific
+	region = "us-east-1"
+)
+
+// Client calls the admin API of the MinIO server behind a storage client
+type Client struct {
+	// storage returns the current storage client, so rotated credentials are
+	// picked up
+	storage func() (*minio.Client, error)
+	http    *http.Client
+}
+
+// New creates a Client for the server behind the client returned by storage
+func New(storage func() (*minio.Client, error)) *Client {
+	return &Client{storage: storage, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Drive is a drive as reported by the server
+type Drive struct {
+	Endpoint       string `json:"endpoint,omitempty"`
+	Path           string `json:"path,omitempty"`
+	State          string `json:"state,omitempty"`
+	UUID           string `json:"uuid,omitempty"`
+	Model          string `json:"model,omitempty"`
+	Healing        bool   `json:"healing,omitempty"`
+	Scanning       bool   `json:"scanning,omitempty"`
+	TotalSpace     uint64 `json:"totalspace,omitempty"`
+	UsedSpace      uint64 `json:"usedspace,omitempty"`
+	AvailableSpace uint64 `json:"availspace,omitempty"`
+	PoolIndex      int    `json:"pool_index"`
+	SetIndex       int    `json:"set_index"`
+	DiskIndex      int    `json:"disk_index"`
+}
+
+// Server is one node of the cluster
+type Server struct {
+	Endpoint   string            `json:"endpoint,omitempty"`
+	State      string            `json:"state,omitempty"`
+	Uptime     int64             `json:"uptime,omitempty"` // seconds
+	Version    string            `json:"version,omitempty"`
+	CommitID   string            `json:"commitID,omitempty"`
+	Edition    string            `json:"edition,omitempty"`
+	PoolNumber int               `json:"poolNumber,omitempty"`
+	Network    map[string]string `json:"network,omitempty"` // endpoint -> online/offline
+	Drives     []Drive           `json:"drives,omitempty"`
+}
+
+// Backend describes the erasure coding setup
+type Backend struct {
+	Type              string `json:"backendType,omitempty"`
+	OnlineDisks       int    `json:"onlineDisks"`
+	OfflineDisks      int    `json:"offlineDisks"`
+	StandardSCParity  int    `json:"standardSCParity"`
+	ReducedSCParity   int    `json:"rrSCParity"`
+	TotalSets         []int  `json:"totalSets,omitempty"`
+	TotalDrivesPerSet []int  `json:"totalDrivesPerSet,omitempty"`
+}
+
+// ServerInfo is the cluster overview returned by the info API
+type ServerInfo struct {
+	Mode         string `json:"mode,omitempty"`
+	Region       string `json:"region,omitempty"`
+	DeploymentID string `json:"deploymentID,omitempty"`
+	Buckets      struct {
+		Count uint64 `json:"count"`
+	} `json:"buckets"`
+	Objects struct {
+		Count uint64 `json:"count"`
+	} `json:"objects"`
+	Usage struct {
+		Size uint64 `json:"size"`
+	} `json:"usage"`
+	Backend Backend  `json:"backend"`
+	Servers []Server `json:"servers,omitempty"`
+}
+
+// BucketUsage is the usage of one bucket
+type BucketUsage struct {
+	Size               uint64            `json:"size"`
+	ObjectsCount       uint64            `json:"objectsCount"`
+	VersionsCount      uint64            `json:"versionsCount"`
+	DeleteMarkersCount uint64            `json:"deleteMarkersCount"`
+	SizesHistogram     map[string]uint64 `json:"objectsSizesHistogram,omitempty"`
+}
+
+// DataUsage is the usage computed by the server's background scanner
+type DataUsage struct {
+	LastUpdate         time.Time              `json:"lastUpdate"`
+	ObjectsCount       uint64                 `json:"objectsCount"`
+	VersionsCount      uint64                 `json:"versionsCount"`
+	DeleteMarkersCount uint64                 `json:"deleteMarkersCount"`
+	ObjectsTotalSize   uint64                 `json:"objectsTotalSize"`
+	BucketsCount       uint64                 `json:"bucketsCount"`
+	Buckets            map[string]BucketUsage `json:"bucketsUsageInfo"`
+}
+
+// HealState is the state of background healing
+type HealState struct {
+	OfflineNodes      []string `json:"offline_nodes,omitempty"`
+	ScannedItemsCount int64    `json:"scannedItemsCount"`
+	HealDisks         []string `json:"healDisks,omitempty"`
+}
+
+// DriveHealth summarizes the drives of every server and the healing in progress
+type DriveHealth struct {
+	Online       int      `json:"online"`
+	Offline      int      `json:"offline"`
+	Healing      int      `json:"healing"`
+	OfflineNodes []string `json:"offlineNodes,omitempty"`
+	HealDisks    []string `json:"healDisks,omitempty"`
+	ScannedItems int64    `json:"scannedItems"`
+	Drives       []Drive  `json:"drives"`
+}
+
+// ServerInfo returns the cluster overview
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	var info ServerInfo
+	if err := c.do(ctx, http.MethodGet, "/info", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// DataUsage returns per-bucket usage as of the server's last scan
+func (c *Client) DataUsage(ctx context.Context) (*DataUsage, error) {
+	var usage DataUsage
+	if err := c.do(ctx, http.MethodGet, "/datausageinfo", &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// HealStatus returns the state of background healing
+func (c *Client) HealStatus(ctx context.Context) (*HealState, error) {
+	var state HealState
+	if err := c.do(ctx, http.MethodPost, "/background-heal/status", &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DriveHealth returns the state of every drive and of background healing
+func (c *Client) DriveHealth(ctx context.Context) (*DriveHealth, error) {
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heal, err := c.HealStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	health := &DriveHealth{
+		OfflineNodes: heal.OfflineNodes,
+		HealDisks:    heal.HealDisks,
+		ScannedItems: heal.ScannedItemsCount,
+		Drives:       []Drive{},
+	}
+	for _, server := range info.Servers {
+		for _, drive := range server.Drives {
+			switch {
+			case drive.Healing:
+				health.Healing++
+			case drive.State == "ok":
+				health.Online++
+			default:
+				health.Offline++
+			}
+			health.Drives = append(health.Drives, drive)
+		}
+	}
+	return health, nil
+}
+
+// do sends a signed admin request and decodes the JSON response into out.
+// Errors from the server are returned as minio.ErrorResponse.
+func (c *Client) do(ctx context.Context, method, path string, out interface{}) error {
+	client, err := c.storage()
+	if err != nil {
+		return err
+	}
+	creds, err := client.GetCreds()
+	if err != nil {
+		return fmt.Errorf("failed to get storage credentials: %w", err)
+	}
+
+	u := *client.EndpointURL()
+	u.Path = adminPath + path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	empty := sha256.Sum256(nil)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(empty[:]))
+	req = signer.SignV4(*req, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, region)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := minio.ErrorResponse{StatusCode: resp.StatusCode}
+		if json.Unmarshal(body, &errResp) != nil || errResp.Code == "" {
+			errResp.Code = http.StatusText(resp.StatusCode)
+			errResp.Message = string(body)
+		}
+		return errResp
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid MinIO admin response: %w", err)
+	}
+	return nil
+}
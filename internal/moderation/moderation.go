@@ -0,0 +1,205 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/filetype"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Object metadata recording the outcome, with canonical header keys
+const (
+	StatusKey    = "Moderation-Status"
+	LabelsKey    = "Moderation-Labels"
+	CheckedAtKey = "Moderation-Checked-At"
+)
+
+// StatusHeader reports the moderation status of a file in responses
+const StatusHeader = "X-Moderation-Status"
+
+// TagKey is the object tag holding the status, on backends with tagging
+const TagKey = "moderation"
+
+// Moderation statuses
+const (
+	StatusApproved = "approved"
+	StatusFlagged  = "flagged"
+)
+
+// Options configures a Moderator
+type Options struct {
+	// URL of the moderation API and the bearer token sent to it
+	URL    string
+	APIKey string
+	// ContentTypes are the types checked, exact or such as image/*
+	ContentTypes []string
+	// Threshold is the label score at which an object is flagged
+	Threshold float64
+	// MaxSize skips larger objects; 0 means no limit
+	MaxSize int64
+	// Quarantine moves flagged objects under QuarantinePrefix in their bucket
+	Quarantine       bool
+	QuarantinePrefix string
+}
+
+// Moderator is a post-upload hook sending media to an external moderation
+// (NSFW detection) API. The object is POSTed as the request body with its
+// content type, and the API answers with JSON such as
+//
+//	{"flagged": false, "labels": {"nsfw": 0.97, "violence": 0.02}}
+//
+// An object is flagged when the API says so or any label scores at least
+// the threshold. The status and flagged labels are written to the object's
+// metadata and tags, and flagged objects are optionally quarantined.
+type Moderator struct {
+	opts   Options
+	types  *filetype.Policy
+	client *http.Client
+	logger *zerolog.Logger
+}
+
+// New creates a Moderator
+func New(opts Options, logger *zerolog.Logger) *Moderator {
+	return &Moderator{
+		opts:   opts,
+		types:  filetype.NewPolicy(opts.ContentTypes, nil),
+		client: &http.Client{Timeout: time.Minute},
+		logger: logger,
+	}
+}
+
+// Name identifies the hook
+func (m *Moderator) Name() string { return "moderation" }
+
+// verdict is the moderation API response
+type verdict struct {
+	Flagged bool               `json:"flagged"`
+	Labels  map[string]float64 `json:"labels"`
+}
+
+// Run moderates an object, unless it isn't media or was already moderated
+func (m *Moderator) Run(ctx context.Context, backend storage.Backend, bucket, key string) error {
+	if m.opts.Quarantine && strings.HasPrefix(key, m.opts.QuarantinePrefix) {
+		return nil
+	}
+	r, info, err := backend.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if info.Metadata[StatusKey] != "" || !m.types.Allows(info.ContentType) || (m.opts.MaxSize > 0 && info.Size > m.opts.MaxSize) {
+		return nil
+	}
+	// The content is needed twice: for the API and to rewrite the metadata
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	v, err := m.classify(ctx, data, info.ContentType)
+	if err != nil {
+		return err
+	}
+	var flagged []string
+	for label, score := range v.Labels {
+		if score >= m.opts.Threshold {
+			flagged = append(flagged, label+"="+strconv.FormatFloat(score, 'f', 2, 64))
+		}
+	}
+	sort.Strings(flagged)
+	status := StatusApproved
+	if v.Flagged || len(flagged) > 0 {
+		status = StatusFlagged
+	}
+	return m.record(ctx, backend, info, data, status, flagged)
+}
+
+// classify sends an object to the moderation API
+func (m *Moderator) classify(ctx context.Context, data []byte, contentType string) (verdict, error) {
+	var v verdict
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.opts.URL, bytes.NewReader(data))
+	if err != nil {
+		return v, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if m.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.opts.APIKey)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return v, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return v, fmt.Errorf("moderation API returned %s: %s", resp.Status, msg)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&v); err != nil {
+		return v, fmt.Errorf("invalid moderation API response: %w", err)
+	}
+	return v, nil
+}
+
+// record rewrites the object with the outcome in its metadata, moving it to
+// quarantine when flagged, and tags it
+func (m *Moderator) record(ctx context.Context, backend storage.Backend, info storage.ObjectInfo, data []byte, status string, labels []string) error {
+	// Leave objects replaced during moderation to their own job
+	current, err := backend.Stat(ctx, info.Bucket, info.Key)
+	if err != nil {
+		return err
+	}
+	if current.ETag != info.ETag {
+		return nil
+	}
+	// Rewriting drops the tags, so carry them over
+	tags, err := storage.ObjectTags(ctx, backend, info.Bucket, info.Key)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string, len(info.Metadata)+3)
+	for k, v := range info.Metadata {
+		metadata[k] = v
+	}
+	metadata[StatusKey] = status
+	metadata[CheckedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	if len(labels) > 0 {
+		metadata[LabelsKey] = strings.Join(labels, ",")
+	}
+	target := info.Key
+	if status == StatusFlagged && m.opts.Quarantine {
+		target = m.opts.QuarantinePrefix + info.Key
+	}
+	if _, err := backend.Put(ctx, info.Bucket, target, bytes.NewReader(data), int64(len(data)),
+		storage.PutOptions{ContentType: info.ContentType, Metadata: metadata}); err != nil {
+		return err
+	}
+
+	if tags == nil {
+		tags = make(map[string]string, 1)
+	}
+	tags[TagKey] = status
+	if err := storage.PutObjectTags(ctx, backend, info.Bucket, target, tags); err != nil && !errors.Is(err, storage.ErrNotSupported) {
+		return err
+	}
+	if target != info.Key {
+		if err := backend.Remove(ctx, info.Bucket, info.Key); err != nil {
+			return err
+		}
+		m.logger.Warn().Str("bucket", info.Bucket).Str("key", info.Key).Str("quarantine", target).Strs("labels", labels).Msg("Flagged object quarantined")
+	} else if status == StatusFlagged {
+		m.logger.Warn().Str("bucket", info.Bucket).Str("key", info.Key).Strs("labels", labels).Msg("Object flagged by moderation")
+	}
+	return nil
+}
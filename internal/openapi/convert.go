@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Version is the OpenAPI version of documents produced by ConvertV3
+const Version = "3.1.0"
+
+// parameter keywords that move into the parameter's schema in OpenAPI 3
+var schemaKeywords = []string{
+	"type", "format", "enum", "default", "items", "collectionFormat",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems",
+}
+
+// ConvertV3 converts the Swagger 2.0 document swag generates into an OpenAPI
+// 3.1 document: definitions become components, body and form parameters become
+// request bodies and response schemas are keyed by media type.
+func ConvertV3(doc []byte) ([]byte, error) {
+	var v2 map[string]interface{}
+	if err := json.Unmarshal(doc, &v2); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	v2 = rewriteRefs(v2).(map[string]interface{})
+
+	v3 := map[string]interface{}{
+		"openapi": Version,
+		"info":    v2["info"],
+		"paths":   map[string]interface{}{},
+	}
+	server := str(v2["basePath"])
+	if host := str(v2["host"]); host != "" {
+		scheme := "http"
+		if schemes := strs(v2["schemes"]); len(schemes) > 0 {
+			scheme = schemes[0]
+		}
+		server = scheme + "://" + host + server
+	}
+	if server != "" {
+		v3["servers"] = []interface{}{map[string]interface{}{"url": server}}
+	}
+	for _, key := range []string{"tags", "security", "externalDocs"} {
+		if v, ok := v2[key]; ok {
+			v3[key] = v
+		}
+	}
+
+	components := map[string]interface{}{}
+	if defs, ok := v2["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = defs
+	}
+	if defs, ok := v2["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := map[string]interface{}{}
+		for name, def := range defs {
+			schemes[name] = convertSecurityScheme(obj(def))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		v3["components"] = components
+	}
+
+	consumes, produces := strs(v2["consumes"]), strs(v2["produces"])
+	paths := v3["paths"].(map[string]interface{})
+	for path, item := range obj(v2["paths"]) {
+		methods := map[string]interface{}{}
+		for method, op := range obj(item) {
+			if method == "parameters" {
+				continue
+			}
+			methods[method] = convertOperation(obj(op), consumes, produces)
+		}
+		paths[path] = methods
+	}
+	return json.MarshalIndent(v3, "", "    ")
+}
+
+func convertOperation(op map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, key := range []string{"operationId", "summary", "description", "tags", "security", "deprecated"} {
+		if v, ok := op[key]; ok {
+			out[key] = v
+		}
+	}
+	if v := strs(op["consumes"]); len(v) > 0 {
+		consumes = v
+	}
+	if v := strs(op["produces"]); len(v) > 0 {
+		produces = v
+	}
+
+	var params []interface{}
+	form := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	var formRequired []string
+	var body map[string]interface{}
+	hasFile := false
+	for _, raw := range arr(op["parameters"]) {
+		p := obj(raw)
+		switch str(p["in"]) {
+		case "body":
+			body = map[string]interface{}{"content": mediaTypes(consumes, "application/json", p["schema"])}
+			if desc := str(p["description"]); desc != "" {
+				body["description"] = desc
+			}
+			if required, _ := p["required"].(bool); required {
+				body["required"] = true
+			}
+		case "formData":
+			schema := paramSchema(p)
+			if schema["type"] == "file" {
+				schema = map[string]interface{}{"type": "string", "format": "binary"}
+				hasFile = true
+			}
+			if desc := str(p["description"]); desc != "" {
+				schema["description"] = desc
+			}
+			form["properties"].(map[string]interface{})[str(p["name"])] = schema
+			if required, _ := p["required"].(bool); required {
+				formRequired = append(formRequired, str(p["name"]))
+			}
+		default:
+			param := map[string]interface{}{"name": p["name"], "in": p["in"], "schema": paramSchema(p)}
+			for _, key := range []string{"description", "required"} {
+				if v, ok := p[key]; ok {
+					param[key] = v
+				}
+			}
+			if str(p["in"]) == "path" {
+				param["required"] = true
+			}
+			params = append(params, param)
+		}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+	if len(form["properties"].(map[string]interface{})) > 0 {
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+		mediaType := "application/x-www-form-urlencoded"
+		if hasFile || contains(consumes, "multipart/form-data") {
+			mediaType = "multipart/form-data"
+		}
+		body = map[string]interface{}{
+			"required": len(formRequired) > 0,
+			"content":  map[string]interface{}{mediaType: map[string]interface{}{"schema": form}},
+		}
+	}
+	if body != nil {
+		out["requestBody"] = body
+	}
+
+	responses := map[string]interface{}{}
+	for code, raw := range obj(op["responses"]) {
+		r := obj(raw)
+		resp := map[string]interface{}{"description": str(r["description"])}
+		if schema, ok := r["schema"]; ok {
+			if obj(schema)["type"] == "file" {
+				schema = map[string]interface{}{"type": "string", "format": "binary"}
+			}
+			resp["content"] = mediaTypes(produces, "application/json", schema)
+		}
+		if headers := obj(r["headers"]); len(headers) > 0 {
+			converted := map[string]interface{}{}
+			for name, h := range headers {
+				header := map[string]interface{}{"schema": paramSchema(obj(h))}
+				if desc := str(obj(h)["description"]); desc != "" {
+					header["description"] = desc
+				}
+				converted[name] = header
+			}
+			resp["headers"] = converted
+		}
+		responses[code] = resp
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]interface{}{"description": "OK"}
+	}
+	out["responses"] = responses
+	return out
+}
+
+// paramSchema lifts a Swagger 2.0 parameter's inline schema keywords into a schema
+func paramSchema(p map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	for _, key := range schemaKeywords {
+		if v, ok := p[key]; ok && key != "collectionFormat" {
+			schema[key] = v
+		}
+	}
+	return schema
+}
+
+func convertSecurityScheme(def map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	switch str(def["type"]) {
+	case "basic":
+		out["type"], out["scheme"] = "http", "basic"
+	case "apiKey":
+		out["type"], out["name"], out["in"] = "apiKey", def["name"], def["in"]
+	case "oauth2":
+		flow := map[string]interface{}{"scopes": def["scopes"]}
+		for _, key := range []string{"authorizationUrl", "tokenUrl"} {
+			if v, ok := def[key]; ok {
+				flow[key] = v
+			}
+		}
+		flows := map[string]string{"implicit": "implicit", "password": "password", "application": "clientCredentials", "accessCode": "authorizationCode"}
+		out["type"] = "oauth2"
+		out["flows"] = map[string]interface{}{flows[str(def["flow"])]: flow}
+	}
+	if desc := str(def["description"]); desc != "" {
+		out["description"] = desc
+	}
+	return out
+}
+
+func mediaTypes(types []string, fallback string, schema interface{}) map[string]interface{} {
+	if len(types) == 0 {
+		types = []string{fallback}
+	}
+	content := map[string]interface{}{}
+	for _, t := range types {
+		content[t] = map[string]interface{}{"schema": schema}
+	}
+	return content
+}
+
+// rewriteRefs points "#/definitions/..." references at "#/components/schemas/..."
+func rewriteRefs(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				v[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+			v[key] = rewriteRefs(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = rewriteRefs(child)
+		}
+	}
+	return v
+}
+
+func obj(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func arr(v interface{}) []interface{} {
+	a, _ := v.([]interface{})
+	return a
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func strs(v interface{}) []string {
+	var out []string
+	for _, item := range arr(v) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
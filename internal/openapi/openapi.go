@@ -0,0 +1,338 @@
+// Package openapi validates requests against the Swagger 2.0 spec generated by
+// swag from the handler annotations.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
+)
+
+// Spec is the subset of a Swagger 2.0 document needed for request validation
+type Spec struct {
+	BasePath    string                          `json:"basePath"`
+	Paths       map[string]map[string]Operation `json:"paths"`
+	Definitions map[string]*Schema              `json:"definitions"`
+
+	// operations maps "METHOD /gin/:path" to its operation
+	operations map[string]*Operation
+	patterns   sync.Map // pattern -> *regexp.Regexp
+}
+
+// Operation is a single method on a path
+type Operation struct {
+	Parameters []Parameter `json:"parameters"`
+
+	spec *Spec
+}
+
+// Parameter is a path, query, header, formData or body parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"` // body parameters
+	Constraints         // other parameters carry their schema inline
+
+	CollectionFormat string `json:"collectionFormat"`
+}
+
+// Constraints holds the validation keywords shared by schemas and non-body parameters
+type Constraints struct {
+	Type      string        `json:"type"`
+	Enum      []interface{} `json:"enum"`
+	Minimum   *float64      `json:"minimum"`
+	Maximum   *float64      `json:"maximum"`
+	MinLength *int          `json:"minLength"`
+	MaxLength *int          `json:"maxLength"`
+	Pattern   string        `json:"pattern"`
+	MinItems  *int          `json:"minItems"`
+	MaxItems  *int          `json:"maxItems"`
+	Items     *Schema       `json:"items"`
+}
+
+// Schema is a JSON schema as used in Swagger 2.0 definitions
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	AllOf      []*Schema          `json:"allOf"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Constraints
+}
+
+// FieldError describes one invalid input
+type FieldError = apierror.FieldError
+
+// Load parses a Swagger 2.0 document
+func Load(doc []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	basePath := strings.TrimRight(spec.BasePath, "/")
+	spec.operations = make(map[string]*Operation)
+	for path, methods := range spec.Paths {
+		// "/files/{filename}" is served by gin as "/api/v1/files/:filename"
+		route := basePath + pathParam.ReplaceAllString(path, ":$1")
+		for method, op := range methods {
+			op := op
+			op.spec = &spec
+			spec.operations[strings.ToUpper(method)+" "+route] = &op
+		}
+	}
+	return &spec, nil
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Operation returns the operation for a gin route, or nil if the spec doesn't
+// describe it
+func (s *Spec) Operation(method, route string) *Operation {
+	return s.operations[method+" "+route]
+}
+
+// Params returns the operation's parameters located in in
+func (o *Operation) Params(in string) []Parameter {
+	var params []Parameter
+	for _, p := range o.Parameters {
+		if p.In == in {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// ValidateParam checks the raw values of a non-body parameter; values is empty
+// when the parameter was not sent
+func (o *Operation) ValidateParam(p Parameter, values []string) []FieldError {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "" && p.Type != "string") {
+		if p.Required {
+			return []FieldError{{Field: p.Name, In: p.In, Message: "is required"}}
+		}
+		return nil
+	}
+	if p.Type == "file" {
+		return nil
+	}
+	if p.Type == "array" {
+		var items []interface{}
+		for _, v := range splitCollection(values, p.CollectionFormat) {
+			item, msg := coerce(v, p.Items)
+			if msg != "" {
+				return []FieldError{{Field: p.Name, In: p.In, Message: msg}}
+			}
+			items = append(items, item)
+		}
+		return o.check(p.In, p.Name, items, &Schema{Constraints: p.Constraints})
+	}
+	value, msg := coerce(values[0], &Schema{Constraints: p.Constraints})
+	if msg != "" {
+		return []FieldError{{Field: p.Name, In: p.In, Message: msg}}
+	}
+	return o.check(p.In, p.Name, value, &Schema{Constraints: p.Constraints})
+}
+
+// ValidateBody checks a decoded JSON body against the body parameter's schema
+func (o *Operation) ValidateBody(p Parameter, body interface{}) []FieldError {
+	if body == nil {
+		if p.Required {
+			return []FieldError{{Field: "body", In: "body", Message: "is required"}}
+		}
+		return nil
+	}
+	if p.Schema == nil {
+		return nil
+	}
+	return o.check("body", "", body, p.Schema)
+}
+
+// check validates a JSON value against a schema, naming fields by their path
+func (o *Operation) check(in, field string, value interface{}, schema *Schema) []FieldError {
+	schema = o.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	var errs []FieldError
+	fail := func(format string, args ...interface{}) []FieldError {
+		name := field
+		if name == "" {
+			name = "body"
+		}
+		return append(errs, FieldError{Field: name, In: in, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, sub := range schema.AllOf {
+		errs = append(errs, o.check(in, field, value, sub)...)
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		for _, name := range schema.Required {
+			if v, ok := obj[name]; !ok || v == nil {
+				errs = append(errs, FieldError{Field: join(field, name), In: in, Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if v, ok := obj[name]; ok && v != nil {
+				errs = append(errs, o.check(in, join(field, name), v, schema.Properties[name])...)
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		if schema.MinItems != nil && len(arr) < *schema.MinItems {
+			return fail("must have at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(arr) > *schema.MaxItems {
+			return fail("must have at most %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range arr {
+				errs = append(errs, o.check(in, fmt.Sprintf("%s[%d]", field, i), item, schema.Items)...)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		if schema.MinLength != nil && len([]rune(s)) < *schema.MinLength {
+			return fail("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && len([]rune(s)) > *schema.MaxLength {
+			return fail("must be at most %d characters", *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			if re, err := o.spec.pattern(schema.Pattern); err == nil && !re.MatchString(s) {
+				return fail("must match %s", schema.Pattern)
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fail("must be a number")
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			return fail("must be an integer")
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fail("must be at least %s", formatNumber(*schema.Minimum))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return fail("must be at most %s", formatNumber(*schema.Maximum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		allowed := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			allowed[i] = fmt.Sprint(v)
+		}
+		return fail("must be one of: %s", strings.Join(allowed, ", "))
+	}
+	return errs
+}
+
+// resolve follows a "#/definitions/..." reference
+func (o *Operation) resolve(schema *Schema) *Schema {
+	for depth := 0; schema != nil && schema.Ref != "" && depth < 32; depth++ {
+		schema = o.spec.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+	}
+	return schema
+}
+
+func (s *Spec) pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns.Store(expr, re)
+	return re, nil
+}
+
+// coerce converts a raw parameter value to the JSON type its schema declares
+func coerce(raw string, schema *Schema) (interface{}, string) {
+	if schema == nil {
+		return raw, ""
+	}
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, "must be an integer"
+		}
+		return float64(n), ""
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, "must be a number"
+		}
+		return n, ""
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "must be true or false"
+		}
+		return b, ""
+	}
+	return raw, ""
+}
+
+func splitCollection(values []string, format string) []string {
+	sep := ","
+	switch format {
+	case "multi":
+		return values
+	case "ssv":
+		sep = " "
+	case "tsv":
+		sep = "\t"
+	case "pipes":
+		sep = "|"
+	}
+	return strings.Split(values[0], sep)
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, v := range enum {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
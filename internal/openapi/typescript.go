@@ -0,0 +1,372 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	nonIdent   = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// TypeScriptClient generates a dependency-free, typed TypeScript client (using
+// fetch) from an OpenAPI 3 document produced by ConvertV3
+func TypeScriptClient(v3 []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(v3, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	g := &tsGenerator{}
+	g.line("// Code generated from the OpenAPI document. DO NOT EDIT.")
+	if title := str(obj(doc["info"])["title"]); title != "" {
+		g.line("// %s %s", title, str(obj(doc["info"])["version"]))
+	}
+	g.line("")
+
+	schemas := obj(obj(doc["components"])["schemas"])
+	for _, name := range sortedKeys(schemas) {
+		schema := obj(schemas[name])
+		if desc := str(schema["description"]); desc != "" {
+			g.line("/** %s */", desc)
+		}
+		if schema["type"] == "object" && schema["additionalProperties"] == nil && len(obj(schema["properties"])) > 0 {
+			g.line("export interface %s %s", typeName(name), g.objectType(schema, ""))
+		} else {
+			g.line("export type %s = %s;", typeName(name), g.tsType(schema, ""))
+		}
+		g.line("")
+	}
+
+	server := ""
+	if servers := arr(doc["servers"]); len(servers) > 0 {
+		server = str(obj(servers[0])["url"])
+	}
+	g.write(tsRuntime, server)
+
+	paths := obj(doc["paths"])
+	for _, path := range sortedKeys(paths) {
+		methods := obj(paths[path])
+		for _, method := range sortedKeys(methods) {
+			g.operation(path, method, obj(methods[method]))
+		}
+	}
+	g.line("}")
+	return g.buf.Bytes(), nil
+}
+
+type tsGenerator struct {
+	buf bytes.Buffer
+}
+
+func (g *tsGenerator) line(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *tsGenerator) write(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// operation writes a client method. Parameters are passed as one object;
+// a request body is passed separately.
+func (g *tsGenerator) operation(path, method string, op map[string]interface{}) {
+	name := str(op["operationId"])
+	if name == "" {
+		name = operationName(method, path)
+	}
+
+	type param struct{ key, name, in, tsType string }
+	var params []param
+	used := map[string]bool{}
+	required := false
+	for _, raw := range arr(op["parameters"]) {
+		p := obj(raw)
+		key := lowerCamel(str(p["name"]))
+		if used[key] {
+			key = lowerCamel(str(p["in"]) + " " + str(p["name"]))
+		}
+		used[key] = true
+		optional := "?"
+		if r, _ := p["required"].(bool); r {
+			optional = ""
+			required = true
+		}
+		params = append(params, param{key: key, name: str(p["name"]), in: str(p["in"]), tsType: key + optional + ": " + g.tsType(obj(p["schema"]), "  ")})
+	}
+
+	var args []string
+	if len(params) > 0 {
+		fields := make([]string, len(params))
+		for i, p := range params {
+			fields[i] = p.tsType
+		}
+		arg := "params: { " + strings.Join(fields, "; ") + " }"
+		if !required {
+			arg += " = {}"
+		}
+		args = append(args, arg)
+	}
+
+	bodyKind := ""
+	if body := obj(op["requestBody"]); body != nil {
+		content := obj(body["content"])
+		optional := "?"
+		if r, _ := body["required"].(bool); r {
+			optional = ""
+		}
+		switch {
+		case content["multipart/form-data"] != nil:
+			bodyKind = "form"
+			args = append(args, "body"+optional+": "+g.tsType(obj(obj(content["multipart/form-data"])["schema"]), "  "))
+		case content["application/x-www-form-urlencoded"] != nil:
+			bodyKind = "urlencoded"
+			args = append(args, "body"+optional+": "+g.tsType(obj(obj(content["application/x-www-form-urlencoded"])["schema"]), "  "))
+		default:
+			bodyKind = "json"
+			var schema map[string]interface{}
+			if keys := sortedKeys(content); len(keys) > 0 {
+				schema = obj(obj(content[keys[0]])["schema"])
+			}
+			args = append(args, "body"+optional+": "+g.tsType(schema, "  "))
+		}
+	}
+	args = append(args, "init?: RequestInit")
+
+	result, binary := g.responseType(obj(op["responses"]))
+
+	g.line("")
+	if summary := str(op["summary"]); summary != "" {
+		g.line("  /** %s */", summary)
+	}
+	g.line("  async %s(%s): Promise<%s> {", name, strings.Join(args, ", "), result)
+	urlPath := pathParam.ReplaceAllStringFunc(path, func(m string) string {
+		for _, p := range params {
+			if p.in == "path" && "{"+p.name+"}" == m {
+				return "${encodeURIComponent(String(params." + p.key + "))}"
+			}
+		}
+		return m
+	})
+	var opts []string
+	for _, in := range []string{"query", "header"} {
+		var fields []string
+		for _, p := range params {
+			if p.in == in {
+				fields = append(fields, fmt.Sprintf("%q: params.%s", p.name, p.key))
+			}
+		}
+		if len(fields) > 0 {
+			opts = append(opts, fmt.Sprintf("%s: { %s },", in, strings.Join(fields, ", ")))
+		}
+	}
+	if bodyKind != "" {
+		opts = append(opts, fmt.Sprintf("body, bodyKind: %q,", bodyKind))
+	}
+	if binary {
+		opts = append(opts, "binary: true,")
+	}
+	call := fmt.Sprintf("    return this.request<%s>(%q, `%s`, ", result, strings.ToUpper(method), urlPath)
+	if len(opts) == 0 {
+		g.line("%s{}, init);", call)
+	} else {
+		g.line("%s{", call)
+		for _, opt := range opts {
+			g.line("      %s", opt)
+		}
+		g.line("    }, init);")
+	}
+	g.line("  }")
+}
+
+// responseType returns the TypeScript type of the first 2xx response
+func (g *tsGenerator) responseType(responses map[string]interface{}) (string, bool) {
+	for _, code := range sortedKeys(responses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		content := obj(obj(responses[code])["content"])
+		for _, mediaType := range sortedKeys(content) {
+			schema := obj(obj(content[mediaType])["schema"])
+			if schema["format"] == "binary" {
+				return "Blob", true
+			}
+			return g.tsType(schema, "  "), false
+		}
+		return "void", false
+	}
+	return "void", false
+}
+
+// tsType renders a schema as a TypeScript type
+func (g *tsGenerator) tsType(schema map[string]interface{}, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	if ref := str(schema["$ref"]); ref != "" {
+		return typeName(ref[strings.LastIndex(ref, "/")+1:])
+	}
+	if all := arr(schema["allOf"]); len(all) > 0 {
+		parts := make([]string, len(all))
+		for i, s := range all {
+			parts[i] = g.tsType(obj(s), indent)
+		}
+		return strings.Join(parts, " & ")
+	}
+	if enum := arr(schema["enum"]); len(enum) > 0 {
+		values := make([]string, len(enum))
+		for i, v := range enum {
+			data, _ := json.Marshal(v)
+			values[i] = string(data)
+		}
+		return strings.Join(values, " | ")
+	}
+	switch schema["type"] {
+	case "string":
+		if schema["format"] == "binary" {
+			return "Blob"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := g.tsType(obj(schema["items"]), indent)
+		if strings.ContainsAny(item, "|&") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if extra := schema["additionalProperties"]; extra != nil {
+			if _, ok := extra.(bool); ok {
+				return "Record<string, unknown>"
+			}
+			return "Record<string, " + g.tsType(obj(extra), indent) + ">"
+		}
+		return g.objectType(schema, indent)
+	}
+	return "unknown"
+}
+
+func (g *tsGenerator) objectType(schema map[string]interface{}, indent string) string {
+	props := obj(schema["properties"])
+	if len(props) == 0 {
+		return "Record<string, unknown>"
+	}
+	required := map[string]bool{}
+	for _, name := range strs(schema["required"]) {
+		required[name] = true
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range sortedKeys(props) {
+		prop := obj(props[name])
+		if desc := str(prop["description"]); desc != "" {
+			fmt.Fprintf(&b, "%s  /** %s */\n", indent, desc)
+		}
+		key := name
+		if !identifier.MatchString(key) {
+			key = fmt.Sprintf("%q", key)
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, key, optional, g.tsType(prop, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typeName turns a swag definition name like "handlers.PresignRequest" into "HandlersPresignRequest"
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range nonIdent.Split(name, -1) {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// operationName derives a method name like "getFilesByFilenameThumbnail"
+func operationName(method, path string) string {
+	parts := []string{method}
+	for _, segment := range strings.Split(path, "/") {
+		if m := pathParam.FindStringSubmatch(segment); m != nil {
+			parts = append(parts, "by "+m[1])
+		} else if segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return lowerCamel(strings.Join(parts, " "))
+}
+
+func lowerCamel(s string) string {
+	name := typeName(s)
+	if name == "" {
+		return "_"
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// tsRuntime is the client class header; %q is the default base URL
+const tsRuntime = `/** RFC 7807 problem details returned for errors */
+export type ApiProblem = { type?: string; title?: string; status?: number; detail?: string; code?: string; correlation_id?: string };
+
+export class ApiError extends Error {
+  constructor(public readonly status: number, public readonly problem: ApiProblem | undefined) {
+    super(problem?.detail ?? problem?.title ?? ` + "`HTTP ${status}`" + `);
+  }
+}
+
+type RequestOptions = {
+  query?: Record<string, unknown>;
+  header?: Record<string, unknown>;
+  body?: unknown;
+  bodyKind?: "json" | "form" | "urlencoded";
+  binary?: boolean;
+};
+
+export class ApiClient {
+  constructor(private readonly baseUrl: string = %q, private readonly defaults: RequestInit = {}) {}
+
+  private async request<T>(method: string, path: string, opts: RequestOptions, init?: RequestInit): Promise<T> {
+    const url = new URL(this.baseUrl.replace(/\/$/, "") + path, globalThis.location?.href);
+    for (const [key, value] of Object.entries(opts.query ?? {})) {
+      if (value !== undefined && value !== null) url.searchParams.set(key, String(value));
+    }
+    const headers = new Headers(this.defaults.headers);
+    new Headers(init?.headers).forEach((value, key) => headers.set(key, value));
+    for (const [key, value] of Object.entries(opts.header ?? {})) {
+      if (value !== undefined && value !== null) headers.set(key, String(value));
+    }
+    let body: BodyInit | undefined;
+    if (opts.body !== undefined) {
+      const fields = Object.entries(opts.body as Record<string, unknown>).filter(([, value]) => value !== undefined && value !== null);
+      if (opts.bodyKind === "form") {
+        const form = new FormData();
+        for (const [key, value] of fields) form.append(key, value instanceof Blob ? value : String(value));
+        body = form;
+      } else if (opts.bodyKind === "urlencoded") {
+        body = new URLSearchParams(fields.map(([key, value]) => [key, String(value)]));
+      } else {
+        headers.set("Content-Type", "application/json");
+        body = JSON.stringify(opts.body);
+      }
+    }
+    const res = await fetch(url, { ...this.defaults, ...init, method, headers, body });
+    if (!res.ok) {
+      const problem = res.headers.get("Content-Type")?.includes("json") ? await res.json().catch(() => undefined) : undefined;
+      throw new ApiError(res.status, problem);
+    }
+    if (opts.binary) return (await res.blob()) as T;
+    if (res.status === 204 || method === "HEAD") return undefined as T;
+    const text = await res.text();
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+`
@@ -0,0 +1,116 @@
+package posthook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// KindRun is the job kind running a hook on an uploaded object
+const KindRun = "posthook.run"
+
+// Hook processes objects after they are uploaded. Hooks run as background
+// jobs, retried when Run fails, so Run must be safe to repeat.
+type Hook interface {
+	// Name identifies the hook in jobs; it must stay stable across restarts
+	Name() string
+	// Run processes an object. Writes go to backend, which is below the
+	// hooks, so they don't trigger hooks again. An object deleted since the
+	// upload is reported as storage.ErrNotFound and not retried.
+	Run(ctx context.Context, backend storage.Backend, bucket, key string) error
+}
+
+type hookRef struct {
+	Hook   string `json:"hook"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+// Backend queues every hook for each object written through it. Objects
+// written around Put other than through NotifyWrite (presigned uploads) are
+// not processed.
+type Backend struct {
+	storage.Backend
+	hooks  map[string]Hook
+	order  []string
+	jobs   *jobs.Queue
+	logger *zerolog.Logger
+}
+
+// New wraps backend and registers the hook job on queue
+func New(backend storage.Backend, queue *jobs.Queue, logger *zerolog.Logger, hooks ...Hook) *Backend {
+	b := &Backend{Backend: backend, hooks: make(map[string]Hook), jobs: queue, logger: logger}
+	for _, hook := range hooks {
+		b.hooks[hook.Name()] = hook
+		b.order = append(b.order, hook.Name())
+	}
+	queue.Register(KindRun, b.run)
+	return b
+}
+
+// Unwrap returns the wrapped backend
+func (b *Backend) Unwrap() storage.Backend { return b.Backend }
+
+func (b *Backend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	info, err := b.Backend.Put(ctx, bucket, key, r, size, opts)
+	if err != nil {
+		return info, err
+	}
+	// Jobs can't read objects sealed with a key only the client holds
+	if storage.CustomerKey(ctx) == nil {
+		b.enqueue(ctx, bucket, key)
+	}
+	return info, nil
+}
+
+// NotifyWrite runs the hooks on an object written directly on storage and
+// passes the notification on
+func (b *Backend) NotifyWrite(ctx context.Context, bucket, key string) error {
+	b.enqueue(ctx, bucket, key)
+	return storage.NotifyWrite(ctx, b.Backend, bucket, key)
+}
+
+// Presign presigns against the wrapped backend
+func (b *Backend) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts storage.PresignOptions) (*url.URL, http.Header, error) {
+	presigner, ok := b.Backend.(storage.Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", storage.ErrNotSupported, b.Backend.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+// enqueue schedules every hook for an object. The write already succeeded,
+// so a failure is logged rather than failing the request.
+func (b *Backend) enqueue(ctx context.Context, bucket, key string) {
+	for _, name := range b.order {
+		if _, err := b.jobs.Enqueue(ctx, KindRun, hookRef{Hook: name, Bucket: bucket, Key: key}); err != nil {
+			b.logger.Error().Err(err).Str("hook", name).Str("bucket", bucket).Str("key", key).Msg("Failed to enqueue post-upload hook")
+		}
+	}
+}
+
+func (b *Backend) run(ctx context.Context, job *jobs.Job) error {
+	var ref hookRef
+	if err := job.Decode(&ref); err != nil {
+		return err
+	}
+	hook, ok := b.hooks[ref.Hook]
+	if !ok {
+		// Queued by a process configured with other hooks
+		b.logger.Warn().Str("hook", ref.Hook).Msg("Skipping unknown post-upload hook")
+		return nil
+	}
+	err := hook.Run(ctx, b.Backend, ref.Bucket, ref.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
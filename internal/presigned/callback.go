@@ -0,0 +1,42 @@
+package presigned
+
+import (
+	"context"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+)
+
+// KindCallback is the job kind delivering callbacks
+const KindCallback = "presigned.callback"
+
+// callbackTimeout bounds one delivery attempt
+const callbackTimeout = 30 * time.Second
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of a callback
+// body, keyed with the callback secret, as for event webhooks
+const SignatureHeader = events.SignatureHeader
+
+type callback struct {
+	URL   string       `json:"url"`
+	Event events.Event `json:"event"`
+}
+
+// callback queues the delivery of a completed upload's event to url. The
+// upload already completed, so failures are logged rather than returned.
+func (s *Service) callback(ctx context.Context, url string, e events.Event) {
+	if _, err := s.jobs.Enqueue(ctx, KindCallback, callback{URL: url, Event: e}); err != nil {
+		s.logger.Error().Err(err).Str("key", e.Key).Msg("Failed to enqueue presigned upload callback")
+	}
+}
+
+func (s *Service) deliver(ctx context.Context, job *jobs.Job) error {
+	var cb callback
+	if err := job.Decode(&cb); err != nil {
+		return err
+	}
+	sink := events.NewWebhookSink(cb.URL, s.opts.CallbackSecret)
+	sink.UseClient(s.callbacks)
+	return sink.Send(ctx, cb.Event)
+}
@@ -0,0 +1,55 @@
+package presigned
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// listenRetryDelay is how long a dropped notification stream waits to reconnect
+const listenRetryDelay = 30 * time.Second
+
+// Listen completes pending uploads as bucket notifications report their
+// objects, until ctx is done. Notifications are a MinIO extension; on other
+// providers uploads are only completed when confirmed.
+func (s *Service) Listen(ctx context.Context, buckets []string) {
+	for _, bucket := range buckets {
+		if client, ok := storage.S3Client(s.backend, bucket); ok {
+			go s.listen(ctx, client, bucket)
+		}
+	}
+}
+
+func (s *Service) listen(ctx context.Context, client *minio.Client, bucket string) {
+	for {
+		for info := range client.ListenBucketNotification(ctx, bucket, "", "", []string{"s3:ObjectCreated:*"}) {
+			if info.Err != nil {
+				if minio.ToErrorResponse(info.Err).Code == "APINotSupported" {
+					s.logger.Info().Str("bucket", bucket).Msg("Bucket notifications not supported; presigned uploads complete when confirmed")
+					return
+				}
+				if ctx.Err() == nil {
+					s.logger.Warn().Err(info.Err).Str("bucket", bucket).Msg("Bucket notification stream failed")
+				}
+				continue
+			}
+			for _, event := range info.Records {
+				key, err := url.QueryUnescape(event.S3.Object.Key)
+				if err != nil {
+					key = event.S3.Object.Key
+				}
+				if err := s.Notify(ctx, bucket, key); err != nil && ctx.Err() == nil {
+					s.logger.Warn().Err(err).Str("bucket", bucket).Str("key", key).Msg("Failed to complete presigned upload")
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
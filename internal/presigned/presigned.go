@@ -0,0 +1,300 @@
+// Package presigned completes uploads that go straight to storage through a
+// presigned PUT URL. The API never sees those bytes, so a presign asking for
+// completion records a pending upload with a callback token. The upload is
+// completed when the client confirms it with the token, or when a bucket
+// notification reports the object: the quota is charged, the backend hears
+// of the write, the upload event is published and a signed copy of it is
+// posted to the presign's callback URL.
+package presigned
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/events"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// Errors returned when an upload can't be completed
+var (
+	ErrNotFound     = errors.New("no pending upload for this object")
+	ErrInvalidToken = errors.New("invalid callback token")
+	ErrNotUploaded  = errors.New("object has not been uploaded yet")
+	ErrTooLarge     = errors.New("object exceeds the maximum allowed size")
+)
+
+const (
+	recordPrefix = "presigned-uploads/"
+	// tokenBytes of randomness give 128-bit, unguessable tokens
+	tokenBytes = 16
+)
+
+// Upload is a presigned PUT waiting to be completed, and once completed what
+// was uploaded. A newer presign for the same object replaces it.
+type Upload struct {
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	TenantID string `json:"tenantId,omitempty"`
+	// Owner is charged for the object, Actor presigned it
+	Owner string `json:"owner,omitempty"`
+	Actor string `json:"actor,omitempty"`
+	// CallbackURL receives the signed upload event
+	CallbackURL string `json:"callbackUrl,omitempty"`
+	// Replaces is the object stored under the key when the URL was
+	// presigned; its owner is credited once the upload completes
+	Replaces *Replaced `json:"replaces,omitempty"`
+	// TokenHash is the hex SHA-256 of the callback token
+	TokenHash string    `json:"tokenHash"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	ETag        string     `json:"etag,omitempty"`
+	ContentType string     `json:"contentType,omitempty"`
+}
+
+// Replaced is the object a presigned upload overwrites. Its ETag and
+// LastModified tell it apart from the upload, which isn't made while they
+// are still the stored object's.
+type Replaced struct {
+	Owner        string    `json:"owner"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Options configures a Service
+type Options struct {
+	// CallbackSecret signs callback bodies when set
+	CallbackSecret string
+	// MaxFileSize returns the upload size limit, 0 for none
+	MaxFileSize func() int64
+	// CallbackTransport connects to callback URLs, which callers choose;
+	// it should refuse internal addresses (see fetch.Client.Transport)
+	CallbackTransport http.RoundTripper
+}
+
+// Service records pending presigned uploads and completes them
+type Service struct {
+	store     store.Store
+	backend   storage.Backend
+	quotas    *quota.Service
+	events    events.Publisher
+	jobs      *jobs.Queue
+	callbacks *http.Client
+	opts      Options
+	logger    *zerolog.Logger
+}
+
+// NewService creates a Service and registers callback delivery on queue.
+// quotas and publisher may be nil.
+func NewService(s store.Store, backend storage.Backend, quotas *quota.Service, publisher events.Publisher, queue *jobs.Queue, opts Options, logger *zerolog.Logger) *Service {
+	svc := &Service{store: s, backend: backend, quotas: quotas, events: publisher, jobs: queue, opts: opts, logger: logger}
+	svc.callbacks = &http.Client{
+		Transport: opts.CallbackTransport,
+		Timeout:   callbackTimeout,
+		// A redirect could lead anywhere; it fails the delivery instead
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	queue.Register(KindCallback, svc.deliver)
+	return svc
+}
+
+func recordKey(bucket, key string) string {
+	return recordPrefix + bucket + "/" + key
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Register records a pending upload and returns its callback token
+func (s *Service) Register(ctx context.Context, upload Upload) (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	upload.TokenHash = hashToken(token)
+	upload.CreatedAt = time.Now().UTC()
+	if err := s.store.Put(ctx, recordKey(upload.Bucket, upload.Key), upload); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Confirm completes the pending upload of an object for the holder of its
+// callback token. Confirming a completed upload again returns it unchanged.
+func (s *Service) Confirm(ctx context.Context, bucket, key, token string) (*Upload, error) {
+	return s.complete(ctx, bucket, key, func(u *Upload) error {
+		if subtle.ConstantTimeCompare([]byte(u.TokenHash), []byte(hashToken(token))) != 1 {
+			return ErrInvalidToken
+		}
+		return nil
+	})
+}
+
+// Notify completes the pending upload of an object reported by a bucket
+// notification; objects without one are ignored
+func (s *Service) Notify(ctx context.Context, bucket, key string) error {
+	_, err := s.complete(ctx, bucket, key, func(*Upload) error { return nil })
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// complete claims the pending upload of an object, charges its owner and
+// announces it. An object over the size limit or quota is removed.
+func (s *Service) complete(ctx context.Context, bucket, key string, authorize func(*Upload) error) (*Upload, error) {
+	var completed bool
+	var stat storage.ObjectInfo
+	upload, err := store.Update(ctx, s.store, recordKey(bucket, key), func(u *Upload, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		if err := authorize(u); err != nil {
+			return true, err
+		}
+		if u.CompletedAt != nil {
+			completed = true
+			return true, nil
+		}
+		var err error
+		if stat, err = s.backend.Stat(ctx, bucket, key); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return true, ErrNotUploaded
+			}
+			return true, err
+		}
+		if r := u.Replaces; r != nil && stat.ETag == r.ETag && stat.LastModified.Equal(r.LastModified) {
+			return true, ErrNotUploaded
+		}
+		now := time.Now().UTC()
+		u.CompletedAt = &now
+		u.Size, u.ETag, u.ContentType = stat.Size, stat.ETag, stat.ContentType
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		return &upload, nil
+	}
+
+	if err := s.charge(ctx, &upload); err != nil {
+		if !errors.Is(err, ErrTooLarge) && !errors.Is(err, quota.ErrQuotaExceeded) {
+			// Charging failed, not the upload: a later attempt completes it
+			s.reopen(bucket, key, upload.TokenHash)
+			return nil, err
+		}
+		metrics.PresignedUploads.Add("rejected", 1)
+		if rmErr := s.backend.Remove(context.Background(), bucket, key); rmErr != nil {
+			s.logger.Error().Err(rmErr).Str("bucket", bucket).Str("key", key).Msg("Failed to remove rejected presigned upload")
+		}
+		_ = s.store.Delete(context.Background(), recordKey(bucket, key))
+		return nil, err
+	}
+	// The object was written on storage, not through the backend
+	if err := storage.NotifyWrite(ctx, s.backend, bucket, key); err != nil {
+		s.logger.Warn().Err(err).Str("bucket", bucket).Str("key", key).Msg("Failed to notify storage of presigned upload")
+	}
+	if r := upload.Replaces; r != nil && r.Owner != "" && s.quotas != nil {
+		// Credits must land even when the request that caused them is cancelled
+		if err := s.quotas.Release(context.Background(), r.Owner, r.Size); err != nil {
+			s.logger.Error().Err(err).Str("subject", r.Owner).Msg("Failed to release quota")
+		}
+	}
+	metrics.PresignedUploads.Add("completed", 1)
+
+	event := events.Event{
+		ID:          uuid.New().String(),
+		Type:        events.TypeUploaded,
+		Time:        upload.CompletedAt.UTC(),
+		Bucket:      bucket,
+		Key:         key,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		Actor:       upload.Actor,
+		TenantID:    upload.TenantID,
+	}
+	if s.events != nil {
+		s.events.Publish(event)
+	}
+	if upload.CallbackURL != "" {
+		s.callback(ctx, upload.CallbackURL, event)
+	}
+	return &upload, nil
+}
+
+// reopen returns a claimed upload to pending, unless a newer presign
+// replaced its record meanwhile
+func (s *Service) reopen(bucket, key, tokenHash string) {
+	_, err := store.Update(context.Background(), s.store, recordKey(bucket, key), func(u *Upload, exists bool) (bool, error) {
+		if !exists || u.TokenHash != tokenHash {
+			return true, ErrNotFound
+		}
+		u.CompletedAt = nil
+		u.Size, u.ETag, u.ContentType = 0, "", ""
+		return true, nil
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		s.logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("Failed to reopen presigned upload")
+	}
+}
+
+// charge enforces the size limit and reserves the upload in its owner's quota
+func (s *Service) charge(ctx context.Context, upload *Upload) error {
+	if s.opts.MaxFileSize != nil {
+		if limit := s.opts.MaxFileSize(); limit > 0 && upload.Size > limit {
+			return ErrTooLarge
+		}
+	}
+	if upload.Owner == "" || s.quotas == nil {
+		return nil
+	}
+	return s.quotas.Reserve(ctx, upload.Owner, upload.Size)
+}
+
+// Purge removes uploads completed before a time, and pending ones whose URL
+// expired before it
+func Purge(ctx context.Context, s store.Store, before time.Time) (int, error) {
+	keys, err := s.List(ctx, recordPrefix)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, key := range keys {
+		var upload Upload
+		if err := s.Get(ctx, key, &upload); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return purged, err
+		}
+		if upload.CompletedAt != nil && !upload.CompletedAt.Before(before) || upload.CompletedAt == nil && !upload.ExpiresAt.Before(before) {
+			continue
+		}
+		if err := s.Delete(ctx, key); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,84 @@
+package presigned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/quota"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+)
+
+// uploadGrace is how long after its URL expired an upload is waited for; one
+// started just before the expiry may still be in flight
+const uploadGrace = time.Hour
+
+// Reconcile completes the pending uploads whose object is stored, which
+// nobody confirmed and no bucket notification reported, and drops those
+// whose URL expired without an upload. It returns how many were completed.
+func (s *Service) Reconcile(ctx context.Context) (int, error) {
+	keys, err := s.store.List(ctx, recordPrefix)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	completed := 0
+	var errs []error
+	for _, key := range keys {
+		var upload Upload
+		if err := s.store.Get(ctx, key, &upload); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return completed, err
+		}
+		if upload.CompletedAt != nil {
+			continue
+		}
+		_, err := s.complete(ctx, upload.Bucket, upload.Key, func(u *Upload) error {
+			// A newer presign may have replaced the record since it was read
+			if u.TokenHash != upload.TokenHash {
+				return ErrNotFound
+			}
+			return nil
+		})
+		switch {
+		case err == nil:
+			completed++
+			metrics.PresignedUploads.Add("reconciled", 1)
+		case errors.Is(err, ErrNotUploaded):
+			if upload.ExpiresAt.Add(uploadGrace).Before(now) {
+				if err := s.store.Delete(ctx, key); err != nil {
+					errs = append(errs, err)
+				}
+				metrics.PresignedUploads.Add("expired", 1)
+			}
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrTooLarge), errors.Is(err, quota.ErrQuotaExceeded):
+			// Replaced, or rejected and removed
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", strings.TrimPrefix(key, recordPrefix), err))
+		}
+	}
+	return completed, errors.Join(errs...)
+}
+
+// Run reconciles every interval until ctx is done
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		completed, err := s.Reconcile(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn().Err(err).Msg("Failed to reconcile presigned uploads")
+		}
+		if completed > 0 {
+			s.logger.Info().Int("completed", completed).Msg("Completed presigned uploads nobody confirmed")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package progress
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// finishedRetention is how long the final state of an upload is kept for
+// subscribers that connect after it finished
+const finishedRetention = 5 * time.Minute
+
+// ErrNotOwner is returned when subscribing to an upload tracked for another caller
+var ErrNotOwner = errors.New("progress: upload belongs to another caller")
+
+// Update represents a single progress notification for an upload
+type Update struct {
+	UploadID         string `json:"uploadId"`
+	BytesTransferred int64  `json:"bytesTransferred"`
+	TotalBytes       int64  `json:"totalBytes"`
+	Done             bool   `json:"done"`
+	Error            string `json:"error,omitempty"`
+}
+
+// Hub fans out upload progress updates to subscribers keyed by upload ID.
+// Uploads and subscribers belong to an owner, an opaque caller identity;
+// subscribers only see the uploads of their own owner.
+type Hub struct {
+	mu sync.Mutex
+	// subscribers maps each subscriber channel to its owner
+	subscribers map[string]map[chan Update]string
+	uploads     map[string]*upload
+}
+
+// upload is the latest state of a tracked upload
+type upload struct {
+	owner    string
+	last     Update
+	finished time.Time // zero while the upload runs
+}
+
+// NewHub creates a new progress Hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan Update]string),
+		uploads:     make(map[string]*upload),
+	}
+}
+
+// Subscribe registers a listener of owner for the given upload ID, which may
+// not have started yet. The returned channel is closed once the upload
+// finishes or the returned cancel function is called; for an upload that
+// already finished it carries the final update and is closed at once.
+// Subscribing to an upload tracked for another owner fails with ErrNotOwner.
+func (h *Hub) Subscribe(uploadID, owner string) (<-chan Update, func(), error) {
+	ch := make(chan Update, 16)
+
+	h.mu.Lock()
+	h.prune(time.Now())
+	if up, ok := h.uploads[uploadID]; ok {
+		if up.owner != owner {
+			h.mu.Unlock()
+			return nil, nil, ErrNotOwner
+		}
+		// Replay the latest known state so late subscribers don't start from zero
+		send(ch, up.last)
+		if !up.finished.IsZero() {
+			h.mu.Unlock()
+			close(ch)
+			return ch, func() {}, nil
+		}
+	}
+	if h.subscribers[uploadID] == nil {
+		h.subscribers[uploadID] = make(map[chan Update]string)
+	}
+	h.subscribers[uploadID][ch] = owner
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if subs, ok := h.subscribers[uploadID]; ok {
+				if _, ok := subs[ch]; ok {
+					delete(subs, ch)
+					close(ch)
+				}
+				if len(subs) == 0 {
+					delete(h.subscribers, uploadID)
+				}
+			}
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Track starts tracking an upload of owner and returns a Tracker that records
+// transferred bytes
+func (h *Hub) Track(uploadID, owner string, totalBytes int64) *Tracker {
+	t := &Tracker{hub: h, uploadID: uploadID, owner: owner, total: totalBytes}
+	h.mu.Lock()
+	h.prune(time.Now())
+	h.uploads[uploadID] = &upload{owner: owner}
+	h.mu.Unlock()
+	h.publish(owner, Update{UploadID: uploadID, TotalBytes: totalBytes})
+	return t
+}
+
+func (h *Hub) publish(owner string, u Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A later upload reusing the ID for another owner replaced this one
+	up, ok := h.uploads[u.UploadID]
+	if !ok || up.owner != owner {
+		return
+	}
+	up.last = u
+	if u.Done {
+		up.finished = time.Now()
+	}
+
+	subs := h.subscribers[u.UploadID]
+	for ch, subOwner := range subs {
+		if subOwner != owner {
+			continue
+		}
+		send(ch, u)
+		if u.Done {
+			close(ch)
+			delete(subs, ch)
+		}
+	}
+	if len(subs) == 0 {
+		delete(h.subscribers, u.UploadID)
+	}
+}
+
+// prune forgets uploads that finished more than finishedRetention ago
+func (h *Hub) prune(now time.Time) {
+	for id, up := range h.uploads {
+		if !up.finished.IsZero() && now.Sub(up.finished) > finishedRetention {
+			delete(h.uploads, id)
+		}
+	}
+}
+
+// send delivers an update without blocking, dropping the oldest queued update if the subscriber is slow
+func send(ch chan Update, u Update) {
+	select {
+	case ch <- u:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	select {
+	case ch <- u:
+	default:
+	}
+}
+
+// Tracker counts bytes as they are uploaded. It implements io.Reader so it can be
+// passed as minio.PutObjectOptions.Progress, which reports each uploaded chunk via Read.
+type Tracker struct {
+	hub         *Hub
+	uploadID    string
+	owner       string
+	total       int64
+	transferred atomic.Int64
+}
+
+// Read records len(p) bytes as transferred
+func (t *Tracker) Read(p []byte) (int, error) {
+	n := t.transferred.Add(int64(len(p)))
+	t.hub.publish(t.owner, Update{UploadID: t.uploadID, BytesTransferred: n, TotalBytes: t.total})
+	return len(p), nil
+}
+
+// Finish publishes the final update for the upload and releases all subscribers
+func (t *Tracker) Finish(err error) {
+	u := Update{
+		UploadID:         t.uploadID,
+		BytesTransferred: t.transferred.Load(),
+		TotalBytes:       t.total,
+		Done:             true,
+	}
+	if err != nil {
+		u.Error = err.Error()
+	}
+	t.hub.publish(t.owner, u)
+}
@@ -0,0 +1,128 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+)
+
+// ErrQuotaExceeded is returned when an upload would exceed the subject's quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+const recordPrefix = "usage/"
+
+// OwnerMetadataKey records the subject that uploaded an object, so deletes and
+// overwrites can credit usage back to the right account
+const OwnerMetadataKey = "Owner"
+
+// Usage is the tracked storage consumption of one subject
+type Usage struct {
+	Subject   string    `json:"subject"`
+	UsedBytes int64     `json:"usedBytes"`
+	Objects   int64     `json:"objects"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Service tracks per-subject usage in the metadata store and enforces limits
+type Service struct {
+	store store.Store
+
+	mu           sync.RWMutex
+	defaultLimit int64
+	overrides    map[string]int64
+}
+
+// NewService creates a quota Service. A limit of 0 means unlimited; overrides are
+// "subject=bytes" entries.
+func NewService(s store.Store, defaultLimit int64, overrides []string) (*Service, error) {
+	svc := &Service{store: s}
+	if err := svc.SetLimits(defaultLimit, overrides); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// SetLimits replaces the default limit and overrides. Usage already recorded is
+// kept; subjects over a lowered limit can delete but not upload.
+func (s *Service) SetLimits(defaultLimit int64, overrides []string) error {
+	parsed := make(map[string]int64, len(overrides))
+	for _, entry := range overrides {
+		subject, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || subject == "" {
+			return fmt.Errorf("invalid quota override %q, expected subject=bytes", entry)
+		}
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid quota override %q: %v", entry, err)
+		}
+		parsed[subject] = limit
+	}
+	s.mu.Lock()
+	s.defaultLimit, s.overrides = defaultLimit, parsed
+	s.mu.Unlock()
+	return nil
+}
+
+func recordKey(subject string) string {
+	return recordPrefix + subject
+}
+
+// Limit returns the quota for a subject in bytes (0 = unlimited)
+func (s *Service) Limit(subject string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if limit, ok := s.overrides[subject]; ok {
+		return limit
+	}
+	return s.defaultLimit
+}
+
+// Usage returns the current usage of a subject
+func (s *Service) Usage(ctx context.Context, subject string) (Usage, error) {
+	var usage Usage
+	if err := s.store.Get(ctx, recordKey(subject), &usage); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Usage{Subject: subject}, nil
+		}
+		return usage, err
+	}
+	return usage, nil
+}
+
+// Reserve atomically charges size bytes to the subject, failing with
+// ErrQuotaExceeded if that would exceed the limit. Callers must Release the
+// reservation if the upload subsequently fails.
+func (s *Service) Reserve(ctx context.Context, subject string, size int64) error {
+	limit := s.Limit(subject)
+	_, err := store.Update(ctx, s.store, recordKey(subject), func(u *Usage, exists bool) (bool, error) {
+		if limit > 0 && u.UsedBytes+size > limit {
+			return false, ErrQuotaExceeded
+		}
+		u.Subject = subject
+		u.UsedBytes += size
+		u.Objects++
+		u.UpdatedAt = time.Now().UTC()
+		return true, nil
+	})
+	return err
+}
+
+// Release credits size bytes back to the subject, e.g. after a delete
+func (s *Service) Release(ctx context.Context, subject string, size int64) error {
+	_, err := store.Update(ctx, s.store, recordKey(subject), func(u *Usage, exists bool) (bool, error) {
+		if !exists {
+			return false, nil
+		}
+		u.UsedBytes = max(0, u.UsedBytes-size)
+		u.Objects = max(0, u.Objects-1)
+		u.UpdatedAt = time.Now().UTC()
+		return true, nil
+	})
+	return err
+}
@@ -0,0 +1,266 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/checksum"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Job kinds run by the jobs queue
+const (
+	KindCopy   = "replication.copy"
+	KindRemove = "replication.remove"
+)
+
+var md5ETag = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Backend writes to a primary backend and mirrors every write to a secondary
+// asynchronously through the jobs queue. Reads are served by the primary.
+// Objects written around Put (presigned uploads) are only mirrored by
+// reconciliation.
+type Backend struct {
+	storage.Backend
+	secondary storage.Backend
+	jobs      *jobs.Queue
+	logger    *zerolog.Logger
+
+	buckets sync.Map // secondary buckets known to exist
+}
+
+type objectRef struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+// New creates a mirroring Backend and registers its jobs on queue
+func New(primary, secondary storage.Backend, queue *jobs.Queue, logger *zerolog.Logger) *Backend {
+	b := &Backend{
+		Backend:   primary,
+		secondary: secondary,
+		jobs:      queue,
+		logger:    logger,
+	}
+	queue.Register(KindCopy, b.runCopy)
+	queue.Register(KindRemove, b.runRemove)
+	return b
+}
+
+// Unwrap returns the primary backend
+func (b *Backend) Unwrap() storage.Backend { return b.Backend }
+
+// Secondary returns the mirror backend
+func (b *Backend) Secondary() storage.Backend { return b.secondary }
+
+func (b *Backend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	info, err := b.Backend.Put(ctx, bucket, key, r, size, opts)
+	if err != nil {
+		return info, err
+	}
+	// The mirror can't read objects sealed with a key only the client holds
+	if storage.CustomerKey(ctx) == nil {
+		b.enqueue(ctx, KindCopy, bucket, key)
+	}
+	return info, nil
+}
+
+func (b *Backend) Remove(ctx context.Context, bucket, key string) error {
+	if err := b.Backend.Remove(ctx, bucket, key); err != nil {
+		return err
+	}
+	b.enqueue(ctx, KindRemove, bucket, key)
+	return nil
+}
+
+// NotifyWrite mirrors an object written directly on the primary
+func (b *Backend) NotifyWrite(ctx context.Context, bucket, key string) error {
+	b.enqueue(ctx, KindCopy, bucket, key)
+	return nil
+}
+
+// Presign presigns against the primary
+func (b *Backend) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts storage.PresignOptions) (*url.URL, http.Header, error) {
+	presigner, ok := b.Backend.(storage.Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", storage.ErrNotSupported, b.Backend.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+// enqueue schedules a mirror job. The primary write already succeeded, so a
+// failure here is logged and left for reconciliation rather than failing the request.
+func (b *Backend) enqueue(ctx context.Context, kind, bucket, key string) {
+	if _, err := b.jobs.Enqueue(ctx, kind, objectRef{Bucket: bucket, Key: key}); err != nil {
+		b.logger.Error().Err(err).Str("kind", kind).Str("bucket", bucket).Str("key", key).Msg("Failed to enqueue replication job")
+	}
+}
+
+func (b *Backend) runCopy(ctx context.Context, job *jobs.Job) error {
+	var ref objectRef
+	if err := job.Decode(&ref); err != nil {
+		return err
+	}
+	return b.copy(ctx, ref.Bucket, ref.Key)
+}
+
+func (b *Backend) runRemove(ctx context.Context, job *jobs.Job) error {
+	var ref objectRef
+	if err := job.Decode(&ref); err != nil {
+		return err
+	}
+	err := b.secondary.Remove(ctx, ref.Bucket, ref.Key)
+	if errors.Is(err, storage.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// copy makes the secondary's object match the primary's current state
+func (b *Backend) copy(ctx context.Context, bucket, key string) error {
+	r, info, err := b.Backend.Get(ctx, bucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		// Deleted since the job was queued
+		return b.secondary.Remove(ctx, bucket, key)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if _, ok := b.buckets.Load(bucket); !ok {
+		if err := storage.EnsureBucket(ctx, b.secondary, bucket); err != nil {
+			return err
+		}
+		b.buckets.Store(bucket, struct{}{})
+	}
+	_, err = b.secondary.Put(ctx, bucket, key, r, info.Size, storage.PutOptions{
+		ContentType: info.ContentType,
+		Metadata:    info.Metadata,
+	})
+	return err
+}
+
+// DriftReport lists keys that differ between the primary and the secondary
+type DriftReport struct {
+	Bucket     string    `json:"bucket"`
+	Prefix     string    `json:"prefix,omitempty"`
+	Checked    int       `json:"checked"`
+	Missing    []string  `json:"missing"`    // on the primary only
+	Mismatched []string  `json:"mismatched"` // on both with different content
+	Extra      []string  `json:"extra"`      // on the secondary only
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// InSync reports whether no drift was found
+func (r *DriftReport) InSync() bool {
+	return len(r.Missing) == 0 && len(r.Mismatched) == 0 && len(r.Extra) == 0
+}
+
+// Detect compares the objects under prefix on both backends
+func (b *Backend) Detect(ctx context.Context, bucket, prefix string) (*DriftReport, error) {
+	primary, err := listAll(ctx, b.Backend, bucket, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list primary: %w", err)
+	}
+	secondary, err := listAll(ctx, b.secondary, bucket, prefix)
+	if err != nil && !errors.Is(err, storage.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to list secondary: %w", err)
+	}
+
+	report := &DriftReport{
+		Bucket:     bucket,
+		Prefix:     prefix,
+		Checked:    len(primary),
+		Missing:    []string{},
+		Mismatched: []string{},
+		Extra:      []string{},
+		CheckedAt:  time.Now().UTC(),
+	}
+	for key, p := range primary {
+		s, ok := secondary[key]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, key)
+		case !sameContent(p, s):
+			report.Mismatched = append(report.Mismatched, key)
+		}
+	}
+	for key := range secondary {
+		if _, ok := primary[key]; !ok {
+			report.Extra = append(report.Extra, key)
+		}
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Mismatched)
+	sort.Strings(report.Extra)
+	return report, nil
+}
+
+// Reconcile detects drift and queues jobs to fix it. Extra objects on the
+// secondary are only removed when prune is set.
+func (b *Backend) Reconcile(ctx context.Context, bucket, prefix string, prune bool) (*DriftReport, error) {
+	report, err := b.Detect(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, keys := range [][]string{report.Missing, report.Mismatched} {
+		for _, key := range keys {
+			if _, err := b.jobs.Enqueue(ctx, KindCopy, objectRef{Bucket: bucket, Key: key}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if prune {
+		for _, key := range report.Extra {
+			if _, err := b.jobs.Enqueue(ctx, KindRemove, objectRef{Bucket: bucket, Key: key}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+func listAll(ctx context.Context, b storage.Backend, bucket, prefix string) (map[string]storage.ObjectInfo, error) {
+	objects := make(map[string]storage.ObjectInfo)
+	opts := storage.ListOptions{Prefix: prefix}
+	for {
+		page, err := b.List(ctx, bucket, opts)
+		if err != nil {
+			return objects, err
+		}
+		for _, object := range page.Objects {
+			objects[object.Key] = object
+		}
+		if page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+}
+
+// sameContent compares sizes and, where both sides have one, the SHA-256 or
+// MD5 ETag. ETags of other forms (multipart, Azure) aren't comparable across providers.
+func sameContent(a, b storage.ObjectInfo) bool {
+	if a.Size != b.Size {
+		return false
+	}
+	shaKey := checksum.MetadataKey(checksum.SHA256)
+	if sa, sb := a.Metadata[shaKey], b.Metadata[shaKey]; sa != "" && sb != "" {
+		return sa == sb
+	}
+	if md5ETag.MatchString(a.ETag) && md5ETag.MatchString(b.ETag) {
+		return a.ETag == b.ETag
+	}
+	return true
+}
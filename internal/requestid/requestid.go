@@ -0,0 +1,66 @@
+// Package requestid carries the IDs of an API request in its context, so calls
+// made on its behalf to the auth service and storage can be traced back to it
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// Header carries the ID the server generates for every request
+	Header = "X-Request-ID"
+	// CorrelationHeader carries the client-supplied ID shared by related requests
+	CorrelationHeader = "X-Correlation-ID"
+)
+
+type contextKey struct{}
+
+type ids struct {
+	request, correlation string
+}
+
+// NewContext returns ctx carrying the request and correlation IDs
+func NewContext(ctx context.Context, requestID, correlationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids{request: requestID, correlation: correlationID})
+}
+
+// FromContext returns the IDs in ctx, empty when there are none
+func FromContext(ctx context.Context) (requestID, correlationID string) {
+	v, _ := ctx.Value(contextKey{}).(ids)
+	return v.request, v.correlation
+}
+
+// SetHeaders adds the IDs in ctx to the headers of an outgoing request
+func SetHeaders(ctx context.Context, h http.Header) {
+	requestID, correlationID := FromContext(ctx)
+	if requestID != "" {
+		h.Set(Header, requestID)
+	}
+	if correlationID != "" {
+		h.Set(CorrelationHeader, correlationID)
+	}
+}
+
+// Transport adds the IDs in each request's context to its headers before
+// passing it to next (http.DefaultTransport when nil). Headers added here are
+// not covered by S3 request signatures, which storage services accept.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return transport{next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestID, correlationID := FromContext(req.Context()); requestID != "" || correlationID != "" {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		SetHeaders(req.Context(), req.Header)
+	}
+	return t.next.RoundTrip(req)
+}
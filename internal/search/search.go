@@ -0,0 +1,450 @@
+// Package search filters objects by key, tags, content type, size and date,
+// and by document text when a content index is configured.
+// Queries are answered from an in-memory index that a background worker keeps
+// up to date, falling back to listing the bucket until it has been indexed.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/contentindex"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Entry is an object as seen by search
+type Entry struct {
+	Key          string            `json:"key" example:"reports/2024/q1.pdf"`
+	Size         int64             `json:"size" example:"52431"`
+	ContentType  string            `json:"contentType,omitempty" example:"application/pdf"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	// Score and Snippet are set on results of content queries
+	Score   float64 `json:"score,omitempty" example:"3.2"`
+	Snippet string  `json:"snippet,omitempty" example:"revenue for the first quarter grew by"`
+}
+
+// Query selects objects. Zero fields match everything.
+type Query struct {
+	Prefix string
+	// Text matches keys containing it, ignoring case
+	Text string
+	// Tags must all be present; an empty value matches any value
+	Tags map[string]string
+	// ContentType matches exactly, or by type with "image/" or "image/*"
+	ContentType string
+	MinSize     int64
+	MaxSize     int64
+	// After and Before bound the last modification time
+	After  time.Time
+	Before time.Time
+	// Content matches the words of the document text, through the content
+	// index; results are then ranked by relevance instead of sorted by key
+	Content string
+	// Limit caps the number of results
+	Limit int
+
+	// keys restricts matches to these keys, when not nil
+	keys map[string]bool
+}
+
+// Result holds the matches of a query, sorted by key
+type Result struct {
+	Entries []Entry
+	// Truncated is set when more objects matched than the limit
+	Truncated bool
+	// IndexedAt is when the index that answered was refreshed; zero when the
+	// bucket was listed instead
+	IndexedAt time.Time
+}
+
+// matchesListing checks the fields a bucket listing provides
+func (q *Query) matchesListing(e *Entry) bool {
+	switch {
+	case q.keys != nil && !q.keys[e.Key]:
+		return false
+	case !strings.HasPrefix(e.Key, q.Prefix):
+		return false
+	case q.Text != "" && !strings.Contains(strings.ToLower(e.Key), strings.ToLower(q.Text)):
+		return false
+	case e.Size < q.MinSize, q.MaxSize > 0 && e.Size > q.MaxSize:
+		return false
+	case !q.After.IsZero() && !e.LastModified.After(q.After):
+		return false
+	case !q.Before.IsZero() && !e.LastModified.Before(q.Before):
+		return false
+	}
+	return true
+}
+
+// needsDetails reports whether matching requires the content type or tags,
+// which a listing doesn't provide
+func (q *Query) needsDetails() bool {
+	return q.ContentType != "" || len(q.Tags) > 0
+}
+
+// matchesDetails checks the content type and tags
+func (q *Query) matchesDetails(e *Entry) bool {
+	if want := strings.TrimSuffix(strings.ToLower(q.ContentType), "*"); want != "" {
+		got := strings.ToLower(e.ContentType)
+		if strings.HasSuffix(want, "/") {
+			if !strings.HasPrefix(got, want) {
+				return false
+			}
+		} else if got != want {
+			return false
+		}
+	}
+	for k, v := range q.Tags {
+		got, ok := e.Tags[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Options configures an Index
+type Options struct {
+	// Buckets to index; empty indexes every bucket
+	Buckets []string
+	// Concurrency is how many objects are inspected in parallel when their
+	// content type and tags are fetched
+	Concurrency int
+	// Content answers content queries; nil rejects them
+	Content contentindex.Index
+}
+
+// ErrContentDisabled is returned for content queries without a content index
+var ErrContentDisabled = errors.New("content search is not enabled")
+
+// maxContentHits bounds the documents a content query considers
+const maxContentHits = 1000
+
+// bucketIndex is the indexed content of one bucket
+type bucketIndex struct {
+	entries     map[string]*Entry
+	refreshedAt time.Time
+}
+
+// Index keeps object metadata in memory. Each refresh lists the bucket and
+// fetches the content type and tags of new and changed objects only.
+type Index struct {
+	backend storage.Backend
+	opts    Options
+	logger  *zerolog.Logger
+
+	mu      sync.RWMutex
+	buckets map[string]*bucketIndex
+	// refreshing serializes refreshes
+	refreshing sync.Mutex
+}
+
+// NewIndex creates an empty Index; queries list buckets until Run has
+// indexed them
+func NewIndex(backend storage.Backend, opts Options, logger *zerolog.Logger) *Index {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	return &Index{backend: backend, opts: opts, logger: logger, buckets: make(map[string]*bucketIndex)}
+}
+
+// Run refreshes the index now and then every interval until ctx is done
+func (ix *Index) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := ix.Refresh(ctx); err != nil && ctx.Err() == nil {
+			ix.logger.Warn().Err(err).Msg("Failed to refresh search index")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Refresh brings the index of every configured bucket up to date. A bucket
+// that fails keeps its previous index.
+func (ix *Index) Refresh(ctx context.Context) error {
+	ix.refreshing.Lock()
+	defer ix.refreshing.Unlock()
+
+	buckets := ix.opts.Buckets
+	if len(buckets) == 0 {
+		list, err := ix.backend.ListBuckets(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list buckets: %w", err)
+		}
+		for _, b := range list {
+			buckets = append(buckets, b.Name)
+		}
+	}
+
+	var errs []error
+	indexed := make(map[string]bool, len(buckets))
+	for _, bucket := range buckets {
+		indexed[bucket] = true
+		if err := ix.refreshBucket(ctx, bucket); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Forget buckets that are gone or no longer configured
+	ix.mu.Lock()
+	for bucket := range ix.buckets {
+		if !indexed[bucket] {
+			delete(ix.buckets, bucket)
+		}
+	}
+	ix.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+func (ix *Index) refreshBucket(ctx context.Context, bucket string) error {
+	start := time.Now()
+	ix.mu.RLock()
+	var previous map[string]*Entry
+	if b := ix.buckets[bucket]; b != nil {
+		previous = b.entries
+	}
+	ix.mu.RUnlock()
+
+	entries := make(map[string]*Entry, len(previous))
+	var changed []*Entry
+	opts := storage.ListOptions{}
+	for {
+		page, err := ix.backend.List(ctx, bucket, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list bucket %s: %w", bucket, err)
+		}
+		for _, object := range page.Objects {
+			if old := previous[object.Key]; old != nil && old.ETag == object.ETag && old.LastModified.Equal(object.LastModified) {
+				entries[object.Key] = old
+				continue
+			}
+			e := &Entry{Key: object.Key, Size: object.Size, ETag: object.ETag, LastModified: object.LastModified}
+			entries[object.Key] = e
+			changed = append(changed, e)
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+
+	// Entries are only shared with readers once the new map is swapped in
+	errs := make([]error, len(changed))
+	ix.forEach(len(changed), func(i int) {
+		errs[i] = ix.fillDetails(ctx, bucket, changed[i])
+	})
+	for i, err := range errs {
+		if errors.Is(err, storage.ErrNotFound) {
+			// Deleted since it was listed
+			delete(entries, changed[i].Key)
+		} else if err != nil {
+			return fmt.Errorf("failed to inspect %s/%s: %w", bucket, changed[i].Key, err)
+		}
+	}
+
+	ix.mu.Lock()
+	ix.buckets[bucket] = &bucketIndex{entries: entries, refreshedAt: start.UTC()}
+	ix.mu.Unlock()
+	ix.logger.Debug().Str("bucket", bucket).Int("objects", len(entries)).Int("changed", len(changed)).Dur("duration", time.Since(start)).Msg("Search index refreshed")
+	return nil
+}
+
+// forEach calls fn for 0..n-1 with up to Concurrency calls in parallel
+func (ix *Index) forEach(n int, fn func(i int)) {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < ix.opts.Concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// fillDetails fetches the content type and, on S3 backends, the tags of e
+func (ix *Index) fillDetails(ctx context.Context, bucket string, e *Entry) error {
+	stat, err := ix.backend.Stat(ctx, bucket, e.Key)
+	if err != nil {
+		return err
+	}
+	e.ContentType = stat.ContentType
+	e.Tags, err = storage.ObjectTags(ctx, ix.backend, bucket, e.Key)
+	return err
+}
+
+// Search runs q against bucket, from the index when the bucket has been
+// indexed and by listing it otherwise
+func (ix *Index) Search(ctx context.Context, bucket string, q Query) (*Result, error) {
+	if q.Content != "" {
+		return ix.searchContent(ctx, bucket, q)
+	}
+	ix.mu.RLock()
+	b := ix.buckets[bucket]
+	ix.mu.RUnlock()
+	if b == nil {
+		return ix.scan(ctx, bucket, q)
+	}
+
+	result := &Result{IndexedAt: b.refreshedAt}
+	for _, e := range b.entries {
+		if q.matchesListing(e) && q.matchesDetails(e) {
+			result.Entries = append(result.Entries, *e)
+		}
+	}
+	sort.Slice(result.Entries, func(i, j int) bool { return result.Entries[i].Key < result.Entries[j].Key })
+	if q.Limit > 0 && len(result.Entries) > q.Limit {
+		result.Entries = result.Entries[:q.Limit]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// scan lists the bucket in key order until the limit is reached, fetching
+// details only for objects that pass the other filters
+func (ix *Index) scan(ctx context.Context, bucket string, q Query) (*Result, error) {
+	result := &Result{}
+	opts := storage.ListOptions{Prefix: q.Prefix}
+	for {
+		page, err := ix.backend.List(ctx, bucket, opts)
+		if err != nil {
+			return nil, err
+		}
+		var candidates []*Entry
+		for _, object := range page.Objects {
+			e := &Entry{Key: object.Key, Size: object.Size, ETag: object.ETag, LastModified: object.LastModified}
+			if q.matchesListing(e) {
+				candidates = append(candidates, e)
+			}
+		}
+		if q.needsDetails() {
+			errs := make([]error, len(candidates))
+			ix.forEach(len(candidates), func(i int) {
+				errs[i] = ix.fillDetails(ctx, bucket, candidates[i])
+			})
+			for i, err := range errs {
+				if err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return nil, err
+				}
+				if err != nil {
+					candidates[i] = nil
+				}
+			}
+		}
+		for _, e := range candidates {
+			if e == nil || !q.matchesDetails(e) {
+				continue
+			}
+			if q.Limit > 0 && len(result.Entries) == q.Limit {
+				result.Truncated = true
+				return result, nil
+			}
+			result.Entries = append(result.Entries, *e)
+		}
+		if page.NextContinuationToken == "" {
+			return result, nil
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+}
+
+// searchContent finds documents in the content index, applies the other
+// filters to them and ranks the matches by relevance
+func (ix *Index) searchContent(ctx context.Context, bucket string, q Query) (*Result, error) {
+	if ix.opts.Content == nil {
+		return nil, ErrContentDisabled
+	}
+	hits, err := ix.opts.Content.Search(ctx, contentindex.Query{Bucket: bucket, Prefix: q.Prefix, Text: q.Content, Limit: maxContentHits})
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]contentindex.Hit, len(hits))
+	q.keys = make(map[string]bool, len(hits))
+	for _, hit := range hits {
+		byKey[hit.Key] = hit
+		q.keys[hit.Key] = true
+	}
+	limit := q.Limit
+	q.Content, q.Limit = "", 0
+
+	var result *Result
+	if ix.indexed(bucket) {
+		result, err = ix.Search(ctx, bucket, q)
+	} else {
+		result, err = ix.lookup(ctx, bucket, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Entries {
+		hit := byKey[result.Entries[i].Key]
+		result.Entries[i].Score, result.Entries[i].Snippet = hit.Score, hit.Snippet
+	}
+	sort.SliceStable(result.Entries, func(i, j int) bool { return result.Entries[i].Score > result.Entries[j].Score })
+	if limit > 0 && len(result.Entries) > limit {
+		result.Entries = result.Entries[:limit]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+func (ix *Index) indexed(bucket string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.buckets[bucket] != nil
+}
+
+// lookup inspects the objects restricted by q.keys directly rather than
+// listing the bucket
+func (ix *Index) lookup(ctx context.Context, bucket string, q Query) (*Result, error) {
+	keys := make([]string, 0, len(q.keys))
+	for key := range q.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]*Entry, len(keys))
+	errs := make([]error, len(keys))
+	ix.forEach(len(keys), func(i int) {
+		stat, err := ix.backend.Stat(ctx, bucket, keys[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		entries[i] = &Entry{Key: keys[i], Size: stat.Size, ContentType: stat.ContentType, ETag: stat.ETag, LastModified: stat.LastModified}
+		if len(q.Tags) > 0 {
+			entries[i].Tags, errs[i] = storage.ObjectTags(ctx, ix.backend, bucket, keys[i])
+		}
+	})
+	result := &Result{}
+	for i, e := range entries {
+		if errors.Is(errs[i], storage.ErrNotFound) {
+			// Deleted but not yet removed from the content index
+			continue
+		}
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if q.matchesListing(e) && q.matchesDetails(e) {
+			result.Entries = append(result.Entries, *e)
+		}
+	}
+	return result, nil
+}
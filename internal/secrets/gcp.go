@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	gcpTokenURL         = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+)
+
+// GCP reads a Google Cloud Secret Manager secret version
+type GCP struct {
+	name   string
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (g *GCP) Name() string { return "gcp:" + g.name }
+
+func (g *GCP) Fetch(ctx context.Context) (map[string]string, error) {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GCP access token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpSecretManagerURL+g.name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := do(g.client, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to access GCP secret: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCP secret payload: %w", err)
+	}
+	return parseValues(payload)
+}
+
+// accessToken returns the service account token from the metadata server,
+// cached until shortly before it expires
+func (g *GCP) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Before(g.expires) {
+		return g.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := do(g.client, req, &resp); err != nil {
+		return "", err
+	}
+	g.token = resp.AccessToken
+	g.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
@@ -0,0 +1,148 @@
+// Package secrets loads credentials from a secrets manager and watches them
+// for rotation
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Providers
+const (
+	ProviderVault = "vault"
+	ProviderAWS   = "aws"
+	ProviderGCP   = "gcp"
+)
+
+// Source fetches a secret holding credentials as "NAME": "value" pairs, named
+// like the environment variables they replace, e.g. MINIO_SECRET_KEY
+type Source interface {
+	// Name identifies the secret for logs
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Options selects and configures a secrets manager
+type Options struct {
+	// Provider is vault, aws or gcp; empty disables secrets loading
+	Provider string
+
+	// VaultAddr, VaultToken and VaultPath locate a KV secret, e.g.
+	// "secret/data/app-minio-api" for KV version 2
+	VaultAddr  string
+	VaultToken string
+	VaultPath  string
+
+	// AWSRegion and AWSSecretID locate an AWS Secrets Manager secret; requests
+	// are signed with the standard AWS credential chain
+	AWSRegion   string
+	AWSSecretID string
+
+	// GCPSecret is "projects/p/secrets/s" (latest version) or a full version
+	// name; requests use the metadata server's service account
+	GCPSecret string
+}
+
+// Open returns the Source selected by opts.Provider, or nil if none is
+func Open(opts Options) (Source, error) {
+	switch strings.ToLower(opts.Provider) {
+	case "":
+		return nil, nil
+	case ProviderVault:
+		if opts.VaultAddr == "" || opts.VaultToken == "" || opts.VaultPath == "" {
+			return nil, fmt.Errorf("VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are required for the vault secrets provider")
+		}
+		return &Vault{addr: strings.TrimRight(opts.VaultAddr, "/"), token: opts.VaultToken, path: strings.Trim(opts.VaultPath, "/"), client: newClient()}, nil
+	case ProviderAWS:
+		if opts.AWSSecretID == "" || opts.AWSRegion == "" {
+			return nil, fmt.Errorf("SECRETS_AWS_SECRET_ID and SECRETS_AWS_REGION are required for the aws secrets provider")
+		}
+		return newAWS(opts.AWSRegion, opts.AWSSecretID), nil
+	case ProviderGCP:
+		if !strings.HasPrefix(opts.GCPSecret, "projects/") {
+			return nil, fmt.Errorf("SECRETS_GCP_SECRET must be projects/<project>/secrets/<name>")
+		}
+		name := opts.GCPSecret
+		if !strings.Contains(name, "/versions/") {
+			name += "/versions/latest"
+		}
+		return &GCP{name: name, client: newClient()}, nil
+	}
+	return nil, fmt.Errorf("unknown secrets provider %q, expected %s, %s or %s", opts.Provider, ProviderVault, ProviderAWS, ProviderGCP)
+}
+
+// Watch fetches src every interval until ctx is done, calling onChange when
+// the values differ from last. Fetch errors are logged and retried on the
+// next tick, keeping the current credentials.
+func Watch(ctx context.Context, src Source, interval time.Duration, last map[string]string, onChange func(map[string]string) error, logger *zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		values, err := src.Fetch(ctx)
+		if err != nil {
+			logger.Warn().Err(err).Str("secret", src.Name()).Msg("Failed to refresh secret")
+			continue
+		}
+		if maps.Equal(values, last) {
+			continue
+		}
+		if err := onChange(values); err != nil {
+			logger.Error().Err(err).Str("secret", src.Name()).Msg("Failed to apply rotated secret")
+			continue
+		}
+		last = values
+		logger.Info().Str("secret", src.Name()).Msg("Applied rotated secret")
+	}
+}
+
+func newClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// do sends req and decodes a JSON response into v
+func do(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s responded %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseValues decodes a secret payload holding a flat JSON object
+func parseValues(payload []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return stringValues(raw)
+}
+
+func stringValues(raw map[string]interface{}) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret value %q is not a string", k)
+		}
+		values[k] = s
+	}
+	return values, nil
+}
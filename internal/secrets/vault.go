@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Vault reads a HashiCorp Vault KV secret (version 1 or 2)
+type Vault struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+}
+
+func (v *Vault) Name() string { return "vault:" + v.path }
+
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := do(v.client, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	data := resp.Data
+	// KV version 2 nests the values under data.data next to data.metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	return stringValues(data)
+}
@@ -0,0 +1,309 @@
+package sharing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Errors returned when a share link can't be used
+var (
+	ErrNotFound         = errors.New("share link not found")
+	ErrExpired          = errors.New("share link has expired")
+	ErrRevoked          = errors.New("share link has been revoked")
+	ErrExhausted        = errors.New("share link download limit reached")
+	ErrPasswordRequired = errors.New("share link requires a password")
+	ErrInvalidPassword  = errors.New("invalid share link password")
+)
+
+const (
+	recordPrefix = "shares/"
+	// slugBytes of randomness give 128-bit, unguessable slugs
+	slugBytes = 16
+)
+
+// Link is a persisted share link
+type Link struct {
+	Slug         string     `json:"slug"`
+	TenantID     string     `json:"tenantId,omitempty"`
+	Bucket       string     `json:"bucket,omitempty"`
+	Prefix       string     `json:"prefix,omitempty"`
+	Key          string     `json:"key"`
+	PasswordHash string     `json:"passwordHash,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	MaxDownloads int        `json:"maxDownloads,omitempty"`
+	Downloads    int        `json:"downloads"`
+	CreatedBy    string     `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	// ExhaustedAt is when the last allowed download was reserved
+	ExhaustedAt *time.Time `json:"exhaustedAt,omitempty"`
+	// Sealed protects a password link to an envelope-encrypted file, in
+	// place of PasswordHash
+	Sealed *SealedKey `json:"sealed,omitempty"`
+
+	// dataKey is the file's data key, unwrapped by Consume
+	dataKey []byte
+}
+
+// HasPassword reports whether the link is password protected
+func (l *Link) HasPassword() bool {
+	return l.PasswordHash != "" || l.Sealed != nil
+}
+
+// checkPassword verifies password against the link, returning the file's
+// data key when the link is sealed
+func (l *Link) checkPassword(password string) ([]byte, error) {
+	if !l.HasPassword() {
+		return nil, nil
+	}
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+	if l.Sealed != nil {
+		return l.Sealed.open(l.Slug, password)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidPassword
+	}
+	return nil, nil
+}
+
+// Usable returns why the link can't be used at the given time, or nil
+func (l *Link) Usable(now time.Time) error {
+	switch {
+	case l.RevokedAt != nil:
+		return ErrRevoked
+	case l.ExpiresAt != nil && now.After(*l.ExpiresAt):
+		return ErrExpired
+	case l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads:
+		return ErrExhausted
+	}
+	return nil
+}
+
+// CreateOptions configures a new share link
+type CreateOptions struct {
+	TenantID     string
+	Bucket       string
+	Prefix       string
+	Key          string
+	Password     string
+	ExpiresAt    *time.Time
+	MaxDownloads int
+	CreatedBy    string
+}
+
+// Service manages share links in the metadata store
+type Service struct {
+	store store.Store
+	// envelope seals password links to encrypted files; nil protects all
+	// of them with a password hash only
+	envelope *storage.Envelope
+}
+
+// NewService creates a new share link Service. With an envelope, password
+// links to files it encrypted wrap the file's data key under the password.
+func NewService(s store.Store, envelope *storage.Envelope) *Service {
+	return &Service{store: s, envelope: envelope}
+}
+
+func recordKey(slug string) string {
+	return recordPrefix + slug
+}
+
+// Create stores a new share link for an object
+func (s *Service) Create(ctx context.Context, opts CreateOptions) (*Link, error) {
+	slug, err := newSlug()
+	if err != nil {
+		return nil, err
+	}
+	link := &Link{
+		Slug:         slug,
+		TenantID:     opts.TenantID,
+		Bucket:       opts.Bucket,
+		Prefix:       opts.Prefix,
+		Key:          opts.Key,
+		ExpiresAt:    opts.ExpiresAt,
+		MaxDownloads: opts.MaxDownloads,
+		CreatedBy:    opts.CreatedBy,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if opts.Password != "" && s.envelope != nil {
+		link.Sealed, err = s.seal(ctx, slug, opts.Bucket, opts.Prefix+opts.Key, opts.Password)
+		if err != nil && !errors.Is(err, storage.ErrNotEncrypted) {
+			return nil, err
+		}
+	}
+	if opts.Password != "" && link.Sealed == nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = string(hash)
+	}
+	if err := s.store.Put(ctx, recordKey(slug), link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Get returns a share link by slug
+func (s *Service) Get(ctx context.Context, slug string) (*Link, error) {
+	var link Link
+	if err := s.store.Get(ctx, recordKey(slug), &link); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// List returns the share links of a tenant, newest first
+func (s *Service) List(ctx context.Context, tenantID string) ([]*Link, error) {
+	keys, err := s.store.List(ctx, recordPrefix)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*Link, 0, len(keys))
+	for _, key := range keys {
+		link, err := s.Get(ctx, strings.TrimPrefix(key, recordPrefix))
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if link.TenantID != tenantID {
+			continue
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.After(links[j].CreatedAt) })
+	return links, nil
+}
+
+// Revoke disables a share link. The record is kept so it shows up as revoked.
+func (s *Service) Revoke(ctx context.Context, slug string) (*Link, error) {
+	link, err := store.Update(ctx, s.store, recordKey(slug), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		if link.RevokedAt == nil {
+			now := time.Now().UTC()
+			link.RevokedAt = &now
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Purge deletes links that expired, were revoked or used up their downloads
+// before the given time and returns how many were deleted
+func (s *Service) Purge(ctx context.Context, before time.Time) (int, error) {
+	keys, err := s.store.List(ctx, recordPrefix)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, key := range keys {
+		var link Link
+		if err := s.store.Get(ctx, key, &link); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return purged, err
+		}
+		expired := link.ExpiresAt != nil && link.ExpiresAt.Before(before)
+		revoked := link.RevokedAt != nil && link.RevokedAt.Before(before)
+		exhausted := link.ExhaustedAt != nil && link.ExhaustedAt.Before(before)
+		if !expired && !revoked && !exhausted {
+			continue
+		}
+		// An expired or revoked link can't become usable again, and a
+		// download released this long after its reservation is not coming,
+		// so there is no need to lock it against concurrent updates
+		if err := s.store.Delete(ctx, key); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// Consume validates a link for download with the supplied password and
+// reserves one of its downloads. Call Release if the download then fails, so
+// only completed downloads are counted. A sealed link comes back with the
+// data key OpenContent reads its file with.
+func (s *Service) Consume(ctx context.Context, slug, password string) (*Link, error) {
+	// A link's password never changes, so it is checked before taking the
+	// record lock rather than holding up other downloads of the link
+	current, err := s.Get(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := current.Usable(time.Now()); err != nil {
+		return nil, err
+	}
+	dataKey, err := current.checkPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := store.Update(ctx, s.store, recordKey(slug), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		now := time.Now()
+		if err := link.Usable(now); err != nil {
+			return false, err
+		}
+		link.Downloads++
+		if link.MaxDownloads > 0 && link.Downloads >= link.MaxDownloads {
+			now = now.UTC()
+			link.ExhaustedAt = &now
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	link.dataKey = dataKey
+	return &link, nil
+}
+
+// Release returns a download reserved by Consume that was not completed
+func (s *Service) Release(ctx context.Context, slug string) error {
+	_, err := store.Update(ctx, s.store, recordKey(slug), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			// Deleting a missing record is a no-op
+			return false, nil
+		}
+		link.Downloads = max(0, link.Downloads-1)
+		if link.MaxDownloads == 0 || link.Downloads < link.MaxDownloads {
+			link.ExhaustedAt = nil
+		}
+		return true, nil
+	})
+	return err
+}
+
+func newSlug() (string, error) {
+	b := make([]byte, slugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,110 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxEntries triggers eviction of expired cache entries and counters once
+// either grows this large
+const maxEntries = 10000
+
+// Memory keeps state in process
+type Memory struct {
+	mu       sync.Mutex
+	values   map[string]memoryValue
+	counters map[string]memoryCount
+	locks    sync.Map // map[string]chan struct{}
+}
+
+type memoryValue struct {
+	value   []byte
+	expires time.Time
+}
+
+type memoryCount struct {
+	count int64
+	reset time.Time
+}
+
+// NewMemory creates empty in-process state
+func NewMemory() *Memory {
+	return &Memory{
+		values:   make(map[string]memoryValue),
+		counters: make(map[string]memoryCount),
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.values[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.values, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.values) >= maxEntries {
+		for k, entry := range m.values {
+			if now.After(entry.expires) {
+				delete(m.values, k)
+			}
+		}
+	}
+	m.values[key] = memoryValue{value: value, expires: now.Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.values, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// Lock waits for key; the ttl is not needed since the lock dies with the process
+func (m *Memory) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	v, _ := m.locks.LoadOrStore(key, make(chan struct{}, 1))
+	held := v.(chan struct{})
+	select {
+	case held <- struct{}{}:
+		return func() { <-held }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	reset := now.Truncate(window).Add(window)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.counters[key]
+	if !ok || !entry.reset.Equal(reset) {
+		if len(m.counters) >= maxEntries {
+			for k, c := range m.counters {
+				if !now.Before(c.reset) {
+					delete(m.counters, k)
+				}
+			}
+		}
+		entry = memoryCount{reset: reset}
+	}
+	entry.count++
+	m.counters[key] = entry
+	return entry.count, reset, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// connectTimeout bounds the connection check at startup
+	connectTimeout = 5 * time.Second
+	// unlockTimeout bounds releasing a lock; an unreleased lock expires anyway
+	unlockTimeout = 5 * time.Second
+	// Waiting for a lock polls with backoff between these delays
+	lockPollMin = 10 * time.Millisecond
+	lockPollMax = 200 * time.Millisecond
+)
+
+// unlockScript deletes a lock only if it still holds our token, so a holder
+// whose lock expired can't release the next holder's
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// extendScript renews a lock that still holds our token
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// Redis shares state between replicas through a Redis server. Keys are
+// namespaced by kind under the prefix: <prefix>cache:, lock: and count:.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis connects to the server at url (redis://[user:password@]host:port/db,
+// rediss:// for TLS) and checks it answers
+func NewRedis(url, prefix string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &Redis{client: client, prefix: prefix}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+"cache:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+"cache:"+key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+"cache:"+key).Err()
+}
+
+// Lock takes key with SET NX, polling while another holder has it. A held
+// lock is renewed every third of its ttl until unlocked.
+func (r *Redis) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	key = r.prefix + "lock:" + key
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b[:])
+
+	delay := lockPollMin
+	for {
+		ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			stop := make(chan struct{})
+			go r.renew(key, token, ttl, stop)
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					close(stop)
+					ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+					defer cancel()
+					_ = unlockScript.Run(ctx, r.client, []string{key}, token).Err()
+				})
+			}, nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		delay = min(delay*2, lockPollMax)
+	}
+}
+
+// renew extends a held lock until stop is closed or the lock is lost
+func (r *Redis) renew(key, token string, ttl time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+			renewed, err := extendScript.Run(ctx, r.client, []string{key}, token, ttl.Milliseconds()).Int()
+			cancel()
+			if err == nil && renewed == 0 {
+				return
+			}
+		}
+	}
+}
+
+// Incr counts in a key per window, which expires once the window has passed
+func (r *Redis) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	start := time.Now().Truncate(window)
+	reset := start.Add(window)
+	key = r.prefix + "count:" + key + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, reset.Add(time.Second))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, time.Time{}, err
+	}
+	return incr.Val(), reset, nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,52 @@
+// Package state holds the short-lived state that replicas of the API have to
+// agree on: cached values, locks and counters. Memory keeps it in process,
+// which is right for a single replica; Redis shares it between replicas.
+// Subsystems depend on the small interfaces below, not on either backend.
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values for a limited time
+type Cache interface {
+	// Get returns the value at key; ok is false when it is missing or expired
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value at key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value at key; deleting a missing value is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Locker grants exclusive access to keys
+type Locker interface {
+	// Lock waits until key is free or ctx is done. The lock is held until
+	// unlock is called; if its holder goes away without calling it, the lock
+	// expires after ttl.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), err error)
+}
+
+// Counter counts events in fixed time windows
+type Counter interface {
+	// Incr adds one to the count of key in the current window, returning the
+	// new count and when the window ends
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, reset time.Time, err error)
+}
+
+// Shared is a state backend
+type Shared interface {
+	Cache
+	Locker
+	Counter
+	Close() error
+}
+
+// New connects to Redis at redisURL, prefixing every key with keyPrefix;
+// without a URL the state stays in process
+func New(redisURL, keyPrefix string) (Shared, error) {
+	if redisURL == "" {
+		return NewMemory(), nil
+	}
+	return NewRedis(redisURL, keyPrefix)
+}
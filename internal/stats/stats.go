@@ -0,0 +1,352 @@
+// Package stats scans storage in the background for per-bucket and per-prefix
+// usage, the largest objects and growth over time
+package stats
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+	"github.com/rs/zerolog"
+)
+
+// KindScan is the job kind of a storage scan
+const KindScan = "stats.scan"
+
+const (
+	latestKey  = "stats/latest"
+	historyKey = "stats/history"
+	// monthPrefix keys the last snapshot of each month
+	monthPrefix = "stats/months/"
+	// maxPrefixes bounds the prefixes reported per bucket, largest first
+	maxPrefixes = 1000
+)
+
+// ErrNoSnapshot is returned before the first scan has completed
+var ErrNoSnapshot = errors.New("no storage statistics yet")
+
+// PrefixStats is the usage under one key prefix; "" holds objects at the root
+type PrefixStats struct {
+	Prefix  string `json:"prefix"`
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// BucketStats is the usage of one bucket
+type BucketStats struct {
+	Bucket   string        `json:"bucket"`
+	Objects  int64         `json:"objects"`
+	Bytes    int64         `json:"bytes"`
+	Prefixes []PrefixStats `json:"prefixes"`
+	// Truncated is set when only the largest prefixes are listed
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ObjectStats identifies a large object
+type ObjectStats struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Snapshot is the result of one scan
+type Snapshot struct {
+	ScannedAt time.Time     `json:"scannedAt"`
+	Duration  string        `json:"duration"`
+	Objects   int64         `json:"objects"`
+	Bytes     int64         `json:"bytes"`
+	Buckets   []BucketStats `json:"buckets"`
+	Largest   []ObjectStats `json:"largest"`
+}
+
+// Point is the total usage at the time of a scan
+type Point struct {
+	Time    time.Time        `json:"time"`
+	Objects int64            `json:"objects"`
+	Bytes   int64            `json:"bytes"`
+	Buckets map[string]int64 `json:"buckets"` // bytes per bucket
+}
+
+// Growth summarizes the change across the recorded history
+type Growth struct {
+	Since       time.Time `json:"since"`
+	Objects     int64     `json:"objects"`
+	Bytes       int64     `json:"bytes"`
+	BytesPerDay int64     `json:"bytesPerDay"`
+}
+
+// Options configures a Scanner
+type Options struct {
+	// Buckets to scan; empty scans every bucket
+	Buckets []string
+	// PrefixDepth is how many path segments make up a reported prefix
+	PrefixDepth int
+	// TopN is how many of the largest objects to keep
+	TopN int
+	// HistoryLimit is how many scans the growth history keeps
+	HistoryLimit int
+}
+
+// Scanner computes usage snapshots as background jobs
+type Scanner struct {
+	backend storage.Backend
+	store   store.Store
+	queue   *jobs.Queue
+	opts    Options
+	logger  *zerolog.Logger
+
+	scanning atomic.Bool
+}
+
+// NewScanner creates a Scanner and registers its job handler on queue
+func NewScanner(backend storage.Backend, s store.Store, queue *jobs.Queue, opts Options, logger *zerolog.Logger) *Scanner {
+	if opts.PrefixDepth <= 0 {
+		opts.PrefixDepth = 1
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = 20
+	}
+	if opts.HistoryLimit <= 0 {
+		opts.HistoryLimit = 120
+	}
+	sc := &Scanner{backend: backend, store: s, queue: queue, opts: opts, logger: logger}
+	queue.Register(KindScan, sc.runScan)
+	return sc
+}
+
+// Schedule queues a scan now if there is no snapshot yet, and then every
+// interval until ctx is done
+func (sc *Scanner) Schedule(ctx context.Context, interval time.Duration) {
+	if _, err := sc.Latest(ctx); errors.Is(err, ErrNoSnapshot) {
+		if _, err := sc.Enqueue(ctx); err != nil {
+			sc.logger.Warn().Err(err).Msg("Failed to queue storage scan")
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := sc.Enqueue(ctx); err != nil {
+					sc.logger.Warn().Err(err).Msg("Failed to queue storage scan")
+				}
+			}
+		}
+	}()
+}
+
+// Enqueue queues a scan job
+func (sc *Scanner) Enqueue(ctx context.Context) (*jobs.Job, error) {
+	return sc.queue.Enqueue(ctx, KindScan, struct{}{})
+}
+
+// Latest returns the most recent snapshot
+func (sc *Scanner) Latest(ctx context.Context) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := sc.store.Get(ctx, latestKey, &snapshot); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Month returns the last snapshot taken in the month of t, or ErrNoSnapshot
+// if no scan completed that month
+func (sc *Scanner) Month(ctx context.Context, t time.Time) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := sc.store.Get(ctx, monthKey(t), &snapshot); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func monthKey(t time.Time) string {
+	return monthPrefix + t.UTC().Format("2006-01")
+}
+
+// History returns the totals of past scans, oldest first
+func (sc *Scanner) History(ctx context.Context) ([]Point, error) {
+	var history []Point
+	if err := sc.store.Get(ctx, historyKey, &history); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GrowthOf summarizes history, or returns nil with fewer than two points
+func GrowthOf(history []Point) *Growth {
+	if len(history) < 2 {
+		return nil
+	}
+	first, last := history[0], history[len(history)-1]
+	g := &Growth{Since: first.Time, Objects: last.Objects - first.Objects, Bytes: last.Bytes - first.Bytes}
+	if days := last.Time.Sub(first.Time).Hours() / 24; days > 0 {
+		g.BytesPerDay = int64(float64(g.Bytes) / days)
+	}
+	return g
+}
+
+func (sc *Scanner) runScan(ctx context.Context, job *jobs.Job) error {
+	// A scan already running covers this one
+	if !sc.scanning.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer sc.scanning.Store(false)
+
+	snapshot, err := sc.Scan(ctx)
+	if err != nil {
+		return err
+	}
+	if err := sc.store.Put(ctx, latestKey, snapshot); err != nil {
+		return fmt.Errorf("failed to save storage statistics: %w", err)
+	}
+	if err := sc.store.Put(ctx, monthKey(snapshot.ScannedAt), snapshot); err != nil {
+		return fmt.Errorf("failed to save storage statistics: %w", err)
+	}
+
+	point := Point{Time: snapshot.ScannedAt, Objects: snapshot.Objects, Bytes: snapshot.Bytes, Buckets: make(map[string]int64, len(snapshot.Buckets))}
+	for _, b := range snapshot.Buckets {
+		point.Buckets[b.Bucket] = b.Bytes
+	}
+	_, err = store.Update(ctx, sc.store, historyKey, func(history *[]Point, _ bool) (bool, error) {
+		*history = append(*history, point)
+		if len(*history) > sc.opts.HistoryLimit {
+			*history = (*history)[len(*history)-sc.opts.HistoryLimit:]
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save storage history: %w", err)
+	}
+	sc.logger.Info().Int64("objects", snapshot.Objects).Int64("bytes", snapshot.Bytes).Str("duration", snapshot.Duration).Msg("Storage scan completed")
+	return nil
+}
+
+// Scan lists every object in the configured buckets
+func (sc *Scanner) Scan(ctx context.Context) (*Snapshot, error) {
+	start := time.Now()
+	buckets := sc.opts.Buckets
+	if len(buckets) == 0 {
+		list, err := sc.backend.ListBuckets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+		for _, b := range list {
+			buckets = append(buckets, b.Name)
+		}
+	}
+
+	snapshot := &Snapshot{ScannedAt: start.UTC()}
+	largest := &objectHeap{}
+	for _, bucket := range buckets {
+		stats, err := sc.scanBucket(ctx, bucket, largest)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Objects += stats.Objects
+		snapshot.Bytes += stats.Bytes
+		snapshot.Buckets = append(snapshot.Buckets, stats)
+	}
+
+	snapshot.Largest = make([]ObjectStats, largest.Len())
+	for i := len(snapshot.Largest) - 1; i >= 0; i-- {
+		snapshot.Largest[i] = heap.Pop(largest).(ObjectStats)
+	}
+	snapshot.Duration = time.Since(start).Round(time.Millisecond).String()
+	return snapshot, nil
+}
+
+func (sc *Scanner) scanBucket(ctx context.Context, bucket string, largest *objectHeap) (BucketStats, error) {
+	stats := BucketStats{Bucket: bucket}
+	prefixes := make(map[string]*PrefixStats)
+	opts := storage.ListOptions{}
+	for {
+		page, err := sc.backend.List(ctx, bucket, opts)
+		if err != nil {
+			return stats, fmt.Errorf("failed to list bucket %s: %w", bucket, err)
+		}
+		for _, object := range page.Objects {
+			stats.Objects++
+			stats.Bytes += object.Size
+
+			prefix := KeyPrefix(object.Key, sc.opts.PrefixDepth)
+			p, ok := prefixes[prefix]
+			if !ok {
+				p = &PrefixStats{Prefix: prefix}
+				prefixes[prefix] = p
+			}
+			p.Objects++
+			p.Bytes += object.Size
+
+			if largest.Len() < sc.opts.TopN || object.Size > (*largest)[0].Size {
+				heap.Push(largest, ObjectStats{Bucket: bucket, Key: object.Key, Size: object.Size, LastModified: object.LastModified})
+				if largest.Len() > sc.opts.TopN {
+					heap.Pop(largest)
+				}
+			}
+		}
+		if page.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+
+	stats.Prefixes = make([]PrefixStats, 0, len(prefixes))
+	for _, p := range prefixes {
+		stats.Prefixes = append(stats.Prefixes, *p)
+	}
+	sort.Slice(stats.Prefixes, func(i, j int) bool { return stats.Prefixes[i].Bytes > stats.Prefixes[j].Bytes })
+	if len(stats.Prefixes) > maxPrefixes {
+		stats.Prefixes = stats.Prefixes[:maxPrefixes]
+		stats.Truncated = true
+	}
+	return stats, nil
+}
+
+// KeyPrefix returns the first depth path segments of key, including the
+// trailing slash, or "" for keys with fewer segments
+func KeyPrefix(key string, depth int) string {
+	end := 0
+	for i := 0; i < depth; i++ {
+		j := strings.IndexByte(key[end:], '/')
+		if j < 0 {
+			break
+		}
+		end += j + 1
+	}
+	return key[:end]
+}
+
+// objectHeap is a min-heap by size, keeping the N largest objects
+type objectHeap []ObjectStats
+
+func (h objectHeap) Len() int            { return len(h) }
+func (h objectHeap) Less(i, j int) bool  { return h[i].Size < h[j].Size }
+func (h objectHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *objectHeap) Push(x interface{}) { *h = append(*h, x.(ObjectStats)) }
+func (h *objectHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
+)
+
+// ProviderAzure serves buckets from Azure Blob Storage containers
+const ProviderAzure = "azure"
+
+// AzureBackend stores objects as block blobs, mapping buckets to containers.
+// Azure metadata names must be identifiers, so "-" in metadata keys is stored
+// as "_" and restored on read.
+type AzureBackend struct {
+	client *service.Client
+	cred   *service.SharedKeyCredential
+	https  bool
+	// tuning applies to uploads that don't bring their own
+	tuning PutTuning
+}
+
+// requestIDPolicy tags Azure calls with the IDs of the API request they serve
+type requestIDPolicy struct{}
+
+func (requestIDPolicy) Do(req *policy.Request) (*http.Response, error) {
+	requestid.SetHeaders(req.Raw().Context(), req.Raw().Header)
+	return req.Next()
+}
+
+// NewAzureBackend creates an AzureBackend for the account's blob endpoint, e.g.
+// https://<account>.blob.core.windows.net/ or an Azurite URL
+func NewAzureBackend(serviceURL, account, key string) (*AzureBackend, error) {
+	cred, err := service.NewSharedKeyCredential(account, key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure storage credentials: %w", err)
+	}
+	client, err := service.NewClientWithSharedKeyCredential(serviceURL, cred, &service.ClientOptions{
+		ClientOptions: azcore.ClientOptions{PerCallPolicies: []policy.Policy{requestIDPolicy{}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure client: %w", err)
+	}
+	return &AzureBackend{
+		client: client,
+		cred:   cred,
+		https:  strings.HasPrefix(serviceURL, "https://"),
+	}, nil
+}
+
+func (b *AzureBackend) Name() string { return ProviderAzure }
+
+func (b *AzureBackend) blob(bucket, key string) *blockblob.Client {
+	return b.client.NewContainerClient(bucket).NewBlockBlobClient(key)
+}
+
+func (b *AzureBackend) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	props, err := b.blob(bucket, key).GetProperties(ctx, nil)
+	if err != nil {
+		return ObjectInfo{}, translateAzureError(err)
+	}
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         deref(props.ContentLength),
+		ETag:         azureETag(props.ETag),
+		ContentType:  deref(props.ContentType),
+		LastModified: deref(props.LastModified),
+		Metadata:     decodeAzureMetadata(props.Metadata),
+	}, nil
+}
+
+func (b *AzureBackend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	var opts *blob.DownloadStreamOptions
+	if r, ok := RangeFrom(ctx); ok {
+		opts = &blob.DownloadStreamOptions{Range: blob.HTTPRange{Offset: r.Offset, Count: r.Length}}
+	}
+	resp, err := b.blob(bucket, key).DownloadStream(ctx, opts)
+	if err != nil {
+		return nil, ObjectInfo{}, translateAzureError(err)
+	}
+	return resp.Body, ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         deref(resp.ContentLength),
+		ETag:         azureETag(resp.ETag),
+		ContentType:  deref(resp.ContentType),
+		LastModified: deref(resp.LastModified),
+		Metadata:     decodeAzureMetadata(resp.Metadata),
+	}, nil
+}
+
+func (b *AzureBackend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	if err := rejectCustomerKey(ctx, b.Name()); err != nil {
+		return ObjectInfo{}, err
+	}
+	if opts.Progress != nil {
+		r = &progressReader{r: r, progress: opts.Progress}
+	}
+	tuning := b.tuning
+	if opts.Tuning != nil {
+		tuning = *opts.Tuning
+	}
+	// Azure has no single-request path for streams, nor per-block MD5 here
+	uploadOpts := &blockblob.UploadStreamOptions{
+		Metadata:    encodeAzureMetadata(opts.Metadata),
+		BlockSize:   int64(tuning.PartSize),
+		Concurrency: int(tuning.Concurrency),
+	}
+	if opts.ContentType != "" {
+		uploadOpts.HTTPHeaders = &blob.HTTPHeaders{BlobContentType: &opts.ContentType}
+	}
+	resp, err := b.blob(bucket, key).UploadStream(ctx, r, uploadOpts)
+	if err != nil {
+		return ObjectInfo{}, translateAzureError(err)
+	}
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         size,
+		ETag:         azureETag(resp.ETag),
+		ContentType:  opts.ContentType,
+		LastModified: deref(resp.LastModified),
+		Metadata:     opts.Metadata,
+	}, nil
+}
+
+// Remove deletes a blob; like S3, removing a missing blob is not an error
+func (b *AzureBackend) Remove(ctx context.Context, bucket, key string) error {
+	_, err := b.blob(bucket, key).Delete(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return translateAzureError(err)
+}
+
+func (b *AzureBackend) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	limit := int32(opts.Limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	listOpts := &container.ListBlobsFlatOptions{
+		Include:    container.ListBlobsInclude{Metadata: true},
+		MaxResults: &limit,
+	}
+	if opts.Prefix != "" {
+		listOpts.Prefix = &opts.Prefix
+	}
+	if opts.ContinuationToken != "" {
+		listOpts.Marker = &opts.ContinuationToken
+	}
+
+	resp, err := b.client.NewContainerClient(bucket).NewListBlobsFlatPager(listOpts).NextPage(ctx)
+	if err != nil {
+		if opts.ContinuationToken != "" && bloberror.HasCode(err, bloberror.OutOfRangeInput, bloberror.InvalidQueryParameterValue) {
+			return ListResult{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		return ListResult{}, translateAzureError(err)
+	}
+	page := ListResult{}
+	if resp.Segment != nil {
+		page.Objects = make([]ObjectInfo, 0, len(resp.Segment.BlobItems))
+		for _, item := range resp.Segment.BlobItems {
+			info := ObjectInfo{Bucket: bucket, Key: deref(item.Name), Metadata: decodeAzureMetadata(item.Metadata)}
+			if p := item.Properties; p != nil {
+				info.Size = deref(p.ContentLength)
+				info.ETag = azureETag(p.ETag)
+				info.ContentType = deref(p.ContentType)
+				info.LastModified = deref(p.LastModified)
+			}
+			page.Objects = append(page.Objects, info)
+		}
+	}
+	page.NextContinuationToken = deref(resp.NextMarker)
+	return page, nil
+}
+
+func (b *AzureBackend) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	var buckets []BucketInfo
+	pager := b.client.NewListContainersPager(nil)
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, translateAzureError(err)
+		}
+		for _, item := range resp.ContainerItems {
+			bucket := BucketInfo{Name: deref(item.Name)}
+			if item.Properties != nil {
+				bucket.CreationDate = deref(item.Properties.LastModified)
+			}
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets, nil
+}
+
+func (b *AzureBackend) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	_, err := b.client.NewContainerClient(bucket).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+		return false, nil
+	}
+	return err == nil, translateAzureError(err)
+}
+
+func (b *AzureBackend) MakeBucket(ctx context.Context, bucket string) error {
+	_, err := b.client.CreateContainer(ctx, bucket, nil)
+	return translateAzureError(err)
+}
+
+// RemoveBucket deletes an empty container. Azure deletes containers with their
+// blobs, so emptiness is checked first to keep S3 semantics.
+func (b *AzureBackend) RemoveBucket(ctx context.Context, bucket string) error {
+	page, err := b.List(ctx, bucket, ListOptions{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Objects) > 0 {
+		return ErrBucketNotEmpty
+	}
+	_, err = b.client.DeleteContainer(ctx, bucket, nil)
+	return translateAzureError(err)
+}
+
+// Presign returns a blob SAS URL. PUT URLs require the x-ms-blob-type header,
+// which is returned with the other headers the client must send.
+func (b *AzureBackend) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	values := sas.BlobSignatureValues{
+		ExpiryTime:    time.Now().UTC().Add(expiry),
+		ContainerName: bucket,
+		BlobName:      key,
+	}
+	if b.https {
+		values.Protocol = sas.ProtocolHTTPS
+	}
+	headers := http.Header{}
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		values.Permissions = (&sas.BlobPermissions{Read: true}).String()
+		values.ContentType = opts.ResponseContentType
+		values.ContentDisposition = opts.ResponseContentDisposition
+	case http.MethodPut:
+		values.Permissions = (&sas.BlobPermissions{Create: true, Write: true}).String()
+		headers.Set("x-ms-blob-type", "BlockBlob")
+		if opts.ContentType != "" {
+			headers.Set("Content-Type", opts.ContentType)
+		}
+		if opts.CacheControl != "" {
+			headers.Set("x-ms-blob-cache-control", opts.CacheControl)
+		}
+		if opts.ContentDisposition != "" {
+			headers.Set("x-ms-blob-content-disposition", opts.ContentDisposition)
+		}
+		for k, v := range opts.Metadata {
+			headers.Set("x-ms-meta-"+strings.ReplaceAll(k, "-", "_"), v)
+		}
+	case http.MethodDelete:
+		values.Permissions = (&sas.BlobPermissions{Delete: true}).String()
+	default:
+		return nil, nil, fmt.Errorf("%w: presigned %s", ErrNotSupported, method)
+	}
+
+	params, err := values.SignWithSharedKey(b.cred)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign SAS: %w", err)
+	}
+	u, err := url.Parse(b.blob(bucket, key).URL())
+	if err != nil {
+		return nil, nil, err
+	}
+	u.RawQuery = params.Encode()
+	return u, headers, nil
+}
+
+func encodeAzureMetadata(metadata map[string]string) map[string]*string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	encoded := make(map[string]*string, len(metadata))
+	for k, v := range metadata {
+		v := v
+		encoded[strings.ReplaceAll(k, "-", "_")] = &v
+	}
+	return encoded
+}
+
+func decodeAzureMetadata(metadata map[string]*string) map[string]string {
+	decoded := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		decoded[textproto.CanonicalMIMEHeaderKey(strings.ReplaceAll(k, "_", "-"))] = deref(v)
+	}
+	return decoded
+}
+
+func azureETag(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+	return strings.Trim(string(*etag), `"`)
+}
+
+func deref[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+// translateAzureError wraps Azure error codes in the package's sentinel errors
+func translateAzureError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case bloberror.HasCode(err, bloberror.BlobNotFound):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case bloberror.HasCode(err, bloberror.ContainerNotFound):
+		return fmt.Errorf("%w: %w", ErrBucketNotFound, err)
+	case bloberror.HasCode(err, bloberror.ContainerAlreadyExists, bloberror.ContainerBeingDeleted):
+		return fmt.Errorf("%w: %w", ErrBucketExists, err)
+	case bloberror.HasCode(err, bloberror.InvalidResourceName):
+		return fmt.Errorf("%w: %w", ErrInvalidBucket, err)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// Server-side encryption modes, as used by SSE_MODE and bucket default encryption
+const (
+	SSEModeNone = ""
+	SSEModeS3   = "sse-s3"
+	SSEModeKMS  = "sse-kms"
+)
+
+// CustomerKeyLen is the size of an SSE-C key (AES-256)
+const CustomerKeyLen = 32
+
+type customerKeyKey struct{}
+
+// WithCustomerKey returns a context whose object reads and writes use the
+// client-provided SSE-C key. Backends that can't honor it reject writes.
+func WithCustomerKey(ctx context.Context, key []byte) context.Context {
+	return context.WithValue(ctx, customerKeyKey{}, key)
+}
+
+// CustomerKey returns the SSE-C key carried by ctx, or nil
+func CustomerKey(ctx context.Context) []byte {
+	key, _ := ctx.Value(customerKeyKey{}).([]byte)
+	return key
+}
+
+// ParseSSEMode returns the server-side encryption applied to uploads for mode,
+// or nil for no encryption. kmsKeyID is required for sse-kms.
+func ParseSSEMode(mode, kmsKeyID string) (encrypt.ServerSide, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case SSEModeNone, "none":
+		return nil, nil
+	case SSEModeS3:
+		return encrypt.NewSSE(), nil
+	case SSEModeKMS:
+		if kmsKeyID == "" {
+			return nil, fmt.Errorf("a KMS key ID is required for %s", SSEModeKMS)
+		}
+		return encrypt.NewSSEKMS(kmsKeyID, nil)
+	}
+	return nil, fmt.Errorf("invalid server-side encryption mode %q, expected %s or %s", mode, SSEModeS3, SSEModeKMS)
+}
+
+// customerSSE returns the SSE-C encryption for the key carried by ctx, or nil
+func customerSSE(ctx context.Context) (encrypt.ServerSide, error) {
+	key := CustomerKey(ctx)
+	if key == nil {
+		return nil, nil
+	}
+	return encrypt.NewSSEC(key)
+}
+
+// rejectCustomerKey fails writes carrying an SSE-C key on backends that would
+// otherwise store the content unencrypted
+func rejectCustomerKey(ctx context.Context, backend string) error {
+	if CustomerKey(ctx) != nil {
+		return fmt.Errorf("%w: customer-provided encryption keys on %s", ErrNotSupported, backend)
+	}
+	return nil
+}
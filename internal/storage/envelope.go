@@ -0,0 +1,527 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EnvelopeAlgorithm identifies the content format of envelope-encrypted objects:
+// AES-256-GCM over 64 KiB segments, each sealed with its index and a final flag
+// in the nonce so segments can't be reordered, dropped or truncated.
+const EnvelopeAlgorithm = "AES256-GCM-STREAM"
+
+// Object metadata describing an envelope-encrypted object
+const (
+	envelopeAlgorithmKey = "Envelope-Algorithm"
+	envelopeKeyIDKey     = "Envelope-Key-Id"
+	envelopeDataKeyKey   = "Envelope-Data-Key"
+)
+
+const (
+	envelopeSegmentSize = 64 << 10
+	envelopeTagSize     = 16
+	envelopeKeySize     = 32
+)
+
+// ErrEnvelopeCorrupt is returned when envelope-encrypted content fails authentication
+var ErrEnvelopeCorrupt = errors.New("encrypted object is corrupt or was modified")
+
+// ErrNotEncrypted is returned for objects stored without envelope encryption
+var ErrNotEncrypted = errors.New("object is not envelope-encrypted")
+
+// KeyWrapper protects per-object data keys with a master key
+type KeyWrapper interface {
+	// WrapKey encrypts a data key, returning the ID of the master key used
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped by the master key keyID
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyring is a KeyWrapper over static AES-256 master keys. New data keys are
+// wrapped with the active key; the others remain available to read objects
+// written before a rotation.
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// ParseKeyring parses "id=base64key" entries. active names the key new objects
+// use, defaulting to the first entry.
+func ParseKeyring(entries []string, active string) (*Keyring, error) {
+	k := &Keyring{active: active, keys: make(map[string]cipher.AEAD, len(entries))}
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid master key entry %q, expected id=base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != envelopeKeySize {
+			return nil, fmt.Errorf("master key %q must be a base64 encoded 256-bit key", id)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("duplicate master key %q", id)
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.active == "" {
+			k.active = id
+		}
+	}
+	if len(k.keys) == 0 {
+		return nil, errors.New("at least one master key is required")
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("active master key %q is not configured", k.active)
+	}
+	return k, nil
+}
+
+// WrapKey seals dataKey with the active master key; the result is nonce || ciphertext
+func (k *Keyring) WrapKey(dataKey []byte) (string, []byte, error) {
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return k.active, aead.Seal(nonce, nonce, dataKey, []byte(k.active)), nil
+}
+
+func (k *Keyring) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrEnvelopeCorrupt
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: data key does not unwrap with master key %q", ErrEnvelopeCorrupt, keyID)
+	}
+	return dataKey, nil
+}
+
+// Envelope encrypts object content with a fresh data key per object before it
+// reaches the wrapped backend, storing the data key wrapped by a master key in
+// the object's metadata, and decrypts transparently on Get. Objects stored
+// without envelope metadata are passed through, so it can be enabled on a
+// bucket with existing content. Presigned URLs are refused for encrypted
+// objects and for uploads, which would bypass it.
+type Envelope struct {
+	Backend
+	keys KeyWrapper
+}
+
+// NewEnvelope wraps backend with envelope encryption under keys
+func NewEnvelope(backend Backend, keys KeyWrapper) *Envelope {
+	return &Envelope{Backend: backend, keys: keys}
+}
+
+// Unwrap returns the backend storing the encrypted content
+func (e *Envelope) Unwrap() Backend { return e.Backend }
+
+func (e *Envelope) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	info, err := e.Backend.Stat(ctx, bucket, key)
+	if err != nil {
+		return info, err
+	}
+	plainInfo(&info)
+	return info, nil
+}
+
+// Get decrypts an object. A range is read from the segments holding it only.
+func (e *Envelope) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	if r, ok := RangeFrom(ctx); ok {
+		stored, err := e.Backend.Stat(ctx, bucket, key)
+		if err != nil {
+			return nil, stored, err
+		}
+		if stored.Metadata[envelopeAlgorithmKey] == "" {
+			return e.Backend.Get(ctx, bucket, key)
+		}
+		aead, err := e.openDataKey(stored.Metadata)
+		if err != nil {
+			return nil, ObjectInfo{}, fmt.Errorf("failed to decrypt %s/%s: %w", bucket, key, err)
+		}
+		return e.getRange(ctx, stored, aead, r)
+	}
+
+	object, info, err := e.Backend.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, info, err
+	}
+	meta := info.Metadata
+	if plainInfo(&info) {
+		aead, err := e.openDataKey(meta)
+		if err != nil {
+			object.Close()
+			return nil, ObjectInfo{}, fmt.Errorf("failed to decrypt %s/%s: %w", bucket, key, err)
+		}
+		object = newOpenReader(object, aead)
+	}
+	return object, info, nil
+}
+
+// DataKey returns the data key of an envelope-encrypted object, and its
+// version: the key as stored with the object, wrapped by a master key, which
+// changes whenever the object is written again
+func (e *Envelope) DataKey(ctx context.Context, bucket, key string) (dataKey []byte, version string, err error) {
+	info, err := e.Backend.Stat(ctx, bucket, key)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Metadata[envelopeAlgorithmKey] == "" {
+		return nil, "", ErrNotEncrypted
+	}
+	if dataKey, err = e.unwrapDataKey(info.Metadata); err != nil {
+		return nil, "", err
+	}
+	return dataKey, info.Metadata[envelopeDataKeyKey], nil
+}
+
+// GetWithDataKey reads an envelope-encrypted object with a data key returned
+// by DataKey, without the master keys. It returns ErrNotFound once the object
+// was written again, as version then names a key its content no longer uses.
+func (e *Envelope) GetWithDataKey(ctx context.Context, bucket, key string, dataKey []byte, version string) (io.ReadCloser, ObjectInfo, error) {
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if r, ok := RangeFrom(ctx); ok {
+		stored, err := e.Backend.Stat(ctx, bucket, key)
+		if err != nil {
+			return nil, stored, err
+		}
+		if stored.Metadata[envelopeDataKeyKey] != version {
+			return nil, ObjectInfo{}, fmt.Errorf("%w: %s/%s was replaced", ErrNotFound, bucket, key)
+		}
+		return e.getRange(ctx, stored, aead, r)
+	}
+	object, info, err := e.Backend.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, info, err
+	}
+	if info.Metadata[envelopeDataKeyKey] != version {
+		object.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s/%s was replaced", ErrNotFound, bucket, key)
+	}
+	plainInfo(&info)
+	return newOpenReader(object, aead), info, nil
+}
+
+// getRange decrypts r of the encrypted object stored describes, fetching only
+// the segments that hold it. The object must still have stored's data key.
+func (e *Envelope) getRange(ctx context.Context, stored ObjectInfo, aead cipher.AEAD, r ByteRange) (io.ReadCloser, ObjectInfo, error) {
+	const sealedSegment = envelopeSegmentSize + envelopeTagSize
+	size := plaintextSize(stored.Size)
+	offset := min(r.Offset, size)
+	length := max(0, min(r.Length, size-offset))
+	first := offset / envelopeSegmentSize
+	last := first
+	if length > 0 {
+		last = (offset + length - 1) / envelopeSegmentSize
+	}
+	from := first * sealedSegment
+	sealed := ByteRange{Offset: from, Length: min((last-first+1)*sealedSegment, stored.Size-from)}
+
+	object, info, err := e.Backend.Get(WithRange(ctx, sealed), stored.Bucket, stored.Key)
+	if err != nil {
+		return nil, info, err
+	}
+	if info.Metadata[envelopeDataKeyKey] != stored.Metadata[envelopeDataKeyKey] {
+		object.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s/%s was replaced", ErrNotFound, stored.Bucket, stored.Key)
+	}
+	plainInfo(&info)
+	// The segments read describe the plaintext from the first one on
+	info.Size = size - first*envelopeSegmentSize
+	plain := newOpenReader(object, aead)
+	plain.seq = uint64(first)
+	plain.last = max(0, (size-1)/envelopeSegmentSize)
+	body, err := readRange(plain, &info, ByteRange{Offset: offset - first*envelopeSegmentSize, Length: length})
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return body, info, nil
+}
+
+func (e *Envelope) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	dataKey := make([]byte, envelopeKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return ObjectInfo{}, err
+	}
+	keyID, wrapped, err := e.keys.WrapKey(dataKey)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	metadata := make(map[string]string, len(opts.Metadata)+3)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata[envelopeAlgorithmKey] = EnvelopeAlgorithm
+	metadata[envelopeKeyIDKey] = keyID
+	metadata[envelopeDataKeyKey] = base64.StdEncoding.EncodeToString(wrapped)
+	opts.Metadata = metadata
+
+	// Storage holds ciphertext, whose digest the caller can't know
+	opts.ContentMD5 = ""
+	// Report progress in plaintext bytes, matching the size the caller passed
+	if opts.Progress != nil {
+		r = &progressReader{r: r, progress: opts.Progress}
+		opts.Progress = nil
+	}
+	sealedSize := int64(-1)
+	if size >= 0 {
+		sealedSize = envelopeSize(size)
+	}
+	info, err := e.Backend.Put(ctx, bucket, key, &sealReader{
+		src:   bufio.NewReaderSize(r, envelopeSegmentSize),
+		aead:  aead,
+		plain: make([]byte, envelopeSegmentSize),
+	}, sealedSize, opts)
+	if err != nil {
+		return info, err
+	}
+	info.Metadata = opts.Metadata
+	plainInfo(&info)
+	if size >= 0 {
+		info.Size = size
+	}
+	return info, nil
+}
+
+func (e *Envelope) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	result, err := e.Backend.List(ctx, bucket, opts)
+	if err != nil {
+		return result, err
+	}
+	// Only listings that carry metadata tell encrypted objects apart
+	for i := range result.Objects {
+		plainInfo(&result.Objects[i])
+	}
+	return result, nil
+}
+
+func (e *Envelope) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	presigner, ok := e.Backend.(Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", ErrNotSupported, e.Name())
+	}
+	switch method {
+	case http.MethodPut, http.MethodPost:
+		return nil, nil, fmt.Errorf("%w: presigned uploads with envelope encryption", ErrNotSupported)
+	case http.MethodGet, http.MethodHead:
+		info, err := e.Backend.Stat(ctx, bucket, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, nil, err
+		}
+		if info.Metadata[envelopeAlgorithmKey] != "" {
+			return nil, nil, fmt.Errorf("%w: presigned downloads of envelope-encrypted objects", ErrNotSupported)
+		}
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+func (e *Envelope) openDataKey(meta map[string]string) (cipher.AEAD, error) {
+	dataKey, err := e.unwrapDataKey(meta)
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(dataKey)
+}
+
+func (e *Envelope) unwrapDataKey(meta map[string]string) ([]byte, error) {
+	if alg := meta[envelopeAlgorithmKey]; alg != EnvelopeAlgorithm {
+		return nil, fmt.Errorf("unsupported envelope algorithm %q", alg)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(meta[envelopeDataKeyKey])
+	if err != nil {
+		return nil, ErrEnvelopeCorrupt
+	}
+	return e.keys.UnwrapKey(meta[envelopeKeyIDKey], wrapped)
+}
+
+// plainInfo rewrites info of an envelope-encrypted object to describe the
+// plaintext, hiding the key metadata. It reports whether the object is encrypted.
+func plainInfo(info *ObjectInfo) bool {
+	if info.Metadata[envelopeAlgorithmKey] == "" {
+		return false
+	}
+	metadata := make(map[string]string, len(info.Metadata))
+	for k, v := range info.Metadata {
+		switch k {
+		case envelopeAlgorithmKey, envelopeKeyIDKey, envelopeDataKeyKey:
+		default:
+			metadata[k] = v
+		}
+	}
+	info.Metadata = metadata
+	info.Size = plaintextSize(info.Size)
+	return true
+}
+
+// envelopeSize is the stored size of size bytes of plaintext; empty content
+// still gets one (empty) final segment
+func envelopeSize(size int64) int64 {
+	segments := (size + envelopeSegmentSize - 1) / envelopeSegmentSize
+	if segments == 0 {
+		segments = 1
+	}
+	return size + segments*envelopeTagSize
+}
+
+func plaintextSize(size int64) int64 {
+	segments := (size + envelopeSegmentSize + envelopeTagSize - 1) / (envelopeSegmentSize + envelopeTagSize)
+	if segments == 0 {
+		segments = 1
+	}
+	if plain := size - segments*envelopeTagSize; plain > 0 {
+		return plain
+	}
+	return 0
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce is the segment index followed by a flag marking the last
+// segment. Data keys are never reused, so a counter nonce is safe.
+func segmentNonce(nonce []byte, seq uint64, final bool) []byte {
+	binary.BigEndian.PutUint64(nonce[3:11], seq)
+	nonce[11] = 0
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// nextSegment reads up to len(buf) bytes and reports whether they are the last
+func nextSegment(src *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(src, buf)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return n, true, nil
+	case err != nil:
+		return n, false, err
+	}
+	if _, err := src.Peek(1); err == io.EOF {
+		return n, true, nil
+	} else if err != nil {
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+// sealReader encrypts its source segment by segment
+type sealReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	plain  []byte
+	nonce  [12]byte
+	sealed []byte
+	out    []byte
+	seq    uint64
+	done   bool
+}
+
+func (s *sealReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		n, final, err := nextSegment(s.src, s.plain)
+		if err != nil {
+			return 0, err
+		}
+		s.sealed = s.aead.Seal(s.sealed[:0], segmentNonce(s.nonce[:], s.seq, final), s.plain[:n], nil)
+		s.out = s.sealed
+		s.seq++
+		s.done = final
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// openReader decrypts and authenticates its source segment by segment
+type openReader struct {
+	src    *bufio.Reader
+	closer io.Closer
+	aead   cipher.AEAD
+	buf    []byte
+	nonce  [12]byte
+	plain  []byte
+	out    []byte
+	seq    uint64
+	// last is the index of the object's final segment when the source is a
+	// range of segments, whose end isn't the object's; -1 otherwise
+	last int64
+	done bool
+}
+
+func newOpenReader(src io.ReadCloser, aead cipher.AEAD) *openReader {
+	return &openReader{
+		src:    bufio.NewReaderSize(src, envelopeSegmentSize+envelopeTagSize),
+		closer: src,
+		aead:   aead,
+		buf:    make([]byte, envelopeSegmentSize+envelopeTagSize),
+		last:   -1,
+	}
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.out) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		n, final, err := nextSegment(o.src, o.buf)
+		if err != nil {
+			return 0, err
+		}
+		if n < envelopeTagSize {
+			return 0, ErrEnvelopeCorrupt
+		}
+		if o.last >= 0 {
+			final = o.seq == uint64(o.last)
+		}
+		o.plain, err = o.aead.Open(o.plain[:0], segmentNonce(o.nonce[:], o.seq, final), o.buf[:n], nil)
+		if err != nil {
+			return 0, ErrEnvelopeCorrupt
+		}
+		o.out = o.plain
+		o.seq++
+		o.done = final
+	}
+	n := copy(p, o.out)
+	o.out = o.out[n:]
+	return n, nil
+}
+
+func (o *openReader) Close() error { return o.closer.Close() }
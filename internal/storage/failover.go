@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/minio/minio-go/v7"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// FailoverOptions tunes when reads move to the secondary and back
+type FailoverOptions struct {
+	// FailureThreshold consecutive connection errors mark the primary down
+	FailureThreshold int
+	// RecoveryThreshold consecutive successful probes mark it up again
+	RecoveryThreshold int
+	// ProbeInterval is how often a down primary is probed
+	ProbeInterval time.Duration
+	// ProbeBucket is checked with BucketExists to probe the primary
+	ProbeBucket string
+}
+
+// Failover serves reads from a secondary backend while the primary is
+// unreachable. A read that fails with a connection error is retried on the
+// secondary; after FailureThreshold consecutive failures the primary is marked
+// down and reads skip it until RecoveryThreshold consecutive probes succeed.
+// Writes always go to the primary.
+type Failover struct {
+	Backend
+	secondary Backend
+	opts      FailoverOptions
+	logger    *zerolog.Logger
+
+	mu        sync.Mutex
+	down      bool
+	failures  int
+	successes int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewFailover creates a Failover and starts its probe loop; call Close to stop it
+func NewFailover(primary, secondary Backend, opts FailoverOptions, logger *zerolog.Logger) *Failover {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	if opts.RecoveryThreshold <= 0 {
+		opts.RecoveryThreshold = 1
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = 10 * time.Second
+	}
+	f := &Failover{
+		Backend:   primary,
+		secondary: secondary,
+		opts:      opts,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go f.probe()
+	return f
+}
+
+// Unwrap returns the primary backend
+func (f *Failover) Unwrap() Backend { return f.Backend }
+
+// Healthy reports whether reads are served by the primary
+func (f *Failover) Healthy() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.down
+}
+
+// Close stops the probe loop
+func (f *Failover) Close() {
+	close(f.stop)
+	<-f.done
+}
+
+func (f *Failover) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	var info ObjectInfo
+	err := f.read(ctx, func(b Backend) (err error) {
+		info, err = b.Stat(ctx, bucket, key)
+		return err
+	})
+	return info, err
+}
+
+func (f *Failover) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	var r io.ReadCloser
+	var info ObjectInfo
+	err := f.read(ctx, func(b Backend) (err error) {
+		r, info, err = b.Get(ctx, bucket, key)
+		return err
+	})
+	return r, info, err
+}
+
+func (f *Failover) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	var page ListResult
+	err := f.read(ctx, func(b Backend) (err error) {
+		page, err = b.List(ctx, bucket, opts)
+		return err
+	})
+	return page, err
+}
+
+func (f *Failover) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	var buckets []BucketInfo
+	err := f.read(ctx, func(b Backend) (err error) {
+		buckets, err = b.ListBuckets(ctx)
+		return err
+	})
+	return buckets, err
+}
+
+func (f *Failover) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	var exists bool
+	err := f.read(ctx, func(b Backend) (err error) {
+		exists, err = b.BucketExists(ctx, bucket)
+		return err
+	})
+	return exists, err
+}
+
+// NotifyWrite forwards to the primary, which may be mirroring writes
+func (f *Failover) NotifyWrite(ctx context.Context, bucket, key string) error {
+	return NotifyWrite(ctx, f.Backend, bucket, key)
+}
+
+// Presign signs reads against the secondary while the primary is down
+func (f *Failover) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	b := f.Backend
+	if !f.Healthy() && (method == http.MethodGet || method == http.MethodHead) {
+		b = f.secondary
+	}
+	presigner, ok := b.(Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", ErrNotSupported, b.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+// read runs op against the primary, falling back to the secondary on connection errors
+func (f *Failover) read(ctx context.Context, op func(Backend) error) error {
+	if f.Healthy() {
+		err := op(f.Backend)
+		if !unavailable(ctx, err) {
+			f.recordSuccess()
+			return err
+		}
+		f.recordFailure(err)
+	}
+
+	metrics.StorageFailover.Add("failover_reads", 1)
+	err := op(f.secondary)
+	if unavailable(ctx, err) {
+		metrics.StorageFailover.Add("secondary_errors", 1)
+	}
+	return err
+}
+
+func (f *Failover) recordSuccess() {
+	f.mu.Lock()
+	f.failures = 0
+	f.mu.Unlock()
+}
+
+func (f *Failover) recordFailure(err error) {
+	metrics.StorageFailover.Add("primary_failures", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures++
+	if !f.down && f.failures >= f.opts.FailureThreshold {
+		f.down = true
+		f.successes = 0
+		metrics.StorageFailover.Add("marked_down", 1)
+		metrics.StoragePrimaryHealthy.Set(0)
+		f.logger.Warn().Err(err).Str("primary", f.Backend.Name()).Str("secondary", f.secondary.Name()).Int("failures", f.failures).Msg("Primary storage marked down, reading from secondary")
+	}
+}
+
+// probe checks a down primary until enough consecutive probes succeed
+func (f *Failover) probe() {
+	defer close(f.done)
+	ticker := time.NewTicker(f.opts.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+		if f.Healthy() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), f.opts.ProbeInterval)
+		_, err := f.Backend.BucketExists(ctx, f.opts.ProbeBucket)
+		cancel()
+
+		f.mu.Lock()
+		if unavailable(context.Background(), err) {
+			f.successes = 0
+		} else if f.successes++; f.successes >= f.opts.RecoveryThreshold {
+			f.down = false
+			f.failures = 0
+			metrics.StorageFailover.Add("marked_up", 1)
+			metrics.StoragePrimaryHealthy.Set(1)
+			f.logger.Info().Str("primary", f.Backend.Name()).Int("probes", f.successes).Msg("Primary storage recovered")
+		}
+		f.mu.Unlock()
+	}
+}
+
+// unavailable reports whether err means the backend couldn't serve the
+// request: network failures, timeouts and 5xx responses. Missing objects and
+// the caller's own cancellation don't count.
+func unavailable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if resp := minio.ToErrorResponse(err); resp.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	var azErr *azcore.ResponseError
+	return errors.As(err, &azErr) && azErr.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProviderFS serves buckets from directories on the local filesystem
+const ProviderFS = "fs"
+
+// fsMetaDir and fsTempDir live in the root; bucket names can't start with "."
+const (
+	fsMetaDir = ".meta"
+	fsTempDir = ".tmp"
+)
+
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// FSBackend stores each bucket as a directory under root and each object as a
+// file, with content type and user metadata in a JSON sidecar under
+// root/.meta. It is meant for development and tests: there is no presigning,
+// and a key can't be both an object and a "directory" of other keys.
+type FSBackend struct {
+	root string
+}
+
+type fsSidecar struct {
+	ETag        string            `json:"etag"`
+	ContentType string            `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// NewFSBackend creates an FSBackend rooted at dir, creating it if needed
+func NewFSBackend(dir string) (*FSBackend, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range []string{root, filepath.Join(root, fsMetaDir), filepath.Join(root, fsTempDir)} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+	return &FSBackend{root: root}, nil
+}
+
+func (b *FSBackend) Name() string { return ProviderFS }
+
+// objectPath returns the data and sidecar paths of key, rejecting keys that
+// would escape the bucket
+func (b *FSBackend) objectPath(bucket, key string) (string, string, error) {
+	if !bucketNamePattern.MatchString(bucket) {
+		return "", "", ErrInvalidBucket
+	}
+	if key == "" || strings.HasSuffix(key, "/") || path.Clean("/"+key) != "/"+key {
+		return "", "", fmt.Errorf("%w: invalid key %q", ErrNotFound, key)
+	}
+	rel := filepath.FromSlash(key)
+	return filepath.Join(b.root, bucket, rel), filepath.Join(b.root, fsMetaDir, bucket, rel+".json"), nil
+}
+
+func (b *FSBackend) bucketPath(bucket string) (string, error) {
+	if !bucketNamePattern.MatchString(bucket) {
+		return "", ErrInvalidBucket
+	}
+	return filepath.Join(b.root, bucket), nil
+}
+
+func (b *FSBackend) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	dataPath, metaPath, err := b.objectPath(bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	fi, err := os.Stat(dataPath)
+	if err != nil || fi.IsDir() {
+		return ObjectInfo{}, b.missing(bucket, err)
+	}
+	return b.info(bucket, key, fi, metaPath), nil
+}
+
+func (b *FSBackend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	dataPath, metaPath, err := b.objectPath(bucket, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(dataPath)
+	if err != nil {
+		return nil, ObjectInfo{}, b.missing(bucket, err)
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, b.missing(bucket, err)
+	}
+	info := b.info(bucket, key, fi, metaPath)
+	if r, ok := RangeFrom(ctx); ok {
+		if _, err := f.Seek(r.Offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, ObjectInfo{}, err
+		}
+		info.Size = max(0, min(r.Length, info.Size-r.Offset))
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(f, r.Length), f}, info, nil
+	}
+	return f, info, nil
+}
+
+func (b *FSBackend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	if err := rejectCustomerKey(ctx, b.Name()); err != nil {
+		return ObjectInfo{}, err
+	}
+	dataPath, metaPath, err := b.objectPath(bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if exists, err := b.BucketExists(ctx, bucket); err != nil || !exists {
+		return ObjectInfo{}, b.missing(bucket, err)
+	}
+	if opts.Progress != nil {
+		r = &progressReader{r: r, progress: opts.Progress}
+	}
+
+	// Write to a temp file and rename so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Join(b.root, fsTempDir), "put-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if size >= 0 && written != size {
+		return ObjectInfo{}, fmt.Errorf("short upload: wrote %d of %d bytes", written, size)
+	}
+
+	sidecar := fsSidecar{ETag: hex.EncodeToString(hash.Sum(nil)), ContentType: opts.ContentType, Metadata: opts.Metadata}
+	data, err := json.Marshal(sidecar)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	for _, p := range []string{dataPath, metaPath} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Rename(tmp.Name(), dataPath); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	fi, err := os.Stat(dataPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return b.info(bucket, key, fi, metaPath), nil
+}
+
+// Remove deletes an object and prunes directories it leaves empty
+func (b *FSBackend) Remove(ctx context.Context, bucket, key string) error {
+	dataPath, metaPath, err := b.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{dataPath, metaPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	b.prune(filepath.Dir(dataPath), filepath.Join(b.root, bucket))
+	b.prune(filepath.Dir(metaPath), filepath.Join(b.root, fsMetaDir, bucket))
+	return nil
+}
+
+// prune removes empty directories from dir up to, but not including, stop
+func (b *FSBackend) prune(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// List returns keys in lexical order; the continuation token is the last key
+// of the previous page
+func (b *FSBackend) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	dir, err := b.bucketPath(bucket)
+	if err != nil {
+		return ListResult{}, err
+	}
+	limit := opts.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+
+	var keys []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, opts.Prefix) && key > opts.ContinuationToken {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return ListResult{}, b.missing(bucket, err)
+	}
+	// WalkDir orders "a/b" before "a-b"; S3 lists in byte order
+	sort.Strings(keys)
+
+	page := ListResult{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		page.NextContinuationToken = keys[limit-1]
+	}
+	page.Objects = make([]ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		info, err := b.Stat(ctx, bucket, key)
+		if err != nil {
+			// Removed since the walk
+			continue
+		}
+		page.Objects = append(page.Objects, info)
+	}
+	return page, nil
+}
+
+func (b *FSBackend) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	entries, err := os.ReadDir(b.root)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []BucketInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || !bucketNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		bucket := BucketInfo{Name: entry.Name()}
+		if fi, err := entry.Info(); err == nil {
+			bucket.CreationDate = fi.ModTime()
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+func (b *FSBackend) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	dir, err := b.bucketPath(bucket)
+	if err != nil {
+		return false, err
+	}
+	fi, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return fi.IsDir(), nil
+}
+
+func (b *FSBackend) MakeBucket(ctx context.Context, bucket string) error {
+	dir, err := b.bucketPath(bucket)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %s", ErrBucketExists, bucket)
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *FSBackend) RemoveBucket(ctx context.Context, bucket string) error {
+	page, err := b.List(ctx, bucket, ListOptions{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Objects) > 0 {
+		return ErrBucketNotEmpty
+	}
+	// Only empty directories remain
+	if err := os.RemoveAll(filepath.Join(b.root, bucket)); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(b.root, fsMetaDir, bucket))
+}
+
+// info builds ObjectInfo from the file and its sidecar. Files copied in by hand
+// have no sidecar, so their content type comes from the extension.
+func (b *FSBackend) info(bucket, key string, fi fs.FileInfo, metaPath string) ObjectInfo {
+	info := ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         fi.Size(),
+		LastModified: fi.ModTime().UTC(),
+		Metadata:     map[string]string{},
+	}
+	var sidecar fsSidecar
+	if data, err := os.ReadFile(metaPath); err == nil && json.Unmarshal(data, &sidecar) == nil {
+		info.ETag = sidecar.ETag
+		info.ContentType = sidecar.ContentType
+		for k, v := range sidecar.Metadata {
+			info.Metadata[k] = v
+		}
+	} else {
+		info.ETag = fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size())
+	}
+	if info.ContentType == "" {
+		info.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if info.ContentType == "" {
+		info.ContentType = "application/octet-stream"
+	}
+	return info
+}
+
+// missing maps a filesystem error to ErrBucketNotFound or ErrNotFound
+func (b *FSBackend) missing(bucket string, err error) error {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if fi, statErr := os.Stat(filepath.Join(b.root, bucket)); statErr != nil || !fi.IsDir() {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderMemory keeps buckets in process memory
+const ProviderMemory = "memory"
+
+// MemoryBackend stores objects in memory. It is meant for tests and local
+// development without an object store: contents are lost on exit, and there
+// is no presigning.
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	created time.Time
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	info ObjectInfo
+	data []byte
+}
+
+// NewMemoryBackend creates an empty MemoryBackend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{buckets: make(map[string]*memoryBucket)}
+}
+
+func (b *MemoryBackend) Name() string { return ProviderMemory }
+
+func (b *MemoryBackend) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	obj, err := b.object(bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return cloneInfo(obj.info), nil
+}
+
+func (b *MemoryBackend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	obj, err := b.object(bucket, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	// Stored data is never modified in place, so readers can share it
+	data, info := obj.data, cloneInfo(obj.info)
+	if r, ok := RangeFrom(ctx); ok {
+		start := min(r.Offset, int64(len(data)))
+		data = data[start:min(start+r.Length, int64(len(data)))]
+		info.Size = int64(len(data))
+	}
+	return io.NopCloser(bytes.NewReader(data)), info, nil
+}
+
+func (b *MemoryBackend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	if err := rejectCustomerKey(ctx, b.Name()); err != nil {
+		return ObjectInfo{}, err
+	}
+	if key == "" {
+		return ObjectInfo{}, fmt.Errorf("%w: invalid key %q", ErrNotFound, key)
+	}
+	if opts.Progress != nil {
+		r = &progressReader{r: r, progress: opts.Progress}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return ObjectInfo{}, fmt.Errorf("short upload: wrote %d of %d bytes", len(data), size)
+	}
+
+	sum := md5.Sum(data)
+	info := ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         int64(len(data)),
+		ETag:         hex.EncodeToString(sum[:]),
+		ContentType:  opts.ContentType,
+		LastModified: time.Now().UTC(),
+		Metadata:     make(map[string]string, len(opts.Metadata)),
+	}
+	if info.ContentType == "" {
+		info.ContentType = "application/octet-stream"
+	}
+	for k, v := range opts.Metadata {
+		info.Metadata[k] = v
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bkt, ok := b.buckets[bucket]
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	bkt.objects[key] = memoryObject{info: info, data: data}
+	return cloneInfo(info), nil
+}
+
+func (b *MemoryBackend) Remove(ctx context.Context, bucket, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bkt, ok := b.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	delete(bkt.objects, key)
+	return nil
+}
+
+// List returns keys in lexical order; the continuation token is the last key
+// of the previous page
+func (b *MemoryBackend) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	bkt, ok := b.buckets[bucket]
+	if !ok {
+		return ListResult{}, fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	var keys []string
+	for key := range bkt.objects {
+		if strings.HasPrefix(key, opts.Prefix) && key > opts.ContinuationToken {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	page := ListResult{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		page.NextContinuationToken = keys[limit-1]
+	}
+	page.Objects = make([]ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		page.Objects = append(page.Objects, cloneInfo(bkt.objects[key].info))
+	}
+	return page, nil
+}
+
+func (b *MemoryBackend) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	buckets := make([]BucketInfo, 0, len(b.buckets))
+	for name, bkt := range b.buckets {
+		buckets = append(buckets, BucketInfo{Name: name, CreationDate: bkt.created})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+func (b *MemoryBackend) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	if !bucketNamePattern.MatchString(bucket) {
+		return false, ErrInvalidBucket
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.buckets[bucket]
+	return ok, nil
+}
+
+func (b *MemoryBackend) MakeBucket(ctx context.Context, bucket string) error {
+	if !bucketNamePattern.MatchString(bucket) {
+		return ErrInvalidBucket
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.buckets[bucket]; ok {
+		return fmt.Errorf("%w: %s", ErrBucketExists, bucket)
+	}
+	b.buckets[bucket] = &memoryBucket{created: time.Now().UTC(), objects: make(map[string]memoryObject)}
+	return nil
+}
+
+func (b *MemoryBackend) RemoveBucket(ctx context.Context, bucket string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bkt, ok := b.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	if len(bkt.objects) > 0 {
+		return ErrBucketNotEmpty
+	}
+	delete(b.buckets, bucket)
+	return nil
+}
+
+// object returns a stored object; the caller holds the lock
+func (b *MemoryBackend) object(bucket, key string) (memoryObject, error) {
+	bkt, ok := b.buckets[bucket]
+	if !ok {
+		return memoryObject{}, fmt.Errorf("%w: %s", ErrBucketNotFound, bucket)
+	}
+	obj, ok := bkt.objects[key]
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
+
+// cloneInfo copies the metadata map so callers can't modify stored objects
+func cloneInfo(info ObjectInfo) ObjectInfo {
+	metadata := make(map[string]string, len(info.Metadata))
+	for k, v := range info.Metadata {
+		metadata[k] = v
+	}
+	info.Metadata = metadata
+	return info
+}
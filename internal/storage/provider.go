@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
+)
+
+// Storage providers
+const (
+	ProviderMinio  = "minio"
+	ProviderS3     = "s3"
+	ProviderAWS    = "aws"
+	ProviderWasabi = "wasabi"
+	ProviderB2     = "b2"
+)
+
+// New creates the backend selected by STORAGE_PROVIDER, routing the buckets in
+// STORAGE_BUCKET_PROVIDERS to their own providers, and makes sure the
+// configured bucket exists
+func New(ctx context.Context, cfg *config.Config) (Backend, error) {
+	backends := map[string]Backend{}
+	open := func(provider string) (Backend, error) {
+		provider = strings.ToLower(provider)
+		if provider == "" {
+			provider = ProviderMinio
+		}
+		if b, ok := backends[provider]; ok {
+			return b, nil
+		}
+		b, err := Open(provider, cfg)
+		if err != nil {
+			return nil, err
+		}
+		backends[provider] = b
+		return b, nil
+	}
+
+	backend, err := open(cfg.StorageProvider)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.StorageBucketProviders) > 0 {
+		router := NewRouter(backend)
+		for _, entry := range cfg.StorageBucketProviders {
+			pattern, provider, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || pattern == "" || provider == "" {
+				return nil, fmt.Errorf("invalid STORAGE_BUCKET_PROVIDERS entry %q, expected bucket=provider", entry)
+			}
+			routed, err := open(provider)
+			if err != nil {
+				return nil, err
+			}
+			router.Route(pattern, routed)
+		}
+		backend = router
+	}
+
+	if err := EnsureBucket(ctx, backend, cfg.MinioBucketName); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// Open creates the backend for a single provider, without bucket routing
+func Open(provider string, cfg *config.Config) (Backend, error) {
+	switch provider = strings.ToLower(provider); provider {
+	case "", ProviderMinio:
+		client, err := config.InitMinioClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newS3Backend(ProviderMinio, client, "", cfg)
+	case ProviderAzure:
+		if cfg.AzureAccount == "" || cfg.AzureAccountKey == "" {
+			return nil, fmt.Errorf("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY are required for the azure storage provider")
+		}
+		endpoint := cfg.AzureEndpoint
+		if endpoint == "" {
+			endpoint = "https://" + cfg.AzureAccount + ".blob.core.windows.net/"
+		}
+		b, err := NewAzureBackend(endpoint, cfg.AzureAccount, cfg.AzureAccountKey)
+		if err != nil {
+			return nil, err
+		}
+		b.tuning = PutTuningFromConfig(cfg)
+		return b, nil
+	case ProviderFS:
+		return NewFSBackend(cfg.StorageFSRoot)
+	case ProviderMemory:
+		return NewMemoryBackend(), nil
+	}
+
+	client, err := newS3Client(provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newS3Backend(provider, client, cfg.S3Region, cfg)
+}
+
+// newS3Backend applies the SSE_MODE default encryption to an S3 backend
+func newS3Backend(name string, client *minio.Client, region string, cfg *config.Config) (Backend, error) {
+	sse, err := ParseSSEMode(cfg.SSEMode, cfg.SSEKMSKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSE_MODE: %w", err)
+	}
+	b := NewS3Backend(name, client, region)
+	b.sse = sse
+	b.tuning = PutTuningFromConfig(cfg)
+	return b, nil
+}
+
+// PutTuningFromConfig returns the upload tuning set by the UPLOAD_PART_SIZE,
+// UPLOAD_CONCURRENCY, UPLOAD_SEND_CONTENT_MD5 and UPLOAD_MULTIPART_THRESHOLD
+// settings
+func PutTuningFromConfig(cfg *config.Config) PutTuning {
+	return PutTuning{
+		PartSize:           uint64(cfg.UploadPartSize),
+		Concurrency:        uint(cfg.UploadConcurrency),
+		SendContentMD5:     cfg.UploadSendContentMD5,
+		MultipartThreshold: cfg.UploadMultipartThreshold,
+	}
+}
+
+func newS3Client(provider string, cfg *config.Config) (*minio.Client, error) {
+	endpoint := cfg.S3Endpoint
+	if endpoint == "" {
+		switch provider {
+		case ProviderAWS:
+			endpoint = "s3." + cfg.S3Region + ".amazonaws.com"
+		case ProviderWasabi:
+			endpoint = "s3." + cfg.S3Region + ".wasabisys.com"
+		case ProviderB2:
+			endpoint = "s3." + cfg.S3Region + ".backblazeb2.com"
+		case ProviderS3:
+			return nil, fmt.Errorf("S3_ENDPOINT is required for the s3 storage provider")
+		default:
+			return nil, fmt.Errorf("unknown storage provider %q", provider)
+		}
+	}
+
+	var creds *credentials.Credentials
+	switch {
+	case cfg.S3AccessKeyID != "":
+		creds = credentials.NewStaticV4(cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3SessionToken)
+	case provider == ProviderAWS:
+		// Fall back to the standard AWS chain: environment, shared credentials file, instance role
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	default:
+		return nil, fmt.Errorf("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the %s storage provider", provider)
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.S3ForcePathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	transport, err := cfg.StorageTransport(cfg.S3UseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %w", provider, err)
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       cfg.S3UseSSL,
+		Region:       cfg.S3Region,
+		BucketLookup: lookup,
+		// Tag calls with the IDs of the API request they serve
+		Transport: requestid.Transport(transport),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client: %w", provider, err)
+	}
+	return client, nil
+}
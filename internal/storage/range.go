@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// ByteRange is Length bytes of an object from Offset
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+type byteRangeKey struct{}
+
+// WithRange returns a context whose object reads return only r of the
+// object. Their ObjectInfo describes the object, except Size, which is the
+// number of bytes read; callers take the object's size from Stat.
+func WithRange(ctx context.Context, r ByteRange) context.Context {
+	return context.WithValue(ctx, byteRangeKey{}, r)
+}
+
+// RangeFrom returns the byte range carried by ctx, if any
+func RangeFrom(ctx context.Context) (ByteRange, bool) {
+	r, ok := ctx.Value(byteRangeKey{}).(ByteRange)
+	return r, ok
+}
+
+// readRange cuts r out of a reader from the start of the object, or from a
+// point before r, by reading past the bytes before it
+func readRange(object io.ReadCloser, info *ObjectInfo, r ByteRange) (io.ReadCloser, error) {
+	if _, err := io.CopyN(io.Discard, object, r.Offset); err != nil {
+		object.Close()
+		return nil, err
+	}
+	info.Size = max(0, min(r.Length, info.Size-r.Offset))
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(object, r.Length), object}, nil
+}
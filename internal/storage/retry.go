@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+)
+
+// Operations Retry retries, as named in RetryOptions.Budgets and metrics
+const (
+	OpStat         = "stat"
+	OpGet          = "get"
+	OpList         = "list"
+	OpRemove       = "remove"
+	OpListBuckets  = "list_buckets"
+	OpBucketExists = "bucket_exists"
+)
+
+// RetryOps lists the operations Retry retries
+var RetryOps = []string{OpStat, OpGet, OpList, OpRemove, OpListBuckets, OpBucketExists}
+
+// RetryOptions tunes Retry
+type RetryOptions struct {
+	// MaxAttempts is how often an operation is tried, the first time included
+	MaxAttempts int
+	// BaseDelay is the longest wait before the first retry. It doubles with
+	// each retry up to MaxDelay, and the actual wait is a random fraction of it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Budgets bound the time an operation may spend across its attempts; no
+	// retry starts that would end past the budget. Operations without one are
+	// bounded by MaxAttempts and their context only.
+	Budgets map[string]time.Duration
+}
+
+// Retry retries idempotent operations (stat, get, list, remove and bucket
+// lookups) that fail with network errors, timeouts or 5xx responses, with
+// jittered exponential backoff. Get is retried until the object is opened;
+// errors while reading it are the caller's. Writes are passed through once.
+type Retry struct {
+	Backend
+	opts RetryOptions
+}
+
+// NewRetry wraps b so its idempotent operations are retried
+func NewRetry(b Backend, opts RetryOptions) *Retry {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.MaxDelay < opts.BaseDelay {
+		opts.MaxDelay = opts.BaseDelay
+	}
+	return &Retry{Backend: b, opts: opts}
+}
+
+// ParseRetryBudgets parses "operation=seconds" entries
+func ParseRetryBudgets(entries []string) (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		op, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		seconds, err := strconv.Atoi(value)
+		if !ok || err != nil || seconds <= 0 || !isRetryOp(op) {
+			return nil, fmt.Errorf("invalid retry budget %q, expected operation=seconds with operation one of %s", entry, strings.Join(RetryOps, ", "))
+		}
+		budgets[op] = time.Duration(seconds) * time.Second
+	}
+	return budgets, nil
+}
+
+func isRetryOp(op string) bool {
+	for _, known := range RetryOps {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the backend whose operations are retried
+func (r *Retry) Unwrap() Backend { return r.Backend }
+
+func (r *Retry) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	var info ObjectInfo
+	err := r.do(ctx, OpStat, func() (err error) {
+		info, err = r.Backend.Stat(ctx, bucket, key)
+		return err
+	})
+	return info, err
+}
+
+func (r *Retry) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	var reader io.ReadCloser
+	var info ObjectInfo
+	err := r.do(ctx, OpGet, func() (err error) {
+		reader, info, err = r.Backend.Get(ctx, bucket, key)
+		return err
+	})
+	return reader, info, err
+}
+
+func (r *Retry) Remove(ctx context.Context, bucket, key string) error {
+	return r.do(ctx, OpRemove, func() error {
+		return r.Backend.Remove(ctx, bucket, key)
+	})
+}
+
+func (r *Retry) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	var page ListResult
+	err := r.do(ctx, OpList, func() (err error) {
+		page, err = r.Backend.List(ctx, bucket, opts)
+		return err
+	})
+	return page, err
+}
+
+func (r *Retry) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	var buckets []BucketInfo
+	err := r.do(ctx, OpListBuckets, func() (err error) {
+		buckets, err = r.Backend.ListBuckets(ctx)
+		return err
+	})
+	return buckets, err
+}
+
+func (r *Retry) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	var exists bool
+	err := r.do(ctx, OpBucketExists, func() (err error) {
+		exists, err = r.Backend.BucketExists(ctx, bucket)
+		return err
+	})
+	return exists, err
+}
+
+// NotifyWrite forwards to the wrapped backend, which may be mirroring writes
+func (r *Retry) NotifyWrite(ctx context.Context, bucket, key string) error {
+	return NotifyWrite(ctx, r.Backend, bucket, key)
+}
+
+func (r *Retry) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	presigner, ok := r.Backend.(Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", ErrNotSupported, r.Backend.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
+
+// do runs op until it succeeds, fails with an error retrying won't fix, or
+// runs out of attempts or budget
+func (r *Retry) do(ctx context.Context, name string, op func() error) error {
+	start := time.Now()
+	budget := r.opts.Budgets[name]
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if !unavailable(ctx, err) {
+			return err
+		}
+		delay := r.backoff(attempt)
+		if attempt >= r.opts.MaxAttempts || (budget > 0 && time.Since(start)+delay > budget) {
+			if attempt > 1 {
+				metrics.StorageRetries.Add("exhausted", 1)
+			}
+			return err
+		}
+
+		metrics.StorageRetries.Add(name, 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt: a random duration up
+// to BaseDelay doubled attempt-1 times, capped at MaxDelay
+func (r *Retry) backoff(attempt int) time.Duration {
+	ceiling := r.opts.BaseDelay
+	for i := 1; i < attempt && ceiling < r.opts.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, r.opts.MaxDelay)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
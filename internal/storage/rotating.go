@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// Rotating serves requests from a backend that can be replaced while the
+// server runs, e.g. with clients built from rotated credentials. Requests
+// already in progress finish on the backend they started with.
+type Rotating struct {
+	current atomic.Value // holds rotatingBackend
+}
+
+// rotatingBackend lets atomic.Value hold backends of different types
+type rotatingBackend struct{ Backend }
+
+// NewRotating creates a Rotating serving from b
+func NewRotating(b Backend) *Rotating {
+	r := &Rotating{}
+	r.Swap(b)
+	return r
+}
+
+// Swap makes b serve subsequent requests
+func (r *Rotating) Swap(b Backend) {
+	r.current.Store(rotatingBackend{b})
+}
+
+// Unwrap returns the backend currently serving requests
+func (r *Rotating) Unwrap() Backend {
+	return r.current.Load().(rotatingBackend).Backend
+}
+
+func (r *Rotating) Name() string { return r.Unwrap().Name() }
+
+func (r *Rotating) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	return r.Unwrap().Stat(ctx, bucket, key)
+}
+
+func (r *Rotating) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	return r.Unwrap().Get(ctx, bucket, key)
+}
+
+func (r *Rotating) Put(ctx context.Context, bucket, key string, reader io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	return r.Unwrap().Put(ctx, bucket, key, reader, size, opts)
+}
+
+func (r *Rotating) Remove(ctx context.Context, bucket, key string) error {
+	return r.Unwrap().Remove(ctx, bucket, key)
+}
+
+func (r *Rotating) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	return r.Unwrap().List(ctx, bucket, opts)
+}
+
+func (r *Rotating) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	return r.Unwrap().ListBuckets(ctx)
+}
+
+func (r *Rotating) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	return r.Unwrap().BucketExists(ctx, bucket)
+}
+
+func (r *Rotating) MakeBucket(ctx context.Context, bucket string) error {
+	return r.Unwrap().MakeBucket(ctx, bucket)
+}
+
+func (r *Rotating) RemoveBucket(ctx context.Context, bucket string) error {
+	return r.Unwrap().RemoveBucket(ctx, bucket)
+}
+
+func (r *Rotating) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	b := r.Unwrap()
+	presigner, ok := b.(Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", ErrNotSupported, b.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
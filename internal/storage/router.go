@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Router sends each bucket to the backend configured for it, falling back to a
+// default backend for unrouted buckets. Patterns are exact bucket names or
+// prefixes ending in "*", e.g. "tenant-*"; the first matching pattern wins.
+type Router struct {
+	fallback Backend
+	routes   []bucketRoute
+}
+
+type bucketRoute struct {
+	pattern string
+	backend Backend
+}
+
+// NewRouter creates a Router that serves unrouted buckets from fallback
+func NewRouter(fallback Backend) *Router {
+	return &Router{fallback: fallback}
+}
+
+// Route serves buckets matching pattern from backend
+func (r *Router) Route(pattern string, backend Backend) {
+	r.routes = append(r.routes, bucketRoute{pattern: pattern, backend: backend})
+}
+
+// Backend returns the backend serving bucket
+func (r *Router) Backend(bucket string) Backend {
+	for _, route := range r.routes {
+		if prefix, ok := strings.CutSuffix(route.pattern, "*"); ok {
+			if strings.HasPrefix(bucket, prefix) {
+				return route.backend
+			}
+		} else if route.pattern == bucket {
+			return route.backend
+		}
+	}
+	return r.fallback
+}
+
+// Wrapper is implemented by backends that add behavior on top of another backend
+type Wrapper interface {
+	Unwrap() Backend
+}
+
+// Route returns the backend serving bucket, resolving Routers and Wrappers
+func Route(b Backend, bucket string) Backend {
+	for {
+		switch v := b.(type) {
+		case *Router:
+			b = v.Backend(bucket)
+		case Wrapper:
+			b = v.Unwrap()
+		default:
+			return b
+		}
+	}
+}
+
+// As finds the first backend of type T in b's chain of Wrappers
+func As[T Backend](b Backend) (T, bool) {
+	for {
+		if t, ok := b.(T); ok {
+			return t, true
+		}
+		w, ok := b.(Wrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		b = w.Unwrap()
+	}
+}
+
+// WriteNotifier is implemented by backends that need to hear about objects
+// written around them, e.g. through multipart uploads on the S3 client
+type WriteNotifier interface {
+	NotifyWrite(ctx context.Context, bucket, key string) error
+}
+
+// NotifyWrite tells b that an object was written outside of Put
+func NotifyWrite(ctx context.Context, b Backend, bucket, key string) error {
+	if n, ok := b.(WriteNotifier); ok {
+		return n.NotifyWrite(ctx, bucket, key)
+	}
+	return nil
+}
+
+func (r *Router) Name() string { return r.fallback.Name() }
+
+func (r *Router) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	return r.Backend(bucket).Stat(ctx, bucket, key)
+}
+
+func (r *Router) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	return r.Backend(bucket).Get(ctx, bucket, key)
+}
+
+func (r *Router) Put(ctx context.Context, bucket, key string, reader io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	return r.Backend(bucket).Put(ctx, bucket, key, reader, size, opts)
+}
+
+func (r *Router) Remove(ctx context.Context, bucket, key string) error {
+	return r.Backend(bucket).Remove(ctx, bucket, key)
+}
+
+func (r *Router) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	return r.Backend(bucket).List(ctx, bucket, opts)
+}
+
+// ListBuckets merges the buckets of every backend, keeping each bucket only
+// from the backend it is routed to
+func (r *Router) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	backends := []Backend{r.fallback}
+	for _, route := range r.routes {
+		seen := false
+		for _, b := range backends {
+			seen = seen || b == route.backend
+		}
+		if !seen {
+			backends = append(backends, route.backend)
+		}
+	}
+
+	var buckets []BucketInfo
+	for _, b := range backends {
+		list, err := b.ListBuckets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s buckets: %w", b.Name(), err)
+		}
+		for _, bucket := range list {
+			if r.Backend(bucket.Name) == b {
+				buckets = append(buckets, bucket)
+			}
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+func (r *Router) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	return r.Backend(bucket).BucketExists(ctx, bucket)
+}
+
+func (r *Router) MakeBucket(ctx context.Context, bucket string) error {
+	return r.Backend(bucket).MakeBucket(ctx, bucket)
+}
+
+func (r *Router) RemoveBucket(ctx context.Context, bucket string) error {
+	return r.Backend(bucket).RemoveBucket(ctx, bucket)
+}
+
+func (r *Router) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	b := r.Backend(bucket)
+	presigner, ok := b.(Presigner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: presigned URLs on %s", ErrNotSupported, b.Name())
+	}
+	return presigner.Presign(ctx, method, bucket, key, expiry, opts)
+}
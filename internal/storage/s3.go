@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// S3Backend stores objects on MinIO or any S3-compatible service
+type S3Backend struct {
+	name   string
+	client *minio.Client
+	region string
+	// sse is the default encryption of uploads without an SSE-C key; nil leaves it to the bucket
+	sse encrypt.ServerSide
+	// tuning applies to uploads that don't bring their own
+	tuning PutTuning
+}
+
+// NewS3Backend wraps an S3 client. name identifies the provider in logs and metrics.
+func NewS3Backend(name string, client *minio.Client, region string) *S3Backend {
+	return &S3Backend{name: name, client: client, region: region}
+}
+
+func (b *S3Backend) Name() string { return b.name }
+
+// Client returns the underlying S3 client
+func (b *S3Backend) Client() *minio.Client { return b.client }
+
+func (b *S3Backend) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	sse, err := customerSSE(ctx)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := b.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return ObjectInfo{}, translateError(err)
+	}
+	return objectInfo(bucket, info), nil
+}
+
+func (b *S3Backend) Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	sse, err := customerSSE(ctx)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	opts := minio.GetObjectOptions{ServerSideEncryption: sse}
+	if r, ok := RangeFrom(ctx); ok {
+		if err := opts.SetRange(r.Offset, r.Offset+r.Length-1); err != nil {
+			return nil, ObjectInfo{}, err
+		}
+	}
+	object, err := b.client.GetObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, ObjectInfo{}, translateError(err)
+	}
+	// GetObject is lazy; Stat issues the request and surfaces missing objects
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, ObjectInfo{}, translateError(err)
+	}
+	return object, objectInfo(bucket, info), nil
+}
+
+func (b *S3Backend) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	sse, err := customerSSE(ctx)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if sse == nil {
+		sse = b.sse
+	}
+	tuning := b.tuning
+	if opts.Tuning != nil {
+		tuning = *opts.Tuning
+	}
+	putOpts := minio.PutObjectOptions{
+		ContentType:          opts.ContentType,
+		UserMetadata:         opts.Metadata,
+		Progress:             opts.Progress,
+		ServerSideEncryption: sse,
+		PartSize:             tuning.PartSize,
+		NumThreads:           tuning.Concurrency,
+		SendContentMd5:       tuning.SendContentMD5,
+		DisableMultipart:     size >= 0 && size < tuning.MultipartThreshold,
+	}
+	var info minio.UploadInfo
+	if sum, ok := contentMD5(opts.ContentMD5); ok && singlePut(size, putOpts) && size <= maxSinglePutSize {
+		// A single PUT is checked against the digest of the whole object
+		info, err = minio.Core{Client: b.client}.PutObject(ctx, bucket, key, r, size, sum, "", putOpts)
+	} else {
+		if opts.ContentMD5 != "" {
+			// Multipart uploads can only have each part checked
+			putOpts.SendContentMd5 = true
+		}
+		info, err = b.client.PutObject(ctx, bucket, key, r, size, putOpts)
+	}
+	if err != nil {
+		return ObjectInfo{}, translateError(err)
+	}
+	return ObjectInfo{
+		Bucket:       info.Bucket,
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		ContentType:  opts.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     opts.Metadata,
+	}, nil
+}
+
+func (b *S3Backend) Remove(ctx context.Context, bucket, key string) error {
+	return translateError(b.client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (b *S3Backend) List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	core := minio.Core{Client: b.client}
+	result, err := core.ListObjectsV2(bucket, opts.Prefix, "", opts.ContinuationToken, "", limit)
+	if err != nil {
+		if opts.ContinuationToken != "" && minio.ToErrorResponse(err).Code == "InvalidArgument" {
+			return ListResult{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		return ListResult{}, translateError(err)
+	}
+	page := ListResult{Objects: make([]ObjectInfo, 0, len(result.Contents))}
+	for _, object := range result.Contents {
+		page.Objects = append(page.Objects, objectInfo(bucket, object))
+	}
+	if result.IsTruncated {
+		page.NextContinuationToken = result.NextContinuationToken
+	}
+	return page, nil
+}
+
+func (b *S3Backend) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	buckets, err := b.client.ListBuckets(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	result := make([]BucketInfo, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, BucketInfo{Name: bucket.Name, CreationDate: bucket.CreationDate})
+	}
+	return result, nil
+}
+
+func (b *S3Backend) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	exists, err := b.client.BucketExists(ctx, bucket)
+	return exists, translateError(err)
+}
+
+func (b *S3Backend) MakeBucket(ctx context.Context, bucket string) error {
+	return translateError(b.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: b.region}))
+}
+
+func (b *S3Backend) RemoveBucket(ctx context.Context, bucket string) error {
+	return translateError(b.client.RemoveBucket(ctx, bucket))
+}
+
+func (b *S3Backend) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error) {
+	headers := http.Header{}
+	var u *url.URL
+	var err error
+	switch method {
+	case http.MethodGet:
+		params := url.Values{}
+		if opts.ResponseContentType != "" {
+			params.Set("response-content-type", opts.ResponseContentType)
+		}
+		if opts.ResponseContentDisposition != "" {
+			params.Set("response-content-disposition", opts.ResponseContentDisposition)
+		}
+		u, err = b.client.PresignedGetObject(ctx, bucket, key, expiry, params)
+	case http.MethodHead:
+		u, err = b.client.PresignedHeadObject(ctx, bucket, key, expiry, nil)
+	case http.MethodPut:
+		// Signed headers pin what the client may upload
+		if opts.ContentType != "" {
+			headers.Set("Content-Type", opts.ContentType)
+		}
+		if opts.CacheControl != "" {
+			headers.Set("Cache-Control", opts.CacheControl)
+		}
+		if opts.ContentDisposition != "" {
+			headers.Set("Content-Disposition", opts.ContentDisposition)
+		}
+		for k, v := range opts.Metadata {
+			headers.Set("X-Amz-Meta-"+k, v)
+		}
+		u, err = b.client.PresignHeader(ctx, http.MethodPut, bucket, key, expiry, nil, headers)
+	case http.MethodDelete:
+		u, err = b.client.Presign(ctx, http.MethodDelete, bucket, key, expiry, nil)
+	default:
+		return nil, nil, fmt.Errorf("%w: presigned %s", ErrNotSupported, method)
+	}
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+	return u, headers, nil
+}
+
+func objectInfo(bucket string, info minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     info.UserMetadata,
+	}
+}
+
+const (
+	// maxSinglePutSize is the largest object S3 accepts in a single PUT
+	maxSinglePutSize = 5 << 30
+	// defaultPartSize is the part size of the S3 client when none is set
+	defaultPartSize = 16 << 20
+)
+
+// singlePut reports whether the S3 client sends an object of size in a single
+// PUT with opts: multipart is disabled below the threshold, and objects no
+// larger than one part don't need it
+func singlePut(size int64, opts minio.PutObjectOptions) bool {
+	partSize := int64(opts.PartSize)
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+	return size >= 0 && (opts.DisableMultipart || size <= partSize)
+}
+
+// contentMD5 converts a hex MD5 to the base64 of a Content-MD5 header
+func contentMD5(hexSum string) (string, bool) {
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || len(sum) != md5.Size {
+		return "", false
+	}
+	return base64.StdEncoding.EncodeToString(sum), true
+}
+
+// translateError wraps S3 error codes in the package's sentinel errors
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	switch resp.Code {
+	case "NoSuchKey":
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case "NoSuchBucket":
+		return fmt.Errorf("%w: %w", ErrBucketNotFound, err)
+	case "BucketAlreadyOwnedByYou", "BucketAlreadyExists":
+		return fmt.Errorf("%w: %w", ErrBucketExists, err)
+	case "BucketNotEmpty":
+		return fmt.Errorf("%w: %w", ErrBucketNotEmpty, err)
+	case "InvalidBucketName":
+		return fmt.Errorf("%w: %w", ErrInvalidBucket, err)
+	case "BadDigest":
+		return fmt.Errorf("%w: %w", ErrBadDigest, err)
+	case "InvalidRequest":
+		if strings.Contains(resp.Message, "Server Side Encryption") {
+			return fmt.Errorf("%w: %w", ErrCustomerKeyRequired, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// Errors returned by backends, wrapping the provider's own error
+var (
+	ErrNotFound       = errors.New("object not found")
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrBucketExists   = errors.New("bucket already exists")
+	ErrBucketNotEmpty = errors.New("bucket is not empty")
+	ErrInvalidBucket  = errors.New("invalid bucket name")
+	ErrInvalidToken   = errors.New("invalid continuation token")
+	ErrNotSupported   = errors.New("operation not supported by the storage backend")
+	ErrBadDigest      = errors.New("content does not match its MD5 digest")
+	// ErrCustomerKeyRequired is returned reading an object sealed with an
+	// SSE-C key without it
+	ErrCustomerKeyRequired = errors.New("object is encrypted with a customer-provided key")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Bucket       string
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	// Metadata holds user metadata with canonical header keys, e.g. "Sha256"
+	Metadata map[string]string
+}
+
+// SourceKeyMetadataKey is recorded on derived objects, such as thumbnails and
+// video streams, with the key of the object they were generated from
+const SourceKeyMetadataKey = "Source-Key"
+
+// BucketInfo describes a bucket (or container)
+type BucketInfo struct {
+	Name         string
+	CreationDate time.Time
+}
+
+// PutOptions configures an upload
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+	// Progress, if set, is read from as bytes are uploaded (see progress.Tracker)
+	Progress io.Reader
+	// Tuning, if set, replaces the backend's upload tuning for this object
+	Tuning *PutTuning
+	// ContentMD5 is the hex MD5 of the content, if known. S3 backends send
+	// it as Content-MD5 of objects sent in a single request, so storage
+	// rejects bytes corrupted on the way with ErrBadDigest, and have each part
+	// of multipart uploads checked instead; others ignore it.
+	ContentMD5 string
+}
+
+// PutTuning controls how an object is sent to the provider. Zero values leave
+// the provider's defaults.
+type PutTuning struct {
+	// PartSize is the size of each part (or block) of a multipart upload
+	PartSize uint64
+	// Concurrency is how many parts are uploaded at once
+	Concurrency uint
+	// SendContentMD5 has the provider verify every part against its MD5
+	SendContentMD5 bool
+	// MultipartThreshold sends objects of known size below it in a single request
+	MultipartThreshold int64
+}
+
+// ListOptions selects one page of a listing
+type ListOptions struct {
+	Prefix            string
+	ContinuationToken string
+	Limit             int
+}
+
+// ListResult is one page of a listing
+type ListResult struct {
+	Objects []ObjectInfo
+	// NextContinuationToken is empty on the last page
+	NextContinuationToken string
+}
+
+// Backend is an object storage provider
+type Backend interface {
+	// Name identifies the provider, e.g. "minio" or "s3"
+	Name() string
+
+	Stat(ctx context.Context, bucket, key string) (ObjectInfo, error)
+	// Get opens an object for reading. The caller must close the reader.
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error)
+	Put(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error)
+	// Remove deletes an object; removing a missing object is not an error
+	Remove(ctx context.Context, bucket, key string) error
+	List(ctx context.Context, bucket string, opts ListOptions) (ListResult, error)
+
+	ListBuckets(ctx context.Context) ([]BucketInfo, error)
+	BucketExists(ctx context.Context, bucket string) (bool, error)
+	MakeBucket(ctx context.Context, bucket string) error
+	RemoveBucket(ctx context.Context, bucket string) error
+}
+
+// PresignOptions adjusts a presigned URL
+type PresignOptions struct {
+	// ResponseContentType and ResponseContentDisposition override the response headers of GET URLs
+	ResponseContentType        string
+	ResponseContentDisposition string
+	// ContentType, CacheControl, ContentDisposition and Metadata (user metadata
+	// without a prefix) are signed into PUT URLs and stored with the object
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
+}
+
+// Presigner is implemented by backends that can issue time-limited URLs
+type Presigner interface {
+	// Presign returns a URL for method and the headers the client must send with it
+	Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, opts PresignOptions) (*url.URL, http.Header, error)
+}
+
+// S3Native is implemented by S3-compatible backends, giving handlers access to
+// S3-only features (multipart presigning, POST policies, bucket CORS)
+type S3Native interface {
+	Client() *minio.Client
+}
+
+// S3Client returns the S3 client serving bucket, if its backend has one
+func S3Client(b Backend, bucket string) (*minio.Client, bool) {
+	if native, ok := Route(b, bucket).(S3Native); ok {
+		return native.Client(), true
+	}
+	return nil, false
+}
+
+// ObjectTags returns the tags of an object; backends without tagging have none
+func ObjectTags(ctx context.Context, b Backend, bucket, key string) (map[string]string, error) {
+	client, ok := S3Client(b, bucket)
+	if !ok {
+		return nil, nil
+	}
+	t, err := client.GetObjectTagging(ctx, bucket, key, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if m := t.ToMap(); len(m) > 0 {
+		return m, nil
+	}
+	return nil, nil
+}
+
+// PutObjectTags replaces the tags of an object, on backends with tagging
+func PutObjectTags(ctx context.Context, b Backend, bucket, key string, objectTags map[string]string) error {
+	client, ok := S3Client(b, bucket)
+	if !ok {
+		return fmt.Errorf("%w: object tags on %s", ErrNotSupported, Route(b, bucket).Name())
+	}
+	t, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return err
+	}
+	if err := client.PutObjectTagging(ctx, bucket, key, t, minio.PutObjectTaggingOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// EnsureBucket creates the bucket if it doesn't exist
+func EnsureBucket(ctx context.Context, b Backend, bucket string) error {
+	exists, err := b.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check if bucket %s exists: %w", bucket, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := b.MakeBucket(ctx, bucket); err != nil && !errors.Is(err, ErrBucketExists) {
+		return fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// progressReader reports bytes to a progress reader as they are consumed,
+// the way minio-go drives PutObjectOptions.Progress
+type progressReader struct {
+	r        io.Reader
+	progress io.Reader
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		_, _ = io.CopyN(io.Discard, p.progress, int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps records in process memory. Records are lost on restart, so it
+// is meant for development and tests.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string, v interface{}) error {
+	s.mu.RLock()
+	data, ok := s.records[key]
+	s.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return decode(data, v)
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, v interface{}) error {
+	data, err := encode(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.records[key] = data
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.records, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Lock holds the record within this process
+func (s *MemoryStore) Lock(ctx context.Context, key string) (func(), error) {
+	return lockKey(key), nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for key := range s.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
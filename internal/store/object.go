@@ -0,0 +1,87 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+const recordSuffix = ".json"
+
+// ObjectStore persists records as JSON objects in a dedicated bucket so state
+// survives restarts and is shared between replicas without extra infrastructure
+type ObjectStore struct {
+	backend storage.Backend
+	bucket  string
+}
+
+// NewObjectStore creates an ObjectStore, creating the bucket if it doesn't exist
+func NewObjectStore(ctx context.Context, backend storage.Backend, bucket string) (*ObjectStore, error) {
+	if err := storage.EnsureBucket(ctx, backend, bucket); err != nil {
+		return nil, fmt.Errorf("failed to initialize metadata bucket: %w", err)
+	}
+	return &ObjectStore{backend: backend, bucket: bucket}, nil
+}
+
+func (s *ObjectStore) Get(ctx context.Context, key string, v interface{}) error {
+	object, _, err := s.backend.Get(ctx, s.bucket, key+recordSuffix)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return err
+	}
+	return decode(data, v)
+}
+
+func (s *ObjectStore) Put(ctx context.Context, key string, v interface{}) error {
+	data, err := encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.backend.Put(ctx, s.bucket, key+recordSuffix, bytes.NewReader(data), int64(len(data)),
+		storage.PutOptions{ContentType: "application/json"})
+	return err
+}
+
+func (s *ObjectStore) Delete(ctx context.Context, key string) error {
+	err := s.backend.Remove(ctx, s.bucket, key+recordSuffix)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Lock holds the record within this process
+func (s *ObjectStore) Lock(ctx context.Context, key string) (func(), error) {
+	return lockKey(key), nil
+}
+
+func (s *ObjectStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	opts := storage.ListOptions{Prefix: prefix}
+	for {
+		page, err := s.backend.List(ctx, s.bucket, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Objects {
+			keys = append(keys, strings.TrimSuffix(object.Key, recordSuffix))
+		}
+		if page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		opts.ContinuationToken = page.NextContinuationToken
+	}
+}
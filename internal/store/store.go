@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/config"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/state"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// ErrNotFound is returned when a record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Store is a small JSON record store for API metadata (dedupe references, share
+// links, usage counters, ...) that must not live in the user-facing bucket listing
+type Store interface {
+	// Get decodes the record at key into v, returning ErrNotFound if it is missing
+	Get(ctx context.Context, key string, v interface{}) error
+	// Put encodes v as the record at key
+	Put(ctx context.Context, key string, v interface{}) error
+	// Delete removes the record at key; deleting a missing record is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the keys of all records under prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Lock holds the record at key for a read-modify-write until unlock is
+	// called. Update takes it, so wrappers must pass it through.
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// New creates the store selected by METADATA_STORE
+func New(cfg *config.Config, backend storage.Backend) (Store, error) {
+	switch cfg.MetadataStore {
+	case "", "minio", "object":
+		return NewObjectStore(context.Background(), backend, cfg.MetadataBucket())
+	case "memory":
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown metadata store %q", cfg.MetadataStore)
+}
+
+// updateLockTTL bounds how long a replica that dies mid-update keeps a record locked
+const updateLockTTL = 30 * time.Second
+
+var keyLocks sync.Map // map[string]*sync.Mutex
+
+func lockKey(key string) func() {
+	v, _ := keyLocks.LoadOrStore(key, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// lockedStore serializes updates across the replicas sharing its locker
+type lockedStore struct {
+	Store
+	locker state.Locker
+}
+
+// Locked wraps s so that Lock also holds locker's lock on the record, for
+// replicas sharing the records of s
+func Locked(s Store, locker state.Locker) Store {
+	return &lockedStore{Store: s, locker: locker}
+}
+
+// Lock holds the record in this process, then across the replicas
+func (s *lockedStore) Lock(ctx context.Context, key string) (func(), error) {
+	unlock, err := s.Store.Lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.locker.Lock(ctx, "store/"+key, updateLockTTL)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return func() {
+		release()
+		unlock()
+	}, nil
+}
+
+// Update performs a read-modify-write of the record at key. fn receives the current
+// record (zero value if missing) and reports whether the record should be kept;
+// returning false deletes it. The record is held with s.Lock throughout, so
+// updates are serialized per key within this process, and across processes
+// when s is Locked.
+func Update[T any](ctx context.Context, s Store, key string, fn func(rec *T, exists bool) (keep bool, err error)) (T, error) {
+	var rec T
+	unlock, err := s.Lock(ctx, key)
+	if err != nil {
+		return rec, fmt.Errorf("failed to lock record %s: %w", key, err)
+	}
+	defer unlock()
+
+	exists := true
+	if err := s.Get(ctx, key, &rec); err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return rec, err
+		}
+		exists = false
+	}
+
+	keep, err := fn(&rec, exists)
+	if err != nil {
+		return rec, err
+	}
+	if !keep {
+		return rec, s.Delete(ctx, key)
+	}
+	return rec, s.Put(ctx, key, &rec)
+}
+
+func encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,230 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/auth"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/storage"
+)
+
+// ScopeKey is the gin context key holding the request's Scope
+const ScopeKey = "TenantScope"
+
+// Tenancy modes
+const (
+	ModeOff    = ""
+	ModePrefix = "prefix"
+	ModeBucket = "bucket"
+)
+
+// ErrNoTenant is returned when tenancy is enabled but the caller carries no tenant claim
+var ErrNoTenant = errors.New("no tenant in credentials")
+
+// Errors resolving a home directory
+var (
+	ErrNoIdentity     = errors.New("no authenticated identity")
+	ErrInvalidSubject = errors.New("subject can't name a home directory")
+)
+
+// Home directory templates; SubjectPlaceholder is replaced by the caller's subject
+const (
+	SubjectPlaceholder  = "{subject}"
+	DefaultHomeTemplate = "users/" + SubjectPlaceholder + "/"
+)
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,40}$`)
+
+// tenantsPrefix holds the tenant directories in prefix mode
+const tenantsPrefix = "tenants/"
+
+// Scope is where a request's objects live: a bucket and a key prefix
+type Scope struct {
+	TenantID string
+	Bucket   string
+	Prefix   string
+}
+
+// Key maps a client-visible name to the stored object key
+func (s Scope) Key(name string) string {
+	return s.Prefix + name
+}
+
+// Name maps a stored object key back to the client-visible name
+func (s Scope) Name(key string) string {
+	return strings.TrimPrefix(key, s.Prefix)
+}
+
+// Resolver derives the Scope of a request from the caller's claims
+type Resolver struct {
+	mode          string
+	claim         string
+	defaultBucket string
+	bucketPrefix  string
+	homeTemplate  string
+	autoHome      bool
+	backend       storage.Backend
+
+	created sync.Map // buckets known to exist
+}
+
+// NewResolver creates a Resolver. In bucket mode tenant buckets are named
+// bucketPrefix+tenantID and created on first use. homeTemplate is the key
+// prefix of a caller's home directory, with {subject} standing for their
+// subject (DefaultHomeTemplate if empty); autoHome confines every
+// authenticated request to it.
+func NewResolver(mode, claim, defaultBucket, bucketPrefix, homeTemplate string, autoHome bool, backend storage.Backend) (*Resolver, error) {
+	switch mode {
+	case ModeOff, ModePrefix, ModeBucket:
+	default:
+		return nil, fmt.Errorf("unknown tenancy mode %q", mode)
+	}
+	if homeTemplate == "" {
+		homeTemplate = DefaultHomeTemplate
+	}
+	if !strings.Contains(homeTemplate, SubjectPlaceholder) || !strings.HasSuffix(homeTemplate, "/") || strings.HasPrefix(homeTemplate, "/") {
+		return nil, fmt.Errorf("home directory template %q must contain %s and end, but not start, with /", homeTemplate, SubjectPlaceholder)
+	}
+	return &Resolver{
+		mode:          mode,
+		claim:         claim,
+		defaultBucket: defaultBucket,
+		bucketPrefix:  bucketPrefix,
+		homeTemplate:  homeTemplate,
+		autoHome:      autoHome,
+		backend:       backend,
+	}, nil
+}
+
+// Enabled reports whether requests are scoped per tenant
+func (r *Resolver) Enabled() bool {
+	return r.mode != ModeOff
+}
+
+// Scoped reports whether requests are scoped per tenant or per user
+func (r *Resolver) Scoped() bool {
+	return r.Enabled() || r.autoHome
+}
+
+// Default returns the unscoped Scope used when tenancy is off
+func (r *Resolver) Default() Scope {
+	return Scope{Bucket: r.defaultBucket}
+}
+
+// Buckets returns the buckets holding tenant data: the default bucket and, in
+// bucket mode, the existing buckets named with the tenant bucket prefix
+func (r *Resolver) Buckets(ctx context.Context) ([]string, error) {
+	buckets := []string{r.defaultBucket}
+	if r.mode != ModeBucket {
+		return buckets, nil
+	}
+	all, err := r.backend.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range all {
+		tenantID, ok := strings.CutPrefix(bucket.Name, r.bucketPrefix)
+		if ok && bucket.Name != r.defaultBucket && tenantIDPattern.MatchString(tenantID) {
+			buckets = append(buckets, bucket.Name)
+		}
+	}
+	return buckets, nil
+}
+
+// TenantOf returns the tenant owning key in bucket, or "" for data outside
+// any tenant's scope. In prefix mode key must reach past the tenant directory.
+func (r *Resolver) TenantOf(bucket, key string) string {
+	switch r.mode {
+	case ModePrefix:
+		rest, ok := strings.CutPrefix(key, tenantsPrefix)
+		tenantID, _, found := strings.Cut(rest, "/")
+		if bucket == r.defaultBucket && ok && found && tenantIDPattern.MatchString(tenantID) {
+			return tenantID
+		}
+	case ModeBucket:
+		tenantID, ok := strings.CutPrefix(bucket, r.bucketPrefix)
+		if ok && bucket != r.defaultBucket && tenantIDPattern.MatchString(tenantID) {
+			return tenantID
+		}
+	}
+	return ""
+}
+
+// Resolve returns the Scope for an identity, within its home directory when
+// home directories are automatic
+func (r *Resolver) Resolve(ctx context.Context, identity *auth.Identity) (Scope, error) {
+	scope, err := r.resolveTenant(ctx, identity)
+	if err != nil || !r.autoHome || identity == nil {
+		return scope, err
+	}
+	return r.home(scope, identity)
+}
+
+// ResolveHome returns the Scope of the identity's home directory
+func (r *Resolver) ResolveHome(ctx context.Context, identity *auth.Identity) (Scope, error) {
+	if identity == nil {
+		return Scope{}, ErrNoIdentity
+	}
+	scope, err := r.resolveTenant(ctx, identity)
+	if err != nil {
+		return Scope{}, err
+	}
+	return r.home(scope, identity)
+}
+
+// home narrows scope to the identity's home directory. The subject is
+// escaped so it stays a single path segment.
+func (r *Resolver) home(scope Scope, identity *auth.Identity) (Scope, error) {
+	subject := strings.TrimSpace(identity.Subject)
+	if subject == "" || subject == "." || subject == ".." {
+		return Scope{}, fmt.Errorf("%w: %q", ErrInvalidSubject, identity.Subject)
+	}
+	scope.Prefix += strings.ReplaceAll(r.homeTemplate, SubjectPlaceholder, url.PathEscape(subject))
+	return scope, nil
+}
+
+func (r *Resolver) resolveTenant(ctx context.Context, identity *auth.Identity) (Scope, error) {
+	if !r.Enabled() {
+		return r.Default(), nil
+	}
+	if identity == nil {
+		return Scope{}, ErrNoTenant
+	}
+	tenantID, _ := identity.Claims[r.claim].(string)
+	tenantID = strings.ToLower(strings.TrimSpace(tenantID))
+	if tenantID == "" {
+		return Scope{}, ErrNoTenant
+	}
+	if !tenantIDPattern.MatchString(tenantID) {
+		return Scope{}, fmt.Errorf("invalid tenant id %q", tenantID)
+	}
+
+	if r.mode == ModePrefix {
+		return Scope{TenantID: tenantID, Bucket: r.defaultBucket, Prefix: tenantsPrefix + tenantID + "/"}, nil
+	}
+
+	bucket := r.bucketPrefix + tenantID
+	if _, ok := r.created.Load(bucket); !ok {
+		if err := storage.EnsureBucket(ctx, r.backend, bucket); err != nil {
+			return Scope{}, err
+		}
+		r.created.Store(bucket, struct{}{})
+	}
+	return Scope{TenantID: tenantID, Bucket: bucket}, nil
+}
+
+// ScopeFrom returns the request's Scope, or fallback if the tenancy middleware didn't run
+func ScopeFrom(c *gin.Context, fallback Scope) Scope {
+	if v, ok := c.Get(ScopeKey); ok {
+		if scope, ok := v.(Scope); ok {
+			return scope
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,222 @@
+// Package throttle limits the bandwidth and concurrency of proxied transfers
+package throttle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/metrics"
+)
+
+// ErrBusy is returned by Acquire when no transfer slot frees up in time
+var ErrBusy = errors.New("too many concurrent transfers")
+
+// chunkSize bounds how many bytes pass between limiter waits, keeping the
+// rate smooth instead of bursting a whole buffer at once
+const chunkSize = 32 << 10
+
+// Limiter is a token bucket metering bytes per second. Callers may take more
+// tokens than are available; they then wait until the debt is repaid, so a
+// transfer never stalls on a read larger than the bucket.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing bytesPerSecond, or nil for no limit
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	rate := float64(bytesPerSecond)
+	return &Limiter{rate: rate, burst: rate, tokens: rate, last: time.Now()}
+}
+
+// WaitN takes n tokens, sleeping while the bucket is in debt
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Options configures a Throttle; zero values disable each limit
+type Options struct {
+	// MaxConcurrent caps transfers in flight
+	MaxConcurrent int
+	// QueueTimeout is how long a transfer waits for a free slot
+	QueueTimeout time.Duration
+	// RateLimit is the bandwidth of each transfer, in bytes per second
+	RateLimit int64
+	// GlobalRateLimit is the bandwidth shared by all transfers, in bytes per second
+	GlobalRateLimit int64
+}
+
+// Throttle hands out transfer slots and meters the bytes of each transfer
+// against its own and the shared bandwidth limit
+type Throttle struct {
+	opts  Options
+	slots chan struct{}
+
+	// Bandwidth limits can change while transfers run; see SetRateLimits
+	rateLimit atomic.Int64
+	global    atomic.Pointer[Limiter]
+}
+
+// New creates a Throttle and publishes its limit to the transfer metrics
+func New(opts Options) *Throttle {
+	t := &Throttle{opts: opts}
+	t.SetRateLimits(opts.RateLimit, opts.GlobalRateLimit)
+	if opts.MaxConcurrent > 0 {
+		t.slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+	metrics.TransfersLimit.Set(int64(opts.MaxConcurrent))
+	return t
+}
+
+// SetRateLimits replaces the per-transfer and shared bandwidth limits. Transfers
+// in progress keep their own rate and move to the new shared limit.
+func (t *Throttle) SetRateLimits(perTransfer, global int64) {
+	t.rateLimit.Store(perTransfer)
+	t.global.Store(NewLimiter(global))
+}
+
+// Acquire waits up to QueueTimeout for a transfer slot. The returned release
+// must be called once the transfer ends.
+func (t *Throttle) Acquire(ctx context.Context) (release func(), err error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		default:
+			metrics.TransfersWaiting.Add(1)
+			err = t.wait(ctx)
+			metrics.TransfersWaiting.Add(-1)
+			if err != nil {
+				metrics.Transfers.Add("rejected", 1)
+				return nil, err
+			}
+		}
+	}
+
+	metrics.TransfersActive.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if t.slots != nil {
+				<-t.slots
+			}
+			metrics.TransfersActive.Add(-1)
+		})
+	}, nil
+}
+
+func (t *Throttle) wait(ctx context.Context) error {
+	var timeout <-chan time.Time
+	if t.opts.QueueTimeout > 0 {
+		timer := time.NewTimer(t.opts.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// meter returns a function charging n bytes of one transfer to both limits
+func (t *Throttle) meter(ctx context.Context, counter string) func(n int) error {
+	own := NewLimiter(t.rateLimit.Load())
+	return func(n int) error {
+		metrics.Transfers.Add(counter, int64(n))
+		if err := own.WaitN(ctx, n); err != nil {
+			return err
+		}
+		return t.global.Load().WaitN(ctx, n)
+	}
+}
+
+// Reader meters an incoming transfer
+func (t *Throttle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &reader{r: r, charge: t.meter(ctx, "bytes_in")}
+}
+
+// Writer meters an outgoing transfer
+func (t *Throttle) Writer(ctx context.Context, w io.Writer) io.Writer {
+	return &writer{w: w, charge: t.meter(ctx, "bytes_out")}
+}
+
+type reader struct {
+	r      io.Reader
+	charge func(n int) error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.charge(n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type writer struct {
+	w      io.Writer
+	charge func(n int) error
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		if err := w.charge(len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
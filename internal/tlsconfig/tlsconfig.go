@@ -0,0 +1,125 @@
+// Package tlsconfig terminates TLS in the server itself, from certificate
+// files or with certificates obtained automatically over ACME
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options selects where certificates come from; leave all empty to serve plain HTTP
+type Options struct {
+	// CertFile and KeyFile are a PEM certificate chain and private key
+	CertFile string
+	KeyFile  string
+
+	// ACMEDomains enables automatic certificates (Let's Encrypt by default)
+	// for these host names
+	ACMEDomains  []string
+	ACMEEmail    string
+	ACMECacheDir string
+	// ACMEDirectoryURL overrides the ACME directory, e.g. for staging
+	ACMEDirectoryURL string
+}
+
+// Server provides the TLS configuration of the HTTP server
+type Server struct {
+	config  *tls.Config
+	manager *autocert.Manager
+
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+// New returns the TLS setup for opts, or nil when TLS is not configured
+func New(opts Options) (*Server, error) {
+	files := opts.CertFile != "" || opts.KeyFile != ""
+	switch {
+	case files && len(opts.ACMEDomains) > 0:
+		return nil, errors.New("TLS certificate files and ACME domains are mutually exclusive")
+	case files:
+		s := &Server{certFile: opts.CertFile, keyFile: opts.KeyFile}
+		if err := s.Reload(); err != nil {
+			return nil, err
+		}
+		s.config = newConfig()
+		s.config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return s.cert.Load(), nil
+		}
+		return s, nil
+	case len(opts.ACMEDomains) > 0:
+		if opts.ACMECacheDir == "" {
+			return nil, errors.New("an ACME cache directory is required so certificates survive restarts")
+		}
+		s := &Server{manager: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.ACMEDomains...),
+			Cache:      autocert.DirCache(opts.ACMECacheDir),
+			Email:      opts.ACMEEmail,
+		}}
+		if opts.ACMEDirectoryURL != "" {
+			s.manager.Client = &acme.Client{DirectoryURL: opts.ACMEDirectoryURL}
+		}
+		s.config = newConfig()
+		s.config.GetCertificate = s.manager.GetCertificate
+		// Answers TLS-ALPN-01 challenges on the HTTPS port itself
+		s.config.NextProtos = append(s.config.NextProtos, acme.ALPNProto)
+		return s, nil
+	}
+	return nil, nil
+}
+
+// newConfig allows TLS 1.2 and later and offers HTTP/2
+func newConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+}
+
+// Config is the tls.Config for http.Server.TLSConfig
+func (s *Server) Config() *tls.Config {
+	return s.config
+}
+
+// Reload re-reads the certificate files, e.g. after they were renewed. The
+// current certificate is kept if the new files are invalid. ACME
+// certificates renew themselves, so Reload does nothing for them.
+func (s *Server) Reload() error {
+	if s.certFile == "" {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	s.cert.Store(&cert)
+	return nil
+}
+
+// HTTPHandler serves the plain HTTP port: it answers ACME HTTP-01 challenges
+// and redirects everything else to HTTPS on httpsPort
+func (s *Server) HTTPHandler(httpsPort string) http.Handler {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	if s.manager != nil {
+		return s.manager.HTTPHandler(redirect)
+	}
+	return redirect
+}
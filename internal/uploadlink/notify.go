@@ -0,0 +1,147 @@
+package uploadlink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/jobs"
+	"github.com/rs/zerolog"
+)
+
+// KindNotify is the job kind delivering webhooks
+const KindNotify = "uploadlink.notify"
+
+// EventFileReceived is sent when a file arrives through an upload link
+const EventFileReceived = "upload_link.file_received"
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+// keyed with the webhook secret
+const SignatureHeader = "X-Webhook-Signature"
+
+// Event is the body of a webhook
+type Event struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Link EventLink `json:"link"`
+	File EventFile `json:"file"`
+}
+
+// EventLink identifies the link an event happened on
+type EventLink struct {
+	Token     string `json:"token"`
+	Name      string `json:"name,omitempty"`
+	TenantID  string `json:"tenantId,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	Uploads   int    `json:"uploads"`
+	Remaining int    `json:"remaining"`
+}
+
+// EventFile describes a received file. Key is relative to the link
+// creator's files, Bucket and ObjectKey locate the object in storage.
+type EventFile struct {
+	Key         string `json:"key"`
+	Bucket      string `json:"bucket"`
+	ObjectKey   string `json:"objectKey"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+}
+
+type delivery struct {
+	URL   string `json:"url"`
+	Event Event  `json:"event"`
+}
+
+// Notifier delivers upload link events to webhooks through the jobs queue,
+// retrying failed deliveries
+type Notifier struct {
+	jobs   *jobs.Queue
+	url    string
+	secret string
+	client *http.Client
+	logger *zerolog.Logger
+}
+
+// NewNotifier registers webhook delivery on queue. Every event goes to the
+// link's notify URL, if it has one, and to url when set. Bodies are signed
+// with secret when set.
+func NewNotifier(queue *jobs.Queue, url, secret string, logger *zerolog.Logger) *Notifier {
+	n := &Notifier{
+		jobs:   queue,
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+	queue.Register(KindNotify, n.run)
+	return n
+}
+
+// FileReceived queues webhooks announcing a file uploaded through link.
+// The upload already succeeded, so failures are logged rather than returned.
+func (n *Notifier) FileReceived(ctx context.Context, link *Link, file EventFile) {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	event := Event{
+		ID:   hex.EncodeToString(id),
+		Type: EventFileReceived,
+		Time: time.Now().UTC(),
+		Link: EventLink{
+			Token:     link.Token,
+			Name:      link.Name,
+			TenantID:  link.TenantID,
+			CreatedBy: link.CreatedBy,
+			Uploads:   link.Uploads,
+			Remaining: link.Remaining(),
+		},
+		File: file,
+	}
+	for _, url := range []string{link.NotifyURL, n.url} {
+		if url == "" {
+			continue
+		}
+		if _, err := n.jobs.Enqueue(ctx, KindNotify, delivery{URL: url, Event: event}); err != nil {
+			n.logger.Error().Err(err).Str("event_id", event.ID).Msg("Failed to enqueue upload link webhook")
+		}
+	}
+}
+
+func (n *Notifier) run(ctx context.Context, job *jobs.Job) error {
+	var d delivery
+	if err := job.Decode(&d); err != nil {
+		return err
+	}
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s returned %s", d.URL, resp.Status)
+	}
+	return nil
+}
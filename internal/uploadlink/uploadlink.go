@@ -0,0 +1,254 @@
+package uploadlink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/store"
+)
+
+// Errors returned when an upload link can't be used
+var (
+	ErrNotFound  = errors.New("upload link not found")
+	ErrExpired   = errors.New("upload link has expired")
+	ErrRevoked   = errors.New("upload link has been revoked")
+	ErrExhausted = errors.New("upload link file limit reached")
+)
+
+const (
+	recordPrefix = "upload-links/"
+	// tokenBytes of randomness give 128-bit, unguessable tokens
+	tokenBytes = 16
+)
+
+// Link lets anyone holding its token upload files into a folder of its
+// creator's scope, within the limits set when it was created
+type Link struct {
+	Token    string `json:"token"`
+	TenantID string `json:"tenantId,omitempty"`
+	Bucket   string `json:"bucket,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	// Name and Message are shown to uploaders
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message,omitempty"`
+	// NotifyURL receives a webhook for every file that arrives
+	NotifyURL string `json:"notifyUrl,omitempty"`
+	// Folder is where uploads land, relative to the scope and ending in "/"
+	Folder string `json:"folder,omitempty"`
+	// MaxFiles is how many files the link accepts in total
+	MaxFiles int `json:"maxFiles"`
+	// MaxFileSize limits each file in bytes; 0 leaves only MAX_FILE_SIZE
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
+	// ContentTypes are the allowed content types, exact or such as image/*;
+	// empty allows whatever the upload policy allows
+	ContentTypes []string   `json:"contentTypes,omitempty"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	Uploads      int        `json:"uploads"`
+	CreatedBy    string     `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Remaining returns how many more files the link accepts
+func (l *Link) Remaining() int {
+	return max(0, l.MaxFiles-l.Uploads)
+}
+
+// Usable returns why the link can't take an upload at the given time, or nil
+func (l *Link) Usable(now time.Time) error {
+	switch {
+	case l.RevokedAt != nil:
+		return ErrRevoked
+	case now.After(l.ExpiresAt):
+		return ErrExpired
+	case l.Remaining() == 0:
+		return ErrExhausted
+	}
+	return nil
+}
+
+// CreateOptions configures a new upload link
+type CreateOptions struct {
+	TenantID     string
+	Bucket       string
+	Prefix       string
+	Name         string
+	Message      string
+	NotifyURL    string
+	Folder       string
+	MaxFiles     int
+	MaxFileSize  int64
+	ContentTypes []string
+	ExpiresAt    time.Time
+	CreatedBy    string
+}
+
+// Service manages upload links in the metadata store
+type Service struct {
+	store store.Store
+}
+
+// NewService creates a new upload link Service
+func NewService(s store.Store) *Service {
+	return &Service{store: s}
+}
+
+func recordKey(token string) string {
+	return recordPrefix + token
+}
+
+// Create stores a new upload link
+func (s *Service) Create(ctx context.Context, opts CreateOptions) (*Link, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &Link{
+		Token:        token,
+		TenantID:     opts.TenantID,
+		Bucket:       opts.Bucket,
+		Prefix:       opts.Prefix,
+		Name:         opts.Name,
+		Message:      opts.Message,
+		NotifyURL:    opts.NotifyURL,
+		Folder:       opts.Folder,
+		MaxFiles:     opts.MaxFiles,
+		MaxFileSize:  opts.MaxFileSize,
+		ContentTypes: opts.ContentTypes,
+		ExpiresAt:    opts.ExpiresAt,
+		CreatedBy:    opts.CreatedBy,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := s.store.Put(ctx, recordKey(token), link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Get returns an upload link by token
+func (s *Service) Get(ctx context.Context, token string) (*Link, error) {
+	var link Link
+	if err := s.store.Get(ctx, recordKey(token), &link); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// List returns the upload links of a tenant, newest first
+func (s *Service) List(ctx context.Context, tenantID string) ([]*Link, error) {
+	keys, err := s.store.List(ctx, recordPrefix)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*Link, 0, len(keys))
+	for _, key := range keys {
+		link, err := s.Get(ctx, strings.TrimPrefix(key, recordPrefix))
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if link.TenantID != tenantID {
+			continue
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.After(links[j].CreatedAt) })
+	return links, nil
+}
+
+// Revoke disables an upload link. The record is kept so it shows up as revoked.
+func (s *Service) Revoke(ctx context.Context, token string) (*Link, error) {
+	link, err := store.Update(ctx, s.store, recordKey(token), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		if link.RevokedAt == nil {
+			now := time.Now().UTC()
+			link.RevokedAt = &now
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Reserve validates a link for one upload and counts it, so concurrent
+// uploads can't exceed MaxFiles. Release gives the slot back if the upload fails.
+func (s *Service) Reserve(ctx context.Context, token string) (*Link, error) {
+	link, err := store.Update(ctx, s.store, recordKey(token), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			return false, ErrNotFound
+		}
+		if err := link.Usable(time.Now()); err != nil {
+			return false, err
+		}
+		link.Uploads++
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Release returns a slot taken by Reserve for an upload that wasn't stored
+func (s *Service) Release(ctx context.Context, token string) error {
+	_, err := store.Update(ctx, s.store, recordKey(token), func(link *Link, exists bool) (bool, error) {
+		if !exists {
+			// Deleting a missing record is a no-op
+			return false, nil
+		}
+		link.Uploads = max(0, link.Uploads-1)
+		return true, nil
+	})
+	return err
+}
+
+// Purge deletes links that expired or were revoked before the given time and
+// returns how many were deleted
+func (s *Service) Purge(ctx context.Context, before time.Time) (int, error) {
+	keys, err := s.store.List(ctx, recordPrefix)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, key := range keys {
+		var link Link
+		if err := s.store.Get(ctx, key, &link); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return purged, err
+		}
+		expired := link.ExpiresAt.Before(before)
+		revoked := link.RevokedAt != nil && link.RevokedAt.Before(before)
+		if !expired && !revoked {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -3,20 +3,45 @@ package utils
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/requestid"
 )
 
-const CorrelationIDHeader = "X-Correlation-ID"
+const CorrelationIDHeader = requestid.CorrelationHeader
 const CorrelationIDKey = "CorrelationID"
 
-// CorrelationIDMiddleware ensures every request has a correlation ID, sets it in context and response header.
+// RequestIDHeader carries the ID the server generates for every request
+const RequestIDHeader = requestid.Header
+
+// RequestIDKey holds the request ID in the Gin context
+const RequestIDKey = "RequestID"
+
+// CorrelationIDFrom returns the correlation ID of the request. Outside
+// CorrelationIDMiddleware it falls back to the header the client sent, or "".
+func CorrelationIDFrom(c *gin.Context) string {
+	if correlationID, ok := c.Get(CorrelationIDKey); ok {
+		if s, ok := correlationID.(string); ok {
+			return s
+		}
+	}
+	return c.GetHeader(CorrelationIDHeader)
+}
+
+// CorrelationIDMiddleware ensures every request has a correlation ID, taken from
+// the client when it sends one, and generates a request ID unique to this
+// request. Both are set in the context and response headers, and carried by
+// the request context to the auth service and storage.
 func CorrelationIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		correlationID := c.GetHeader(CorrelationIDHeader)
 		if correlationID == "" {
 			correlationID = uuid.New().String()
 		}
+		requestID := uuid.New().String()
 		c.Set(CorrelationIDKey, correlationID)
+		c.Set(RequestIDKey, requestID)
 		c.Writer.Header().Set(CorrelationIDHeader, correlationID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), requestID, correlationID))
 		c.Next()
 	}
 }
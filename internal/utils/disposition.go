@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ContentDisposition builds a Content-Disposition header value with an ASCII
+// fallback filename and an RFC 5987 encoded filename* for non-ASCII names
+func ContentDisposition(dispositionType, filename string) string {
+	var fallback strings.Builder
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteRune('_')
+		case r < 0x20 || r == 0x7f:
+			// drop control characters
+		case r > 0x7e:
+			fallback.WriteRune('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	value := fmt.Sprintf("%s; filename=\"%s\"", dispositionType, fallback.String())
+	if fallback.String() != filename {
+		// url.PathEscape leaves a few characters RFC 5987 doesn't allow in attr-char
+		encoded := strings.NewReplacer("'", "%27", "(", "%28", ")", "%29", "*", "%2A", ",", "%2C", ";", "%3B", "=", "%3D", "@", "%40", ":", "%3A", "/", "%2F").
+			Replace(url.PathEscape(filename))
+		value += "; filename*=UTF-8''" + encoded
+	}
+	return value
+}
@@ -2,13 +2,11 @@ package utils
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
 )
 
-// ErrorResponse represents an error response for Swagger documentation
-type ErrorResponse struct {
-	Error   bool   `json:"error" example:"true"`
-	Message string `json:"message" example:"Error message details"`
-}
+// ErrorResponse documents the RFC 7807 problem body of error responses for Swagger
+type ErrorResponse = apierror.Problem
 
 func SendError(c *gin.Context, status int, message string) {
 	SendErrorWithCorrelationID(c, status, message)
@@ -2,26 +2,82 @@ package utils
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/muhammad-junaid-iftikhar/app-minio-api/internal/apierror"
 )
 
+// APIVersionKey holds the API version a request was routed to ("v1", "v2")
+const APIVersionKey = "APIVersion"
+
+// ContinuationTokenHeader carries the token for the next page of a v1 listing
+const ContinuationTokenHeader = "X-Continuation-Token"
+
 type StandardResponse struct {
 	CorrelationID string      `json:"correlation_id"`
 	Data          interface{} `json:"data,omitempty"`
-	Error         interface{} `json:"error,omitempty"`
+}
+
+// ResponseV2 is the /api/v2 envelope: the payload and what describes it
+type ResponseV2 struct {
+	Data interface{}    `json:"data"`
+	Meta ResponseMetaV2 `json:"meta"`
+}
+
+// ResponseMetaV2 describes a /api/v2 response
+type ResponseMetaV2 struct {
+	CorrelationID string `json:"correlation_id"`
+	Page          *Page  `json:"page,omitempty"`
+}
+
+// Page describes one page of a paginated listing
+type Page struct {
+	Limit int `json:"limit"`
+	// Pass as cursor to fetch the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
 }
 
 func SendJSONWithCorrelationID(c *gin.Context, status int, data interface{}) {
-	correlationID, _ := c.Get(CorrelationIDKey)
+	correlationID := CorrelationIDFrom(c)
+	if c.GetString(APIVersionKey) == "v2" {
+		c.JSON(status, ResponseV2{
+			Data: data,
+			Meta: ResponseMetaV2{CorrelationID: correlationID},
+		})
+		return
+	}
 	c.JSON(status, StandardResponse{
-		CorrelationID: correlationID.(string),
+		CorrelationID: correlationID,
 		Data:          data,
 	})
 }
 
-func SendErrorWithCorrelationID(c *gin.Context, status int, errMsg string) {
-	correlationID, _ := c.Get(CorrelationIDKey)
-	c.JSON(status, StandardResponse{
-		CorrelationID: correlationID.(string),
-		Error:         errMsg,
+// SendPage sends one page of a listing. v1 carries the next page token in the
+// X-Continuation-Token header, v2 in the envelope.
+func SendPage(c *gin.Context, status int, data interface{}, page Page) {
+	if c.GetString(APIVersionKey) != "v2" {
+		if page.NextCursor != "" {
+			c.Header(ContinuationTokenHeader, page.NextCursor)
+		}
+		SendJSONWithCorrelationID(c, status, data)
+		return
+	}
+	page.HasMore = page.NextCursor != ""
+	c.JSON(status, ResponseV2{
+		Data: data,
+		Meta: ResponseMetaV2{CorrelationID: CorrelationIDFrom(c), Page: &page},
 	})
 }
+
+// PageCursor returns the token of the page requested: cursor on v2,
+// continuation_token on v1
+func PageCursor(c *gin.Context) string {
+	if c.GetString(APIVersionKey) == "v2" {
+		return c.Query("cursor")
+	}
+	return c.Query("continuation_token")
+}
+
+// SendErrorWithCorrelationID sends an RFC 7807 problem with the generic code for status
+func SendErrorWithCorrelationID(c *gin.Context, status int, errMsg string) {
+	apierror.Write(c, status, apierror.CodeForStatus(status), errMsg)
+}